@@ -0,0 +1,473 @@
+package cartridge
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// apiTokenLocalsKey stores the request's authenticated *APIToken in fiber locals.
+const apiTokenLocalsKey = "cartridge_api_token"
+
+// APIToken is a personal access token for the public API. Only a hash of
+// the secret is stored; the token itself is shown once, when it is issued.
+type APIToken struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     string     `gorm:"size:255;index" json:"-"`
+	Name       string     `gorm:"size:255" json:"name"`
+	Prefix     string     `gorm:"size:64" json:"prefix"` // first characters of the token, to tell tokens apart
+	Hash       string     `gorm:"size:64;uniqueIndex" json:"-"`
+	Scopes     string     `gorm:"type:text" json:"-"` // space-separated
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// TableName keeps API tokens out of the application's own tables.
+func (APIToken) TableName() string {
+	return "cartridge_api_tokens"
+}
+
+// ScopeList returns the scopes granted to the token.
+func (t *APIToken) ScopeList() []string {
+	return strings.Fields(t.Scopes)
+}
+
+// Expired reports whether the token can no longer be used.
+func (t *APIToken) Expired() bool {
+	return t.ExpiresAt != nil && !t.ExpiresAt.After(time.Now())
+}
+
+// APITokenRequest describes a token to issue. It is also the body of the
+// create endpoint mounted by Server.APITokenRoutes.
+type APITokenRequest struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"` // nil for a token that doesn't expire
+}
+
+// APITokensConfig configures API token issuance and authentication.
+type APITokensConfig struct {
+	// Prefix starts every token, so leaked tokens are easy to recognize,
+	// e.g. by secret scanners. Default: "ctk_"
+	Prefix string
+
+	// Scopes lists the scopes users may grant their tokens. Entries can't be
+	// wildcards, and requests for other scopes are rejected. Default: nil
+	// (tokens carry no scopes)
+	Scopes []string
+
+	// MaxPerUser caps the active tokens of a user. Default: 25
+	MaxPerUser int
+
+	// MaxTTL caps how long tokens live. Tokens requested without an expiry,
+	// or a later one, expire after MaxTTL. Default: 0 (no cap)
+	MaxTTL time.Duration
+
+	// Header is checked for the token when the request has no
+	// "Authorization: Bearer" header. Default: "X-API-Key"
+	Header string
+
+	// TouchInterval limits how often LastUsedAt is written for a busy token.
+	// Default: 1 minute
+	TouchInterval time.Duration
+}
+
+// APITokens issues, lists, revokes and authenticates API tokens stored in
+// the cartridge_api_tokens table.
+type APITokens struct {
+	dbManager DBManager
+	cfg       APITokensConfig
+}
+
+// NewAPITokens creates the API token store. The cartridge_api_tokens table
+// is auto-migrated if it doesn't exist.
+func NewAPITokens(dbManager DBManager, config ...APITokensConfig) (*APITokens, error) {
+	var cfg APITokensConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.Prefix == "" {
+		cfg.Prefix = "ctk_"
+	}
+	if cfg.MaxPerUser <= 0 {
+		cfg.MaxPerUser = 25
+	}
+	if cfg.Header == "" {
+		cfg.Header = "X-API-Key"
+	}
+	if cfg.TouchInterval <= 0 {
+		cfg.TouchInterval = time.Minute
+	}
+	for _, scope := range cfg.Scopes {
+		if strings.Contains(scope, "*") {
+			return nil, fmt.Errorf("cartridge: API token scope %q is a wildcard; list scopes individually", scope)
+		}
+	}
+
+	db, err := dbManager.Connect()
+	if err != nil {
+		return nil, fmt.Errorf("cartridge: connect database: %w", err)
+	}
+	if err := db.AutoMigrate(&APIToken{}); err != nil {
+		return nil, fmt.Errorf("cartridge: migrate API tokens: %w", err)
+	}
+	return &APITokens{dbManager: dbManager, cfg: cfg}, nil
+}
+
+// Issue creates a token for owner and returns its secret, which can't be
+// recovered later. A token may only carry scopes from
+// APITokensConfig.Scopes that owner's permissions cover, so it never grants
+// more than its user holds. Invalid requests return ValidationErrors, and
+// users at MaxPerUser get a 409 Error.
+func (t *APITokens) Issue(ctx context.Context, owner *Principal, req APITokenRequest) (string, *APIToken, error) {
+	if owner == nil || owner.ID == "" {
+		return "", nil, ErrUnauthorized("authentication required")
+	}
+	userID := owner.ID
+	if err := Validate(req); err != nil {
+		return "", nil, err
+	}
+	if err := t.checkScopes(owner, req.Scopes); err != nil {
+		return "", nil, err
+	}
+	now := time.Now().UTC()
+	expiresAt := req.ExpiresAt
+	if expiresAt != nil && !expiresAt.After(now) {
+		return "", nil, ValidationErrors{{Field: "expires_at", Tag: "future", Message: "expires_at must be in the future"}}
+	}
+	if t.cfg.MaxTTL > 0 && (expiresAt == nil || expiresAt.Sub(now) > t.cfg.MaxTTL) {
+		limit := now.Add(t.cfg.MaxTTL)
+		expiresAt = &limit
+	}
+
+	db, err := t.dbManager.Connect()
+	if err != nil {
+		return "", nil, err
+	}
+	db = db.WithContext(ctx)
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, err
+	}
+	plain := t.cfg.Prefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	var scopes []string
+	for _, scope := range req.Scopes {
+		if scope = strings.TrimSpace(scope); scope != "" && !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	if expiresAt != nil {
+		utc := expiresAt.UTC()
+		expiresAt = &utc
+	}
+	token := &APIToken{
+		UserID:    userID,
+		Name:      req.Name,
+		Prefix:    plain[:len(t.cfg.Prefix)+6],
+		Hash:      hashAPIToken(plain),
+		Scopes:    strings.Join(scopes, " "),
+		ExpiresAt: expiresAt,
+		CreatedAt: now,
+	}
+	// Count and insert in one transaction, locking the user's tokens on
+	// Postgres and MySQL, so concurrent issues can't exceed MaxPerUser
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var active []uint
+		err := tx.Model(&APIToken{}).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND (expires_at IS NULL OR expires_at > ?)", userID, now).
+			Pluck("id", &active).Error
+		if err != nil {
+			return err
+		}
+		if len(active) >= t.cfg.MaxPerUser {
+			return ErrConflict("too many API tokens; revoke one first")
+		}
+		return tx.Create(token).Error
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return plain, token, nil
+}
+
+// checkScopes rejects wildcards, scopes that aren't available and scopes
+// owner doesn't hold.
+func (t *APITokens) checkScopes(owner *Principal, scopes []string) error {
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if scope == "" {
+			continue
+		}
+		verr := FieldError{Field: "scopes", Tag: "oneof", Param: strings.Join(t.cfg.Scopes, " ")}
+		switch {
+		case strings.Contains(scope, "*"):
+			verr.Message = fmt.Sprintf("scopes: %q is a wildcard; list scopes individually", scope)
+		case !slices.Contains(t.cfg.Scopes, scope):
+			verr.Message = fmt.Sprintf("scopes: %q is not an available scope", scope)
+		case !owner.Can(scope):
+			verr.Tag, verr.Param = "permission", ""
+			verr.Message = fmt.Sprintf("scopes: %q is not one of your permissions", scope)
+		default:
+			continue
+		}
+		return ValidationErrors{verr}
+	}
+	return nil
+}
+
+// List returns the tokens of userID, newest first, including expired ones.
+func (t *APITokens) List(ctx context.Context, userID string) ([]APIToken, error) {
+	db, err := t.dbManager.Connect()
+	if err != nil {
+		return nil, err
+	}
+	tokens := []APIToken{}
+	err = db.WithContext(ctx).Where("user_id = ?", userID).Order("id DESC").Find(&tokens).Error
+	return tokens, err
+}
+
+// Revoke deletes the token with id owned by userID. It returns a 404 Error
+// when the user has no such token.
+func (t *APITokens) Revoke(ctx context.Context, userID string, id uint) error {
+	db, err := t.dbManager.Connect()
+	if err != nil {
+		return err
+	}
+	result := db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).Delete(&APIToken{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound("API token")
+	}
+	return nil
+}
+
+// Authenticate returns the token matching plain, or nil when it is unknown,
+// revoked or expired. LastUsedAt is updated at most once per TouchInterval.
+func (t *APITokens) Authenticate(ctx context.Context, plain string) (*APIToken, error) {
+	if !strings.HasPrefix(plain, t.cfg.Prefix) {
+		return nil, nil
+	}
+	db, err := t.dbManager.Connect()
+	if err != nil {
+		return nil, err
+	}
+	db = db.WithContext(ctx)
+
+	var token APIToken
+	err = db.Where("hash = ?", hashAPIToken(plain)).First(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if token.Expired() {
+		return nil, nil
+	}
+
+	now := time.Now().UTC()
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= t.cfg.TouchInterval {
+		// Best effort: a failed write must not fail the request
+		if db.Model(&token).UpdateColumn("last_used_at", now).Error == nil {
+			token.LastUsedAt = &now
+		}
+	}
+	return &token, nil
+}
+
+// Middleware rejects requests without a valid API token with 401
+// Unauthorized. The token is read from "Authorization: Bearer <token>" or
+// the configured Header, and is available through ctx.APIToken().
+// DefaultPrincipalResolver turns it into a Principal whose permissions are
+// the token's scopes, so RouteConfig.Authorize checks them:
+//
+//	s.Get("/api/orders", listOrders, &cartridge.RouteConfig{
+//	    CustomMiddleware: []fiber.Handler{s.APITokens().Middleware()},
+//	    Authorize:        cartridge.Policy("orders:read"),
+//	    EnableCSRF:       cartridge.Bool(false),
+//	})
+func (t *APITokens) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		plain := strings.TrimSpace(c.Get(fiber.HeaderAuthorization))
+		if len(plain) > 7 && strings.EqualFold(plain[:7], "bearer ") {
+			plain = strings.TrimSpace(plain[7:])
+		} else {
+			plain = strings.TrimSpace(c.Get(t.cfg.Header))
+		}
+		if plain == "" {
+			c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="api"`)
+			return ErrUnauthorized("missing API token")
+		}
+
+		token, err := t.Authenticate(c.UserContext(), plain)
+		if err != nil {
+			return err
+		}
+		if token == nil {
+			c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="api", error="invalid_token"`)
+			return ErrUnauthorized("invalid API token")
+		}
+		c.Locals(apiTokenLocalsKey, token)
+		return c.Next()
+	}
+}
+
+// APIToken returns the token authenticated by APITokens.Middleware, or nil.
+func (ctx *Context) APIToken() *APIToken {
+	token, _ := ctx.Locals(apiTokenLocalsKey).(*APIToken)
+	return token
+}
+
+// hashAPIToken returns the stored form of a token. Tokens carry 256 bits of
+// randomness, so a fast hash is enough.
+func hashAPIToken(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
+
+// APITokenRoutesConfig configures the routes of Server.APITokenRoutes.
+type APITokenRoutesConfig struct {
+	// Route configures every route, e.g. Roles or CustomMiddleware.
+	Route *RouteConfig
+
+	// CreateRateLimit limits how often a user may issue tokens.
+	// Default: 10 per hour per user
+	CreateRateLimit *RateLimit
+}
+
+// apiTokenResponse is a token as served by the token routes.
+type apiTokenResponse struct {
+	*APIToken
+	Scopes []string `json:"scopes"`
+	Token  string   `json:"token,omitempty"` // only when issued
+}
+
+// APITokenRoutes mounts endpoints where signed-in users manage their own
+// API tokens:
+//
+//	GET    /settings/tokens      list the caller's tokens
+//	POST   /settings/tokens      issue a token: {"name", "scopes", "expires_at"}
+//	DELETE /settings/tokens/:id  revoke a token
+//
+// The secret is only in the POST response. The caller is the route's
+// Principal, or ServerConfig.PrincipalResolver's, and can only grant scopes
+// its permissions cover; requests authenticated with an API token are
+// refused, so a leaked token can't mint others.
+// Routes are named "api_tokens.index", "api_tokens.create" and
+// "api_tokens.delete". Panics if API tokens are not enabled (see
+// WithAPITokens).
+func (s *Server) APITokenRoutes(path string, config ...APITokenRoutesConfig) {
+	tokens := s.tokens
+	if tokens == nil {
+		panic("cartridge: API tokens are not enabled (use WithAPITokens)")
+	}
+	var cfg APITokenRoutesConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.CreateRateLimit == nil {
+		cfg.CreateRateLimit = &RateLimit{Max: 10, Window: time.Hour, Key: RateLimitByUser}
+	}
+	route := func(name string) *RouteConfig {
+		var routeCfg RouteConfig
+		if cfg.Route != nil {
+			routeCfg = *cfg.Route
+		}
+		routeCfg.Name = "api_tokens." + name
+		return &routeCfg
+	}
+	path = "/" + strings.Trim(path, "/")
+
+	s.Get(path, func(ctx *Context) error {
+		owner, err := apiTokenOwner(ctx)
+		if err != nil {
+			return err
+		}
+		list, err := tokens.List(ctx.UserContext(), owner.ID)
+		if err != nil {
+			return err
+		}
+		out := make([]apiTokenResponse, len(list))
+		for i := range list {
+			out[i] = apiTokenResponse{APIToken: &list[i], Scopes: list[i].ScopeList()}
+		}
+		return ctx.JSON(fiber.Map{"tokens": out})
+	}, route("index"))
+
+	create := route("create")
+	create.RateLimit = cfg.CreateRateLimit
+	s.Post(path, func(ctx *Context) error {
+		owner, err := apiTokenOwner(ctx)
+		if err != nil {
+			return err
+		}
+		req, err := BindJSON[APITokenRequest](ctx)
+		if err != nil {
+			return err
+		}
+		plain, token, err := tokens.Issue(ctx.UserContext(), owner, req)
+		if err != nil {
+			return err
+		}
+		return ctx.Status(fiber.StatusCreated).JSON(apiTokenResponse{
+			APIToken: token,
+			Scopes:   token.ScopeList(),
+			Token:    plain,
+		})
+	}, create)
+
+	s.Delete(path+"/:id", func(ctx *Context) error {
+		owner, err := apiTokenOwner(ctx)
+		if err != nil {
+			return err
+		}
+		id, err := strconv.ParseUint(ctx.Params("id"), 10, 64)
+		if err != nil {
+			return ErrNotFound("API token")
+		}
+		if err := tokens.Revoke(ctx.UserContext(), owner.ID, uint(id)); err != nil {
+			return err
+		}
+		return ctx.SendStatus(fiber.StatusNoContent)
+	}, route("delete"))
+}
+
+// apiTokenOwner returns the user managing their tokens.
+func apiTokenOwner(ctx *Context) (*Principal, error) {
+	if ctx.APIToken() != nil {
+		return nil, ErrForbidden("API tokens can't manage API tokens")
+	}
+	p := ctx.Principal()
+	if p == nil {
+		resolve := ctx.principals
+		if resolve == nil {
+			resolve = DefaultPrincipalResolver
+		}
+		var err error
+		if p, err = resolve(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if p == nil || p.ID == "" {
+		return nil, ErrUnauthorized("authentication required")
+	}
+	return p, nil
+}
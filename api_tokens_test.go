@@ -0,0 +1,199 @@
+package cartridge
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestAPITokens_Store(t *testing.T) {
+	tokens, err := NewAPITokens(&mockDBManager{db: openAsyncTestDB(t)}, APITokensConfig{
+		Scopes:     []string{"orders:read", "orders:write"},
+		MaxPerUser: 2,
+	})
+	if err != nil {
+		t.Fatalf("NewAPITokens failed: %v", err)
+	}
+	ctx := context.Background()
+	alice := &Principal{ID: "alice", Permissions: []string{"orders:read", "products:*"}}
+
+	plain, token, err := tokens.Issue(ctx, alice, APITokenRequest{Name: "CI", Scopes: []string{"orders:read"}})
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+	if !strings.HasPrefix(plain, "ctk_") || !strings.HasPrefix(plain, token.Prefix) || strings.Contains(token.Hash, plain) {
+		t.Errorf("unexpected token %q with prefix %q", plain, token.Prefix)
+	}
+
+	for _, scope := range []string{"admin", "*", "orders:*", "orders:write"} {
+		if _, _, err := tokens.Issue(ctx, alice, APITokenRequest{Name: "x", Scopes: []string{scope}}); err == nil {
+			t.Errorf("expected scope %q to be rejected", scope)
+		}
+	}
+	past := time.Now().Add(-time.Hour)
+	if _, _, err := tokens.Issue(ctx, alice, APITokenRequest{Name: "x", ExpiresAt: &past}); err == nil {
+		t.Error("expected an expiry in the past to be rejected")
+	}
+
+	got, err := tokens.Authenticate(ctx, plain)
+	if err != nil || got == nil || got.UserID != "alice" || got.LastUsedAt == nil {
+		t.Fatalf("expected alice's token with a last use, got %+v (%v)", got, err)
+	}
+	if got, _ := tokens.Authenticate(ctx, plain+"x"); got != nil {
+		t.Error("expected an unknown token to be rejected")
+	}
+
+	if _, _, err := tokens.Issue(ctx, alice, APITokenRequest{Name: "second"}); err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+	if _, _, err := tokens.Issue(ctx, alice, APITokenRequest{Name: "third"}); err == nil {
+		t.Error("expected MaxPerUser to be enforced")
+	}
+
+	if err := tokens.Revoke(ctx, "bob", token.ID); err == nil {
+		t.Error("expected bob not to revoke alice's token")
+	}
+	if err := tokens.Revoke(ctx, "alice", token.ID); err != nil {
+		t.Fatalf("Revoke failed: %v", err)
+	}
+	if got, _ := tokens.Authenticate(ctx, plain); got != nil {
+		t.Error("expected a revoked token to be rejected")
+	}
+	if list, _ := tokens.List(ctx, "alice"); len(list) != 1 || list[0].Name != "second" {
+		t.Errorf("expected the remaining token, got %+v", list)
+	}
+
+	if _, err := NewAPITokens(&mockDBManager{db: openAsyncTestDB(t)}, APITokensConfig{Scopes: []string{"*"}}); err == nil {
+		t.Error("expected a wildcard in Scopes to be rejected")
+	}
+	unscoped, err := NewAPITokens(&mockDBManager{db: openAsyncTestDB(t)})
+	if err != nil {
+		t.Fatalf("NewAPITokens failed: %v", err)
+	}
+	admin := &Principal{ID: "root", Permissions: []string{"*"}}
+	if _, _, err := unscoped.Issue(ctx, admin, APITokenRequest{Name: "x", Scopes: []string{"orders:read"}}); err == nil {
+		t.Error("expected scopes to be rejected when none are configured")
+	}
+}
+
+func TestAPITokens_Routes(t *testing.T) {
+	db := openAsyncTestDB(t)
+	tokens, err := NewAPITokens(&mockDBManager{db: db}, APITokensConfig{Scopes: []string{"orders:read", "orders:write"}})
+	if err != nil {
+		t.Fatalf("NewAPITokens failed: %v", err)
+	}
+
+	cfg := DefaultServerConfig()
+	cfg.EnableStaticAssets = false
+	cfg.EnableRequestLogger = false
+	cfg.EnableSecFetchSite = false
+	cfg.Config = &testConfig{}
+	cfg.Logger = testLogger()
+	cfg.DBManager = &mockDBManager{db: db}
+	cfg.ErrorHandler = DefaultErrorHandler(testLogger(), false)
+	// Stands in for a login that loads the user's permissions
+	cfg.PrincipalResolver = func(ctx *Context) (*Principal, error) {
+		if user := ctx.Get("X-User"); user != "" {
+			return &Principal{ID: user, Permissions: []string{"orders:*"}}, nil
+		}
+		return DefaultPrincipalResolver(ctx)
+	}
+	srv, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	srv.SetAPITokens(tokens)
+	srv.SetSession(NewSessionManager(SessionConfig{Secret: "test-secret-key-32-characters-xx", TTL: time.Hour}))
+	srv.Get("/login", func(ctx *Context) error {
+		return ctx.Auth.SetAuthCookie(ctx.Ctx, "mallory")
+	})
+	srv.APITokenRoutes("/settings/tokens", APITokenRoutesConfig{
+		CreateRateLimit: &RateLimit{Max: 2, Key: RateLimitByUser},
+	})
+	srv.Get("/api/orders", func(ctx *Context) error {
+		return ctx.SendString("orders for " + ctx.Principal().ID)
+	}, &RouteConfig{
+		CustomMiddleware: []fiber.Handler{tokens.Middleware()},
+		Authorize:        Policy("orders:read"),
+	})
+
+	send := func(method, target, body string, headers map[string]string) (int, string) {
+		t.Helper()
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		resp, err := srv.App().Test(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		data, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(data)
+	}
+	alice := map[string]string{"X-User": "alice"}
+
+	if status, _ := send("GET", "/settings/tokens", "", nil); status != 401 {
+		t.Errorf("expected 401 when signed out, got %d", status)
+	}
+
+	status, body := send("POST", "/settings/tokens", `{"name": "CLI", "scopes": ["orders:read"]}`, alice)
+	var created struct {
+		ID     uint     `json:"id"`
+		Token  string   `json:"token"`
+		Prefix string   `json:"prefix"`
+		Scopes []string `json:"scopes"`
+	}
+	if err := json.Unmarshal([]byte(body), &created); err != nil || status != 201 || created.Token == "" {
+		t.Fatalf("expected a new token, got %d %s", status, body)
+	}
+
+	status, body = send("GET", "/settings/tokens", "", alice)
+	if status != 200 || strings.Contains(body, created.Token) || !strings.Contains(body, created.Prefix) {
+		t.Errorf("expected the listing to show the prefix only, got %d %s", status, body)
+	}
+
+	bearer := map[string]string{"Authorization": "Bearer " + created.Token}
+	if status, body := send("GET", "/api/orders", "", bearer); status != 200 || body != "orders for alice" {
+		t.Errorf("expected the token to authenticate, got %d %s", status, body)
+	}
+	if status, _ := send("GET", "/api/orders", "", map[string]string{"X-API-Key": "ctk_nope"}); status != 401 {
+		t.Errorf("expected 401 for an unknown token, got %d", status)
+	}
+
+	if status, _ := send("POST", "/settings/tokens", `{"name": "again"}`, alice); status != 201 {
+		t.Errorf("expected a second token, got %d", status)
+	}
+	if status, _ := send("POST", "/settings/tokens", `{"name": "too many"}`, alice); status != 429 {
+		t.Errorf("expected issuance to be rate limited, got %d", status)
+	}
+
+	// A plain session user holds no permissions, so can't grant any
+	resp, err := srv.App().Test(httptest.NewRequest("GET", "/login", nil))
+	if err != nil || len(resp.Cookies()) == 0 {
+		t.Fatalf("login failed: %v", err)
+	}
+	session := map[string]string{"Cookie": resp.Cookies()[0].Name + "=" + resp.Cookies()[0].Value}
+	for _, scopes := range []string{`["*"]`, `["orders:read"]`} {
+		if status, body := send("POST", "/settings/tokens", `{"name": "escalate", "scopes": `+scopes+`}`, session); status != 422 {
+			t.Errorf("expected a session user to be refused %s, got %d %s", scopes, status, body)
+		}
+	}
+
+	if status, _ := send("DELETE", "/settings/tokens/"+strconv.FormatUint(uint64(created.ID), 10), "", map[string]string{"X-User": "bob"}); status != 404 {
+		t.Errorf("expected 404 revoking another user's token, got %d", status)
+	}
+	if status, _ := send("DELETE", "/settings/tokens/"+strconv.FormatUint(uint64(created.ID), 10), "", alice); status != 204 {
+		t.Errorf("expected 204, got %d", status)
+	}
+	if status, _ := send("GET", "/api/orders", "", bearer); status != 401 {
+		t.Errorf("expected a revoked token to be rejected, got %d", status)
+	}
+}
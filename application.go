@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 )
@@ -14,6 +15,20 @@ type BackgroundWorker interface {
 	Stop()
 }
 
+// WorkerSetup is implemented by background workers that use the
+// application's logger and database. SetupWorker is called just before the
+// worker starts.
+type WorkerSetup interface {
+	SetupWorker(logger Logger, dbManager DBManager)
+}
+
+// WorkerDrainer is implemented by background workers that can finish their
+// in-flight work on shutdown. Drain stops taking new work and waits for
+// running work until ctx is done, then cancels it. Stop is called afterwards.
+type WorkerDrainer interface {
+	Drain(ctx context.Context) error
+}
+
 // Application wires together configuration, logging, database, and HTTP server.
 // It manages the complete lifecycle of a cartridge web application.
 type Application struct {
@@ -22,6 +37,13 @@ type Application struct {
 	DBManager DBManager
 	Server    *Server
 	workers   []BackgroundWorker
+	started   []BackgroundWorker
+	lifecycle LifecycleConfig
+	seeders   []Seeder
+	snapshot  string // effective config of the previous run
+	phaseMu   sync.RWMutex
+	phase     LifecyclePhase
+	ready     bool
 }
 
 // ApplicationOptions configure application bootstrapping.
@@ -45,6 +67,12 @@ type ApplicationOptions struct {
 
 	// Background workers to run alongside the server
 	BackgroundWorkers []BackgroundWorker
+
+	// Lifecycle configures startup phases (migrate, warmup, workers, cron, listen, ready)
+	Lifecycle LifecycleConfig
+
+	// Seeders run, in order, on the "seed" command (see RunCommand)
+	Seeders []Seeder
 }
 
 // NewApplication constructs a cartridge application.
@@ -84,57 +112,103 @@ func NewApplication(opts ApplicationOptions) (*Application, error) {
 		opts.RouteMountFunc(server)
 	}
 
-	return &Application{
+	app := &Application{
 		Config:    opts.Config,
 		Logger:    opts.Logger,
 		DBManager: opts.DBManager,
 		Server:    server,
 		workers:   opts.BackgroundWorkers,
-	}, nil
+		lifecycle: opts.Lifecycle,
+		seeders:   opts.Seeders,
+		snapshot:  configSnapshotPath(opts.Config),
+	}
+	if opts.Config != nil && opts.Config.IsDevelopment() {
+		app.mountConfigEndpoint()
+	}
+	return app, nil
 }
 
-// AddWorker adds a background worker to the application.
+// AddWorker adds a background worker to the application. It is started
+// with the other workers on Run and stopped, in reverse order, on Shutdown.
+// Add workers before calling Run.
 func (a *Application) AddWorker(w BackgroundWorker) {
 	a.workers = append(a.workers, w)
 }
 
-// Start launches background workers and the HTTP server.
+// On subscribes handler to the named event emitted with ctx.Emit or Emit:
+//
+//	app.On("product.created", notifyFollowers)
+//	app.On("product.created", reindexProduct, cartridge.EventAsync())
+//
+// Subscribe before Run, so no event is emitted before its subscribers exist.
+func (a *Application) On(name string, handler EventHandler, opts ...EventOption) {
+	a.Server.Events().On(name, handler, opts...)
+}
+
+// Emit delivers an event outside a request, e.g. from a job (see EventBus.Emit).
+func (a *Application) Emit(ctx context.Context, name string, payload any) error {
+	return a.Server.Events().Emit(ctx, name, payload)
+}
+
+// Start runs the lifecycle phases and blocks while the HTTP server is listening.
+// By default: migrate → warmup → workers → cron → listen → ready.
 func (a *Application) Start() error {
-	// Start all background workers first
-	for _, w := range a.workers {
-		if err := w.Start(); err != nil {
-			// Stop any already started workers
-			a.stopWorkers()
-			return err
-		}
+	serveErr := make(chan error, 1)
+	if err := a.startPhases(serveErr); err != nil {
+		return err
 	}
-	return a.Server.Start()
+	return <-serveErr
 }
 
-// StartAsync launches the HTTP server asynchronously.
+// StartAsync runs the lifecycle phases and returns once the application is ready.
+// The HTTP server keeps running in the background.
 func (a *Application) StartAsync() error {
-	// Start all background workers first
-	for _, w := range a.workers {
-		if err := w.Start(); err != nil {
-			// Stop any already started workers
-			a.stopWorkers()
-			return err
-		}
+	serveErr := make(chan error, 1)
+	if err := a.startPhases(serveErr); err != nil {
+		return err
 	}
-	return a.Server.StartAsync()
+	go func() {
+		if err := <-serveErr; err != nil {
+			a.Logger.Error("Server error", "error", err)
+		}
+	}()
+	return nil
 }
 
-// Shutdown gracefully stops workers and the server.
+// Shutdown gracefully stops workers and the server. Workers implementing
+// WorkerDrainer, such as the async and cron managers, first get until ctx is
+// done (or LifecycleConfig.DrainTimeout) to finish running work.
 func (a *Application) Shutdown(ctx context.Context) error {
+	a.drainWorkers(ctx)
 	a.stopWorkers()
 	return a.Server.Shutdown(ctx)
 }
 
-// stopWorkers stops all background workers.
+// drainWorkers drains started workers in reverse start order, sharing one
+// deadline. Cron drains before async, so jobs can still queue tasks.
+func (a *Application) drainWorkers(ctx context.Context) {
+	if a.lifecycle.DrainTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.lifecycle.DrainTimeout)
+		defer cancel()
+	}
+	for i := len(a.started) - 1; i >= 0; i-- {
+		d, ok := a.started[i].(WorkerDrainer)
+		if !ok {
+			continue
+		}
+		if err := d.Drain(ctx); err != nil {
+			a.Logger.Warn("Worker did not drain in time", "error", err)
+		}
+	}
+}
+
+// stopWorkers stops started background workers in reverse start order.
 func (a *Application) stopWorkers() {
-	for _, w := range a.workers {
-		w.Stop()
+	for i := len(a.started) - 1; i >= 0; i-- {
+		a.started[i].Stop()
 	}
+	a.started = nil
 }
 
 // Run starts the application and waits for termination signals.
@@ -146,14 +220,20 @@ func (a *Application) Run() error {
 // RunWithTimeout starts the application and waits for termination signals.
 // It handles graceful shutdown with the specified timeout.
 func (a *Application) RunWithTimeout(timeout time.Duration) error {
-	if err := a.Start(); err != nil {
+	serveErr := make(chan error, 1)
+	if err := a.startPhases(serveErr); err != nil {
 		return err
 	}
 
-	// Wait for termination signal
+	// Wait for termination signal or a server failure
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
-	<-stop
+	select {
+	case <-stop:
+	case err := <-serveErr:
+		a.stopWorkers()
+		return err
+	}
 
 	a.Logger.Info("Shutting down gracefully...")
 
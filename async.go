@@ -0,0 +1,1092 @@
+package cartridge
+
+import (
+	"container/heap"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// AsyncTaskStatus describes where an async task is in its lifecycle.
+type AsyncTaskStatus string
+
+// Async task statuses.
+const (
+	AsyncPending   AsyncTaskStatus = "pending"
+	AsyncRunning   AsyncTaskStatus = "running"
+	AsyncCompleted AsyncTaskStatus = "completed"
+	AsyncFailed    AsyncTaskStatus = "failed"
+	AsyncCanceled  AsyncTaskStatus = "canceled"
+)
+
+var (
+	// ErrAsyncTaskNotFound is returned when a task ID is unknown.
+	ErrAsyncTaskNotFound = errors.New("cartridge: async task not found")
+
+	// ErrAsyncNotRunning is returned when tasks are submitted before Start or after Stop.
+	ErrAsyncNotRunning = errors.New("cartridge: async manager is not running")
+
+	// ErrAsyncQueueFull is returned when the task queue is at capacity.
+	// Callers should back off and retry, or shed the work.
+	ErrAsyncQueueFull = errors.New("cartridge: async queue is full")
+
+	// ErrAsyncTaskState is returned when a task can't be canceled or retried
+	// in its current status.
+	ErrAsyncTaskState = errors.New("cartridge: async task is in the wrong state")
+
+	// ErrAsyncCanceled is recorded as the error of tasks canceled before they ran.
+	ErrAsyncCanceled = errors.New("cartridge: async task canceled")
+)
+
+// AsyncHandler processes a single async task.
+// The payload is the JSON-encoded value passed to Run. The returned value is
+// JSON-encoded and stored as the task result.
+type AsyncHandler func(ctx *JobContext, payload json.RawMessage) (any, error)
+
+// AsyncFunc is the body of an ad-hoc task started with RunFunc or ctx.Promote.
+type AsyncFunc func(ctx *JobContext) (any, error)
+
+// AsyncTask is the record of a submitted async task.
+// In durable mode it is persisted to the cartridge_async_tasks table.
+type AsyncTask struct {
+	ID         string          `gorm:"primaryKey;size:32" json:"id"`
+	Name       string          `gorm:"size:255;index" json:"name"`
+	Status     AsyncTaskStatus `gorm:"size:16;index" json:"status"`
+	Payload    string          `gorm:"type:text" json:"payload"`
+	Result     string          `gorm:"type:text" json:"result,omitempty"`
+	Error      string          `gorm:"type:text" json:"error,omitempty"`
+	Priority   int             `gorm:"index" json:"priority"`
+	Attempts   int             `json:"attempts"`
+	CreatedAt  time.Time       `json:"created_at"`
+	StartedAt  *time.Time      `json:"started_at,omitempty"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+	RunAt      *time.Time      `gorm:"index" json:"run_at,omitempty"`    // delayed tasks wait until then
+	NextID     string          `gorm:"size:32" json:"next_id,omitempty"` // task queued after this chain step
+	Chain      string          `gorm:"type:text" json:"-"`               // rest of the AsyncChain, if any
+
+	fn          AsyncFunc          // ad-hoc task body; such tasks are kept in memory only
+	traceParent trace.SpanContext  // span of the request that queued the task
+	cancel      context.CancelFunc // cancels the running handler
+	canceled    bool               // Cancel was called
+	timer       *time.Timer        // queues a delayed task when it's due
+	saveMu      *sync.Mutex        // orders the task's writes to the database
+}
+
+// TableName specifies the table name.
+func (AsyncTask) TableName() string {
+	return "cartridge_async_tasks"
+}
+
+// AsyncConfig configures the async manager.
+type AsyncConfig struct {
+	// Logger for task execution. Optional, defaults to slog.Default().
+	Logger Logger
+
+	// DBManager provides database access to handlers and durable storage.
+	DBManager DBManager
+
+	// Durable persists tasks to the cartridge_async_tasks table so queued work
+	// survives restarts. Pending tasks are resumed on Start. Requires DBManager.
+	Durable bool
+
+	// Workers is the number of tasks executed concurrently. Default: 4.
+	Workers int
+
+	// QueueSize is the maximum number of tasks waiting for a worker.
+	// Run returns ErrAsyncQueueFull beyond this limit. Default: 1000.
+	QueueSize int
+
+	// Retention is how long finished tasks stay in memory for Get and List.
+	// In durable mode older tasks are read from the database; otherwise
+	// they are forgotten. Default: 1h.
+	Retention time.Duration
+
+	// MaxRetained caps the finished tasks kept in memory, dropping the
+	// oldest first. Default: 1000.
+	MaxRetained int
+
+	// Cache is returned by JobContext.Cache(). Optional, defaults to a
+	// process-wide in-memory cache.
+	Cache *Cache
+}
+
+// AsyncTaskFilter selects tasks for AsyncManager.List.
+type AsyncTaskFilter struct {
+	// Name matches the task name. Optional.
+	Name string
+
+	// Status matches the task status. Optional.
+	Status AsyncTaskStatus
+
+	// Limit caps the number of tasks returned. Default: no limit.
+	Limit int
+
+	// Offset skips that many matching tasks, for paging.
+	Offset int
+}
+
+// AsyncRunOption configures a single task submission.
+type AsyncRunOption func(*asyncRunOptions)
+
+type asyncRunOptions struct {
+	priority    int
+	traceParent trace.SpanContext
+	runAt       time.Time
+	chain       string // set by AsyncChain.Dispatch
+}
+
+// AsyncPriority sets the task priority. Higher values run first;
+// tasks with equal priority run in submission order. Default: 0.
+func AsyncPriority(priority int) AsyncRunOption {
+	return func(o *asyncRunOptions) {
+		o.priority = priority
+	}
+}
+
+// AsyncDelay holds the task back for d before queuing it.
+func AsyncDelay(d time.Duration) AsyncRunOption {
+	return AsyncRunAt(time.Now().Add(d))
+}
+
+// AsyncRunAt holds the task back until t before queuing it. Delayed tasks
+// are pending until then, and don't count against QueueSize. In durable mode
+// they survive restarts; a task that came due while the app was down runs on
+// Start.
+func AsyncRunAt(t time.Time) AsyncRunOption {
+	return func(o *asyncRunOptions) {
+		o.runAt = t
+	}
+}
+
+// AsyncTraceFrom runs the task as a child of the span in ctx, so it shows up in
+// the trace of the request that queued it:
+//
+//	app.AsyncJob("send-invoice", payload, cartridge.AsyncTraceFrom(ctx.UserContext()))
+func AsyncTraceFrom(ctx context.Context) AsyncRunOption {
+	return func(o *asyncRunOptions) {
+		o.traceParent = trace.SpanContextFromContext(ctx)
+	}
+}
+
+// runAtUTC returns the delay set by AsyncRunAt, or nil.
+func (o asyncRunOptions) runAtUTC() *time.Time {
+	if o.runAt.IsZero() {
+		return nil
+	}
+	t := o.runAt.UTC()
+	return &t
+}
+
+// AsyncManager runs registered handlers on a bounded worker pool and tracks
+// their status. It implements BackgroundWorker.
+type AsyncManager struct {
+	logger    Logger
+	dbManager DBManager
+	durable   bool
+	workers   int
+	queueSize int
+	retention time.Duration
+	retained  int
+	cache     *Cache
+
+	mu       sync.RWMutex
+	cond     *sync.Cond
+	handlers map[string]AsyncHandler
+	tasks    map[string]*AsyncTask
+	finished []finishedTask // finished tasks in memory, oldest first
+	queue    asyncQueue
+	queued   int    // queued tasks plus reserved slots
+	seq      uint64 // submission counter for FIFO ordering within a priority
+	running  bool
+	draining bool // Drain was called; no new tasks are accepted
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	metrics  jobMetricsRecorder
+}
+
+// NewAsyncManager creates an async manager with the given configuration.
+func NewAsyncManager(cfg AsyncConfig) *AsyncManager {
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = 4
+	}
+
+	queueSize := cfg.QueueSize
+	if queueSize <= 0 {
+		queueSize = 1000
+	}
+
+	retention := cfg.Retention
+	if retention <= 0 {
+		retention = time.Hour
+	}
+
+	retained := cfg.MaxRetained
+	if retained <= 0 {
+		retained = 1000
+	}
+
+	m := &AsyncManager{
+		logger:    logger,
+		dbManager: cfg.DBManager,
+		durable:   cfg.Durable,
+		workers:   workers,
+		queueSize: queueSize,
+		retention: retention,
+		retained:  retained,
+		cache:     cfg.Cache,
+		handlers:  make(map[string]AsyncHandler),
+		tasks:     make(map[string]*AsyncTask),
+	}
+	m.cond = sync.NewCond(&m.mu)
+	return m
+}
+
+// Register adds a handler for the given task name.
+// Handlers must be registered before Start so durable tasks can be resumed.
+func (m *AsyncManager) Register(name string, handler AsyncHandler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlers[name] = handler
+}
+
+// Start launches the worker pool. In durable mode it also prepares the task
+// table and resumes unfinished tasks.
+func (m *AsyncManager) Start() error {
+	m.mu.Lock()
+	if m.running {
+		m.mu.Unlock()
+		return nil
+	}
+	if m.durable && m.dbManager == nil {
+		m.mu.Unlock()
+		return fmt.Errorf("cartridge: durable async requires a database manager")
+	}
+	m.ctx, m.cancel = context.WithCancel(context.Background())
+	m.running = true
+	m.draining = false
+	m.mu.Unlock()
+
+	m.wg.Add(m.workers)
+	for i := 0; i < m.workers; i++ {
+		go m.worker()
+	}
+
+	if !m.durable {
+		return nil
+	}
+
+	if err := m.resume(); err != nil {
+		m.Stop()
+		return err
+	}
+	return nil
+}
+
+// Stop cancels the task context and waits for in-flight tasks to finish.
+// Queued and delayed tasks are not started. In durable mode, interrupted,
+// queued and delayed tasks stay in the table and resume on next Start;
+// otherwise they are recorded as canceled. Use Drain first to let running
+// tasks finish.
+func (m *AsyncManager) Stop() {
+	m.mu.Lock()
+	if !m.running {
+		m.mu.Unlock()
+		return
+	}
+	m.running = false
+	m.cancel()
+	dropped := m.dropWaiting()
+	m.cond.Broadcast()
+	m.mu.Unlock()
+
+	for _, task := range dropped {
+		m.finish(task, nil, ErrAsyncCanceled)
+	}
+	m.wg.Wait()
+}
+
+// Drain prepares for shutdown: it stops accepting tasks and waits for
+// running ones until ctx is done, then cancels them. Queued and delayed tasks
+// don't start and are handled as in Stop, as are tasks cut off by ctx. Call
+// Stop afterwards.
+func (m *AsyncManager) Drain(ctx context.Context) error {
+	m.mu.Lock()
+	if !m.running || m.draining {
+		m.mu.Unlock()
+		return nil
+	}
+	m.draining = true
+	dropped := m.dropWaiting()
+	m.cond.Broadcast()
+	m.mu.Unlock()
+
+	for _, task := range dropped {
+		m.finish(task, nil, ErrAsyncCanceled)
+	}
+
+	// Idle workers exit once draining; busy ones after their task
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+	m.cancel()
+	<-done
+	return fmt.Errorf("cartridge: async drain: canceled running tasks: %w", ctx.Err())
+}
+
+// dropWaiting empties the queue and stops the timers of delayed tasks. It
+// returns the tasks that won't resume on the next Start, marked canceled for
+// the caller to finish. Must be called with m.mu held.
+func (m *AsyncManager) dropWaiting() []*AsyncTask {
+	resumable := func(task *AsyncTask) bool { return m.durable && task.fn == nil }
+
+	var dropped []*AsyncTask
+	for _, item := range m.queue {
+		if !resumable(item.task) {
+			dropped = append(dropped, item.task)
+		}
+	}
+	m.queue = nil
+	m.queued = 0
+	for _, task := range m.tasks {
+		if task.timer == nil {
+			continue
+		}
+		task.timer.Stop()
+		task.timer = nil
+		if !resumable(task) {
+			dropped = append(dropped, task)
+		}
+	}
+	for _, task := range dropped {
+		task.canceled = true
+	}
+	return dropped
+}
+
+// Run submits a task for background execution and returns its ID.
+// The payload is JSON-encoded and passed to the handler registered under name.
+// Returns ErrAsyncQueueFull when the queue is at capacity.
+//
+//	id, err := async.Run("send_email", msg, cartridge.AsyncPriority(10))
+func (m *AsyncManager) Run(name string, payload any, opts ...AsyncRunOption) (string, error) {
+	var o asyncRunOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	m.mu.RLock()
+	_, ok := m.handlers[name]
+	m.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("cartridge: no async handler registered for %q", name)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("cartridge: encode async payload: %w", err)
+	}
+
+	task := &AsyncTask{
+		ID:          newAsyncTaskID(),
+		Name:        name,
+		Status:      AsyncPending,
+		Payload:     string(data),
+		Priority:    o.priority,
+		CreatedAt:   time.Now().UTC(),
+		RunAt:       o.runAtUTC(),
+		Chain:       o.chain,
+		traceParent: o.traceParent,
+	}
+
+	if err := m.submit(task); err != nil {
+		return "", err
+	}
+	return task.ID, nil
+}
+
+// RunFunc submits an ad-hoc task that runs fn instead of a registered handler.
+// The name only labels the task. Ad-hoc tasks are never persisted, so they
+// don't survive restarts even in durable mode.
+func (m *AsyncManager) RunFunc(name string, fn AsyncFunc, opts ...AsyncRunOption) (string, error) {
+	var o asyncRunOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	task := &AsyncTask{
+		ID:          newAsyncTaskID(),
+		Name:        name,
+		Status:      AsyncPending,
+		Priority:    o.priority,
+		CreatedAt:   time.Now().UTC(),
+		RunAt:       o.runAtUTC(),
+		fn:          fn,
+		traceParent: o.traceParent,
+	}
+	if err := m.submit(task); err != nil {
+		return "", err
+	}
+	return task.ID, nil
+}
+
+// goFunc starts an ad-hoc task on its own goroutine, outside the worker pool,
+// and tracks it like a queued task. done is closed when it finishes.
+func (m *AsyncManager) goFunc(name string, fn AsyncFunc, opts ...AsyncRunOption) (id string, done <-chan struct{}, err error) {
+	var o asyncRunOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	task := &AsyncTask{
+		ID:          newAsyncTaskID(),
+		Name:        name,
+		Status:      AsyncPending,
+		CreatedAt:   time.Now().UTC(),
+		fn:          fn,
+		traceParent: o.traceParent,
+	}
+
+	m.mu.Lock()
+	if !m.running || m.draining {
+		m.mu.Unlock()
+		return "", nil, ErrAsyncNotRunning
+	}
+	m.tasks[task.ID] = task
+	m.wg.Add(1)
+	m.mu.Unlock()
+
+	ch := make(chan struct{})
+	go func() {
+		defer m.wg.Done()
+		defer close(ch)
+		m.execute(task)
+	}()
+	return task.ID, ch, nil
+}
+
+// Get returns a snapshot of the task with the given ID.
+// In durable mode, tasks from previous runs are loaded from the database.
+func (m *AsyncManager) Get(id string) (*AsyncTask, error) {
+	m.mu.RLock()
+	task, ok := m.tasks[id]
+	if ok {
+		snapshot := *task
+		m.mu.RUnlock()
+		return &snapshot, nil
+	}
+	m.mu.RUnlock()
+
+	if !m.durable {
+		return nil, ErrAsyncTaskNotFound
+	}
+	return m.load(id)
+}
+
+// List returns tasks matching the filter, newest first. In durable mode it
+// includes tasks from previous runs.
+func (m *AsyncManager) List(filter AsyncTaskFilter) ([]AsyncTask, error) {
+	match := func(t *AsyncTask) bool {
+		return (filter.Name == "" || t.Name == filter.Name) &&
+			(filter.Status == "" || t.Status == filter.Status)
+	}
+
+	byID := make(map[string]AsyncTask)
+	m.mu.RLock()
+	for id, task := range m.tasks {
+		if match(task) {
+			byID[id] = *task
+		}
+	}
+	m.mu.RUnlock()
+
+	if m.durable {
+		db, err := m.dbManager.Connect()
+		if err != nil {
+			return nil, fmt.Errorf("cartridge: connect database: %w", err)
+		}
+		query := db.Order("created_at DESC, id DESC")
+		if filter.Name != "" {
+			query = query.Where("name = ?", filter.Name)
+		}
+		if filter.Status != "" {
+			query = query.Where("status = ?", filter.Status)
+		}
+		if filter.Limit > 0 {
+			query = query.Limit(filter.Offset + filter.Limit)
+		}
+		var stored []AsyncTask
+		if err := query.Find(&stored).Error; err != nil {
+			return nil, fmt.Errorf("cartridge: list async tasks: %w", err)
+		}
+		// Tasks in memory are at least as fresh as their rows
+		for _, task := range stored {
+			if _, ok := byID[task.ID]; !ok {
+				byID[task.ID] = task
+			}
+		}
+	}
+
+	tasks := make([]AsyncTask, 0, len(byID))
+	for _, task := range byID {
+		tasks = append(tasks, task)
+	}
+	slices.SortFunc(tasks, func(a, b AsyncTask) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(b.ID, a.ID)
+	})
+
+	if filter.Offset >= len(tasks) {
+		return []AsyncTask{}, nil
+	}
+	tasks = tasks[filter.Offset:]
+	if filter.Limit > 0 && len(tasks) > filter.Limit {
+		tasks = tasks[:filter.Limit]
+	}
+	return tasks, nil
+}
+
+// Cancel stops a pending or running task. Pending tasks are removed from the
+// queue; running tasks have their context canceled and are recorded as
+// canceled once the handler returns with an error.
+func (m *AsyncManager) Cancel(id string) error {
+	m.mu.Lock()
+	task, ok := m.tasks[id]
+	if ok {
+		if task.Status != AsyncPending && task.Status != AsyncRunning {
+			m.mu.Unlock()
+			return fmt.Errorf("%w: task %s is %s, only pending or running tasks can be canceled", ErrAsyncTaskState, id, task.Status)
+		}
+		task.canceled = true
+		if task.cancel != nil {
+			task.cancel()
+		}
+		dequeued := m.queue.remove(task)
+		if dequeued {
+			m.queued--
+		}
+		if task.timer != nil {
+			// A delayed task that isn't due yet
+			task.timer.Stop()
+			task.timer = nil
+			dequeued = true
+		}
+		m.mu.Unlock()
+
+		if dequeued {
+			m.finish(task, nil, ErrAsyncCanceled)
+		}
+		return nil
+	}
+	m.mu.Unlock()
+
+	if !m.durable {
+		return ErrAsyncTaskNotFound
+	}
+
+	// A pending row that was never queued, e.g. its handler is gone
+	task, err := m.load(id)
+	if err != nil {
+		return err
+	}
+	if task.Status != AsyncPending {
+		return fmt.Errorf("%w: task %s is %s, only pending or running tasks can be canceled", ErrAsyncTaskState, id, task.Status)
+	}
+	finished := time.Now().UTC()
+	task.Status = AsyncCanceled
+	task.Error = ErrAsyncCanceled.Error()
+	task.FinishedAt = &finished
+	return m.persist(task)
+}
+
+// Retry re-queues a failed or canceled task. A delayed task canceled before
+// it was due waits for its RunAt again.
+func (m *AsyncManager) Retry(id string) error {
+	task, err := m.Get(id)
+	if err != nil {
+		return err
+	}
+	if task.Status != AsyncFailed && task.Status != AsyncCanceled {
+		return fmt.Errorf("%w: task %s is %s, only failed or canceled tasks can be retried", ErrAsyncTaskState, id, task.Status)
+	}
+
+	task.Status = AsyncPending
+	task.Error = ""
+	task.Result = ""
+	task.StartedAt = nil
+	task.FinishedAt = nil
+	task.cancel = nil
+	task.canceled = false
+	// Wait for the failed run's last write so the pending row lands after it
+	unlock := m.lockSave(task)
+	defer unlock()
+	return m.submit(task)
+}
+
+// Metrics returns run counts, failures and duration histograms per task
+// name, sorted by name. Retries count as separate runs.
+func (m *AsyncManager) Metrics() []JobMetrics {
+	return m.metrics.snapshot()
+}
+
+// QueueLen returns the number of tasks waiting for a worker.
+func (m *AsyncManager) QueueLen() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.queue.Len()
+}
+
+// submit reserves a queue slot, stores the task, and queues it for a worker.
+// Tasks with a future RunAt are held back until they're due.
+func (m *AsyncManager) submit(task *AsyncTask) error {
+	if task.RunAt != nil && task.RunAt.After(time.Now()) {
+		return m.delay(task)
+	}
+
+	m.mu.Lock()
+	if !m.running || m.draining {
+		m.mu.Unlock()
+		return ErrAsyncNotRunning
+	}
+	if m.queued >= m.queueSize {
+		m.mu.Unlock()
+		return ErrAsyncQueueFull
+	}
+	m.queued++
+	m.mu.Unlock()
+
+	if err := m.persist(task); err != nil {
+		m.mu.Lock()
+		m.queued--
+		m.mu.Unlock()
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.running || m.draining {
+		return ErrAsyncNotRunning
+	}
+	m.tasks[task.ID] = task
+	m.seq++
+	heap.Push(&m.queue, &asyncQueueItem{task: task, seq: m.seq})
+	m.cond.Signal()
+	return nil
+}
+
+// delay stores a task and queues it when its RunAt comes. It takes no queue
+// slot while it waits.
+func (m *AsyncManager) delay(task *AsyncTask) error {
+	m.mu.RLock()
+	accepting := m.running && !m.draining
+	m.mu.RUnlock()
+	if !accepting {
+		return ErrAsyncNotRunning
+	}
+
+	if err := m.persist(task); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.running || m.draining {
+		return ErrAsyncNotRunning
+	}
+	m.tasks[task.ID] = task
+	task.timer = time.AfterFunc(time.Until(*task.RunAt), func() { m.release(task) })
+	return nil
+}
+
+// release queues a delayed task that has come due. When the queue is full it
+// tries again a second later rather than dropping the task.
+func (m *AsyncManager) release(task *AsyncTask) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.running || task.timer == nil {
+		// Stopped or canceled meanwhile
+		return
+	}
+	if m.queued >= m.queueSize {
+		m.logger.Warn("async queue full, delaying due task", "id", task.ID, "name", task.Name)
+		task.timer = time.AfterFunc(time.Second, func() { m.release(task) })
+		return
+	}
+	task.timer = nil
+	m.queued++
+	m.seq++
+	heap.Push(&m.queue, &asyncQueueItem{task: task, seq: m.seq})
+	m.cond.Signal()
+}
+
+// worker executes queued tasks until the manager stops or drains.
+func (m *AsyncManager) worker() {
+	defer m.wg.Done()
+
+	for {
+		m.mu.Lock()
+		for m.running && !m.draining && m.queue.Len() == 0 {
+			m.cond.Wait()
+		}
+		if !m.running || m.draining {
+			m.mu.Unlock()
+			return
+		}
+		item := heap.Pop(&m.queue).(*asyncQueueItem)
+		m.queued--
+		m.mu.Unlock()
+
+		m.execute(item.task)
+	}
+}
+
+// execute runs the task handler and records the outcome.
+func (m *AsyncManager) execute(task *AsyncTask) {
+	m.mu.Lock()
+	handler := m.handlers[task.Name]
+	base := m.ctx
+	if task.canceled {
+		// Canceled between leaving the queue and starting
+		m.mu.Unlock()
+		m.finish(task, nil, ErrAsyncCanceled)
+		return
+	}
+	ctx, cancel := context.WithCancel(base)
+	defer cancel()
+	task.cancel = cancel
+	m.mu.Unlock()
+	if task.fn != nil {
+		handler = func(ctx *JobContext, _ json.RawMessage) (any, error) { return task.fn(ctx) }
+	}
+
+	started := time.Now().UTC()
+	m.update(task, func(t *AsyncTask) {
+		t.Status = AsyncRunning
+		t.StartedAt = &started
+		t.Attempts++
+	})
+
+	ctx, span := startJobSpan(ctx, "async "+task.Name, task.traceParent,
+		attribute.String("cartridge.async.id", task.ID),
+		attribute.Int("cartridge.async.attempt", task.Attempts),
+	)
+	jobCtx := &JobContext{
+		Context: ctx,
+		Logger:  m.logger,
+		cache:   m.cache,
+	}
+	if m.dbManager != nil {
+		db, err := m.dbManager.Connect()
+		if err != nil {
+			err = fmt.Errorf("connect database: %w", err)
+			endJobSpan(span, err)
+			m.finish(task, nil, err)
+			return
+		}
+		jobCtx.DB = db.WithContext(ctx)
+	}
+
+	result, err := invokeAsyncHandler(handler, jobCtx, json.RawMessage(task.Payload))
+	endJobSpan(span, err)
+
+	// Leave interrupted durable tasks pending so they resume on the next boot.
+	m.mu.RLock()
+	canceled := task.canceled
+	m.mu.RUnlock()
+	if base.Err() != nil && m.durable && task.fn == nil && !canceled {
+		m.update(task, func(t *AsyncTask) {
+			t.Status = AsyncPending
+			t.StartedAt = nil
+		})
+		m.logger.Info("async task interrupted by shutdown", "id", task.ID, "name", task.Name)
+		return
+	}
+	if base.Err() != nil && err != nil {
+		// Cut off by shutdown and won't resume
+		m.mu.Lock()
+		task.canceled = true
+		m.mu.Unlock()
+	}
+
+	m.finish(task, result, err)
+}
+
+// finish records the task result or error.
+func (m *AsyncManager) finish(task *AsyncTask, result any, err error) {
+	finished := time.Now().UTC()
+
+	var encoded string
+	if err == nil && result != nil {
+		data, encErr := json.Marshal(result)
+		if encErr != nil {
+			err = fmt.Errorf("encode result: %w", encErr)
+		} else {
+			encoded = string(data)
+		}
+	}
+
+	// Link the next chain step before the step shows as finished
+	var next *AsyncTask
+	if task.Chain != "" {
+		next = m.nextInChain(task, encoded, err)
+	}
+
+	m.update(task, func(t *AsyncTask) {
+		m.evictFinished(t, finished)
+		t.FinishedAt = &finished
+		t.cancel = nil
+		if next != nil {
+			t.NextID = next.ID
+		}
+		if err != nil {
+			t.Status = AsyncFailed
+			if t.canceled {
+				t.Status = AsyncCanceled
+			}
+			t.Error = err.Error()
+			return
+		}
+		t.Status = AsyncCompleted
+		t.Result = encoded
+	})
+	if next != nil {
+		defer m.queueInChain(task, next)
+	}
+
+	m.mu.RLock()
+	started := task.StartedAt
+	canceled := task.canceled
+	m.mu.RUnlock()
+	if started != nil {
+		m.metrics.observe(task.Name, finished.Sub(*started), finished, err)
+	}
+
+	if err != nil && canceled {
+		m.logger.Info("async task canceled", "id", task.ID, "name", task.Name)
+		return
+	}
+	if err != nil {
+		m.logger.Error("async task failed", "id", task.ID, "name", task.Name, "error", err)
+		return
+	}
+	m.logger.Debug("async task completed", "id", task.ID, "name", task.Name)
+}
+
+// finishedTask records when a task finished, for evicting it from memory.
+type finishedTask struct {
+	id string
+	at time.Time
+}
+
+// evictFinished records that task finished at and drops the finished tasks
+// past the retention period or count from memory. A task retried since it
+// finished is kept. Must be called with m.mu held.
+func (m *AsyncManager) evictFinished(task *AsyncTask, at time.Time) {
+	m.finished = append(m.finished, finishedTask{id: task.ID, at: at})
+	cutoff := at.Add(-m.retention)
+	for len(m.finished) > 0 && (len(m.finished) > m.retained || m.finished[0].at.Before(cutoff)) {
+		oldest := m.finished[0]
+		m.finished = m.finished[1:]
+		if t, ok := m.tasks[oldest.id]; ok && t.FinishedAt != nil && t.FinishedAt.Equal(oldest.at) {
+			delete(m.tasks, oldest.id)
+		}
+	}
+}
+
+// update mutates the task under lock and persists it in durable mode. The
+// task's save lock is held until the row is written, so concurrent updates
+// reach the database in the order they were made.
+func (m *AsyncManager) update(task *AsyncTask, fn func(*AsyncTask)) {
+	unlock := m.lockSave(task)
+	defer unlock()
+
+	m.mu.Lock()
+	fn(task)
+	snapshot := *task
+	m.mu.Unlock()
+
+	if err := m.persist(&snapshot); err != nil {
+		m.logger.Error("failed to persist async task", "id", task.ID, "error", err)
+	}
+}
+
+// lockSave takes the task's save lock, creating it on first use, and
+// returns its unlock func.
+func (m *AsyncManager) lockSave(task *AsyncTask) func() {
+	m.mu.Lock()
+	if task.saveMu == nil {
+		task.saveMu = new(sync.Mutex)
+	}
+	mu := task.saveMu
+	m.mu.Unlock()
+	mu.Lock()
+	return mu.Unlock
+}
+
+// persist writes the task to the database in durable mode.
+func (m *AsyncManager) persist(task *AsyncTask) error {
+	if !m.durable || task.fn != nil {
+		return nil
+	}
+	db, err := m.dbManager.Connect()
+	if err != nil {
+		return fmt.Errorf("cartridge: connect database: %w", err)
+	}
+	if err := db.Save(task).Error; err != nil {
+		return fmt.Errorf("cartridge: save async task: %w", err)
+	}
+	return nil
+}
+
+// load reads a task from the database.
+func (m *AsyncManager) load(id string) (*AsyncTask, error) {
+	db, err := m.dbManager.Connect()
+	if err != nil {
+		return nil, fmt.Errorf("cartridge: connect database: %w", err)
+	}
+
+	var tasks []AsyncTask
+	if err := db.Where("id = ?", id).Limit(1).Find(&tasks).Error; err != nil {
+		return nil, fmt.Errorf("cartridge: load async task: %w", err)
+	}
+	if len(tasks) == 0 {
+		return nil, ErrAsyncTaskNotFound
+	}
+	return &tasks[0], nil
+}
+
+// resume migrates the task table and re-submits unfinished tasks, including
+// delayed ones.
+func (m *AsyncManager) resume() error {
+	db, err := m.dbManager.Connect()
+	if err != nil {
+		return fmt.Errorf("cartridge: connect database: %w", err)
+	}
+	if err := db.AutoMigrate(&AsyncTask{}); err != nil {
+		return fmt.Errorf("cartridge: migrate async tasks: %w", err)
+	}
+
+	var pending []AsyncTask
+	if err := db.Where("status IN ?", []AsyncTaskStatus{AsyncPending, AsyncRunning}).
+		Order("priority DESC, created_at ASC").
+		Find(&pending).Error; err != nil {
+		return fmt.Errorf("cartridge: load pending async tasks: %w", err)
+	}
+
+	resumed := 0
+	for i := range pending {
+		task := pending[i]
+
+		m.mu.RLock()
+		_, ok := m.handlers[task.Name]
+		m.mu.RUnlock()
+		if !ok {
+			m.logger.Warn("skipping async task with no registered handler", "id", task.ID, "name", task.Name)
+			continue
+		}
+
+		task.Status = AsyncPending
+		task.StartedAt = nil
+		if err := m.submit(&task); err != nil {
+			if errors.Is(err, ErrAsyncQueueFull) {
+				m.logger.Warn("async queue full, remaining tasks resume on next start", "resumed", resumed)
+				break
+			}
+			return err
+		}
+		resumed++
+	}
+
+	if resumed > 0 {
+		m.logger.Info("resumed async tasks", "count", resumed)
+	}
+	return nil
+}
+
+// asyncQueueItem is a queued task with its submission order.
+type asyncQueueItem struct {
+	task *AsyncTask
+	seq  uint64
+}
+
+// asyncQueue is a priority queue of tasks implementing heap.Interface.
+// Higher priority first; FIFO within the same priority.
+type asyncQueue []*asyncQueueItem
+
+func (q asyncQueue) Len() int { return len(q) }
+
+func (q asyncQueue) Less(i, j int) bool {
+	if q[i].task.Priority != q[j].task.Priority {
+		return q[i].task.Priority > q[j].task.Priority
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q asyncQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *asyncQueue) Push(x any) { *q = append(*q, x.(*asyncQueueItem)) }
+
+// remove drops task from the queue, reporting whether it was queued.
+func (q *asyncQueue) remove(task *AsyncTask) bool {
+	for i, item := range *q {
+		if item.task == task {
+			heap.Remove(q, i)
+			return true
+		}
+	}
+	return false
+}
+
+func (q *asyncQueue) Pop() any {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+	return item
+}
+
+// invokeAsyncHandler calls the handler, converting panics into errors.
+func invokeAsyncHandler(handler AsyncHandler, ctx *JobContext, payload json.RawMessage) (result any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(ctx, payload)
+}
+
+// newAsyncTaskID returns a random 32-character hex ID.
+func newAsyncTaskID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%032x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
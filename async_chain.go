@@ -0,0 +1,197 @@
+package cartridge
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// AsyncChainFailure is the payload of a chain's failure handler.
+type AsyncChainFailure struct {
+	TaskID  string          `json:"task_id"` // the step that failed
+	Step    string          `json:"step"`
+	Error   string          `json:"error"`
+	Payload json.RawMessage `json:"payload"` // the failed step's input
+}
+
+// asyncChainSpec is the rest of a chain, stored with each of its tasks so
+// durable chains continue after a restart.
+type asyncChainSpec struct {
+	Steps     []string `json:"steps,omitempty"`
+	OnFailure string   `json:"on_failure,omitempty"`
+}
+
+type asyncChainStep struct {
+	name    string
+	handler AsyncHandler
+}
+
+// AsyncChain runs async tasks one after another, each receiving the
+// previous step's result as its payload:
+//
+//	id, err := app.AsyncChain().
+//	    Then("export", exportOrders).
+//	    Then("upload", uploadExport).
+//	    OnFailure("export_failed", cleanupExport).
+//	    Dispatch(exportRequest)
+//
+// A step that fails or is canceled ends the chain and queues the failure
+// handler with an AsyncChainFailure. Each step is an ordinary task: it shows
+// up in List, can be canceled, and links to the task that followed it
+// through AsyncTask.NextID. Retrying a failed step resumes the chain.
+type AsyncChain struct {
+	m         *AsyncManager
+	steps     []asyncChainStep
+	onFailure *asyncChainStep
+}
+
+// Chain starts building a chain of tasks.
+func (m *AsyncManager) Chain() *AsyncChain {
+	return &AsyncChain{m: m}
+}
+
+// AsyncChain starts building a chain of async tasks (see AsyncChain).
+func (a *App) AsyncChain() *AsyncChain {
+	return &AsyncChain{m: a.Async}
+}
+
+// Then appends a step. A non-nil handler is registered under name, like
+// Register; with nil, the handler already registered under name runs. In
+// durable mode, register steps at startup (WithAsync) so chains interrupted
+// by a restart can resume.
+func (c *AsyncChain) Then(name string, handler AsyncHandler) *AsyncChain {
+	c.steps = append(c.steps, asyncChainStep{name: name, handler: handler})
+	return c
+}
+
+// OnFailure sets the task queued when a step fails or is canceled. Its
+// payload is an AsyncChainFailure. The handler is registered like Then's.
+func (c *AsyncChain) OnFailure(name string, handler AsyncHandler) *AsyncChain {
+	c.onFailure = &asyncChainStep{name: name, handler: handler}
+	return c
+}
+
+// Dispatch queues the first step with payload and returns its task ID. The
+// options apply to every step.
+func (c *AsyncChain) Dispatch(payload any, opts ...AsyncRunOption) (string, error) {
+	if c.m == nil {
+		return "", fmt.Errorf("cartridge: async is not enabled (use WithAsync)")
+	}
+	if len(c.steps) == 0 {
+		return "", fmt.Errorf("cartridge: async chain has no steps")
+	}
+
+	steps := c.steps
+	if c.onFailure != nil {
+		steps = append(steps[:len(steps):len(steps)], *c.onFailure)
+	}
+	for _, step := range steps {
+		if step.handler != nil {
+			c.m.Register(step.name, step.handler)
+		}
+		c.m.mu.RLock()
+		_, ok := c.m.handlers[step.name]
+		c.m.mu.RUnlock()
+		if !ok {
+			return "", fmt.Errorf("cartridge: no async handler registered for %q", step.name)
+		}
+	}
+
+	spec := asyncChainSpec{}
+	for _, step := range c.steps[1:] {
+		spec.Steps = append(spec.Steps, step.name)
+	}
+	if c.onFailure != nil {
+		spec.OnFailure = c.onFailure.name
+	}
+	chain, err := encodeAsyncChain(spec)
+	if err != nil {
+		return "", err
+	}
+	return c.m.Run(c.steps[0].name, payload, append(opts, func(o *asyncRunOptions) { o.chain = chain })...)
+}
+
+// nextInChain returns the task that follows a finished chain step: the next
+// step with the step's result, or the failure handler. It returns nil at the
+// end of the chain.
+func (m *AsyncManager) nextInChain(step *AsyncTask, result string, err error) *AsyncTask {
+	var spec asyncChainSpec
+	if err := json.Unmarshal([]byte(step.Chain), &spec); err != nil {
+		m.logger.Error("async chain: invalid chain", "id", step.ID, "error", err)
+		return nil
+	}
+
+	next := &AsyncTask{
+		ID:          newAsyncTaskID(),
+		Status:      AsyncPending,
+		Priority:    step.Priority,
+		CreatedAt:   time.Now().UTC(),
+		traceParent: step.traceParent,
+	}
+	switch {
+	case err == nil && len(spec.Steps) > 0:
+		next.Name = spec.Steps[0]
+		next.Payload = result
+		if next.Payload == "" {
+			next.Payload = "null"
+		}
+		spec.Steps = spec.Steps[1:]
+		chain, err := encodeAsyncChain(spec)
+		if err != nil {
+			m.logger.Error("async chain: encode chain", "id", step.ID, "error", err)
+			return nil
+		}
+		next.Chain = chain
+	case err != nil && spec.OnFailure != "":
+		failure, encErr := json.Marshal(AsyncChainFailure{
+			TaskID:  step.ID,
+			Step:    step.Name,
+			Error:   err.Error(),
+			Payload: json.RawMessage(step.Payload),
+		})
+		if encErr != nil {
+			m.logger.Error("async chain: encode failure", "id", step.ID, "error", encErr)
+			return nil
+		}
+		next.Name = spec.OnFailure
+		next.Payload = string(failure)
+	default:
+		return nil
+	}
+	return next
+}
+
+// queueInChain submits the task following step, whose NextID already points
+// at it.
+func (m *AsyncManager) queueInChain(step, next *AsyncTask) {
+	err := m.submit(next)
+	if err == nil {
+		return
+	}
+	if errors.Is(err, ErrAsyncNotRunning) && m.durable {
+		// Store the step so the chain resumes on the next Start
+		if err := m.persist(next); err == nil {
+			m.logger.Info("async chain paused by shutdown", "id", step.ID, "next", next.Name)
+			return
+		}
+	}
+	if errors.Is(err, ErrAsyncNotRunning) {
+		m.logger.Warn("async chain stopped by shutdown", "id", step.ID, "next", next.Name)
+	} else {
+		m.logger.Error("async chain: failed to queue next task", "id", step.ID, "next", next.Name, "error", err)
+	}
+	m.update(step, func(t *AsyncTask) { t.NextID = "" })
+}
+
+// encodeAsyncChain returns the stored form of a chain, "" when nothing follows.
+func encodeAsyncChain(spec asyncChainSpec) (string, error) {
+	if len(spec.Steps) == 0 && spec.OnFailure == "" {
+		return "", nil
+	}
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return "", fmt.Errorf("cartridge: encode async chain: %w", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,88 @@
+package cartridge
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestAsyncChain(t *testing.T) {
+	m := NewAsyncManager(AsyncConfig{Logger: testLogger(), DBManager: &mockDBManager{db: openAsyncTestDB(t)}, Durable: true})
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer m.Stop()
+
+	double := func(ctx *JobContext, payload json.RawMessage) (any, error) {
+		var n int
+		if err := json.Unmarshal(payload, &n); err != nil {
+			return nil, err
+		}
+		return n * 2, nil
+	}
+	failures := make(chan AsyncChainFailure, 1)
+	onFailure := func(ctx *JobContext, payload json.RawMessage) (any, error) {
+		var failure AsyncChainFailure
+		err := json.Unmarshal(payload, &failure)
+		failures <- failure
+		return nil, err
+	}
+
+	t.Run("each step gets the previous result", func(t *testing.T) {
+		id, err := m.Chain().
+			Then("double", double).
+			Then("double_again", double).
+			Then("format", func(ctx *JobContext, payload json.RawMessage) (any, error) {
+				return "total: " + string(payload), nil
+			}).
+			OnFailure("chain_failed", onFailure).
+			Dispatch(5)
+		if err != nil {
+			t.Fatalf("Dispatch failed: %v", err)
+		}
+
+		var results []string
+		for id != "" {
+			task := waitForAsyncStatus(t, m, id, AsyncCompleted)
+			results = append(results, task.Result)
+			id = task.NextID
+		}
+		if len(results) != 3 || results[0] != "10" || results[1] != "20" || results[2] != `"total: 20"` {
+			t.Errorf("unexpected results %v", results)
+		}
+	})
+
+	t.Run("a failure skips the rest", func(t *testing.T) {
+		id, err := m.Chain().
+			Then("double", nil).
+			Then("broken", func(ctx *JobContext, payload json.RawMessage) (any, error) {
+				return nil, errors.New("disk full")
+			}).
+			Then("double_again", nil).
+			OnFailure("chain_failed", nil).
+			Dispatch(1)
+		if err != nil {
+			t.Fatalf("Dispatch failed: %v", err)
+		}
+
+		first := waitForAsyncStatus(t, m, id, AsyncCompleted)
+		broken := waitForAsyncStatus(t, m, first.NextID, AsyncFailed)
+		failure := <-failures
+		if failure.TaskID != broken.ID || failure.Step != "broken" || failure.Error != "disk full" || string(failure.Payload) != "2" {
+			t.Errorf("unexpected failure payload %+v", failure)
+		}
+		handler := waitForAsyncStatus(t, m, broken.NextID, AsyncCompleted)
+		if handler.Name != "chain_failed" || handler.NextID != "" {
+			t.Errorf("expected the failure handler to end the chain, got %+v", handler)
+		}
+	})
+
+	t.Run("unknown step", func(t *testing.T) {
+		if _, err := m.Chain().Then("double", nil).Then("missing", nil).Dispatch(1); err == nil {
+			t.Error("expected an error for a step without a handler")
+		}
+		if _, err := (&App{}).AsyncChain().Then("double", double).Dispatch(1); err == nil {
+			t.Error("expected an error without async")
+		}
+	})
+}
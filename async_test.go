@@ -0,0 +1,516 @@
+package cartridge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// openAsyncTestDB opens a file-backed SQLite database shared across connections.
+func openAsyncTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "async.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	return db
+}
+
+// waitForAsyncStatus polls until the task reaches the given status or times out.
+func waitForAsyncStatus(t *testing.T, m *AsyncManager, id string, status AsyncTaskStatus) *AsyncTask {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		task, err := m.Get(id)
+		if err == nil && task.Status == status {
+			return task
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("task %s did not reach status %s", id, status)
+	return nil
+}
+
+// waitForStoredAsyncStatus polls the task's row until it reaches the given status.
+func waitForStoredAsyncStatus(t *testing.T, db *gorm.DB, id string, status AsyncTaskStatus) *AsyncTask {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	var stored AsyncTask
+	for time.Now().Before(deadline) {
+		err := db.Where("id = ?", id).First(&stored).Error
+		if err == nil && stored.Status == status {
+			return &stored
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("persisted task %s did not reach status %s, got %s", id, status, stored.Status)
+	return nil
+}
+
+func TestAsyncManager_RunInMemory(t *testing.T) {
+	m := NewAsyncManager(AsyncConfig{Logger: testLogger()})
+	m.Register("double", func(ctx *JobContext, payload json.RawMessage) (any, error) {
+		var n int
+		if err := json.Unmarshal(payload, &n); err != nil {
+			return nil, err
+		}
+		return n * 2, nil
+	})
+
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer m.Stop()
+
+	id, err := m.Run("double", 21)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	task := waitForAsyncStatus(t, m, id, AsyncCompleted)
+	if task.Result != "42" {
+		t.Errorf("expected result 42, got %s", task.Result)
+	}
+}
+
+func TestAsyncManager_RunRequiresStart(t *testing.T) {
+	m := NewAsyncManager(AsyncConfig{Logger: testLogger()})
+	m.Register("noop", func(ctx *JobContext, payload json.RawMessage) (any, error) {
+		return nil, nil
+	})
+
+	if _, err := m.Run("noop", nil); !errors.Is(err, ErrAsyncNotRunning) {
+		t.Errorf("expected ErrAsyncNotRunning, got %v", err)
+	}
+}
+
+func TestAsyncManager_UnknownHandler(t *testing.T) {
+	m := NewAsyncManager(AsyncConfig{Logger: testLogger()})
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer m.Stop()
+
+	if _, err := m.Run("missing", nil); err == nil {
+		t.Error("expected error for unregistered handler")
+	}
+}
+
+func TestAsyncManager_DurableRetry(t *testing.T) {
+	db := openAsyncTestDB(t)
+	manager := &mockDBManager{db: db}
+
+	var calls int32
+	m := NewAsyncManager(AsyncConfig{Logger: testLogger(), DBManager: manager, Durable: true})
+	m.Register("flaky", func(ctx *JobContext, payload json.RawMessage) (any, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return nil, errors.New("first attempt fails")
+		}
+		return "ok", nil
+	})
+
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer m.Stop()
+
+	id, err := m.Run("flaky", map[string]string{"key": "value"})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	failed := waitForAsyncStatus(t, m, id, AsyncFailed)
+	if failed.Error != "first attempt fails" {
+		t.Errorf("unexpected error: %s", failed.Error)
+	}
+
+	if err := m.Retry(id); err != nil {
+		t.Fatalf("Retry failed: %v", err)
+	}
+
+	completed := waitForAsyncStatus(t, m, id, AsyncCompleted)
+	if completed.Attempts != 2 {
+		t.Errorf("expected 2 attempts, got %d", completed.Attempts)
+	}
+
+	// The persisted row reaches the final state, which may trail the
+	// in-memory task by a moment
+	stored := waitForStoredAsyncStatus(t, db, id, AsyncCompleted)
+	if stored.Attempts != 2 {
+		t.Errorf("expected 2 persisted attempts, got %d", stored.Attempts)
+	}
+}
+
+func TestAsyncManager_DurableList(t *testing.T) {
+	db := openAsyncTestDB(t)
+	manager := &mockDBManager{db: db}
+
+	// A task left over from a previous run, no longer in memory
+	if err := db.AutoMigrate(&AsyncTask{}); err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+	old := AsyncTask{ID: "old", Name: "echo", Status: AsyncCompleted, CreatedAt: time.Now().UTC().Add(-time.Hour)}
+	if err := db.Create(&old).Error; err != nil {
+		t.Fatalf("insert failed: %v", err)
+	}
+
+	m := NewAsyncManager(AsyncConfig{Logger: testLogger(), DBManager: manager, Durable: true})
+	m.Register("echo", func(ctx *JobContext, payload json.RawMessage) (any, error) {
+		return payload, nil
+	})
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer m.Stop()
+
+	id, err := m.Run("echo", 1)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	waitForAsyncStatus(t, m, id, AsyncCompleted)
+
+	tasks, err := m.List(AsyncTaskFilter{Name: "echo", Status: AsyncCompleted})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(tasks) != 2 || tasks[0].ID != id || tasks[1].ID != "old" {
+		t.Fatalf("expected both tasks newest first, got %+v", tasks)
+	}
+
+	page, err := m.List(AsyncTaskFilter{Limit: 1, Offset: 1})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(page) != 1 || page[0].ID != "old" {
+		t.Errorf("expected the second page to hold the old task, got %+v", page)
+	}
+
+	if none, _ := m.List(AsyncTaskFilter{Status: AsyncFailed}); len(none) != 0 {
+		t.Errorf("expected no failed tasks, got %+v", none)
+	}
+}
+
+func TestAsyncManager_DurableResume(t *testing.T) {
+	db := openAsyncTestDB(t)
+	manager := &mockDBManager{db: db}
+
+	// Simulate a task left pending by a previous process
+	if err := db.AutoMigrate(&AsyncTask{}); err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+	pending := AsyncTask{
+		ID:        "resume-me",
+		Name:      "echo",
+		Status:    AsyncRunning,
+		Payload:   `"hello"`,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := db.Create(&pending).Error; err != nil {
+		t.Fatalf("failed to seed task: %v", err)
+	}
+
+	m := NewAsyncManager(AsyncConfig{Logger: testLogger(), DBManager: manager, Durable: true})
+	m.Register("echo", func(ctx *JobContext, payload json.RawMessage) (any, error) {
+		return payload, nil
+	})
+
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer m.Stop()
+
+	task := waitForAsyncStatus(t, m, "resume-me", AsyncCompleted)
+	if task.Result != `"hello"` {
+		t.Errorf("expected echoed payload, got %s", task.Result)
+	}
+}
+
+func TestAsyncManager_EvictsFinishedTasks(t *testing.T) {
+	for _, durable := range []bool{false, true} {
+		cfg := AsyncConfig{Logger: testLogger(), Workers: 1, MaxRetained: 2}
+		if durable {
+			cfg.DBManager = &mockDBManager{db: openAsyncTestDB(t)}
+			cfg.Durable = true
+		}
+		m := NewAsyncManager(cfg)
+		m.Register("noop", func(ctx *JobContext, payload json.RawMessage) (any, error) {
+			return nil, nil
+		})
+		if err := m.Start(); err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+
+		var ids []string
+		for i := 0; i < 3; i++ {
+			id, err := m.Run("noop", i)
+			if err != nil {
+				t.Fatalf("Run failed: %v", err)
+			}
+			waitForAsyncStatus(t, m, id, AsyncCompleted)
+			ids = append(ids, id)
+		}
+
+		m.mu.RLock()
+		_, first := m.tasks[ids[0]]
+		_, last := m.tasks[ids[2]]
+		m.mu.RUnlock()
+		if first || !last {
+			t.Errorf("durable=%v: expected only the oldest task evicted, first kept=%v last kept=%v", durable, first, last)
+		}
+
+		task, err := m.Get(ids[0])
+		switch {
+		case durable && (err != nil || task.Status != AsyncCompleted):
+			t.Errorf("expected the evicted task from the database, got %+v, %v", task, err)
+		case !durable && !errors.Is(err, ErrAsyncTaskNotFound):
+			t.Errorf("expected ErrAsyncTaskNotFound for an evicted in-memory task, got %v", err)
+		}
+		m.Stop()
+	}
+}
+
+func TestAsyncManager_RetryRejectsNonFailed(t *testing.T) {
+	m := NewAsyncManager(AsyncConfig{Logger: testLogger()})
+	m.Register("noop", func(ctx *JobContext, payload json.RawMessage) (any, error) {
+		return nil, nil
+	})
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer m.Stop()
+
+	id, err := m.Run("noop", nil)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	waitForAsyncStatus(t, m, id, AsyncCompleted)
+
+	if err := m.Retry(id); err == nil {
+		t.Error("expected error when retrying a completed task")
+	}
+	if err := m.Retry("unknown"); !errors.Is(err, ErrAsyncTaskNotFound) {
+		t.Errorf("expected ErrAsyncTaskNotFound, got %v", err)
+	}
+}
+
+func TestAsyncManager_PriorityOrder(t *testing.T) {
+	m := NewAsyncManager(AsyncConfig{Logger: testLogger(), Workers: 1})
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	m.Register("block", func(ctx *JobContext, payload json.RawMessage) (any, error) {
+		close(started)
+		<-release
+		return nil, nil
+	})
+
+	var mu sync.Mutex
+	var order []string
+	m.Register("record", func(ctx *JobContext, payload json.RawMessage) (any, error) {
+		var label string
+		if err := json.Unmarshal(payload, &label); err != nil {
+			return nil, err
+		}
+		mu.Lock()
+		order = append(order, label)
+		mu.Unlock()
+		return nil, nil
+	})
+
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer m.Stop()
+
+	// Occupy the only worker so the next tasks queue up
+	if _, err := m.Run("block", nil); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	<-started
+
+	low, _ := m.Run("record", "low", AsyncPriority(-1))
+	m.Run("record", "normal")
+	high, _ := m.Run("record", "high", AsyncPriority(10))
+	close(release)
+
+	waitForAsyncStatus(t, m, low, AsyncCompleted)
+	waitForAsyncStatus(t, m, high, AsyncCompleted)
+
+	mu.Lock()
+	defer mu.Unlock()
+	expected := []string{"high", "normal", "low"}
+	if strings.Join(order, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected order %v, got %v", expected, order)
+	}
+}
+
+func TestAsyncManager_QueueFull(t *testing.T) {
+	m := NewAsyncManager(AsyncConfig{Logger: testLogger(), Workers: 1, QueueSize: 1})
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	m.Register("block", func(ctx *JobContext, payload json.RawMessage) (any, error) {
+		started <- struct{}{}
+		<-release
+		return nil, nil
+	})
+
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer m.Stop()
+	defer close(release)
+
+	if _, err := m.Run("block", nil); err != nil {
+		t.Fatalf("first Run failed: %v", err)
+	}
+	<-started
+
+	if _, err := m.Run("block", nil); err != nil {
+		t.Fatalf("second Run should queue: %v", err)
+	}
+	if m.QueueLen() != 1 {
+		t.Errorf("expected 1 queued task, got %d", m.QueueLen())
+	}
+
+	if _, err := m.Run("block", nil); !errors.Is(err, ErrAsyncQueueFull) {
+		t.Errorf("expected ErrAsyncQueueFull, got %v", err)
+	}
+}
+
+func TestAsyncManager_Delayed(t *testing.T) {
+	m := NewAsyncManager(AsyncConfig{Logger: testLogger(), QueueSize: 1})
+	m.Register("echo", func(ctx *JobContext, payload json.RawMessage) (any, error) {
+		return payload, nil
+	})
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer m.Stop()
+
+	id, err := m.Run("echo", "later", AsyncDelay(100*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	canceled, err := m.Run("echo", "never", AsyncDelay(time.Hour))
+	if err != nil {
+		t.Fatalf("delayed tasks shouldn't take queue slots: %v", err)
+	}
+
+	task, err := m.Get(id)
+	if err != nil || task.Status != AsyncPending || task.RunAt == nil || m.QueueLen() != 0 {
+		t.Fatalf("expected a pending task waiting outside the queue, got %+v (%v)", task, err)
+	}
+	task = waitForAsyncStatus(t, m, id, AsyncCompleted)
+	if task.StartedAt.Before(*task.RunAt) {
+		t.Errorf("task started at %v, before its run time %v", task.StartedAt, task.RunAt)
+	}
+
+	if err := m.Cancel(canceled); err != nil {
+		t.Fatalf("Cancel failed: %v", err)
+	}
+	waitForAsyncStatus(t, m, canceled, AsyncCanceled)
+}
+
+func TestAsyncManager_DurableDelayedResume(t *testing.T) {
+	manager := &mockDBManager{db: openAsyncTestDB(t)}
+	echo := func(ctx *JobContext, payload json.RawMessage) (any, error) {
+		return payload, nil
+	}
+
+	first := NewAsyncManager(AsyncConfig{Logger: testLogger(), DBManager: manager, Durable: true})
+	first.Register("echo", echo)
+	if err := first.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	id, err := first.Run("echo", "reminder", AsyncRunAt(time.Now().Add(200*time.Millisecond)))
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	first.Stop()
+
+	// The next process picks the task up and runs it when due
+	second := NewAsyncManager(AsyncConfig{Logger: testLogger(), DBManager: manager, Durable: true})
+	second.Register("echo", echo)
+	if err := second.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer second.Stop()
+
+	task := waitForAsyncStatus(t, second, id, AsyncCompleted)
+	if task.Result != `"reminder"` || task.StartedAt.Before(*task.RunAt) {
+		t.Errorf("unexpected task %+v", task)
+	}
+}
+
+func TestAsyncManager_Drain(t *testing.T) {
+	t.Run("running tasks finish", func(t *testing.T) {
+		m := NewAsyncManager(AsyncConfig{Logger: testLogger(), Workers: 1})
+		release := make(chan struct{})
+		started := make(chan struct{}, 1)
+		m.Register("block", func(ctx *JobContext, payload json.RawMessage) (any, error) {
+			started <- struct{}{}
+			<-release
+			return "done", nil
+		})
+		if err := m.Start(); err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+		defer m.Stop()
+
+		running, _ := m.Run("block", nil)
+		<-started
+		queued, _ := m.Run("block", nil)
+		delayed, _ := m.Run("block", nil, AsyncDelay(time.Hour))
+
+		time.AfterFunc(50*time.Millisecond, func() { close(release) })
+		if err := m.Drain(context.Background()); err != nil {
+			t.Fatalf("Drain failed: %v", err)
+		}
+		if task, _ := m.Get(running); task.Status != AsyncCompleted {
+			t.Errorf("expected the running task to complete, got %s", task.Status)
+		}
+		for _, id := range []string{queued, delayed} {
+			if task, _ := m.Get(id); task.Status != AsyncCanceled {
+				t.Errorf("expected waiting task %s to be canceled, got %s", id, task.Status)
+			}
+		}
+		if _, err := m.Run("block", nil); !errors.Is(err, ErrAsyncNotRunning) {
+			t.Errorf("expected ErrAsyncNotRunning while draining, got %v", err)
+		}
+	})
+
+	t.Run("deadline cancels the rest", func(t *testing.T) {
+		m := NewAsyncManager(AsyncConfig{Logger: testLogger()})
+		started := make(chan struct{}, 1)
+		m.Register("stuck", func(ctx *JobContext, payload json.RawMessage) (any, error) {
+			started <- struct{}{}
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+		if err := m.Start(); err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+		defer m.Stop()
+
+		id, _ := m.Run("stuck", nil)
+		<-started
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		if err := m.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected the drain deadline error, got %v", err)
+		}
+		if task, _ := m.Get(id); task.Status != AsyncCanceled {
+			t.Errorf("expected the cut-off task to be canceled, got %s", task.Status)
+		}
+	})
+}
@@ -0,0 +1,398 @@
+package cartridge
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// AuditCleanupJob is the cron job ID of the audit log retention cleanup.
+const AuditCleanupJob = "cartridge_audit_cleanup"
+
+// defaultAuditQueryLimit caps AuditQuery results when Limit is 0.
+const defaultAuditQueryLimit = 100
+
+// AuditRecord is one audit log entry. Records are never updated; they are
+// only removed by retention cleanup.
+type AuditRecord struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+	Actor     string    `gorm:"size:255;index" json:"actor,omitempty"` // Principal ID; empty for anonymous callers
+	Action    string    `gorm:"size:255;index" json:"action"`
+	Target    string    `gorm:"size:255;index" json:"target,omitempty"`
+	IP        string    `gorm:"size:64" json:"ip,omitempty"`
+	RequestID string    `gorm:"size:64" json:"request_id,omitempty"`
+	Metadata  string    `gorm:"type:text" json:"metadata,omitempty"` // JSON object
+}
+
+// TableName keeps the audit log out of the application's own tables.
+func (AuditRecord) TableName() string {
+	return "cartridge_audit_log"
+}
+
+// Meta decodes Metadata. It returns nil when the record has none.
+func (r AuditRecord) Meta() map[string]any {
+	if r.Metadata == "" {
+		return nil
+	}
+	var meta map[string]any
+	if err := json.Unmarshal([]byte(r.Metadata), &meta); err != nil {
+		return nil
+	}
+	return meta
+}
+
+// AuditQuery filters audit records. Zero fields match everything.
+type AuditQuery struct {
+	Actor  string
+	Action string
+	Target string
+	Since  time.Time // inclusive
+	Until  time.Time // exclusive
+	// BeforeID pages backwards: pass the ID of the last record of the
+	// previous page.
+	BeforeID uint
+	// Limit caps the records returned. Default: 100
+	Limit int
+}
+
+// matches reports whether rec passes the filter.
+func (q AuditQuery) matches(rec AuditRecord) bool {
+	return (q.Actor == "" || rec.Actor == q.Actor) &&
+		(q.Action == "" || rec.Action == q.Action) &&
+		(q.Target == "" || rec.Target == q.Target) &&
+		(q.Since.IsZero() || !rec.CreatedAt.Before(q.Since)) &&
+		(q.Until.IsZero() || rec.CreatedAt.Before(q.Until)) &&
+		(q.BeforeID == 0 || rec.ID < q.BeforeID)
+}
+
+func (q AuditQuery) limit() int {
+	if q.Limit <= 0 {
+		return defaultAuditQueryLimit
+	}
+	return q.Limit
+}
+
+// AuditStore persists audit records. Stores are append-only: the only way
+// to remove records is DeleteBefore, used by retention cleanup.
+type AuditStore interface {
+	// Append stores rec and sets its ID.
+	Append(ctx context.Context, rec *AuditRecord) error
+	// Query returns matching records, newest first.
+	Query(ctx context.Context, q AuditQuery) ([]AuditRecord, error)
+	// DeleteBefore removes records older than cutoff.
+	DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// AuditConfig configures the audit log enabled with WithAuditLog.
+type AuditConfig struct {
+	// Store keeps the records. Default: the cartridge_audit_log table
+	// (NewDatabaseAuditStore)
+	Store AuditStore
+
+	// Retention removes records older than this once a day.
+	// Default: 0 (keep records forever)
+	Retention time.Duration
+}
+
+// AuditCleanup returns a daily cron job removing records older than
+// retention from store.
+func AuditCleanup(store AuditStore, retention time.Duration) CronJob {
+	return CronJob{
+		ID:            AuditCleanupJob,
+		Schedule:      "@daily",
+		SkipIfRunning: true,
+		Handler: func(ctx *JobContext) error {
+			n, err := store.DeleteBefore(ctx, time.Now().Add(-retention))
+			if err != nil {
+				return err
+			}
+			if n > 0 {
+				ctx.Logger.Info("expired audit records removed", "count", n)
+			}
+			return nil
+		},
+	}
+}
+
+// Audit records that the caller performed action on target, e.g.
+//
+//	ctx.Audit("product.delete", "product:42", map[string]any{"name": product.Name})
+//
+// The actor is the route's authorized Principal or, on routes without
+// authorization, the principal from ServerConfig.PrincipalResolver. The
+// client IP, request ID and time are recorded alongside. metadata may be
+// nil. It fails when the audit log is not enabled (see WithAuditLog).
+func (ctx *Context) Audit(action, target string, metadata map[string]any) error {
+	if ctx.audit == nil {
+		return fmt.Errorf("cartridge: audit log is not enabled (use WithAuditLog)")
+	}
+
+	rec := &AuditRecord{
+		CreatedAt: time.Now().UTC(),
+		Actor:     ctx.auditActor(),
+		Action:    action,
+		Target:    target,
+		IP:        ctx.IP(),
+	}
+	rec.RequestID, _ = ctx.Locals("requestid").(string)
+	if len(metadata) > 0 {
+		data, err := json.Marshal(metadata)
+		if err != nil {
+			return fmt.Errorf("cartridge: encode audit metadata: %w", err)
+		}
+		rec.Metadata = string(data)
+	}
+	if err := ctx.audit.Append(ctx.UserContext(), rec); err != nil {
+		return fmt.Errorf("cartridge: append audit record: %w", err)
+	}
+	return nil
+}
+
+// auditActor returns the caller's principal ID, or "" when anonymous.
+func (ctx *Context) auditActor() string {
+	if p := ctx.Principal(); p != nil {
+		return p.ID
+	}
+	resolve := ctx.principals
+	if resolve == nil {
+		resolve = DefaultPrincipalResolver
+	}
+	if p, err := resolve(ctx); err == nil && p != nil {
+		return p.ID
+	}
+	return ""
+}
+
+// DatabaseAuditStore keeps audit records in the cartridge_audit_log table.
+// Works with any GORM-supported database (SQLite, PostgreSQL, MySQL).
+type DatabaseAuditStore struct {
+	dbManager DBManager
+}
+
+// NewDatabaseAuditStore creates a database-backed audit store.
+// The cartridge_audit_log table is auto-migrated if it doesn't exist.
+func NewDatabaseAuditStore(dbManager DBManager) (*DatabaseAuditStore, error) {
+	db, err := dbManager.Connect()
+	if err != nil {
+		return nil, fmt.Errorf("cartridge: connect database: %w", err)
+	}
+	if err := db.AutoMigrate(&AuditRecord{}); err != nil {
+		return nil, fmt.Errorf("cartridge: migrate audit log: %w", err)
+	}
+	return &DatabaseAuditStore{dbManager: dbManager}, nil
+}
+
+// Append inserts rec.
+func (s *DatabaseAuditStore) Append(ctx context.Context, rec *AuditRecord) error {
+	db, err := s.dbManager.Connect()
+	if err != nil {
+		return err
+	}
+	return db.WithContext(ctx).Create(rec).Error
+}
+
+// Query returns matching records, newest first.
+func (s *DatabaseAuditStore) Query(ctx context.Context, q AuditQuery) ([]AuditRecord, error) {
+	db, err := s.dbManager.Connect()
+	if err != nil {
+		return nil, err
+	}
+
+	tx := db.WithContext(ctx).Model(&AuditRecord{})
+	if q.Actor != "" {
+		tx = tx.Where("actor = ?", q.Actor)
+	}
+	if q.Action != "" {
+		tx = tx.Where("action = ?", q.Action)
+	}
+	if q.Target != "" {
+		tx = tx.Where("target = ?", q.Target)
+	}
+	if !q.Since.IsZero() {
+		tx = tx.Where("created_at >= ?", q.Since.UTC())
+	}
+	if !q.Until.IsZero() {
+		tx = tx.Where("created_at < ?", q.Until.UTC())
+	}
+	if q.BeforeID != 0 {
+		tx = tx.Where("id < ?", q.BeforeID)
+	}
+
+	var records []AuditRecord
+	err = tx.Order("id DESC").Limit(q.limit()).Find(&records).Error
+	return records, err
+}
+
+// DeleteBefore removes records older than cutoff.
+func (s *DatabaseAuditStore) DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	db, err := s.dbManager.Connect()
+	if err != nil {
+		return 0, err
+	}
+	result := db.WithContext(ctx).Where("created_at < ?", cutoff.UTC()).Delete(&AuditRecord{})
+	return result.RowsAffected, result.Error
+}
+
+// FileAuditStore appends audit records to a file as JSON lines, e.g. for
+// shipping to a log pipeline or keeping them off the main database. Queries
+// scan the whole file, so prefer the database store for large logs.
+type FileAuditStore struct {
+	path string
+
+	mu     sync.Mutex
+	file   *os.File
+	nextID uint
+}
+
+// NewFileAuditStore opens (or creates) the audit file at path.
+func NewFileAuditStore(path string) (*FileAuditStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("cartridge: create audit log directory: %w", err)
+	}
+	s := &FileAuditStore{path: path, nextID: 1}
+	if err := s.scan(func(rec AuditRecord) {
+		s.nextID = max(s.nextID, rec.ID+1)
+	}); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("cartridge: open audit log: %w", err)
+	}
+	s.file = file
+	return s, nil
+}
+
+// Append writes rec as one line and syncs it to disk.
+func (s *FileAuditStore) Append(_ context.Context, rec *AuditRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec.ID = s.nextID
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	if err := s.file.Sync(); err != nil {
+		return err
+	}
+	s.nextID++
+	return nil
+}
+
+// Query returns matching records, newest first.
+func (s *FileAuditStore) Query(_ context.Context, q AuditQuery) ([]AuditRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var records []AuditRecord
+	err := s.scan(func(rec AuditRecord) {
+		if q.matches(rec) {
+			records = append(records, rec)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.Reverse(records)
+	if len(records) > q.limit() {
+		records = records[:q.limit()]
+	}
+	return records, nil
+}
+
+// DeleteBefore rewrites the file without records older than cutoff. The
+// new file replaces the old one atomically.
+func (s *FileAuditStore) DeleteBefore(_ context.Context, cutoff time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	var n int64
+	var encErr error
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	err = s.scan(func(rec AuditRecord) {
+		if rec.CreatedAt.Before(cutoff) {
+			n++
+			return
+		}
+		if encErr == nil {
+			encErr = enc.Encode(rec)
+		}
+	})
+	if err == nil {
+		err = encErr
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil || n == 0 {
+		return 0, err
+	}
+
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return 0, err
+	}
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return n, fmt.Errorf("cartridge: reopen audit log: %w", err)
+	}
+	s.file.Close()
+	s.file = file
+	return n, nil
+}
+
+// Close closes the audit file.
+func (s *FileAuditStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// scan calls fn for each record in the file, oldest first.
+func (s *FileAuditStore) scan(fn func(AuditRecord)) error {
+	file, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("cartridge: read audit log: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16<<20)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var rec AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return fmt.Errorf("cartridge: read audit log: %w", err)
+		}
+		fn(rec)
+	}
+	return scanner.Err()
+}
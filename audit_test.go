@@ -0,0 +1,119 @@
+package cartridge
+
+import (
+	"context"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestContextAudit(t *testing.T) {
+	store, err := NewDatabaseAuditStore(&mockDBManager{db: openAsyncTestDB(t)})
+	if err != nil {
+		t.Fatalf("NewDatabaseAuditStore failed: %v", err)
+	}
+
+	cfg := DefaultServerConfig()
+	cfg.EnableStaticAssets = false
+	cfg.EnableRequestLogger = false
+	cfg.EnableSecFetchSite = false
+	cfg.Config = &testConfig{}
+	cfg.Logger = testLogger()
+	cfg.DBManager = &testDBManager{}
+	cfg.ErrorHandler = DefaultErrorHandler(testLogger(), false)
+	cfg.PrincipalResolver = func(ctx *Context) (*Principal, error) {
+		return &Principal{ID: "user-1"}, nil
+	}
+
+	srv, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	srv.SetAuditStore(store)
+	srv.Post("/products/:id/delete", func(ctx *Context) error {
+		if err := ctx.Audit("product.delete", "product:"+ctx.Params("id"), map[string]any{"reason": "spam"}); err != nil {
+			return err
+		}
+		return ctx.SendStatus(204)
+	})
+
+	resp, err := srv.App().Test(httptest.NewRequest("POST", "/products/42/delete", nil))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.StatusCode != 204 {
+		t.Fatalf("expected 204, got %d", resp.StatusCode)
+	}
+
+	records, err := srv.AuditStore().Query(context.Background(), AuditQuery{Action: "product.delete"})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records))
+	}
+	rec := records[0]
+	if rec.Actor != "user-1" || rec.Target != "product:42" || rec.IP == "" || rec.RequestID == "" {
+		t.Errorf("unexpected record %+v", rec)
+	}
+	if rec.Meta()["reason"] != "spam" {
+		t.Errorf("expected metadata to round-trip, got %q", rec.Metadata)
+	}
+}
+
+func TestAuditStores(t *testing.T) {
+	dbStore, err := NewDatabaseAuditStore(&mockDBManager{db: openAsyncTestDB(t)})
+	if err != nil {
+		t.Fatalf("NewDatabaseAuditStore failed: %v", err)
+	}
+	fileStore, err := NewFileAuditStore(filepath.Join(t.TempDir(), "audit", "audit.jsonl"))
+	if err != nil {
+		t.Fatalf("NewFileAuditStore failed: %v", err)
+	}
+	defer fileStore.Close()
+
+	for name, store := range map[string]AuditStore{"database": dbStore, "file": fileStore} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now().UTC()
+			for _, rec := range []*AuditRecord{
+				{CreatedAt: now.Add(-48 * time.Hour), Actor: "alice", Action: "login"},
+				{CreatedAt: now.Add(-time.Hour), Actor: "bob", Action: "login"},
+				{CreatedAt: now, Actor: "alice", Action: "product.update", Target: "product:1"},
+			} {
+				if err := store.Append(ctx, rec); err != nil {
+					t.Fatalf("Append failed: %v", err)
+				}
+				if rec.ID == 0 {
+					t.Fatal("expected Append to set the ID")
+				}
+			}
+
+			records, err := store.Query(ctx, AuditQuery{Actor: "alice"})
+			if err != nil {
+				t.Fatalf("Query failed: %v", err)
+			}
+			if len(records) != 2 || records[0].Action != "product.update" {
+				t.Fatalf("expected alice's records newest first, got %+v", records)
+			}
+
+			page, err := store.Query(ctx, AuditQuery{Limit: 1, BeforeID: records[0].ID})
+			if err != nil {
+				t.Fatalf("Query failed: %v", err)
+			}
+			if len(page) != 1 || page[0].Actor != "bob" {
+				t.Fatalf("expected the next page to hold bob's login, got %+v", page)
+			}
+
+			n, err := store.DeleteBefore(ctx, now.Add(-24*time.Hour))
+			if err != nil || n != 1 {
+				t.Fatalf("expected 1 record removed, got %d (%v)", n, err)
+			}
+			records, _ = store.Query(ctx, AuditQuery{Since: now.Add(-72 * time.Hour)})
+			if len(records) != 2 {
+				t.Errorf("expected 2 records left, got %d", len(records))
+			}
+		})
+	}
+}
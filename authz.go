@@ -0,0 +1,189 @@
+package cartridge
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// principalLocalsKey stores the authorized *Principal in fiber locals.
+const principalLocalsKey = "cartridge_principal"
+
+// Principal is the authenticated caller as seen by route authorization.
+type Principal struct {
+	ID          string
+	Roles       []string
+	Permissions []string
+}
+
+// HasRole reports whether the principal has the role.
+func (p *Principal) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+// Can reports whether the principal holds the permission. Granted
+// permissions may end in "*" to cover a namespace: "products:*" grants
+// "products:write", and "*" grants everything.
+func (p *Principal) Can(permission string) bool {
+	for _, granted := range p.Permissions {
+		if granted == permission {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(granted, "*"); ok && strings.HasPrefix(permission, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// PrincipalResolver loads the caller's identity, roles and permissions.
+// It returns nil when the request is not authenticated.
+type PrincipalResolver func(ctx *Context) (*Principal, error)
+
+// AuthPolicy is an authorization requirement for RouteConfig.Authorize.
+type AuthPolicy struct {
+	name  string
+	check func(ctx *Context, p *Principal) bool
+}
+
+// Name returns the policy name shown in route introspection.
+func (a *AuthPolicy) Name() string {
+	return a.name
+}
+
+// Policy requires the named permission, unless a policy function was
+// registered under that name with Server.DefinePolicy, in which case the
+// function decides:
+//
+//	s.Post("/products", createProduct, &cartridge.RouteConfig{
+//	    Authorize: cartridge.Policy("products:write"),
+//	})
+func Policy(name string) *AuthPolicy {
+	return &AuthPolicy{name: name}
+}
+
+// PolicyFunc creates an inline policy, e.g. for ownership checks.
+func PolicyFunc(name string, fn func(ctx *Context, p *Principal) bool) *AuthPolicy {
+	return &AuthPolicy{name: name, check: fn}
+}
+
+// DefinePolicy registers fn as the check for Policy(name). Use it when a
+// permission alone isn't enough, e.g. plan limits or feature flags. Define
+// policies while mounting routes, before the server starts.
+func (s *Server) DefinePolicy(name string, fn func(ctx *Context, p *Principal) bool) {
+	if s.policies == nil {
+		s.policies = make(map[string]func(ctx *Context, p *Principal) bool)
+	}
+	s.policies[name] = fn
+}
+
+// Principal returns the caller authorized for this route, or nil when the
+// route has no Roles or Authorize requirement.
+func (ctx *Context) Principal() *Principal {
+	p, _ := ctx.Locals(principalLocalsKey).(*Principal)
+	return p
+}
+
+// authorize returns middleware enforcing the route's Roles and Authorize.
+// Unauthenticated callers get 401; authenticated callers lacking a role or
+// failing the policy get 403.
+func (s *Server) authorize(roles []string, policy *AuthPolicy) fiber.Handler {
+	resolve := s.cfg.PrincipalResolver
+	if resolve == nil {
+		resolve = DefaultPrincipalResolver
+	}
+
+	return func(c *fiber.Ctx) error {
+		ctx := s.context(c)
+		principal, err := resolve(ctx)
+		if err != nil {
+			return err
+		}
+		if principal == nil {
+			return ErrUnauthorized("authentication required")
+		}
+		if len(roles) > 0 && !slices.ContainsFunc(roles, principal.HasRole) {
+			return ErrForbidden("insufficient role")
+		}
+		if policy != nil && !s.allows(ctx, principal, policy) {
+			return ErrForbidden("permission denied")
+		}
+		c.Locals(principalLocalsKey, principal)
+		return c.Next()
+	}
+}
+
+// allows evaluates policy for the principal.
+func (s *Server) allows(ctx *Context, p *Principal, policy *AuthPolicy) bool {
+	if policy.check != nil {
+		return policy.check(ctx, p)
+	}
+	if fn := s.policies[policy.name]; fn != nil {
+		return fn(ctx, p)
+	}
+	return p.Can(policy.name)
+}
+
+// DefaultPrincipalResolver reads the principal from verified JWT claims
+// ("sub", "roles", and "permissions" or a space-separated "scope"), then
+// from an API token (its user, with its scopes as permissions), falling
+// back to the user ID in the session cookie, which carries no roles. Set
+// ServerConfig.PrincipalResolver to load roles from the database instead.
+func DefaultPrincipalResolver(ctx *Context) (*Principal, error) {
+	if claims := ctx.JWTClaims(); claims != nil {
+		p := &Principal{
+			ID:          claims.Subject(),
+			Roles:       claimStrings(claims["roles"]),
+			Permissions: claimStrings(claims["permissions"]),
+		}
+		if scope, ok := claims["scope"].(string); ok {
+			p.Permissions = append(p.Permissions, strings.Fields(scope)...)
+		}
+		return p, nil
+	}
+	if token := ctx.APIToken(); token != nil {
+		return &Principal{ID: token.UserID, Permissions: token.ScopeList()}, nil
+	}
+	if ctx.Auth != nil {
+		if id, ok := ctx.Auth.GetAuthCookie(ctx.Ctx); ok {
+			return &Principal{ID: id}, nil
+		}
+	}
+	return nil, nil
+}
+
+// claimStrings converts a JWT claim holding a string or a list of strings.
+func claimStrings(v any) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// RouteInfo describes a registered route and its authorization requirements.
+type RouteInfo struct {
+	Name   string   `json:"name,omitempty"`
+	Method string   `json:"method"`
+	Path   string   `json:"path"`
+	Roles  []string `json:"roles,omitempty"`
+	Policy string   `json:"policy,omitempty"`
+	// Feature is the flag gating the route, if any.
+	Feature string `json:"feature,omitempty"`
+}
+
+// Routes returns the routes registered through the Server, in order.
+func (s *Server) Routes() []RouteInfo {
+	return slices.Clone(s.routes)
+}
@@ -0,0 +1,124 @@
+package cartridge
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestRouteAuthorization(t *testing.T) {
+	cfg := DefaultServerConfig()
+	cfg.EnableStaticAssets = false
+	cfg.EnableRequestLogger = false
+	cfg.EnableSecFetchSite = false
+	cfg.Config = &testConfig{}
+	cfg.Logger = testLogger()
+	cfg.DBManager = &testDBManager{}
+	cfg.ErrorHandler = DefaultErrorHandler(testLogger(), false)
+
+	srv, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	auth, err := NewJWTAuth(JWTConfig{Secret: []byte("test-secret")})
+	if err != nil {
+		t.Fatalf("NewJWTAuth failed: %v", err)
+	}
+	// Authenticate when a token is present; authorization decides what's required
+	optionalJWT := func(c *fiber.Ctx) error {
+		if c.Get(fiber.HeaderAuthorization) == "" {
+			return c.Next()
+		}
+		return auth.Middleware()(c)
+	}
+
+	ok := func(ctx *Context) error { return ctx.SendString(ctx.Principal().ID) }
+	srv.DefinePolicy("billing:export", func(ctx *Context, p *Principal) bool {
+		return p.HasRole("owner") || ctx.Query("month") == "current"
+	})
+	srv.Post("/products", ok, &RouteConfig{
+		CustomMiddleware: []fiber.Handler{optionalJWT},
+		Authorize:        Policy("products:write"),
+	})
+	srv.Get("/admin", ok, &RouteConfig{
+		CustomMiddleware: []fiber.Handler{optionalJWT},
+		Roles:            []string{"admin", "owner"},
+	})
+	srv.Get("/billing/export", ok, &RouteConfig{
+		CustomMiddleware: []fiber.Handler{optionalJWT},
+		Authorize:        Policy("billing:export"),
+	})
+	srv.Get("/users/:id", ok, &RouteConfig{
+		CustomMiddleware: []fiber.Handler{optionalJWT},
+		Authorize: PolicyFunc("users:self", func(ctx *Context, p *Principal) bool {
+			return ctx.Params("id") == p.ID
+		}),
+	})
+	srv.Get("/public", func(ctx *Context) error { return ctx.SendString("hi") })
+
+	token := func(claims JWTClaims) string {
+		signed, err := auth.Sign(claims)
+		if err != nil {
+			t.Fatalf("Sign failed: %v", err)
+		}
+		return signed
+	}
+	editor := token(JWTClaims{"sub": "7", "roles": []string{"editor"}, "scope": "products:* orders:read"})
+	owner := token(JWTClaims{"sub": "1", "roles": []any{"owner"}})
+
+	tests := []struct {
+		method, path, token string
+		want                int
+	}{
+		{"POST", "/products", "", fiber.StatusUnauthorized},
+		{"POST", "/products", owner, fiber.StatusForbidden},
+		{"POST", "/products", editor, fiber.StatusOK},
+		{"GET", "/admin", editor, fiber.StatusForbidden},
+		{"GET", "/admin", owner, fiber.StatusOK},
+		{"GET", "/billing/export", editor, fiber.StatusForbidden},
+		{"GET", "/billing/export?month=current", editor, fiber.StatusOK},
+		{"GET", "/billing/export", owner, fiber.StatusOK},
+		{"GET", "/users/7", editor, fiber.StatusOK},
+		{"GET", "/users/1", editor, fiber.StatusForbidden},
+		{"GET", "/public", "", fiber.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		req.Header.Set("Accept", "application/json")
+		if tt.token != "" {
+			req.Header.Set(fiber.HeaderAuthorization, "Bearer "+tt.token)
+		}
+		resp, err := srv.App().Test(req)
+		if err != nil {
+			t.Fatalf("%s %s: request failed: %v", tt.method, tt.path, err)
+		}
+		if resp.StatusCode != tt.want {
+			t.Errorf("%s %s: expected %d, got %d", tt.method, tt.path, tt.want, resp.StatusCode)
+		}
+	}
+
+	routes := srv.Routes()
+	if len(routes) != 5 {
+		t.Fatalf("expected 5 routes, got %d", len(routes))
+	}
+	if routes[0].Policy != "products:write" || len(routes[1].Roles) != 2 || routes[4].Policy != "" {
+		t.Errorf("unexpected route info: %+v", routes)
+	}
+}
+
+func TestPrincipal_Can(t *testing.T) {
+	p := &Principal{Permissions: []string{"products:*", "orders:read"}}
+	for perm, want := range map[string]bool{
+		"products:write": true,
+		"orders:read":    true,
+		"orders:write":   false,
+	} {
+		if got := p.Can(perm); got != want {
+			t.Errorf("%s: expected %v, got %v", perm, want, got)
+		}
+	}
+	if !(&Principal{Permissions: []string{"*"}}).Can("anything") {
+		t.Error("expected * to grant everything")
+	}
+}
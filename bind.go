@@ -0,0 +1,74 @@
+package cartridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// BindJSON decodes the JSON request body into a T and validates it using
+// `validate` struct tags (see Validate).
+//
+// Malformed bodies return a 400 *fiber.Error; failed validation returns
+// ValidationErrors, which DefaultErrorHandler renders as 422 with per-field messages.
+//
+//	func createUser(ctx *cartridge.Context) error {
+//	    req, err := cartridge.BindJSON[CreateUserRequest](ctx)
+//	    if err != nil {
+//	        return err
+//	    }
+//	    ...
+//	}
+func BindJSON[T any](ctx *Context) (T, error) {
+	var v T
+
+	body := bytes.TrimSpace(ctx.Body())
+	if len(body) == 0 {
+		return v, fiber.NewError(fiber.StatusBadRequest, "request body is empty")
+	}
+
+	if err := json.Unmarshal(body, &v); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			return v, fiber.NewError(fiber.StatusBadRequest,
+				fmt.Sprintf("invalid JSON body: %s must be %s", typeErr.Field, typeErr.Type))
+		}
+		return v, fiber.NewError(fiber.StatusBadRequest, "invalid JSON body: "+err.Error())
+	}
+
+	if err := Validate(v); err != nil {
+		return v, err
+	}
+	return v, nil
+}
+
+// validatedBodyKey stores the body decoded by ValidateBody in fiber locals.
+const validatedBodyKey = "cartridge_validated_body"
+
+// ValidateBody returns a middleware that binds and validates the JSON body as
+// a T before the handler runs. Invalid payloads are rejected with the same
+// 400/422 errors as BindJSON; the handler reads the result with ValidatedBody.
+//
+//	s.Post("/products", createProduct, &cartridge.RouteConfig{
+//	    Validate: cartridge.ValidateBody[CreateProductRequest](),
+//	})
+func ValidateBody[T any]() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		v, err := BindJSON[T](&Context{Ctx: c})
+		if err != nil {
+			return err
+		}
+		c.Locals(validatedBodyKey, v)
+		return c.Next()
+	}
+}
+
+// ValidatedBody returns the body bound by ValidateBody. The second result is
+// false if the route has no ValidateBody[T] middleware.
+func ValidatedBody[T any](ctx *Context) (T, bool) {
+	v, ok := ctx.Locals(validatedBodyKey).(T)
+	return v, ok
+}
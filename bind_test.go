@@ -0,0 +1,134 @@
+package cartridge
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type testCreateUser struct {
+	Name  string `json:"name" validate:"required"`
+	Email string `json:"email" validate:"required,email"`
+}
+
+func TestBindJSON(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: DefaultErrorHandler(testLogger(), false)})
+	app.Post("/users", func(c *fiber.Ctx) error {
+		req, err := BindJSON[testCreateUser](&Context{Ctx: c})
+		if err != nil {
+			return err
+		}
+		return c.SendString(req.Name + " <" + req.Email + ">")
+	})
+
+	post := func(body string) *http.Response {
+		req, _ := http.NewRequest("POST", "/users", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		return resp
+	}
+
+	t.Run("binds valid body", func(t *testing.T) {
+		resp := post(`{"name":"Ada","email":"ada@example.com"}`)
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != 200 || string(body) != "Ada <ada@example.com>" {
+			t.Errorf("unexpected response %d: %s", resp.StatusCode, body)
+		}
+	})
+
+	t.Run("rejects malformed JSON", func(t *testing.T) {
+		if resp := post(`{"name":`); resp.StatusCode != 400 {
+			t.Errorf("expected 400, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("rejects empty body", func(t *testing.T) {
+		if resp := post(""); resp.StatusCode != 400 {
+			t.Errorf("expected 400, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("returns field errors", func(t *testing.T) {
+		resp := post(`{"name":"","email":"bad"}`)
+		if resp.StatusCode != fiber.StatusUnprocessableEntity {
+			t.Fatalf("expected 422, got %d", resp.StatusCode)
+		}
+
+		var payload struct {
+			Fields map[string]string `json:"fields"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if payload.Fields["name"] == "" || payload.Fields["email"] == "" {
+			t.Errorf("expected name and email errors, got %v", payload.Fields)
+		}
+	})
+}
+
+func TestValidateBody(t *testing.T) {
+	cfg := DefaultServerConfig()
+	cfg.EnableStaticAssets = false
+	cfg.EnableRequestLogger = false
+	cfg.EnableSecFetchSite = false
+	cfg.Config = &testConfig{}
+	cfg.Logger = testLogger()
+	cfg.DBManager = &testDBManager{}
+
+	srv, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	handlerRan := false
+	srv.Post("/users", func(ctx *Context) error {
+		handlerRan = true
+		req, ok := ValidatedBody[testCreateUser](ctx)
+		if !ok {
+			return ctx.SendStatus(fiber.StatusInternalServerError)
+		}
+		return ctx.SendString(req.Name)
+	}, &RouteConfig{Validate: ValidateBody[testCreateUser]()})
+
+	post := func(body string) *http.Response {
+		req, _ := http.NewRequest("POST", "/users", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		resp, err := srv.App().Test(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		return resp
+	}
+
+	resp := post(`{"name":"","email":"bad"}`)
+	if resp.StatusCode != fiber.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d", resp.StatusCode)
+	}
+	var payload struct {
+		Fields map[string]string `json:"fields"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if payload.Fields["name"] == "" || payload.Fields["email"] == "" {
+		t.Errorf("expected name and email errors, got %v", payload.Fields)
+	}
+	if handlerRan {
+		t.Error("handler should not run for invalid payloads")
+	}
+
+	resp = post(`{"name":"Ada","email":"ada@example.com"}`)
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != 200 || string(body) != "Ada" {
+		t.Errorf("unexpected response %d: %s", resp.StatusCode, body)
+	}
+}
@@ -0,0 +1,144 @@
+package cartridge
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DefaultMaxBodySize is ServerConfig.MaxBodySize when unset.
+const DefaultMaxBodySize = 4 << 20
+
+// errBodyTooLarge is the 413 for a request body over limit bytes.
+func errBodyTooLarge(limit int) error {
+	return NewError(fiber.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds %d bytes", limit)).
+		WithDetails(fiber.Map{"max_bytes": limit})
+}
+
+// maxBodySize returns ServerConfig.MaxBodySize or its default.
+func (s *Server) maxBodySize() int {
+	if s.cfg.MaxBodySize > 0 {
+		return s.cfg.MaxBodySize
+	}
+	return DefaultMaxBodySize
+}
+
+// routeBodyLimit returns the body limit middleware for a route, or nil when
+// the server's own limit already covers it. Buffered bodies are capped by
+// the server while reading, so a route can only lower the limit; streamed
+// bodies are checked here, against the route's limit or the server's. The
+// largest limit is kept for streamBodyPrecheck.
+func (s *Server) routeBodyLimit(routeCfg *RouteConfig) fiber.Handler {
+	limit := s.maxBodySize()
+	if routeCfg != nil && routeCfg.MaxBodySize > 0 && (routeCfg.MaxBodySize < limit || s.cfg.StreamRequestBody) {
+		limit = routeCfg.MaxBodySize
+	} else if !s.cfg.StreamRequestBody {
+		return nil
+	}
+	if limit > s.largestBody {
+		s.largestBody = limit
+	}
+	return bodyLimit(limit)
+}
+
+// bodyLimit rejects request bodies over limit bytes with 413. A streamed
+// body of unknown length (chunked) is read up to the limit, so handlers see
+// it buffered.
+func bodyLimit(limit int) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		req := c.Request()
+		if !req.IsBodyStream() {
+			if len(req.Body()) > limit {
+				return errBodyTooLarge(limit)
+			}
+			return c.Next()
+		}
+
+		if req.Header.ContentLength() > limit {
+			// The unread body is still on the connection
+			c.Context().SetConnectionClose()
+			return errBodyTooLarge(limit)
+		}
+		if req.Header.ContentLength() < 0 {
+			if err := bufferChunkedBody(c, limit); err != nil {
+				return err
+			}
+		}
+		return c.Next()
+	}
+}
+
+// bufferChunkedBody reads a streamed body of unknown length up to limit
+// bytes and replaces the stream with it, or rejects it with 413.
+func bufferChunkedBody(c *fiber.Ctx, limit int) error {
+	req := c.Request()
+	body, err := io.ReadAll(io.LimitReader(req.BodyStream(), int64(limit)+1))
+	if err != nil {
+		return ErrBadRequest("invalid request body").Wrap(err)
+	}
+	if len(body) > limit {
+		c.Context().SetConnectionClose()
+		return errBodyTooLarge(limit)
+	}
+	req.SetBody(body)
+	return nil
+}
+
+// streamBodyPrecheck rejects streamed requests whose body exceeds every
+// route's limit before global middleware, such as Decompress and the
+// request logger, reads the body. A body of unknown length (chunked) is
+// buffered up to that limit here, since only reading it tells its size.
+func (s *Server) streamBodyPrecheck(c *fiber.Ctx) error {
+	req := c.Request()
+	if !req.IsBodyStream() {
+		return c.Next()
+	}
+	limit := max(s.largestBody, s.maxBodySize())
+	if req.Header.ContentLength() > limit {
+		c.Context().SetConnectionClose()
+		return errBodyTooLarge(limit)
+	}
+	if req.Header.ContentLength() < 0 {
+		if err := bufferChunkedBody(c, limit); err != nil {
+			return err
+		}
+	}
+	return c.Next()
+}
+
+// MultipartReader returns a reader over the parts of a multipart/form-data
+// request body, to process uploads part by part instead of through
+// FormFile or SaveUpload. With ServerConfig.StreamRequestBody the parts are
+// read from the connection as they arrive, so a large upload is never held
+// in memory:
+//
+//	mr, err := ctx.MultipartReader()
+//	if err != nil {
+//	    return err
+//	}
+//	for {
+//	    part, err := mr.NextPart()
+//	    if err == io.EOF {
+//	        break
+//	    }
+//	    ...
+//	}
+//
+// Don't mix it with FormValue, FormFile or BodyParser, which parse the
+// body themselves.
+func (ctx *Context) MultipartReader() (*multipart.Reader, error) {
+	boundary := string(ctx.Request().Header.MultipartFormBoundary())
+	if boundary == "" {
+		return nil, ErrBadRequest("expected a multipart/form-data body")
+	}
+	var body io.Reader
+	if ctx.Request().IsBodyStream() {
+		body = ctx.Request().BodyStream()
+	} else {
+		body = bytes.NewReader(ctx.Request().Body())
+	}
+	return multipart.NewReader(body, boundary), nil
+}
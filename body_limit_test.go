@@ -0,0 +1,205 @@
+package cartridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// newBodyLimitServer serves on a real listener, since app.Test fails
+// requests fasthttp rejects while reading, and returns its URL.
+func newBodyLimitServer(t *testing.T, stream bool) string {
+	t.Helper()
+	cfg := DefaultServerConfig()
+	cfg.EnableStaticAssets = false
+	cfg.EnableRequestLogger = false
+	cfg.EnableSecFetchSite = false
+	cfg.Config = &testConfig{}
+	cfg.Logger = testLogger()
+	cfg.DBManager = &testDBManager{}
+	cfg.ErrorHandler = DefaultErrorHandler(testLogger(), false)
+	cfg.UploadStorage = NewMemoryStorage()
+	cfg.MaxBodySize = 1024
+	cfg.StreamRequestBody = stream
+
+	srv, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	echo := func(ctx *Context) error {
+		return ctx.SendString(string(ctx.Body()))
+	}
+	srv.Post("/echo", echo)
+	srv.Post("/small", echo, &RouteConfig{MaxBodySize: 100})
+	srv.Post("/upload", func(ctx *Context) error {
+		mr, err := ctx.MultipartReader()
+		if err != nil {
+			return err
+		}
+		var total int64
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			n, _ := io.Copy(io.Discard, part)
+			total += n
+		}
+		return ctx.JSON(fiber.Map{"bytes": total})
+	}, &RouteConfig{MaxBodySize: 1 << 20})
+	srv.Post("/save", func(ctx *Context) error {
+		file, err := ctx.SaveUpload("avatar", UploadOptions{MaxSize: 1 << 20})
+		if err != nil {
+			return err
+		}
+		return ctx.JSON(fiber.Map{"size": file.Size})
+	}, &RouteConfig{MaxBodySize: 1 << 20})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	go func() { _ = srv.App().Listener(ln) }()
+	t.Cleanup(func() { _ = srv.App().Shutdown() })
+	return "http://" + ln.Addr().String()
+}
+
+// postBody sends body to url and returns the status and response body.
+// Chunked bodies are sent without a Content-Length.
+func postBody(t *testing.T, url, contentType string, body []byte, chunked bool) (int, string) {
+	t.Helper()
+	var reader io.Reader = bytes.NewReader(body)
+	if chunked {
+		reader = io.MultiReader(reader) // hides the length from net/http
+	}
+	resp, err := http.Post(url, contentType, reader)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(data)
+}
+
+// multipartBody returns a form with one file field and its content type.
+func multipartBody(t *testing.T, field string, content []byte) ([]byte, string) {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreateFormFile(field, "file.bin")
+	if err != nil {
+		t.Fatalf("CreateFormFile failed: %v", err)
+	}
+	_, _ = part.Write(content)
+	_ = w.Close()
+	return body.Bytes(), w.FormDataContentType()
+}
+
+func TestBodyLimit(t *testing.T) {
+	url := newBodyLimitServer(t, false)
+
+	if status, body := postBody(t, url+"/echo", "text/plain", []byte("hello"), false); status != fiber.StatusOK || body != "hello" {
+		t.Errorf("expected the body echoed, got %d %q", status, body)
+	}
+	status, body := postBody(t, url+"/echo", "text/plain", make([]byte, 2048), false)
+	if status != fiber.StatusRequestEntityTooLarge || !strings.Contains(body, "Request Entity Too Large") {
+		t.Errorf("expected 413 over the server limit, got %d %s", status, body)
+	}
+
+	status, body = postBody(t, url+"/small", "text/plain", make([]byte, 200), false)
+	var resp struct {
+		Details map[string]int `json:"details"`
+	}
+	_ = json.Unmarshal([]byte(body), &resp)
+	if status != fiber.StatusRequestEntityTooLarge || resp.Details["max_bytes"] != 100 {
+		t.Errorf("expected 413 with the route limit, got %d %s", status, body)
+	}
+
+	// Buffered bodies can't exceed the server limit, whatever the route says
+	form, contentType := multipartBody(t, "file", make([]byte, 4096))
+	if status, body := postBody(t, url+"/upload", contentType, form, false); status != fiber.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413 for a buffered upload over the server limit, got %d %s", status, body)
+	}
+}
+
+func TestBodyLimit_Stream(t *testing.T) {
+	url := newBodyLimitServer(t, true)
+
+	if status, body := postBody(t, url+"/echo", "text/plain", []byte("hello"), false); status != fiber.StatusOK || body != "hello" {
+		t.Errorf("expected the body echoed, got %d %q", status, body)
+	}
+	if status, body := postBody(t, url+"/echo", "text/plain", []byte("chunked"), true); status != fiber.StatusOK || body != "chunked" {
+		t.Errorf("expected the chunked body echoed, got %d %q", status, body)
+	}
+	for _, chunked := range []bool{false, true} {
+		if status, _ := postBody(t, url+"/echo", "text/plain", make([]byte, 2048), chunked); status != fiber.StatusRequestEntityTooLarge {
+			t.Errorf("chunked=%v: expected 413 over the server limit, got %d", chunked, status)
+		}
+		if status, _ := postBody(t, url+"/small", "text/plain", make([]byte, 200), chunked); status != fiber.StatusRequestEntityTooLarge {
+			t.Errorf("chunked=%v: expected 413 over the route limit, got %d", chunked, status)
+		}
+	}
+
+	// Upload routes raise the limit and read the parts as they stream in
+	form, contentType := multipartBody(t, "file", make([]byte, 256<<10))
+	if status, body := postBody(t, url+"/upload", contentType, form, false); status != fiber.StatusOK || body != `{"bytes":262144}` {
+		t.Errorf("expected the upload streamed, got %d %s", status, body)
+	}
+	form, contentType = multipartBody(t, "avatar", make([]byte, 256<<10))
+	if status, body := postBody(t, url+"/save", contentType, form, false); status != fiber.StatusOK || body != `{"size":262144}` {
+		t.Errorf("expected SaveUpload to read the streamed form, got %d %s", status, body)
+	}
+	form, contentType = multipartBody(t, "file", make([]byte, 1<<20))
+	if status, _ := postBody(t, url+"/upload", contentType, form, false); status != fiber.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413 over the upload route's limit, got %d", status)
+	}
+
+	if status, _ := postBody(t, url+"/upload", "application/json", []byte("{}"), false); status != fiber.StatusBadRequest {
+		t.Errorf("expected 400 for a body that isn't multipart, got %d", status)
+	}
+}
+
+func TestStreamBodyPrecheck_Chunked(t *testing.T) {
+	s := &Server{cfg: &ServerConfig{MaxBodySize: 1024}, largestBody: 2048}
+	app := fiber.New(fiber.Config{StreamRequestBody: true, ErrorHandler: DefaultErrorHandler(testLogger(), false)})
+	app.Use(s.streamBodyPrecheck)
+	// Stands in for global middleware that reads the whole body
+	app.Post("/", func(c *fiber.Ctx) error {
+		return c.SendString(fmt.Sprint(len(c.Body())))
+	})
+
+	for _, tc := range []struct {
+		size int
+		want int
+	}{
+		{2048, fiber.StatusOK},
+		{2049, fiber.StatusRequestEntityTooLarge},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/", io.MultiReader(bytes.NewReader(make([]byte, tc.size))))
+		req.ContentLength = -1
+		req.TransferEncoding = []string{"chunked"}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != tc.want {
+			t.Errorf("chunked body of %d bytes: expected %d, got %d %s", tc.size, tc.want, resp.StatusCode, body)
+		}
+		if tc.want == fiber.StatusOK && string(body) != fmt.Sprint(tc.size) {
+			t.Errorf("expected the buffered body passed on, got %s", body)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package cartridge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// BulkMode selects how a bulk request is committed.
+type BulkMode int
+
+const (
+	// BulkPerItem runs each item in its own transaction. Failed items are
+	// reported without affecting the others. Default.
+	BulkPerItem BulkMode = iota
+
+	// BulkAtomic runs all items in one transaction. The first failure rolls
+	// back everything; the remaining items are reported as 424 Failed Dependency.
+	BulkAtomic
+)
+
+// BulkConfig configures a bulk endpoint.
+type BulkConfig struct {
+	// Mode selects per-item or all-or-nothing transactions. Default: BulkPerItem.
+	Mode BulkMode
+
+	// Concurrency is how many items run at once in BulkPerItem mode.
+	// BulkAtomic always runs items in order. Default: 4
+	Concurrency int
+
+	// MaxItems rejects larger batches with 413. Default: 1000
+	MaxItems int
+}
+
+// BulkItemHandler processes one item inside tx. The returned value is
+// included in the item's result; errors map to a status like handler errors
+// (e.g. ErrNotFound becomes 404).
+type BulkItemHandler[T any] func(ctx context.Context, tx *gorm.DB, item T) (any, error)
+
+// BulkResult is the outcome of one item, in request order.
+type BulkResult struct {
+	Index   int    `json:"index"`
+	Status  int    `json:"status"`
+	Data    any    `json:"data,omitempty"`
+	Error   string `json:"error,omitempty"`
+	Details any    `json:"details,omitempty"`
+}
+
+// BulkResponse is the 207 Multi-Status body returned by Bulk.
+type BulkResponse struct {
+	Results   []BulkResult `json:"results"`
+	Succeeded int          `json:"succeeded"`
+	Failed    int          `json:"failed"`
+}
+
+// errBulkRolledBack aborts the atomic transaction after an item fails.
+var errBulkRolledBack = errors.New("cartridge: bulk transaction rolled back")
+
+// Bulk returns a handler for batch endpoints. The request body is a JSON
+// array of T; each item is validated with its `validate` tags and passed to
+// fn. The response is 207 Multi-Status with a result per item.
+//
+//	s.Post("/api/products/bulk", cartridge.Bulk(cartridge.BulkConfig{},
+//	    func(ctx context.Context, tx *gorm.DB, p CreateProductRequest) (any, error) {
+//	        product := Product{Name: p.Name}
+//	        return product, tx.Create(&product).Error
+//	    }))
+func Bulk[T any](cfg BulkConfig, fn BulkItemHandler[T]) HandlerFunc {
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 4
+	}
+	if cfg.MaxItems <= 0 {
+		cfg.MaxItems = 1000
+	}
+
+	return func(ctx *Context) error {
+		var raw []json.RawMessage
+		body := bytes.TrimSpace(ctx.Body())
+		if len(body) == 0 || body[0] != '[' {
+			return ErrBadRequest("request body must be a JSON array")
+		}
+		if err := json.Unmarshal(body, &raw); err != nil {
+			return ErrBadRequest("invalid JSON body").Wrap(err)
+		}
+		if len(raw) == 0 {
+			return ErrBadRequest("request body must contain at least one item")
+		}
+		if len(raw) > cfg.MaxItems {
+			return NewError(fiber.StatusRequestEntityTooLarge,
+				fmt.Sprintf("batch has %d items, the limit is %d", len(raw), cfg.MaxItems))
+		}
+
+		results := make([]BulkResult, len(raw))
+		items := make([]T, len(raw))
+		valid := make([]bool, len(raw))
+		for i, msg := range raw {
+			results[i].Index = i
+			if err := json.Unmarshal(msg, &items[i]); err != nil {
+				results[i].setError(ErrBadRequest("invalid item").Wrap(err))
+				continue
+			}
+			if err := Validate(items[i]); err != nil {
+				results[i].setError(err)
+				continue
+			}
+			valid[i] = true
+		}
+
+		reqCtx := ctx.queryContext()
+		db := ctx.DB()
+		if cfg.Mode == BulkAtomic {
+			runBulkAtomic(reqCtx, db, fn, items, valid, results)
+		} else {
+			runBulkPerItem(reqCtx, db, fn, items, valid, results, cfg.Concurrency)
+		}
+
+		resp := BulkResponse{Results: results}
+		for _, r := range results {
+			if r.Error == "" {
+				resp.Succeeded++
+			} else {
+				resp.Failed++
+			}
+		}
+		return ctx.Status(fiber.StatusMultiStatus).JSON(resp)
+	}
+}
+
+// runBulkPerItem runs each valid item in its own transaction, bounded by concurrency.
+func runBulkPerItem[T any](ctx context.Context, db *gorm.DB, fn BulkItemHandler[T], items []T, valid []bool, results []BulkResult, concurrency int) {
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := range items {
+		if !valid[i] {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			var data any
+			err := db.Transaction(func(tx *gorm.DB) error {
+				var err error
+				data, err = fn(ctx, tx, items[i])
+				return err
+			})
+			results[i].set(data, err)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// runBulkAtomic runs all items in one transaction, rolling back on the first
+// failure. Invalid items fail the batch before the transaction starts.
+func runBulkAtomic[T any](ctx context.Context, db *gorm.DB, fn BulkItemHandler[T], items []T, valid []bool, results []BulkResult) {
+	failed := -1
+	for i := range items {
+		if !valid[i] {
+			failed = i
+			break
+		}
+	}
+
+	if failed < 0 {
+		data := make([]any, len(items))
+		err := db.Transaction(func(tx *gorm.DB) error {
+			for i, item := range items {
+				d, err := fn(ctx, tx, item)
+				if err != nil {
+					results[i].setError(err)
+					failed = i
+					return errBulkRolledBack
+				}
+				data[i] = d
+			}
+			return nil
+		})
+		if err != nil && failed < 0 {
+			// Commit failed after every item succeeded
+			for i := range results {
+				results[i].setError(err)
+			}
+			return
+		}
+		if err == nil {
+			for i := range results {
+				results[i].set(data[i], nil)
+			}
+			return
+		}
+	}
+
+	rolledBack := NewError(fiber.StatusFailedDependency, fmt.Sprintf("rolled back: item %d failed", failed))
+	for i := range results {
+		if results[i].Error == "" {
+			results[i].setError(rolledBack)
+		}
+	}
+}
+
+// set records an item's data or error.
+func (r *BulkResult) set(data any, err error) {
+	if err != nil {
+		r.setError(err)
+		return
+	}
+	r.Status = fiber.StatusOK
+	r.Data = data
+}
+
+// setError maps err to a status and client-safe message, as the default
+// error handler would.
+func (r *BulkResult) setError(err error) {
+	appErr := asError(err)
+	r.Status = appErr.status()
+	r.Error = appErr.Message
+	r.Details = appErr.Details
+}
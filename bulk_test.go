@@ -0,0 +1,123 @@
+package cartridge
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type bulkWidget struct {
+	ID   uint   `gorm:"primaryKey"`
+	Name string `gorm:"uniqueIndex"`
+}
+
+type bulkWidgetRequest struct {
+	Name string `json:"name" validate:"required"`
+}
+
+func newBulkTestApp(t *testing.T, mode BulkMode) (*fiber.App, *gorm.DB) {
+	t.Helper()
+	db := openAsyncTestDB(t)
+	if err := db.AutoMigrate(&bulkWidget{}); err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+	// SQLite allows one writer; serialize the per-item transactions
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+
+	handler := Bulk(BulkConfig{Mode: mode, MaxItems: 5}, func(ctx context.Context, tx *gorm.DB, req bulkWidgetRequest) (any, error) {
+		if req.Name == "forbidden" {
+			return nil, ErrForbidden("name not allowed")
+		}
+		w := bulkWidget{Name: req.Name}
+		return w, tx.Create(&w).Error
+	})
+
+	app := fiber.New(fiber.Config{ErrorHandler: DefaultErrorHandler(testLogger(), false)})
+	app.Post("/widgets/bulk", func(c *fiber.Ctx) error {
+		return handler(&Context{Ctx: c, DBManager: &mockDBManager{db: db}})
+	})
+	return app, db
+}
+
+func postBulk(t *testing.T, app *fiber.App, body string) (int, BulkResponse) {
+	t.Helper()
+	req, _ := http.NewRequest("POST", "/widgets/bulk", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	var out BulkResponse
+	_ = json.NewDecoder(resp.Body).Decode(&out)
+	return resp.StatusCode, out
+}
+
+func TestBulk_PerItem(t *testing.T) {
+	app, db := newBulkTestApp(t, BulkPerItem)
+
+	status, resp := postBulk(t, app, `[{"name":"a"},{"name":""},{"name":"b"},{"name":"forbidden"}]`)
+	if status != fiber.StatusMultiStatus {
+		t.Fatalf("expected 207, got %d", status)
+	}
+	if resp.Succeeded != 2 || resp.Failed != 2 {
+		t.Errorf("expected 2 succeeded and 2 failed, got %+v", resp)
+	}
+
+	wantStatus := []int{200, 422, 200, 403}
+	for i, r := range resp.Results {
+		if r.Index != i || r.Status != wantStatus[i] {
+			t.Errorf("result %d: expected index %d status %d, got %+v", i, i, wantStatus[i], r)
+		}
+	}
+
+	var count int64
+	db.Model(&bulkWidget{}).Count(&count)
+	if count != 2 {
+		t.Errorf("expected 2 widgets, got %d", count)
+	}
+}
+
+func TestBulk_Atomic(t *testing.T) {
+	app, db := newBulkTestApp(t, BulkAtomic)
+
+	_, resp := postBulk(t, app, `[{"name":"a"},{"name":"forbidden"},{"name":"b"}]`)
+	wantStatus := []int{424, 403, 424}
+	for i, r := range resp.Results {
+		if r.Status != wantStatus[i] {
+			t.Errorf("result %d: expected status %d, got %+v", i, wantStatus[i], r)
+		}
+	}
+
+	var count int64
+	db.Model(&bulkWidget{}).Count(&count)
+	if count != 0 {
+		t.Errorf("expected rollback, found %d widgets", count)
+	}
+
+	_, resp = postBulk(t, app, `[{"name":"a"},{"name":"b"}]`)
+	if resp.Succeeded != 2 {
+		t.Errorf("expected both items to commit, got %+v", resp)
+	}
+}
+
+func TestBulk_RejectsBadBatches(t *testing.T) {
+	app, _ := newBulkTestApp(t, BulkPerItem)
+
+	tests := map[string]int{
+		`{"name":"a"}`:        fiber.StatusBadRequest,
+		`[]`:                  fiber.StatusBadRequest,
+		`[{},{},{},{},{},{}]`: fiber.StatusRequestEntityTooLarge,
+	}
+	for body, want := range tests {
+		if status, _ := postBulk(t, app, body); status != want {
+			t.Errorf("%s: expected %d, got %d", body, want, status)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package cartridge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"github.com/karloscodes/cartridge/cache"
+)
+
+// Cache is a key-value cache for handlers, cron jobs and async tasks, e.g.
+// for expensive queries or third-party API results. Values are stored as
+// JSON, so they behave the same with every store.
+type Cache struct {
+	store cache.Store
+	calls *singleflight.Group // shared by WithContext copies
+	ctx   context.Context
+}
+
+// NewCache creates a cache backed by store. Default: in memory, 10000
+// entries (cache.NewLRUStore)
+func NewCache(store cache.Store) *Cache {
+	if store == nil {
+		store = cache.NewLRUStore()
+	}
+	return &Cache{store: store, calls: &singleflight.Group{}, ctx: context.Background()}
+}
+
+// defaultCache is used by Contexts and managers created without a cache, so
+// requests and background jobs in the process still share one.
+var defaultCache = sync.OnceValue(func() *Cache { return NewCache(nil) })
+
+// WithContext returns the cache bound to ctx, which is passed to the store.
+// ctx.Cache() and JobContext.Cache() return bound caches.
+func (c *Cache) WithContext(ctx context.Context) *Cache {
+	bound := *c
+	bound.ctx = ctx
+	return &bound
+}
+
+// Store returns the backing store.
+func (c *Cache) Store() cache.Store {
+	return c.store
+}
+
+// Get decodes the value for key into dest and reports whether it was found.
+func (c *Cache) Get(key string, dest any) (bool, error) {
+	data, ok := c.store.Read(c.ctx, key)
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal(data, dest); err != nil {
+		return false, fmt.Errorf("cartridge: decode cache %q: %w", key, err)
+	}
+	return true, nil
+}
+
+// Set stores value for ttl; ttl <= 0 uses the store's default TTL.
+func (c *Cache) Set(key string, value any, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("cartridge: encode cache %q: %w", key, err)
+	}
+	return c.write(key, data, ttl)
+}
+
+// Delete removes key.
+func (c *Cache) Delete(key string) error {
+	return c.store.Delete(c.ctx, key)
+}
+
+// Remember decodes the cached value for key into dest, or calls fn, caches
+// its result for ttl and decodes that. Concurrent misses for the same key in
+// this process share one fn call, so an expiring entry doesn't stampede the
+// database. Errors from fn are returned and not cached.
+//
+//	var stats DashboardStats
+//	err := ctx.Cache().Remember("dashboard:stats", time.Minute, &stats, func() (any, error) {
+//	    return loadStats(ctx.DB())
+//	})
+func (c *Cache) Remember(key string, ttl time.Duration, dest any, fn func() (any, error)) error {
+	if ok, err := c.Get(key, dest); ok || err != nil {
+		return err
+	}
+
+	data, err, _ := c.calls.Do(key, func() (any, error) {
+		value, err := fn()
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("cartridge: encode cache %q: %w", key, err)
+		}
+		if err := c.write(key, data, ttl); err != nil {
+			return nil, err
+		}
+		return data, nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data.([]byte), dest); err != nil {
+		return fmt.Errorf("cartridge: decode cache %q: %w", key, err)
+	}
+	return nil
+}
+
+// write stores encoded data, with the store's default TTL when ttl <= 0.
+func (c *Cache) write(key string, data []byte, ttl time.Duration) error {
+	if ttl <= 0 {
+		return c.store.Write(c.ctx, key, data)
+	}
+	return c.store.WriteWithTTL(c.ctx, key, data, ttl)
+}
+
+// Cache returns the application cache bound to the request's context.
+func (ctx *Context) Cache() *Cache {
+	c := ctx.cache
+	if c == nil {
+		c = defaultCache()
+	}
+	return c.WithContext(ctx.UserContext())
+}
+
+// Cache returns the application cache bound to the job's context.
+func (ctx *JobContext) Cache() *Cache {
+	c := ctx.cache
+	if c == nil {
+		c = defaultCache()
+	}
+	return c.WithContext(ctx)
+}
@@ -2,6 +2,7 @@ package cache
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -100,7 +101,9 @@ func (s *DatabaseStore) Delete(ctx context.Context, key string) error {
 
 // DeleteByPrefix removes all keys matching the prefix.
 func (s *DatabaseStore) DeleteByPrefix(ctx context.Context, prefix string) (int, error) {
-	result := s.db.WithContext(ctx).Where("key LIKE ?", prefix+"%").Delete(&CacheEntry{})
+	// "!" escapes LIKE wildcards portably, so "_" and "%" in prefix are literal
+	like := strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(prefix) + "%"
+	result := s.db.WithContext(ctx).Where("key LIKE ? ESCAPE '!'", like).Delete(&CacheEntry{})
 	return int(result.RowsAffected), result.Error
 }
 
@@ -0,0 +1,158 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LRUStore is an in-memory cache that evicts the least recently used entry
+// when full. Unlike MemoryStore it is always bounded, which suits keys
+// derived from request input. Expired entries are dropped when read or
+// evicted, so no cleanup goroutine runs.
+type LRUStore struct {
+	mu    sync.Mutex
+	order *list.List // front is most recently used
+	items map[string]*list.Element
+	opts  Options
+}
+
+type lruEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewLRUStore creates an LRU store. MaxEntries defaults to 10000.
+func NewLRUStore(opts ...Option) *LRUStore {
+	options := applyOptions(opts...)
+	if options.MaxEntries <= 0 {
+		options.MaxEntries = 10000
+	}
+	return &LRUStore{order: list.New(), items: make(map[string]*list.Element), opts: options}
+}
+
+// Read retrieves a value and marks it recently used.
+func (s *LRUStore) Read(ctx context.Context, key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.items[key]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*lruEntry)
+	if time.Now().After(entry.expiresAt) {
+		s.removeLocked(el)
+		return nil, false
+	}
+	s.order.MoveToFront(el)
+	return entry.value, true
+}
+
+// Write stores a value with the default TTL.
+func (s *LRUStore) Write(ctx context.Context, key string, value []byte) error {
+	return s.WriteWithTTL(ctx, key, value, s.opts.TTL)
+}
+
+// WriteWithTTL stores a value with a custom TTL, evicting the least
+// recently used entry if the store is full.
+func (s *LRUStore) WriteWithTTL(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiresAt := time.Now().Add(ttl)
+	if el, ok := s.items[key]; ok {
+		entry := el.Value.(*lruEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		s.order.MoveToFront(el)
+		return nil
+	}
+
+	s.items[key] = s.order.PushFront(&lruEntry{key: key, value: value, expiresAt: expiresAt})
+	for int64(s.order.Len()) > s.opts.MaxEntries {
+		s.removeLocked(s.order.Back())
+	}
+	return nil
+}
+
+// Delete removes a key from the cache.
+func (s *LRUStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.items[key]; ok {
+		s.removeLocked(el)
+	}
+	return nil
+}
+
+// DeleteByPrefix removes all keys matching the prefix.
+func (s *LRUStore) DeleteByPrefix(ctx context.Context, prefix string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for key, el := range s.items {
+		if strings.HasPrefix(key, prefix) {
+			s.removeLocked(el)
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Clear removes all entries from the cache.
+func (s *LRUStore) Clear(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.order.Init()
+	s.items = make(map[string]*list.Element)
+	return nil
+}
+
+// Exist checks if a key exists and is not expired.
+func (s *LRUStore) Exist(ctx context.Context, key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.items[key]
+	return ok && time.Now().Before(el.Value.(*lruEntry).expiresAt)
+}
+
+// Stats returns cache statistics.
+func (s *LRUStore) Stats(ctx context.Context) Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	expired := int64(0)
+	for _, el := range s.items {
+		if now.After(el.Value.(*lruEntry).expiresAt) {
+			expired++
+		}
+	}
+
+	return Stats{
+		Entries:        int64(len(s.items)),
+		ExpiredEntries: expired,
+		MaxEntries:     s.opts.MaxEntries,
+		TTL:            s.opts.TTL,
+		Backend:        "lru",
+	}
+}
+
+// Close is a no-op; LRUStore runs no background goroutine.
+func (s *LRUStore) Close() error {
+	return nil
+}
+
+// removeLocked unlinks el. Must hold the lock.
+func (s *LRUStore) removeLocked(el *list.Element) {
+	s.order.Remove(el)
+	delete(s.items, el.Value.(*lruEntry).key)
+}
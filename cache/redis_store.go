@@ -0,0 +1,333 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RedisConfig configures RedisStore.
+type RedisConfig struct {
+	// Addr is the server's host:port. Default: "localhost:6379"
+	Addr string
+	// Username and Password authenticate with AUTH when Password is set.
+	Username string
+	Password string
+	// DB is the database number selected on connect. Default: 0
+	DB int
+	// Prefix is prepended to every key, to share a server between apps.
+	// Clear only removes keys with the prefix.
+	Prefix string
+	// PoolSize is the number of idle connections kept open. Default: 10
+	PoolSize int
+	// Timeout bounds dialing and each command when the context has no
+	// deadline. Default: 5s
+	Timeout time.Duration
+	// TLS enables TLS with this configuration.
+	TLS *tls.Config
+}
+
+// RedisStore keeps cache values in Redis (or a compatible server such as
+// Valkey), shared between instances. Redis expires keys itself, so no cleanup
+// goroutine runs. It speaks the protocol directly and needs no client library.
+type RedisStore struct {
+	cfg  RedisConfig
+	opts Options
+	idle chan *redisConn
+}
+
+type redisConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+// redisError is an error reply from the server.
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+// NewRedisStore creates a Redis-backed cache store. Connections are opened
+// on first use. MaxEntries is not enforced; configure maxmemory on the server.
+func NewRedisStore(cfg RedisConfig, opts ...Option) *RedisStore {
+	if cfg.Addr == "" {
+		cfg.Addr = "localhost:6379"
+	}
+	if cfg.PoolSize <= 0 {
+		cfg.PoolSize = 10
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	return &RedisStore{cfg: cfg, opts: applyOptions(opts...), idle: make(chan *redisConn, cfg.PoolSize)}
+}
+
+// Read retrieves a value from the cache.
+func (s *RedisStore) Read(ctx context.Context, key string) ([]byte, bool) {
+	reply, err := s.do(ctx, "GET", s.cfg.Prefix+key)
+	value, ok := reply.([]byte)
+	if err != nil || !ok {
+		return nil, false
+	}
+	return value, true
+}
+
+// Write stores a value with the default TTL.
+func (s *RedisStore) Write(ctx context.Context, key string, value []byte) error {
+	return s.WriteWithTTL(ctx, key, value, s.opts.TTL)
+}
+
+// WriteWithTTL stores a value with a millisecond expiry.
+func (s *RedisStore) WriteWithTTL(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	_, err := s.do(ctx, "SET", s.cfg.Prefix+key, string(value), "PX", strconv.FormatInt(max(ttl.Milliseconds(), 1), 10))
+	return err
+}
+
+// Delete removes a key from the cache.
+func (s *RedisStore) Delete(ctx context.Context, key string) error {
+	_, err := s.do(ctx, "DEL", s.cfg.Prefix+key)
+	return err
+}
+
+// DeleteByPrefix removes all keys matching the prefix.
+func (s *RedisStore) DeleteByPrefix(ctx context.Context, prefix string) (int, error) {
+	count := 0
+	err := s.scan(ctx, prefix, func(keys []string) error {
+		reply, err := s.do(ctx, append([]string{"DEL"}, keys...)...)
+		if n, ok := reply.(int64); ok {
+			count += int(n)
+		}
+		return err
+	})
+	return count, err
+}
+
+// Clear removes all entries with the configured prefix.
+func (s *RedisStore) Clear(ctx context.Context) error {
+	_, err := s.DeleteByPrefix(ctx, "")
+	return err
+}
+
+// Exist checks if a key exists.
+func (s *RedisStore) Exist(ctx context.Context, key string) bool {
+	reply, err := s.do(ctx, "EXISTS", s.cfg.Prefix+key)
+	n, _ := reply.(int64)
+	return err == nil && n > 0
+}
+
+// Stats returns cache statistics. Expired keys are removed by Redis, so
+// ExpiredEntries is always 0.
+func (s *RedisStore) Stats(ctx context.Context) Stats {
+	var entries int64
+	s.scan(ctx, "", func(keys []string) error {
+		entries += int64(len(keys))
+		return nil
+	})
+	return Stats{
+		Entries:    entries,
+		MaxEntries: s.opts.MaxEntries,
+		TTL:        s.opts.TTL,
+		Backend:    "redis",
+	}
+}
+
+// Eval runs a Lua script with EVAL. The configured prefix is added to keys,
+// so scripts can implement atomic updates other packages need, such as
+// rate limiting.
+func (s *RedisStore) Eval(ctx context.Context, script string, keys []string, args ...string) (any, error) {
+	cmd := append([]string{"EVAL", script, strconv.Itoa(len(keys))}, make([]string, len(keys))...)
+	for i, key := range keys {
+		cmd[3+i] = s.cfg.Prefix + key
+	}
+	return s.do(ctx, append(cmd, args...)...)
+}
+
+// scan calls fn with each batch of full keys starting with the configured
+// prefix plus prefix.
+func (s *RedisStore) scan(ctx context.Context, prefix string, fn func(keys []string) error) error {
+	match := redisGlobEscaper.Replace(s.cfg.Prefix+prefix) + "*"
+	cursor := "0"
+	for {
+		reply, err := s.do(ctx, "SCAN", cursor, "MATCH", match, "COUNT", "100")
+		if err != nil {
+			return err
+		}
+		page, ok := reply.([]any)
+		if !ok || len(page) != 2 {
+			return fmt.Errorf("redis: unexpected SCAN reply %T", reply)
+		}
+		next, _ := page[0].([]byte)
+		items, _ := page[1].([]any)
+		keys := make([]string, 0, len(items))
+		for _, item := range items {
+			if key, ok := item.([]byte); ok {
+				keys = append(keys, string(key))
+			}
+		}
+		if len(keys) > 0 {
+			if err := fn(keys); err != nil {
+				return err
+			}
+		}
+		cursor = string(next)
+		if cursor == "0" || cursor == "" {
+			return nil
+		}
+	}
+}
+
+// redisGlobEscaper makes a key prefix literal in a MATCH pattern.
+var redisGlobEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`)
+
+// Close closes idle connections.
+func (s *RedisStore) Close() error {
+	for {
+		select {
+		case conn := <-s.idle:
+			conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// do runs one command on a pooled connection. Connections that fail are
+// discarded rather than returned to the pool.
+func (s *RedisStore) do(ctx context.Context, args ...string) (any, error) {
+	conn, err := s.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := conn.roundTrip(s.deadline(ctx), args...)
+	var replyErr redisError
+	if err != nil && !errors.As(err, &replyErr) {
+		conn.Close()
+		return nil, fmt.Errorf("redis %s: %w", args[0], err)
+	}
+
+	select {
+	case s.idle <- conn:
+	default:
+		conn.Close()
+	}
+	return reply, err
+}
+
+// conn returns an idle connection or dials, authenticates and selects the DB.
+func (s *RedisStore) conn(ctx context.Context) (*redisConn, error) {
+	select {
+	case conn := <-s.idle:
+		return conn, nil
+	default:
+	}
+
+	dialer := &net.Dialer{Timeout: s.cfg.Timeout}
+	var raw net.Conn
+	var err error
+	if s.cfg.TLS != nil {
+		raw, err = (&tls.Dialer{NetDialer: dialer, Config: s.cfg.TLS}).DialContext(ctx, "tcp", s.cfg.Addr)
+	} else {
+		raw, err = dialer.DialContext(ctx, "tcp", s.cfg.Addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("redis connect: %w", err)
+	}
+	conn := &redisConn{Conn: raw, r: bufio.NewReader(raw)}
+
+	var setup [][]string
+	if s.cfg.Password != "" {
+		if s.cfg.Username != "" {
+			setup = append(setup, []string{"AUTH", s.cfg.Username, s.cfg.Password})
+		} else {
+			setup = append(setup, []string{"AUTH", s.cfg.Password})
+		}
+	}
+	if s.cfg.DB != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(s.cfg.DB)})
+	}
+	for _, cmd := range setup {
+		if _, err := conn.roundTrip(s.deadline(ctx), cmd...); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("redis %s: %w", cmd[0], err)
+		}
+	}
+	return conn, nil
+}
+
+// deadline is the context's deadline, or the configured timeout from now.
+func (s *RedisStore) deadline(ctx context.Context) time.Time {
+	if deadline, ok := ctx.Deadline(); ok {
+		return deadline
+	}
+	return time.Now().Add(s.cfg.Timeout)
+}
+
+// roundTrip writes a command as a RESP array and reads the reply.
+func (c *redisConn) roundTrip(deadline time.Time, args ...string) (any, error) {
+	if err := c.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+	buf := []byte("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, "\r\n"...)
+		buf = append(buf, arg...)
+		buf = append(buf, "\r\n"...)
+	}
+	if _, err := c.Write(buf); err != nil {
+		return nil, err
+	}
+	return c.readReply()
+}
+
+// readReply parses one RESP2 reply: simple strings, errors, integers, bulk
+// strings (nil when absent) and arrays.
+func (c *redisConn) readReply() (any, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("malformed reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, redisError(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, data); err != nil {
+			return nil, err
+		}
+		return data[:n], nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		items := make([]any, n)
+		for i := range items {
+			if items[i], err = c.readReply(); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("unknown reply type %q", kind)
+}
@@ -0,0 +1,152 @@
+package cache_test
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/karloscodes/cartridge/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRedis serves the commands RedisStore uses from a map. Keys never expire.
+func fakeRedis(t *testing.T, password string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	var mu sync.Mutex
+	data := map[string]string{}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				authed := password == ""
+				for {
+					args, err := readRESPCommand(r)
+					if err != nil {
+						return
+					}
+					mu.Lock()
+					var reply string
+					switch cmd := strings.ToUpper(args[0]); {
+					case cmd == "AUTH":
+						authed = args[len(args)-1] == password
+						reply = "+OK\r\n"
+						if !authed {
+							reply = "-WRONGPASS invalid password\r\n"
+						}
+					case !authed:
+						reply = "-NOAUTH Authentication required.\r\n"
+					case cmd == "GET":
+						if v, ok := data[args[1]]; ok {
+							reply = bulkString(v)
+						} else {
+							reply = "$-1\r\n"
+						}
+					case cmd == "SET":
+						data[args[1]] = args[2]
+						reply = "+OK\r\n"
+					case cmd == "DEL" || cmd == "EXISTS":
+						n := 0
+						for _, key := range args[1:] {
+							if _, ok := data[key]; ok {
+								n++
+								if cmd == "DEL" {
+									delete(data, key)
+								}
+							}
+						}
+						reply = ":" + strconv.Itoa(n) + "\r\n"
+					case cmd == "SCAN":
+						// One page; MATCH is always an escaped prefix plus "*"
+						prefix := strings.ReplaceAll(strings.TrimSuffix(args[3], "*"), `\`, "")
+						var keys []string
+						for key := range data {
+							if strings.HasPrefix(key, prefix) {
+								keys = append(keys, bulkString(key))
+							}
+						}
+						reply = "*2\r\n" + bulkString("0") + "*" + strconv.Itoa(len(keys)) + "\r\n" + strings.Join(keys, "")
+					default:
+						reply = "-ERR unknown command\r\n"
+					}
+					mu.Unlock()
+					conn.Write([]byte(reply))
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func bulkString(s string) string {
+	return "$" + strconv.Itoa(len(s)) + "\r\n" + s + "\r\n"
+}
+
+func readRESPCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	args := make([]string, n)
+	for i := range args {
+		if _, err := r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		arg, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args[i] = strings.TrimSuffix(arg, "\r\n")
+	}
+	return args, nil
+}
+
+func TestRedisStore(t *testing.T) {
+	addr := fakeRedis(t, "secret")
+	store := cache.NewRedisStore(cache.RedisConfig{Addr: addr, Password: "secret", Prefix: "app:"}, cache.WithTTL(1*time.Hour))
+	defer store.Close()
+
+	runStoreTests(t, store, "RedisStore")
+}
+
+func TestRedisStorePrefix(t *testing.T) {
+	addr := fakeRedis(t, "")
+	ctx := context.Background()
+
+	app := cache.NewRedisStore(cache.RedisConfig{Addr: addr, Prefix: "app:"})
+	defer app.Close()
+	other := cache.NewRedisStore(cache.RedisConfig{Addr: addr, Prefix: "other:"})
+	defer other.Close()
+
+	require.NoError(t, app.Write(ctx, "key", []byte("value")))
+	require.NoError(t, other.Write(ctx, "key", []byte("value")))
+
+	// Clear only removes keys under the store's prefix
+	require.NoError(t, app.Clear(ctx))
+	assert.False(t, app.Exist(ctx, "key"))
+	assert.True(t, other.Exist(ctx, "key"))
+}
+
+func TestRedisStoreAuthFailure(t *testing.T) {
+	addr := fakeRedis(t, "secret")
+	store := cache.NewRedisStore(cache.RedisConfig{Addr: addr, Password: "wrong"})
+	defer store.Close()
+
+	err := store.Write(context.Background(), "key", []byte("value"))
+	assert.ErrorContains(t, err, "WRONGPASS")
+}
@@ -1,5 +1,5 @@
 // Package cache provides a unified caching interface inspired by Rails' Solid Cache.
-// It supports both in-memory and database-backed stores with automatic expiration.
+// It supports in-memory, database-backed and Redis stores with automatic expiration.
 package cache
 
 import (
@@ -190,6 +190,40 @@ func TestMemoryStoreMaxEntries(t *testing.T) {
 	assert.True(t, ok, "Entry 'e' should remain")
 }
 
+func TestLRUStore(t *testing.T) {
+	store := cache.NewLRUStore(cache.WithTTL(1 * time.Hour))
+	defer store.Close()
+
+	runStoreTests(t, store, "LRUStore")
+}
+
+func TestLRUStoreEviction(t *testing.T) {
+	store := cache.NewLRUStore(cache.WithMaxEntries(2))
+	defer store.Close()
+
+	ctx := context.Background()
+
+	require.NoError(t, store.Write(ctx, "a", []byte("1")))
+	require.NoError(t, store.Write(ctx, "b", []byte("2")))
+
+	// Reading "a" makes "b" the least recently used
+	_, ok := store.Read(ctx, "a")
+	require.True(t, ok)
+	require.NoError(t, store.Write(ctx, "c", []byte("3")))
+
+	_, ok = store.Read(ctx, "b")
+	assert.False(t, ok, "Entry 'b' should be evicted")
+
+	_, ok = store.Read(ctx, "a")
+	assert.True(t, ok, "Entry 'a' should remain")
+
+	// Expired entries are dropped on read
+	require.NoError(t, store.WriteWithTTL(ctx, "short", []byte("v"), time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+	_, ok = store.Read(ctx, "short")
+	assert.False(t, ok)
+}
+
 func TestDatabaseStore(t *testing.T) {
 	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Silent),
@@ -267,6 +301,27 @@ func TestDatabaseStoreMaxEntries(t *testing.T) {
 	assert.True(t, ok, "Entry 'e' should remain")
 }
 
+func TestDatabaseStoreDeleteByPrefixLiteral(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	store, err := cache.NewDatabaseStore(db, cache.WithCleanupInterval(0))
+	require.NoError(t, err)
+	defer store.Close()
+
+	ctx := context.Background()
+	require.NoError(t, store.Write(ctx, "/product_tags", []byte("value")))
+	require.NoError(t, store.Write(ctx, "/products", []byte("value")))
+
+	// "_" and "%" are literal, not LIKE wildcards
+	count, err := store.DeleteByPrefix(ctx, "/product_")
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.True(t, store.Exist(ctx, "/products"))
+}
+
 func TestDefaultOptions(t *testing.T) {
 	opts := cache.DefaultOptions()
 
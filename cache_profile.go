@@ -0,0 +1,174 @@
+package cartridge
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// CacheProfile is a named Cache-Control policy. Static assets get the first
+// profile whose patterns match their path; handlers apply one by name with
+// ctx.ApplyCacheProfile.
+type CacheProfile struct {
+	// Name identifies the profile for ctx.ApplyCacheProfile. Required.
+	Name string
+
+	// Patterns are path.Match globs. Patterns without a "/" match the file
+	// name ("*.png"); others match the path below the static prefix ("fonts/*").
+	Patterns []string
+
+	// Match decides whether the profile applies to a path below the static
+	// prefix, in addition to Patterns.
+	Match func(path string) bool
+
+	// MaxAge is how long browsers and CDNs may reuse the response.
+	MaxAge time.Duration
+
+	// Immutable tells browsers not to revalidate while fresh.
+	Immutable bool
+
+	// Private keeps the response out of shared caches (CDNs, proxies).
+	Private bool
+
+	// NoCache stores the response but revalidates it on every use.
+	NoCache bool
+
+	// NoStore forbids storing the response at all. Other fields are ignored.
+	NoStore bool
+}
+
+// CacheControl returns the Cache-Control header value for the profile.
+func (p CacheProfile) CacheControl() string {
+	if p.NoStore {
+		return "no-store"
+	}
+
+	directives := []string{"public"}
+	if p.Private {
+		directives[0] = "private"
+	}
+	if p.NoCache {
+		directives = append(directives, "no-cache")
+	}
+	directives = append(directives, "max-age="+strconv.Itoa(int(p.MaxAge.Seconds())))
+	if p.Immutable {
+		directives = append(directives, "immutable")
+	}
+	return strings.Join(directives, ", ")
+}
+
+// matches reports whether the profile applies to a path below the static prefix.
+func (p CacheProfile) matches(assetPath string) bool {
+	assetPath = strings.TrimPrefix(assetPath, "/")
+	if p.Match != nil && p.Match(assetPath) {
+		return true
+	}
+	for _, pattern := range p.Patterns {
+		target := path.Base(assetPath)
+		if strings.Contains(pattern, "/") {
+			target = assetPath
+		}
+		if ok, _ := path.Match(pattern, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// fingerprintPattern matches bundler content hashes such as app-BZk3x9Q2.js or
+// app.3f2a9c1d.css: a separator, then 8+ hash characters including a digit.
+var fingerprintPattern = regexp.MustCompile(`[.-]([A-Za-z0-9_]*[0-9][A-Za-z0-9_]*)\.[A-Za-z0-9]+$`)
+
+// IsFingerprinted reports whether a file name carries a content hash, so it
+// can be cached forever.
+func IsFingerprinted(name string) bool {
+	m := fingerprintPattern.FindStringSubmatch(path.Base(name))
+	return m != nil && len(m[1]) >= 8
+}
+
+// DefaultCacheProfiles returns the built-in profiles, in matching order:
+//
+//   - "immutable": fingerprinted files, cached for a year without revalidation
+//   - "html": HTML pages, never stored
+//   - "images": images, cached for 7 days
+func DefaultCacheProfiles() []CacheProfile {
+	return []CacheProfile{
+		{Name: "immutable", Match: IsFingerprinted, MaxAge: 365 * 24 * time.Hour, Immutable: true},
+		{Name: "html", Patterns: []string{"*.html", "*.htm"}, NoStore: true},
+		{Name: "images", Patterns: []string{"*.png", "*.jpg", "*.jpeg", "*.gif", "*.webp", "*.avif", "*.svg", "*.ico"}, MaxAge: 7 * 24 * time.Hour},
+	}
+}
+
+// mergeCacheProfiles replaces profiles in base that share a name with one in
+// overrides, and appends the rest.
+func mergeCacheProfiles(base, overrides []CacheProfile) []CacheProfile {
+	merged := append([]CacheProfile(nil), base...)
+	for _, o := range overrides {
+		replaced := false
+		for i := range merged {
+			if merged[i].Name == o.Name {
+				merged[i] = o
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, o)
+		}
+	}
+	return merged
+}
+
+// CacheProfile returns the profile with the given name.
+func (s *Server) CacheProfile(name string) (CacheProfile, bool) {
+	for _, p := range s.cfg.CacheProfiles {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return CacheProfile{}, false
+}
+
+// cacheProfileMiddleware sets Cache-Control on successful static responses
+// from the first profile matching the path below prefix. Directory requests
+// are matched as their index file.
+func (s *Server) cacheProfileMiddleware(prefix, index string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		err := c.Next()
+		if err != nil || c.Response().StatusCode() >= fiber.StatusBadRequest {
+			return err
+		}
+		assetPath := strings.TrimPrefix(c.Path(), prefix)
+		if assetPath == "" || strings.HasSuffix(assetPath, "/") {
+			assetPath += index
+		}
+		for _, p := range s.cfg.CacheProfiles {
+			if p.matches(assetPath) {
+				c.Set(fiber.HeaderCacheControl, p.CacheControl())
+				break
+			}
+		}
+		return nil
+	}
+}
+
+// ApplyCacheProfile sets the Cache-Control header from a named profile:
+//
+//	if err := ctx.ApplyCacheProfile("images"); err != nil {
+//	    return err
+//	}
+//	return ctx.Send(thumbnail)
+func (ctx *Context) ApplyCacheProfile(name string) error {
+	for _, p := range ctx.caching {
+		if p.Name == name {
+			ctx.Set(fiber.HeaderCacheControl, p.CacheControl())
+			return nil
+		}
+	}
+	return fmt.Errorf("cartridge: unknown cache profile %q", name)
+}
@@ -0,0 +1,105 @@
+package cartridge
+
+import (
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestCacheProfile_CacheControl(t *testing.T) {
+	tests := map[string]CacheProfile{
+		"no-store":                            {NoStore: true, MaxAge: time.Hour},
+		"public, max-age=31536000, immutable": {MaxAge: 365 * 24 * time.Hour, Immutable: true},
+		"private, no-cache, max-age=0":        {Private: true, NoCache: true},
+		"public, max-age=604800":              {MaxAge: 7 * 24 * time.Hour},
+	}
+	for want, p := range tests {
+		if got := p.CacheControl(); got != want {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+	}
+}
+
+func TestIsFingerprinted(t *testing.T) {
+	tests := map[string]bool{
+		"app-BZk3x9Q2.js":         true,
+		"chunks/app.3f2a9c1d.css": true,
+		"app.js":                  false,
+		"logo-2x.png":             false,
+		"jquery-3.6.0.min.js":     false,
+		"app-settings.js":         false,
+	}
+	for name, want := range tests {
+		if got := IsFingerprinted(name); got != want {
+			t.Errorf("%s: expected %v, got %v", name, want, got)
+		}
+	}
+}
+
+func TestCacheProfiles(t *testing.T) {
+	cfg := DefaultServerConfig()
+	cfg.EnableRequestLogger = false
+	cfg.Config = &testConfig{}
+	cfg.Logger = slog.New(slog.NewTextHandler(os.Stderr, nil))
+	cfg.DBManager = &testDBManager{}
+	cfg.StaticFS = fstest.MapFS{
+		"app-BZk3x9Q2.js":   &fstest.MapFile{Data: []byte("js")},
+		"app.js":            &fstest.MapFile{Data: []byte("js")},
+		"docs/index.html":   &fstest.MapFile{Data: []byte("<h1>docs</h1>")},
+		"logo.png":          &fstest.MapFile{Data: []byte("png")},
+		"fonts/inter.woff2": &fstest.MapFile{Data: []byte("font")},
+	}
+	cfg.CacheProfiles = mergeCacheProfiles(cfg.CacheProfiles, []CacheProfile{
+		{Name: "images", Patterns: []string{"*.png"}, MaxAge: time.Hour},
+		{Name: "fonts", Patterns: []string{"fonts/*"}, MaxAge: 30 * 24 * time.Hour},
+		{Name: "api", Private: true, NoCache: true},
+	})
+
+	srv, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	srv.Get("/avatar", func(ctx *Context) error {
+		if err := ctx.ApplyCacheProfile("api"); err != nil {
+			return err
+		}
+		return ctx.SendString("avatar")
+	})
+	srv.Get("/unknown", func(ctx *Context) error {
+		return ctx.ApplyCacheProfile("missing")
+	})
+
+	tests := map[string]string{
+		"/assets/app-BZk3x9Q2.js":   "public, max-age=31536000, immutable",
+		"/assets/app.js":            "public, max-age=31536000",
+		"/assets/docs/":             "no-store",
+		"/assets/logo.png":          "public, max-age=3600",
+		"/assets/fonts/inter.woff2": "public, max-age=2592000",
+		"/avatar":                   "private, no-cache, max-age=0",
+	}
+	for path, want := range tests {
+		resp, err := srv.App().Test(httptest.NewRequest("GET", path, nil))
+		if err != nil {
+			t.Fatalf("%s: request failed: %v", path, err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Errorf("%s: expected 200, got %d", path, resp.StatusCode)
+		}
+		if got := resp.Header.Get(fiber.HeaderCacheControl); got != want {
+			t.Errorf("%s: expected Cache-Control %q, got %q", path, want, got)
+		}
+	}
+
+	resp, _ := srv.App().Test(httptest.NewRequest("GET", "/unknown", nil))
+	if resp.StatusCode != fiber.StatusInternalServerError {
+		t.Errorf("expected 500 for an unknown profile, got %d", resp.StatusCode)
+	}
+	if _, ok := srv.CacheProfile("fonts"); !ok {
+		t.Error("expected the fonts profile to be registered")
+	}
+}
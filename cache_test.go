@@ -0,0 +1,97 @@
+package cartridge
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCache_Remember(t *testing.T) {
+	cache := NewCache(nil)
+
+	var calls atomic.Int32
+	load := func() (any, error) {
+		calls.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		return map[string]int{"orders": 42}, nil
+	}
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var stats map[string]int
+			if err := cache.Remember("stats", time.Minute, &stats, load); err != nil || stats["orders"] != 42 {
+				t.Errorf("unexpected result %v, %v", stats, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if calls.Load() != 1 {
+		t.Errorf("expected concurrent misses to share one call, got %d", calls.Load())
+	}
+
+	boom := errors.New("boom")
+	var v int
+	if err := cache.Remember("failing", time.Minute, &v, func() (any, error) { return nil, boom }); !errors.Is(err, boom) {
+		t.Errorf("expected fn error, got %v", err)
+	}
+	if ok, _ := cache.Get("failing", &v); ok {
+		t.Error("expected errors not to be cached")
+	}
+
+	if err := cache.Set("short", "v", time.Millisecond); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	var s string
+	if ok, _ := cache.Get("short", &s); ok {
+		t.Error("expected entry to expire")
+	}
+}
+
+func TestContext_CacheSharedWithJobs(t *testing.T) {
+	cfg := DefaultServerConfig()
+	cfg.EnableStaticAssets = false
+	cfg.EnableRequestLogger = false
+	cfg.EnableSecFetchSite = false
+	cfg.Config = &testConfig{}
+	cfg.Logger = testLogger()
+	cfg.DBManager = &testDBManager{}
+	cfg.ErrorHandler = DefaultErrorHandler(testLogger(), false)
+	cfg.Cache = NewCache(nil)
+
+	srv, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	srv.Get("/rates", func(ctx *Context) error {
+		var rate float64
+		if ok, err := ctx.Cache().Get("eur_usd", &rate); !ok || err != nil {
+			return ctx.SendString("missing")
+		}
+		return ctx.SendString(strconv.FormatFloat(rate, 'f', 2, 64))
+	})
+
+	// A cron job warms the cache the handler reads
+	job := &JobContext{Context: context.Background(), Logger: testLogger(), cache: cfg.Cache}
+	if err := job.Cache().Set("eur_usd", 1.08, time.Minute); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	resp, err := srv.App().Test(httptest.NewRequest("GET", "/rates", nil))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "1.08" {
+		t.Errorf("expected cached rate, got %q", body)
+	}
+}
@@ -0,0 +1,77 @@
+// Command routecheck reports handlers that read route parameters their
+// route doesn't declare (see package routecheck).
+//
+//	go run github.com/karloscodes/cartridge/cmd/routecheck ./...
+//
+// It exits with status 1 when it finds problems, like go vet.
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/karloscodes/cartridge/routecheck"
+)
+
+func main() {
+	patterns := os.Args[1:]
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+
+	found := false
+	for _, pattern := range patterns {
+		dirs, err := expand(pattern)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "routecheck:", err)
+			os.Exit(2)
+		}
+		for _, dir := range dirs {
+			diags, err := routecheck.CheckDir(dir)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "routecheck:", err)
+				os.Exit(2)
+			}
+			for _, d := range diags {
+				fmt.Fprintln(os.Stderr, d)
+				found = true
+			}
+		}
+	}
+	if found {
+		os.Exit(1)
+	}
+}
+
+// expand turns a directory, or a directory followed by "/...", into the
+// directories to check. Like the go command, it skips testdata, vendor and
+// directories starting with "." or "_".
+func expand(pattern string) ([]string, error) {
+	root, recursive := strings.CutSuffix(pattern, "/...")
+	if !recursive {
+		return []string{pattern}, nil
+	}
+	if root == "" {
+		root = "."
+	}
+
+	var dirs []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		name := d.Name()
+		if path != root && (name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+			return filepath.SkipDir
+		}
+		dirs = append(dirs, path)
+		return nil
+	})
+	return dirs, err
+}
@@ -0,0 +1,240 @@
+package cartridge
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Seeder fills the database with initial or sample data, e.g. an admin
+// user. Seeders run on the "seed" command and should be safe to run twice.
+type Seeder func(ctx context.Context, db *gorm.DB) error
+
+// AddSeeder adds a seeder run by the "seed" command, after those already added.
+func (a *Application) AddSeeder(seeder Seeder) {
+	a.seeders = append(a.seeders, seeder)
+}
+
+// commandUsage lists the commands RunCommand handles.
+const commandUsage = `Commands:
+  migrate                     apply all pending migrations, including contract ones
+  migrate:rollback [-step N]  revert the last N migrations (default 1)
+  migrate:status              list migrations and whether they are applied
+  seed                        run the registered seeders
+  routes                      list the registered routes
+  help                        show this help
+`
+
+// RunCommand runs the management command named by args[1], so the app's
+// binary can manage its own schema without booting the server:
+//
+//	if handled, err := app.RunCommand(os.Args); handled {
+//	    if err != nil {
+//	        log.Fatal(err)
+//	    }
+//	    return
+//	}
+//	app.Run()
+//
+// It handles migrate, migrate:rollback, migrate:status, seed, routes and
+// help, printing to stdout, and returns false without doing anything when
+// args has no command or one it doesn't know. The migrate commands use the
+// migrator from WithMigrator; rollback and status need one implementing
+// RollbackMigrator and StatusMigrator, like SQLMigrator.
+func (a *Application) RunCommand(args []string) (bool, error) {
+	return a.runCommand(os.Stdout, args)
+}
+
+func (a *Application) runCommand(w io.Writer, args []string) (bool, error) {
+	if len(args) < 2 {
+		return false, nil
+	}
+	name, rest := args[1], args[2:]
+
+	var err error
+	switch name {
+	case "migrate":
+		err = a.migrateCommand(w)
+	case "migrate:rollback":
+		err = a.rollbackCommand(w, rest)
+	case "migrate:status":
+		err = a.statusCommand(w)
+	case "seed":
+		err = a.seedCommand(w)
+	case "routes":
+		err = a.routesCommand(w)
+	case "help", "-h", "--help":
+		_, err = io.WriteString(w, commandUsage)
+	default:
+		return false, nil
+	}
+	if err != nil {
+		return true, fmt.Errorf("cartridge: %s: %w", name, err)
+	}
+	return true, nil
+}
+
+// commandDB connects to the database for a command.
+func (a *Application) commandDB() (*gorm.DB, error) {
+	if a.DBManager == nil {
+		return nil, errors.New("no database manager configured")
+	}
+	db, err := a.DBManager.Connect()
+	if err != nil {
+		return nil, fmt.Errorf("connect database: %w", err)
+	}
+	return db, nil
+}
+
+// commandMigrator returns the configured migrator and a database connection.
+func (a *Application) commandMigrator() (Migrator, *gorm.DB, error) {
+	if a.lifecycle.Migrator == nil {
+		return nil, nil, errors.New("no migrator configured (use WithMigrator)")
+	}
+	db, err := a.commandDB()
+	if err != nil {
+		return nil, nil, err
+	}
+	return a.lifecycle.Migrator, db, nil
+}
+
+func (a *Application) migrateCommand(w io.Writer) error {
+	migrator, db, err := a.commandMigrator()
+	if err != nil {
+		return err
+	}
+
+	var before []MigrationStatus
+	statusMigrator, hasStatus := migrator.(StatusMigrator)
+	if hasStatus {
+		if before, err = statusMigrator.Status(db); err != nil {
+			return err
+		}
+	}
+	if err := migrator.Migrate(db); err != nil {
+		return err
+	}
+	if checkpointer, ok := a.DBManager.(DatabaseManager); ok {
+		if err := checkpointer.CheckpointWAL("FULL"); err != nil {
+			a.Logger.Warn("failed to checkpoint WAL after migration", "error", err)
+		}
+	}
+
+	if !hasStatus {
+		_, err = fmt.Fprintln(w, "Migrations complete")
+		return err
+	}
+	applied := 0
+	for _, migration := range before {
+		if migration.AppliedAt == nil {
+			fmt.Fprintf(w, "Migrated  %s %s\n", migration.Version, migration.Name)
+			applied++
+		}
+	}
+	if applied == 0 {
+		_, err = fmt.Fprintln(w, "Nothing to migrate")
+	}
+	return err
+}
+
+func (a *Application) rollbackCommand(w io.Writer, args []string) error {
+	flags := flag.NewFlagSet("migrate:rollback", flag.ContinueOnError)
+	flags.SetOutput(w)
+	steps := flags.Int("step", 1, "number of migrations to roll back")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *steps < 1 {
+		return fmt.Errorf("-step must be at least 1, got %d", *steps)
+	}
+
+	migrator, db, err := a.commandMigrator()
+	if err != nil {
+		return err
+	}
+	rollback, ok := migrator.(RollbackMigrator)
+	if !ok {
+		return fmt.Errorf("%T does not support rollback", migrator)
+	}
+	reverted, err := rollback.Rollback(db, *steps)
+	for _, migration := range reverted {
+		fmt.Fprintf(w, "Rolled back  %s %s\n", migration.Version, migration.Name)
+	}
+	if err != nil {
+		return err
+	}
+	if len(reverted) == 0 {
+		_, err = fmt.Fprintln(w, "Nothing to roll back")
+	}
+	return err
+}
+
+func (a *Application) statusCommand(w io.Writer) error {
+	migrator, db, err := a.commandMigrator()
+	if err != nil {
+		return err
+	}
+	statusMigrator, ok := migrator.(StatusMigrator)
+	if !ok {
+		return fmt.Errorf("%T does not report status", migrator)
+	}
+	statuses, err := statusMigrator.Status(db)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tKIND\tAPPLIED")
+	for _, migration := range statuses {
+		applied := "pending"
+		if migration.AppliedAt != nil {
+			applied = migration.AppliedAt.Local().Format(time.DateTime)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", migration.Version, migration.Name, migration.Kind, applied)
+	}
+	return tw.Flush()
+}
+
+func (a *Application) seedCommand(w io.Writer) error {
+	if len(a.seeders) == 0 {
+		return errors.New("no seeders registered (use WithSeeder or AddSeeder)")
+	}
+	db, err := a.commandDB()
+	if err != nil {
+		return err
+	}
+	for i, seeder := range a.seeders {
+		if err := seeder(context.Background(), db); err != nil {
+			return fmt.Errorf("seeder %d: %w", i+1, err)
+		}
+	}
+	_, err = fmt.Fprintf(w, "Ran %d seeders\n", len(a.seeders))
+	return err
+}
+
+func (a *Application) routesCommand(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHOD\tPATH\tNAME\tACCESS")
+	for _, route := range a.Server.Routes() {
+		var access []string
+		if len(route.Roles) > 0 {
+			access = append(access, "roles="+strings.Join(route.Roles, ","))
+		}
+		if route.Policy != "" {
+			access = append(access, "policy="+route.Policy)
+		}
+		if route.Feature != "" {
+			access = append(access, "feature="+route.Feature)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", route.Method, route.Path, route.Name, strings.Join(access, " "))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,117 @@
+package cartridge
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestSQLMigrator_RollbackAndStatus(t *testing.T) {
+	db := openAsyncTestDB(t)
+	migrator := NewSQLMigrator(SQLMigratorConfig{},
+		SQLMigration{Version: "001", Name: "create_posts", SQL: "CREATE TABLE posts (id INTEGER PRIMARY KEY)", Down: "DROP TABLE posts"},
+		SQLMigration{Version: "002", Name: "add_posts_title", SQL: "ALTER TABLE posts ADD COLUMN title TEXT", Down: "ALTER TABLE posts DROP COLUMN title"},
+		SQLMigration{Version: "003", Name: "create_tags", SQL: "CREATE TABLE tags (id INTEGER PRIMARY KEY)"},
+	)
+	if err := migrator.Migrate(db); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+
+	if _, err := migrator.Rollback(db, 1); err == nil || !strings.Contains(err.Error(), "no Down SQL") {
+		t.Errorf("expected a migration without Down to stop the rollback, got %v", err)
+	}
+	db.Exec("DELETE FROM cartridge_schema_migrations WHERE version = ?", "003")
+
+	reverted, err := migrator.Rollback(db, 2)
+	if err != nil || len(reverted) != 2 || reverted[0].Version != "002" || reverted[1].Version != "001" {
+		t.Fatalf("expected 002 then 001 to be rolled back, got %+v (%v)", reverted, err)
+	}
+	if db.Migrator().HasTable("posts") {
+		t.Error("expected Down to drop the posts table")
+	}
+
+	statuses, err := migrator.Status(db)
+	if err != nil || len(statuses) != 3 {
+		t.Fatalf("expected three migrations, got %+v (%v)", statuses, err)
+	}
+	for _, status := range statuses {
+		if status.AppliedAt != nil {
+			t.Errorf("expected %s to be pending, got applied at %v", status.Version, status.AppliedAt)
+		}
+	}
+}
+
+func TestApplication_RunCommand(t *testing.T) {
+	db := openAsyncTestDB(t)
+	cfg := DefaultServerConfig()
+	cfg.EnableStaticAssets = false
+	cfg.EnableRequestLogger = false
+	cfg.EnableSecFetchSite = false
+	cfg.Config = &testConfig{}
+	cfg.Logger = testLogger()
+	cfg.DBManager = &mockDBManager{db: db}
+	cfg.ErrorHandler = DefaultErrorHandler(testLogger(), false)
+	srv, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	srv.Get("/posts", func(ctx *Context) error { return nil }, &RouteConfig{Name: "posts.index"})
+
+	app, err := NewApplication(ApplicationOptions{
+		Config:    &testConfig{},
+		Logger:    testLogger(),
+		DBManager: &mockDBManager{db: db},
+		Server:    srv,
+		Lifecycle: LifecycleConfig{Migrator: NewSQLMigrator(SQLMigratorConfig{},
+			SQLMigration{Version: "001", Name: "create_posts", SQL: "CREATE TABLE posts (id INTEGER PRIMARY KEY, title TEXT)", Down: "DROP TABLE posts"},
+		)},
+		Seeders: []Seeder{func(ctx context.Context, db *gorm.DB) error {
+			return db.Exec("INSERT INTO posts (title) VALUES ('Hello')").Error
+		}},
+	})
+	if err != nil {
+		t.Fatalf("NewApplication failed: %v", err)
+	}
+
+	run := func(args ...string) string {
+		t.Helper()
+		var out bytes.Buffer
+		handled, err := app.runCommand(&out, append([]string{"app"}, args...))
+		if !handled || err != nil {
+			t.Fatalf("%v: expected the command to be handled, got %v %v", args, handled, err)
+		}
+		return out.String()
+	}
+
+	if handled, _ := app.runCommand(&bytes.Buffer{}, []string{"app"}); handled {
+		t.Error("expected no command to fall through to the server")
+	}
+	if handled, _ := app.runCommand(&bytes.Buffer{}, []string{"app", "serve"}); handled {
+		t.Error("expected an unknown command to fall through")
+	}
+
+	if out := run("migrate:status"); !strings.Contains(out, "create_posts") || !strings.Contains(out, "pending") {
+		t.Errorf("expected a pending migration, got %q", out)
+	}
+	if out := run("migrate"); !strings.Contains(out, "Migrated  001 create_posts") {
+		t.Errorf("unexpected migrate output %q", out)
+	}
+	run("seed")
+	var count int64
+	db.Table("posts").Count(&count)
+	if count != 1 {
+		t.Errorf("expected the seeder to insert a post, got %d", count)
+	}
+	if out := run("routes"); !strings.Contains(out, "/posts") || !strings.Contains(out, "posts.index") {
+		t.Errorf("expected the route table, got %q", out)
+	}
+	if out := run("migrate:rollback", "-step", "1"); !strings.Contains(out, "Rolled back  001 create_posts") {
+		t.Errorf("unexpected rollback output %q", out)
+	}
+	if out := run("migrate:rollback"); !strings.Contains(out, "Nothing to roll back") {
+		t.Errorf("expected nothing left to roll back, got %q", out)
+	}
+}
@@ -0,0 +1,37 @@
+package cartridge
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	cartridgemiddleware "github.com/karloscodes/cartridge/middleware"
+)
+
+// compressConfig is the compression configuration for server and route
+// middleware.
+func (s *Server) compressConfig() cartridgemiddleware.CompressConfig {
+	if s.cfg.Compress != nil {
+		return *s.cfg.Compress
+	}
+	return cartridgemiddleware.DefaultCompressConfig()
+}
+
+// routeCompress applies RouteConfig.Compress: enabled adds the middleware
+// unless it already runs globally, disabled marks the response to be sent
+// uncompressed. A route compressed on its own gets weak ETags, as the
+// server does with EnableCompress.
+func (s *Server) routeCompress(enabled bool) fiber.Handler {
+	if !enabled {
+		return func(c *fiber.Ctx) error {
+			cartridgemiddleware.SkipCompress(c)
+			return c.Next()
+		}
+	}
+	if s.cfg.EnableCompress {
+		return func(c *fiber.Ctx) error { return c.Next() }
+	}
+	compress := cartridgemiddleware.Compress(s.compressConfig())
+	return func(c *fiber.Ctx) error {
+		cartridgemiddleware.WeakETag(c)
+		return compress(c)
+	}
+}
@@ -0,0 +1,50 @@
+package cartridge
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestRouteConfig_Compress(t *testing.T) {
+	page := strings.Repeat("<p>report</p>", 200)
+	handler := func(ctx *Context) error {
+		ctx.Type("html")
+		return ctx.SendString(page)
+	}
+
+	for _, enabled := range []bool{true, false} {
+		cfg := DefaultServerConfig()
+		cfg.EnableStaticAssets = false
+		cfg.EnableRequestLogger = false
+		cfg.EnableSecFetchSite = false
+		cfg.EnableCompress = enabled
+		cfg.Config = &testConfig{}
+		cfg.Logger = testLogger()
+		cfg.DBManager = &testDBManager{}
+		srv, err := NewServer(cfg)
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		srv.Get("/default", handler)
+		srv.Get("/on", handler, &RouteConfig{Compress: Bool(true)})
+		srv.Get("/off", handler, &RouteConfig{Compress: Bool(false)})
+
+		want := map[string]string{"/default": "", "/on": "br", "/off": ""}
+		if enabled {
+			want["/default"] = "br"
+		}
+		for path, encoding := range want {
+			req := httpGet(path)
+			req.Header.Set(fiber.HeaderAcceptEncoding, "gzip, br")
+			resp, err := srv.App().Test(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			if got := resp.Header.Get(fiber.HeaderContentEncoding); got != encoding {
+				t.Errorf("server compression %v, %s: expected encoding %q, got %q", enabled, path, encoding, got)
+			}
+		}
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"maps"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 
 	"github.com/spf13/viper"
@@ -18,6 +20,13 @@ const (
 	Test        = "test"
 )
 
+// Database driver constants.
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+	DriverMySQL    = "mysql"
+)
+
 // Config provides common configuration for cartridge applications.
 // Apps can embed this struct and add their own fields.
 type Config struct {
@@ -45,19 +54,35 @@ type Config struct {
 	SessionTimeout int    `mapstructure:"sessiontimeoutseconds"`
 
 	// Data and database configuration.
-	DataDirectory    string `mapstructure:"datadirectory"`
-	DatabaseFilename string `mapstructure:"databasefilename"`
-	DatabasePath     string `mapstructure:"-"` // Resolved path, not from env
-	MaxOpenConns     int    `mapstructure:"databasemaxopenconns"`
-	MaxIdleConns     int    `mapstructure:"databasemaxidleconns"`
+	// DatabaseDriver selects the backend: sqlite (default), postgres, or mysql.
+	// DatabaseURL is the connection string for postgres and mysql.
+	// DatabaseReplicaURLs is a comma-separated list of read replica URLs.
+	DatabaseDriver      string `mapstructure:"databasedriver"`
+	DatabaseURL         string `mapstructure:"databaseurl"`
+	DatabaseReplicaURLs string `mapstructure:"databasereplicaurls"`
+	DataDirectory       string `mapstructure:"datadirectory"`
+	DatabaseFilename    string `mapstructure:"databasefilename"`
+	DatabasePath        string `mapstructure:"-"` // Resolved path, not from env
+	MaxOpenConns        int    `mapstructure:"databasemaxopenconns"`
+	MaxIdleConns        int    `mapstructure:"databasemaxidleconns"`
 
 	// Internal: the env var prefix (derived from AppName).
 	envPrefix string
+	sources   map[string]string
+	settings  *viper.Viper      // file and .env settings, for Get
+	file      string            // config file read by Load, if any
+	secrets   map[string]string // fetched by ResolveSecrets, by name
 }
 
 // Load creates a new Config for the given app name.
 // It reads from environment variables prefixed with the uppercase app name.
 // Example: Load("formlander") reads FORMLANDER_ENV, FORMLANDER_PORT, etc.
+//
+// Settings can also come from a cartridge.yaml, .yml, .toml or .json file in
+// the working directory or config/, or the file named by {PREFIX}_CONFIG.
+// A development, production or test section overrides the top-level
+// settings in that environment. Precedence, highest first: environment
+// variables, .env, the environment's section, the rest of the file, defaults.
 func Load(appName string) (*Config, error) {
 	v := viper.New()
 
@@ -69,10 +94,11 @@ func Load(appName string) (*Config, error) {
 	prefix := strings.ToUpper(appName)
 
 	// Read .env file if present
-	v.SetConfigName(".env")
-	v.SetConfigType("env")
-	v.AddConfigPath(".")
-	_ = v.ReadInConfig()
+	dotenv := viper.New()
+	dotenv.SetConfigName(".env")
+	dotenv.SetConfigType("env")
+	dotenv.AddConfigPath(".")
+	_ = dotenv.ReadInConfig()
 
 	// Set defaults
 	setDefaults(v, appName)
@@ -81,11 +107,37 @@ func Load(appName string) (*Config, error) {
 	v.SetEnvPrefix(prefix)
 	bindEnvVars(v, prefix)
 
-	cfg := &Config{envPrefix: prefix}
+	// Layer the config file beneath .env
+	file, err := findConfigFile(prefix)
+	if err != nil {
+		return nil, err
+	}
+	if file != "" {
+		base, sections, err := readConfigFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if err := v.MergeConfigMap(base); err != nil {
+			return nil, fmt.Errorf("config: merge %s: %w", file, err)
+		}
+		if err := v.MergeConfigMap(dotenv.AllSettings()); err != nil {
+			return nil, fmt.Errorf("config: merge .env: %w", err)
+		}
+		if err := v.MergeConfigMap(sections[v.GetString("environment")]); err != nil {
+			return nil, fmt.Errorf("config: merge %s: %w", file, err)
+		}
+	}
+	if err := v.MergeConfigMap(dotenv.AllSettings()); err != nil {
+		return nil, fmt.Errorf("config: merge .env: %w", err)
+	}
+
+	cfg := &Config{envPrefix: prefix, settings: v, file: file}
 	if err := v.Unmarshal(cfg); err != nil {
 		return nil, fmt.Errorf("config: unmarshal: %w", err)
 	}
 
+	cfg.sources = loadSources(v, dotenv, prefix)
+
 	// Resolve database path
 	cfg.DatabasePath = cfg.resolveDatabasePath()
 
@@ -114,21 +166,82 @@ func setDefaults(v *viper.Viper, appName string) {
 
 	v.SetDefault("sessiontimeoutseconds", 604800) // 1 week
 
+	v.SetDefault("databasedriver", DriverSQLite)
 	v.SetDefault("datadirectory", "storage")
 	v.SetDefault("databasefilename", appName+".db")
 	v.SetDefault("databasemaxopenconns", 0)
 	v.SetDefault("databasemaxidleconns", 0)
 }
 
+// envVars maps config keys to their env var, after the prefix.
+var envVars = map[string]string{
+	"environment":         "_ENV",
+	"port":                "_PORT",
+	"sessionsecret":       "_SESSION_SECRET",
+	"loglevel":            "_LOG_LEVEL",
+	"datadirectory":       "_DATA_DIR",
+	"debug":               "_DEBUG",
+	"databasedriver":      "_DATABASE_DRIVER",
+	"databaseurl":         "_DATABASE_URL",
+	"databasereplicaurls": "_DATABASE_REPLICA_URLS",
+}
+
 func bindEnvVars(v *viper.Viper, prefix string) {
 	// Core env vars: {PREFIX}_ENV, {PREFIX}_PORT, etc.
 	// BindEnv errors are ignored - these bindings are simple key mappings
-	_ = v.BindEnv("environment", prefix+"_ENV")
-	_ = v.BindEnv("port", prefix+"_PORT")
-	_ = v.BindEnv("sessionsecret", prefix+"_SESSION_SECRET")
-	_ = v.BindEnv("loglevel", prefix+"_LOG_LEVEL")
-	_ = v.BindEnv("datadirectory", prefix+"_DATA_DIR")
-	_ = v.BindEnv("debug", prefix+"_DEBUG")
+	for key, suffix := range envVars {
+		_ = v.BindEnv(key, prefix+suffix)
+	}
+}
+
+// loadSources records where each field's value came from.
+func loadSources(v, dotenv *viper.Viper, prefix string) map[string]string {
+	sources := make(map[string]string)
+	t := reflect.TypeFor[Config]()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := field.Tag.Get("mapstructure")
+		if key == "" || key == "-" {
+			continue
+		}
+		source := SourceDefault
+		if suffix, ok := envVars[key]; ok && os.Getenv(prefix+suffix) != "" {
+			source = SourceEnv
+		} else if dotenv.InConfig(key) {
+			source = SourceDotEnv
+		} else if v.InConfig(key) {
+			source = SourceFile
+		}
+		sources[field.Name] = source
+	}
+	return sources
+}
+
+// Setting sources reported by Sources.
+const (
+	SourceDefault = "default" // built-in default
+	SourceEnv     = "env"     // environment variable
+	SourceDotEnv  = ".env"    // .env file
+	SourceFile    = "file"    // config file, e.g. cartridge.yaml
+	SourceDerived = "derived" // adjusted by Load, e.g. the development session secret
+	SourceSecret  = "secret"  // fetched by ResolveSecrets
+)
+
+// Sources reports where each setting came from (SourceDefault, SourceEnv,
+// SourceDotEnv, SourceFile, SourceDerived or SourceSecret), keyed by field
+// name. It is empty for a Config not created by Load.
+func (c *Config) Sources() map[string]string {
+	return maps.Clone(c.sources)
+}
+
+// File returns the config file Load read, or "" when there was none.
+func (c *Config) File() string { return c.file }
+
+// derived marks a field Load adjusted.
+func (c *Config) derived(field string) {
+	if c.sources != nil {
+		c.sources[field] = SourceDerived
+	}
 }
 
 func (c *Config) validate() error {
@@ -138,6 +251,7 @@ func (c *Config) validate() error {
 	if c.LogLevel == "" || c.LogLevel == "error" {
 		if c.IsDevelopment() || c.IsTest() {
 			c.LogLevel = "info"
+			c.derived("LogLevel")
 		}
 	}
 
@@ -145,6 +259,7 @@ func (c *Config) validate() error {
 	if c.SessionSecret == "" {
 		if pk := os.Getenv("PRIVATE_KEY"); pk != "" {
 			c.SessionSecret = pk
+			c.derived("SessionSecret")
 		}
 	}
 	if c.IsProduction() {
@@ -153,11 +268,29 @@ func (c *Config) validate() error {
 		}
 	} else if c.SessionSecret == "" {
 		c.SessionSecret = "dev-secret-do-not-use-in-production-f8e3a9c2d1b7e6a4"
+		c.derived("SessionSecret")
 		if c.IsDevelopment() {
 			log.Printf("info: Using default development secret (set %s_SESSION_SECRET for custom value)", c.envPrefix)
 		}
 	}
 
+	// Validate database driver
+	c.DatabaseDriver = strings.ToLower(strings.TrimSpace(c.DatabaseDriver))
+	switch c.DatabaseDriver {
+	case "":
+		c.DatabaseDriver = DriverSQLite
+	case DriverSQLite:
+	case DriverPostgres, DriverMySQL:
+		if c.DatabaseURL == "" {
+			problems = append(problems, fmt.Sprintf("%s_DATABASE_URL is required for the %s driver", c.envPrefix, c.DatabaseDriver))
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("invalid %s_DATABASE_DRIVER value %q (expected sqlite, postgres, or mysql)", c.envPrefix, c.DatabaseDriver))
+	}
+	if c.DatabaseDriver == DriverSQLite && len(c.DatabaseReplicaDSNs()) > 0 {
+		problems = append(problems, fmt.Sprintf("%s_DATABASE_REPLICA_URLS is not supported for the sqlite driver", c.envPrefix))
+	}
+
 	// Validate environment
 	switch c.Environment {
 	case Development, Production, Test:
@@ -225,12 +358,40 @@ func (c *Config) GetAppName() string      { return c.AppName }
 
 // Database configuration.
 
-func (c *Config) DatabaseDSN() string { return c.DatabasePath }
+// GetDatabaseDriver returns the database driver name (sqlite, postgres, or mysql).
+func (c *Config) GetDatabaseDriver() string {
+	if c.DatabaseDriver == "" {
+		return DriverSQLite
+	}
+	return c.DatabaseDriver
+}
+
+// DatabaseDSN returns the SQLite file path, or DatabaseURL for other drivers.
+func (c *Config) DatabaseDSN() string {
+	if c.GetDatabaseDriver() != DriverSQLite {
+		return c.DatabaseURL
+	}
+	return c.DatabasePath
+}
+
+// DatabaseReplicaDSNs returns the read replica connection strings, if any.
+func (c *Config) DatabaseReplicaDSNs() []string {
+	var dsns []string
+	for _, dsn := range strings.Split(c.DatabaseReplicaURLs, ",") {
+		if dsn = strings.TrimSpace(dsn); dsn != "" {
+			dsns = append(dsns, dsn)
+		}
+	}
+	return dsns
+}
 
 func (c *Config) GetMaxOpenConns() int {
 	if c.MaxOpenConns > 0 {
 		return c.MaxOpenConns
 	}
+	if c.GetDatabaseDriver() != DriverSQLite {
+		return 25
+	}
 	if c.IsProduction() {
 		return 10
 	}
@@ -241,7 +402,7 @@ func (c *Config) GetMaxIdleConns() int {
 	if c.MaxIdleConns > 0 {
 		return c.MaxIdleConns
 	}
-	if c.IsProduction() {
+	if c.GetDatabaseDriver() != DriverSQLite || c.IsProduction() {
 		return 5
 	}
 	return 1
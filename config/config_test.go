@@ -1,7 +1,13 @@
 package config
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestConfig_EnvironmentMethods(t *testing.T) {
@@ -190,6 +196,26 @@ func TestLoad(t *testing.T) {
 		}
 	})
 
+	t.Run("reports where settings came from", func(t *testing.T) {
+		t.Setenv("SRCAPP_ENV", "test")
+		t.Setenv("SRCAPP_PORT", "9090")
+		cfg, err := Load("srcapp")
+		if err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+
+		sources := cfg.Sources()
+		if sources["Port"] != SourceEnv || sources["Environment"] != SourceEnv {
+			t.Errorf("expected env sources, got %v", sources)
+		}
+		if sources["LogsDirectory"] != SourceDefault {
+			t.Errorf("expected a default source, got %q", sources["LogsDirectory"])
+		}
+		if sources["SessionSecret"] != SourceDerived || sources["LogLevel"] != SourceDerived {
+			t.Errorf("expected development fallbacks to be derived, got %v", sources)
+		}
+	})
+
 	t.Run("requires session secret in production", func(t *testing.T) {
 		t.Setenv("PRODAPP_ENV", "production")
 		t.Setenv("PRODAPP_SESSION_SECRET", "")
@@ -199,3 +225,197 @@ func TestLoad(t *testing.T) {
 		}
 	})
 }
+
+func TestLoad_DatabaseDriver(t *testing.T) {
+	t.Run("defaults to sqlite", func(t *testing.T) {
+		t.Setenv("DBAPP_ENV", "test")
+		cfg, err := Load("dbapp")
+		if err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		if cfg.GetDatabaseDriver() != DriverSQLite {
+			t.Errorf("expected sqlite, got %s", cfg.GetDatabaseDriver())
+		}
+		if cfg.DatabaseDSN() != cfg.DatabasePath {
+			t.Errorf("expected sqlite DSN to be the database path, got %s", cfg.DatabaseDSN())
+		}
+	})
+
+	t.Run("uses database URL for postgres", func(t *testing.T) {
+		t.Setenv("PGAPP_ENV", "test")
+		t.Setenv("PGAPP_DATABASE_DRIVER", "Postgres")
+		t.Setenv("PGAPP_DATABASE_URL", "postgres://localhost/pgapp")
+		cfg, err := Load("pgapp")
+		if err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		if cfg.GetDatabaseDriver() != DriverPostgres {
+			t.Errorf("expected postgres, got %s", cfg.GetDatabaseDriver())
+		}
+		if cfg.DatabaseDSN() != "postgres://localhost/pgapp" {
+			t.Errorf("unexpected DSN %s", cfg.DatabaseDSN())
+		}
+		if cfg.GetMaxOpenConns() != 25 || cfg.GetMaxIdleConns() != 5 {
+			t.Errorf("unexpected pool settings %d/%d", cfg.GetMaxOpenConns(), cfg.GetMaxIdleConns())
+		}
+	})
+
+	t.Run("requires database URL for mysql", func(t *testing.T) {
+		t.Setenv("MYSQLAPP_ENV", "test")
+		t.Setenv("MYSQLAPP_DATABASE_DRIVER", "mysql")
+		if _, err := Load("mysqlapp"); err == nil {
+			t.Error("expected error when database URL is missing")
+		}
+	})
+
+	t.Run("rejects unknown driver", func(t *testing.T) {
+		t.Setenv("ORAAPP_ENV", "test")
+		t.Setenv("ORAAPP_DATABASE_DRIVER", "oracle")
+		if _, err := Load("oraapp"); err == nil {
+			t.Error("expected error for unknown driver")
+		}
+	})
+}
+
+func TestLoad_ConfigFile(t *testing.T) {
+	t.Run("merges the environment section and env overrides", func(t *testing.T) {
+		t.Chdir(t.TempDir())
+		writeFile(t, "cartridge.yaml", `
+port: 3000
+log_level: warn
+retries: 3
+stripe:
+  api_key: sk_base
+  timeout: 30s
+test:
+  port: 4000
+  stripe:
+    api_key: sk_test
+production:
+  port: 80
+`)
+		t.Setenv("FILEAPP_ENV", "test")
+		t.Setenv("FILEAPP_WEBHOOK_SECRET", "whsec")
+
+		cfg, err := Load("fileapp")
+		if err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		if cfg.Port != "4000" || cfg.LogLevel != "warn" || cfg.File() != "cartridge.yaml" {
+			t.Errorf("expected the file's test settings, got port %s, log level %s, file %q", cfg.Port, cfg.LogLevel, cfg.File())
+		}
+		if got := cfg.GetString("stripe.api_key"); got != "sk_test" {
+			t.Errorf("expected the test section's key, got %q", got)
+		}
+		if got := cfg.GetDuration("stripe.timeout"); got != 30*time.Second {
+			t.Errorf("expected the top-level timeout to be kept, got %v", got)
+		}
+		if cfg.GetInt("retries") != 3 || cfg.GetString("log_level") != "warn" || cfg.GetString("webhook.secret") != "whsec" {
+			t.Errorf("unexpected typed values: %d %q %q", cfg.GetInt("retries"), cfg.GetString("log_level"), cfg.GetString("webhook.secret"))
+		}
+		if sources := cfg.Sources(); sources["Port"] != SourceFile || sources["Environment"] != SourceEnv {
+			t.Errorf("expected file and env sources, got %v", sources)
+		}
+
+		t.Setenv("FILEAPP_STRIPE_API_KEY", "sk_env")
+		if got := cfg.GetString("stripe.api_key"); got != "sk_env" {
+			t.Errorf("expected the environment to win, got %q", got)
+		}
+	})
+
+	t.Run("reads the file named by the environment", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Chdir(dir)
+		writeFile(t, "settings.toml", "port = \"5000\"\n\n[mail]\nfrom = \"hi@example.com\"\n")
+		t.Setenv("TOMLAPP_ENV", "test")
+		t.Setenv("TOMLAPP_CONFIG", "settings.toml")
+
+		cfg, err := Load("tomlapp")
+		if err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		if cfg.Port != "5000" || cfg.GetString("mail.from") != "hi@example.com" {
+			t.Errorf("expected the TOML settings, got port %s and %q", cfg.Port, cfg.GetString("mail.from"))
+		}
+
+		t.Setenv("TOMLAPP_CONFIG", "missing.yaml")
+		if _, err := Load("tomlapp"); err == nil {
+			t.Error("expected an error for a missing config file")
+		}
+	})
+}
+
+func TestConfig_Require(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeFile(t, "cartridge.json", `{"mail": {"from": "hi@example.com", "host": ""}}`)
+	t.Setenv("REQAPP_ENV", "test")
+
+	cfg, err := Load("reqapp")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if err := cfg.Require("mail.from", "port"); err != nil {
+		t.Errorf("expected set keys to pass, got %v", err)
+	}
+	err = cfg.Require("mail.from", "mail.host", "stripe.api_key")
+	if err == nil || !strings.Contains(err.Error(), "mail.host (REQAPP_MAIL_HOST), stripe.api_key (REQAPP_STRIPE_API_KEY)") {
+		t.Errorf("expected the missing keys with their env vars, got %v", err)
+	}
+}
+
+type mapSecrets map[string]string
+
+func (m mapSecrets) Secret(_ context.Context, name string) (string, error) {
+	if value, ok := m[name]; ok {
+		return value, nil
+	}
+	return "", fmt.Errorf("%s: %w", name, ErrSecretNotFound)
+}
+
+func TestConfig_ResolveSecrets(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeFile(t, "cartridge.yaml", "stripe:\n  api_key: ${secret:stripe_key}\n")
+	t.Setenv("SECAPP_ENV", "production")
+	t.Setenv("SECAPP_SESSION_SECRET", "${secret:session}")
+	t.Setenv("SECAPP_DATABASE_DRIVER", "postgres")
+	t.Setenv("SECAPP_DATABASE_URL", "postgres://app:${secret:db_password}@db/app")
+	t.Setenv("SECAPP_MAIL_PASSWORD", "${secret:mail}")
+
+	cfg, err := Load("secapp")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	secrets := mapSecrets{"session": "s3cr3t", "db_password": "pw", "stripe_key": "sk_live", "mail": "hunter2"}
+	if err := cfg.ResolveSecrets(context.Background(), secrets); err != nil {
+		t.Fatalf("ResolveSecrets failed: %v", err)
+	}
+	if cfg.SessionSecret != "s3cr3t" || cfg.DatabaseURL != "postgres://app:pw@db/app" {
+		t.Errorf("expected resolved fields, got %q %q", cfg.SessionSecret, cfg.DatabaseURL)
+	}
+	if got := cfg.GetString("stripe.api_key"); got != "sk_live" {
+		t.Errorf("expected the file setting resolved, got %q", got)
+	}
+	if got := cfg.GetString("mail.password"); got != "hunter2" {
+		t.Errorf("expected the env override resolved, got %q", got)
+	}
+	if got := cfg.Sources()["SessionSecret"]; got != SourceSecret {
+		t.Errorf("expected source %q, got %q", SourceSecret, got)
+	}
+
+	cfg, err = Load("secapp")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	delete(secrets, "db_password")
+	err = cfg.ResolveSecrets(context.Background(), secrets)
+	if !errors.Is(err, ErrSecretNotFound) || !strings.Contains(err.Error(), `"db_password" for DatabaseURL`) {
+		t.Errorf("expected the missing secret and its setting, got %v", err)
+	}
+}
+
+func writeFile(t *testing.T, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+}
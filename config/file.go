@@ -0,0 +1,190 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+)
+
+// configFileNames are the files Load looks for, in order, in the working
+// directory and then in config/.
+var configFileNames = []string{"cartridge.yaml", "cartridge.yml", "cartridge.toml", "cartridge.json"}
+
+// findConfigFile returns the config file to read: {PREFIX}_CONFIG when set,
+// otherwise the first of configFileNames that exists, or "" for none.
+func findConfigFile(prefix string) (string, error) {
+	if path := os.Getenv(prefix + "_CONFIG"); path != "" {
+		if _, err := os.Stat(path); err != nil {
+			return "", fmt.Errorf("config: %s_CONFIG: %w", prefix, err)
+		}
+		return path, nil
+	}
+	for _, dir := range []string{".", "config"} {
+		for _, name := range configFileNames {
+			path := filepath.Join(dir, name)
+			if _, err := os.Stat(path); err == nil {
+				return path, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// readConfigFile reads a YAML, TOML or JSON file. It returns the top-level
+// settings and the development, production and test sections separately,
+// with keys naming Config fields normalized (see fieldKey).
+func readConfigFile(path string) (base map[string]any, sections map[string]map[string]any, err error) {
+	fv := viper.New()
+	fv.SetConfigFile(path)
+	if err := fv.ReadInConfig(); err != nil {
+		return nil, nil, fmt.Errorf("config: read %s: %w", path, err)
+	}
+
+	base = make(map[string]any)
+	sections = make(map[string]map[string]any)
+	for key, value := range fv.AllSettings() {
+		if section, ok := value.(map[string]any); ok && isEnvironment(key) {
+			sections[key] = normalizeKeys(section)
+			continue
+		}
+		base[key] = value
+	}
+	return normalizeKeys(base), sections, nil
+}
+
+func isEnvironment(name string) bool {
+	return name == Development || name == Production || name == Test
+}
+
+// normalizeKeys renames top-level keys that name Config fields.
+func normalizeKeys(settings map[string]any) map[string]any {
+	out := make(map[string]any, len(settings))
+	for key, value := range settings {
+		out[fieldKey(key)] = value
+	}
+	return out
+}
+
+// fieldKey maps the names a Config field goes by in files and lookups
+// (log_level, LogLevel, LOG_LEVEL or loglevel) to its mapstructure key.
+// Other keys are returned lowercased.
+func fieldKey(key string) string {
+	key = strings.ToLower(strings.ReplaceAll(key, "-", "_"))
+	for tag, suffix := range envVars {
+		if key == strings.ToLower(suffix[1:]) {
+			return tag
+		}
+	}
+	compact := strings.ReplaceAll(key, "_", "")
+	t := reflect.TypeFor[Config]()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("mapstructure")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		if compact == tag || compact == strings.ToLower(field.Name) {
+			return tag
+		}
+	}
+	return key
+}
+
+// field returns the Config field with the given mapstructure key.
+func (c *Config) field(key string) (reflect.Value, bool) {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if tag := t.Field(i).Tag.Get("mapstructure"); tag == key && tag != "-" {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// envName returns the environment variable that overrides key, e.g.
+// MYAPP_STRIPE_API_KEY for stripe.api_key.
+func (c *Config) envName(key string) string {
+	if suffix, ok := envVars[key]; ok {
+		return c.envPrefix + suffix
+	}
+	return c.envPrefix + "_" + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+}
+
+// Get returns the setting at key, a dotted path into the config file such
+// as "stripe.api_key". An environment variable named after the key, e.g.
+// MYAPP_STRIPE_API_KEY, takes precedence over the file. Keys naming Config
+// fields, e.g. "log_level", return the field. Get returns nil for unknown
+// keys.
+func (c *Config) Get(key string) any {
+	key = fieldKey(key)
+	if field, ok := c.field(key); ok {
+		return field.Interface()
+	}
+	if c.envPrefix != "" {
+		if value, ok := os.LookupEnv(c.envName(key)); ok {
+			return c.expandSecrets(value)
+		}
+	}
+	if c.settings == nil {
+		return nil
+	}
+	return c.settings.Get(key)
+}
+
+// Settings returns the defaults and the config file and .env settings,
+// keyed as in the file. It is nil for a Config not created by Load.
+func (c *Config) Settings() map[string]any {
+	if c.settings == nil {
+		return nil
+	}
+	return c.settings.AllSettings()
+}
+
+// GetString returns the setting at key as a string (see Get).
+func (c *Config) GetString(key string) string { return cast.ToString(c.Get(key)) }
+
+// GetInt returns the setting at key as an int, or 0 when it isn't a number.
+func (c *Config) GetInt(key string) int { return cast.ToInt(c.Get(key)) }
+
+// GetBool returns the setting at key as a bool.
+func (c *Config) GetBool(key string) bool { return cast.ToBool(c.Get(key)) }
+
+// GetDuration returns the setting at key as a duration. Strings are parsed
+// with time.ParseDuration, e.g. "30s"; plain numbers are nanoseconds.
+func (c *Config) GetDuration(key string) time.Duration { return cast.ToDuration(c.Get(key)) }
+
+// IsSet reports whether the setting at key has a non-empty value.
+func (c *Config) IsSet(key string) bool {
+	value := c.Get(key)
+	if value == nil {
+		return false
+	}
+	if s, ok := value.(string); ok {
+		return s != ""
+	}
+	return true
+}
+
+// Require returns an error naming every key that isn't set, with the
+// environment variable that would set it. Call it at startup, or use
+// cartridge.WithRequiredConfig.
+func (c *Config) Require(keys ...string) error {
+	var missing []string
+	for _, key := range keys {
+		if !c.IsSet(key) {
+			missing = append(missing, fmt.Sprintf("%s (%s)", key, c.envName(fieldKey(key))))
+		}
+	}
+	if len(missing) > 0 {
+		return errors.New("config: missing required settings: " + strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// SecretsProvider fetches secrets, such as the session secret or a database
+// password, from a secrets store. The cartridge package provides EnvSecrets,
+// FileSecrets, VaultSecrets and AWSSecrets.
+type SecretsProvider interface {
+	// Secret returns the secret called name, or an error wrapping
+	// ErrSecretNotFound when there is none.
+	Secret(ctx context.Context, name string) (string, error)
+}
+
+// ErrSecretNotFound is returned by a SecretsProvider for an unknown secret.
+var ErrSecretNotFound = errors.New("config: secret not found")
+
+// secretRef matches a secret reference, ${secret:NAME}.
+var secretRef = regexp.MustCompile(`\$\{secret:([^}]+)\}`)
+
+// ResolveSecrets replaces each ${secret:NAME} in the settings with the
+// secret NAME from p. References can make up a whole value or part of one:
+//
+//	MYAPP_SESSION_SECRET=${secret:session_secret}
+//	MYAPP_DATABASE_URL=postgres://app:${secret:db_password}@db/app
+//
+// They work in Config fields, in PRIVATE_KEY, in the config file and .env,
+// and in environment variables overriding app settings read with Get.
+// Secrets are inserted as is, so a password in a URL must be URL-safe.
+//
+// Secrets are fetched once. To pick up rotated secrets, load the config
+// again and resolve it (see cartridge.WithSecrets).
+func (c *Config) ResolveSecrets(ctx context.Context, p SecretsProvider) error {
+	if c.secrets == nil {
+		c.secrets = make(map[string]string)
+	}
+	fetch := func(setting, value string) (string, error) {
+		var err error
+		expanded := secretRef.ReplaceAllStringFunc(value, func(ref string) string {
+			name := secretRef.FindStringSubmatch(ref)[1]
+			secret, ok := c.secrets[name]
+			if !ok && err == nil {
+				secret, err = p.Secret(ctx, name)
+				if err != nil {
+					err = fmt.Errorf("config: secret %q for %s: %w", name, setting, err)
+					return ref
+				}
+				c.secrets[name] = secret
+			}
+			return secret
+		})
+		return expanded, err
+	}
+
+	// Config fields
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || field.Type.Kind() != reflect.String || !secretRef.MatchString(v.Field(i).String()) {
+			continue
+		}
+		value, err := fetch(field.Name, v.Field(i).String())
+		if err != nil {
+			return err
+		}
+		v.Field(i).SetString(value)
+		if c.sources != nil {
+			c.sources[field.Name] = SourceSecret
+		}
+	}
+
+	// App settings from the file and .env
+	if c.settings != nil {
+		for _, key := range c.settings.AllKeys() {
+			if _, ok := c.field(key); ok {
+				continue
+			}
+			value, ok := c.settings.Get(key).(string)
+			if !ok || !secretRef.MatchString(value) {
+				continue
+			}
+			value, err := fetch(key, value)
+			if err != nil {
+				return err
+			}
+			c.settings.Set(key, value)
+		}
+	}
+
+	// Environment overrides of app settings, expanded by Get
+	if c.envPrefix != "" {
+		for _, env := range os.Environ() {
+			name, value, _ := strings.Cut(env, "=")
+			if strings.HasPrefix(name, c.envPrefix+"_") && secretRef.MatchString(value) {
+				if _, err := fetch(name, value); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// expandSecrets replaces the secret references in value with the secrets
+// fetched by ResolveSecrets.
+func (c *Config) expandSecrets(value string) string {
+	if c.secrets == nil {
+		return value
+	}
+	return secretRef.ReplaceAllStringFunc(value, func(ref string) string {
+		if secret, ok := c.secrets[secretRef.FindStringSubmatch(ref)[1]]; ok {
+			return secret
+		}
+		return ref
+	})
+}
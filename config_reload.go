@@ -0,0 +1,307 @@
+package cartridge
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/karloscodes/cartridge/config"
+	cartridgemiddleware "github.com/karloscodes/cartridge/middleware"
+)
+
+// configReloadDebounce groups the events of one editor save into a single
+// reload.
+const configReloadDebounce = 100 * time.Millisecond
+
+// ConfigChangeFunc is called with the previous and the reloaded config.
+type ConfigChangeFunc func(old, new *config.Config)
+
+// configReloader reloads the config when its file changes or the process
+// receives SIGHUP, and passes the old and new config to the OnConfigChange
+// callbacks. A reload that fails, e.g. on a syntax error, is logged and the
+// current config stays in effect.
+type configReloader struct {
+	load     func() (*config.Config, error)
+	current  atomic.Pointer[config.Config]
+	debounce time.Duration
+	interval time.Duration // reload periodically too, e.g. for rotated secrets
+	logger   Logger
+
+	mu        sync.Mutex // serializes reloads
+	callbacks []ConfigChangeFunc
+
+	watcher *fsnotify.Watcher
+	signals chan os.Signal
+	stop    chan struct{}
+	done    sync.WaitGroup
+}
+
+func newConfigReloader(cfg *config.Config, load func() (*config.Config, error)) *configReloader {
+	r := &configReloader{load: load, debounce: configReloadDebounce}
+	r.current.Store(cfg)
+	return r
+}
+
+// SetupWorker implements WorkerSetup.
+func (r *configReloader) SetupWorker(logger Logger, _ DBManager) {
+	r.logger = logger
+}
+
+// Start listens for SIGHUP and watches the config file, if there is one.
+// If the file can't be watched it logs a warning; SIGHUP still works.
+func (r *configReloader) Start() error {
+	r.stop = make(chan struct{})
+	r.signals = make(chan os.Signal, 1)
+	signal.Notify(r.signals, syscall.SIGHUP)
+
+	if file := r.current.Load().File(); file != "" {
+		watcher, err := fsnotify.NewWatcher()
+		if err == nil {
+			// Watch the directory: editors replace the file rather than write it
+			err = watcher.Add(filepath.Dir(file))
+			if err != nil {
+				watcher.Close()
+			}
+		}
+		if err != nil {
+			r.logger.Warn("config watcher unavailable; send SIGHUP to reload", "file", file, "error", err)
+		} else {
+			r.watcher = watcher
+		}
+	}
+
+	r.done.Add(1)
+	go r.run()
+	return nil
+}
+
+// Stop stops watching.
+func (r *configReloader) Stop() {
+	if r.stop == nil {
+		return
+	}
+	signal.Stop(r.signals)
+	close(r.stop)
+	if r.watcher != nil {
+		r.watcher.Close()
+	}
+	r.done.Wait()
+	r.stop = nil
+	r.watcher = nil
+}
+
+// run reloads on SIGHUP, after the debounce on file events, and every
+// interval if set.
+func (r *configReloader) run() {
+	defer r.done.Done()
+
+	var events chan fsnotify.Event
+	var errs chan error
+	var file string
+	if r.watcher != nil {
+		events, errs = r.watcher.Events, r.watcher.Errors
+		file = filepath.Clean(r.current.Load().File())
+	}
+	timer := time.NewTimer(r.debounce)
+	timer.Stop()
+	defer timer.Stop()
+	var tick <-chan time.Time
+	if r.interval > 0 {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-r.signals:
+			r.logger.Info("SIGHUP received, reloading config")
+			r.reload()
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) == file {
+				timer.Reset(r.debounce)
+			}
+		case err, ok := <-errs:
+			if !ok {
+				return
+			}
+			r.logger.Warn("config watcher", "error", err)
+		case <-timer.C:
+			r.reload()
+		case <-tick:
+			r.reload()
+		}
+	}
+}
+
+// reload loads the config and, if anything changed, makes it current and
+// runs the callbacks. It reports whether the config changed.
+func (r *configReloader) reload() (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, err := r.load()
+	if err != nil {
+		r.logger.Error("config reload failed, keeping the current config", "error", err)
+		return false, err
+	}
+	old := r.current.Load()
+	if configEqual(old, next) {
+		return false, nil
+	}
+	r.current.Store(next)
+	r.logger.Info("config reloaded")
+
+	for _, fn := range r.callbacks {
+		r.notify(fn, old, next)
+	}
+	return true, nil
+}
+
+// notify runs a callback, recovering panics so one bad callback doesn't
+// stop the others.
+func (r *configReloader) notify(fn ConfigChangeFunc, old, next *config.Config) {
+	defer func() {
+		if p := recover(); p != nil {
+			r.logger.Error("config change callback panicked", "panic", p)
+		}
+	}()
+	fn(old, next)
+}
+
+func (r *configReloader) onChange(fn ConfigChangeFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.callbacks = append(r.callbacks, fn)
+}
+
+// configEqual reports whether two configs hold the same settings: the
+// same fields and the same file and .env values.
+func configEqual(a, b *config.Config) bool {
+	va, vb := reflect.ValueOf(a).Elem(), reflect.ValueOf(b).Elem()
+	for i := 0; i < va.NumField(); i++ {
+		if va.Type().Field(i).IsExported() && !reflect.DeepEqual(va.Field(i).Interface(), vb.Field(i).Interface()) {
+			return false
+		}
+	}
+	return reflect.DeepEqual(a.Settings(), b.Settings())
+}
+
+// OnConfigChange calls fn with the old and new config each time the config
+// is reloaded with changes: when its file is saved, on SIGHUP, or on
+// ReloadConfig. The first call enables reloading (see WithConfigReload).
+//
+//	app.OnConfigChange(func(old, new *config.Config) {
+//	    app.Server.SetCORSOrigins(strings.Split(new.GetString("cors.origins"), ",")...)
+//	})
+//
+// Callbacks run one at a time, in registration order.
+func (a *App) OnConfigChange(fn ConfigChangeFunc) {
+	a.configReloader().onChange(fn)
+}
+
+// ReloadConfig reloads the config now, as SIGHUP does, and reports whether
+// it changed. On error the current config stays in effect.
+func (a *App) ReloadConfig() (bool, error) {
+	return a.configReloader().reload()
+}
+
+// CurrentConfig returns the config in effect. App.Config keeps the config
+// the app started with; after a reload, read settings that may change from
+// here.
+func (a *App) CurrentConfig() *config.Config {
+	if a.reloader == nil {
+		return a.Config
+	}
+	return a.reloader.current.Load()
+}
+
+// configReloader returns the app's reloader, creating it on first use. It
+// reloads with config.Load, so apps using WithConfig reload from the
+// environment and config file too, and fetches the secrets again with
+// WithSecrets.
+func (a *App) configReloader() *configReloader {
+	if a.reloader == nil {
+		a.reloader = newConfigReloader(a.Config, func() (*config.Config, error) {
+			cfg, err := config.Load(a.Config.AppName)
+			if err == nil && a.secrets != nil {
+				err = resolveSecrets(cfg, a.secrets)
+			}
+			return cfg, err
+		})
+		a.reloader.SetupWorker(a.Logger, nil)
+		a.reloader.onChange(a.applyLogLevel)
+		a.AddWorker(a.reloader)
+	}
+	return a.reloader
+}
+
+// applyLogLevel applies a changed log level to the app's logger.
+func (a *App) applyLogLevel(old, new *config.Config) {
+	if a.logLevel == nil || old.LogLevel == new.LogLevel {
+		return
+	}
+	a.logLevel.Set(resolveLogLevel(new, new.LogLevel))
+	a.Logger.Info("log level changed", "level", a.logLevel.Level().String())
+}
+
+// liveHandler is a handler that can be replaced while the server runs.
+type liveHandler struct {
+	handler atomic.Pointer[fiber.Handler]
+}
+
+func newLiveHandler(h fiber.Handler) *liveHandler {
+	l := &liveHandler{}
+	l.store(h)
+	return l
+}
+
+func (l *liveHandler) store(h fiber.Handler) { l.handler.Store(&h) }
+
+func (l *liveHandler) serve(c *fiber.Ctx) error { return (*l.handler.Load())(c) }
+
+// SetRateLimit replaces ServerConfig.RateLimit while the server runs, e.g.
+// from OnConfigChange. Routes with their own RateLimit keep it. Routes pick
+// up the global limit when they are registered, so ServerConfig.RateLimit
+// must be set at startup.
+func (s *Server) SetRateLimit(limit RateLimit) error {
+	if s.liveRateLimit == nil {
+		return errors.New("cartridge: no global rate limit to replace (set ServerConfig.RateLimit)")
+	}
+	s.liveRateLimit.store(s.rateLimit(limit, "global"))
+	return nil
+}
+
+// SetCORSOrigins replaces the origins allowed by ServerConfig.CORS while the
+// server runs, e.g. from OnConfigChange. ServerConfig.CORS must be set at
+// startup.
+func (s *Server) SetCORSOrigins(origins ...string) error {
+	if s.liveCORS == nil {
+		return errors.New("cartridge: no CORS policy to update (set ServerConfig.CORS or WithCORS)")
+	}
+	s.corsMu.Lock()
+	defer s.corsMu.Unlock()
+	cors := s.corsConfig
+	cors.AllowOrigins = origins
+	if err := cors.Validate(); err != nil {
+		return fmt.Errorf("cartridge: %w", err)
+	}
+	s.corsConfig = cors
+	s.liveCORS.store(cartridgemiddleware.CORS(cors))
+	return nil
+}
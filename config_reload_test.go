@@ -0,0 +1,137 @@
+package cartridge
+
+import (
+	"net/http/httptest"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/karloscodes/cartridge/config"
+	cartridgemiddleware "github.com/karloscodes/cartridge/middleware"
+)
+
+func TestConfigReloader(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("RELOADAPP_ENV", "test")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile("cartridge.yaml", []byte(content), 0o644); err != nil {
+			t.Fatalf("write config: %v", err)
+		}
+	}
+	write("log_level: info\nbanner: hello\n")
+
+	load := func() (*config.Config, error) { return config.Load("reloadapp") }
+	cfg, err := load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	r := newConfigReloader(cfg, load)
+	r.debounce = 10 * time.Millisecond
+	r.SetupWorker(testLogger(), nil)
+	changes := make(chan [2]string, 4)
+	r.onChange(func(old, new *config.Config) {
+		changes <- [2]string{old.GetString("banner"), new.GetString("banner")}
+	})
+	r.onChange(func(old, new *config.Config) { panic("bad callback") })
+
+	if changed, err := r.reload(); changed || err != nil {
+		t.Errorf("expected no change for the same file, got %v %v", changed, err)
+	}
+
+	if err := r.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer r.Stop()
+	expect := func(old, new string) {
+		t.Helper()
+		select {
+		case got := <-changes:
+			if got != [2]string{old, new} {
+				t.Errorf("expected %s -> %s, got %v", old, new, got)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("no reload for %s -> %s", old, new)
+		}
+	}
+
+	// Saving the file reloads it
+	write("log_level: info\nbanner: welcome\n")
+	expect("hello", "welcome")
+	if got := r.current.Load().GetString("banner"); got != "welcome" {
+		t.Errorf("expected the new config to be current, got %q", got)
+	}
+
+	// A broken file keeps the current config
+	r.Stop()
+	write("banner: [unclosed\n")
+	if _, err := r.reload(); err == nil {
+		t.Error("expected an error for a broken file")
+	}
+	if got := r.current.Load().GetString("banner"); got != "welcome" {
+		t.Errorf("expected the previous config to stay, got %q", got)
+	}
+
+	// SIGHUP reloads too
+	write("banner: goodbye\n")
+	if err := r.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatalf("signal failed: %v", err)
+	}
+	expect("welcome", "goodbye")
+}
+
+func TestServer_LiveSettings(t *testing.T) {
+	cfg := DefaultServerConfig()
+	cfg.EnableStaticAssets = false
+	cfg.EnableRequestLogger = false
+	cfg.EnableSecFetchSite = false
+	cfg.Config = &testConfig{}
+	cfg.Logger = testLogger()
+	cfg.DBManager = &testDBManager{}
+	cfg.RateLimit = &RateLimit{Max: 1, Window: time.Minute}
+	policy := cartridgemiddleware.ProductionCORS("https://a.example.com")
+	cfg.CORS = &policy
+
+	srv, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	srv.Get("/api", func(ctx *Context) error { return ctx.SendString("ok") }, &RouteConfig{EnableCORS: true})
+
+	do := func(origin string) (int, string) {
+		req := httptest.NewRequest("GET", "/api", nil)
+		req.Header.Set("Origin", origin)
+		resp, err := srv.App().Test(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		return resp.StatusCode, resp.Header.Get("Access-Control-Allow-Origin")
+	}
+
+	do("https://a.example.com")
+	if status, _ := do("https://a.example.com"); status != fiber.StatusTooManyRequests {
+		t.Errorf("expected the limit of 1 to apply, got %d", status)
+	}
+	if err := srv.SetRateLimit(RateLimit{Max: 10, Window: time.Minute}); err != nil {
+		t.Fatalf("SetRateLimit failed: %v", err)
+	}
+	if status, origin := do("https://b.example.com"); status != fiber.StatusOK || origin != "" {
+		t.Errorf("expected the raised limit and b not yet allowed, got %d %q", status, origin)
+	}
+
+	if err := srv.SetCORSOrigins("https://b.example.com"); err != nil {
+		t.Fatalf("SetCORSOrigins failed: %v", err)
+	}
+	if _, origin := do("https://b.example.com"); origin != "https://b.example.com" {
+		t.Errorf("expected b to be allowed, got %q", origin)
+	}
+	if err := srv.SetCORSOrigins("*"); err == nil {
+		t.Error("expected a wildcard with credentials to be rejected")
+	}
+}
@@ -1,8 +1,13 @@
 package cartridge
 
 import (
+	"context"
+
 	"github.com/gofiber/fiber/v2"
+	oteltrace "go.opentelemetry.io/otel/trace"
 	"gorm.io/gorm"
+
+	"github.com/karloscodes/cartridge/database"
 )
 
 // Context provides request-scoped access to application dependencies.
@@ -10,12 +15,32 @@ import (
 // adding direct field access to logger, config, and database manager.
 // This eliminates the need for context.Locals and provides type-safe access.
 type Context struct {
-	*fiber.Ctx              // All Fiber HTTP methods (Render, JSON, etc.)
-	Logger     Logger       // Request logger (shared across app)
-	Config     Config       // Runtime configuration
-	DBManager  DBManager    // Database connection pool
-	Session    *SessionManager // Session management (may be nil if not configured)
-	db         *gorm.DB     // Cached database session (lazy-loaded)
+	*fiber.Ctx                    // All Fiber HTTP methods (Render, JSON, etc.)
+	Logger      Logger            // Request logger (shared across app)
+	Config      Config            // Runtime configuration
+	DBManager   DBManager         // Database connection pool
+	Auth        *SessionManager   // Cookie authentication (may be nil if not configured)
+	db          *gorm.DB          // Cached database session (lazy-loaded)
+	readDB      *gorm.DB          // Cached read replica session (lazy-loaded)
+	async       *AsyncManager     // Task runner for Promote (nil if not enabled)
+	errorFormat ErrorFormat       // JSON error shape used by Fail and friends
+	errorPages  ErrorPages        // HTML error page templates used by Fail and friends
+	caching     []CacheProfile    // Named Cache-Control policies for ApplyCacheProfile
+	uploads     UploadStorage     // Default backend for SaveUpload (nil if not configured)
+	services    *serviceScope     // Provided services and per-request overrides
+	cache       *Cache            // Application cache for ctx.Cache()
+	features    FeatureResolver   // Flag lookup for ctx.Feature (nil = all off)
+	audit       AuditStore        // Backend for ctx.Audit (nil if not enabled)
+	principals  PrincipalResolver // Actor lookup for ctx.Audit on routes without authorization
+	tenantDB    TenantDatabase    // Tenant-scoped database for DB (nil = shared database)
+	releaseDB   func()            // Returns the tenant connection when the request ends
+	pageMeta    *PageMetadata     // Site-wide defaults for Meta (nil if not configured)
+	helpers     *TemplateHelpers  // Request-bound view helpers, also for components
+	notifier    *Notifier         // Delivery for ctx.Notify (nil if not enabled)
+	events      *EventBus         // Subscribers for ctx.Emit
+	background  *goroutineGroup   // Server-scoped goroutines, parent of the request's
+	goroutines  *goroutineGroup   // Goroutines started by Go (nil until first use)
+	detach      func() bool       // Unlinks goroutines from server shutdown
 }
 
 // DB provides a per-request database session with context attached.
@@ -26,7 +51,12 @@ func (ctx *Context) DB() *gorm.DB {
 		return ctx.db
 	}
 
-	db := ctx.DBManager.GetConnection()
+	var db *gorm.DB
+	if ctx.tenantDB != nil {
+		db = ctx.openTenantDB(ctx.queryContext())
+	} else {
+		db = ctx.DBManager.GetConnection()
+	}
 	if db == nil {
 		if ctx.Logger != nil {
 			ctx.Logger.Error("failed to get database connection")
@@ -34,11 +64,60 @@ func (ctx *Context) DB() *gorm.DB {
 		panic("cartridge: database connection failed")
 	}
 
-	// Attach the request context for cancellation support and cache it
-	ctx.db = db.WithContext(ctx.Context())
+	// Attach the request context for cancellation support and query attribution, then cache it
+	ctx.db = db.WithContext(ctx.queryContext())
 	return ctx.db
 }
 
+// ReadDB provides a per-request database session for read-only queries.
+// When the DBManager is configured with read replicas, each request picks one
+// round-robin; otherwise it returns the same session as DB. Writes must go
+// through DB, and reads that must see the request's own writes should too.
+// Requests with a tenant database always use DB.
+func (ctx *Context) ReadDB() *gorm.DB {
+	if ctx.readDB != nil {
+		return ctx.readDB
+	}
+	if ctx.tenantDB != nil {
+		return ctx.DB()
+	}
+
+	rm, ok := ctx.DBManager.(ReadDBManager)
+	if !ok {
+		return ctx.DB()
+	}
+	db := rm.ReadConnection()
+	if db == nil {
+		if ctx.Logger != nil {
+			ctx.Logger.Error("failed to get read database connection")
+		}
+		panic("cartridge: read database connection failed")
+	}
+
+	ctx.readDB = db.WithContext(ctx.queryContext())
+	return ctx.readDB
+}
+
+// queryContext returns the request context with the query trace attached.
+func (ctx *Context) queryContext() context.Context {
+	var reqCtx context.Context = ctx.Context()
+	if trace := ctx.QueryTrace(); trace != nil {
+		reqCtx = database.WithQueryTrace(reqCtx, trace)
+	}
+	// Parent query spans on the request span, if the request is traced
+	if span := oteltrace.SpanFromContext(ctx.UserContext()); span.SpanContext().IsValid() {
+		reqCtx = oteltrace.ContextWithSpan(reqCtx, span)
+	}
+	return reqCtx
+}
+
+// QueryTrace returns the queries issued during this request, with their count
+// and total duration. Returns nil if query tracing is disabled.
+func (ctx *Context) QueryTrace() *database.QueryTrace {
+	trace, _ := ctx.Locals(queryTraceLocalsKey).(*database.QueryTrace)
+	return trace
+}
+
 // HandlerFunc is the signature for cartridge request handlers.
 // Handlers receive a Context with embedded Fiber context and direct access to dependencies.
 type HandlerFunc func(*Context) error
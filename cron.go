@@ -0,0 +1,639 @@
+package cartridge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	// ErrCronJobNotFound is returned when a cron job ID is unknown.
+	ErrCronJobNotFound = errors.New("cartridge: cron job not found")
+
+	// ErrCronJobRunning is returned by Trigger for a SkipIfRunning job that
+	// is still running.
+	ErrCronJobRunning = errors.New("cartridge: cron job is already running")
+
+	// ErrCronNotRunning is returned by Trigger before Start, or after Drain or Stop.
+	ErrCronNotRunning = errors.New("cartridge: cron manager is not running")
+)
+
+// CronHandler runs a single scheduled cron job invocation.
+type CronHandler func(ctx *JobContext) error
+
+// CronJob describes a scheduled job.
+type CronJob struct {
+	// ID uniquely identifies the job in status and history. Required.
+	ID string
+
+	// Schedule is a five-field cron expression or descriptor (see ParseCronSchedule). Required.
+	Schedule string
+
+	// Handler is invoked at each scheduled time. Required.
+	Handler CronHandler
+
+	// SkipIfRunning skips a scheduled run while the previous run is still in
+	// progress, so long jobs don't stack up. Default: false.
+	SkipIfRunning bool
+
+	// Location is the time zone the schedule is evaluated in.
+	// Default: CronConfig.Location
+	Location *time.Location
+}
+
+// CronJobOption configures a cron job registered via WithCronJob.
+type CronJobOption func(*CronJob)
+
+// CronSkipIfRunning skips scheduled runs while the previous run is still in progress.
+func CronSkipIfRunning() CronJobOption {
+	return func(j *CronJob) {
+		j.SkipIfRunning = true
+	}
+}
+
+// CronRun records a single cron job execution.
+// When a DBManager is configured it is persisted to the cartridge_cron_runs table.
+type CronRun struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	JobID      string    `gorm:"size:255;index" json:"job_id"`
+	StartedAt  time.Time `gorm:"index" json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	DurationMs int64     `json:"duration_ms"`
+	Error      string    `gorm:"type:text" json:"error,omitempty"`
+}
+
+// TableName specifies the table name.
+func (CronRun) TableName() string {
+	return "cartridge_cron_runs"
+}
+
+// CronJobStatus is a point-in-time view of a cron job.
+type CronJobStatus struct {
+	ID            string     `json:"id"`
+	Schedule      string     `json:"schedule"`
+	Timezone      string     `json:"timezone"`
+	SkipIfRunning bool       `json:"skip_if_running"`
+	Paused        bool       `json:"paused"`
+	Running       bool       `json:"running"`
+	LastRun       *time.Time `json:"last_run,omitempty"`
+	LastDuration  int64      `json:"last_duration_ms"`
+	LastError     string     `json:"last_error,omitempty"`
+	NextRun       *time.Time `json:"next_run,omitempty"`
+	Skipped       int        `json:"skipped"`
+}
+
+// CronConfig configures the cron manager.
+type CronConfig struct {
+	// Logger for job execution. Optional, defaults to slog.Default().
+	Logger Logger
+
+	// DBManager provides database access to handlers and persists run history.
+	// Without it, history is kept in memory only.
+	DBManager DBManager
+
+	// Location is the time zone schedules are evaluated in. Default: time.Local.
+	Location *time.Location
+
+	// HistoryLimit is the number of runs kept per job. Older runs are pruned. Default: 100.
+	HistoryLimit int
+
+	// Cache is returned by JobContext.Cache(). Optional, defaults to a
+	// process-wide in-memory cache.
+	Cache *Cache
+}
+
+// cronEntry is the runtime state of a registered job.
+type cronEntry struct {
+	job      CronJob
+	schedule CronSchedule
+	running  int
+	skipped  int
+	paused   bool
+	next     time.Time
+	last     *CronRun
+	history  []CronRun // newest last; only used without a database
+	err      error     // schedule builder error, reported by Start
+}
+
+// CronManager runs jobs on cron schedules and records their run history.
+// It implements BackgroundWorker.
+type CronManager struct {
+	logger       Logger
+	dbManager    DBManager
+	location     *time.Location
+	historyLimit int
+	cache        *Cache
+
+	mu      sync.Mutex
+	entries map[string]*cronEntry
+	order   []string
+	started bool
+	closed  bool // stop is closed; set by Drain or Stop
+	stop    chan struct{}
+	ctx     context.Context
+	cancel  context.CancelFunc
+	loops   sync.WaitGroup
+	runs    sync.WaitGroup
+	metrics jobMetricsRecorder
+	err     error // first job Schedule rejected, reported by Start
+}
+
+// NewCronManager creates a cron manager with the given configuration.
+func NewCronManager(cfg CronConfig) *CronManager {
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	location := cfg.Location
+	if location == nil {
+		location = time.Local
+	}
+
+	historyLimit := cfg.HistoryLimit
+	if historyLimit <= 0 {
+		historyLimit = 100
+	}
+
+	return &CronManager{
+		logger:       logger,
+		dbManager:    cfg.DBManager,
+		location:     location,
+		historyLimit: historyLimit,
+		cache:        cfg.Cache,
+		entries:      make(map[string]*cronEntry),
+	}
+}
+
+// Add registers a job. Jobs must be added before Start.
+func (m *CronManager) Add(job CronJob) error {
+	if job.ID == "" {
+		return fmt.Errorf("cartridge: cron job ID is required")
+	}
+	if job.Handler == nil {
+		return fmt.Errorf("cartridge: cron job %q has no handler", job.ID)
+	}
+
+	schedule, err := ParseCronSchedule(job.Schedule)
+	if err != nil {
+		return fmt.Errorf("cartridge: cron job %q: %w", job.ID, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.started {
+		return fmt.Errorf("cartridge: cron job %q added after start", job.ID)
+	}
+	if _, exists := m.entries[job.ID]; exists {
+		return fmt.Errorf("cartridge: duplicate cron job %q", job.ID)
+	}
+
+	m.entries[job.ID] = &cronEntry{job: job, schedule: schedule}
+	m.order = append(m.order, job.ID)
+	return nil
+}
+
+// Start prepares the history table and begins scheduling jobs.
+func (m *CronManager) Start() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.started {
+		return nil
+	}
+	if m.err != nil {
+		return m.err
+	}
+	for _, id := range m.order {
+		e := m.entries[id]
+		if e.err != nil {
+			return fmt.Errorf("cartridge: cron job %q: %w", id, e.err)
+		}
+		if e.schedule == nil {
+			return fmt.Errorf("cartridge: cron job %q has no schedule (e.g. Daily or EveryFiveMinutes)", id)
+		}
+	}
+
+	if m.dbManager != nil {
+		if err := m.prepareHistory(); err != nil {
+			return err
+		}
+	}
+
+	m.stop = make(chan struct{})
+	m.closed = false
+	m.ctx, m.cancel = context.WithCancel(context.Background())
+	m.started = true
+
+	for _, id := range m.order {
+		m.loops.Add(1)
+		go m.loop(m.entries[id])
+	}
+
+	m.logger.Info("cron manager started", "jobs", len(m.order))
+	return nil
+}
+
+// Stop stops scheduling, cancels the job context and waits for running jobs.
+// Use Drain first to let running jobs finish.
+func (m *CronManager) Stop() {
+	m.mu.Lock()
+	if !m.started {
+		m.mu.Unlock()
+		return
+	}
+	m.closeStop()
+	m.started = false
+	m.mu.Unlock()
+
+	m.loops.Wait()
+	m.cancel()
+	m.runs.Wait()
+	m.logger.Info("cron manager stopped")
+}
+
+// Drain prepares for shutdown: it stops scheduling and triggering jobs and
+// waits for running ones until ctx is done, then cancels them. Canceled runs
+// are recorded with their error. Call Stop afterwards.
+func (m *CronManager) Drain(ctx context.Context) error {
+	m.mu.Lock()
+	if !m.started || m.closed {
+		m.mu.Unlock()
+		return nil
+	}
+	m.closeStop()
+	m.mu.Unlock()
+	m.loops.Wait()
+
+	done := make(chan struct{})
+	go func() {
+		m.runs.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+	m.cancel()
+	<-done
+	return fmt.Errorf("cartridge: cron drain: canceled running jobs: %w", ctx.Err())
+}
+
+// closeStop ends the scheduling loops. Must be called with m.mu held.
+func (m *CronManager) closeStop() {
+	if !m.closed {
+		close(m.stop)
+		m.closed = true
+	}
+}
+
+// Phase starts the cron manager in PhaseCron, after other background workers.
+func (m *CronManager) Phase() LifecyclePhase {
+	return PhaseCron
+}
+
+// Status returns the state of every job in registration order.
+func (m *CronManager) Status() []CronJobStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	statuses := make([]CronJobStatus, 0, len(m.order))
+	for _, id := range m.order {
+		e := m.entries[id]
+		status := CronJobStatus{
+			ID:            e.job.ID,
+			Schedule:      e.job.Schedule,
+			Timezone:      m.locationOf(e).String(),
+			SkipIfRunning: e.job.SkipIfRunning,
+			Paused:        e.paused,
+			Running:       e.running > 0,
+			Skipped:       e.skipped,
+		}
+		if e.last != nil {
+			started := e.last.StartedAt
+			status.LastRun = &started
+			status.LastDuration = e.last.DurationMs
+			status.LastError = e.last.Error
+		}
+		if !e.next.IsZero() && !e.paused {
+			next := e.next
+			status.NextRun = &next
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// Metrics returns run counts, failures and duration histograms per job,
+// sorted by job ID. Jobs that haven't run yet are omitted.
+func (m *CronManager) Metrics() []JobMetrics {
+	return m.metrics.snapshot()
+}
+
+// History returns up to limit recent runs of a job, newest first.
+// A limit <= 0 returns all retained runs.
+func (m *CronManager) History(id string, limit int) ([]CronRun, error) {
+	m.mu.Lock()
+	e, ok := m.entries[id]
+	if !ok {
+		m.mu.Unlock()
+		return nil, ErrCronJobNotFound
+	}
+	if m.dbManager == nil {
+		runs := make([]CronRun, 0, len(e.history))
+		for i := len(e.history) - 1; i >= 0; i-- {
+			runs = append(runs, e.history[i])
+			if limit > 0 && len(runs) == limit {
+				break
+			}
+		}
+		m.mu.Unlock()
+		return runs, nil
+	}
+	m.mu.Unlock()
+
+	db, err := m.dbManager.Connect()
+	if err != nil {
+		return nil, fmt.Errorf("cartridge: connect database: %w", err)
+	}
+
+	query := db.Where("job_id = ?", id).Order("started_at DESC, id DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var runs []CronRun
+	if err := query.Find(&runs).Error; err != nil {
+		return nil, fmt.Errorf("cartridge: load cron history: %w", err)
+	}
+	return runs, nil
+}
+
+// Trigger runs a job now, outside its schedule, even while it is paused.
+// The run gets the same JobContext and is recorded like a scheduled one.
+// SkipIfRunning jobs return ErrCronJobRunning while a run is in progress.
+func (m *CronManager) Trigger(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entries[id]
+	if !ok {
+		return ErrCronJobNotFound
+	}
+	if !m.started || m.closed {
+		return ErrCronNotRunning
+	}
+	if e.running > 0 && e.job.SkipIfRunning {
+		return ErrCronJobRunning
+	}
+	m.launch(e)
+	return nil
+}
+
+// Pause stops a job's scheduled runs until Resume. A run in progress
+// finishes, and Trigger still runs the job. Pauses last until the process
+// exits; re-pause from WithInit to keep a job off across restarts.
+func (m *CronManager) Pause(id string) error {
+	return m.setPaused(id, true)
+}
+
+// Resume restarts the scheduled runs of a paused job, from its next
+// scheduled time. Missed runs are not caught up.
+func (m *CronManager) Resume(id string) error {
+	return m.setPaused(id, false)
+}
+
+func (m *CronManager) setPaused(id string, paused bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[id]
+	if !ok {
+		return ErrCronJobNotFound
+	}
+	if e.paused == paused {
+		return nil
+	}
+	e.paused = paused
+	if paused {
+		m.logger.Info("cron job paused", "job", id)
+	} else {
+		m.logger.Info("cron job resumed", "job", id)
+	}
+	return nil
+}
+
+// NextRuns returns the next n scheduled times of a job.
+func (m *CronManager) NextRuns(id string, n int) ([]time.Time, error) {
+	m.mu.Lock()
+	e, ok := m.entries[id]
+	m.mu.Unlock()
+	if !ok {
+		return nil, ErrCronJobNotFound
+	}
+
+	runs := make([]time.Time, 0, n)
+	t := time.Now().In(m.locationOf(e))
+	for len(runs) < n {
+		t = e.schedule.Next(t)
+		if t.IsZero() {
+			break
+		}
+		runs = append(runs, t)
+	}
+	return runs, nil
+}
+
+// loop waits for each scheduled time of a job and triggers it until Stop.
+func (m *CronManager) loop(e *cronEntry) {
+	defer m.loops.Done()
+
+	for {
+		next := e.schedule.Next(time.Now().In(m.locationOf(e)))
+
+		m.mu.Lock()
+		e.next = next
+		m.mu.Unlock()
+
+		if next.IsZero() {
+			m.logger.Warn("cron job has no future runs", "job", e.job.ID, "schedule", e.job.Schedule)
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+			m.trigger(e)
+		case <-m.stop:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// locationOf returns the time zone of a job's schedule.
+func (m *CronManager) locationOf(e *cronEntry) *time.Location {
+	if e.job.Location != nil {
+		return e.job.Location
+	}
+	return m.location
+}
+
+// trigger launches a scheduled run unless the job is paused, or still
+// running with SkipIfRunning set.
+func (m *CronManager) trigger(e *cronEntry) {
+	m.mu.Lock()
+	if e.paused {
+		m.mu.Unlock()
+		return
+	}
+	if e.running > 0 && e.job.SkipIfRunning {
+		e.skipped++
+		m.mu.Unlock()
+		m.logger.Warn("cron job still running, skipping scheduled run", "job", e.job.ID)
+		return
+	}
+	m.launch(e)
+	m.mu.Unlock()
+}
+
+// launch starts a run of the job. Must be called with m.mu held.
+func (m *CronManager) launch(e *cronEntry) {
+	e.running++
+	m.runs.Add(1)
+	go m.execute(m.ctx, e)
+}
+
+// execute runs the job handler and records the run.
+func (m *CronManager) execute(ctx context.Context, e *cronEntry) {
+	defer m.runs.Done()
+
+	run := CronRun{JobID: e.job.ID, StartedAt: time.Now().UTC()}
+
+	ctx, span := startJobSpan(ctx, "cron "+e.job.ID, trace.SpanContext{},
+		attribute.String("cartridge.cron.schedule", e.job.Schedule),
+	)
+	jobCtx := &JobContext{
+		Context: ctx,
+		Logger:  m.logger,
+		cache:   m.cache,
+	}
+
+	var err error
+	if m.dbManager != nil {
+		db, connErr := m.dbManager.Connect()
+		if connErr != nil {
+			err = fmt.Errorf("connect database: %w", connErr)
+		} else {
+			jobCtx.DB = db.WithContext(ctx)
+		}
+	}
+	if err == nil {
+		err = invokeCronHandler(e.job.Handler, jobCtx)
+	}
+	if err != nil && ctx.Err() != nil {
+		err = fmt.Errorf("canceled by shutdown: %w", err)
+	}
+	endJobSpan(span, err)
+
+	run.FinishedAt = time.Now().UTC()
+	run.DurationMs = run.FinishedAt.Sub(run.StartedAt).Milliseconds()
+	if err != nil {
+		run.Error = err.Error()
+		m.logger.Error("cron job failed", "job", e.job.ID, "error", err)
+	} else {
+		m.logger.Debug("cron job completed", "job", e.job.ID, "duration_ms", run.DurationMs)
+	}
+	m.metrics.observe(e.job.ID, run.FinishedAt.Sub(run.StartedAt), run.FinishedAt, err)
+
+	m.record(e, run)
+}
+
+// record stores a finished run in memory and, with a database, in the history table.
+func (m *CronManager) record(e *cronEntry, run CronRun) {
+	m.mu.Lock()
+	e.running--
+	e.last = &run
+	if m.dbManager == nil {
+		e.history = append(e.history, run)
+		if len(e.history) > m.historyLimit {
+			e.history = e.history[len(e.history)-m.historyLimit:]
+		}
+	}
+	m.mu.Unlock()
+
+	if m.dbManager == nil {
+		return
+	}
+	if err := m.persistRun(&run); err != nil {
+		m.logger.Error("failed to record cron run", "job", run.JobID, "error", err)
+	}
+}
+
+// persistRun inserts the run and prunes runs beyond the history limit.
+func (m *CronManager) persistRun(run *CronRun) error {
+	db, err := m.dbManager.Connect()
+	if err != nil {
+		return fmt.Errorf("connect database: %w", err)
+	}
+	if err := db.Create(run).Error; err != nil {
+		return fmt.Errorf("insert cron run: %w", err)
+	}
+
+	var cutoff []uint
+	if err := db.Model(&CronRun{}).
+		Where("job_id = ?", run.JobID).
+		Order("id DESC").
+		Offset(m.historyLimit).
+		Limit(1).
+		Pluck("id", &cutoff).Error; err != nil {
+		return fmt.Errorf("find cron history cutoff: %w", err)
+	}
+	if len(cutoff) == 0 {
+		return nil
+	}
+	if err := db.Where("job_id = ? AND id <= ?", run.JobID, cutoff[0]).Delete(&CronRun{}).Error; err != nil {
+		return fmt.Errorf("prune cron history: %w", err)
+	}
+	return nil
+}
+
+// prepareHistory migrates the history table and loads each job's last run.
+// Must be called with m.mu held.
+func (m *CronManager) prepareHistory() error {
+	db, err := m.dbManager.Connect()
+	if err != nil {
+		return fmt.Errorf("cartridge: connect database: %w", err)
+	}
+	if err := db.AutoMigrate(&CronRun{}); err != nil {
+		return fmt.Errorf("cartridge: migrate cron runs: %w", err)
+	}
+
+	for _, id := range m.order {
+		var runs []CronRun
+		if err := db.Where("job_id = ?", id).Order("started_at DESC, id DESC").Limit(1).Find(&runs).Error; err != nil {
+			return fmt.Errorf("cartridge: load cron history: %w", err)
+		}
+		if len(runs) > 0 {
+			m.entries[id].last = &runs[0]
+		}
+	}
+	return nil
+}
+
+// invokeCronHandler calls the handler, converting panics into errors.
+func invokeCronHandler(handler CronHandler, ctx *JobContext) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(ctx)
+}
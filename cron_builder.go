@@ -0,0 +1,265 @@
+package cartridge
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ScheduledJob builds a cron job's schedule with chained calls instead of a
+// cron expression. Create one with App.Schedule or CronManager.Schedule:
+//
+//	app.Schedule("digest", sendDigest).Weekly().OnSunday().At("08:00").Timezone("Europe/Madrid")
+//	app.Schedule("sync", syncFeeds).EveryFiveMinutes().Weekdays()
+//
+// A frequency (EveryMinute through Monthly, Every or Cron) starts the
+// schedule over; day filters and At refine it. Each call compiles to a cron
+// expression, shown in CronStatus. Mistakes such as At("25:00"), or a job
+// left without a frequency, make Start fail.
+type ScheduledJob struct {
+	m      *CronManager
+	entry  *cronEntry
+	fields [5]string     // minute, hour, day of month, month, day of week
+	every  time.Duration // set by Every instead of fields
+}
+
+// Schedule registers a job whose schedule is set with the returned builder.
+// Like Add, it must be called before Start.
+func (m *CronManager) Schedule(id string, handler CronHandler) *ScheduledJob {
+	s := &ScheduledJob{m: m, entry: &cronEntry{job: CronJob{ID: id, Handler: handler}}}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var err error
+	switch {
+	case id == "":
+		err = fmt.Errorf("cartridge: cron job ID is required")
+	case handler == nil:
+		err = fmt.Errorf("cartridge: cron job %q has no handler", id)
+	case m.started:
+		err = fmt.Errorf("cartridge: cron job %q added after start", id)
+	case m.entries[id] != nil:
+		err = fmt.Errorf("cartridge: duplicate cron job %q", id)
+	}
+	if err != nil {
+		// The builder still works, but the job is never registered
+		m.logger.Error("cron schedule rejected", "job", id, "error", err)
+		if m.err == nil {
+			m.err = err
+		}
+		return s
+	}
+	m.entries[id] = s.entry
+	m.order = append(m.order, id)
+	return s
+}
+
+// Schedule registers a cron job with a fluent schedule (see ScheduledJob),
+// creating the cron manager if no other job needed one. Call it before Run,
+// e.g. from WithInit:
+//
+//	app.Schedule("backup", backupDatabase).DailyAt("02:00")
+func (a *App) Schedule(id string, handler CronHandler) *ScheduledJob {
+	if a.Cron == nil {
+		a.Cron = NewCronManager(CronConfig{Logger: a.Logger, DBManager: a.Database, Cache: a.Server.cfg.Cache})
+		a.AddWorker(a.Cron)
+	}
+	return a.Cron.Schedule(id, handler)
+}
+
+// Cron sets a five-field cron expression (see ParseCronSchedule), which day
+// filters and At can then refine.
+func (s *ScheduledJob) Cron(expr string) *ScheduledJob {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return s.fail(fmt.Errorf("cron expression %q must have 5 fields, got %d", expr, len(fields)))
+	}
+	s.every = 0
+	copy(s.fields[:], fields)
+	return s.apply()
+}
+
+// Every runs the job at a fixed interval, measured from when the manager
+// starts. Day filters and At don't apply to it.
+func (s *ScheduledJob) Every(interval time.Duration) *ScheduledJob {
+	s.every = interval
+	s.fields = [5]string{}
+	return s.apply()
+}
+
+// EveryMinute runs the job every minute.
+func (s *ScheduledJob) EveryMinute() *ScheduledJob { return s.frequency("*", "*", "*") }
+
+// EveryFiveMinutes runs the job at :00, :05, :10, ...
+func (s *ScheduledJob) EveryFiveMinutes() *ScheduledJob { return s.frequency("*/5", "*", "*") }
+
+// EveryTenMinutes runs the job at :00, :10, :20, ...
+func (s *ScheduledJob) EveryTenMinutes() *ScheduledJob { return s.frequency("*/10", "*", "*") }
+
+// EveryFifteenMinutes runs the job at :00, :15, :30 and :45.
+func (s *ScheduledJob) EveryFifteenMinutes() *ScheduledJob { return s.frequency("*/15", "*", "*") }
+
+// EveryThirtyMinutes runs the job at :00 and :30.
+func (s *ScheduledJob) EveryThirtyMinutes() *ScheduledJob { return s.frequency("*/30", "*", "*") }
+
+// Hourly runs the job at the start of every hour.
+func (s *ScheduledJob) Hourly() *ScheduledJob { return s.frequency("0", "*", "*") }
+
+// HourlyAt runs the job every hour at the given minute.
+func (s *ScheduledJob) HourlyAt(minute int) *ScheduledJob {
+	return s.frequency(strconv.Itoa(minute), "*", "*")
+}
+
+// Daily runs the job at midnight. Chain At for another time.
+func (s *ScheduledJob) Daily() *ScheduledJob { return s.frequency("0", "0", "*") }
+
+// DailyAt runs the job every day at the given "HH:MM" time.
+func (s *ScheduledJob) DailyAt(hhmm string) *ScheduledJob { return s.Daily().At(hhmm) }
+
+// Weekly runs the job on Sundays at midnight. Chain a day filter and At to
+// pick another day and time.
+func (s *ScheduledJob) Weekly() *ScheduledJob {
+	s.frequency("0", "0", "*")
+	return s.Days(time.Sunday)
+}
+
+// Monthly runs the job at midnight on the first of the month.
+func (s *ScheduledJob) Monthly() *ScheduledJob { return s.frequency("0", "0", "1") }
+
+// MonthlyOn runs the job on the given day of the month at the given "HH:MM"
+// time. Months without that day are skipped.
+func (s *ScheduledJob) MonthlyOn(day int, hhmm string) *ScheduledJob {
+	return s.frequency("0", "0", strconv.Itoa(day)).At(hhmm)
+}
+
+// At sets the time of day, as "HH:MM" in 24-hour time.
+func (s *ScheduledJob) At(hhmm string) *ScheduledJob {
+	h, m, ok := strings.Cut(hhmm, ":")
+	hour, herr := strconv.Atoi(h)
+	minute, merr := strconv.Atoi(m)
+	if !ok || herr != nil || merr != nil || hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+		return s.fail(fmt.Errorf("invalid time %q, want HH:MM", hhmm))
+	}
+	s.fields[0], s.fields[1] = strconv.Itoa(minute), strconv.Itoa(hour)
+	return s.apply()
+}
+
+// Days restricts the job to the given days of the week.
+func (s *ScheduledJob) Days(days ...time.Weekday) *ScheduledJob {
+	list := make([]string, len(days))
+	for i, day := range days {
+		list[i] = strconv.Itoa(int(day))
+	}
+	s.fields[4] = strings.Join(list, ",")
+	return s.apply()
+}
+
+// Weekdays restricts the job to Monday through Friday.
+func (s *ScheduledJob) Weekdays() *ScheduledJob { return s.days("1-5") }
+
+// Weekends restricts the job to Saturday and Sunday.
+func (s *ScheduledJob) Weekends() *ScheduledJob { return s.days("0,6") }
+
+// OnMonday restricts the job to Mondays.
+func (s *ScheduledJob) OnMonday() *ScheduledJob { return s.Days(time.Monday) }
+
+// OnTuesday restricts the job to Tuesdays.
+func (s *ScheduledJob) OnTuesday() *ScheduledJob { return s.Days(time.Tuesday) }
+
+// OnWednesday restricts the job to Wednesdays.
+func (s *ScheduledJob) OnWednesday() *ScheduledJob { return s.Days(time.Wednesday) }
+
+// OnThursday restricts the job to Thursdays.
+func (s *ScheduledJob) OnThursday() *ScheduledJob { return s.Days(time.Thursday) }
+
+// OnFriday restricts the job to Fridays.
+func (s *ScheduledJob) OnFriday() *ScheduledJob { return s.Days(time.Friday) }
+
+// OnSaturday restricts the job to Saturdays.
+func (s *ScheduledJob) OnSaturday() *ScheduledJob { return s.Days(time.Saturday) }
+
+// OnSunday restricts the job to Sundays.
+func (s *ScheduledJob) OnSunday() *ScheduledJob { return s.Days(time.Sunday) }
+
+// Timezone evaluates the schedule in the named IANA time zone, e.g.
+// "Europe/Madrid", instead of the manager's CronConfig.Location.
+func (s *ScheduledJob) Timezone(name string) *ScheduledJob {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return s.fail(fmt.Errorf("time zone: %w", err))
+	}
+	return s.update(func(job *CronJob) { job.Location = loc })
+}
+
+// SkipIfRunning skips scheduled runs while the previous run is still in
+// progress (see CronSkipIfRunning).
+func (s *ScheduledJob) SkipIfRunning() *ScheduledJob {
+	return s.update(func(job *CronJob) { job.SkipIfRunning = true })
+}
+
+// frequency starts the schedule over with the given minute, hour and day of
+// month, every month and day of the week.
+func (s *ScheduledJob) frequency(minute, hour, dom string) *ScheduledJob {
+	s.every = 0
+	s.fields = [5]string{minute, hour, dom, "*", "*"}
+	return s.apply()
+}
+
+// days sets the day of week field.
+func (s *ScheduledJob) days(dow string) *ScheduledJob {
+	s.fields[4] = dow
+	return s.apply()
+}
+
+// apply compiles the builder state and updates the registered job.
+func (s *ScheduledJob) apply() *ScheduledJob {
+	if s.every > 0 {
+		if s.fields != [5]string{} {
+			return s.fail(fmt.Errorf("Every can't be combined with At or day filters"))
+		}
+		expr := "@every " + s.every.String()
+		schedule, err := ParseCronSchedule(expr)
+		return s.compile(expr, schedule, err)
+	}
+	if s.fields[0] == "" {
+		return s.fail(fmt.Errorf("set a frequency (e.g. Daily) before At or day filters"))
+	}
+	expr := strings.Join(s.fields[:], " ")
+	schedule, err := ParseCronSchedule(expr)
+	return s.compile(expr, schedule, err)
+}
+
+// compile stores a compiled schedule in the registered job.
+func (s *ScheduledJob) compile(expr string, schedule CronSchedule, err error) *ScheduledJob {
+	if err != nil {
+		return s.fail(err)
+	}
+	return s.update(func(job *CronJob) {
+		job.Schedule = expr
+		s.entry.schedule = schedule
+	})
+}
+
+// update changes the registered job. Jobs can't change once the manager
+// has started.
+func (s *ScheduledJob) update(fn func(job *CronJob)) *ScheduledJob {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	if s.m.started {
+		s.m.logger.Warn("cron job changed after start, ignoring", "job", s.entry.job.ID)
+		return s
+	}
+	fn(&s.entry.job)
+	return s
+}
+
+// fail records the first error in the chain; Start reports it.
+func (s *ScheduledJob) fail(err error) *ScheduledJob {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	if s.entry.err == nil {
+		s.entry.err = err
+	}
+	return s
+}
@@ -0,0 +1,91 @@
+package cartridge
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScheduledJob_Expressions(t *testing.T) {
+	noop := func(ctx *JobContext) error { return nil }
+	m := NewCronManager(CronConfig{Logger: testLogger(), Location: time.UTC})
+
+	tests := []struct {
+		job  *ScheduledJob
+		want string
+	}{
+		{m.Schedule("every-minute", noop).EveryMinute(), "* * * * *"},
+		{m.Schedule("five", noop).EveryFiveMinutes(), "*/5 * * * *"},
+		{m.Schedule("five-weekdays", noop).EveryFiveMinutes().Weekdays(), "*/5 * * * 1-5"},
+		{m.Schedule("hourly-at", noop).HourlyAt(17), "17 * * * *"},
+		{m.Schedule("daily-at", noop).DailyAt("02:00"), "0 2 * * *"},
+		{m.Schedule("weekly", noop).Weekly(), "0 0 * * 0"},
+		{m.Schedule("weekly-sunday", noop).Weekly().OnSunday().At("08:00"), "0 8 * * 0"},
+		{m.Schedule("weekly-friday", noop).Weekly().OnFriday().At("17:30"), "30 17 * * 5"},
+		{m.Schedule("days", noop).Daily().Days(time.Tuesday, time.Thursday), "0 0 * * 2,4"},
+		{m.Schedule("monthly-on", noop).MonthlyOn(15, "09:45"), "45 9 15 * *"},
+		{m.Schedule("cron", noop).Cron("0 6 * * *").Weekends(), "0 6 * * 0,6"},
+		{m.Schedule("every", noop).Every(90 * time.Second), "@every 1m30s"},
+		{m.Schedule("reset", noop).DailyAt("04:00").EveryTenMinutes(), "*/10 * * * *"},
+	}
+	for _, tt := range tests {
+		if got := tt.job.entry.job.Schedule; got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.job.entry.job.ID, tt.want, got)
+		}
+	}
+
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer m.Stop()
+	if status := m.Status(); len(status) != len(tests) || status[6].Schedule != "0 8 * * 0" || status[6].Timezone != "UTC" {
+		t.Errorf("unexpected status %+v", status)
+	}
+}
+
+func TestScheduledJob_Timezone(t *testing.T) {
+	m := NewCronManager(CronConfig{Logger: testLogger(), Location: time.UTC})
+	m.Schedule("report", func(ctx *JobContext) error { return nil }).DailyAt("02:00").Timezone("Europe/Madrid")
+
+	runs, err := m.NextRuns("report", 1)
+	if err != nil || len(runs) != 1 {
+		t.Fatalf("NextRuns failed: %v %v", runs, err)
+	}
+	if runs[0].Location().String() != "Europe/Madrid" || runs[0].Hour() != 2 || runs[0].Minute() != 0 {
+		t.Errorf("expected 02:00 in Madrid, got %v", runs[0])
+	}
+	if status := m.Status(); status[0].Timezone != "Europe/Madrid" {
+		t.Errorf("expected the job's time zone in its status, got %q", status[0].Timezone)
+	}
+}
+
+func TestScheduledJob_Errors(t *testing.T) {
+	noop := func(ctx *JobContext) error { return nil }
+
+	tests := []struct {
+		name  string
+		build func(m *CronManager)
+		want  string
+	}{
+		{"no frequency", func(m *CronManager) { m.Schedule("a", noop) }, "has no schedule"},
+		{"invalid time", func(m *CronManager) { m.Schedule("a", noop).DailyAt("25:00") }, `invalid time "25:00"`},
+		{"first error wins", func(m *CronManager) { m.Schedule("a", noop).At("8am").Daily() }, `invalid time "8am"`},
+		{"unknown zone", func(m *CronManager) { m.Schedule("a", noop).Daily().Timezone("Mars/Olympus") }, "time zone"},
+		{"every with days", func(m *CronManager) { m.Schedule("a", noop).Every(time.Hour).Weekdays() }, "Every can't be combined"},
+		{"duplicate", func(m *CronManager) {
+			m.Schedule("a", noop).Daily()
+			m.Schedule("a", noop).Hourly()
+		}, "duplicate cron job"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewCronManager(CronConfig{Logger: testLogger()})
+			tt.build(m)
+			err := m.Start()
+			defer m.Stop()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,294 @@
+package cartridge
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule computes the next activation time after a given time.
+type CronSchedule interface {
+	// Next returns the first activation time strictly after t.
+	// Returns the zero time if the schedule never fires again.
+	Next(t time.Time) time.Time
+}
+
+// ParseCronSchedule parses a standard five-field cron expression
+// (minute hour day-of-month month day-of-week) or a descriptor.
+//
+// Fields support "*", single values, ranges ("1-5"), lists ("1,15"), and
+// steps ("*/15", "0-30/10"). Day-of-week is 0-6 with Sunday as 0 (7 is
+// also accepted for Sunday). Supported descriptors are @yearly, @annually,
+// @monthly, @weekly, @daily, @midnight, @hourly and "@every <duration>".
+//
+// Schedules are evaluated in the location of the time passed to Next. Across
+// daylight saving changes, schedules with fixed hours neither skip nor
+// repeat: times the clocks skip run once when they jump, and times in a
+// repeated hour run only on the first pass.
+func ParseCronSchedule(expr string) (CronSchedule, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, fmt.Errorf("cartridge: empty cron expression")
+	}
+
+	if strings.HasPrefix(expr, "@") {
+		return parseCronDescriptor(expr)
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cartridge: cron expression %q must have 5 fields, got %d", expr, len(fields))
+	}
+
+	minute, err := parseCronField(fields[0], 0, 59)
+	if err != nil {
+		return nil, fmt.Errorf("cartridge: cron minute: %w", err)
+	}
+	hour, err := parseCronField(fields[1], 0, 23)
+	if err != nil {
+		return nil, fmt.Errorf("cartridge: cron hour: %w", err)
+	}
+	dom, err := parseCronField(fields[2], 1, 31)
+	if err != nil {
+		return nil, fmt.Errorf("cartridge: cron day of month: %w", err)
+	}
+	month, err := parseCronField(fields[3], 1, 12)
+	if err != nil {
+		return nil, fmt.Errorf("cartridge: cron month: %w", err)
+	}
+	dow, err := parseCronField(fields[4], 0, 7)
+	if err != nil {
+		return nil, fmt.Errorf("cartridge: cron day of week: %w", err)
+	}
+	// Fold 7 (Sunday) into 0
+	if dow&(1<<7) != 0 {
+		dow |= 1
+		dow &^= 1 << 7
+	}
+
+	return &cronSpec{
+		minute:  minute,
+		hour:    hour,
+		dom:     dom,
+		month:   month,
+		dow:     dow,
+		domStar: fields[2] == "*" || fields[2] == "?",
+		dowStar: fields[4] == "*" || fields[4] == "?",
+	}, nil
+}
+
+// parseCronDescriptor handles @-prefixed shorthand schedules.
+func parseCronDescriptor(expr string) (CronSchedule, error) {
+	switch expr {
+	case "@yearly", "@annually":
+		return ParseCronSchedule("0 0 1 1 *")
+	case "@monthly":
+		return ParseCronSchedule("0 0 1 * *")
+	case "@weekly":
+		return ParseCronSchedule("0 0 * * 0")
+	case "@daily", "@midnight":
+		return ParseCronSchedule("0 0 * * *")
+	case "@hourly":
+		return ParseCronSchedule("0 * * * *")
+	}
+
+	if rest, ok := strings.CutPrefix(expr, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, fmt.Errorf("cartridge: cron %q: %w", expr, err)
+		}
+		if d < time.Second {
+			return nil, fmt.Errorf("cartridge: cron %q: interval must be at least 1s", expr)
+		}
+		return everySchedule{interval: d}, nil
+	}
+
+	return nil, fmt.Errorf("cartridge: unknown cron descriptor %q", expr)
+}
+
+// parseCronField parses one comma-separated cron field into a bitmask.
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		b, err := parseCronRange(part, min, max)
+		if err != nil {
+			return 0, err
+		}
+		bits |= b
+	}
+	return bits, nil
+}
+
+// parseCronRange parses "*", "n", "a-b", with an optional "/step" suffix.
+func parseCronRange(part string, min, max int) (uint64, error) {
+	rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+	step := 1
+	if hasStep {
+		n, err := strconv.Atoi(stepPart)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid step %q", part)
+		}
+		step = n
+	}
+
+	var start, end int
+	switch {
+	case rangePart == "*" || rangePart == "?":
+		start, end = min, max
+	case strings.Contains(rangePart, "-"):
+		lo, hi, _ := strings.Cut(rangePart, "-")
+		var err error
+		if start, err = strconv.Atoi(lo); err != nil {
+			return 0, fmt.Errorf("invalid range %q", part)
+		}
+		if end, err = strconv.Atoi(hi); err != nil {
+			return 0, fmt.Errorf("invalid range %q", part)
+		}
+	default:
+		n, err := strconv.Atoi(rangePart)
+		if err != nil {
+			return 0, fmt.Errorf("invalid value %q", part)
+		}
+		start = n
+		end = n
+		if hasStep {
+			end = max
+		}
+	}
+
+	if start < min || end > max || start > end {
+		return 0, fmt.Errorf("value %q out of range %d-%d", part, min, max)
+	}
+
+	var bits uint64
+	for i := start; i <= end; i += step {
+		bits |= 1 << uint(i)
+	}
+	return bits, nil
+}
+
+// cronSpec is a parsed five-field cron expression.
+type cronSpec struct {
+	minute, hour, dom, month, dow uint64
+	domStar, dowStar              bool
+}
+
+// allCronHours is the hour field of schedules that run every hour.
+const allCronHours = 1<<24 - 1
+
+// Next returns the next matching minute after t, in t's location.
+//
+// Schedules with fixed hours ("30 2 * * *") follow the wall clock across
+// daylight saving changes: a time skipped when clocks go forward runs once,
+// as soon as the clocks have jumped (03:00 for 02:30), and an hour repeated
+// when they go back runs only the first time. Schedules that run every hour
+// ("*/15 * * * *") follow elapsed time instead, so they neither bunch up nor
+// pause around a change.
+func (s *cronSpec) Next(t time.Time) time.Time {
+	if s.hour == allCronHours {
+		return s.next(t)
+	}
+
+	// Search the wall clock, where every day has 24 hours, then map the
+	// match back to t's location. A match can map to or before t when t is
+	// in the second pass of a repeated hour.
+	w := wallClock(t)
+	for {
+		if w = s.next(w); w.IsZero() {
+			return w
+		}
+		if at := wallTime(w, t.Location()); at.After(t) {
+			return at
+		}
+	}
+}
+
+// next returns the next matching minute after t, stepping through t's
+// location in elapsed time.
+func (s *cronSpec) next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+
+	// Give up after five years of searching (e.g. "0 0 30 2 *")
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches applies standard cron semantics: when both day fields are
+// restricted, a day matches if either field matches.
+func (s *cronSpec) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// wallClock returns t's local date and time as a UTC time, a calendar
+// without daylight saving changes.
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// wallTime returns the first instant the clocks in loc show the wall clock
+// time w (see wallClock). If they skip it, it returns the instant they jump
+// past it.
+func wallTime(w time.Time, loc *time.Location) time.Time {
+	// Try the offsets in effect a day before and after: a day holds at
+	// most one change, so the time is in one of them, or in the gap
+	// between them
+	var first, skipped time.Time
+	for _, probe := range []time.Time{w.AddDate(0, 0, -1), w.AddDate(0, 0, 1)} {
+		_, offset := time.Date(probe.Year(), probe.Month(), probe.Day(), probe.Hour(), probe.Minute(), 0, 0, loc).Zone()
+		at := w.Add(-time.Duration(offset) * time.Second).In(loc)
+		if !wallClock(at).Equal(w) {
+			if skipped.IsZero() {
+				skipped = at
+			}
+			continue
+		}
+		if first.IsZero() || at.Before(first) {
+			first = at
+		}
+	}
+	if !first.IsZero() {
+		return first
+	}
+	// With the earlier offset, w maps past the change; the zone period
+	// that instant is in starts when the clocks jumped
+	start, _ := skipped.ZoneBounds()
+	return start
+}
+
+// everySchedule fires at a fixed interval.
+type everySchedule struct {
+	interval time.Duration
+}
+
+// Next returns t plus the interval, rounded down to the second.
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.Truncate(time.Second).Add(s.interval)
+}
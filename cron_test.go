@@ -0,0 +1,399 @@
+package cartridge
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCronSchedule_Next(t *testing.T) {
+	base := time.Date(2024, time.January, 15, 10, 7, 30, 0, time.UTC) // Monday
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, time.January, 15, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, time.January, 15, 10, 15, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2024, time.January, 16, 3, 0, 0, 0, time.UTC)},
+		{"30 9 * * 1-5", time.Date(2024, time.January, 16, 9, 30, 0, 0, time.UTC)},
+		{"0 0 * * 0", time.Date(2024, time.January, 21, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, time.January, 21, 0, 0, 0, 0, time.UTC)},
+		{"0 12 1,15 * *", time.Date(2024, time.January, 15, 12, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, time.January, 15, 11, 0, 0, 0, time.UTC)},
+		{"@every 90s", time.Date(2024, time.January, 15, 10, 9, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			schedule, err := ParseCronSchedule(tt.expr)
+			if err != nil {
+				t.Fatalf("parse failed: %v", err)
+			}
+			if got := schedule.Next(base); !got.Equal(tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseCronSchedule_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "5-1 * * * *", "*/0 * * * *", "@sometimes", "@every 10ms"} {
+		if _, err := ParseCronSchedule(expr); err == nil {
+			t.Errorf("expected error for %q", expr)
+		}
+	}
+}
+
+func TestCronManager_AddValidation(t *testing.T) {
+	m := NewCronManager(CronConfig{Logger: testLogger()})
+	noop := func(ctx *JobContext) error { return nil }
+
+	if err := m.Add(CronJob{ID: "a", Schedule: "bogus", Handler: noop}); err == nil {
+		t.Error("expected error for invalid schedule")
+	}
+	if err := m.Add(CronJob{ID: "a", Schedule: "@daily", Handler: noop}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := m.Add(CronJob{ID: "a", Schedule: "@daily", Handler: noop}); err == nil {
+		t.Error("expected error for duplicate job ID")
+	}
+}
+
+func TestCronManager_SkipIfRunning(t *testing.T) {
+	m := NewCronManager(CronConfig{Logger: testLogger()})
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 2)
+	err := m.Add(CronJob{
+		ID:       "slow",
+		Schedule: "@daily",
+		Handler: func(ctx *JobContext) error {
+			started <- struct{}{}
+			<-release
+			return nil
+		},
+		SkipIfRunning: true,
+	})
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer m.Stop()
+
+	entry := m.entries["slow"]
+	m.trigger(entry)
+	<-started
+	m.trigger(entry)
+	close(release)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) && m.Status()[0].Running {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	status := m.Status()[0]
+	if status.Running {
+		t.Fatal("expected job to finish")
+	}
+	if status.Skipped != 1 {
+		t.Errorf("expected 1 skipped run, got %d", status.Skipped)
+	}
+	if status.NextRun == nil {
+		t.Error("expected next run to be scheduled")
+	}
+
+	runs, err := m.History("slow", 0)
+	if err != nil {
+		t.Fatalf("History failed: %v", err)
+	}
+	if len(runs) != 1 {
+		t.Errorf("expected 1 recorded run, got %d", len(runs))
+	}
+}
+
+func TestCronManager_Trigger(t *testing.T) {
+	m := NewCronManager(CronConfig{Logger: testLogger(), Location: time.UTC})
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	err := m.Add(CronJob{
+		ID:       "report",
+		Schedule: "0 3 * * *",
+		Handler: func(ctx *JobContext) error {
+			started <- struct{}{}
+			<-release
+			return nil
+		},
+		SkipIfRunning: true,
+	})
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := m.Trigger("report"); !errors.Is(err, ErrCronNotRunning) {
+		t.Errorf("expected ErrCronNotRunning before Start, got %v", err)
+	}
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer m.Stop()
+
+	if err := m.Trigger("report"); err != nil {
+		t.Fatalf("Trigger failed: %v", err)
+	}
+	<-started
+	if err := m.Trigger("report"); !errors.Is(err, ErrCronJobRunning) {
+		t.Errorf("expected ErrCronJobRunning, got %v", err)
+	}
+	if err := m.Trigger("missing"); !errors.Is(err, ErrCronJobNotFound) {
+		t.Errorf("expected ErrCronJobNotFound, got %v", err)
+	}
+	close(release)
+
+	next, err := m.NextRuns("report", 3)
+	if err != nil {
+		t.Fatalf("NextRuns failed: %v", err)
+	}
+	if len(next) != 3 || next[1].Sub(next[0]) != 24*time.Hour || next[0].Hour() != 3 {
+		t.Errorf("expected three daily runs at 03:00, got %v", next)
+	}
+}
+
+func TestCronManager_Pause(t *testing.T) {
+	m := NewCronManager(CronConfig{Logger: testLogger()})
+	runs := make(chan struct{}, 10)
+	err := m.Add(CronJob{ID: "tick", Schedule: "@every 1s", Handler: func(ctx *JobContext) error {
+		runs <- struct{}{}
+		return nil
+	}})
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := m.Pause("tick"); err != nil {
+		t.Fatalf("Pause failed: %v", err)
+	}
+	if err := m.Pause("missing"); !errors.Is(err, ErrCronJobNotFound) {
+		t.Errorf("expected ErrCronJobNotFound, got %v", err)
+	}
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer m.Stop()
+
+	select {
+	case <-runs:
+		t.Fatal("paused job ran on schedule")
+	case <-time.After(1500 * time.Millisecond):
+	}
+	if status := m.Status()[0]; !status.Paused || status.NextRun != nil {
+		t.Errorf("expected a paused status without a next run, got %+v", status)
+	}
+
+	// Manual runs still work while paused
+	if err := m.Trigger("tick"); err != nil {
+		t.Fatalf("Trigger failed: %v", err)
+	}
+	<-runs
+
+	if err := m.Resume("tick"); err != nil {
+		t.Fatalf("Resume failed: %v", err)
+	}
+	select {
+	case <-runs:
+	case <-time.After(2 * time.Second):
+		t.Fatal("resumed job did not run")
+	}
+	if m.Status()[0].Paused {
+		t.Error("expected the job resumed")
+	}
+}
+
+func TestCronManager_PersistentHistory(t *testing.T) {
+	db := openAsyncTestDB(t)
+	m := NewCronManager(CronConfig{
+		Logger:       testLogger(),
+		DBManager:    &mockDBManager{db: db},
+		HistoryLimit: 2,
+	})
+
+	calls := 0
+	err := m.Add(CronJob{
+		ID:       "report",
+		Schedule: "@daily",
+		Handler: func(ctx *JobContext) error {
+			calls++
+			if calls == 3 {
+				return errors.New("boom")
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer m.Stop()
+
+	// Run synchronously so history order is deterministic
+	for i := 0; i < 3; i++ {
+		m.mu.Lock()
+		m.entries["report"].running++
+		m.runs.Add(1)
+		m.mu.Unlock()
+		m.execute(m.ctx, m.entries["report"])
+	}
+
+	runs, err := m.History("report", 10)
+	if err != nil {
+		t.Fatalf("History failed: %v", err)
+	}
+	if len(runs) != 2 {
+		t.Fatalf("expected history pruned to 2 runs, got %d", len(runs))
+	}
+	if runs[0].Error != "boom" {
+		t.Errorf("expected newest run to carry the error, got %q", runs[0].Error)
+	}
+
+	status := m.Status()[0]
+	if status.LastError != "boom" || status.LastRun == nil {
+		t.Errorf("unexpected status: %+v", status)
+	}
+
+	if _, err := m.History("missing", 1); !errors.Is(err, ErrCronJobNotFound) {
+		t.Errorf("expected ErrCronJobNotFound, got %v", err)
+	}
+}
+
+func TestParseCronSchedule_DaylightSaving(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want []time.Time
+	}{
+		{
+			// 2024-03-10: clocks go from 02:00 EST to 03:00 EDT
+			name: "skipped time runs when the clocks jump",
+			expr: "30 2 * * *",
+			from: time.Date(2024, time.March, 9, 12, 0, 0, 0, ny),
+			want: []time.Time{
+				time.Date(2024, time.March, 10, 7, 0, 0, 0, time.UTC), // 03:00 EDT
+				time.Date(2024, time.March, 11, 6, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			// 2024-11-03: clocks go from 02:00 EDT back to 01:00 EST
+			name: "repeated hour runs once",
+			expr: "30 1 * * *",
+			from: time.Date(2024, time.November, 2, 12, 0, 0, 0, ny),
+			want: []time.Time{
+				time.Date(2024, time.November, 3, 5, 30, 0, 0, time.UTC), // 01:30 EDT
+				time.Date(2024, time.November, 4, 6, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "every hour follows elapsed time",
+			expr: "0 * * * *",
+			from: time.Date(2024, time.November, 3, 0, 30, 0, 0, ny),
+			want: []time.Time{
+				time.Date(2024, time.November, 3, 5, 0, 0, 0, time.UTC), // 01:00 EDT
+				time.Date(2024, time.November, 3, 6, 0, 0, 0, time.UTC), // 01:00 EST
+				time.Date(2024, time.November, 3, 7, 0, 0, 0, time.UTC), // 02:00 EST
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseCronSchedule(tt.expr)
+			if err != nil {
+				t.Fatalf("parse failed: %v", err)
+			}
+			next := tt.from
+			for _, want := range tt.want {
+				next = schedule.Next(next)
+				if !next.Equal(want) {
+					t.Fatalf("expected %v, got %v", want.In(ny), next)
+				}
+			}
+		})
+	}
+
+	// The second pass of the repeated hour doesn't run it again
+	schedule, _ := ParseCronSchedule("30 1 * * *")
+	secondPass := time.Date(2024, time.November, 3, 6, 10, 0, 0, time.UTC).In(ny) // 01:10 EST
+	if got, want := schedule.Next(secondPass), time.Date(2024, time.November, 4, 6, 30, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("expected %v, got %v", want.In(ny), got)
+	}
+}
+
+func TestWithCronJobIn(t *testing.T) {
+	noop := func(ctx *JobContext) error { return nil }
+	var c appConfig
+	WithCronJobIn("market-open", "30 9 * * 1-5", "America/New_York", noop, CronSkipIfRunning())(&c)
+	if len(c.cronJobs) != 1 || c.cronJobs[0].Location == nil || c.cronJobs[0].Location.String() != "America/New_York" || !c.cronJobs[0].SkipIfRunning {
+		t.Fatalf("unexpected jobs %+v", c.cronJobs)
+	}
+
+	WithCronJobIn("bad", "@daily", "Mars/Olympus", noop)(&c)
+	if c.cronErr == nil || len(c.cronJobs) != 1 {
+		t.Errorf("expected an error for an unknown time zone, got %v", c.cronErr)
+	}
+}
+
+func TestCronManager_Drain(t *testing.T) {
+	m := NewCronManager(CronConfig{Logger: testLogger(), Location: time.UTC})
+	started := make(chan struct{}, 1)
+	noop := func(ctx *JobContext) error { return nil }
+	m.Add(CronJob{ID: "noop", Schedule: "0 3 * * *", Handler: noop})
+	m.Add(CronJob{ID: "slow", Schedule: "0 3 * * *", Handler: func(ctx *JobContext) error {
+		started <- struct{}{}
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	}})
+	m.Add(CronJob{ID: "stuck", Schedule: "0 3 * * *", Handler: func(ctx *JobContext) error {
+		started <- struct{}{}
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer m.Stop()
+
+	m.Trigger("slow")
+	<-started
+	m.Trigger("stuck")
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if err := m.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the drain deadline error, got %v", err)
+	}
+	if err := m.Trigger("noop"); !errors.Is(err, ErrCronNotRunning) {
+		t.Errorf("expected ErrCronNotRunning after Drain, got %v", err)
+	}
+
+	slow, _ := m.History("slow", 1)
+	stuck, _ := m.History("stuck", 1)
+	if len(slow) != 1 || slow[0].Error != "" {
+		t.Errorf("expected the slow run to finish cleanly, got %+v", slow)
+	}
+	if len(stuck) != 1 || !strings.HasPrefix(stuck[0].Error, "canceled by shutdown") {
+		t.Errorf("expected the stuck run to be canceled, got %+v", stuck)
+	}
+}
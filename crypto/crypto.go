@@ -81,6 +81,15 @@ func normalizeKey(key string) []byte {
 	return keyBytes
 }
 
+// HashPassword returns the bcrypt hash of password, ready to store.
+func HashPassword(password string) (string, error) {
+	hash, err := GeneratePasswordHash(password)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
 // GeneratePasswordHash creates a bcrypt hash of the password.
 func GeneratePasswordHash(password string) ([]byte, error) {
 	return bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
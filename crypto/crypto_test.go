@@ -61,3 +61,22 @@ func TestPasswordHash(t *testing.T) {
 		t.Error("VerifyPassword should return false for wrong password")
 	}
 }
+
+func TestHashPassword(t *testing.T) {
+	hash, err := HashPassword("secure-password-123")
+	if err != nil {
+		t.Fatalf("HashPassword failed: %v", err)
+	}
+
+	other, _ := HashPassword("secure-password-123")
+	if hash == other {
+		t.Error("hashes of the same password should use different salts")
+	}
+
+	if !VerifyPassword(hash, "secure-password-123") {
+		t.Error("VerifyPassword should accept the hashed password")
+	}
+	if VerifyPassword(hash, "secure-password-124") {
+		t.Error("VerifyPassword should reject a different password")
+	}
+}
@@ -0,0 +1,18 @@
+package cartridge
+
+import (
+	cartridgemiddleware "github.com/karloscodes/cartridge/middleware"
+)
+
+// CSPNonce returns the request's Content-Security-Policy nonce, or "" when
+// no CSP policy is set (see WithCSP). Inline scripts run when they carry it.
+func (ctx *Context) CSPNonce() string {
+	return cartridgemiddleware.CSPNonce(ctx.Ctx)
+}
+
+// cspPreset sends policy along with the default security headers.
+func cspPreset(policy *cartridgemiddleware.CSP) *cartridgemiddleware.SecurityHeaders {
+	headers := cartridgemiddleware.DefaultSecurityHeaders()
+	headers.CSP = policy
+	return &headers
+}
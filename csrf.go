@@ -0,0 +1,51 @@
+package cartridge
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+
+	"github.com/gofiber/fiber/v2"
+
+	cartridgemiddleware "github.com/karloscodes/cartridge/middleware"
+)
+
+// csrfLocalsKey holds the request's CSRF token in fiber locals. NewServer
+// sets it as the CSRFConfig.ContextKey.
+const csrfLocalsKey = "csrf"
+
+// CSRFToken returns the request's CSRF token, or "" when the route isn't
+// protected by CSRF middleware (see ServerConfig.CSRF).
+func (ctx *Context) CSRFToken() string {
+	token, _ := ctx.Locals(csrfLocalsKey).(string)
+	return token
+}
+
+// RotateCSRFToken replaces the CSRF token once the handler returns. Call it
+// when the user signs in or out.
+func (ctx *Context) RotateCSRFToken() {
+	cartridgemiddleware.RotateCSRFToken(ctx.Ctx)
+}
+
+// csrfPreset signs tokens with a key derived from the session secret, so
+// every instance agrees on them, and secures the cookie in production.
+func csrfPreset(cfg Config, sessionSecret string, csrf cartridgemiddleware.CSRFConfig) *cartridgemiddleware.CSRFConfig {
+	if len(csrf.Secret) == 0 && sessionSecret != "" {
+		mac := hmac.New(sha256.New, []byte(sessionSecret))
+		mac.Write([]byte("cartridge-csrf"))
+		csrf.Secret = mac.Sum(nil)
+	}
+	if cfg.IsProduction() {
+		csrf.CookieSecure = true
+	}
+	return &csrf
+}
+
+// csrfBinding binds CSRF tokens to the signed-in user (see
+// CSRFConfig.Binding), so a token minted for one user is useless to
+// another. Without a session manager tokens are unbound.
+func (s *Server) csrfBinding(c *fiber.Ctx) string {
+	if s.session == nil {
+		return ""
+	}
+	return s.session.currentUser(c)
+}
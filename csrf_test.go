@@ -0,0 +1,136 @@
+package cartridge
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	cartridgemiddleware "github.com/karloscodes/cartridge/middleware"
+)
+
+func TestServerCSRF(t *testing.T) {
+	csrf := cartridgemiddleware.DefaultCSRFConfig()
+	csrf.ContextKey = "ignored"
+
+	cfg := DefaultServerConfig()
+	cfg.EnableStaticAssets = false
+	cfg.EnableRequestLogger = false
+	cfg.EnableSecFetchSite = false
+	cfg.Config = &testConfig{}
+	cfg.Logger = testLogger()
+	cfg.DBManager = &testDBManager{}
+	cfg.ErrorHandler = DefaultErrorHandler(testLogger(), false)
+	cfg.CSRF = &csrf
+
+	srv, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	ok := func(ctx *Context) error { return ctx.SendString(ctx.CSRFToken()) }
+	srv.Get("/form", ok)
+	srv.Post("/form", ok)
+	srv.Post("/webhook", ok, &RouteConfig{EnableCSRF: Bool(false)})
+
+	resp, err := srv.App().Test(httptest.NewRequest("GET", "/form", nil))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	token := string(body)
+	if token == "" {
+		t.Fatal("expected ctx.CSRFToken to return the issued token")
+	}
+
+	post := func(path, token string) int {
+		req := httptest.NewRequest("POST", path, nil)
+		if token != "" {
+			req.AddCookie(&http.Cookie{Name: csrf.CookieName, Value: token})
+			req.Header.Set(csrf.HeaderName, token)
+		}
+		resp, err := srv.App().Test(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		return resp.StatusCode
+	}
+
+	if status := post("/form", ""); status != fiber.StatusForbidden {
+		t.Errorf("expected 403 without a token, got %d", status)
+	}
+	if status := post("/form", token); status != fiber.StatusOK {
+		t.Errorf("expected 200 with the token, got %d", status)
+	}
+	if status := post("/webhook", ""); status != fiber.StatusOK {
+		t.Errorf("expected 200 on a route without CSRF, got %d", status)
+	}
+}
+
+func TestServerCSRF_BoundToUser(t *testing.T) {
+	csrf := cartridgemiddleware.DefaultCSRFConfig()
+	csrf.Secret = []byte("csrf-secret")
+
+	cfg := DefaultServerConfig()
+	cfg.EnableStaticAssets = false
+	cfg.EnableRequestLogger = false
+	cfg.EnableSecFetchSite = false
+	cfg.Config = &testConfig{}
+	cfg.Logger = testLogger()
+	cfg.DBManager = &testDBManager{}
+	cfg.ErrorHandler = DefaultErrorHandler(testLogger(), false)
+	cfg.CSRF = &csrf
+
+	srv, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	srv.SetSession(NewSessionManager(SessionConfig{Secret: "session-secret-of-at-least-32-bytes!"}))
+	ok := func(ctx *Context) error { return ctx.SendString(ctx.CSRFToken()) }
+	srv.Get("/form", ok)
+	srv.Post("/form", ok)
+	srv.Post("/login", func(ctx *Context) error {
+		return ctx.Auth.SetAuthCookie(ctx.Ctx, "alice")
+	})
+
+	send := func(method, path string, cookies ...*http.Cookie) *http.Response {
+		req := httptest.NewRequest(method, path, nil)
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+			if cookie.Name == csrf.CookieName {
+				req.Header.Set(csrf.HeaderName, cookie.Value)
+			}
+		}
+		resp, err := srv.App().Test(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		return resp
+	}
+	cookie := func(resp *http.Response, name string) *http.Cookie {
+		for _, c := range resp.Cookies() {
+			if c.Name == name {
+				return c
+			}
+		}
+		t.Fatalf("expected a %s cookie", name)
+		return nil
+	}
+
+	// A token issued before signing in is replaced by the login response
+	anonymous := cookie(send("GET", "/form"), csrf.CookieName)
+	login := send("POST", "/login", anonymous)
+	if login.StatusCode != fiber.StatusOK {
+		t.Fatalf("expected the login to pass CSRF, got %d", login.StatusCode)
+	}
+	auth, rotated := cookie(login, "session"), cookie(login, csrf.CookieName)
+
+	if status := send("POST", "/form", auth, rotated).StatusCode; status != fiber.StatusOK {
+		t.Errorf("expected 200 with the user's token, got %d", status)
+	}
+	// Another visitor's valid token, e.g. planted by a sibling subdomain
+	if status := send("POST", "/form", auth, anonymous).StatusCode; status != fiber.StatusForbidden {
+		t.Errorf("expected 403 with a token minted for someone else, got %d", status)
+	}
+}
@@ -7,12 +7,17 @@ type Config struct {
 	// DSN is the database connection string.
 	// For SQLite: file path (e.g., "storage/app.db")
 	// For PostgreSQL: connection URL or DSN string
+	// For MySQL: go-sql-driver DSN (e.g., "user:pass@tcp(host:3306)/dbname")
 	DSN string
 
-	// MaxOpenConns is the maximum number of open connections. Default: 1 for SQLite, 25 for PostgreSQL.
+	// ReplicaDSNs are connection strings for read replicas. Optional.
+	// Manager.ReadConnect distributes reads across them round-robin.
+	ReplicaDSNs []string
+
+	// MaxOpenConns is the maximum number of open connections. Default: 1 for SQLite, 25 for PostgreSQL/MySQL.
 	MaxOpenConns int
 
-	// MaxIdleConns is the maximum number of idle connections. Default: 1 for SQLite, 5 for PostgreSQL.
+	// MaxIdleConns is the maximum number of idle connections. Default: 1 for SQLite, 5 for PostgreSQL/MySQL.
 	MaxIdleConns int
 
 	// ConnMaxLifetime is the maximum connection lifetime. Default: 10 minutes.
@@ -23,6 +28,9 @@ type Config struct {
 
 	// PostgreSQL-specific options (ignored for other drivers)
 	Postgres PostgresOptions
+
+	// MySQL-specific options (ignored for other drivers)
+	MySQL MySQLOptions
 }
 
 // SQLiteOptions contains SQLite-specific configuration.
@@ -50,6 +58,15 @@ type PostgresOptions struct {
 	SearchPath string
 }
 
+// MySQLOptions contains MySQL-specific configuration.
+type MySQLOptions struct {
+	// Charset for the connection. Default: "utf8mb4".
+	Charset string
+
+	// Loc is the time zone used to parse DATETIME values. Default: "UTC".
+	Loc string
+}
+
 // DefaultConfig returns configuration with sensible defaults.
 func DefaultConfig(dsn string) *Config {
 	return &Config{
@@ -66,5 +83,9 @@ func DefaultConfig(dsn string) *Config {
 			SSLMode:  "prefer",
 			Timezone: "UTC",
 		},
+		MySQL: MySQLOptions{
+			Charset: "utf8mb4",
+			Loc:     "UTC",
+		},
 	}
 }
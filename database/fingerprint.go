@@ -0,0 +1,85 @@
+package database
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	fingerprintStrings = regexp.MustCompile(`'(?:[^']|'')*'`)
+	fingerprintNumbers = regexp.MustCompile(`\b\d+(?:\.\d+)?\b`)
+	fingerprintLists   = regexp.MustCompile(`\(\s*\?(?:\s*,\s*\?)*\s*\)`)
+	fingerprintSpaces  = regexp.MustCompile(`\s+`)
+	fingerprintTable   = regexp.MustCompile("(?i)\\bfrom\\s+[`\"]?(\\w+)[`\"]?")
+)
+
+// FingerprintSQL normalizes a query so that executions differing only in
+// literal values share a fingerprint: string and numeric literals become "?"
+// and IN lists collapse to "(?)".
+func FingerprintSQL(sql string) string {
+	fp := fingerprintStrings.ReplaceAllString(sql, "?")
+	fp = fingerprintNumbers.ReplaceAllString(fp, "?")
+	fp = fingerprintLists.ReplaceAllString(fp, "(?)")
+	fp = fingerprintSpaces.ReplaceAllString(fp, " ")
+	return strings.TrimSpace(fp)
+}
+
+// RepeatedQuery is a query fingerprint executed several times in one trace.
+type RepeatedQuery struct {
+	Fingerprint string `json:"fingerprint"`
+	Count       int    `json:"count"`
+	Table       string `json:"table,omitempty"`
+}
+
+// SuggestedPreload returns a GORM Preload hint for the repeated table,
+// e.g. `Preload("Comments")` for the comments table. Empty if unknown.
+func (r RepeatedQuery) SuggestedPreload() string {
+	if r.Table == "" {
+		return ""
+	}
+	var b strings.Builder
+	for _, part := range strings.Split(r.Table, "_") {
+		if part == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return `Preload("` + b.String() + `")`
+}
+
+// Repeated returns SELECT fingerprints executed at least min times, most
+// frequent first. Only the first 200 queries of a trace are considered.
+func (t *QueryTrace) Repeated(min int) []RepeatedQuery {
+	queries := t.Queries()
+
+	counts := make(map[string]int)
+	var order []string
+	for _, q := range queries {
+		if !strings.HasPrefix(strings.ToUpper(strings.TrimSpace(q.SQL)), "SELECT") {
+			continue
+		}
+		fp := FingerprintSQL(q.SQL)
+		if counts[fp] == 0 {
+			order = append(order, fp)
+		}
+		counts[fp]++
+	}
+
+	var repeated []RepeatedQuery
+	for _, fp := range order {
+		if counts[fp] < min {
+			continue
+		}
+		r := RepeatedQuery{Fingerprint: fp, Count: counts[fp]}
+		if m := fingerprintTable.FindStringSubmatch(fp); m != nil {
+			r.Table = m[1]
+		}
+		repeated = append(repeated, r)
+	}
+
+	sort.SliceStable(repeated, func(i, j int) bool {
+		return repeated[i].Count > repeated[j].Count
+	})
+	return repeated
+}
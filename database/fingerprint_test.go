@@ -0,0 +1,48 @@
+package database
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestFingerprintSQL(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want string
+	}{
+		{`SELECT * FROM "users" WHERE "users"."id" = 42`, `SELECT * FROM "users" WHERE "users"."id" = ?`},
+		{`SELECT * FROM users WHERE name = 'O''Brien'`, `SELECT * FROM users WHERE name = ?`},
+		{`SELECT * FROM posts WHERE id IN (1, 2,3)`, `SELECT * FROM posts WHERE id IN (?)`},
+		{"SELECT *\n  FROM table1   LIMIT 10", `SELECT * FROM table1 LIMIT ?`},
+	}
+
+	for _, tt := range tests {
+		if got := FingerprintSQL(tt.sql); got != tt.want {
+			t.Errorf("FingerprintSQL(%q) = %q, want %q", tt.sql, got, tt.want)
+		}
+	}
+}
+
+func TestQueryTrace_Repeated(t *testing.T) {
+	trace := NewQueryTrace("req", "/posts")
+	trace.Record(`SELECT * FROM "posts"`, time.Millisecond, 10, nil)
+	for i := 1; i <= 10; i++ {
+		sql := fmt.Sprintf(`SELECT * FROM "order_items" WHERE "order_items"."post_id" = %d`, i)
+		trace.Record(sql, time.Millisecond, 1, nil)
+	}
+	for i := 0; i < 6; i++ {
+		trace.Record(`UPDATE "posts" SET views = views + 1`, time.Millisecond, 1, nil)
+	}
+
+	repeated := trace.Repeated(5)
+	if len(repeated) != 1 {
+		t.Fatalf("expected 1 repeated SELECT, got %+v", repeated)
+	}
+	if repeated[0].Count != 10 || repeated[0].Table != "order_items" {
+		t.Errorf("unexpected repeated query: %+v", repeated[0])
+	}
+	if hint := repeated[0].SuggestedPreload(); hint != `Preload("OrderItems")` {
+		t.Errorf("unexpected suggestion: %s", hint)
+	}
+}
@@ -20,6 +20,10 @@ type GormLoggerConfig struct {
 
 	// IgnoreRecordNotFoundError suppresses "record not found" errors. Default: true.
 	IgnoreRecordNotFoundError bool
+
+	// Dialect is the database driver name, e.g. "postgres". It decides which
+	// quoted tokens are literals in query span text. Default: "" (SQLite rules)
+	Dialect string
 }
 
 // GormLogger adapts slog to gorm's logger.Interface.
@@ -90,7 +94,9 @@ func (l *GormLogger) Error(ctx context.Context, msg string, data ...interface{})
 }
 
 func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
-	if l.level <= logger.Silent {
+	trace := QueryTraceFromContext(ctx)
+	traced := spanRecording(ctx)
+	if l.level <= logger.Silent && trace == nil && !traced {
 		return
 	}
 
@@ -98,28 +104,41 @@ func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (stri
 	sql, rows := fc()
 	sql = sanitizeGormSQL(sql)
 
+	if traced {
+		recordQuerySpan(ctx, l.config.Dialect, begin, sql, rows, err)
+	}
+
+	// Attribute the query to the request that issued it
+	if trace != nil {
+		trace.Record(sql, elapsed, rows, err)
+	}
+	if l.level <= logger.Silent {
+		return
+	}
+
+	attrs := []any{
+		slog.Duration("elapsed", elapsed),
+		slog.Int64("rows", rows),
+		slog.String("sql", sql),
+	}
+	if trace != nil {
+		if id := trace.RequestID(); id != "" {
+			attrs = append(attrs, slog.String("request_id", id))
+		}
+		if route := trace.Route(); route != "" {
+			attrs = append(attrs, slog.String("route", route))
+		}
+	}
+
 	switch {
 	case err != nil && (l.config.IgnoreRecordNotFoundError && errors.Is(err, gorm.ErrRecordNotFound)):
 		return
 	case err != nil:
-		l.slogger.Error("gorm query failed",
-			slog.Duration("elapsed", elapsed),
-			slog.Int64("rows", rows),
-			slog.String("sql", sql),
-			slog.String("error", err.Error()),
-		)
+		l.slogger.Error("gorm query failed", append(attrs, slog.String("error", err.Error()))...)
 	case elapsed > l.config.SlowThreshold && l.level >= logger.Warn:
-		l.slogger.Warn("gorm slow query",
-			slog.Duration("elapsed", elapsed),
-			slog.Int64("rows", rows),
-			slog.String("sql", sql),
-		)
+		l.slogger.Warn("gorm slow query", attrs...)
 	case l.level >= logger.Info:
-		l.slogger.Debug("gorm query",
-			slog.Duration("elapsed", elapsed),
-			slog.Int64("rows", rows),
-			slog.String("sql", sql),
-		)
+		l.slogger.Debug("gorm query", attrs...)
 	}
 }
 
@@ -4,19 +4,24 @@ import (
 	"fmt"
 	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"gorm.io/gorm"
 )
 
 // Manager manages database connections using a pluggable driver.
+// When Config.ReplicaDSNs is set, ReadConnect spreads reads across replicas.
 type Manager struct {
-	driver  Driver
-	cfg     *Config
-	logger  *slog.Logger
-	db      *gorm.DB
-	dbOnce  sync.Once
-	dbMutex sync.Mutex
+	driver      Driver
+	cfg         *Config
+	logger      *slog.Logger
+	db          *gorm.DB
+	dbOnce      sync.Once
+	dbMutex     sync.Mutex
+	replicas    []*gorm.DB
+	replicaOnce sync.Once
+	nextReplica atomic.Uint64
 }
 
 // NewManager creates a new database manager with the given driver and config.
@@ -58,11 +63,48 @@ func (m *Manager) GetConnection() *gorm.DB {
 	return db
 }
 
-// Close closes the database connection.
+// ReadConnect returns a connection for read-only queries. Replicas are used
+// round-robin; without replicas (or if none could be opened) it returns the primary.
+func (m *Manager) ReadConnect() (*gorm.DB, error) {
+	m.replicaOnce.Do(m.openReplicas)
+
+	m.dbMutex.Lock()
+	replicas := m.replicas
+	m.dbMutex.Unlock()
+
+	if len(replicas) == 0 {
+		return m.Connect()
+	}
+	i := m.nextReplica.Add(1) - 1
+	return replicas[i%uint64(len(replicas))].Session(&gorm.Session{}), nil
+}
+
+// ReadConnection implements cartridge.ReadDBManager.
+// Returns nil if no connection is available.
+func (m *Manager) ReadConnection() *gorm.DB {
+	db, err := m.ReadConnect()
+	if err != nil {
+		m.logger.Error("failed to get read connection", slog.Any("error", err))
+		return nil
+	}
+	return db
+}
+
+// Close closes the primary and replica connections.
 func (m *Manager) Close() error {
 	m.dbMutex.Lock()
 	defer m.dbMutex.Unlock()
 
+	for _, replica := range m.replicas {
+		if sqlDB, err := replica.DB(); err == nil {
+			if err := sqlDB.Close(); err != nil {
+				m.logger.Warn("failed to close replica", slog.Any("error", err))
+			}
+		}
+	}
+	m.replicas = nil
+	m.replicaOnce = sync.Once{}
+
 	if m.db == nil {
 		return nil
 	}
@@ -113,11 +155,53 @@ func (m *Manager) open() error {
 		return nil
 	}
 
+	db, err := m.dial(m.cfg.DSN)
+	if err != nil {
+		return err
+	}
+
+	m.logger.Info("database connection established",
+		slog.String("driver", m.driver.Name()),
+		slog.Int("max_open", m.cfg.MaxOpenConns),
+		slog.Int("max_idle", m.cfg.MaxIdleConns),
+	)
+
+	m.db = db
+	return nil
+}
+
+// openReplicas connects to each configured replica. Replicas that fail to
+// connect are logged and skipped so reads fall back to the remaining ones.
+func (m *Manager) openReplicas() {
+	var replicas []*gorm.DB
+	for i, dsn := range m.cfg.ReplicaDSNs {
+		db, err := m.dial(dsn)
+		if err != nil {
+			m.logger.Error("failed to connect to read replica", slog.Int("replica", i), slog.Any("error", err))
+			continue
+		}
+		replicas = append(replicas, db)
+	}
+
+	if len(replicas) > 0 {
+		m.logger.Info("read replicas connected",
+			slog.String("driver", m.driver.Name()),
+			slog.Int("replicas", len(replicas)),
+		)
+	}
+
+	m.dbMutex.Lock()
+	m.replicas = replicas
+	m.dbMutex.Unlock()
+}
+
+// dial opens and configures a single connection pool for dsn.
+func (m *Manager) dial(dsn string) (*gorm.DB, error) {
 	// Configure DSN with driver-specific options
-	dsn := m.driver.ConfigureDSN(m.cfg.DSN, m.cfg)
+	dsn = m.driver.ConfigureDSN(dsn, m.cfg)
 
 	// Create GORM logger
-	gormLogger := NewGormLogger(m.logger.With(slog.String("component", "gorm")), nil)
+	gormLogger := NewGormLogger(m.logger.With(slog.String("component", "gorm")), &GormLoggerConfig{Dialect: m.driver.Name()})
 
 	// Open connection using driver's dialector
 	db, err := gorm.Open(m.driver.Open(dsn), &gorm.Config{
@@ -128,30 +212,23 @@ func (m *Manager) open() error {
 		},
 	})
 	if err != nil {
-		return fmt.Errorf("database: open: %w", err)
+		return nil, fmt.Errorf("database: open: %w", err)
 	}
 
 	// Run driver-specific post-connection setup
 	if err := m.driver.AfterConnect(db, m.cfg, m.logger); err != nil {
-		return err
+		return nil, err
 	}
 
 	// Configure connection pool
 	sqlDB, err := db.DB()
 	if err != nil {
-		return fmt.Errorf("database: access sql.DB: %w", err)
+		return nil, fmt.Errorf("database: access sql.DB: %w", err)
 	}
 
 	sqlDB.SetMaxOpenConns(m.cfg.MaxOpenConns)
 	sqlDB.SetMaxIdleConns(m.cfg.MaxIdleConns)
 	sqlDB.SetConnMaxLifetime(m.cfg.ConnMaxLifetime)
 
-	m.logger.Info("database connection established",
-		slog.String("driver", m.driver.Name()),
-		slog.Int("max_open", m.cfg.MaxOpenConns),
-		slog.Int("max_idle", m.cfg.MaxIdleConns),
-	)
-
-	m.db = db
-	return nil
+	return db, nil
 }
@@ -3,6 +3,7 @@ package database
 import (
 	"io"
 	"log/slog"
+	"path/filepath"
 	"testing"
 
 	"gorm.io/driver/sqlite"
@@ -74,6 +75,78 @@ func TestManager_Connect(t *testing.T) {
 	}
 }
 
+func TestManager_ReadConnect(t *testing.T) {
+	dir := t.TempDir()
+	dsn := func(name string) string { return filepath.Join(dir, name+".db") }
+
+	// Tag each database so we can tell which one served a read
+	for _, name := range []string{"primary", "replica1", "replica2"} {
+		db, err := gorm.Open(sqlite.Open(dsn(name)), &gorm.Config{})
+		if err != nil {
+			t.Fatalf("open %s: %v", name, err)
+		}
+		db.Exec("CREATE TABLE node (name TEXT)")
+		db.Exec("INSERT INTO node (name) VALUES (?)", name)
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}
+
+	cfg := &Config{
+		DSN:          dsn("primary"),
+		ReplicaDSNs:  []string{dsn("replica1"), dsn("replica2")},
+		MaxOpenConns: 1,
+		MaxIdleConns: 1,
+	}
+	manager := NewManager(&mockDriver{}, cfg, testLogger())
+	defer manager.Close()
+
+	nodeName := func(db *gorm.DB) string {
+		var name string
+		if err := db.Raw("SELECT name FROM node").Scan(&name).Error; err != nil {
+			t.Fatalf("query failed: %v", err)
+		}
+		return name
+	}
+
+	var got []string
+	for i := 0; i < 4; i++ {
+		db, err := manager.ReadConnect()
+		if err != nil {
+			t.Fatalf("ReadConnect failed: %v", err)
+		}
+		got = append(got, nodeName(db))
+	}
+	want := []string{"replica1", "replica2", "replica1", "replica2"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected reads %v, got %v", want, got)
+		}
+	}
+
+	if name := nodeName(manager.GetConnection()); name != "primary" {
+		t.Errorf("expected writes to use primary, got %s", name)
+	}
+}
+
+func TestManager_ReadConnectWithoutReplicas(t *testing.T) {
+	manager := NewManager(&mockDriver{}, &Config{DSN: ":memory:", MaxOpenConns: 1, MaxIdleConns: 1}, testLogger())
+	defer manager.Close()
+
+	primary, err := manager.Connect()
+	if err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	read, err := manager.ReadConnect()
+	if err != nil {
+		t.Fatalf("ReadConnect failed: %v", err)
+	}
+	readDB, _ := read.DB()
+	primaryDB, _ := primary.DB()
+	if readDB != primaryDB {
+		t.Error("expected reads to fall back to the primary connection")
+	}
+}
+
 func TestManager_Driver(t *testing.T) {
 	driver := &mockDriver{}
 	manager := NewManager(driver, nil, nil)
@@ -0,0 +1,111 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// maxTracedQueries caps how many queries a trace keeps. Counts and durations
+// keep accumulating beyond the cap.
+const maxTracedQueries = 200
+
+// TracedQuery is a single query recorded by a QueryTrace.
+type TracedQuery struct {
+	SQL      string        `json:"sql"`
+	Duration time.Duration `json:"duration"`
+	Rows     int64         `json:"rows"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// QueryTrace collects the queries issued on behalf of one unit of work,
+// typically an HTTP request. It is safe for concurrent use.
+type QueryTrace struct {
+	mu        sync.Mutex
+	requestID string
+	route     string
+	count     int
+	duration  time.Duration
+	queries   []TracedQuery
+}
+
+// NewQueryTrace creates a trace attributed to the given request ID and route.
+func NewQueryTrace(requestID, route string) *QueryTrace {
+	return &QueryTrace{requestID: requestID, route: route}
+}
+
+type queryTraceKey struct{}
+
+// WithQueryTrace returns a context carrying the trace. GORM sessions created
+// with db.WithContext(ctx) record their queries into it.
+func WithQueryTrace(ctx context.Context, trace *QueryTrace) context.Context {
+	return context.WithValue(ctx, queryTraceKey{}, trace)
+}
+
+// QueryTraceFromContext returns the trace attached to ctx, or nil.
+func QueryTraceFromContext(ctx context.Context) *QueryTrace {
+	if ctx == nil {
+		return nil
+	}
+	trace, _ := ctx.Value(queryTraceKey{}).(*QueryTrace)
+	return trace
+}
+
+// Record adds a query to the trace.
+func (t *QueryTrace) Record(sql string, duration time.Duration, rows int64, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.count++
+	t.duration += duration
+	if len(t.queries) >= maxTracedQueries {
+		return
+	}
+	q := TracedQuery{SQL: sql, Duration: duration, Rows: rows}
+	if err != nil {
+		q.Error = err.Error()
+	}
+	t.queries = append(t.queries, q)
+}
+
+// SetRoute sets the route the trace is attributed to.
+func (t *QueryTrace) SetRoute(route string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.route = route
+}
+
+// RequestID returns the request ID the trace is attributed to.
+func (t *QueryTrace) RequestID() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.requestID
+}
+
+// Route returns the route the trace is attributed to.
+func (t *QueryTrace) Route() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.route
+}
+
+// Count returns the number of queries recorded.
+func (t *QueryTrace) Count() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.count
+}
+
+// Duration returns the total time spent in recorded queries.
+func (t *QueryTrace) Duration() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.duration
+}
+
+// Queries returns a copy of the recorded queries (at most 200).
+func (t *QueryTrace) Queries() []TracedQuery {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]TracedQuery(nil), t.queries...)
+}
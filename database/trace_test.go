@@ -0,0 +1,49 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestGormLogger_RecordsQueryTrace(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: NewGormLogger(testLogger(), nil),
+	})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+
+	trace := NewQueryTrace("req-1", "/users/:id")
+	ctx := WithQueryTrace(context.Background(), trace)
+
+	var n int
+	for i := 0; i < 3; i++ {
+		if err := db.WithContext(ctx).Raw("SELECT 1").Scan(&n).Error; err != nil {
+			t.Fatalf("query failed: %v", err)
+		}
+	}
+
+	// Queries outside the traced context are not attributed
+	db.Raw("SELECT 2").Scan(&n)
+
+	if trace.Count() != 3 {
+		t.Errorf("expected 3 traced queries, got %d", trace.Count())
+	}
+	queries := trace.Queries()
+	if len(queries) != 3 || !strings.Contains(queries[0].SQL, "SELECT 1") {
+		t.Errorf("unexpected traced queries: %+v", queries)
+	}
+	if trace.RequestID() != "req-1" || trace.Route() != "/users/:id" {
+		t.Errorf("unexpected attribution: %s %s", trace.RequestID(), trace.Route())
+	}
+}
+
+func TestQueryTraceFromContext_Missing(t *testing.T) {
+	if QueryTraceFromContext(context.Background()) != nil {
+		t.Error("expected nil trace for plain context")
+	}
+}
@@ -0,0 +1,65 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const tracerName = "github.com/karloscodes/cartridge/database"
+
+// spanQuoted matches double-quoted strings. SQLite's Explain renders string
+// vars this way and MySQL reads them as literals, but PostgreSQL quotes
+// identifiers with them.
+var spanQuoted = regexp.MustCompile(`"(?:[^"]|"")*"`)
+
+// spanRecording reports whether ctx carries an OpenTelemetry span that records,
+// i.e. the query was issued by a traced request or job.
+func spanRecording(ctx context.Context) bool {
+	return trace.SpanFromContext(ctx).IsRecording()
+}
+
+// recordQuerySpan adds a client span for a finished query as a child of the
+// span in ctx, using the parent's tracer provider. Literal values are replaced
+// with "?" so user data doesn't end up in the tracing backend.
+func recordQuerySpan(ctx context.Context, dialect string, begin time.Time, sql string, rows int64, err error) {
+	parent := trace.SpanFromContext(ctx)
+	_, span := parent.TracerProvider().Tracer(tracerName).Start(ctx, queryOperation(sql),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(begin),
+		trace.WithAttributes(
+			attribute.String("db.query.text", spanText(sql, dialect)),
+			attribute.Int64("db.response.returned_rows", rows),
+		),
+	)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// spanText returns sql with its literal values replaced by "?", keeping
+// PostgreSQL's double-quoted identifiers.
+func spanText(sql, dialect string) string {
+	if dialect != "postgres" {
+		sql = spanQuoted.ReplaceAllString(sql, "?")
+	}
+	return FingerprintSQL(sql)
+}
+
+// queryOperation names a query span after its SQL verb, e.g. "SELECT".
+func queryOperation(sql string) string {
+	verb, _, _ := strings.Cut(sql, " ")
+	if verb == "" {
+		return "db.query"
+	}
+	return strings.ToUpper(verb)
+}
@@ -0,0 +1,65 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+func TestGormLogger_RecordsQuerySpans(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: NewGormLogger(testLogger(), nil).LogMode(gormlogger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "request")
+
+	var n int
+	if err := db.WithContext(ctx).Raw("SELECT 1 WHERE 'secret' = ?", "secret").Scan(&n).Error; err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	// Queries without a span are not traced
+	if err := db.Raw("SELECT 2").Scan(&n).Error; err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected a query span and the request span, got %d", len(spans))
+	}
+	query := spans[0]
+	if query.Name() != "SELECT" {
+		t.Errorf("expected span name SELECT, got %q", query.Name())
+	}
+	if query.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Error("expected query span to be a child of the request span")
+	}
+	for _, kv := range query.Attributes() {
+		if kv.Key == "db.query.text" && kv.Value.AsString() != "SELECT ? WHERE ? = ?" {
+			t.Errorf("expected literals to be stripped, got %q", kv.Value.AsString())
+		}
+	}
+}
+
+func TestSpanText(t *testing.T) {
+	tests := []struct{ dialect, sql, want string }{
+		{"sqlite", `SELECT * FROM users WHERE email = "a@b.co" AND id = 7`, "SELECT * FROM users WHERE email = ? AND id = ?"},
+		{"mysql", "SELECT * FROM `users` WHERE `email` = 'a@b.co'", "SELECT * FROM `users` WHERE `email` = ?"},
+		{"postgres", `SELECT * FROM "users" WHERE "users"."email" = 'a@b.co'`, `SELECT * FROM "users" WHERE "users"."email" = ?`},
+	}
+	for _, tt := range tests {
+		if got := spanText(tt.sql, tt.dialect); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.dialect, tt.want, got)
+		}
+	}
+}
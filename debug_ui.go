@@ -0,0 +1,200 @@
+package cartridge
+
+import (
+	"bytes"
+	"html/template"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DefaultDebugUIPath is the conventional mount point for MountDebugUI.
+const DefaultDebugUIPath = "/_cartridge"
+
+// debugUITaskLimit caps the async tasks the debug UI lists.
+const debugUITaskLimit = 50
+
+// DebugUIConfig configures the debug dashboard.
+type DebugUIConfig struct {
+	// Auth guards every page, e.g. a session or basic auth check that
+	// returns an error or calls c.Next(). Default: nil, which serves the
+	// dashboard in development only and answers 404 elsewhere
+	Auth fiber.Handler
+
+	// Roles admits callers with any of these roles (see RouteConfig.Roles),
+	// checked after Auth. Optional.
+	Roles []string
+
+	// Logs supplies the log tail. Default: the app logger's handler, when it
+	// is a LogTail, as with NewSSRApp and NewInertiaApp
+	Logs *LogTail
+}
+
+// DebugSnapshot is what the debug dashboard shows, also served as JSON at
+// its "/api" subpath. Async task payloads and results are left out.
+type DebugSnapshot struct {
+	Routes      []RouteInfo     `json:"routes"`
+	Cron        []CronJobStatus `json:"cron"`
+	Tasks       []AsyncTask     `json:"tasks"`
+	SlowQueries []SlowQuery     `json:"slow_queries"`
+	Config      EffectiveConfig `json:"config"`
+	Logs        []LogEntry      `json:"logs"`
+}
+
+// MountDebugUI mounts a dashboard at path showing the routes, cron
+// schedules with their next runs, recent async tasks, slow queries, the
+// redacted effective config and the log tail. Without DebugUIConfig.Auth
+// it is only served in development.
+//
+//	app.MountDebugUI(cartridge.DefaultDebugUIPath, cartridge.DebugUIConfig{
+//	    Auth: requireAdmin,
+//	})
+func (a *Application) MountDebugUI(path string, config ...DebugUIConfig) {
+	var cfg DebugUIConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	logs := cfg.Logs
+	if logs == nil {
+		logs, _ = a.Logger.Handler().(*LogTail)
+	}
+
+	auth := cfg.Auth
+	if auth == nil {
+		development := a.Config != nil && a.Config.IsDevelopment()
+		auth = func(c *fiber.Ctx) error {
+			if !development {
+				return fiber.ErrNotFound
+			}
+			return c.Next()
+		}
+	}
+	route := &RouteConfig{
+		CustomMiddleware: []fiber.Handler{auth},
+		Roles:            cfg.Roles,
+		EnableRateLimit:  Bool(false),
+	}
+
+	path = strings.TrimSuffix(path, "/")
+	a.Server.Get(path, func(ctx *Context) error {
+		var buf bytes.Buffer
+		if err := debugUITemplate.Execute(&buf, newDebugUIPage(path, a.debugSnapshot(logs))); err != nil {
+			return err
+		}
+		ctx.Set(fiber.HeaderCacheControl, "no-store")
+		ctx.Type("html")
+		return ctx.Send(buf.Bytes())
+	}, route)
+	a.Server.Get(path+"/api", func(ctx *Context) error {
+		ctx.Set(fiber.HeaderCacheControl, "no-store")
+		return ctx.JSON(a.debugSnapshot(logs))
+	}, route)
+}
+
+// debugSnapshot collects the dashboard's data.
+func (a *Application) debugSnapshot(logs *LogTail) DebugSnapshot {
+	snapshot := DebugSnapshot{
+		Routes:      a.Server.Routes(),
+		SlowQueries: a.Server.SlowQueries(),
+		Config:      a.EffectiveConfig(),
+	}
+	for _, w := range a.workers {
+		if cron, ok := w.(*CronManager); ok {
+			snapshot.Cron = append(snapshot.Cron, cron.Status()...)
+		}
+	}
+	if a.Server.async != nil {
+		tasks, err := a.Server.async.List(AsyncTaskFilter{Limit: debugUITaskLimit})
+		if err != nil {
+			a.Logger.Warn("debug UI: list async tasks", "error", err)
+		}
+		for i := range tasks {
+			tasks[i].Payload, tasks[i].Result = "", ""
+		}
+		snapshot.Tasks = tasks
+	}
+	if logs != nil {
+		snapshot.Logs = logs.Entries()
+		slices.Reverse(snapshot.Logs) // newest first
+	}
+	return snapshot
+}
+
+// debugUIPage is the dashboard template's data.
+type debugUIPage struct {
+	Path   string
+	Config []debugUISetting
+	DebugSnapshot
+}
+
+type debugUISetting struct {
+	Key string
+	ConfigSetting
+}
+
+func newDebugUIPage(path string, snapshot DebugSnapshot) debugUIPage {
+	page := debugUIPage{Path: path, DebugSnapshot: snapshot}
+	for key, setting := range snapshot.Config {
+		page.Config = append(page.Config, debugUISetting{key, setting})
+	}
+	slices.SortFunc(page.Config, func(a, b debugUISetting) int { return strings.Compare(a.Key, b.Key) })
+	return page
+}
+
+var debugUITemplate = template.Must(template.New("debug").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>cartridge debug</title>
+<style>
+body { font: 14px/1.4 system-ui, sans-serif; margin: 2rem; color: #222; }
+h2 { margin-top: 2rem; border-bottom: 1px solid #ddd; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: .25rem .5rem; border-bottom: 1px solid #eee; vertical-align: top; }
+code, td.mono { font-family: ui-monospace, monospace; font-size: 12px; }
+.muted { color: #888; }
+</style>
+</head>
+<body>
+<h1>cartridge debug</h1>
+<p class="muted">JSON: <a href="{{.Path}}/api">{{.Path}}/api</a></p>
+
+<h2>Routes ({{len .Routes}})</h2>
+<table>
+<tr><th>Method</th><th>Path</th><th>Name</th><th>Roles</th><th>Policy</th><th>Feature</th></tr>
+{{range .Routes}}<tr><td>{{.Method}}</td><td class="mono">{{.Path}}</td><td>{{.Name}}</td><td>{{range $i, $r := .Roles}}{{if $i}}, {{end}}{{$r}}{{end}}</td><td>{{.Policy}}</td><td>{{.Feature}}</td></tr>
+{{end}}</table>
+
+<h2>Cron</h2>
+{{if .Cron}}<table>
+<tr><th>Job</th><th>Schedule</th><th>Last run</th><th>Last error</th><th>Next run</th></tr>
+{{range .Cron}}<tr><td>{{.ID}}</td><td class="mono">{{.Schedule}}</td><td>{{with .LastRun}}{{.Format "2006-01-02 15:04:05"}}{{end}}{{if .Running}} (running){{end}}</td><td>{{.LastError}}</td><td>{{with .NextRun}}{{.Format "2006-01-02 15:04:05"}}{{end}}</td></tr>
+{{end}}</table>{{else}}<p class="muted">No cron jobs.</p>{{end}}
+
+<h2>Async tasks</h2>
+{{if .Tasks}}<table>
+<tr><th>ID</th><th>Name</th><th>Status</th><th>Attempts</th><th>Created</th><th>Error</th></tr>
+{{range .Tasks}}<tr><td class="mono">{{.ID}}</td><td>{{.Name}}</td><td>{{.Status}}</td><td>{{.Attempts}}</td><td>{{.CreatedAt.Format "2006-01-02 15:04:05"}}</td><td>{{.Error}}</td></tr>
+{{end}}</table>{{else}}<p class="muted">No async tasks.</p>{{end}}
+
+<h2>Slow queries</h2>
+{{if .SlowQueries}}<table>
+<tr><th>Time</th><th>Route</th><th>Duration</th><th>Rows</th><th>SQL</th></tr>
+{{range .SlowQueries}}<tr><td>{{.Time.Format "15:04:05"}}</td><td>{{.Method}} {{.Route}}</td><td>{{.Duration}}</td><td>{{.Rows}}</td><td class="mono">{{.SQL}}</td></tr>
+{{end}}</table>{{else}}<p class="muted">No slow queries.</p>{{end}}
+
+<h2>Config</h2>
+<table>
+<tr><th>Setting</th><th>Value</th><th>Source</th></tr>
+{{range .Config}}<tr><td class="mono">{{.Key}}</td><td class="mono">{{.Value}}</td><td>{{.Source}}</td></tr>
+{{end}}</table>
+
+<h2>Logs</h2>
+{{if .Logs}}<table>
+<tr><th>Time</th><th>Level</th><th>Message</th></tr>
+{{range .Logs}}<tr><td>{{.Time.Format "15:04:05"}}</td><td>{{.Level}}</td><td>{{.Message}}{{range $k, $v := .Attrs}} <code class="muted">{{$k}}={{$v}}</code>{{end}}</td></tr>
+{{end}}</table>{{else}}<p class="muted">No log tail; the app's logger has no LogTail handler.</p>{{end}}
+</body>
+</html>
+`))
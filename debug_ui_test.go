@@ -0,0 +1,93 @@
+package cartridge
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/karloscodes/cartridge/config"
+)
+
+func TestApplication_MountDebugUI(t *testing.T) {
+	newApp := func(env string) *Application {
+		t.Helper()
+		appCfg := &config.Config{AppName: "shop", Environment: env, SessionSecret: "hunter2"}
+		logger := slog.New(NewLogTail(slog.NewTextHandler(io.Discard, nil), 10))
+		cfg := DefaultServerConfig()
+		cfg.EnableStaticAssets = false
+		cfg.EnableRequestLogger = false
+		cfg.Config = appCfg
+		cfg.Logger = logger
+		cfg.DBManager = &testDBManager{}
+		cfg.ErrorHandler = DefaultErrorHandler(logger, false)
+		srv, err := NewServer(cfg)
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		srv.Get("/orders", func(ctx *Context) error { return nil }, &RouteConfig{Name: "orders"})
+
+		cron := NewCronManager(CronConfig{Logger: logger})
+		if err := cron.Add(CronJob{ID: "digest", Schedule: "@daily", Handler: func(ctx *JobContext) error { return nil }}); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+		app, err := NewApplication(ApplicationOptions{
+			Config: appCfg, Logger: logger, DBManager: &testDBManager{}, Server: srv,
+			BackgroundWorkers: []BackgroundWorker{cron},
+		})
+		if err != nil {
+			t.Fatalf("NewApplication failed: %v", err)
+		}
+		logger.Warn("payment provider slow", "api_key", "sk_live_123")
+		return app
+	}
+	get := func(app *Application, path string) (int, string) {
+		t.Helper()
+		resp, err := app.Server.App().Test(httptest.NewRequest("GET", path, nil))
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	app := newApp(config.Development)
+	app.MountDebugUI(DefaultDebugUIPath)
+	status, body := get(app, DefaultDebugUIPath)
+	if status != 200 || !strings.Contains(body, "/orders") || !strings.Contains(body, "digest") || !strings.Contains(body, "payment provider slow") {
+		t.Errorf("expected the dashboard in development, got %d %s", status, body)
+	}
+	if strings.Contains(body, "sk_live_123") || strings.Contains(body, "hunter2") {
+		t.Error("expected secrets to be redacted")
+	}
+
+	status, body = get(app, DefaultDebugUIPath+"/api")
+	var snapshot DebugSnapshot
+	if err := json.Unmarshal([]byte(body), &snapshot); status != 200 || err != nil {
+		t.Fatalf("expected the snapshot as JSON, got %d %v", status, err)
+	}
+	if len(snapshot.Cron) != 1 || snapshot.Logs[0].Attrs["api_key"] != "[redacted]" || snapshot.Config["config.SessionSecret"].Value != "[redacted]" {
+		t.Errorf("unexpected snapshot %+v", snapshot)
+	}
+
+	// Outside development it needs Auth
+	prod := newApp(config.Production)
+	prod.MountDebugUI(DefaultDebugUIPath)
+	if status, _ := get(prod, DefaultDebugUIPath); status != 404 {
+		t.Errorf("expected 404 in production without Auth, got %d", status)
+	}
+	guarded := newApp(config.Production)
+	guarded.MountDebugUI("/admin/debug/", DebugUIConfig{Auth: func(c *fiber.Ctx) error {
+		if c.Get("X-Admin") != "yes" {
+			return ErrUnauthorized("admins only")
+		}
+		return c.Next()
+	}})
+	if status, _ := get(guarded, "/admin/debug"); status != 401 {
+		t.Errorf("expected Auth to reject the request, got %d", status)
+	}
+}
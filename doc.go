@@ -129,4 +129,8 @@
 //	s.Get("/api/public", handler, &cartridge.RouteConfig{
 //		EnableCORS: true,
 //	})
+//
+// Restrict origins, including wildcard subdomains, with WithCORS:
+//
+//	cartridge.WithCORS("https://app.example.com", "https://*.example.com")
 package cartridge
@@ -0,0 +1,288 @@
+package cartridge
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Mapping fills in destination fields that can't be copied by name, such as
+// computed values. It runs after the automatic copy.
+type Mapping[S, D any] func(src *S, dst *D)
+
+// Map converts models to DTOs, so handlers return only the fields the DTO
+// declares instead of raw models with password hashes and internal columns.
+//
+// Each exported DTO field is copied from the model field with the same name,
+// or from the path in its `map` tag; `map:"-"` leaves it alone. Struct, slice
+// and pointer fields are mapped recursively, so a nested model fills a nested
+// DTO. Nil pointers along the way leave the field at its zero value. Fields
+// of incompatible types panic on first use, like other programming errors.
+//
+//	type ProductDTO struct {
+//	    ID       uint
+//	    Name     string
+//	    Vendor   string `map:"Vendor.Name"`
+//	    Variants []VariantDTO
+//	    Price    string `map:"-"`
+//	}
+//
+//	dtos := cartridge.Map(products, func(p *Product, dto *ProductDTO) {
+//	    dto.Price = p.Price.String()
+//	})
+//
+// Map always returns a non-nil slice, so empty lists encode as [].
+func Map[S, D any](items []S, mapping ...Mapping[S, D]) []D {
+	out := make([]D, len(items))
+	if len(items) == 0 {
+		return out
+	}
+	copyItem := copierFor(reflect.TypeFor[D](), reflect.TypeFor[S]())
+	for i := range items {
+		copyItem(reflect.ValueOf(&out[i]).Elem(), reflect.ValueOf(&items[i]).Elem())
+		for _, m := range mapping {
+			m(&items[i], &out[i])
+		}
+	}
+	return out
+}
+
+// MapOne converts a single model to a DTO like Map. A nil model returns nil.
+func MapOne[S, D any](src *S, mapping ...Mapping[S, D]) *D {
+	if src == nil {
+		return nil
+	}
+	dst := new(D)
+	copierFor(reflect.TypeFor[D](), reflect.TypeFor[S]())(reflect.ValueOf(dst).Elem(), reflect.ValueOf(src).Elem())
+	for _, m := range mapping {
+		m(src, dst)
+	}
+	return dst
+}
+
+// copyFunc copies src into dst, which is settable.
+type copyFunc func(dst, src reflect.Value)
+
+type planKey struct{ dst, src reflect.Type }
+
+// structPlan copies the fields of one struct type into another.
+type structPlan struct {
+	fields []fieldPlan
+}
+
+type fieldPlan struct {
+	dst  int     // destination field index
+	path [][]int // source field indexes, one per path segment
+	copy copyFunc
+}
+
+var (
+	copiers     sync.Map // planKey -> copyFunc
+	structPlans sync.Map // planKey -> *structPlan
+	planMu      sync.Mutex
+)
+
+// copierFor returns the cached copyFunc from src to dst values, building it
+// and the plans of nested structs on first use.
+func copierFor(dst, src reflect.Type) copyFunc {
+	key := planKey{dst, src}
+	if fn, ok := copiers.Load(key); ok {
+		return fn.(copyFunc)
+	}
+
+	planMu.Lock()
+	defer planMu.Unlock()
+	// Plans are published only once complete; building holds the ones in
+	// progress so self-referencing types resolve to themselves
+	building := make(map[planKey]*structPlan)
+	fn, err := buildCopy(dst, src, building)
+	if err != nil {
+		panic("cartridge: Map: " + err.Error())
+	}
+	for k, v := range building {
+		structPlans.Store(k, v)
+	}
+	copiers.Store(key, fn)
+	return fn
+}
+
+func buildStructPlan(dst, src reflect.Type, building map[planKey]*structPlan) (*structPlan, error) {
+	key := planKey{dst, src}
+	if p, ok := structPlans.Load(key); ok {
+		return p.(*structPlan), nil
+	}
+	if p, ok := building[key]; ok {
+		return p, nil
+	}
+	p := &structPlan{}
+	building[key] = p
+
+	for i := 0; i < dst.NumField(); i++ {
+		field := dst.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag, tagged := field.Tag.Lookup("map")
+		if tag == "-" {
+			continue
+		}
+
+		// Untagged embedded structs are filled from the same source
+		if field.Anonymous && !tagged && indirect(field.Type).Kind() == reflect.Struct {
+			fn, err := buildCopy(field.Type, src, building)
+			if err != nil {
+				return nil, err
+			}
+			p.fields = append(p.fields, fieldPlan{dst: i, copy: fn})
+			continue
+		}
+
+		name := field.Name
+		if tagged {
+			name = tag
+		}
+		path, srcType, ok := resolveSourcePath(src, name)
+		if !ok {
+			if tagged {
+				return nil, fmt.Errorf("%s has no field %q for %s.%s", src, tag, dst, field.Name)
+			}
+			continue // filled by a Mapping, if at all
+		}
+		fn, err := buildCopy(field.Type, srcType, building)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", dst, field.Name, err)
+		}
+		p.fields = append(p.fields, fieldPlan{dst: i, path: path, copy: fn})
+	}
+	return p, nil
+}
+
+// resolveSourcePath finds a dotted field path in src, following pointers.
+func resolveSourcePath(src reflect.Type, name string) ([][]int, reflect.Type, bool) {
+	var path [][]int
+	t := src
+	for _, segment := range strings.Split(name, ".") {
+		t = indirect(t)
+		if t.Kind() != reflect.Struct {
+			return nil, nil, false
+		}
+		f, ok := t.FieldByName(segment)
+		if !ok || !f.IsExported() || !promotedReadable(t, f.Index) {
+			return nil, nil, false
+		}
+		path = append(path, f.Index)
+		t = f.Type
+	}
+	return path, t, true
+}
+
+// buildCopy returns a copyFunc from src values to dst values.
+func buildCopy(dst, src reflect.Type, building map[planKey]*structPlan) (copyFunc, error) {
+	switch {
+	case src.AssignableTo(dst):
+		return func(d, s reflect.Value) { d.Set(s) }, nil
+
+	case src.Kind() == reflect.Pointer:
+		elem, err := buildCopy(dst, src.Elem(), building)
+		if err != nil {
+			return nil, err
+		}
+		return func(d, s reflect.Value) {
+			if s.IsNil() {
+				d.SetZero()
+				return
+			}
+			elem(d, s.Elem())
+		}, nil
+
+	case dst.Kind() == reflect.Pointer:
+		elem, err := buildCopy(dst.Elem(), src, building)
+		if err != nil {
+			return nil, err
+		}
+		return func(d, s reflect.Value) {
+			v := reflect.New(dst.Elem())
+			elem(v.Elem(), s)
+			d.Set(v)
+		}, nil
+
+	case dst.Kind() == reflect.Struct && src.Kind() == reflect.Struct:
+		p, err := buildStructPlan(dst, src, building)
+		if err != nil {
+			return nil, err
+		}
+		return p.copy, nil
+
+	case dst.Kind() == reflect.Slice && (src.Kind() == reflect.Slice || src.Kind() == reflect.Array):
+		elem, err := buildCopy(dst.Elem(), src.Elem(), building)
+		if err != nil {
+			return nil, err
+		}
+		return func(d, s reflect.Value) {
+			if s.Kind() == reflect.Slice && s.IsNil() {
+				d.SetZero()
+				return
+			}
+			out := reflect.MakeSlice(dst, s.Len(), s.Len())
+			for i := 0; i < s.Len(); i++ {
+				elem(out.Index(i), s.Index(i))
+			}
+			d.Set(out)
+		}, nil
+
+	case dst.Kind() == src.Kind() && src.ConvertibleTo(dst):
+		// Named types of the same kind, e.g. type Status string
+		return func(d, s reflect.Value) { d.Set(s.Convert(dst)) }, nil
+	}
+	return nil, fmt.Errorf("cannot map %s to %s", src, dst)
+}
+
+// copy fills the fields of dst from src.
+func (p *structPlan) copy(dst, src reflect.Value) {
+	for _, f := range p.fields {
+		s, ok := followPath(src, f.path)
+		if !ok {
+			continue // nil pointer along the path
+		}
+		f.copy(dst.Field(f.dst), s)
+	}
+}
+
+// followPath walks field indexes from v, dereferencing pointers and
+// reporting false on a nil one.
+func followPath(v reflect.Value, path [][]int) (reflect.Value, bool) {
+	for _, index := range path {
+		if v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		var err error
+		if v, err = v.FieldByIndexErr(index); err != nil {
+			return reflect.Value{}, false // nil embedded pointer
+		}
+	}
+	return v, true
+}
+
+// promotedReadable reports whether a promoted field is reachable through
+// exported embedded structs; reflection can't read it otherwise.
+func promotedReadable(t reflect.Type, index []int) bool {
+	for _, i := range index[:len(index)-1] {
+		f := t.Field(i)
+		if !f.IsExported() {
+			return false
+		}
+		t = indirect(f.Type)
+	}
+	return true
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Pointer {
+		return t.Elem()
+	}
+	return t
+}
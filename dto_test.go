@@ -0,0 +1,141 @@
+package cartridge
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type dtoVendor struct {
+	Name  string
+	Email string
+}
+
+type dtoVariant struct {
+	SKU   string
+	Stock int
+}
+
+type dtoStatus string
+
+// DTOModel is exported: fields promoted from unexported embedded structs
+// can't be read through reflection.
+type DTOModel struct {
+	ID        uint
+	CreatedAt time.Time
+}
+
+type dtoProduct struct {
+	DTOModel
+	Name         string
+	PasswordHash string
+	Status       dtoStatus
+	PriceCents   int64
+	Vendor       *dtoVendor
+	Variants     []dtoVariant
+	Parent       *dtoProduct
+}
+
+type dtoVariantDTO struct {
+	SKU string `json:"sku"`
+}
+
+type dtoProductDTO struct {
+	ID         uint            `json:"id"`
+	Name       string          `json:"name"`
+	Status     string          `json:"status"`
+	VendorName string          `json:"vendor_name" map:"Vendor.Name"`
+	Variants   []dtoVariantDTO `json:"variants"`
+	Parent     *dtoProductDTO  `json:"parent,omitempty"`
+	Price      string          `json:"price" map:"-"`
+}
+
+func TestMap(t *testing.T) {
+	products := []dtoProduct{
+		{
+			DTOModel:     DTOModel{ID: 1},
+			Name:         "Lamp",
+			PasswordHash: "secret",
+			Status:       "active",
+			PriceCents:   1250,
+			Vendor:       &dtoVendor{Name: "Acme", Email: "ops@acme.test"},
+			Variants:     []dtoVariant{{SKU: "L-1"}, {SKU: "L-2"}},
+			Parent:       &dtoProduct{DTOModel: DTOModel{ID: 9}, Name: "Lighting"},
+		},
+		{DTOModel: DTOModel{ID: 2}, Name: "Chair"},
+	}
+
+	dtos := Map(products, func(p *dtoProduct, dto *dtoProductDTO) {
+		dto.Price = "$" + strconv.FormatInt(p.PriceCents, 10)
+	})
+	if len(dtos) != 2 {
+		t.Fatalf("expected 2 DTOs, got %d", len(dtos))
+	}
+
+	lamp := dtos[0]
+	if lamp.ID != 1 || lamp.Name != "Lamp" || lamp.Status != "active" || lamp.VendorName != "Acme" {
+		t.Errorf("unexpected fields: %+v", lamp)
+	}
+	if len(lamp.Variants) != 2 || lamp.Variants[1].SKU != "L-2" {
+		t.Errorf("expected nested variants, got %+v", lamp.Variants)
+	}
+	if lamp.Parent == nil || lamp.Parent.ID != 9 || lamp.Parent.Parent != nil {
+		t.Errorf("expected the parent to be mapped recursively, got %+v", lamp.Parent)
+	}
+	if lamp.Price != "$1250" {
+		t.Errorf("expected the mapping to set Price, got %q", lamp.Price)
+	}
+
+	// Nil pointers and slices leave zero values
+	chair := dtos[1]
+	if chair.VendorName != "" || chair.Variants != nil || chair.Parent != nil {
+		t.Errorf("expected zero values for nil fields, got %+v", chair)
+	}
+
+	body, _ := json.Marshal(lamp)
+	if strings.Contains(string(body), "secret") {
+		t.Errorf("internal fields leaked: %s", body)
+	}
+}
+
+func TestMap_Empty(t *testing.T) {
+	dtos := Map[dtoProduct, dtoProductDTO](nil)
+	if dtos == nil || len(dtos) != 0 {
+		t.Errorf("expected an empty non-nil slice, got %#v", dtos)
+	}
+	if body, _ := json.Marshal(dtos); string(body) != "[]" {
+		t.Errorf("expected [], got %s", body)
+	}
+}
+
+func TestMapOne(t *testing.T) {
+	if got := MapOne[dtoProduct, dtoProductDTO](nil); got != nil {
+		t.Errorf("expected nil for a nil model, got %+v", got)
+	}
+
+	dto := MapOne[dtoProduct, dtoProductDTO](&dtoProduct{DTOModel: DTOModel{ID: 3}, Name: "Desk"})
+	if dto == nil || dto.ID != 3 || dto.Name != "Desk" {
+		t.Errorf("unexpected DTO: %+v", dto)
+	}
+
+	// Pointer models are dereferenced, nil ones map to zero values
+	dtos := Map[*dtoProduct, dtoProductDTO]([]*dtoProduct{{Name: "Shelf"}, nil})
+	if dtos[0].Name != "Shelf" || dtos[1].Name != "" {
+		t.Errorf("unexpected DTOs: %+v", dtos)
+	}
+}
+
+func TestMap_IncompatibleField(t *testing.T) {
+	type badDTO struct {
+		Name int
+	}
+	defer func() {
+		r := recover()
+		if r == nil || !strings.Contains(r.(string), "badDTO.Name") {
+			t.Errorf("expected a panic naming the field, got %v", r)
+		}
+	}()
+	Map[dtoProduct, badDTO]([]dtoProduct{{Name: "Lamp"}})
+}
@@ -0,0 +1,335 @@
+package cartridge
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/karloscodes/cartridge/config"
+)
+
+// ConfigPath serves the effective configuration as JSON in development.
+const ConfigPath = "/_config"
+
+// Sources of the settings in EffectiveConfig, besides those reported by
+// config.Config.Sources.
+const (
+	SourceOption = "option" // set by an AppOption or ServerConfig
+	SourceWiring = "wiring" // a dependency attached to the app
+)
+
+// ConfigSetting is a setting's value and where it came from: a
+// config.Source* constant, SourceOption or SourceWiring.
+type ConfigSetting struct {
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// EffectiveConfig is the configuration an application runs with: the
+// runtime config ("config.Port"), the server configuration after defaults
+// and options ("server.CSRF.CookieName") and the dependencies wired in
+// ("wiring.Session", "wiring.services.mailer"). Secrets are redacted.
+type EffectiveConfig map[string]ConfigSetting
+
+// ConfigChange is a setting that differs between two EffectiveConfigs.
+// Old is empty for an added setting and New for a removed one.
+type ConfigChange struct {
+	Key string
+	Old string
+	New string
+}
+
+// Diff returns the settings that changed since previous, sorted by key.
+func (c EffectiveConfig) Diff(previous EffectiveConfig) []ConfigChange {
+	var changes []ConfigChange
+	for key, setting := range c {
+		if old, ok := previous[key]; !ok || old.Value != setting.Value {
+			changes = append(changes, ConfigChange{Key: key, Old: old.Value, New: setting.Value})
+		}
+	}
+	for key, old := range previous {
+		if _, ok := c[key]; !ok {
+			changes = append(changes, ConfigChange{Key: key, Old: old.Value})
+		}
+	}
+	slices.SortFunc(changes, func(a, b ConfigChange) int { return strings.Compare(a.Key, b.Key) })
+	return changes
+}
+
+// EffectiveConfig returns the fully merged configuration, to debug
+// surprises such as "why is CSRF still on?". In development it is also
+// served at ConfigPath.
+func (a *Application) EffectiveConfig() EffectiveConfig {
+	settings := EffectiveConfig{}
+
+	// Runtime config, with the sources config.Load recorded
+	var sources map[string]string
+	if s, ok := a.Config.(interface{ Sources() map[string]string }); ok {
+		sources = s.Sources()
+	}
+	runtime := map[string]string{}
+	flattenSettings(runtime, "", reflect.ValueOf(a.Config), 0)
+	for key, value := range runtime {
+		field, _, _ := strings.Cut(key, ".")
+		source := sources[field]
+		if source == "" {
+			source = config.SourceDefault
+		}
+		if source == config.SourceSecret && value != "" {
+			value = "[redacted]"
+		}
+		settings["config."+key] = ConfigSetting{Value: value, Source: source}
+	}
+
+	// Server settings, compared with the defaults
+	defaults := map[string]string{}
+	flattenSettings(defaults, "", reflect.ValueOf(DefaultServerConfig()), 0)
+	server := map[string]string{}
+	flattenSettings(server, "", reflect.ValueOf(a.Server.cfg), 0)
+	for key, value := range server {
+		source := SourceOption
+		if def, ok := defaults[key]; ok && def == value {
+			source = config.SourceDefault
+		}
+		settings["server."+key] = ConfigSetting{Value: value, Source: source}
+	}
+
+	for key, value := range a.wiring() {
+		settings["wiring."+key] = ConfigSetting{Value: value, Source: SourceWiring}
+	}
+	return settings
+}
+
+// wiring describes the dependencies attached to the app and its server.
+func (a *Application) wiring() map[string]string {
+	s := a.Server
+	wiring := map[string]string{}
+	for name, dep := range map[string]any{
+		"Session":   s.session,
+		"Sessions":  s.sessions,
+		"JWT":       s.jwt,
+		"APITokens": s.tokens,
+		"Async":     s.async,
+		"Audit":     s.audit,
+		"Migrator":  a.lifecycle.Migrator,
+	} {
+		wiring[name] = typeName(reflect.ValueOf(dep))
+	}
+	for key, service := range s.services {
+		wiring["services."+string(key)] = typeName(reflect.ValueOf(service))
+	}
+	workers := make([]string, len(a.workers))
+	for i, w := range a.workers {
+		workers[i] = fmt.Sprintf("%T", w)
+	}
+	wiring["workers"] = strings.Join(workers, ", ")
+	wiring["seeders"] = fmt.Sprint(len(a.seeders))
+	wiring["routes"] = fmt.Sprint(len(s.routes))
+	return wiring
+}
+
+// Types printed with their String method rather than field by field.
+var (
+	durationType = reflect.TypeFor[time.Duration]()
+	timeType     = reflect.TypeFor[time.Time]()
+)
+
+// serverDependencies are ServerConfig fields reported elsewhere: the
+// runtime config under "config." and the rest by their type.
+var serverDependencies = map[string]bool{"Config": true, "Logger": true, "DBManager": true}
+
+// flattenSettings adds v's exported fields to out under dotted names.
+// Nested structs and pointers to structs are expanded a few levels deep;
+// interfaces are reported by their type and funcs as "func" or "nil".
+func flattenSettings(out map[string]string, prefix string, v reflect.Value, depth int) {
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			out[prefix] = "nil"
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct || v.Type() == timeType || depth > 2 {
+		if prefix != "" {
+			out[prefix] = settingValue(prefix, v)
+		}
+		return
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		key := field.Name
+		if prefix != "" {
+			key = prefix + "." + field.Name
+		}
+		value := v.Field(i)
+		switch {
+		case field.Anonymous && value.Kind() == reflect.Struct:
+			// Embedded config structs, e.g. an app's config.Config
+			flattenSettings(out, prefix, value, depth)
+		case depth == 0 && serverDependencies[field.Name] && v.Type() == reflect.TypeFor[ServerConfig]():
+			out[key] = typeName(value)
+		case value.Kind() == reflect.Interface:
+			out[key] = typeName(value)
+		default:
+			flattenSettings(out, key, value, depth+1)
+		}
+	}
+}
+
+// settingValue formats a leaf setting, redacting secrets.
+func settingValue(key string, v reflect.Value) string {
+	if !v.IsValid() {
+		return "nil"
+	}
+	if v.Kind() != reflect.Func && isSecretSetting(key) && !v.IsZero() {
+		return "[redacted]"
+	}
+	switch v.Kind() {
+	case reflect.Func:
+		if v.IsNil() {
+			return "nil"
+		}
+		return "func"
+	case reflect.Chan, reflect.UnsafePointer:
+		return typeName(v)
+	case reflect.Map, reflect.Slice, reflect.Array:
+		if v.Kind() != reflect.Array && v.IsNil() {
+			return "[]"
+		}
+		if elem := v.Type().Elem(); elem.Kind() > reflect.Complex128 && elem.Kind() != reflect.String {
+			return fmt.Sprintf("%d entries", v.Len())
+		}
+	case reflect.String:
+		return redactCredentials(v.String())
+	case reflect.Struct:
+		if v.Type() != timeType {
+			return typeName(v)
+		}
+	}
+	if v.Type() == durationType || v.Type() == timeType {
+		return fmt.Sprint(v.Interface())
+	}
+	if !v.CanInterface() {
+		return typeName(v)
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// typeName reports a dependency by its dynamic type, or "nil".
+func typeName(v reflect.Value) string {
+	if !v.IsValid() || (v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer) && v.IsNil() {
+		return "nil"
+	}
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+		if v.Kind() == reflect.Pointer && v.IsNil() {
+			return "nil"
+		}
+	}
+	return v.Type().String()
+}
+
+// isSecretSetting reports whether a setting named key holds a secret.
+// Connection strings count, since their password may be in any of several
+// driver-specific forms.
+func isSecretSetting(key string) bool {
+	name := strings.ToLower(key[strings.LastIndex(key, ".")+1:])
+	for _, marker := range []string{"secret", "password", "token", "privatekey", "apikey", "credential", "databaseurl", "databasereplicaurl", "dsn"} {
+		if strings.Contains(name, marker) {
+			return true
+		}
+	}
+	return strings.HasSuffix(name, "key")
+}
+
+// Passwords outside URLs: a "user:password@" prefix, as in MySQL DSNs, and
+// "password=..." pairs, as in PostgreSQL key/value DSNs.
+var (
+	dsnUserPassword = regexp.MustCompile(`^(\s*[^:@/\s]*):[^@\s]*@`)
+	dsnKeyPassword  = regexp.MustCompile(`(?i)\b(password|passwd|pwd)(\s*=\s*)(?:'(?:[^'\\]|\\.)*'|[^\s&;]*)`)
+)
+
+// redactCredentials hides the passwords in s, a setting that may hold
+// comma-separated connection strings: URLs, MySQL DSNs or key/value DSNs.
+func redactCredentials(s string) string {
+	parts := strings.Split(s, ",")
+	for i, part := range parts {
+		if strings.Contains(part, "://") {
+			if u, err := url.Parse(strings.TrimSpace(part)); err == nil && u.User != nil {
+				if _, ok := u.User.Password(); ok {
+					part = u.Redacted()
+				}
+			}
+		} else {
+			part = dsnUserPassword.ReplaceAllString(part, "${1}:xxxxx@")
+		}
+		parts[i] = dsnKeyPassword.ReplaceAllString(part, "${1}${2}xxxxx")
+	}
+	return strings.Join(parts, ",")
+}
+
+// configSnapshotPath is where the effective config is saved between runs:
+// effective-config.json in config.Config's DataDirectory, or "" for other
+// Configs.
+func configSnapshotPath(cfg Config) string {
+	if c, ok := cfg.(*config.Config); ok && c.DataDirectory != "" {
+		return filepath.Join(c.DataDirectory, "effective-config.json")
+	}
+	return ""
+}
+
+// logConfigChanges logs the settings that changed since the previous run
+// and saves the current ones for the next.
+func (a *Application) logConfigChanges() {
+	if a.snapshot == "" {
+		return
+	}
+	current := a.EffectiveConfig()
+
+	data, err := os.ReadFile(a.snapshot)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		a.Logger.Warn("failed to read previous config", "path", a.snapshot, "error", err)
+	default:
+		var previous EffectiveConfig
+		if err := json.Unmarshal(data, &previous); err != nil {
+			a.Logger.Warn("failed to parse previous config", "path", a.snapshot, "error", err)
+			break
+		}
+		for _, change := range current.Diff(previous) {
+			a.Logger.Info("config changed since last run", "key", change.Key, "old", change.Old, "new", change.New)
+		}
+	}
+
+	data, err = json.MarshalIndent(current, "", "  ")
+	if err == nil {
+		err = os.WriteFile(a.snapshot, data, 0o600)
+	}
+	if err != nil {
+		a.Logger.Warn("failed to save config snapshot", "path", a.snapshot, "error", err)
+	}
+}
+
+// mountConfigEndpoint serves EffectiveConfig at ConfigPath.
+func (a *Application) mountConfigEndpoint() {
+	a.Server.Get(ConfigPath, func(ctx *Context) error {
+		return ctx.JSON(a.EffectiveConfig())
+	}, &RouteConfig{EnableRateLimit: Bool(false)})
+}
@@ -0,0 +1,174 @@
+package cartridge
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// DefaultTemplateDirs are the conventional template locations checked by EmbedAssets,
+// in order, when EmbedOptions.TemplatesDir is empty.
+var DefaultTemplateDirs = []string{"web/templates", "templates", "resources/views", "views"}
+
+// DefaultStaticDirs are the conventional static asset locations checked by EmbedAssets,
+// in order, when EmbedOptions.StaticDir is empty.
+var DefaultStaticDirs = []string{"web/dist/assets", "dist/assets", "web/static", "static", "public"}
+
+// EmbedOptions configures EmbedAssets.
+type EmbedOptions struct {
+	// TemplatesDir is the template directory inside the templates FS.
+	// Default: auto-detected from DefaultTemplateDirs, or the FS root.
+	TemplatesDir string
+
+	// StaticDir is the static asset directory inside the static FS.
+	// Default: auto-detected from DefaultStaticDirs, or the FS root.
+	StaticDir string
+
+	// TemplateExtension is the template file extension. Default: ".html".
+	TemplateExtension string
+}
+
+// EmbeddedAssets holds validated, sub-rooted filesystems ready for WithAssets.
+type EmbeddedAssets struct {
+	// Templates is rooted at the template directory. Nil if no templates FS was given.
+	Templates fs.FS
+
+	// Static is rooted at the static asset directory. Nil if no static FS was given.
+	Static fs.FS
+
+	// TemplatesDir is the directory that was resolved for templates.
+	TemplatesDir string
+
+	// StaticDir is the directory that was resolved for static assets.
+	StaticDir string
+}
+
+// EmbedAssets resolves template and static directories inside embedded filesystems,
+// wires fs.Sub, and validates that they actually contain files.
+//
+// Misconfigured //go:embed patterns otherwise fail silently with empty template sets;
+// EmbedAssets returns a descriptive error instead so the app fails at startup.
+//
+//	//go:embed resources/views
+//	var templatesFS embed.FS
+//
+//	//go:embed public
+//	var staticFS embed.FS
+//
+//	assets, err := cartridge.EmbedAssets(templatesFS, staticFS)
+//	app, err := cartridge.NewSSRApp("myapp", cartridge.WithAssets(assets.Templates, assets.Static))
+func EmbedAssets(templatesFS, staticFS fs.FS, opts ...EmbedOptions) (*EmbeddedAssets, error) {
+	var o EmbedOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.TemplateExtension == "" {
+		o.TemplateExtension = ".html"
+	}
+
+	assets := &EmbeddedAssets{}
+
+	if templatesFS != nil {
+		dir, err := resolveEmbedDir(templatesFS, o.TemplatesDir, DefaultTemplateDirs, o.TemplateExtension)
+		if err != nil {
+			return nil, fmt.Errorf("cartridge: templates: %w", err)
+		}
+		sub, err := fs.Sub(templatesFS, dir)
+		if err != nil {
+			return nil, fmt.Errorf("cartridge: templates: sub %q: %w", dir, err)
+		}
+		assets.Templates = sub
+		assets.TemplatesDir = dir
+	}
+
+	if staticFS != nil {
+		dir, err := resolveEmbedDir(staticFS, o.StaticDir, DefaultStaticDirs, "")
+		if err != nil {
+			return nil, fmt.Errorf("cartridge: static assets: %w", err)
+		}
+		sub, err := fs.Sub(staticFS, dir)
+		if err != nil {
+			return nil, fmt.Errorf("cartridge: static assets: sub %q: %w", dir, err)
+		}
+		assets.Static = sub
+		assets.StaticDir = dir
+	}
+
+	return assets, nil
+}
+
+// MustEmbedAssets is like EmbedAssets but panics on error.
+// Useful for package-level variables in an embed.go file.
+func MustEmbedAssets(templatesFS, staticFS fs.FS, opts ...EmbedOptions) *EmbeddedAssets {
+	assets, err := EmbedAssets(templatesFS, staticFS, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return assets
+}
+
+// resolveEmbedDir picks the directory to sub-root: the explicit one if given,
+// otherwise the first conventional directory that exists, otherwise the root.
+// The chosen directory must contain at least one file (with ext, if set).
+func resolveEmbedDir(fsys fs.FS, explicit string, candidates []string, ext string) (string, error) {
+	if explicit != "" {
+		dir := path.Clean(strings.Trim(explicit, "/"))
+		if !isEmbedDir(fsys, dir) {
+			return "", fmt.Errorf("directory %q not found in embedded FS (check your //go:embed directive)", dir)
+		}
+		if !hasEmbedFiles(fsys, dir, ext) {
+			return "", emptyEmbedDirError(dir, ext)
+		}
+		return dir, nil
+	}
+
+	for _, candidate := range candidates {
+		if isEmbedDir(fsys, candidate) && hasEmbedFiles(fsys, candidate, ext) {
+			return candidate, nil
+		}
+	}
+
+	// Fall back to the root for FS values that were already sub-rooted
+	if hasEmbedFiles(fsys, ".", ext) {
+		return ".", nil
+	}
+
+	return "", fmt.Errorf("no files found (looked in %s and the FS root; check your //go:embed directive)",
+		strings.Join(candidates, ", "))
+}
+
+// isEmbedDir reports whether dir exists and is a directory.
+func isEmbedDir(fsys fs.FS, dir string) bool {
+	info, err := fs.Stat(fsys, dir)
+	return err == nil && info.IsDir()
+}
+
+// errEmbedFileFound stops the directory walk early.
+var errEmbedFileFound = errors.New("found")
+
+// hasEmbedFiles reports whether dir contains at least one regular file with the extension.
+func hasEmbedFiles(fsys fs.FS, dir, ext string) bool {
+	err := fs.WalkDir(fsys, dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if ext == "" || strings.HasSuffix(p, ext) {
+			return errEmbedFileFound
+		}
+		return nil
+	})
+	return errors.Is(err, errEmbedFileFound)
+}
+
+// emptyEmbedDirError describes an existing but empty directory.
+func emptyEmbedDirError(dir, ext string) error {
+	if ext != "" {
+		return fmt.Errorf("directory %q contains no *%s files (check your //go:embed directive)", dir, ext)
+	}
+	return fmt.Errorf("directory %q contains no files (check your //go:embed directive)", dir)
+}
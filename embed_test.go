@@ -0,0 +1,95 @@
+package cartridge
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestEmbedAssets(t *testing.T) {
+	t.Run("detects conventional directories", func(t *testing.T) {
+		templates := fstest.MapFS{
+			"resources/views/home.html": &fstest.MapFile{Data: []byte("home")},
+		}
+		static := fstest.MapFS{
+			"public/app.css": &fstest.MapFile{Data: []byte("body{}")},
+		}
+
+		assets, err := EmbedAssets(templates, static)
+		if err != nil {
+			t.Fatalf("EmbedAssets failed: %v", err)
+		}
+		if assets.TemplatesDir != "resources/views" {
+			t.Errorf("expected resources/views, got %s", assets.TemplatesDir)
+		}
+		if assets.StaticDir != "public" {
+			t.Errorf("expected public, got %s", assets.StaticDir)
+		}
+		if _, err := fs.Stat(assets.Templates, "home.html"); err != nil {
+			t.Errorf("expected home.html at templates root: %v", err)
+		}
+		if _, err := fs.Stat(assets.Static, "app.css"); err != nil {
+			t.Errorf("expected app.css at static root: %v", err)
+		}
+	})
+
+	t.Run("uses explicit directories", func(t *testing.T) {
+		templates := fstest.MapFS{
+			"ui/pages/index.tmpl": &fstest.MapFile{Data: []byte("index")},
+		}
+
+		assets, err := EmbedAssets(templates, nil, EmbedOptions{
+			TemplatesDir:      "ui/pages",
+			TemplateExtension: ".tmpl",
+		})
+		if err != nil {
+			t.Fatalf("EmbedAssets failed: %v", err)
+		}
+		if assets.TemplatesDir != "ui/pages" {
+			t.Errorf("expected ui/pages, got %s", assets.TemplatesDir)
+		}
+		if assets.Static != nil {
+			t.Error("expected nil static FS")
+		}
+	})
+
+	t.Run("falls back to already sub-rooted FS", func(t *testing.T) {
+		templates := fstest.MapFS{
+			"layout.html": &fstest.MapFile{Data: []byte("layout")},
+		}
+
+		assets, err := EmbedAssets(templates, nil)
+		if err != nil {
+			t.Fatalf("EmbedAssets failed: %v", err)
+		}
+		if assets.TemplatesDir != "." {
+			t.Errorf("expected root, got %s", assets.TemplatesDir)
+		}
+	})
+
+	t.Run("errors on empty template set", func(t *testing.T) {
+		templates := fstest.MapFS{
+			"web/templates/README.md": &fstest.MapFile{Data: []byte("docs")},
+		}
+
+		_, err := EmbedAssets(templates, nil)
+		if err == nil {
+			t.Fatal("expected error for FS without templates")
+		}
+		if !strings.Contains(err.Error(), "go:embed") {
+			t.Errorf("expected hint about go:embed, got %v", err)
+		}
+	})
+
+	t.Run("errors on missing explicit directory", func(t *testing.T) {
+		static := fstest.MapFS{
+			"public/app.js": &fstest.MapFile{Data: []byte("")},
+		}
+
+		_, err := EmbedAssets(nil, static, EmbedOptions{StaticDir: "dist"})
+		if err == nil || !strings.Contains(err.Error(), `"dist" not found`) {
+			t.Errorf("expected missing directory error, got %v", err)
+		}
+	})
+}
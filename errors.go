@@ -1,20 +1,179 @@
 package cartridge
 
 import (
+	"errors"
 	"fmt"
+	"html"
 	"log/slog"
+	"sort"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+// ErrorFormat selects the shape of JSON error responses.
+type ErrorFormat string
+
+const (
+	// ErrorFormatSimple renders {"error": "Not Found", "message": "..."}. Default.
+	ErrorFormatSimple ErrorFormat = "simple"
+
+	// ErrorFormatEnvelope renders {"error": {"code": "not_found", "message": "...",
+	// "details": ..., "request_id": "..."}}.
+	ErrorFormatEnvelope ErrorFormat = "envelope"
+
+	// ErrorFormatProblem renders application/problem+json (RFC 9457) with
+	// type, title, status, detail and instance members.
+	ErrorFormatProblem ErrorFormat = "problem"
+)
+
+// MIMEApplicationProblemJSON is the RFC 9457 problem details media type.
+// Clients that list it in Accept receive problem details whatever the
+// configured ErrorFormat.
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// Error is an HTTP error that handlers can return. DefaultErrorHandler maps
+// it to a JSON or HTML response with its status, code, message and details.
+// The wrapped Err is logged but never shown to clients.
+//
+//	return cartridge.ErrNotFound("product")
+//	return cartridge.ErrConflict("email already registered").WithDetails(map[string]string{"email": "taken"})
+type Error struct {
+	Status  int    // HTTP status. Default: 500
+	Code    string // Machine-readable code. Default: ErrorCode(Status)
+	Message string // Client-safe message
+	Details any    // Optional JSON details, e.g. per-field messages
+	Type    string // Problem type URI for problem+json. Default: "about:blank"
+	Err     error  // Underlying cause, for logs and errors.Is/As
+}
+
+// NewError creates an Error with the given status and message.
+func NewError(status int, message string) *Error {
+	return &Error{Status: status, Message: message}
+}
+
+// Error returns the message followed by the wrapped error, if any.
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap returns the wrapped error.
+func (e *Error) Unwrap() error { return e.Err }
+
+// WithCode returns a copy of e with a custom machine-readable code.
+func (e *Error) WithCode(code string) *Error {
+	c := *e
+	c.Code = code
+	return &c
+}
+
+// WithDetails returns a copy of e with JSON details attached.
+func (e *Error) WithDetails(details any) *Error {
+	c := *e
+	c.Details = details
+	return &c
+}
+
+// WithType returns a copy of e with a problem type URI, which may be
+// relative, e.g. "/problems/out-of-credit".
+func (e *Error) WithType(uri string) *Error {
+	c := *e
+	c.Type = uri
+	return &c
+}
+
+// Wrap returns a copy of e wrapping err as its cause.
+func (e *Error) Wrap(err error) *Error {
+	c := *e
+	c.Err = err
+	return &c
+}
+
+// status returns the HTTP status, defaulting to 500.
+func (e *Error) status() int {
+	if e.Status == 0 {
+		return fiber.StatusInternalServerError
+	}
+	return e.Status
+}
+
+// code returns the machine-readable code, defaulting to ErrorCode(status).
+func (e *Error) code() string {
+	if e.Code != "" {
+		return e.Code
+	}
+	return ErrorCode(e.status())
+}
+
+// ErrBadRequest returns a 400 Error.
+func ErrBadRequest(message string) *Error {
+	return NewError(fiber.StatusBadRequest, message)
+}
+
+// ErrUnauthorized returns a 401 Error.
+func ErrUnauthorized(message string) *Error {
+	return NewError(fiber.StatusUnauthorized, message)
+}
+
+// ErrForbidden returns a 403 Error.
+func ErrForbidden(message string) *Error {
+	return NewError(fiber.StatusForbidden, message)
+}
+
+// ErrNotFound returns a 404 Error for a resource: ErrNotFound("product")
+// has the message "product not found".
+func ErrNotFound(resource string) *Error {
+	return NewError(fiber.StatusNotFound, resource+" not found")
+}
+
+// ErrConflict returns a 409 Error.
+func ErrConflict(message string) *Error {
+	return NewError(fiber.StatusConflict, message)
+}
+
+// ErrUnprocessable returns a 422 Error with optional details.
+func ErrUnprocessable(message string, details any) *Error {
+	return &Error{Status: fiber.StatusUnprocessableEntity, Message: message, Details: details}
+}
+
+// ErrInternal returns a 500 Error wrapping err. Clients see a generic message.
+func ErrInternal(err error) *Error {
+	return &Error{Status: fiber.StatusInternalServerError, Message: "internal server error", Err: err}
+}
+
 // DefaultErrorHandler returns a production-ready error handler.
 // It returns JSON for API requests and simple HTML for browser requests.
-// For custom error pages with templates, use WithErrorHandler to provide your own.
-func DefaultErrorHandler(logger *slog.Logger, isDev bool) fiber.ErrorHandler {
+// JSON responses use the given format (default: ErrorFormatSimple).
+// For custom error pages with templates, use WithErrorPages, or
+// WithErrorHandler to provide your own.
+func DefaultErrorHandler(logger *slog.Logger, isDev bool, format ...ErrorFormat) fiber.ErrorHandler {
+	errFormat := ErrorFormatSimple
+	if len(format) > 0 && format[0] != "" {
+		errFormat = format[0]
+	}
+	return newErrorHandler(logger, isDev, errFormat, nil)
+}
+
+// newErrorHandler is DefaultErrorHandler rendering HTML with pages.
+func newErrorHandler(logger *slog.Logger, isDev bool, errFormat ErrorFormat, pages ErrorPages) fiber.ErrorHandler {
+	if errFormat == "" {
+		errFormat = ErrorFormatSimple
+	}
+
 	return func(c *fiber.Ctx, err error) error {
-		code := fiber.StatusInternalServerError
-		if e, ok := err.(*fiber.Error); ok {
-			code = e.Code
+		appErr := asError(err)
+		code := appErr.status()
+
+		// Validation failures are client errors with per-field details
+		var validationErrs ValidationErrors
+		if errors.As(err, &validationErrs) {
+			if wantsJSON(c) {
+				return writeErrorJSON(c, errFormat, appErr)
+			}
+			return writeErrorHTML(c, logger, pages, appErr, appErr.Message)
 		}
 
 		logger.Error("request failed",
@@ -25,20 +184,220 @@ func DefaultErrorHandler(logger *slog.Logger, isDev bool) fiber.ErrorHandler {
 		)
 
 		// JSON error response for API requests
-		if c.Accepts(fiber.MIMEApplicationJSON) == fiber.MIMEApplicationJSON {
-			return c.Status(code).JSON(fiber.Map{
-				"error":   ErrorCodeName(code),
-				"message": err.Error(),
-			})
+		if wantsJSON(c) {
+			return writeErrorJSON(c, errFormat, appErr)
 		}
 
-		// Simple HTML error page for browser requests
+		// HTML error page for browser requests. Client errors show their
+		// message; server errors only in development.
 		errorMsg := ""
-		if isDev {
+		if code < fiber.StatusInternalServerError {
+			errorMsg = appErr.Message
+		} else if isDev {
 			errorMsg = err.Error()
 		}
-		return c.Status(code).SendString(errorHTML(code, ErrorCodeName(code), errorMsg))
+		return writeErrorHTML(c, logger, pages, appErr, errorMsg)
+	}
+}
+
+// ErrorPages maps HTTP statuses to the templates rendering their HTML error
+// pages, e.g. 404: "errors/404". A status without a page uses its class's:
+// 500 covers every 5xx, and 400 the 4xx without a page of their own.
+// Statuses without either get the built-in page.
+type ErrorPages map[int]string
+
+// DefaultErrorPages renders errors/403, errors/404 and errors/500, the last
+// for every server error.
+var DefaultErrorPages = ErrorPages{
+	fiber.StatusForbidden:           "errors/403",
+	fiber.StatusNotFound:            "errors/404",
+	fiber.StatusInternalServerError: "errors/500",
+}
+
+// template returns the template for status, or "" for none.
+func (p ErrorPages) template(status int) string {
+	if name, ok := p[status]; ok {
+		return name
+	}
+	return p[status/100*100]
+}
+
+// writeErrorHTML writes the HTML error page for e: its template from pages,
+// or the built-in page. Pages get the view data every page gets (.Helpers)
+// and Status, Title, Code, Message, Details, RequestID and Path. A page that
+// fails to render is logged and replaced by the built-in page.
+func writeErrorHTML(c *fiber.Ctx, logger *slog.Logger, pages ErrorPages, e *Error, message string) error {
+	status := e.status()
+	if name := pages.template(status); name != "" && c.App().Config().Views != nil {
+		requestID, _ := c.Locals("requestid").(string)
+		err := c.Status(status).Render(name, fiber.Map{
+			"Status":    status,
+			"Title":     ErrorCodeName(status),
+			"Code":      e.code(),
+			"Message":   message,
+			"Details":   e.Details,
+			"RequestID": requestID,
+			"Path":      c.Path(),
+		})
+		if err == nil {
+			return nil
+		}
+		if logger != nil {
+			logger.Error("error page failed to render", slog.String("template", name), slog.Any("error", err))
+		}
+	}
+	return c.Status(status).Type("html").SendString(errorHTML(status, ErrorCodeName(status), html.EscapeString(message)))
+}
+
+// asError converts any handler error into an *Error: *Error is used as is,
+// *fiber.Error keeps its status, ValidationErrors become 422 with per-field
+// details, and anything else is a 500 carrying the error's message.
+func asError(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	var validationErrs ValidationErrors
+	if errors.As(err, &validationErrs) {
+		return &Error{Status: fiber.StatusUnprocessableEntity, Message: validationErrs.Error(), Details: validationErrs.Fields(), Err: err}
+	}
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return &Error{Status: fiberErr.Code, Message: fiberErr.Message, Err: err}
+	}
+	return &Error{Status: fiber.StatusInternalServerError, Message: err.Error(), Err: err}
+}
+
+// wantsJSON reports whether the client accepts a JSON response and doesn't
+// prefer HTML, as browsers do. Clients without a preference get JSON.
+func wantsJSON(c *fiber.Ctx) bool {
+	accepted := c.Accepts(fiber.MIMEApplicationJSON, MIMEApplicationProblemJSON, fiber.MIMETextHTML)
+	return accepted != "" && accepted != fiber.MIMETextHTML
+}
+
+// writeErrorJSON writes an error response in the given format. Details are
+// optional: per-field messages for validation errors, or any JSON value.
+func writeErrorJSON(c *fiber.Ctx, format ErrorFormat, e *Error) error {
+	requestID, _ := c.Locals("requestid").(string)
+	status, message, details := e.status(), e.Message, e.Details
+	if acceptsProblem(c) {
+		format = ErrorFormatProblem
+	}
+
+	switch format {
+	case ErrorFormatEnvelope:
+		body := fiber.Map{
+			"code":    e.code(),
+			"message": message,
+		}
+		if details != nil {
+			body["details"] = details
+		}
+		if requestID != "" {
+			body["request_id"] = requestID
+		}
+		return c.Status(status).JSON(fiber.Map{"error": body})
+
+	case ErrorFormatProblem:
+		problemType := e.Type
+		if problemType == "" {
+			problemType = "about:blank"
+		}
+		body := fiber.Map{
+			"type":     problemType,
+			"title":    ErrorCodeName(status),
+			"status":   status,
+			"detail":   message,
+			"instance": c.OriginalURL(),
+		}
+		if e.Code != "" {
+			body["code"] = e.Code
+		}
+		if fields, ok := details.(map[string]string); ok {
+			body["errors"] = problemFieldErrors(fields)
+		} else if details != nil {
+			body["errors"] = details
+		}
+		if requestID != "" {
+			body["request_id"] = requestID
+		}
+		return c.Status(status).JSON(body, MIMEApplicationProblemJSON)
+
+	default:
+		body := fiber.Map{
+			"error":   ErrorCodeName(status),
+			"message": message,
+		}
+		if e.Code != "" {
+			body["code"] = e.Code
+		}
+		if fields, ok := details.(map[string]string); ok {
+			body["fields"] = fields
+		} else if details != nil {
+			body["details"] = details
+		}
+		return c.Status(status).JSON(body)
+	}
+}
+
+// acceptsProblem reports whether the client explicitly asked for
+// application/problem+json.
+func acceptsProblem(c *fiber.Ctx) bool {
+	return strings.Contains(c.Get(fiber.HeaderAccept), MIMEApplicationProblemJSON)
+}
+
+// problemFieldErrors converts per-field messages to the RFC 9457 "errors"
+// extension: [{"detail": "...", "pointer": "#/email"}], sorted by field.
+func problemFieldErrors(fields map[string]string) []fiber.Map {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	errs := make([]fiber.Map, 0, len(names))
+	for _, name := range names {
+		pointer := "#/" + strings.NewReplacer(".", "/", "[", "/", "]", "").Replace(name)
+		errs = append(errs, fiber.Map{"detail": fields[name], "pointer": pointer})
+	}
+	return errs
+}
+
+// Fail writes an error response: JSON in the server's ErrorFormat for API
+// requests, or the HTML error page for browsers (see ErrorPages). Details
+// are included in JSON responses only.
+//
+//	return ctx.Fail(fiber.StatusConflict, "email already registered")
+func (ctx *Context) Fail(status int, message string, details ...any) error {
+	if wantsJSON(ctx.Ctx) {
+		var d any
+		if len(details) == 1 {
+			d = details[0]
+		} else if len(details) > 1 {
+			d = details
+		}
+		return writeErrorJSON(ctx.Ctx, ctx.errorFormat, &Error{Status: status, Message: message, Details: d})
+	}
+	return writeErrorHTML(ctx.Ctx, ctx.Logger, ctx.errorPages, &Error{Status: status, Message: message}, message)
+}
+
+// BadRequest writes a 400 error response. See Fail.
+func (ctx *Context) BadRequest(message string, details ...any) error {
+	return ctx.Fail(fiber.StatusBadRequest, message, details...)
+}
+
+// NotFound writes a 404 error response. See Fail.
+func (ctx *Context) NotFound(message string) error {
+	return ctx.Fail(fiber.StatusNotFound, message)
+}
+
+// ErrorCode returns a machine-readable code for a status, e.g. "not_found".
+func ErrorCode(status int) string {
+	name := ErrorCodeName(status)
+	if name == "Error" {
+		return "error"
 	}
+	return strings.ReplaceAll(strings.ToLower(name), " ", "_")
 }
 
 // ErrorCodeName returns a human-readable name for common HTTP status codes.
@@ -54,6 +413,14 @@ func ErrorCodeName(code int) string {
 		return "Not Found"
 	case fiber.StatusMethodNotAllowed:
 		return "Method Not Allowed"
+	case fiber.StatusConflict:
+		return "Conflict"
+	case fiber.StatusRequestEntityTooLarge:
+		return "Request Entity Too Large"
+	case fiber.StatusRequestedRangeNotSatisfiable:
+		return "Range Not Satisfiable"
+	case fiber.StatusUnprocessableEntity:
+		return "Unprocessable Entity"
 	case fiber.StatusTooManyRequests:
 		return "Too Many Requests"
 	case fiber.StatusInternalServerError:
@@ -1,12 +1,19 @@
 package cartridge
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
+	"net/http"
 	"os"
 	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/template/html/v2"
 )
 
 func TestErrorCodeName(t *testing.T) {
@@ -76,3 +83,265 @@ func TestDefaultErrorHandler(t *testing.T) {
 		}
 	})
 }
+
+func TestErrorFormats(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := []struct {
+		format      ErrorFormat
+		contentType string
+		check       func(t *testing.T, body map[string]any)
+	}{
+		{
+			format:      ErrorFormatSimple,
+			contentType: fiber.MIMEApplicationJSON,
+			check: func(t *testing.T, body map[string]any) {
+				if body["error"] != "Not Found" || body["message"] != "no such product" {
+					t.Errorf("unexpected body %v", body)
+				}
+			},
+		},
+		{
+			format:      ErrorFormatEnvelope,
+			contentType: fiber.MIMEApplicationJSON,
+			check: func(t *testing.T, body map[string]any) {
+				e, _ := body["error"].(map[string]any)
+				if e["code"] != "not_found" || e["message"] != "no such product" || e["request_id"] != "req-1" {
+					t.Errorf("unexpected body %v", body)
+				}
+			},
+		},
+		{
+			format:      ErrorFormatProblem,
+			contentType: MIMEApplicationProblemJSON,
+			check: func(t *testing.T, body map[string]any) {
+				instance, _ := body["instance"].(string)
+				if body["title"] != "Not Found" || body["status"] != float64(404) ||
+					body["detail"] != "no such product" || !strings.HasSuffix(instance, "/products/9") {
+					t.Errorf("unexpected body %v", body)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			app := fiber.New(fiber.Config{ErrorHandler: DefaultErrorHandler(logger, false, tt.format)})
+			app.Use(func(c *fiber.Ctx) error {
+				c.Locals("requestid", "req-1")
+				return c.Next()
+			})
+			// Both the error handler and ctx.NotFound produce the same shape
+			app.Get("/products/:id", func(c *fiber.Ctx) error {
+				return fiber.NewError(fiber.StatusNotFound, "no such product")
+			})
+			app.Get("/helper/products/:id", func(c *fiber.Ctx) error {
+				return (&Context{Ctx: c, errorFormat: tt.format}).NotFound("no such product")
+			})
+
+			for _, path := range []string{"/products/9", "/helper/products/9"} {
+				req := httpGet(path)
+				req.Header.Set("Accept", "application/json")
+				resp, err := app.Test(req)
+				if err != nil {
+					t.Fatalf("request failed: %v", err)
+				}
+				if resp.StatusCode != fiber.StatusNotFound {
+					t.Errorf("expected 404, got %d", resp.StatusCode)
+				}
+				if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, tt.contentType) {
+					t.Errorf("expected content type %s, got %s", tt.contentType, ct)
+				}
+				var body map[string]any
+				if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+					t.Fatalf("decode failed: %v", err)
+				}
+				tt.check(t, body)
+			}
+		})
+	}
+}
+
+func TestErrorType(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	errDB := errors.New("connection refused to 10.0.0.5")
+
+	app := fiber.New(fiber.Config{ErrorHandler: DefaultErrorHandler(logger, false, ErrorFormatEnvelope)})
+	app.Get("/missing", func(c *fiber.Ctx) error {
+		return ErrNotFound("product")
+	})
+	app.Get("/taken", func(c *fiber.Ctx) error {
+		return ErrConflict("email already registered").WithCode("email_taken").WithDetails(map[string]string{"email": "taken"})
+	})
+	app.Get("/broken", func(c *fiber.Ctx) error {
+		return fmt.Errorf("load product: %w", ErrInternal(errDB))
+	})
+
+	get := func(path, accept string) (*http.Response, string) {
+		req := httpGet(path)
+		req.Header.Set("Accept", accept)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		return resp, string(body)
+	}
+
+	t.Run("maps status and code", func(t *testing.T) {
+		resp, body := get("/taken", "application/json")
+		if resp.StatusCode != fiber.StatusConflict {
+			t.Errorf("expected 409, got %d", resp.StatusCode)
+		}
+		for _, want := range []string{`"code":"email_taken"`, `"message":"email already registered"`, `"email":"taken"`} {
+			if !strings.Contains(body, want) {
+				t.Errorf("expected %s in %s", want, body)
+			}
+		}
+	})
+
+	t.Run("defaults code from status", func(t *testing.T) {
+		resp, body := get("/missing", "application/json")
+		if resp.StatusCode != fiber.StatusNotFound || !strings.Contains(body, `"code":"not_found"`) ||
+			!strings.Contains(body, `"message":"product not found"`) {
+			t.Errorf("unexpected response %d: %s", resp.StatusCode, body)
+		}
+	})
+
+	t.Run("renders HTML for browsers", func(t *testing.T) {
+		resp, body := get("/missing", "text/html")
+		if resp.StatusCode != fiber.StatusNotFound || !strings.Contains(body, "product not found") {
+			t.Errorf("unexpected response %d: %s", resp.StatusCode, body)
+		}
+	})
+
+	t.Run("hides wrapped cause", func(t *testing.T) {
+		resp, body := get("/broken", "application/json")
+		if resp.StatusCode != fiber.StatusInternalServerError {
+			t.Errorf("expected 500, got %d", resp.StatusCode)
+		}
+		if strings.Contains(body, "10.0.0.5") {
+			t.Errorf("internal cause leaked: %s", body)
+		}
+	})
+
+	t.Run("unwraps", func(t *testing.T) {
+		if !errors.Is(ErrInternal(errDB), errDB) {
+			t.Error("expected errors.Is to find the cause")
+		}
+	})
+}
+
+func TestProblemDetails(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// The configured format is simple; problem+json is negotiated by Accept
+	app := fiber.New(fiber.Config{ErrorHandler: DefaultErrorHandler(logger, false)})
+	app.Post("/credits", func(c *fiber.Ctx) error {
+		return NewError(fiber.StatusForbidden, "balance is 30, cost is 50").WithType("/problems/out-of-credit")
+	})
+	app.Post("/users", func(c *fiber.Ctx) error {
+		return ValidationErrors{
+			{Field: "email", Message: "email must be a valid email address"},
+			{Field: "address.city", Message: "address.city is required"},
+		}
+	})
+
+	request := func(path, accept string) (*http.Response, map[string]any) {
+		req, _ := http.NewRequest("POST", path, nil)
+		req.Header.Set("Accept", accept)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		var body map[string]any
+		_ = json.NewDecoder(resp.Body).Decode(&body)
+		return resp, body
+	}
+
+	t.Run("negotiates problem+json", func(t *testing.T) {
+		resp, body := request("/credits", MIMEApplicationProblemJSON)
+		if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, MIMEApplicationProblemJSON) {
+			t.Errorf("expected problem+json, got %s", ct)
+		}
+		if body["type"] != "/problems/out-of-credit" || body["status"] != float64(403) ||
+			body["title"] != "Forbidden" || body["detail"] != "balance is 30, cost is 50" {
+			t.Errorf("unexpected body %v", body)
+		}
+	})
+
+	t.Run("keeps configured format for plain JSON", func(t *testing.T) {
+		_, body := request("/credits", "application/json")
+		if body["error"] != "Forbidden" {
+			t.Errorf("expected simple format, got %v", body)
+		}
+	})
+
+	t.Run("lists field errors with JSON pointers", func(t *testing.T) {
+		_, body := request("/users", MIMEApplicationProblemJSON)
+		errs, _ := body["errors"].([]any)
+		if len(errs) != 2 {
+			t.Fatalf("expected 2 errors, got %v", body["errors"])
+		}
+		first, _ := errs[0].(map[string]any)
+		if first["pointer"] != "#/address/city" || first["detail"] != "address.city is required" {
+			t.Errorf("unexpected first error %v", first)
+		}
+	})
+}
+
+func TestErrorPages(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	views := html.NewFileSystem(http.FS(fstest.MapFS{
+		"errors/404.html": {Data: []byte(`{{ .Status }} {{ .Title }}: {{ .Message }} at {{ .Path }} ({{ .Code }}, {{ .RequestID }})`)},
+		"errors/500.html": {Data: []byte(`{{ .Status }} sorry{{ .Message }}`)},
+		"errors/403.html": {Data: []byte(`{{ call .Status }}`)},
+	}), ".html")
+
+	app := fiber.New(fiber.Config{Views: views, ErrorHandler: newErrorHandler(logger, false, "", DefaultErrorPages)})
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("requestid", "req-1")
+		return c.Next()
+	})
+	app.Get("/products/:id", func(c *fiber.Ctx) error { return ErrNotFound("product") })
+	app.Get("/down", func(c *fiber.Ctx) error { return NewError(fiber.StatusServiceUnavailable, "db at 10.0.0.5 down") })
+	app.Get("/login", func(c *fiber.Ctx) error { return ErrUnauthorized("sign in first") })
+	app.Get("/admin", func(c *fiber.Ctx) error { return ErrForbidden("admins only") })
+	app.Get("/fail", func(c *fiber.Ctx) error {
+		return (&Context{Ctx: c, Logger: logger, errorPages: DefaultErrorPages}).NotFound("no such order")
+	})
+
+	get := func(path, accept string) (int, string, string) {
+		req := httpGet(path)
+		req.Header.Set("Accept", accept)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, resp.Header.Get("Content-Type"), string(body)
+	}
+	browser := "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+
+	tests := []struct {
+		path   string
+		status int
+		want   string
+	}{
+		{"/products/1", 404, "404 Not Found: product not found at /products/1 (not_found, req-1)"},
+		{"/fail", 404, "404 Not Found: no such order at /fail (not_found, req-1)"},
+		{"/down", 503, "503 sorry"},                          // the 5xx page, without the message
+		{"/login", 401, "<title>401 - Unauthorized</title>"}, // no page: built in
+		{"/admin", 403, "<title>403 - Forbidden</title>"},    // broken page: built in
+	}
+	for _, tt := range tests {
+		status, contentType, body := get(tt.path, browser)
+		if status != tt.status || !strings.HasPrefix(contentType, "text/html") || !strings.Contains(body, tt.want) {
+			t.Errorf("%s: expected %d with %q, got %d %s %q", tt.path, tt.status, tt.want, status, contentType, body)
+		}
+	}
+
+	if status, contentType, _ := get("/products/1", "application/json"); status != 404 || !strings.HasPrefix(contentType, "application/json") {
+		t.Errorf("expected JSON for API requests, got %d %s", status, contentType)
+	}
+}
@@ -0,0 +1,107 @@
+package cartridge
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	cartridgemiddleware "github.com/karloscodes/cartridge/middleware"
+)
+
+// etagConfig is the ETag configuration for server and route middleware.
+func (s *Server) etagConfig() cartridgemiddleware.ETagConfig {
+	// A compressed response carries the identity body's tag, so it can only
+	// be a weak validator
+	return cartridgemiddleware.ETagConfig{Weak: s.cfg.EnableCompress}
+}
+
+// routeETag applies RouteConfig.ETag: enabled adds the middleware unless it
+// already runs globally, disabled marks the response to be left untagged.
+func (s *Server) routeETag(enabled bool) fiber.Handler {
+	if !enabled {
+		return func(c *fiber.Ctx) error {
+			cartridgemiddleware.SkipETag(c)
+			return c.Next()
+		}
+	}
+	if s.cfg.EnableETag {
+		return func(c *fiber.Ctx) error { return c.Next() }
+	}
+	return cartridgemiddleware.ETag(s.etagConfig())
+}
+
+// staticValidator is the ETag and modification time of an embedded asset.
+type staticValidator struct {
+	etag    string
+	modTime time.Time
+}
+
+// staticValidators sets ETag and Last-Modified on embedded static assets and
+// answers 304 to conditional requests, before the file is opened. Tags are
+// content hashes computed once per file. Embedded files have no modification
+// time, so the server's start time stands in: the content can't change while
+// it runs.
+func (s *Server) staticValidators(prefix, index string) fiber.Handler {
+	started := time.Now().UTC().Truncate(time.Second)
+	var cache sync.Map // asset name -> staticValidator
+
+	return func(c *fiber.Ctx) error {
+		if c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead {
+			return c.Next()
+		}
+
+		name := strings.TrimPrefix(strings.TrimPrefix(c.Path(), prefix), "/")
+		if name == "" || strings.HasSuffix(name, "/") {
+			name += index
+		}
+		name = path.Clean(name)
+
+		v, ok := cache.Load(name)
+		if !ok {
+			validator, err := s.staticValidator(name, started)
+			if err != nil {
+				// Directories and missing files are the file server's concern
+				return c.Next()
+			}
+			v, _ = cache.LoadOrStore(name, validator)
+		}
+		validator := v.(staticValidator)
+
+		c.Set(fiber.HeaderETag, validator.etag)
+		c.Set(fiber.HeaderLastModified, validator.modTime.Format(http.TimeFormat))
+		if cartridgemiddleware.NotModified(c, validator.etag, validator.modTime) {
+			c.Status(fiber.StatusNotModified)
+			return nil
+		}
+		return c.Next()
+	}
+}
+
+// staticValidator hashes an embedded asset.
+func (s *Server) staticValidator(name string, started time.Time) (staticValidator, error) {
+	info, err := fs.Stat(s.cfg.StaticFS, name)
+	if err != nil {
+		return staticValidator{}, err
+	}
+	if info.IsDir() {
+		return staticValidator{}, fs.ErrNotExist
+	}
+	data, err := fs.ReadFile(s.cfg.StaticFS, name)
+	if err != nil {
+		return staticValidator{}, err
+	}
+
+	sum := sha256.Sum256(data)
+	modTime := info.ModTime().UTC().Truncate(time.Second)
+	if modTime.IsZero() {
+		modTime = started
+	}
+	return staticValidator{etag: `"` + hex.EncodeToString(sum[:16]) + `"`, modTime: modTime}, nil
+}
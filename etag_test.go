@@ -0,0 +1,138 @@
+package cartridge
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func newETagTestServer(t *testing.T, enableETag bool) *Server {
+	t.Helper()
+	cfg := DefaultServerConfig()
+	cfg.EnableRequestLogger = false
+	cfg.EnableSecFetchSite = false
+	cfg.EnableCompress = false
+	cfg.EnableETag = enableETag
+	cfg.Config = &testConfig{}
+	cfg.Logger = testLogger()
+	cfg.DBManager = &testDBManager{}
+	cfg.ErrorHandler = DefaultErrorHandler(testLogger(), false)
+	cfg.StaticFS = fstest.MapFS{
+		"app.js":          &fstest.MapFile{Data: []byte("console.log(1)")},
+		"docs/index.html": &fstest.MapFile{Data: []byte("<h1>docs</h1>")},
+	}
+
+	srv, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	return srv
+}
+
+// conditionalGet fetches path, then refetches it with the returned ETag.
+func conditionalGet(t *testing.T, srv *Server, path string) (etag string, revalidated int) {
+	t.Helper()
+	resp, err := srv.App().Test(httptest.NewRequest("GET", path, nil))
+	if err != nil {
+		t.Fatalf("%s: request failed: %v", path, err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("%s: expected 200, got %d", path, resp.StatusCode)
+	}
+	etag = resp.Header.Get(fiber.HeaderETag)
+	if etag == "" {
+		return "", 0
+	}
+
+	req := httptest.NewRequest("GET", path, nil)
+	req.Header.Set(fiber.HeaderIfNoneMatch, etag)
+	resp, err = srv.App().Test(req)
+	if err != nil {
+		t.Fatalf("%s: conditional request failed: %v", path, err)
+	}
+	return etag, resp.StatusCode
+}
+
+func TestServer_ETag(t *testing.T) {
+	t.Run("per route", func(t *testing.T) {
+		srv := newETagTestServer(t, false)
+		srv.Get("/status", func(ctx *Context) error {
+			return ctx.JSON(fiber.Map{"state": "running"})
+		}, &RouteConfig{ETag: Bool(true)})
+		srv.Get("/plain", func(ctx *Context) error {
+			return ctx.SendString("plain")
+		})
+
+		if _, status := conditionalGet(t, srv, "/status"); status != fiber.StatusNotModified {
+			t.Errorf("expected 304 for a matching ETag, got %d", status)
+		}
+		if etag, _ := conditionalGet(t, srv, "/plain"); etag != "" {
+			t.Errorf("expected no ETag without EnableETag, got %q", etag)
+		}
+	})
+
+	t.Run("global with opt-out", func(t *testing.T) {
+		srv := newETagTestServer(t, true)
+		srv.Get("/status", func(ctx *Context) error {
+			return ctx.SendString("running")
+		})
+		srv.Get("/live", func(ctx *Context) error {
+			return ctx.SendString("live")
+		}, &RouteConfig{ETag: Bool(false)})
+
+		if _, status := conditionalGet(t, srv, "/status"); status != fiber.StatusNotModified {
+			t.Errorf("expected 304 for a matching ETag, got %d", status)
+		}
+		if etag, _ := conditionalGet(t, srv, "/live"); etag != "" {
+			t.Errorf("expected opted-out route to have no ETag, got %q", etag)
+		}
+	})
+
+	t.Run("compressed route", func(t *testing.T) {
+		for _, global := range []bool{true, false} {
+			srv := newETagTestServer(t, global)
+			routeCfg := &RouteConfig{Compress: Bool(true)}
+			if !global {
+				routeCfg.ETag = Bool(true)
+			}
+			srv.Get("/report", func(ctx *Context) error {
+				return ctx.SendString("report")
+			}, routeCfg)
+
+			etag, status := conditionalGet(t, srv, "/report")
+			if !strings.HasPrefix(etag, `W/"`) {
+				t.Errorf("global ETag %v: expected a weak ETag on a compressed route, got %q", global, etag)
+			}
+			if status != fiber.StatusNotModified {
+				t.Errorf("global ETag %v: expected 304 for a matching ETag, got %d", global, status)
+			}
+		}
+	})
+
+	t.Run("static assets", func(t *testing.T) {
+		srv := newETagTestServer(t, false)
+
+		for _, path := range []string{"/assets/app.js", "/assets/docs/"} {
+			if _, status := conditionalGet(t, srv, path); status != fiber.StatusNotModified {
+				t.Errorf("%s: expected 304 for a matching ETag, got %d", path, status)
+			}
+		}
+
+		resp, err := srv.App().Test(httptest.NewRequest("GET", "/assets/app.js", nil))
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		req := httptest.NewRequest("GET", "/assets/app.js", nil)
+		req.Header.Set(fiber.HeaderIfModifiedSince, resp.Header.Get(fiber.HeaderLastModified))
+		resp, err = srv.App().Test(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusNotModified {
+			t.Errorf("expected 304 for If-Modified-Since, got %d", resp.StatusCode)
+		}
+	})
+}
@@ -0,0 +1,147 @@
+package cartridge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Event is a named occurrence passed to subscribers, e.g. "product.created"
+// with the new product as payload.
+type Event struct {
+	Name      string
+	Payload   any
+	EmittedAt time.Time
+}
+
+// EventHandler handles an event. Synchronous subscribers receive the
+// emitter's context. Async subscribers receive a *JobContext, which carries
+// the logger and a database handle:
+//
+//	app.On("product.created", func(ctx context.Context, e cartridge.Event) error {
+//	    job := ctx.(*cartridge.JobContext)
+//	    return reindex(job.DB, e.Payload.(*Product))
+//	}, cartridge.EventAsync())
+type EventHandler func(ctx context.Context, e Event) error
+
+// EventOption configures a subscription.
+type EventOption func(*eventSubscriber)
+
+// EventAsync dispatches the subscriber on the async worker pool instead of
+// within Emit, so slow side effects don't hold up the emitter. It requires
+// WithAsync or WithAsyncRequests. The task is named "event <name>" and, like
+// other ad-hoc tasks, is not persisted.
+func EventAsync() EventOption {
+	return func(s *eventSubscriber) {
+		s.async = true
+	}
+}
+
+type eventSubscriber struct {
+	handler EventHandler
+	async   bool
+}
+
+// EventBus delivers emitted events to in-process subscribers. It decouples
+// handlers from their side effects: the handler emits "order.placed", and
+// email, search indexing and analytics subscribe to it.
+type EventBus struct {
+	logger Logger
+
+	mu    sync.RWMutex
+	subs  map[string][]*eventSubscriber
+	async *AsyncManager
+}
+
+// NewEventBus creates an event bus. Logger defaults to slog.Default().
+func NewEventBus(logger Logger) *EventBus {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &EventBus{logger: logger, subs: make(map[string][]*eventSubscriber)}
+}
+
+// SetAsync sets the manager EventAsync subscribers are dispatched on.
+func (b *EventBus) SetAsync(m *AsyncManager) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.async = m
+}
+
+// On subscribes handler to the named event. Subscribers run in the order
+// they were added.
+func (b *EventBus) On(name string, handler EventHandler, opts ...EventOption) {
+	sub := &eventSubscriber{handler: handler}
+	for _, opt := range opts {
+		opt(sub)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs[name] = append(b.subs[name], sub)
+}
+
+// Emit delivers the event to its subscribers. Synchronous subscribers run
+// before Emit returns; async ones are queued. Every subscriber is tried, and
+// the errors of those that failed (or couldn't be queued) are logged and
+// returned joined. Emitting an event nobody subscribed to does nothing.
+//
+// Async subscribers share the payload with the emitter and each other, so
+// it must not be modified after Emit.
+func (b *EventBus) Emit(ctx context.Context, name string, payload any) error {
+	b.mu.RLock()
+	subs := b.subs[name]
+	async := b.async
+	b.mu.RUnlock()
+
+	event := Event{Name: name, Payload: payload, EmittedAt: time.Now().UTC()}
+	var errs []error
+	for _, sub := range subs {
+		if !sub.async {
+			if err := invokeEventHandler(sub.handler, ctx, event); err != nil {
+				errs = append(errs, fmt.Errorf("cartridge: event %s: %w", name, err))
+			}
+			continue
+		}
+		if async == nil {
+			errs = append(errs, fmt.Errorf("cartridge: event %s: async subscriber: %w", name, ErrAsyncNotEnabled))
+			continue
+		}
+		handler := sub.handler
+		_, err := async.RunFunc("event "+name, func(job *JobContext) (any, error) {
+			return nil, handler(job, event)
+		}, AsyncTraceFrom(ctx))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("cartridge: event %s: %w", name, err))
+		}
+	}
+	if len(errs) > 0 {
+		b.logger.Error("event subscribers failed", "event", name, "error", errors.Join(errs...))
+	}
+	return errors.Join(errs...)
+}
+
+// invokeEventHandler runs a synchronous subscriber, turning a panic into an
+// error so one subscriber can't take down the emitter.
+func invokeEventHandler(handler EventHandler, ctx context.Context, e Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(ctx, e)
+}
+
+// Emit delivers an event to the app's subscribers (see EventBus.Emit):
+//
+//	if err := db.Create(&product).Error; err != nil {
+//	    return err
+//	}
+//	ctx.Emit("product.created", &product)
+//
+// Synchronous subscribers receive the request's context.
+func (ctx *Context) Emit(name string, payload any) error {
+	return ctx.events.Emit(ctx.UserContext(), name, payload)
+}
@@ -0,0 +1,104 @@
+package cartridge
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestEventBus(t *testing.T) {
+	bus := NewEventBus(testLogger())
+
+	var calls []string
+	bus.On("product.created", func(ctx context.Context, e Event) error {
+		calls = append(calls, "first "+e.Payload.(string))
+		return nil
+	})
+	bus.On("product.created", func(ctx context.Context, e Event) error {
+		panic("boom")
+	})
+	bus.On("product.created", func(ctx context.Context, e Event) error {
+		calls = append(calls, "third "+e.Payload.(string))
+		return errors.New("index unavailable")
+	})
+
+	err := bus.Emit(context.Background(), "product.created", "lamp")
+	if err == nil || !strings.Contains(err.Error(), "panic: boom") || !strings.Contains(err.Error(), "index unavailable") {
+		t.Errorf("expected both failures, got %v", err)
+	}
+	if len(calls) != 2 || calls[0] != "first lamp" || calls[1] != "third lamp" {
+		t.Errorf("expected every subscriber in order, got %v", calls)
+	}
+
+	if err := bus.Emit(context.Background(), "product.deleted", nil); err != nil {
+		t.Errorf("expected no error without subscribers, got %v", err)
+	}
+}
+
+func TestEventBus_Async(t *testing.T) {
+	bus := NewEventBus(testLogger())
+	received := make(chan Event, 1)
+	bus.On("order.placed", func(ctx context.Context, e Event) error {
+		if _, ok := ctx.(*JobContext); !ok {
+			t.Errorf("expected a *JobContext, got %T", ctx)
+		}
+		received <- e
+		return nil
+	}, EventAsync())
+
+	if err := bus.Emit(context.Background(), "order.placed", 7); !errors.Is(err, ErrAsyncNotEnabled) {
+		t.Errorf("expected ErrAsyncNotEnabled without an async manager, got %v", err)
+	}
+
+	m := NewAsyncManager(AsyncConfig{Logger: testLogger()})
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer m.Stop()
+	bus.SetAsync(m)
+
+	if err := bus.Emit(context.Background(), "order.placed", 7); err != nil {
+		t.Fatalf("Emit failed: %v", err)
+	}
+	select {
+	case e := <-received:
+		if e.Name != "order.placed" || e.Payload != 7 {
+			t.Errorf("unexpected event %+v", e)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("async subscriber did not run")
+	}
+
+	tasks, err := m.List(AsyncTaskFilter{Name: "event order.placed"})
+	if err != nil || len(tasks) != 1 {
+		t.Errorf("expected one event task, got %v (%v)", tasks, err)
+	}
+}
+
+func TestContext_Emit(t *testing.T) {
+	srv := newTemplateTestServer(t, nil)
+	var created string
+	srv.Events().On("product.created", func(ctx context.Context, e Event) error {
+		created = e.Payload.(string)
+		return nil
+	})
+	srv.Post("/products", func(ctx *Context) error {
+		if err := ctx.Emit("product.created", "lamp"); err != nil {
+			return err
+		}
+		return ctx.SendStatus(fiber.StatusCreated)
+	})
+
+	resp, err := srv.App().Test(httptest.NewRequest("POST", "/products", nil))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusCreated || created != "lamp" {
+		t.Errorf("expected 201 and the subscriber called, got %d and %q", resp.StatusCode, created)
+	}
+}
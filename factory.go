@@ -2,35 +2,75 @@ package cartridge
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"html/template"
 	"io/fs"
 	"log/slog"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	html "github.com/gofiber/template/html/v2"
 	"gorm.io/gorm"
 
+	"github.com/karloscodes/cartridge/cache"
 	"github.com/karloscodes/cartridge/config"
+	"github.com/karloscodes/cartridge/database"
+	cartridgemiddleware "github.com/karloscodes/cartridge/middleware"
+	"github.com/karloscodes/cartridge/mysql"
+	"github.com/karloscodes/cartridge/postgres"
 	"github.com/karloscodes/cartridge/sqlite"
 )
 
+// DatabaseManager is a DBManager that can checkpoint and close its connection.
+// It is implemented by sqlite.Manager and database.Manager.
+type DatabaseManager interface {
+	DBManager
+	CheckpointWAL(mode string) error
+	Close() error
+}
+
 // App is a fully configured cartridge application.
 type App struct {
 	*Application
 	Config    *config.Config
 	Logger    *slog.Logger
-	DBManager *sqlite.Manager
+	DBManager *sqlite.Manager // SQLite manager; nil when another database driver is configured
+	Database  DatabaseManager // Active database manager for the configured driver
 	Server    *Server
 	Session   *SessionManager
+	Sessions  *Sessions
+	JWT       *JWTAuth
+	APITokens *APITokens // nil unless WithAPITokens is used
+	Async     *AsyncManager
+	Cron      *CronManager
+	WAL       *WALCheckpointer // nil unless WithWALCheckpoints is used with SQLite
+	Audit     AuditStore       // nil unless WithAuditLog is used
+	Notifier  *Notifier        // nil unless WithNotifications is used
+
+	pendingWorkers []BackgroundWorker     // added by the init callback, before Application exists
+	reloader       *configReloader        // created by WithConfigReload or OnConfigChange
+	logLevel       *slog.LevelVar         // the logger's level, changed on config reload
+	secrets        config.SecretsProvider // resolves the config on reload
+}
+
+// AddWorker adds a background worker that starts on Run and stops on
+// Shutdown. Workers implementing WorkerSetup receive the app's logger and
+// database. It can be called from WithInit.
+func (a *App) AddWorker(w BackgroundWorker) {
+	if a.Application == nil {
+		a.pendingWorkers = append(a.pendingWorkers, w)
+		return
+	}
+	a.Application.AddWorker(w)
 }
 
 // MigrateDatabase runs database migrations using the provided migrator.
 // It connects to the database, runs migrations, and checkpoints WAL.
 func (a *App) MigrateDatabase(migrator Migrator) error {
-	db, err := a.DBManager.Connect()
+	db, err := a.Database.Connect()
 	if err != nil {
 		return fmt.Errorf("connect database: %w", err)
 	}
@@ -39,16 +79,96 @@ func (a *App) MigrateDatabase(migrator Migrator) error {
 		return fmt.Errorf("run migrations: %w", err)
 	}
 
-	if err := a.DBManager.CheckpointWAL("FULL"); err != nil {
+	if err := a.Database.CheckpointWAL("FULL"); err != nil {
 		a.Logger.Warn("failed to checkpoint WAL after migration", slog.Any("error", err))
 	}
 
 	return nil
 }
 
+// AsyncJob submits a task to the registered async handler and returns its ID.
+// Returns ErrAsyncQueueFull when the async queue is at capacity.
+func (a *App) AsyncJob(name string, payload any, opts ...AsyncRunOption) (string, error) {
+	if a.Async == nil {
+		return "", fmt.Errorf("cartridge: async is not enabled (use WithAsync)")
+	}
+	return a.Async.Run(name, payload, opts...)
+}
+
+// AsyncAfter submits a task that runs once delay has passed, e.g. a reminder
+// a day after signup. With WithDurableAsync the task survives restarts.
+//
+//	app.AsyncAfter(24*time.Hour, "send_reminder", user.ID)
+func (a *App) AsyncAfter(delay time.Duration, name string, payload any, opts ...AsyncRunOption) (string, error) {
+	return a.AsyncJob(name, payload, append(opts, AsyncDelay(delay))...)
+}
+
+// AsyncAt submits a task that runs once at t (see AsyncAfter).
+func (a *App) AsyncAt(t time.Time, name string, payload any, opts ...AsyncRunOption) (string, error) {
+	return a.AsyncJob(name, payload, append(opts, AsyncRunAt(t))...)
+}
+
+// AsyncStatus returns the current state of an async task.
+func (a *App) AsyncStatus(id string) (*AsyncTask, error) {
+	if a.Async == nil {
+		return nil, fmt.Errorf("cartridge: async is not enabled (use WithAsync)")
+	}
+	return a.Async.Get(id)
+}
+
+// AsyncRetry re-queues a failed or canceled async task.
+func (a *App) AsyncRetry(id string) error {
+	if a.Async == nil {
+		return fmt.Errorf("cartridge: async is not enabled (use WithAsync)")
+	}
+	return a.Async.Retry(id)
+}
+
+// CronStatus returns the last run, last error and next scheduled run of each cron job.
+func (a *App) CronStatus() []CronJobStatus {
+	if a.Cron == nil {
+		return nil
+	}
+	return a.Cron.Status()
+}
+
+// RunCronJobNow runs a cron job immediately, outside its schedule and even
+// while it is paused. It returns once the run has started.
+func (a *App) RunCronJobNow(id string) error {
+	if a.Cron == nil {
+		return fmt.Errorf("cartridge: cron is not enabled (use WithCronJob)")
+	}
+	return a.Cron.Trigger(id)
+}
+
+// PauseCronJob stops a cron job's scheduled runs until ResumeCronJob.
+// CronStatus reports it as paused.
+func (a *App) PauseCronJob(id string) error {
+	if a.Cron == nil {
+		return fmt.Errorf("cartridge: cron is not enabled (use WithCronJob)")
+	}
+	return a.Cron.Pause(id)
+}
+
+// ResumeCronJob restarts the scheduled runs of a paused cron job.
+func (a *App) ResumeCronJob(id string) error {
+	if a.Cron == nil {
+		return fmt.Errorf("cartridge: cron is not enabled (use WithCronJob)")
+	}
+	return a.Cron.Resume(id)
+}
+
+// CronHistory returns up to limit recent runs of a cron job, newest first.
+func (a *App) CronHistory(id string, limit int) ([]CronRun, error) {
+	if a.Cron == nil {
+		return nil, fmt.Errorf("cartridge: cron is not enabled (use WithCronJob)")
+	}
+	return a.Cron.History(id, limit)
+}
+
 // GetDB returns the database connection.
 func (a *App) GetDB() (*gorm.DB, error) {
-	return a.DBManager.Connect()
+	return a.Database.Connect()
 }
 
 // AppOption configures the application.
@@ -62,14 +182,57 @@ type jobGroup struct {
 
 type appConfig struct {
 	cfg           *config.Config
+	required      []string // config keys checked by NewSSRApp
+	reloadConfig  bool
+	reloadEvery   time.Duration // periodic config reload, for secrets rotation
+	secrets       config.SecretsProvider
 	templatesFS   fs.FS
 	staticFS      fs.FS
 	templateFuncs template.FuncMap
+	viewEngine    ViewEngine // replaces the html/template engine
 	errorHandler  fiber.ErrorHandler
+	errorFormat   ErrorFormat
+	errorPages    ErrorPages
 	init          func(*App)
 	routes        func(*Server)
 	jobGroups     []jobGroup
 	sessionPath   string // login path for session middleware
+	sessionStore  SessionStore
+	dbSessions    bool
+	dbCache       bool
+	cacheStore    cache.Store
+	timezone      TimezoneConfig
+	jwt           *JWTConfig
+	cors          *cartridgemiddleware.CORSConfig
+	csrf          *cartridgemiddleware.CSRFConfig
+	csp           *cartridgemiddleware.CSP
+	headerPresets map[string]string
+	sqlMigrations *sqlMigrationsOption
+	reportOnly    func(Config) bool
+	secReports    *SecurityReportsConfig
+	capture       *cartridgemiddleware.CaptureConfig
+	serverOpts    []func(*ServerConfig)
+	corsOrigins   []string
+	asyncHandlers map[string]AsyncHandler
+	asyncDurable  bool
+	asyncRequests bool
+	asyncWorkers  int
+	asyncQueue    int
+	assetsErr     error // deferred EmbedAssets error, reported by NewSSRApp
+	pwa           *PWAConfig
+	cronJobs      []CronJob
+	cronErr       error // invalid WithCronJobIn time zone, reported by NewSSRApp
+	jobsAPIToken  string
+	lifecycle     LifecycleConfig
+	tracing       string // OTLP endpoint; empty disables tracing
+	workers       []BackgroundWorker
+	seeders       []Seeder
+	walCheckpoint *WALCheckpointConfig
+	maintenance   *MaintenanceConfig
+	audit         *AuditConfig
+	apiTokens     *APITokensConfig
+	notifier      *NotifierConfig
+	dbKey         func() (string, error) // SQLCipher key provider; nil leaves the database unencrypted
 }
 
 // WithConfig provides a pre-loaded config instead of loading one.
@@ -79,6 +242,25 @@ func WithConfig(cfg *config.Config) AppOption {
 	}
 }
 
+// WithRequiredConfig makes NewSSRApp fail unless every key is set, in the
+// config file or the environment (see config.Config.Get):
+//
+//	cartridge.WithRequiredConfig("stripe.api_key", "mail.from")
+func WithRequiredConfig(keys ...string) AppOption {
+	return func(c *appConfig) {
+		c.required = append(c.required, keys...)
+	}
+}
+
+// WithConfigReload reloads the config when its file changes or the process
+// receives SIGHUP. A changed log level applies right away; register
+// app.OnConfigChange callbacks for anything else.
+func WithConfigReload() AppOption {
+	return func(c *appConfig) {
+		c.reloadConfig = true
+	}
+}
+
 // WithAssets sets embedded templates and static files for production.
 func WithAssets(templates, static fs.FS) AppOption {
 	return func(c *appConfig) {
@@ -87,6 +269,31 @@ func WithAssets(templates, static fs.FS) AppOption {
 	}
 }
 
+// WithEmbeddedAssets resolves and validates embedded templates and static files
+// using EmbedAssets. Conventional directories (web/templates, resources/views,
+// public, ...) are detected automatically; NewSSRApp fails with a descriptive
+// error if the embedded filesystems are empty or misconfigured.
+func WithEmbeddedAssets(templates, static fs.FS, opts ...EmbedOptions) AppOption {
+	return func(c *appConfig) {
+		assets, err := EmbedAssets(templates, static, opts...)
+		if err != nil {
+			c.assetsErr = err
+			return
+		}
+		c.templatesFS = assets.Templates
+		c.staticFS = assets.Static
+	}
+}
+
+// WithPWA serves favicons, the web app manifest and an optional service worker
+// from cfg.FS with long-lived cache headers, and adds a "pwaTags" template
+// function that emits the matching <link> tags.
+func WithPWA(cfg PWAConfig) AppOption {
+	return func(c *appConfig) {
+		c.pwa = &cfg
+	}
+}
+
 // WithTemplateFuncs adds custom template functions.
 func WithTemplateFuncs(funcs template.FuncMap) AppOption {
 	return func(c *appConfig) {
@@ -94,6 +301,18 @@ func WithTemplateFuncs(funcs template.FuncMap) AppOption {
 	}
 }
 
+// WithViewEngine renders views with engine instead of the html/template
+// engine reading web/templates, e.g. one of Fiber's jet or pug engines. The
+// template functions (see WithTemplateFuncs) are added when the engine
+// accepts them, and in development it reloads its templates on every render
+// when it supports that. templ components need no engine: see
+// Context.RenderComponent.
+func WithViewEngine(engine ViewEngine) AppOption {
+	return func(c *appConfig) {
+		c.viewEngine = engine
+	}
+}
+
 // WithErrorHandler sets a custom error handler.
 func WithErrorHandler(handler fiber.ErrorHandler) AppOption {
 	return func(c *appConfig) {
@@ -101,6 +320,218 @@ func WithErrorHandler(handler fiber.ErrorHandler) AppOption {
 	}
 }
 
+// WithErrorPages renders HTML error responses from templates, for the
+// default error handler and ctx.Fail. Without pages, DefaultErrorPages
+// renders errors/403, errors/404 and errors/500 (for every 5xx):
+//
+//	cartridge.WithErrorPages()
+//	cartridge.WithErrorPages(cartridge.ErrorPages{404: "errors/missing", 500: "errors/oops"})
+//
+// API requests still get JSON.
+func WithErrorPages(pages ...ErrorPages) AppOption {
+	return func(c *appConfig) {
+		c.errorPages = DefaultErrorPages
+		if len(pages) > 0 {
+			c.errorPages = pages[0]
+		}
+	}
+}
+
+// WithErrorFormat selects the JSON error response shape used by the default
+// error handler and ctx.Fail/BadRequest/NotFound. Default: ErrorFormatSimple.
+func WithErrorFormat(format ErrorFormat) AppOption {
+	return func(c *appConfig) {
+		c.errorFormat = format
+	}
+}
+
+// WithCORS sets the CORS policy for routes with EnableCORS. Origins may be
+// exact or wildcard subdomains ("https://*.example.com"). Production allows
+// only these origins; development and test also allow localhost on any port.
+func WithCORS(origins ...string) AppOption {
+	return func(c *appConfig) {
+		c.corsOrigins = origins
+	}
+}
+
+// WithCORSConfig sets an explicit CORS policy for routes with EnableCORS,
+// e.g. with an AllowOriginFunc that checks tenant domains.
+func WithCORSConfig(cors cartridgemiddleware.CORSConfig) AppOption {
+	return func(c *appConfig) {
+		c.cors = &cors
+	}
+}
+
+// WithCSRF enables CSRF protection on every route (see ServerConfig.CSRF).
+// Tokens are signed with a key derived from the session secret and the
+// cookie is Secure in production. Forms send the token in the _csrf field:
+//
+//	<input type="hidden" name="_csrf" value="{{ .Helpers.CSRFToken }}">
+func WithCSRF(csrf ...cartridgemiddleware.CSRFConfig) AppOption {
+	return func(c *appConfig) {
+		cfg := cartridgemiddleware.DefaultCSRFConfig()
+		if len(csrf) > 0 {
+			cfg = csrf[0]
+		}
+		c.csrf = &cfg
+	}
+}
+
+// WithCSP sends a Content-Security-Policy built from policy, with a fresh
+// nonce per request for inline scripts (see middleware.CSP):
+//
+//	cartridge.WithCSP(middleware.NewCSP().AllowScripts("self").ReportURI("/csp-reports"))
+//	<script nonce="{{ cspNonce . }}">...</script>
+func WithCSP(policy *cartridgemiddleware.CSP) AppOption {
+	return func(c *appConfig) {
+		c.csp = policy
+	}
+}
+
+// WithHeaderPreset sends the named security header preset for requests
+// under prefix, e.g. a route group serving an API or embeddable widgets
+// (see ServerConfig.HeaderPresets):
+//
+//	cartridge.WithHeaderPreset("/api", middleware.PresetAPIOnly)
+//	cartridge.WithHeaderPreset("/embed", middleware.PresetRelaxedEmbed)
+func WithHeaderPreset(prefix, preset string) AppOption {
+	return func(c *appConfig) {
+		if c.headerPresets == nil {
+			c.headerPresets = make(map[string]string)
+		}
+		c.headerPresets[prefix] = preset
+	}
+}
+
+// WithSecurityReportOnly sends the Content-Security-Policy and
+// Permissions-Policy as report-only in the given environments, or in all of
+// them when none are given, so stricter headers can be trialled without
+// breaking pages:
+//
+//	cartridge.WithSecurityReportOnly(config.Development, config.Test)
+func WithSecurityReportOnly(environments ...string) AppOption {
+	return func(c *appConfig) {
+		c.reportOnly = reportOnlyIn(environments)
+	}
+}
+
+// WithSecurityReports collects CSP and Permissions-Policy violation reports
+// at DefaultSecurityReportsPath, logging each one unless cfg sets OnReport.
+func WithSecurityReports(cfg ...SecurityReportsConfig) AppOption {
+	return func(c *appConfig) {
+		reports := SecurityReportsConfig{}
+		if len(cfg) > 0 {
+			reports = cfg[0]
+		}
+		c.secReports = &reports
+	}
+}
+
+// WithRequestCapture records requests sent with an X-Capture header as
+// JSON fixtures in testdata/captures, for replay with testsupport's
+// TestServer.Replay. The header value names the fixture:
+//
+//	curl -H "X-Capture: checkout-empty-cart" -X POST localhost:8080/checkout
+//
+// Capture only runs in development; the option is ignored elsewhere.
+func WithRequestCapture(capture ...cartridgemiddleware.CaptureConfig) AppOption {
+	return func(c *appConfig) {
+		cfg := cartridgemiddleware.DefaultCaptureConfig()
+		if len(capture) > 0 {
+			cfg = capture[0]
+		}
+		c.capture = &cfg
+	}
+}
+
+// WithServerConfig adjusts the server configuration before the server is
+// created, e.g. to tune header size or connection limits:
+//
+//	cartridge.WithServerConfig(func(s *cartridge.ServerConfig) {
+//	    s.MaxHeaderSize = 16 * 1024
+//	    s.MaxRequestsPerConn = 1000
+//	})
+func WithServerConfig(fn func(*ServerConfig)) AppOption {
+	return func(c *appConfig) {
+		c.serverOpts = append(c.serverOpts, fn)
+	}
+}
+
+// WithUnixSocket serves on a Unix domain socket at path instead of the
+// configured port, for a reverse proxy on the same host. perms are the
+// socket file's permissions, e.g. 0660 so the proxy's group can connect
+// (0660 when zero):
+//
+//	cartridge.WithUnixSocket("/run/myapp/http.sock", 0o660)
+//
+// Under systemd socket activation the passed socket is served instead.
+func WithUnixSocket(path string, perms os.FileMode) AppOption {
+	return WithServerConfig(func(s *ServerConfig) {
+		s.UnixSocket = path
+		s.UnixSocketPerms = perms
+	})
+}
+
+// WithTracing exports OpenTelemetry traces to an OTLP/HTTP collector, e.g.
+// "localhost:4318" for a local agent or "https://otel.example.com" (see
+// TracingConfig). Each request gets a server span that continues the caller's
+// traceparent; queries, async tasks and cron runs become child spans.
+func WithTracing(endpoint string) AppOption {
+	return func(c *appConfig) {
+		c.tracing = endpoint
+	}
+}
+
+// WithCacheProfiles adds Cache-Control profiles for static assets and
+// ctx.ApplyCacheProfile. A profile named like a default ("immutable", "html",
+// "images") replaces it; others are matched after the defaults.
+func WithCacheProfiles(profiles ...CacheProfile) AppOption {
+	return WithServerConfig(func(s *ServerConfig) {
+		s.CacheProfiles = mergeCacheProfiles(s.CacheProfiles, profiles)
+	})
+}
+
+// WithUploadStorage sets the backend for ctx.SaveUpload.
+func WithUploadStorage(storage UploadStorage) AppOption {
+	return WithServerConfig(func(s *ServerConfig) {
+		s.UploadStorage = storage
+	})
+}
+
+// WithFeatures sets the feature flag resolver for RouteConfig.Feature and
+// ctx.Feature. Without it every gated route answers 404.
+func WithFeatures(resolver FeatureResolver) AppOption {
+	return WithServerConfig(func(s *ServerConfig) {
+		s.Features = resolver
+	})
+}
+
+// WithPageMeta sets site-wide defaults for page metadata (see PageMetadata):
+//
+//	cartridge.WithPageMeta(cartridge.PageMetadata{
+//	    SiteName: "Acme",
+//	    SiteURL:  "https://acme.com",
+//	    Image:    "/assets/og-default.png",
+//	})
+func WithPageMeta(meta PageMetadata) AppOption {
+	return WithServerConfig(func(s *ServerConfig) {
+		s.PageMeta = &meta
+	})
+}
+
+// WithTenancy resolves a tenant for every request (see TenancyConfig):
+//
+//	cartridge.WithTenancy(cartridge.TenancyConfig{
+//	    Resolvers: []cartridge.TenantResolver{cartridge.TenantFromSubdomain("example.com")},
+//	    Required:  true,
+//	    Database:  cartridge.NewSQLiteTenantFiles("storage/tenants"),
+//	})
+func WithTenancy(cfg TenancyConfig) AppOption {
+	return WithServerConfig(func(s *ServerConfig) {
+		s.Tenancy = &cfg
+	})
+}
+
 // WithInit sets initialization callback (e.g., auth setup).
 func WithInit(fn func(*App)) AppOption {
 	return func(c *appConfig) {
@@ -134,6 +565,337 @@ func WithSession(loginPath string) AppOption {
 	}
 }
 
+// WithSessionStore sets the backend for ctx.Session(). Stores implementing
+// SessionCleaner have expired sessions purged by the cron manager.
+// Default: encrypted cookies (NewCookieSessionStore).
+func WithSessionStore(store SessionStore) AppOption {
+	return func(c *appConfig) {
+		c.sessionStore = store
+	}
+}
+
+// WithDatabaseSessions keeps ctx.Session() data in the application database
+// (cartridge_sessions table) instead of cookies.
+func WithDatabaseSessions() AppOption {
+	return func(c *appConfig) {
+		c.dbSessions = true
+	}
+}
+
+// WithNotifications enables ctx.Notify with the given channels. Which
+// channels each notification type uses is set in cfg, not in handlers:
+//
+//	cartridge.WithNotifications(cartridge.NotifierConfig{
+//	    Channels: map[string]cartridge.NotificationChannel{
+//	        cartridge.MailChannel:  cartridge.NewMailChannel(mailer),
+//	        cartridge.SlackChannel: cartridge.NewSlackChannel(cartridge.SlackChannelConfig{WebhookURL: url}),
+//	    },
+//	    Via: map[string][]string{"password_reset": {cartridge.MailChannel}},
+//	})
+func WithNotifications(cfg NotifierConfig) AppOption {
+	return func(c *appConfig) {
+		c.notifier = &cfg
+	}
+}
+
+// WithAuditLog enables ctx.Audit. Records go to the cartridge_audit_log
+// table unless AuditConfig.Store is set, and are read back through
+// App.Audit or Server.AuditStore:
+//
+//	cartridge.WithAuditLog(cartridge.AuditConfig{Retention: 365 * 24 * time.Hour})
+func WithAuditLog(cfg ...AuditConfig) AppOption {
+	return func(c *appConfig) {
+		audit := AuditConfig{}
+		if len(cfg) > 0 {
+			audit = cfg[0]
+		}
+		c.audit = &audit
+	}
+}
+
+// WithDatabaseEncryption encrypts the SQLite database at rest with
+// SQLCipher, using the key returned by key each time the database opens.
+// The binary must be linked against SQLCipher; NewSSRApp's database fails to
+// open otherwise rather than storing plain text. Use sqlite.EncryptFile to
+// convert an existing database and Manager.Rekey to rotate the key.
+//
+//	cartridge.WithDatabaseEncryption(func() (string, error) {
+//	    return os.Getenv("DATABASE_KEY"), nil
+//	})
+func WithDatabaseEncryption(key func() (string, error)) AppOption {
+	return func(c *appConfig) {
+		c.dbKey = key
+	}
+}
+
+// WithDatabaseResponseCache keeps RouteConfig.Cache responses in the
+// application database (cache_entries table, see cache.DatabaseStore)
+// instead of memory, so they survive restarts and are shared between instances.
+func WithDatabaseResponseCache() AppOption {
+	return func(c *appConfig) {
+		c.dbCache = true
+	}
+}
+
+// WithCacheStore sets the backend for ctx.Cache() and JobContext.Cache(),
+// e.g. cache.NewRedisStore to share entries between instances.
+// Default: in memory (cache.NewLRUStore)
+func WithCacheStore(store cache.Store) AppOption {
+	return func(c *appConfig) {
+		c.cacheStore = store
+	}
+}
+
+// WithTimezone customizes how the user's time zone is resolved for
+// ctx.Location() and the localtime template helper, e.g. from their profile.
+// By default the "tz" cookie and X-Timezone header are used, falling back to UTC.
+func WithTimezone(tz TimezoneConfig) AppOption {
+	return func(c *appConfig) {
+		c.timezone = tz
+	}
+}
+
+// WithJWT enables token authentication for API routes. Handlers sign tokens
+// with ctx.SignJWT; routes opt in to verification with server.JWT().Middleware().
+func WithJWT(jwt JWTConfig) AppOption {
+	return func(c *appConfig) {
+		c.jwt = &jwt
+	}
+}
+
+// WithAPITokens stores personal API tokens in the cartridge_api_tokens
+// table. Mount the management endpoints with server.APITokenRoutes and
+// protect API routes with server.APITokens().Middleware():
+//
+//	cartridge.WithAPITokens(cartridge.APITokensConfig{Scopes: []string{"orders:read", "orders:write"}})
+func WithAPITokens(cfg ...APITokensConfig) AppOption {
+	return func(c *appConfig) {
+		tokens := APITokensConfig{}
+		if len(cfg) > 0 {
+			tokens = cfg[0]
+		}
+		c.apiTokens = &tokens
+	}
+}
+
+// WithAsync registers a handler for background tasks submitted via App.AsyncJob.
+// Call multiple times to register several task types.
+func WithAsync(name string, handler AsyncHandler) AppOption {
+	return func(c *appConfig) {
+		if c.asyncHandlers == nil {
+			c.asyncHandlers = make(map[string]AsyncHandler)
+		}
+		c.asyncHandlers[name] = handler
+	}
+}
+
+// WithAsyncRequests enables ctx.Promote and ctx.PromoteAfter, which hand slow
+// requests to the async worker pool and answer 202 with a status URL under
+// AsyncStatusPath. Implied by WithAsync.
+func WithAsyncRequests() AppOption {
+	return func(c *appConfig) {
+		c.asyncRequests = true
+	}
+}
+
+// WithDurableAsync persists async tasks to the application database so queued
+// work survives restarts. Unfinished tasks are resumed when the app starts.
+func WithDurableAsync() AppOption {
+	return func(c *appConfig) {
+		c.asyncDurable = true
+	}
+}
+
+// WithAsyncWorkers sets how many async tasks run concurrently and, optionally,
+// how many may wait in the queue before AsyncJob returns ErrAsyncQueueFull.
+// Defaults: 4 workers, 1000 queued tasks.
+func WithAsyncWorkers(workers int, queueSize ...int) AppOption {
+	return func(c *appConfig) {
+		c.asyncWorkers = workers
+		if len(queueSize) > 0 {
+			c.asyncQueue = queueSize[0]
+		}
+	}
+}
+
+// WithCronJob schedules a handler using a cron expression (e.g. "*/5 * * * *" or "@daily").
+// Each run is recorded in the cartridge_cron_runs table and exposed via App.CronHistory.
+//
+//	cartridge.WithCronJob("cleanup", "0 3 * * *", cleanup, cartridge.CronSkipIfRunning())
+func WithCronJob(id, schedule string, handler CronHandler, opts ...CronJobOption) AppOption {
+	return func(c *appConfig) {
+		job := CronJob{ID: id, Schedule: schedule, Handler: handler}
+		for _, opt := range opts {
+			opt(&job)
+		}
+		c.cronJobs = append(c.cronJobs, job)
+	}
+}
+
+// WithCronJobIn is WithCronJob with the schedule evaluated in the named IANA
+// time zone instead of the server's local time:
+//
+//	cartridge.WithCronJobIn("ny-open", "30 9 * * 1-5", "America/New_York", notifyOpen)
+//
+// Daylight saving changes don't skip or repeat fixed-time jobs (see
+// ParseCronSchedule). NewSSRApp fails if the zone is unknown.
+func WithCronJobIn(id, schedule, timezone string, handler CronHandler, opts ...CronJobOption) AppOption {
+	return func(c *appConfig) {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			if c.cronErr == nil {
+				c.cronErr = fmt.Errorf("cartridge: cron job %q: time zone: %w", id, err)
+			}
+			return
+		}
+		job := CronJob{ID: id, Schedule: schedule, Handler: handler, Location: loc}
+		for _, opt := range opts {
+			opt(&job)
+		}
+		c.cronJobs = append(c.cronJobs, job)
+	}
+}
+
+// WithJobsAPI mounts the jobs management API at JobsAPIPath so dashboards and
+// CLIs can list, cancel and requeue async tasks and inspect or trigger cron
+// jobs. Callers authenticate with "Authorization: Bearer <token>".
+//
+//	cartridge.WithJobsAPI(os.Getenv("JOBS_API_TOKEN"))
+func WithJobsAPI(token string) AppOption {
+	return func(c *appConfig) {
+		c.jobsAPIToken = token
+	}
+}
+
+// WithMigrator runs the migrator in the migrate phase, before warmup, workers,
+// cron and the HTTP listener start.
+func WithMigrator(migrator Migrator) AppOption {
+	return func(c *appConfig) {
+		c.lifecycle.Migrator = migrator
+	}
+}
+
+// WithSchemaCheck compares the database with cfg.Models after migrations
+// at every startup and logs missing tables, columns and indexes (see
+// Application.CheckSchema).
+//
+//	cartridge.WithSchemaCheck(cartridge.SchemaCheckConfig{
+//	    Models:           []any{&User{}, &Post{}},
+//	    FailInProduction: true,
+//	})
+func WithSchemaCheck(cfg SchemaCheckConfig) AppOption {
+	return func(c *appConfig) {
+		c.lifecycle.SchemaCheck = &cfg
+	}
+}
+
+// WithSQLMigrations runs the SQL migrations in migrations (see
+// LoadSQLMigrations) in the migrate phase, replacing WithMigrator. In
+// development they are read from ./migrations on disk instead, when it
+// exists, and files added while the app runs are applied as they change.
+//
+//	//go:embed migrations/*.sql
+//	var migrationsFS embed.FS
+//
+//	cartridge.WithSQLMigrations(migrationsFS, cartridge.SQLMigratorConfig{AppVersion: version})
+func WithSQLMigrations(migrations fs.FS, cfg ...SQLMigratorConfig) AppOption {
+	return func(c *appConfig) {
+		opt := &sqlMigrationsOption{fsys: migrations}
+		if len(cfg) > 0 {
+			opt.cfg = cfg[0]
+		}
+		c.sqlMigrations = opt
+	}
+}
+
+// WithSeeder adds a seeder run by the "seed" command (see
+// Application.RunCommand). Call multiple times to register several; they
+// run in order.
+func WithSeeder(seeder Seeder) AppOption {
+	return func(c *appConfig) {
+		c.seeders = append(c.seeders, seeder)
+	}
+}
+
+// WithWarmup adds a function that runs in the warmup phase, after migrations.
+// Call multiple times to register several warmup steps; they run in order.
+func WithWarmup(fn func(ctx context.Context) error) AppOption {
+	return func(c *appConfig) {
+		c.lifecycle.Warmup = append(c.lifecycle.Warmup, fn)
+	}
+}
+
+// WithWorker adds a custom background worker to the application.
+// Use this for workers that implement BackgroundWorker directly (Start/Stop).
+func WithWorker(worker BackgroundWorker) AppOption {
+	return func(c *appConfig) {
+		c.workers = append(c.workers, worker)
+	}
+}
+
+// WithWALCheckpoints checkpoints the SQLite WAL in the background, skipping
+// runs under heavy write load (see WALCheckpointConfig). Ignored for other
+// database drivers.
+func WithWALCheckpoints(cfg ...WALCheckpointConfig) AppOption {
+	return func(c *appConfig) {
+		checkpoint := DefaultWALCheckpointConfig()
+		if len(cfg) > 0 {
+			checkpoint = cfg[0]
+		}
+		c.walCheckpoint = &checkpoint
+	}
+}
+
+// WithDatabaseMaintenance schedules SQLite maintenance as cron jobs:
+// incremental vacuum, PRAGMA optimize and integrity checks (see
+// MaintenanceConfig). Ignored for other database drivers.
+//
+//	maintenance := cartridge.DefaultMaintenanceConfig()
+//	maintenance.OnIntegrityFailure = func(problems []string) { alerts.Page("database corrupt", problems) }
+//	cartridge.WithDatabaseMaintenance(maintenance)
+func WithDatabaseMaintenance(cfg ...MaintenanceConfig) AppOption {
+	return func(c *appConfig) {
+		maintenance := DefaultMaintenanceConfig()
+		if len(cfg) > 0 {
+			maintenance = cfg[0]
+		}
+		c.maintenance = &maintenance
+	}
+}
+
+// WithReadinessCheck adds a check that must pass before the app is ready.
+// Checks are retried until they pass or the readiness timeout (30s) expires.
+func WithReadinessCheck(name string, check func(ctx context.Context) error) AppOption {
+	return func(c *appConfig) {
+		c.lifecycle.ReadinessChecks = append(c.lifecycle.ReadinessChecks, ReadinessCheck{Name: name, Check: check})
+	}
+}
+
+// WithWorkersAfterReady delays background workers, async tasks and cron jobs
+// until the server is listening and all readiness checks pass.
+func WithWorkersAfterReady() AppOption {
+	return func(c *appConfig) {
+		c.lifecycle.DelayWorkersUntilReady = true
+	}
+}
+
+// WithDrainTimeout bounds how long shutdown waits for running async tasks and
+// cron jobs to finish. Past it they are canceled: durable tasks resume on the
+// next start, others are recorded as canceled. Default: the shutdown timeout.
+func WithDrainTimeout(timeout time.Duration) AppOption {
+	return func(c *appConfig) {
+		c.lifecycle.DrainTimeout = timeout
+	}
+}
+
+// WithLifecycle replaces the lifecycle configuration (phase order, readiness timeout,
+// phase callbacks). Options such as WithMigrator applied afterwards still take effect.
+func WithLifecycle(lifecycle LifecycleConfig) AppOption {
+	return func(c *appConfig) {
+		c.lifecycle = lifecycle
+	}
+}
+
 // NewSSRApp creates a server-side rendered application with sensible defaults.
 //
 // Example:
@@ -150,6 +912,12 @@ func NewSSRApp(appName string, opts ...AppOption) (*App, error) {
 	for _, opt := range opts {
 		opt(cfg)
 	}
+	if cfg.assetsErr != nil {
+		return nil, cfg.assetsErr
+	}
+	if cfg.cronErr != nil {
+		return nil, cfg.cronErr
+	}
 
 	// Load config
 	var appCfg *config.Config
@@ -162,21 +930,58 @@ func NewSSRApp(appName string, opts ...AppOption) (*App, error) {
 			return nil, fmt.Errorf("load config: %w", err)
 		}
 	}
+	if cfg.secrets != nil {
+		if err := resolveSecrets(appCfg, cfg.secrets); err != nil {
+			return nil, err
+		}
+	}
+	if err := appCfg.Require(cfg.required...); err != nil {
+		return nil, err
+	}
 
-	// Create logger
-	logger := NewLogger(appCfg, nil)
+	// Create logger, keeping a tail for the debug UI
+	logLevel := new(slog.LevelVar)
+	logger := slog.New(NewLogTail(newLogger(appCfg, nil, logLevel).Handler(), DefaultLogTailSize))
 	slog.SetDefault(logger)
 
-	// Create database manager
-	dbManager := sqlite.NewManager(sqlite.Config{
-		Path:         appCfg.DatabaseDSN(),
-		MaxOpenConns: appCfg.GetMaxOpenConns(),
-		MaxIdleConns: appCfg.GetMaxIdleConns(),
-		Logger:       logger,
-	})
+	// Create database manager for the configured driver
+	dbManager, err := newDatabaseManager(appCfg, logger, cfg.dbKey)
+	if err != nil {
+		return nil, err
+	}
+	sqliteManager, _ := dbManager.(*sqlite.Manager)
+
+	var migrationWatcher BackgroundWorker
+	if cfg.sqlMigrations != nil {
+		var migrator *SQLMigrator
+		migrator, migrationWatcher, err = cfg.sqlMigrations.migrator(appCfg.IsDevelopment(), logger)
+		if err != nil {
+			return nil, err
+		}
+		cfg.lifecycle.Migrator = migrator
+	}
+
+	// Discover PWA assets and expose their link tags to templates
+	var pwaAssets *PWAAssets
+	if cfg.pwa != nil {
+		pwaAssets, err = NewPWAAssets(*cfg.pwa)
+		if err != nil {
+			return nil, err
+		}
+		funcs := template.FuncMap{"pwaTags": pwaAssets.LinkTags}
+		for name, fn := range cfg.templateFuncs {
+			funcs[name] = fn
+		}
+		cfg.templateFuncs = funcs
+	}
 
 	// Create views engine
-	viewsEngine := createViewsEngine(appCfg, cfg.templatesFS, cfg.templateFuncs)
+	var viewsEngine ViewEngine
+	if cfg.viewEngine != nil {
+		viewsEngine = configureViewEngine(appCfg, cfg.viewEngine, cfg.templateFuncs)
+	} else {
+		viewsEngine = createViewsEngine(appCfg, cfg.templatesFS, cfg.templateFuncs)
+	}
 
 	// Build server config
 	serverCfg := DefaultServerConfig()
@@ -184,15 +989,68 @@ func NewSSRApp(appName string, opts ...AppOption) (*App, error) {
 	serverCfg.Logger = logger
 	serverCfg.DBManager = dbManager
 	serverCfg.ViewsEngine = viewsEngine
+	serverCfg.Cache = NewCache(cfg.cacheStore)
 	// In development mode, serve static from disk for hot-reload
 	// In production, use embedded filesystem
 	if !appCfg.IsDevelopment() && cfg.staticFS != nil {
 		serverCfg.StaticFS = cfg.staticFS
 	}
+	serverCfg.ErrorFormat = cfg.errorFormat
+	serverCfg.ErrorPages = cfg.errorPages
+	serverCfg.CORS = cfg.cors
+	if serverCfg.CORS == nil && cfg.corsOrigins != nil {
+		serverCfg.CORS = corsPreset(appCfg, cfg.corsOrigins)
+	}
+	if cfg.csrf != nil {
+		serverCfg.CSRF = csrfPreset(appCfg, appCfg.GetSessionSecret(), *cfg.csrf)
+	}
+	if cfg.csp != nil {
+		serverCfg.SecurityHeaders = cspPreset(cfg.csp)
+	}
+	serverCfg.HeaderPresets = cfg.headerPresets
+	serverCfg.SecurityReportOnly = cfg.reportOnly != nil && cfg.reportOnly(appCfg)
+	serverCfg.SecurityReports = cfg.secReports
+	if cfg.capture != nil && appCfg.IsDevelopment() {
+		serverCfg.RequestCapture = cfg.capture
+	}
+	var notifier *Notifier
+	if cfg.notifier != nil {
+		notifierCfg := *cfg.notifier
+		if notifierCfg.Logger == nil {
+			notifierCfg.Logger = logger
+		}
+		if notifier, err = NewNotifier(notifierCfg); err != nil {
+			return nil, err
+		}
+		serverCfg.Notifier = notifier
+	}
 	if cfg.errorHandler != nil {
 		serverCfg.ErrorHandler = cfg.errorHandler
 	} else {
-		serverCfg.ErrorHandler = DefaultErrorHandler(logger, appCfg.IsDevelopment())
+		serverCfg.ErrorHandler = newErrorHandler(logger, appCfg.IsDevelopment(), cfg.errorFormat, cfg.errorPages)
+	}
+
+	// Install the tracer provider before anything can start spans
+	var tracing *Tracing
+	if cfg.tracing != "" {
+		tracing, err = SetupTracing(TracingConfig{Endpoint: cfg.tracing, ServiceName: appName})
+		if err != nil {
+			return nil, err
+		}
+		serverCfg.EnableTracing = true
+	}
+	if cfg.dbCache {
+		db, err := dbManager.Connect()
+		if err != nil {
+			return nil, fmt.Errorf("connect database: %w", err)
+		}
+		serverCfg.ResponseCacheStore, err = cache.NewDatabaseStore(db)
+		if err != nil {
+			return nil, fmt.Errorf("create response cache: %w", err)
+		}
+	}
+	for _, fn := range cfg.serverOpts {
+		fn(serverCfg)
 	}
 
 	// Create server
@@ -201,6 +1059,10 @@ func NewSSRApp(appName string, opts ...AppOption) (*App, error) {
 		return nil, fmt.Errorf("create server: %w", err)
 	}
 
+	if pwaAssets != nil {
+		pwaAssets.Mount(server.App())
+	}
+
 	// Create session manager if enabled and attach to server
 	var sessionMgr *SessionManager
 	if cfg.sessionPath != "" {
@@ -214,6 +1076,108 @@ func NewSSRApp(appName string, opts ...AppOption) (*App, error) {
 		server.SetSession(sessionMgr)
 	}
 
+	// Enable ctx.Session() before routes are mounted
+	sessionStore := cfg.sessionStore
+	if cfg.dbSessions {
+		sessionStore, err = NewDatabaseSessionStore(dbManager)
+		if err != nil {
+			return nil, err
+		}
+	}
+	sessions := NewSessions(SessionsConfig{
+		Store:      sessionStore,
+		Secret:     appCfg.GetSessionSecret(),
+		CookieName: appCfg.AppName + "_session_data",
+		TTL:        time.Duration(appCfg.GetSessionTimeout()) * time.Second,
+		Secure:     appCfg.IsProduction(),
+		Logger:     logger,
+	})
+	server.SetSessions(sessions)
+	if job, ok := sessions.CleanupJob(); ok {
+		cfg.cronJobs = append(cfg.cronJobs, job)
+	}
+	server.App().Use(TimezoneMiddleware(cfg.timezone))
+
+	var auditStore AuditStore
+	if cfg.audit != nil {
+		auditStore = cfg.audit.Store
+		if auditStore == nil {
+			auditStore, err = NewDatabaseAuditStore(dbManager)
+			if err != nil {
+				return nil, err
+			}
+		}
+		server.SetAuditStore(auditStore)
+		if cfg.audit.Retention > 0 {
+			cfg.cronJobs = append(cfg.cronJobs, AuditCleanup(auditStore, cfg.audit.Retention))
+		}
+	}
+
+	var jwtAuth *JWTAuth
+	if cfg.jwt != nil {
+		jwtAuth, err = NewJWTAuth(*cfg.jwt)
+		if err != nil {
+			return nil, err
+		}
+		server.SetJWT(jwtAuth)
+	}
+
+	var apiTokens *APITokens
+	if cfg.apiTokens != nil {
+		apiTokens, err = NewAPITokens(dbManager, *cfg.apiTokens)
+		if err != nil {
+			return nil, err
+		}
+		server.SetAPITokens(apiTokens)
+	}
+
+	// Create async manager if any handlers were registered or requests can be
+	// promoted, and mount its status endpoint before the routes
+	var asyncMgr *AsyncManager
+	if len(cfg.asyncHandlers) > 0 || cfg.asyncRequests {
+		asyncMgr = NewAsyncManager(AsyncConfig{
+			Logger:    logger,
+			DBManager: dbManager,
+			Durable:   cfg.asyncDurable,
+			Workers:   cfg.asyncWorkers,
+			QueueSize: cfg.asyncQueue,
+			Cache:     serverCfg.Cache,
+		})
+		for name, handler := range cfg.asyncHandlers {
+			asyncMgr.Register(name, handler)
+		}
+		server.SetAsync(asyncMgr)
+	}
+
+	if cfg.maintenance != nil && sqliteManager != nil {
+		cfg.cronJobs = append(cfg.cronJobs, MaintenanceJobs(server.GetLimiter(), *cfg.maintenance)...)
+	}
+
+	// Create cron manager if any jobs were scheduled
+	var cronMgr *CronManager
+	if len(cfg.cronJobs) > 0 {
+		cronMgr = NewCronManager(CronConfig{
+			Logger:    logger,
+			DBManager: dbManager,
+			Cache:     serverCfg.Cache,
+		})
+		for _, job := range cfg.cronJobs {
+			if err := cronMgr.Add(job); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if cfg.jobsAPIToken != "" {
+		if err := server.SetJobsAPI(JobsAPIConfig{
+			Token: cfg.jobsAPIToken,
+			Async: asyncMgr,
+			Cron:  cronMgr,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
 	// Mount routes (session is available via server.Session())
 	if cfg.routes != nil {
 		cfg.routes(server)
@@ -223,9 +1187,22 @@ func NewSSRApp(appName string, opts ...AppOption) (*App, error) {
 	app := &App{
 		Config:    appCfg,
 		Logger:    logger,
-		DBManager: dbManager,
+		DBManager: sqliteManager,
+		Database:  dbManager,
 		Server:    server,
 		Session:   sessionMgr,
+		Sessions:  sessions,
+		JWT:       jwtAuth,
+		APITokens: apiTokens,
+		Async:     asyncMgr,
+		Cron:      cronMgr,
+		Audit:     auditStore,
+		Notifier:  notifier,
+		logLevel:  logLevel,
+		secrets:   cfg.secrets,
+	}
+	if cfg.reloadConfig {
+		app.configReloader().interval = cfg.reloadEvery
 	}
 
 	// Run init callback
@@ -233,13 +1210,52 @@ func NewSSRApp(appName string, opts ...AppOption) (*App, error) {
 		cfg.init(app)
 	}
 
-	// Create job dispatchers for each job group
+	// Tracing goes first so it stops last and flushes spans from the others
 	var workers []BackgroundWorker
+	if tracing != nil {
+		workers = append(workers, tracing)
+	}
+
+	// Tenant databases close after everything that might still use them
+	if serverCfg.Tenancy != nil {
+		if w, ok := serverCfg.Tenancy.Database.(BackgroundWorker); ok {
+			workers = append(workers, w)
+		}
+	}
+
+	// Add custom workers
+	workers = append(workers, cfg.workers...)
+	workers = append(workers, app.pendingWorkers...)
+	app.pendingWorkers = nil
+
+	if migrationWatcher != nil {
+		workers = append(workers, migrationWatcher)
+	}
+
+	// Reload development templates as they are saved
+	if views, ok := viewsEngine.(*reloadingViews); ok {
+		workers = append(workers, newTemplateWatcher(views.reloader))
+	}
+
+	if cfg.walCheckpoint != nil && sqliteManager != nil {
+		app.WAL = NewWALCheckpointer(sqliteManager, server.GetLimiter(), logger, *cfg.walCheckpoint)
+		workers = append(workers, app.WAL)
+	}
+
+	// Create job dispatchers for each job group
 	for _, group := range cfg.jobGroups {
 		dispatcher := NewJobDispatcher(logger, dbManager, group.interval, group.processors...)
 		workers = append(workers, dispatcher)
 	}
 
+	if asyncMgr != nil {
+		workers = append(workers, asyncMgr)
+	}
+
+	if cronMgr != nil {
+		workers = append(workers, cronMgr)
+	}
+
 	// Create application
 	application, err := NewApplication(ApplicationOptions{
 		Config:            appCfg,
@@ -247,6 +1263,8 @@ func NewSSRApp(appName string, opts ...AppOption) (*App, error) {
 		DBManager:         dbManager,
 		Server:            server,
 		BackgroundWorkers: workers,
+		Lifecycle:         cfg.lifecycle,
+		Seeders:           cfg.seeders,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("create application: %w", err)
@@ -256,8 +1274,45 @@ func NewSSRApp(appName string, opts ...AppOption) (*App, error) {
 	return app, nil
 }
 
+// newDatabaseManager creates the database manager for the configured driver.
+// SQLite gets WAL pragmas and immediate transactions; PostgreSQL and MySQL use
+// the generic manager with pooled connections. key encrypts SQLite
+// databases and is rejected for other drivers.
+func newDatabaseManager(cfg *config.Config, logger *slog.Logger, key func() (string, error)) (DatabaseManager, error) {
+	driver := cfg.GetDatabaseDriver()
+	if key != nil && driver != config.DriverSQLite {
+		return nil, fmt.Errorf("cartridge: database encryption requires SQLite, not %s", driver)
+	}
+	switch driver {
+	case config.DriverSQLite:
+		return sqlite.NewManager(sqlite.Config{
+			Path:         cfg.DatabaseDSN(),
+			MaxOpenConns: cfg.GetMaxOpenConns(),
+			MaxIdleConns: cfg.GetMaxIdleConns(),
+			Logger:       logger,
+			KeyProvider:  key,
+		}), nil
+	case config.DriverPostgres, config.DriverMySQL:
+		dbCfg := database.DefaultConfig(cfg.DatabaseDSN())
+		dbCfg.MaxOpenConns = cfg.GetMaxOpenConns()
+		dbCfg.MaxIdleConns = cfg.GetMaxIdleConns()
+		dbCfg.ConnMaxLifetime = 30 * time.Minute
+		dbCfg.ReplicaDSNs = cfg.DatabaseReplicaDSNs()
+
+		var dbDriver database.Driver = postgres.NewDriver()
+		if driver == config.DriverMySQL {
+			dbDriver = mysql.NewDriver()
+		}
+		return database.NewManager(dbDriver, dbCfg, logger), nil
+	default:
+		return nil, fmt.Errorf("cartridge: unsupported database driver %q", driver)
+	}
+}
+
 // createViewsEngine creates the template engine with provided functions.
-func createViewsEngine(cfg *config.Config, templatesFS fs.FS, funcs template.FuncMap) *html.Engine {
+// In development templates are read from web/templates and re-parsed when
+// their content changes (see templateReloader and templateWatcher).
+func createViewsEngine(cfg *config.Config, templatesFS fs.FS, funcs template.FuncMap) fiber.Views {
 	var engine *html.Engine
 
 	if !cfg.IsDevelopment() && templatesFS != nil {
@@ -284,15 +1339,14 @@ func createViewsEngine(cfg *config.Config, templatesFS fs.FS, funcs template.Fun
 		return template.HTML(buf.String()), nil
 	})
 
-	// Add provided template functions
-	for name, fn := range funcs {
-		engine.AddFunc(name, fn)
-	}
+	// Add sanitize/markdown, localtime/timeago and money helpers, then the
+	// provided template functions, which may override them
+	engine.AddFuncMap(viewTemplateFuncs(funcs))
 
 	// Development mode settings
 	engine.Debug(cfg.IsDevelopment())
 	if cfg.IsDevelopment() {
-		engine.Reload(true)
+		return &reloadingViews{Engine: engine, reloader: newTemplateReloader(engine, "web/templates", ".html")}
 	}
 
 	return engine
@@ -0,0 +1,67 @@
+package cartridge
+
+import (
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// FeatureResolver reports whether a feature flag is on for the request,
+// e.g. by checking ctx.Principal() or the tenant against a flag service.
+type FeatureResolver func(ctx *Context, feature string) (bool, error)
+
+// featureLocalsKey caches resolved flags for the request.
+const featureLocalsKey = "cartridge_features"
+
+// StaticFeatures turns the named features on for everyone and leaves the
+// rest off. Useful for environment-driven flags:
+//
+//	cfg.Features = cartridge.StaticFeatures(strings.Split(os.Getenv("FEATURES"), ",")...)
+func StaticFeatures(enabled ...string) FeatureResolver {
+	return func(_ *Context, feature string) (bool, error) {
+		return slices.Contains(enabled, feature), nil
+	}
+}
+
+// Feature reports whether feature is on for this request. Features are off
+// when no ServerConfig.Features resolver is set. Results are cached for the
+// rest of the request.
+func (ctx *Context) Feature(feature string) (bool, error) {
+	flags, _ := ctx.Locals(featureLocalsKey).(map[string]bool)
+	if on, ok := flags[feature]; ok {
+		return on, nil
+	}
+	if ctx.features == nil {
+		return false, nil
+	}
+
+	on, err := ctx.features(ctx, feature)
+	if err != nil {
+		return false, err
+	}
+	if flags == nil {
+		flags = make(map[string]bool)
+		ctx.Locals(featureLocalsKey, flags)
+	}
+	flags[feature] = on
+	return on, nil
+}
+
+// requireFeature returns middleware for RouteConfig.Feature. Requests with
+// the feature off get ServerConfig.FeatureDeniedStatus: by default a plain
+// 404, so dark-launched routes look like they don't exist.
+func (s *Server) requireFeature(feature string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		on, err := s.context(c).Feature(feature)
+		if err != nil {
+			return err
+		}
+		if on {
+			return c.Next()
+		}
+		if s.cfg.FeatureDeniedStatus == fiber.StatusForbidden {
+			return ErrForbidden("feature not available")
+		}
+		return fiber.ErrNotFound
+	}
+}
@@ -0,0 +1,80 @@
+package cartridge
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestRouteFeature(t *testing.T) {
+	newServer := func(deniedStatus int) (*Server, *int) {
+		cfg := DefaultServerConfig()
+		cfg.EnableStaticAssets = false
+		cfg.EnableRequestLogger = false
+		cfg.EnableSecFetchSite = false
+		cfg.Config = &testConfig{}
+		cfg.Logger = testLogger()
+		cfg.DBManager = &testDBManager{}
+		cfg.ErrorHandler = DefaultErrorHandler(testLogger(), false)
+		cfg.FeatureDeniedStatus = deniedStatus
+
+		lookups := 0
+		cfg.Features = func(ctx *Context, feature string) (bool, error) {
+			lookups++
+			return feature == "beta-search" && ctx.Get("X-Tenant") == "acme", nil
+		}
+
+		srv, err := NewServer(cfg)
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		srv.Get("/search", func(ctx *Context) error {
+			// Already resolved by the route gate
+			if on, _ := ctx.Feature("beta-search"); !on {
+				return ErrInternal(nil)
+			}
+			return ctx.SendString("results")
+		}, &RouteConfig{Feature: "beta-search"})
+		return srv, &lookups
+	}
+
+	search := func(srv *Server, tenant string) int {
+		req := httptest.NewRequest("GET", "/search", nil)
+		req.Header.Set("X-Tenant", tenant)
+		resp, err := srv.App().Test(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		return resp.StatusCode
+	}
+
+	srv, lookups := newServer(0)
+	if status := search(srv, "acme"); status != fiber.StatusOK {
+		t.Errorf("expected 200 with the feature on, got %d", status)
+	}
+	if *lookups != 1 {
+		t.Errorf("expected one flag lookup per request, got %d", *lookups)
+	}
+	if status := search(srv, "globex"); status != fiber.StatusNotFound {
+		t.Errorf("expected 404 with the feature off, got %d", status)
+	}
+	if routes := srv.Routes(); routes[0].Feature != "beta-search" {
+		t.Errorf("expected feature in route info, got %+v", routes[0])
+	}
+
+	srv, _ = newServer(fiber.StatusForbidden)
+	if status := search(srv, "globex"); status != fiber.StatusForbidden {
+		t.Errorf("expected 403 with FeatureDeniedStatus, got %d", status)
+	}
+}
+
+func TestStaticFeatures(t *testing.T) {
+	features := StaticFeatures("new-checkout")
+	if on, _ := features(nil, "new-checkout"); !on {
+		t.Error("expected listed feature to be on")
+	}
+	if on, _ := features(nil, "beta-search"); on {
+		t.Error("expected unlisted feature to be off")
+	}
+}
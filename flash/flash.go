@@ -102,3 +102,63 @@ func GetFlash(c *fiber.Ctx) *FlashMessage {
 
 	return &flash
 }
+
+// ErrorsCookieName is the cookie carrying validation errors to the next request.
+const ErrorsCookieName = "fusionaly_errors"
+
+// SetErrors stores validation errors, keyed by field (or by error bag, then
+// field), for the next request. Like SetFlash, secure should be true in
+// production.
+func SetErrors(c *fiber.Ctx, errors map[string]any, secure ...bool) {
+	isSecure := false
+	if len(secure) > 0 {
+		isSecure = secure[0]
+	}
+
+	jsonData, err := json.Marshal(errors)
+	if err != nil {
+		slog.Default().Error("Failed to marshal validation errors", slog.Any("error", err))
+		return
+	}
+
+	c.Cookie(&fiber.Cookie{
+		Name:     ErrorsCookieName,
+		Value:    base64.StdEncoding.EncodeToString(jsonData),
+		Path:     "/",
+		MaxAge:   60,
+		Secure:   isSecure,
+		HTTPOnly: true,
+		SameSite: "Lax",
+	})
+}
+
+// GetErrors retrieves and clears the validation errors stored by the
+// previous request. It returns an empty map when there are none.
+func GetErrors(c *fiber.Ctx) map[string]any {
+	errors := map[string]any{}
+	encodedData := c.Cookies(ErrorsCookieName)
+	if encodedData == "" {
+		return errors
+	}
+
+	c.Cookie(&fiber.Cookie{
+		Name:     ErrorsCookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		Expires:  time.Now().Add(-24 * time.Hour),
+		HTTPOnly: true,
+		SameSite: "Lax",
+	})
+
+	jsonData, err := base64.StdEncoding.DecodeString(encodedData)
+	if err != nil {
+		slog.Default().Error("Failed to decode validation errors", slog.Any("error", err))
+		return errors
+	}
+	if err := json.Unmarshal(jsonData, &errors); err != nil {
+		slog.Default().Error("Failed to unmarshal validation errors", slog.Any("error", err))
+		return map[string]any{}
+	}
+	return errors
+}
@@ -0,0 +1,177 @@
+package cartridge
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+)
+
+// goroutineGroup runs goroutines that share a context, recover from
+// panics and can be waited for. The server has one for app-scoped
+// goroutines; each request using ctx.Go gets its own, nested in it.
+type goroutineGroup struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	logger  Logger
+	request bool // collect errors for Wait and cancel siblings on the first
+
+	wg      sync.WaitGroup
+	running atomic.Int64
+	errOnce sync.Once
+	err     error
+}
+
+func newGoroutineGroup(parent context.Context, logger Logger, request bool) *goroutineGroup {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	ctx, cancel := context.WithCancel(parent)
+	return &goroutineGroup{ctx: ctx, cancel: cancel, logger: logger, request: request}
+}
+
+// goroutine starts fn in the group.
+func (g *goroutineGroup) goroutine(fn func(ctx context.Context) error, attrs ...any) {
+	g.wg.Add(1)
+	g.running.Add(1)
+	go func() {
+		defer g.wg.Done()
+		defer g.running.Add(-1)
+
+		err := g.run(fn, attrs)
+		if err == nil {
+			return
+		}
+		if !g.request {
+			g.logger.Error("background goroutine failed", append([]any{"error", err}, attrs...)...)
+			return
+		}
+		g.errOnce.Do(func() {
+			g.err = err
+			g.cancel()
+		})
+	}()
+}
+
+// run calls fn, turning a panic into an error logged with its stack.
+func (g *goroutineGroup) run(fn func(ctx context.Context) error, attrs []any) (err error) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		g.logger.Error("goroutine panicked", append([]any{"panic", r, "stack", string(debug.Stack())}, attrs...)...)
+		if e, ok := r.(error); ok {
+			err = fmt.Errorf("panic: %w", e)
+		} else {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn(g.ctx)
+}
+
+// wait blocks until every goroutine has returned and returns the first error.
+func (g *goroutineGroup) wait() error {
+	g.wg.Wait()
+	return g.err
+}
+
+// stop cancels the group's context and waits for its goroutines until ctx
+// is done.
+func (g *goroutineGroup) stop(ctx context.Context) error {
+	g.cancel()
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("cartridge: %d goroutines still running: %w", g.running.Load(), ctx.Err())
+	}
+}
+
+// Go runs fn in a goroutine tied to the request, for fanning out work
+// such as parallel queries or API calls:
+//
+//	var user User
+//	var orders []Order
+//	ctx.Go(func(c context.Context) error { return loadUser(c, id, &user) })
+//	ctx.Go(func(c context.Context) error { return loadOrders(c, id, &orders) })
+//	if err := ctx.Wait(); err != nil {
+//	    return err
+//	}
+//
+// fn's context is canceled when the first goroutine fails, the request
+// ends or the server shuts down. Panics are recovered and logged with
+// their stack, and returned by Wait as errors. Goroutines don't outlive
+// the request: when the handler returns without calling Wait, they are
+// canceled and waited for, and their error is logged. Use Server.Go for
+// work that should continue after the response.
+//
+// fn must not use ctx, which is reused once the request ends; read what
+// it needs before calling Go.
+func (ctx *Context) Go(fn func(ctx context.Context) error) {
+	if ctx.goroutines == nil {
+		// Canceled with either the request or the server
+		group := newGoroutineGroup(ctx.UserContext(), ctx.Logger, true)
+		ctx.detach = func() bool { return true }
+		if ctx.background != nil {
+			ctx.detach = context.AfterFunc(ctx.background.ctx, group.cancel)
+		}
+		ctx.goroutines = group
+	}
+	requestID, _ := ctx.Locals("requestid").(string)
+	ctx.goroutines.goroutine(fn, "method", ctx.Method(), "path", ctx.Path(), "request_id", requestID)
+}
+
+// Wait blocks until the goroutines started with Go have returned, and
+// returns the first error. Go may be called again afterwards.
+func (ctx *Context) Wait() error {
+	if ctx.goroutines == nil {
+		return nil
+	}
+	err := ctx.goroutines.wait()
+	ctx.endGoroutines()
+	return err
+}
+
+// finishGoroutines cancels and waits for the request's goroutines once the
+// handler has returned, logging an error nobody waited for.
+func (ctx *Context) finishGoroutines() {
+	group := ctx.goroutines
+	if group == nil {
+		return
+	}
+	group.cancel()
+	if err := group.wait(); err != nil {
+		group.logger.Error("request goroutine failed", "error", err, "method", ctx.Method(), "path", ctx.Path())
+	}
+	ctx.endGoroutines()
+}
+
+// endGoroutines releases the request's finished goroutine group.
+func (ctx *Context) endGoroutines() {
+	ctx.goroutines.cancel()
+	ctx.detach()
+	ctx.goroutines = nil
+	ctx.detach = nil
+}
+
+// Go runs fn in a goroutine that may outlive the request that started it,
+// e.g. to send a notification after responding. Panics are recovered and
+// logged with their stack, and errors are logged. fn's context is canceled
+// when the server shuts down, and Shutdown waits for fn to return within
+// its deadline.
+func (s *Server) Go(fn func(ctx context.Context) error) {
+	s.goroutines.goroutine(fn)
+}
+
+// Go runs fn in an app-scoped goroutine (see Server.Go).
+func (a *Application) Go(fn func(ctx context.Context) error) {
+	a.Server.Go(fn)
+}
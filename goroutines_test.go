@@ -0,0 +1,132 @@
+package cartridge
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGoroutineGroup(t *testing.T) {
+	t.Run("request groups cancel siblings and return the first error", func(t *testing.T) {
+		g := newGoroutineGroup(context.Background(), testLogger(), true)
+		g.goroutine(func(ctx context.Context) error { return errors.New("boom") })
+		g.goroutine(func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		if err := g.wait(); err == nil || err.Error() != "boom" {
+			t.Errorf("expected the first error, got %v", err)
+		}
+	})
+
+	t.Run("panics become errors", func(t *testing.T) {
+		g := newGoroutineGroup(context.Background(), testLogger(), true)
+		g.goroutine(func(ctx context.Context) error { panic("kaput") })
+		if err := g.wait(); err == nil || !strings.Contains(err.Error(), "kaput") {
+			t.Errorf("expected the panic as an error, got %v", err)
+		}
+	})
+
+	t.Run("stop cancels and waits within the deadline", func(t *testing.T) {
+		g := newGoroutineGroup(context.Background(), testLogger(), false)
+		var finished atomic.Bool
+		g.goroutine(func(ctx context.Context) error {
+			<-ctx.Done()
+			finished.Store(true)
+			return nil
+		})
+		if err := g.stop(context.Background()); err != nil || !finished.Load() {
+			t.Errorf("expected a clean stop, got %v", err)
+		}
+
+		stuck := newGoroutineGroup(context.Background(), testLogger(), false)
+		release := make(chan struct{})
+		defer close(release)
+		stuck.goroutine(func(ctx context.Context) error {
+			<-release
+			return nil
+		})
+		deadline, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		if err := stuck.stop(deadline); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected the deadline to expire, got %v", err)
+		}
+	})
+}
+
+func TestContext_Go(t *testing.T) {
+	cfg := DefaultServerConfig()
+	cfg.EnableStaticAssets = false
+	cfg.EnableRequestLogger = false
+	cfg.EnableSecFetchSite = false
+	cfg.Config = &testConfig{}
+	cfg.Logger = testLogger()
+	cfg.DBManager = &testDBManager{}
+	cfg.ErrorHandler = DefaultErrorHandler(testLogger(), false)
+	srv, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	var leaked atomic.Bool
+	srv.Get("/fanout", func(ctx *Context) error {
+		results := make([]string, 2)
+		for i, name := range []string{"users", "orders"} {
+			ctx.Go(func(c context.Context) error {
+				results[i] = name
+				return nil
+			})
+		}
+		if err := ctx.Wait(); err != nil {
+			return err
+		}
+		// Forgotten goroutines are canceled when the handler returns
+		ctx.Go(func(c context.Context) error {
+			select {
+			case <-c.Done():
+			case <-time.After(time.Second):
+				leaked.Store(true)
+			}
+			return nil
+		})
+		return ctx.SendString(strings.Join(results, ","))
+	})
+	srv.Get("/panic", func(ctx *Context) error {
+		ctx.Go(func(c context.Context) error { panic("kaput") })
+		return ctx.Wait()
+	})
+
+	resp, err := srv.App().Test(httptest.NewRequest("GET", "/fanout", nil))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "users,orders" || leaked.Load() {
+		t.Errorf("unexpected fan-out result %q (leaked: %v)", body, leaked.Load())
+	}
+
+	resp, err = srv.App().Test(httptest.NewRequest("GET", "/panic", nil))
+	if err != nil || resp.StatusCode != 500 {
+		t.Errorf("expected a recovered panic to answer 500, got %v %v", resp.StatusCode, err)
+	}
+
+	done := make(chan struct{})
+	srv.Go(func(ctx context.Context) error {
+		<-ctx.Done()
+		close(done)
+		return nil
+	})
+	if err := srv.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown failed: %v", err)
+	}
+	select {
+	case <-done:
+	default:
+		t.Error("expected Shutdown to cancel and wait for app goroutines")
+	}
+}
@@ -0,0 +1,107 @@
+package cartridge
+
+import (
+	"context"
+	"runtime"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// HealthPath serves HealthStats as JSON when ServerConfig.EnableHealthCheck
+// is set. The request logger skips it.
+const HealthPath = "/_health"
+
+// HealthStats is a snapshot of the process, served at HealthPath.
+type HealthStats struct {
+	// Status is "ok", or "unavailable" when the database doesn't answer a ping.
+	Status string `json:"status"`
+
+	// StartedAt is when the server was created; Uptime is the time since.
+	StartedAt     time.Time `json:"started_at"`
+	Uptime        string    `json:"uptime"`
+	UptimeSeconds int64     `json:"uptime_seconds"`
+
+	Goroutines int          `json:"goroutines"`
+	Memory     HealthMemory `json:"memory"`
+
+	// Database is nil when the database manager has no connection.
+	Database *HealthDatabase `json:"database,omitempty"`
+
+	// AsyncQueue is the number of queued async tasks, or nil without an
+	// AsyncManager.
+	AsyncQueue *int `json:"async_queue,omitempty"`
+}
+
+// HealthMemory reports Go runtime memory, in bytes.
+type HealthMemory struct {
+	Alloc     uint64 `json:"alloc"`      // live heap objects
+	HeapInUse uint64 `json:"heap_inuse"` // heap spans in use
+	Sys       uint64 `json:"sys"`        // obtained from the OS
+	NumGC     uint32 `json:"num_gc"`
+}
+
+// HealthDatabase reports the connection pool (see sql.DBStats).
+type HealthDatabase struct {
+	OpenConnections int    `json:"open_connections"`
+	InUse           int    `json:"in_use"`
+	Idle            int    `json:"idle"`
+	WaitCount       int64  `json:"wait_count"`
+	Error           string `json:"error,omitempty"` // ping failure
+}
+
+// healthPingTimeout bounds the database ping in HealthStats.
+const healthPingTimeout = 2 * time.Second
+
+// HealthStats returns the server's process stats, as served at HealthPath.
+func (s *Server) HealthStats(ctx context.Context) HealthStats {
+	uptime := time.Since(s.started)
+	stats := HealthStats{
+		Status:        "ok",
+		StartedAt:     s.started,
+		Uptime:        uptime.Round(time.Second).String(),
+		UptimeSeconds: int64(uptime.Seconds()),
+		Goroutines:    runtime.NumGoroutine(),
+	}
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	stats.Memory = HealthMemory{Alloc: mem.Alloc, HeapInUse: mem.HeapInuse, Sys: mem.Sys, NumGC: mem.NumGC}
+
+	if db := s.cfg.DBManager.GetConnection(); db != nil {
+		if sqlDB, err := db.DB(); err == nil {
+			pool := sqlDB.Stats()
+			stats.Database = &HealthDatabase{
+				OpenConnections: pool.OpenConnections,
+				InUse:           pool.InUse,
+				Idle:            pool.Idle,
+				WaitCount:       pool.WaitCount,
+			}
+			pingCtx, cancel := context.WithTimeout(ctx, healthPingTimeout)
+			defer cancel()
+			if err := sqlDB.PingContext(pingCtx); err != nil {
+				stats.Status = "unavailable"
+				stats.Database.Error = err.Error()
+			}
+		}
+	}
+
+	if s.async != nil {
+		queued := s.async.QueueLen()
+		stats.AsyncQueue = &queued
+	}
+	return stats
+}
+
+// mountHealthCheck serves HealthStats at HealthPath, answering 503 when
+// the database is unavailable. Like AsyncStatusPath it is mounted on the
+// Fiber app, outside the route table and rate limits.
+func (s *Server) mountHealthCheck() {
+	s.app.Get(HealthPath, func(c *fiber.Ctx) error {
+		stats := s.HealthStats(c.UserContext())
+		if stats.Status != "ok" {
+			c.Status(fiber.StatusServiceUnavailable)
+		}
+		return c.JSON(stats)
+	})
+}
@@ -0,0 +1,53 @@
+package cartridge
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestServer_HealthCheck(t *testing.T) {
+	db := openAsyncTestDB(t)
+	cfg := DefaultServerConfig()
+	cfg.EnableStaticAssets = false
+	cfg.EnableRequestLogger = false
+	cfg.Config = &testConfig{}
+	cfg.Logger = testLogger()
+	cfg.DBManager = &mockDBManager{db: db}
+	cfg.ErrorHandler = DefaultErrorHandler(testLogger(), false)
+	srv, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	srv.SetAsync(NewAsyncManager(AsyncConfig{Logger: testLogger(), DBManager: cfg.DBManager}))
+	srv.started = time.Now().Add(-90 * time.Minute)
+
+	resp, err := srv.App().Test(httptest.NewRequest("GET", HealthPath, nil))
+	if err != nil || resp.StatusCode != 200 {
+		t.Fatalf("expected 200 from the health check, got %v %v", resp.StatusCode, err)
+	}
+	var stats HealthStats
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		t.Fatalf("failed to decode stats: %v", err)
+	}
+
+	if stats.Status != "ok" || stats.Uptime != "1h30m0s" || stats.UptimeSeconds < 90*60 {
+		t.Errorf("expected uptime since the server started, got %+v", stats)
+	}
+	if stats.Goroutines == 0 || stats.Memory.Alloc == 0 || stats.Memory.Sys == 0 {
+		t.Errorf("expected runtime stats, got %+v", stats)
+	}
+	if stats.Database == nil || stats.Database.OpenConnections == 0 {
+		t.Errorf("expected connection pool stats, got %+v", stats.Database)
+	}
+	if stats.AsyncQueue == nil || *stats.AsyncQueue != 0 {
+		t.Errorf("expected an empty async queue, got %v", stats.AsyncQueue)
+	}
+
+	// Without a connection or async manager those sections are omitted
+	bare := newSecurityHeadersTestServer(t, func(cfg *ServerConfig) {})
+	if stats := bare.HealthStats(t.Context()); stats.Database != nil || stats.AsyncQueue != nil {
+		t.Errorf("expected no database or async stats, got %+v", stats)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/karloscodes/cartridge/flash"
 
@@ -39,6 +40,70 @@ func SetDevMode(enabled bool) {
 	devMode = enabled
 }
 
+// manifestMu guards jsFile and cssFile once a watcher reloads them.
+var manifestMu sync.RWMutex
+
+// manifestWatched is set by ReloadManifest: dev mode then serves the paths
+// the watcher last read instead of re-reading the manifest per request.
+var manifestWatched atomic.Bool
+
+// ReloadManifest re-reads the Vite manifest. Cartridge's development file
+// watcher calls it after vite writes a new manifest.
+func ReloadManifest() {
+	js, css := readManifest()
+	manifestMu.Lock()
+	jsFile, cssFile = js, css
+	manifestMu.Unlock()
+	manifestWatched.Store(true)
+}
+
+// DefaultManifestPaths are where the Vite manifest is looked for, in order.
+var DefaultManifestPaths = []string{"dist/.vite/manifest.json", "web/dist/.vite/manifest.json"}
+
+// DefaultEntry is the manifest key of the Inertia entry point.
+const DefaultEntry = "src/inertia.tsx"
+
+// ViteConfig locates the Vite build and dev server. Zero fields keep the defaults.
+type ViteConfig struct {
+	// ManifestPaths are tried in order. Default: DefaultManifestPaths
+	ManifestPaths []string
+
+	// Entry is the entry point's manifest key, which is also its source path.
+	// Default: DefaultEntry
+	Entry string
+
+	// Base is the URL prefix assets are served under, matching Vite's base
+	// option. Default: "/assets"
+	Base string
+
+	// DevServerURL is the Vite dev server, e.g. "http://localhost:5173". In
+	// dev mode, pages then load the Vite client and Entry from Base, which the
+	// app proxies to the dev server, instead of the built files. Default: ""
+	DevServerURL string
+
+	// ReactRefresh adds the preamble @vitejs/plugin-react needs when pages
+	// load from the dev server. Default: false
+	ReactRefresh bool
+}
+
+// vite is the active ViteConfig, set once at startup.
+var vite = ViteConfig{ManifestPaths: DefaultManifestPaths, Entry: DefaultEntry, Base: "/assets"}
+
+// SetVite sets where the Vite manifest, entry point and dev server are.
+func SetVite(cfg ViteConfig) {
+	if len(cfg.ManifestPaths) == 0 {
+		cfg.ManifestPaths = DefaultManifestPaths
+	}
+	if cfg.Entry == "" {
+		cfg.Entry = DefaultEntry
+	}
+	cfg.Base = "/" + strings.Trim(cfg.Base, "/")
+	if cfg.Base == "/" {
+		cfg.Base = "/assets"
+	}
+	vite = cfg
+}
+
 // SetTitle sets the HTML page title for server-rendered pages.
 func SetTitle(title string) {
 	pageTitle = title
@@ -53,25 +118,22 @@ func SetManifestData(data []byte) {
 // readManifest reads the Vite manifest and returns JS and CSS paths
 func readManifest() (js, css string) {
 	// Default fallback paths (without hashes)
-	js = "/assets/inertia.js"
-	css = "/assets/inertia.css"
+	js = vite.Base + "/inertia.js"
+	css = vite.Base + "/inertia.css"
 
-	// Try to read the manifest file from multiple locations
+	// Try the manifest locations in order, then the embedded manifest
 	var data []byte
-	var err error
-
-	// Try dist/.vite/manifest.json first (production build location)
-	data, err = os.ReadFile("dist/.vite/manifest.json")
-	if err != nil {
-		// Fallback to web/dist/.vite/manifest.json
-		data, err = os.ReadFile("web/dist/.vite/manifest.json")
-		if err != nil {
-			// Use embedded manifest data if available
-			if len(manifestData) > 0 {
-				data = manifestData
-			} else {
-				return // Use fallback paths
-			}
+	for _, path := range vite.ManifestPaths {
+		var err error
+		if data, err = os.ReadFile(path); err == nil {
+			break
+		}
+	}
+	if data == nil {
+		if len(manifestData) > 0 {
+			data = manifestData
+		} else {
+			return // Use fallback paths
 		}
 	}
 
@@ -80,8 +142,8 @@ func readManifest() (js, css string) {
 		return // Use fallback paths
 	}
 
-	// Find the entry point (src/inertia.tsx)
-	if entry, ok := manifest["src/inertia.tsx"]; ok {
+	// Find the entry point (src/inertia.tsx by default)
+	if entry, ok := manifest[vite.Entry]; ok {
 		js = "/" + entry.File
 		if len(entry.CSS) > 0 {
 			css = "/" + entry.CSS[0]
@@ -90,20 +152,29 @@ func readManifest() (js, css string) {
 	return
 }
 
-// loadManifest reads the Vite manifest and extracts asset paths.
+// loadManifest reads the Vite manifest and returns the asset paths.
 // In production, uses sync.Once to cache for performance.
-// In dev mode, re-reads on every call to pick up vite rebuilds.
-func loadManifest() {
-	if devMode {
+// In dev mode, re-reads on every call to pick up vite rebuilds, unless a
+// watcher keeps the paths current (see ReloadManifest).
+func loadManifest() (js, css string) {
+	if devMode && !manifestWatched.Load() {
 		// In dev mode, always re-read the manifest
-		jsFile, cssFile = readManifest()
-		return
+		return readManifest()
 	}
 
 	// In production, cache the manifest
 	manifestOnce.Do(func() {
-		jsFile, cssFile = readManifest()
+		if manifestWatched.Load() {
+			return
+		}
+		js, css := readManifest()
+		manifestMu.Lock()
+		jsFile, cssFile = js, css
+		manifestMu.Unlock()
 	})
+	manifestMu.RLock()
+	defer manifestMu.RUnlock()
+	return jsFile, cssFile
 }
 
 // Props is a type alias for map[string]interface{} to make handler code cleaner
@@ -136,11 +207,11 @@ func RenderPage(c *fiber.Ctx, component string, props map[string]interface{}) er
 
 // Render sends an Inertia response
 // Automatically detects if request is Inertia (AJAX) or initial page load
-// Automatically injects flash messages from context if available
+// Automatically injects flash messages, validation errors and the CSRF token from context if available
 // Supports deferred props via X-Inertia-Partial-Data header
 func Render(c *fiber.Ctx, i *inertiapkg.Inertia, component string, props map[string]interface{}) error {
 	// Load asset paths from manifest (cached in production, fresh in dev)
-	loadManifest()
+	jsFile, cssFile := loadManifest()
 
 	// Build full URL with query string for proper Inertia navigation
 	fullURL := c.Path()
@@ -153,6 +224,20 @@ func Render(c *fiber.Ctx, i *inertiapkg.Inertia, component string, props map[str
 		props["flash"] = flash.GetFlash(c)
 	}
 
+	// Auto-inject validation errors from the previous request ({} when none),
+	// which useForm() reads as form.errors
+	if _, exists := props["errors"]; !exists {
+		props["errors"] = flash.GetErrors(c)
+	}
+
+	// Auto-inject the CSRF token set by the CSRF middleware, for fetch calls
+	// and plain forms (axios already sends the XSRF-TOKEN cookie back)
+	if _, exists := props["csrf_token"]; !exists {
+		if token, ok := c.Locals("csrf").(string); ok && token != "" {
+			props["csrf_token"] = token
+		}
+	}
+
 	// Check if this is an Inertia request (subsequent navigation)
 	if c.Get("X-Inertia") != "" {
 		// Set required Inertia response headers
@@ -166,6 +251,10 @@ func Render(c *fiber.Ctx, i *inertiapkg.Inertia, component string, props map[str
 		// If this is a partial reload request, only return requested props
 		if partialData != "" && (partialComponent == "" || partialComponent == component) {
 			resolvedProps := resolveProps(props, partialData, partialComponent, component)
+			// Forms need their errors on partial reloads too
+			if _, ok := resolvedProps["errors"]; !ok {
+				resolvedProps["errors"] = props["errors"]
+			}
 			return c.JSON(fiber.Map{
 				"component": component,
 				"props":     resolvedProps,
@@ -223,10 +312,23 @@ func Render(c *fiber.Ctx, i *inertiapkg.Inertia, component string, props map[str
 		c.Set("Cache-Control", "no-cache")
 	}
 
+	// Tags carry the Content-Security-Policy nonce set by Helmet, if any
+	nonceAttr := ""
+	if nonce, ok := c.Locals("csp_nonce").(string); ok && nonce != "" {
+		nonceAttr = ` nonce="` + html.EscapeString(nonce) + `"`
+	}
+
 	// Build CSS link tag only if we have a CSS file
 	cssLink := ""
 	if cssFile != "" {
-		cssLink = `<link rel="stylesheet" href="` + cssFile + `">`
+		cssLink = `<link rel="stylesheet" href="` + cssFile + `"` + nonceAttr + `>`
+	}
+	scripts := `<script type="module" src="` + jsFile + `"` + nonceAttr + `></script>`
+
+	// Load from the Vite dev server for HMR; it injects the CSS itself
+	if devMode && vite.DevServerURL != "" {
+		cssLink = ""
+		scripts = devServerScripts(nonceAttr)
 	}
 
 	// Use manifest-resolved asset paths and HTML-escape the JSON to prevent attribute injection
@@ -241,13 +343,32 @@ func Render(c *fiber.Ctx, i *inertiapkg.Inertia, component string, props map[str
 </head>
 <body>
     <div id="app" data-page='` + html.EscapeString(string(pageJSON)) + `'></div>
-    <script type="module" src="` + jsFile + `"></script>
+    ` + scripts + `
 </body>
 </html>`
 
 	return c.SendString(htmlContent)
 }
 
+// devServerScripts loads the Vite client and the entry point from the dev
+// server, through the app's proxy under vite.Base.
+func devServerScripts(nonceAttr string) string {
+	var b strings.Builder
+	if vite.ReactRefresh {
+		b.WriteString(`<script type="module"` + nonceAttr + `>
+import RefreshRuntime from "` + vite.Base + `/@react-refresh"
+RefreshRuntime.injectIntoGlobalHook(window)
+window.$RefreshReg$ = () => {}
+window.$RefreshSig$ = () => (type) => type
+window.__vite_plugin_react_preamble_installed__ = true
+</script>
+    `)
+	}
+	b.WriteString(`<script type="module" src="` + vite.Base + `/@vite/client"` + nonceAttr + `></script>
+    <script type="module" src="` + vite.Base + "/" + html.EscapeString(vite.Entry) + `"` + nonceAttr + `></script>`)
+	return b.String()
+}
+
 // resolveProps handles partial reload requests for deferred props
 func resolveProps(props map[string]interface{}, partialData, partialComponent, component string) map[string]interface{} {
 	// If not a partial reload or wrong component, return all non-deferred props
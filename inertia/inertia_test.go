@@ -1,7 +1,10 @@
 package inertia
 
 import (
+	"io"
 	"net/http"
+	"os"
+	"strings"
 	"testing"
 
 	"github.com/gofiber/fiber/v2"
@@ -47,3 +50,42 @@ func TestRenderSetsCacheControlInProductionMode(t *testing.T) {
 		t.Errorf("production mode: expected Cache-Control 'no-cache', got %q", cc)
 	}
 }
+
+func TestSetVite(t *testing.T) {
+	dir := t.TempDir()
+	manifest := dir + "/manifest.json"
+	os.WriteFile(manifest, []byte(`{"resources/js/app.tsx": {"file": "assets/app-abc123.js", "css": ["assets/app-def456.css"]}}`), 0o644)
+
+	SetVite(ViteConfig{ManifestPaths: []string{dir + "/missing.json", manifest}, Entry: "resources/js/app.tsx"})
+	defer SetVite(ViteConfig{})
+	if js, css := readManifest(); js != "/assets/app-abc123.js" || css != "/assets/app-def456.css" {
+		t.Errorf("expected the configured entry from the configured manifest, got %q %q", js, css)
+	}
+
+	SetDevMode(true)
+	defer SetDevMode(false)
+	SetVite(ViteConfig{Entry: "resources/js/app.tsx", Base: "static/", DevServerURL: "http://localhost:5173", ReactRefresh: true})
+
+	app := fiber.New()
+	app.Get("/test", func(c *fiber.Ctx) error {
+		return RenderPage(c, "TestComponent", map[string]interface{}{})
+	})
+	req, _ := http.NewRequest("GET", "/test", nil)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	for _, want := range []string{
+		`import RefreshRuntime from "/static/@react-refresh"`,
+		`<script type="module" src="/static/@vite/client"></script>`,
+		`<script type="module" src="/static/resources/js/app.tsx"></script>`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("expected %s in the page, got %s", want, body)
+		}
+	}
+	if strings.Contains(string(body), "stylesheet") {
+		t.Error("expected the dev server to inject the CSS")
+	}
+}
@@ -3,9 +3,14 @@ package cartridge
 import (
 	"fmt"
 	"io/fs"
+	"log/slog"
+	"os"
 	"time"
 
+	"github.com/karloscodes/cartridge/cache"
+	"github.com/karloscodes/cartridge/config"
 	"github.com/karloscodes/cartridge/inertia"
+	cartridgemiddleware "github.com/karloscodes/cartridge/middleware"
 	"github.com/karloscodes/cartridge/sqlite"
 )
 
@@ -14,6 +19,11 @@ type InertiaApp struct {
 	*Application
 	DBManager *sqlite.Manager
 	Session   *SessionManager
+	Sessions  *Sessions
+	WAL       *WALCheckpointer // nil unless InertiaWithWALCheckpoints is used
+	Audit     AuditStore       // nil unless InertiaWithAuditLog is used
+	APITokens *APITokens       // nil unless InertiaWithAPITokens is used
+	Notifier  *Notifier        // nil unless InertiaWithNotifications is used
 }
 
 // InertiaOption configures the Inertia application.
@@ -33,9 +43,32 @@ type inertiaConfig struct {
 	jobGroups        []inertiaJobGroup
 	workers          []BackgroundWorker
 	sessionPath      string
+	sessionStore     SessionStore
+	timezone         TimezoneConfig
 	crossOriginAPI   bool
+	corsOrigins      []string
 	pageTitle        string
 	catchAllRedirect string
+	tracingEndpoint  string
+	uploadStorage    UploadStorage
+	unixSocket       string
+	unixSocketPerms  os.FileMode
+	cacheStore       cache.Store
+	csrf             *cartridgemiddleware.CSRFConfig
+	csp              *cartridgemiddleware.CSP
+	headerPresets    map[string]string
+	reportOnly       func(Config) bool
+	securityReports  *SecurityReportsConfig
+	capture          *cartridgemiddleware.CaptureConfig
+	walCheckpoint    *WALCheckpointConfig
+	maintenance      *MaintenanceConfig
+	audit            *AuditConfig
+	apiTokens        *APITokensConfig
+	tenancy          *TenancyConfig
+	dbKey            func() (string, error)
+	secrets          config.SecretsProvider
+	notifier         *NotifierConfig
+	vite             inertia.ViteConfig
 }
 
 // InertiaWithConfig provides a pre-loaded config instead of using default.
@@ -45,6 +78,15 @@ func InertiaWithConfig(cfg Config) InertiaOption {
 	}
 }
 
+// InertiaWithSecrets resolves the ${secret:NAME} references in the config
+// with p before the app reads it (see WithSecrets). The config must be a
+// *config.Config, as returned by config.Load.
+func InertiaWithSecrets(p config.SecretsProvider) InertiaOption {
+	return func(c *inertiaConfig) {
+		c.secrets = p
+	}
+}
+
 // InertiaWithStaticAssets sets embedded static files for production.
 // In development mode, assets are served from disk for hot-reload.
 func InertiaWithStaticAssets(static fs.FS) InertiaOption {
@@ -53,6 +95,22 @@ func InertiaWithStaticAssets(static fs.FS) InertiaOption {
 	}
 }
 
+// InertiaWithVite sets the Vite manifest paths, entry point and dev server.
+// With DevServerURL set, development pages load from the Vite dev server
+// for HMR, and requests under the asset prefix are proxied to it; configure
+// Vite with the same base ("/assets/" by default).
+//
+//	cartridge.InertiaWithVite(inertia.ViteConfig{
+//	    Entry:        "resources/js/app.tsx",
+//	    DevServerURL: "http://localhost:5173",
+//	    ReactRefresh: true,
+//	})
+func InertiaWithVite(cfg inertia.ViteConfig) InertiaOption {
+	return func(c *inertiaConfig) {
+		c.vite = cfg
+	}
+}
+
 // InertiaWithDBManager provides a custom database manager.
 // Use this when you need a custom DB manager with additional methods (e.g., migrations).
 func InertiaWithDBManager(dbManager DBManager) InertiaOption {
@@ -88,6 +146,98 @@ func InertiaWithWorker(worker BackgroundWorker) InertiaOption {
 	}
 }
 
+// InertiaWithWALCheckpoints checkpoints the SQLite WAL in the background
+// (see WithWALCheckpoints). Ignored with InertiaWithDBManager.
+func InertiaWithWALCheckpoints(cfg ...WALCheckpointConfig) InertiaOption {
+	return func(c *inertiaConfig) {
+		checkpoint := DefaultWALCheckpointConfig()
+		if len(cfg) > 0 {
+			checkpoint = cfg[0]
+		}
+		c.walCheckpoint = &checkpoint
+	}
+}
+
+// InertiaWithDatabaseEncryption encrypts the SQLite database at rest with
+// SQLCipher (see WithDatabaseEncryption). Ignored with InertiaWithDBManager.
+func InertiaWithDatabaseEncryption(key func() (string, error)) InertiaOption {
+	return func(c *inertiaConfig) {
+		c.dbKey = key
+	}
+}
+
+// InertiaWithDatabaseMaintenance schedules SQLite maintenance cron jobs
+// (see WithDatabaseMaintenance). Ignored with InertiaWithDBManager.
+func InertiaWithDatabaseMaintenance(cfg ...MaintenanceConfig) InertiaOption {
+	return func(c *inertiaConfig) {
+		maintenance := DefaultMaintenanceConfig()
+		if len(cfg) > 0 {
+			maintenance = cfg[0]
+		}
+		c.maintenance = &maintenance
+	}
+}
+
+// InertiaWithSessionStore sets the backend for ctx.Session().
+// Default: encrypted cookies (NewCookieSessionStore).
+func InertiaWithSessionStore(store SessionStore) InertiaOption {
+	return func(c *inertiaConfig) {
+		c.sessionStore = store
+	}
+}
+
+// InertiaWithAPITokens enables personal API tokens (see WithAPITokens).
+func InertiaWithAPITokens(cfg ...APITokensConfig) InertiaOption {
+	return func(c *inertiaConfig) {
+		tokens := APITokensConfig{}
+		if len(cfg) > 0 {
+			tokens = cfg[0]
+		}
+		c.apiTokens = &tokens
+	}
+}
+
+// InertiaWithAuditLog enables ctx.Audit (see WithAuditLog).
+func InertiaWithAuditLog(cfg ...AuditConfig) InertiaOption {
+	return func(c *inertiaConfig) {
+		audit := AuditConfig{}
+		if len(cfg) > 0 {
+			audit = cfg[0]
+		}
+		c.audit = &audit
+	}
+}
+
+// InertiaWithNotifications enables ctx.Notify (see WithNotifications).
+func InertiaWithNotifications(cfg NotifierConfig) InertiaOption {
+	return func(c *inertiaConfig) {
+		c.notifier = &cfg
+	}
+}
+
+// InertiaWithTenancy resolves a tenant for every request (see WithTenancy).
+func InertiaWithTenancy(cfg TenancyConfig) InertiaOption {
+	return func(c *inertiaConfig) {
+		c.tenancy = &cfg
+	}
+}
+
+// InertiaWithCacheStore sets the backend for ctx.Cache() and
+// JobContext.Cache(). Default: in memory (cache.NewLRUStore)
+func InertiaWithCacheStore(store cache.Store) InertiaOption {
+	return func(c *inertiaConfig) {
+		c.cacheStore = store
+	}
+}
+
+// InertiaWithTimezone customizes how the user's time zone is resolved for
+// ctx.Location() and ctx.LocalTime().
+func InertiaWithTimezone(tz TimezoneConfig) InertiaOption {
+	return func(c *inertiaConfig) {
+		c.timezone = tz
+	}
+}
+
 // InertiaWithSession enables session management.
 // The cookie name is "{appname}_session".
 func InertiaWithSession(loginPath string) InertiaOption {
@@ -104,6 +254,102 @@ func InertiaWithCrossOriginAPI() InertiaOption {
 	}
 }
 
+// InertiaWithCORS sets the CORS policy for routes with EnableCORS.
+// See WithCORS.
+func InertiaWithCORS(origins ...string) InertiaOption {
+	return func(c *inertiaConfig) {
+		c.corsOrigins = origins
+	}
+}
+
+// InertiaWithCSRF enables CSRF protection with the XSRF-TOKEN cookie that
+// Inertia's axios sends back as X-XSRF-TOKEN. See WithCSRF.
+func InertiaWithCSRF(csrf ...cartridgemiddleware.CSRFConfig) InertiaOption {
+	return func(c *inertiaConfig) {
+		cfg := cartridgemiddleware.InertiaCSRFConfig()
+		if len(csrf) > 0 {
+			cfg = csrf[0]
+		}
+		c.csrf = &cfg
+	}
+}
+
+// InertiaWithCSP sends a Content-Security-Policy built from policy. The
+// page's script and stylesheet tags carry the request's nonce. See WithCSP.
+func InertiaWithCSP(policy *cartridgemiddleware.CSP) InertiaOption {
+	return func(c *inertiaConfig) {
+		c.csp = policy
+	}
+}
+
+// InertiaWithHeaderPreset sends the named security header preset for
+// requests under prefix. See WithHeaderPreset.
+func InertiaWithHeaderPreset(prefix, preset string) InertiaOption {
+	return func(c *inertiaConfig) {
+		if c.headerPresets == nil {
+			c.headerPresets = make(map[string]string)
+		}
+		c.headerPresets[prefix] = preset
+	}
+}
+
+// InertiaWithSecurityReportOnly sends the Content-Security-Policy and
+// Permissions-Policy as report-only in the given environments, or in all of
+// them when none are given. See WithSecurityReportOnly.
+func InertiaWithSecurityReportOnly(environments ...string) InertiaOption {
+	return func(c *inertiaConfig) {
+		c.reportOnly = reportOnlyIn(environments)
+	}
+}
+
+// InertiaWithSecurityReports collects CSP and Permissions-Policy violation
+// reports. See WithSecurityReports.
+func InertiaWithSecurityReports(cfg ...SecurityReportsConfig) InertiaOption {
+	return func(c *inertiaConfig) {
+		reports := SecurityReportsConfig{}
+		if len(cfg) > 0 {
+			reports = cfg[0]
+		}
+		c.securityReports = &reports
+	}
+}
+
+// InertiaWithRequestCapture records requests sent with the capture header
+// as test fixtures in development (see WithRequestCapture).
+func InertiaWithRequestCapture(capture ...cartridgemiddleware.CaptureConfig) InertiaOption {
+	return func(c *inertiaConfig) {
+		cfg := cartridgemiddleware.DefaultCaptureConfig()
+		if len(capture) > 0 {
+			cfg = capture[0]
+		}
+		c.capture = &cfg
+	}
+}
+
+// InertiaWithTracing exports OpenTelemetry traces to an OTLP/HTTP collector.
+// See WithTracing.
+func InertiaWithTracing(endpoint string) InertiaOption {
+	return func(c *inertiaConfig) {
+		c.tracingEndpoint = endpoint
+	}
+}
+
+// InertiaWithUploadStorage sets the backend for ctx.SaveUpload.
+func InertiaWithUploadStorage(storage UploadStorage) InertiaOption {
+	return func(c *inertiaConfig) {
+		c.uploadStorage = storage
+	}
+}
+
+// InertiaWithUnixSocket serves on a Unix domain socket at path instead of
+// the configured port (see WithUnixSocket).
+func InertiaWithUnixSocket(path string, perms os.FileMode) InertiaOption {
+	return func(c *inertiaConfig) {
+		c.unixSocket = path
+		c.unixSocketPerms = perms
+	}
+}
+
 // InertiaWithPageTitle sets the HTML page title for Inertia pages.
 func InertiaWithPageTitle(title string) InertiaOption {
 	return func(c *inertiaConfig) {
@@ -172,18 +418,32 @@ func NewInertiaApp(opts ...InertiaOption) (*InertiaApp, error) {
 		return nil, fmt.Errorf("cartridge: config must implement FactoryConfig interface")
 	}
 
+	// Fetch secrets before anything reads the settings that reference them
+	if cfg.secrets != nil {
+		appCfg, ok := cfg.cfg.(*config.Config)
+		if !ok {
+			return nil, fmt.Errorf("cartridge: InertiaWithSecrets requires a *config.Config")
+		}
+		if err := resolveSecrets(appCfg, cfg.secrets); err != nil {
+			return nil, err
+		}
+	}
+
 	// Enable Inertia dev mode in development (re-reads manifest on every request)
 	if cfg.cfg.IsDevelopment() {
 		inertia.SetDevMode(true)
 	}
 
+	// Locate the Vite build and dev server
+	inertia.SetVite(cfg.vite)
+
 	// Set page title if provided
 	if cfg.pageTitle != "" {
 		inertia.SetTitle(cfg.pageTitle)
 	}
 
-	// Create logger
-	logger := NewLogger(cfg.cfg, nil)
+	// Create logger, keeping a tail for the debug UI
+	logger := slog.New(NewLogTail(NewLogger(cfg.cfg, nil).Handler(), DefaultLogTailSize))
 
 	// Create or use provided database manager
 	var dbManager DBManager
@@ -197,6 +457,7 @@ func NewInertiaApp(opts ...InertiaOption) (*InertiaApp, error) {
 			MaxOpenConns: factoryCfg.GetMaxOpenConns(),
 			MaxIdleConns: factoryCfg.GetMaxIdleConns(),
 			Logger:       logger,
+			KeyProvider:  cfg.dbKey,
 		})
 		dbManager = sqliteManager
 	}
@@ -206,17 +467,66 @@ func NewInertiaApp(opts ...InertiaOption) (*InertiaApp, error) {
 	serverCfg.Config = cfg.cfg
 	serverCfg.Logger = logger
 	serverCfg.DBManager = dbManager
+	serverCfg.UploadStorage = cfg.uploadStorage
+	serverCfg.UnixSocket = cfg.unixSocket
+	serverCfg.UnixSocketPerms = cfg.unixSocketPerms
+	serverCfg.Tenancy = cfg.tenancy
+	serverCfg.Cache = NewCache(cfg.cacheStore)
 
 	// Use embedded static assets in production, disk in development for hot-reload
 	if !cfg.cfg.IsDevelopment() && cfg.staticFS != nil {
 		serverCfg.StaticFS = cfg.staticFS
 	}
+	serverCfg.StaticDevServer = cfg.vite.DevServerURL
+
+	if cfg.corsOrigins != nil {
+		serverCfg.CORS = corsPreset(cfg.cfg, cfg.corsOrigins)
+	}
+	if cfg.csrf != nil {
+		serverCfg.CSRF = csrfPreset(cfg.cfg, factoryCfg.GetSessionSecret(), *cfg.csrf)
+	}
+	if cfg.csp != nil {
+		serverCfg.SecurityHeaders = cspPreset(cfg.csp)
+	}
+	serverCfg.HeaderPresets = cfg.headerPresets
+	serverCfg.SecurityReportOnly = cfg.reportOnly != nil && cfg.reportOnly(cfg.cfg)
+	serverCfg.SecurityReports = cfg.securityReports
+	if cfg.capture != nil && cfg.cfg.IsDevelopment() {
+		serverCfg.RequestCapture = cfg.capture
+	}
+
+	var notifier *Notifier
+	if cfg.notifier != nil {
+		notifierCfg := *cfg.notifier
+		if notifierCfg.Logger == nil {
+			notifierCfg.Logger = logger
+		}
+		var err error
+		if notifier, err = NewNotifier(notifierCfg); err != nil {
+			return nil, err
+		}
+		serverCfg.Notifier = notifier
+	}
 
 	// Configure SecFetchSite for cross-origin APIs (analytics, public endpoints)
 	if cfg.crossOriginAPI {
 		serverCfg.SecFetchSiteAllowedValues = []string{"cross-site", "same-site", "same-origin"}
 	}
 
+	// Install the tracer provider before anything can start spans
+	var tracing *Tracing
+	if cfg.tracingEndpoint != "" {
+		var err error
+		tracing, err = SetupTracing(TracingConfig{
+			Endpoint:    cfg.tracingEndpoint,
+			ServiceName: factoryCfg.GetAppName(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		serverCfg.EnableTracing = true
+	}
+
 	// Create server
 	server, err := NewServer(serverCfg)
 	if err != nil {
@@ -236,6 +546,39 @@ func NewInertiaApp(opts ...InertiaOption) (*InertiaApp, error) {
 		server.SetSession(sessionMgr)
 	}
 
+	// Enable ctx.Session() before routes are mounted
+	sessions := NewSessions(SessionsConfig{
+		Store:      cfg.sessionStore,
+		Secret:     factoryCfg.GetSessionSecret(),
+		CookieName: factoryCfg.GetAppName() + "_session_data",
+		TTL:        time.Duration(factoryCfg.GetSessionTimeout()) * time.Second,
+		Secure:     cfg.cfg.IsProduction(),
+		Logger:     logger,
+	})
+	server.SetSessions(sessions)
+	server.App().Use(TimezoneMiddleware(cfg.timezone))
+
+	var auditStore AuditStore
+	if cfg.audit != nil {
+		auditStore = cfg.audit.Store
+		if auditStore == nil {
+			auditStore, err = NewDatabaseAuditStore(dbManager)
+			if err != nil {
+				return nil, err
+			}
+		}
+		server.SetAuditStore(auditStore)
+	}
+
+	var apiTokens *APITokens
+	if cfg.apiTokens != nil {
+		apiTokens, err = NewAPITokens(dbManager, *cfg.apiTokens)
+		if err != nil {
+			return nil, err
+		}
+		server.SetAPITokens(apiTokens)
+	}
+
 	// Mount routes (session is available via server.Session())
 	if cfg.routes != nil {
 		cfg.routes(server)
@@ -249,15 +592,64 @@ func NewInertiaApp(opts ...InertiaOption) (*InertiaApp, error) {
 	// Collect background workers
 	var workers []BackgroundWorker
 
+	// Tracing goes first so it stops last and flushes spans from the others
+	if tracing != nil {
+		workers = append(workers, tracing)
+	}
+
+	// Tenant databases close after everything that might still use them
+	if cfg.tenancy != nil {
+		if w, ok := cfg.tenancy.Database.(BackgroundWorker); ok {
+			workers = append(workers, w)
+		}
+	}
+
 	// Add custom workers
 	workers = append(workers, cfg.workers...)
 
+	// Pick up vite rebuilds of the asset manifest in development
+	if cfg.cfg.IsDevelopment() {
+		manifests := cfg.vite.ManifestPaths
+		if len(manifests) == 0 {
+			manifests = inertia.DefaultManifestPaths
+		}
+		workers = append(workers, newTemplateWatcher(nil).watchManifests(manifests, inertia.ReloadManifest))
+	}
+
+	var walCheckpointer *WALCheckpointer
+	if cfg.walCheckpoint != nil && sqliteManager != nil {
+		walCheckpointer = NewWALCheckpointer(sqliteManager, server.GetLimiter(), logger, *cfg.walCheckpoint)
+		workers = append(workers, walCheckpointer)
+	}
+
 	// Create job dispatchers for each job group
 	for _, group := range cfg.jobGroups {
 		dispatcher := NewJobDispatcher(logger, dbManager, group.interval, group.processors...)
 		workers = append(workers, dispatcher)
 	}
 
+	// Purge expired sessions for server-side stores and audit records, and
+	// run database maintenance
+	var cronJobs []CronJob
+	if job, ok := sessions.CleanupJob(); ok {
+		cronJobs = append(cronJobs, job)
+	}
+	if auditStore != nil && cfg.audit.Retention > 0 {
+		cronJobs = append(cronJobs, AuditCleanup(auditStore, cfg.audit.Retention))
+	}
+	if cfg.maintenance != nil && sqliteManager != nil {
+		cronJobs = append(cronJobs, MaintenanceJobs(server.GetLimiter(), *cfg.maintenance)...)
+	}
+	if len(cronJobs) > 0 {
+		cron := NewCronManager(CronConfig{Logger: logger, DBManager: dbManager})
+		for _, job := range cronJobs {
+			if err := cron.Add(job); err != nil {
+				return nil, err
+			}
+		}
+		workers = append(workers, cron)
+	}
+
 	// Create application
 	application, err := NewApplication(ApplicationOptions{
 		Config:            cfg.cfg,
@@ -274,5 +666,10 @@ func NewInertiaApp(opts ...InertiaOption) (*InertiaApp, error) {
 		Application: application,
 		DBManager:   sqliteManager,
 		Session:     sessionMgr,
+		Sessions:    sessions,
+		WAL:         walCheckpointer,
+		Audit:       auditStore,
+		APITokens:   apiTokens,
+		Notifier:    notifier,
 	}, nil
 }
@@ -0,0 +1,54 @@
+package cartridge
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/karloscodes/cartridge/flash"
+)
+
+// InertiaErrorBagHeader names the error bag an Inertia form submits with
+// (useForm's errorBag option).
+const InertiaErrorBagHeader = "X-Inertia-Error-Bag"
+
+// InertiaValidationError sends the visitor back to the form with errors, the
+// way Inertia expects: a 303 redirect to the referring page, whose next
+// render gets the errors as its "errors" prop, so useForm().errors fills in.
+// With an error bag they are nested under its name.
+//
+//	var form SignupForm
+//	if err := ctx.BodyParser(&form); err != nil {
+//	    return err
+//	}
+//	var invalid cartridge.ValidationErrors
+//	if errors.As(cartridge.Validate(&form), &invalid) {
+//	    return ctx.InertiaValidationError(invalid.Fields())
+//	}
+func (ctx *Context) InertiaValidationError(errors map[string]string) error {
+	fields := make(map[string]any, len(errors))
+	for field, message := range errors {
+		fields[field] = message
+	}
+	stored := fields
+	if bag := ctx.Get(InertiaErrorBagHeader); bag != "" {
+		stored = map[string]any{bag: fields}
+	}
+	flash.SetErrors(ctx.Ctx, stored, ctx.Config != nil && ctx.Config.IsProduction())
+	return ctx.Redirect(ctx.backURL(), fiber.StatusSeeOther)
+}
+
+// backURL returns the path of the referring page when it is on this host,
+// else "/", so a forged Referer can't redirect off site.
+func (ctx *Context) backURL() string {
+	ref, err := url.Parse(ctx.Get(fiber.HeaderReferer))
+	if err != nil || ref.Path == "" || (ref.Host != "" && ref.Host != ctx.Hostname()) {
+		return "/"
+	}
+	back := ref.RequestURI()
+	if !strings.HasPrefix(back, "/") || strings.HasPrefix(back, "//") || strings.HasPrefix(back, "/\\") {
+		return "/"
+	}
+	return back
+}
@@ -0,0 +1,86 @@
+package cartridge
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/karloscodes/cartridge/flash"
+	"github.com/karloscodes/cartridge/inertia"
+)
+
+func TestContext_InertiaValidationError(t *testing.T) {
+	srv := newTemplateTestServer(t, nil)
+	srv.Post("/signup", func(ctx *Context) error {
+		return ctx.InertiaValidationError(map[string]string{"email": "email is required"})
+	})
+	srv.Get("/signup", func(ctx *Context) error {
+		return inertia.RenderPage(ctx.Ctx, "Signup", map[string]any{})
+	})
+
+	submit := func(referer, bag string) (location, cookie string) {
+		t.Helper()
+		req := httptest.NewRequest("POST", "http://example.com/signup", nil)
+		req.Header.Set("Referer", referer)
+		if bag != "" {
+			req.Header.Set(InertiaErrorBagHeader, bag)
+		}
+		resp, err := srv.App().Test(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusSeeOther {
+			t.Fatalf("expected 303, got %d", resp.StatusCode)
+		}
+		for _, c := range resp.Cookies() {
+			if c.Name == flash.ErrorsCookieName {
+				cookie = c.Name + "=" + c.Value
+			}
+		}
+		return resp.Header.Get("Location"), cookie
+	}
+	errorsProp := func(cookie string) map[string]any {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/signup", nil)
+		req.Header.Set("X-Inertia", "true")
+		req.Header.Set("Cookie", cookie)
+		resp, err := srv.App().Test(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		var page struct {
+			Props struct {
+				Errors map[string]any `json:"errors"`
+			} `json:"props"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+			t.Fatalf("decode failed: %v", err)
+		}
+		return page.Props.Errors
+	}
+
+	location, cookie := submit("http://example.com/signup?plan=pro", "")
+	if location != "/signup?plan=pro" {
+		t.Errorf("expected a redirect back to the form, got %q", location)
+	}
+	if got := errorsProp(cookie); got["email"] != "email is required" {
+		t.Errorf("expected the errors prop, got %v", got)
+	}
+	if got := errorsProp(""); got == nil || len(got) != 0 {
+		t.Errorf("expected an empty errors prop without errors, got %v", got)
+	}
+
+	_, cookie = submit("/signup", "createUser")
+	got := errorsProp(cookie)
+	if bag, _ := got["createUser"].(map[string]any); bag["email"] != "email is required" {
+		t.Errorf("expected the errors under the bag, got %v", got)
+	}
+
+	for _, referer := range []string{"https://evil.example/phish", "http://example.com//evil.example", ""} {
+		if location, _ := submit(referer, ""); location != "/" {
+			t.Errorf("%q: expected a redirect home, got %q", referer, location)
+		}
+	}
+}
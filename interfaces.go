@@ -43,3 +43,11 @@ type DBManager interface {
 	// Returns an error if the connection cannot be established.
 	Connect() (*gorm.DB, error)
 }
+
+// ReadDBManager is implemented by DBManagers that can route reads to replicas.
+// Context.ReadDB uses it when available and falls back to the primary otherwise.
+type ReadDBManager interface {
+	// ReadConnection returns a connection for read-only queries.
+	// Returns nil if the connection is unavailable.
+	ReadConnection() *gorm.DB
+}
@@ -0,0 +1,196 @@
+package cartridge
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// jobDurationBuckets are the histogram upper bounds, in seconds.
+var jobDurationBuckets = []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 15, 60, 300, 900}
+
+// JobMetrics summarizes the runs of one cron job or async handler since the
+// process started.
+type JobMetrics struct {
+	Name        string     `json:"name"`
+	Runs        int64      `json:"runs"`
+	Failures    int64      `json:"failures"`
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+	LastFailure *time.Time `json:"last_failure,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+
+	// DurationSum is the total run time in seconds.
+	DurationSum float64 `json:"duration_seconds_sum"`
+
+	// DurationBuckets counts runs per upper bound in seconds (cumulative,
+	// Prometheus style); runs over the last bound are only in Runs.
+	DurationBuckets []JobDurationBucket `json:"duration_buckets"`
+}
+
+// JobDurationBucket is one cumulative histogram bucket.
+type JobDurationBucket struct {
+	LE    float64 `json:"le"`
+	Count int64   `json:"count"`
+}
+
+// jobMetricsRecorder collects JobMetrics per name.
+type jobMetricsRecorder struct {
+	mu      sync.Mutex
+	metrics map[string]*jobMetricsEntry
+}
+
+type jobMetricsEntry struct {
+	JobMetrics
+	buckets []int64 // non-cumulative counts per jobDurationBuckets bound
+}
+
+// observe records a finished run.
+func (r *jobMetricsRecorder) observe(name string, duration time.Duration, finished time.Time, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.metrics == nil {
+		r.metrics = make(map[string]*jobMetricsEntry)
+	}
+	e := r.metrics[name]
+	if e == nil {
+		e = &jobMetricsEntry{JobMetrics: JobMetrics{Name: name}, buckets: make([]int64, len(jobDurationBuckets))}
+		r.metrics[name] = e
+	}
+
+	e.Runs++
+	seconds := duration.Seconds()
+	e.DurationSum += seconds
+	if i, _ := slices.BinarySearch(jobDurationBuckets, seconds); i < len(e.buckets) {
+		e.buckets[i]++
+	}
+	if err != nil {
+		e.Failures++
+		e.LastFailure = &finished
+		e.LastError = err.Error()
+		return
+	}
+	e.LastSuccess = &finished
+}
+
+// snapshot returns a copy of the metrics sorted by name.
+func (r *jobMetricsRecorder) snapshot() []JobMetrics {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make([]JobMetrics, 0, len(r.metrics))
+	for _, e := range r.metrics {
+		m := e.JobMetrics
+		m.DurationBuckets = make([]JobDurationBucket, len(jobDurationBuckets))
+		var cumulative int64
+		for i, le := range jobDurationBuckets {
+			cumulative += e.buckets[i]
+			m.DurationBuckets[i] = JobDurationBucket{LE: le, Count: cumulative}
+		}
+		out = append(out, m)
+	}
+	slices.SortFunc(out, func(a, b JobMetrics) int { return strings.Compare(a.Name, b.Name) })
+	return out
+}
+
+// JobsOverview is the state of background work, for dashboards and monitors.
+type JobsOverview struct {
+	Cron       []CronJobStatus `json:"cron"`
+	CronRuns   []JobMetrics    `json:"cron_metrics"`
+	AsyncRuns  []JobMetrics    `json:"async_metrics"`
+	AsyncQueue int             `json:"async_queue"`
+}
+
+// JobsOverview returns cron job status and run metrics for cron jobs and
+// async handlers. Serve it as JSON for an admin dashboard:
+//
+//	s.Get("/admin/jobs", func(ctx *cartridge.Context) error {
+//	    return ctx.JSON(app.JobsOverview())
+//	}, &cartridge.RouteConfig{Roles: []string{"admin"}})
+func (a *App) JobsOverview() JobsOverview {
+	overview := JobsOverview{
+		Cron:      []CronJobStatus{},
+		CronRuns:  []JobMetrics{},
+		AsyncRuns: []JobMetrics{},
+	}
+	if a.Cron != nil {
+		overview.Cron = a.Cron.Status()
+		overview.CronRuns = a.Cron.Metrics()
+	}
+	if a.Async != nil {
+		overview.AsyncRuns = a.Async.Metrics()
+		overview.AsyncQueue = a.Async.QueueLen()
+	}
+	return overview
+}
+
+// JobMetricsHandler serves cron and async metrics, route latency budget
+// counts and WAL metrics in the Prometheus text format. Mount it on an internal or
+// protected route:
+//
+//	s.App().Get("/metrics", app.JobMetricsHandler())
+func (a *App) JobMetricsHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		overview := a.JobsOverview()
+		c.Set(fiber.HeaderContentType, "text/plain; version=0.0.4; charset=utf-8")
+		var b strings.Builder
+		WriteJobMetrics(&b, "cron", overview.CronRuns)
+		WriteJobMetrics(&b, "async", overview.AsyncRuns)
+		if a.Async != nil {
+			fmt.Fprintf(&b, "# TYPE cartridge_async_queue_length gauge\ncartridge_async_queue_length %d\n", overview.AsyncQueue)
+		}
+		if a.Server != nil {
+			WriteLatencyBudgetMetrics(&b, a.Server.LatencyBudgetMetrics())
+		}
+		if a.WAL != nil {
+			WriteWALMetrics(&b, a.WAL.Metrics())
+		}
+		return c.SendString(b.String())
+	}
+}
+
+// WriteJobMetrics writes metrics in the Prometheus text format, labelled
+// with kind ("cron" or "async") and the job name.
+func WriteJobMetrics(w io.Writer, kind string, metrics []JobMetrics) {
+	if len(metrics) == 0 {
+		return
+	}
+	prefix := "cartridge_" + kind
+	label := func(m JobMetrics) string { return `name="` + promEscape(m.Name) + `"` }
+
+	fmt.Fprintf(w, "# TYPE %s_runs_total counter\n", prefix)
+	for _, m := range metrics {
+		fmt.Fprintf(w, "%s_runs_total{%s} %d\n", prefix, label(m), m.Runs)
+	}
+	fmt.Fprintf(w, "# TYPE %s_failures_total counter\n", prefix)
+	for _, m := range metrics {
+		fmt.Fprintf(w, "%s_failures_total{%s} %d\n", prefix, label(m), m.Failures)
+	}
+	fmt.Fprintf(w, "# TYPE %s_last_success_timestamp_seconds gauge\n", prefix)
+	for _, m := range metrics {
+		if m.LastSuccess != nil {
+			fmt.Fprintf(w, "%s_last_success_timestamp_seconds{%s} %d\n", prefix, label(m), m.LastSuccess.Unix())
+		}
+	}
+	fmt.Fprintf(w, "# TYPE %s_duration_seconds histogram\n", prefix)
+	for _, m := range metrics {
+		for _, bucket := range m.DurationBuckets {
+			le := strconv.FormatFloat(bucket.LE, 'g', -1, 64)
+			fmt.Fprintf(w, "%s_duration_seconds_bucket{%s,le=\"%s\"} %d\n", prefix, label(m), le, bucket.Count)
+		}
+		fmt.Fprintf(w, "%s_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", prefix, label(m), m.Runs)
+		fmt.Fprintf(w, "%s_duration_seconds_sum{%s} %g\n", prefix, label(m), m.DurationSum)
+		fmt.Fprintf(w, "%s_duration_seconds_count{%s} %d\n", prefix, label(m), m.Runs)
+	}
+}
+
+// promEscape escapes a Prometheus label value.
+func promEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
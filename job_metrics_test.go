@@ -0,0 +1,95 @@
+package cartridge
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestJobMetricsRecorder(t *testing.T) {
+	var r jobMetricsRecorder
+	now := time.Now()
+	r.observe("report", 30*time.Millisecond, now, nil)
+	r.observe("report", 2*time.Second, now, errors.New("boom"))
+	r.observe("report", time.Hour, now, nil)
+	r.observe("cleanup", time.Second, now, nil)
+
+	metrics := r.snapshot()
+	if len(metrics) != 2 || metrics[0].Name != "cleanup" {
+		t.Fatalf("expected metrics sorted by name, got %+v", metrics)
+	}
+	report := metrics[1]
+	if report.Runs != 3 || report.Failures != 1 || report.LastError != "boom" || report.LastSuccess == nil {
+		t.Errorf("unexpected counters %+v", report)
+	}
+
+	counts := map[float64]int64{}
+	for _, b := range report.DurationBuckets {
+		counts[b.LE] = b.Count
+	}
+	// Buckets are cumulative; the hour-long run only shows in +Inf (Runs)
+	if counts[0.01] != 0 || counts[0.05] != 1 || counts[5] != 2 || counts[900] != 2 {
+		t.Errorf("unexpected buckets %v", counts)
+	}
+}
+
+func TestApp_JobsOverview(t *testing.T) {
+	m := NewAsyncManager(AsyncConfig{Logger: testLogger()})
+	m.Register("resize", func(ctx *JobContext, payload json.RawMessage) (any, error) {
+		if string(payload) == `"bad"` {
+			return nil, errors.New("unsupported format")
+		}
+		return nil, nil
+	})
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer m.Stop()
+
+	for _, payload := range []string{"ok", "bad"} {
+		if _, err := m.Run("resize", payload); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+	}
+
+	app := &App{Async: m}
+	deadline := time.Now().Add(2 * time.Second)
+	var overview JobsOverview
+	for time.Now().Before(deadline) {
+		overview = app.JobsOverview()
+		if len(overview.AsyncRuns) == 1 && overview.AsyncRuns[0].Runs == 2 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(overview.AsyncRuns) != 1 || overview.AsyncRuns[0].Runs != 2 || overview.AsyncRuns[0].Failures != 1 {
+		t.Fatalf("unexpected async metrics %+v", overview.AsyncRuns)
+	}
+	if overview.Cron == nil || overview.CronRuns == nil {
+		t.Error("expected empty cron lists rather than nil without a cron manager")
+	}
+
+	srv := fiber.New()
+	srv.Get("/metrics", app.JobMetricsHandler())
+	resp, err := srv.Test(httptest.NewRequest("GET", "/metrics", nil))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	for _, want := range []string{
+		`cartridge_async_runs_total{name="resize"} 2`,
+		`cartridge_async_failures_total{name="resize"} 1`,
+		`cartridge_async_duration_seconds_bucket{name="resize",le="+Inf"} 2`,
+		`cartridge_async_queue_length 0`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("expected metrics output to contain %q, got:\n%s", want, body)
+		}
+	}
+}
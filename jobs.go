@@ -13,6 +13,8 @@ type JobContext struct {
 	context.Context
 	Logger Logger
 	DB     *gorm.DB
+
+	cache *Cache // application cache for Cache(); nil uses the process default
 }
 
 // Processor defines the interface for processing a batch of work.
@@ -0,0 +1,262 @@
+package cartridge
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// JobsAPIPath is where the jobs management API is mounted. The version is
+// part of the path so clients keep working when the API evolves.
+//
+//	GET  /_jobs/v1/tasks                 list tasks (?status=&name=&limit=&offset=)
+//	GET  /_jobs/v1/tasks/:id             task detail with payload and result
+//	POST /_jobs/v1/tasks/:id/cancel      cancel a pending or running task
+//	POST /_jobs/v1/tasks/:id/requeue     re-queue a failed or canceled task
+//	GET  /_jobs/v1/cron                  cron jobs with their next runs (?next=)
+//	GET  /_jobs/v1/cron/:id/runs         recent runs of a cron job (?limit=)
+//	POST /_jobs/v1/cron/:id/trigger      run a cron job now
+//	POST /_jobs/v1/cron/:id/pause        stop a cron job's scheduled runs
+//	POST /_jobs/v1/cron/:id/resume       restart a paused cron job
+const JobsAPIPath = "/_jobs/v1"
+
+// JobsAPIConfig configures the jobs management API.
+type JobsAPIConfig struct {
+	// Token authenticates callers, who send "Authorization: Bearer <token>". Required.
+	Token string
+
+	// Async exposes its tasks under /tasks. Optional.
+	Async *AsyncManager
+
+	// Cron exposes its jobs under /cron. Optional.
+	Cron *CronManager
+}
+
+// jobsAPITask is a task as served by the jobs API.
+type jobsAPITask struct {
+	ID         string          `json:"id"`
+	Name       string          `json:"name"`
+	Status     AsyncTaskStatus `json:"status"`
+	Priority   int             `json:"priority"`
+	Attempts   int             `json:"attempts"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+	Result     json.RawMessage `json:"result,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	StartedAt  *time.Time      `json:"started_at,omitempty"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+}
+
+// jobsAPICronJob is a cron job as served by the jobs API.
+type jobsAPICronJob struct {
+	CronJobStatus
+	NextRuns []time.Time `json:"next_runs"`
+}
+
+// SetJobsAPI mounts the jobs management API at JobsAPIPath. Call it before
+// mounting routes that could shadow it.
+func (s *Server) SetJobsAPI(cfg JobsAPIConfig) error {
+	if cfg.Token == "" {
+		return fmt.Errorf("cartridge: jobs API requires a token")
+	}
+	api := s.app.Group(JobsAPIPath, jobsAPIAuth(cfg.Token))
+
+	if m := cfg.Async; m != nil {
+		api.Get("/tasks", jobsAPIListTasks(m))
+		api.Get("/tasks/:id", jobsAPIGetTask(m))
+		api.Post("/tasks/:id/cancel", jobsAPICancelTask(m))
+		api.Post("/tasks/:id/requeue", jobsAPIRequeueTask(m))
+	}
+	if m := cfg.Cron; m != nil {
+		api.Get("/cron", jobsAPIListCron(m))
+		api.Get("/cron/:id/runs", jobsAPICronRuns(m))
+		api.Post("/cron/:id/trigger", jobsAPITriggerCron(m))
+		api.Post("/cron/:id/pause", jobsAPIPauseCron(m, true))
+		api.Post("/cron/:id/resume", jobsAPIPauseCron(m, false))
+	}
+	return nil
+}
+
+// jobsAPIAuth rejects requests without the bearer token.
+func jobsAPIAuth(token string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		got, ok := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="jobs"`)
+			return ErrUnauthorized("invalid or missing jobs API token")
+		}
+		return c.Next()
+	}
+}
+
+func jobsAPIListTasks(m *AsyncManager) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		filter := AsyncTaskFilter{
+			Name:   c.Query("name"),
+			Status: AsyncTaskStatus(c.Query("status")),
+			Limit:  min(c.QueryInt("limit", 50), 500),
+			Offset: max(c.QueryInt("offset"), 0),
+		}
+		switch filter.Status {
+		case "", AsyncPending, AsyncRunning, AsyncCompleted, AsyncFailed, AsyncCanceled:
+		default:
+			return ErrBadRequest(fmt.Sprintf("unknown task status %q", filter.Status))
+		}
+		if filter.Limit <= 0 {
+			filter.Limit = 50
+		}
+
+		tasks, err := m.List(filter)
+		if err != nil {
+			return err
+		}
+		out := make([]jobsAPITask, len(tasks))
+		for i := range tasks {
+			out[i] = newJobsAPITask(&tasks[i], false)
+		}
+		return c.JSON(fiber.Map{
+			"tasks":        out,
+			"queue_length": m.QueueLen(),
+		})
+	}
+}
+
+func jobsAPIGetTask(m *AsyncManager) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		task, err := m.Get(c.Params("id"))
+		if err != nil {
+			return jobsAPIError(err)
+		}
+		return c.JSON(newJobsAPITask(task, true))
+	}
+}
+
+func jobsAPICancelTask(m *AsyncManager) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Params("id")
+		if err := m.Cancel(id); err != nil {
+			return jobsAPIError(err)
+		}
+		task, err := m.Get(id)
+		if err != nil {
+			return jobsAPIError(err)
+		}
+		return c.JSON(newJobsAPITask(task, true))
+	}
+}
+
+func jobsAPIRequeueTask(m *AsyncManager) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Params("id")
+		if err := m.Retry(id); err != nil {
+			return jobsAPIError(err)
+		}
+		task, err := m.Get(id)
+		if err != nil {
+			return jobsAPIError(err)
+		}
+		return c.Status(fiber.StatusAccepted).JSON(newJobsAPITask(task, true))
+	}
+}
+
+func jobsAPIListCron(m *CronManager) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		next := min(max(c.QueryInt("next", 5), 0), 50)
+		statuses := m.Status()
+		jobs := make([]jobsAPICronJob, len(statuses))
+		for i, status := range statuses {
+			runs, err := m.NextRuns(status.ID, next)
+			if err != nil {
+				return err
+			}
+			jobs[i] = jobsAPICronJob{CronJobStatus: status, NextRuns: runs}
+		}
+		return c.JSON(fiber.Map{"jobs": jobs})
+	}
+}
+
+func jobsAPICronRuns(m *CronManager) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		runs, err := m.History(c.Params("id"), min(c.QueryInt("limit", 20), 500))
+		if err != nil {
+			return jobsAPIError(err)
+		}
+		return c.JSON(fiber.Map{"runs": runs})
+	}
+}
+
+func jobsAPITriggerCron(m *CronManager) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Params("id")
+		if err := m.Trigger(id); err != nil {
+			return jobsAPIError(err)
+		}
+		return jobsAPICronStatus(c.Status(fiber.StatusAccepted), m, id)
+	}
+}
+
+func jobsAPIPauseCron(m *CronManager, pause bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Params("id")
+		update := m.Resume
+		if pause {
+			update = m.Pause
+		}
+		if err := update(id); err != nil {
+			return jobsAPIError(err)
+		}
+		return jobsAPICronStatus(c, m, id)
+	}
+}
+
+// jobsAPICronStatus responds with the status of a cron job.
+func jobsAPICronStatus(c *fiber.Ctx, m *CronManager, id string) error {
+	for _, status := range m.Status() {
+		if status.ID == id {
+			return c.JSON(status)
+		}
+	}
+	return ErrNotFound("cron job")
+}
+
+// jobsAPIError maps manager errors to HTTP errors.
+func jobsAPIError(err error) error {
+	switch {
+	case errors.Is(err, ErrAsyncTaskNotFound):
+		return ErrNotFound("task")
+	case errors.Is(err, ErrCronJobNotFound):
+		return ErrNotFound("cron job")
+	case errors.Is(err, ErrAsyncTaskState), errors.Is(err, ErrCronJobRunning):
+		return ErrConflict(err.Error()).Wrap(err)
+	case errors.Is(err, ErrAsyncNotRunning), errors.Is(err, ErrCronNotRunning), errors.Is(err, ErrAsyncQueueFull):
+		return NewError(fiber.StatusServiceUnavailable, err.Error()).Wrap(err)
+	}
+	return err
+}
+
+// newJobsAPITask converts a task, with its payload and result when detailed.
+func newJobsAPITask(task *AsyncTask, detailed bool) jobsAPITask {
+	out := jobsAPITask{
+		ID:         task.ID,
+		Name:       task.Name,
+		Status:     task.Status,
+		Priority:   task.Priority,
+		Attempts:   task.Attempts,
+		Error:      task.Error,
+		CreatedAt:  task.CreatedAt,
+		StartedAt:  task.StartedAt,
+		FinishedAt: task.FinishedAt,
+	}
+	if detailed && task.Payload != "" {
+		out.Payload = json.RawMessage(task.Payload)
+	}
+	if detailed && task.Result != "" {
+		out.Result = json.RawMessage(task.Result)
+	}
+	return out
+}
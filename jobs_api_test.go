@@ -0,0 +1,204 @@
+package cartridge
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestJobsAPI(t *testing.T) {
+	cfg := DefaultServerConfig()
+	cfg.EnableStaticAssets = false
+	cfg.EnableRequestLogger = false
+	cfg.EnableSecFetchSite = false
+	cfg.Config = &testConfig{}
+	cfg.Logger = testLogger()
+	cfg.DBManager = &testDBManager{}
+	cfg.ErrorHandler = DefaultErrorHandler(testLogger(), false)
+
+	srv, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	async := NewAsyncManager(AsyncConfig{Logger: testLogger(), Workers: 1})
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	async.Register("block", func(ctx *JobContext, payload json.RawMessage) (any, error) {
+		started <- struct{}{}
+		<-release
+		return "done", nil
+	})
+	async.Register("echo", func(ctx *JobContext, payload json.RawMessage) (any, error) {
+		return payload, nil
+	})
+	if err := async.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer async.Stop()
+	defer close(release)
+
+	ran := make(chan struct{}, 1)
+	cron := NewCronManager(CronConfig{Logger: testLogger()})
+	if err := cron.Add(CronJob{ID: "report", Schedule: "0 3 * * *", Handler: func(ctx *JobContext) error {
+		ran <- struct{}{}
+		return nil
+	}}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := cron.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer cron.Stop()
+
+	if err := srv.SetJobsAPI(JobsAPIConfig{Async: async, Cron: cron}); err == nil {
+		t.Fatal("expected an error without a token")
+	}
+	if err := srv.SetJobsAPI(JobsAPIConfig{Token: "secret", Async: async, Cron: cron}); err != nil {
+		t.Fatalf("SetJobsAPI failed: %v", err)
+	}
+
+	do := func(method, path, token string) (int, map[string]any) {
+		t.Helper()
+		req, _ := http.NewRequest(method, JobsAPIPath+path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := srv.App().Test(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		var out map[string]any
+		_ = json.Unmarshal(body, &out)
+		return resp.StatusCode, out
+	}
+
+	if status, _ := do("GET", "/tasks", ""); status != fiber.StatusUnauthorized {
+		t.Errorf("expected 401 without token, got %d", status)
+	}
+	if status, _ := do("GET", "/tasks", "wrong"); status != fiber.StatusUnauthorized {
+		t.Errorf("expected 401 with a wrong token, got %d", status)
+	}
+
+	// One task holds the only worker so the next one stays queued
+	if _, err := async.Run("block", nil); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	<-started
+	queued, err := async.Run("echo", map[string]int{"n": 1})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	t.Run("lists and filters tasks", func(t *testing.T) {
+		status, body := do("GET", "/tasks?status=pending", "secret")
+		tasks, _ := body["tasks"].([]any)
+		if status != fiber.StatusOK || len(tasks) != 1 || body["queue_length"] != float64(1) {
+			t.Fatalf("unexpected response %d: %v", status, body)
+		}
+		if task := tasks[0].(map[string]any); task["id"] != queued || task["payload"] != nil {
+			t.Errorf("expected the queued task without its payload, got %v", task)
+		}
+		if status, _ := do("GET", "/tasks?status=bogus", "secret"); status != fiber.StatusBadRequest {
+			t.Errorf("expected 400 for an unknown status, got %d", status)
+		}
+	})
+
+	t.Run("cancels and requeues a task", func(t *testing.T) {
+		status, body := do("POST", "/tasks/"+queued+"/cancel", "secret")
+		if status != fiber.StatusOK || body["status"] != string(AsyncCanceled) {
+			t.Fatalf("unexpected cancel response %d: %v", status, body)
+		}
+		if async.QueueLen() != 0 {
+			t.Errorf("expected the task to leave the queue, got %d queued", async.QueueLen())
+		}
+		if status, _ := do("POST", "/tasks/"+queued+"/cancel", "secret"); status != fiber.StatusConflict {
+			t.Errorf("expected 409 canceling a canceled task, got %d", status)
+		}
+
+		status, body = do("POST", "/tasks/"+queued+"/requeue", "secret")
+		if status != fiber.StatusAccepted || body["status"] != string(AsyncPending) {
+			t.Fatalf("unexpected requeue response %d: %v", status, body)
+		}
+		release <- struct{}{}
+		waitForAsyncStatus(t, async, queued, AsyncCompleted)
+
+		status, body = do("GET", "/tasks/"+queued, "secret")
+		result, _ := body["result"].(map[string]any)
+		if status != fiber.StatusOK || result["n"] != float64(1) {
+			t.Errorf("expected the task result, got %d: %v", status, body)
+		}
+		if status, _ := do("GET", "/tasks/unknown", "secret"); status != fiber.StatusNotFound {
+			t.Errorf("expected 404 for an unknown task, got %d", status)
+		}
+	})
+
+	t.Run("lists and triggers cron jobs", func(t *testing.T) {
+		status, body := do("GET", "/cron?next=3", "secret")
+		jobs, _ := body["jobs"].([]any)
+		if status != fiber.StatusOK || len(jobs) != 1 {
+			t.Fatalf("unexpected response %d: %v", status, body)
+		}
+		if next, _ := jobs[0].(map[string]any)["next_runs"].([]any); len(next) != 3 {
+			t.Errorf("expected 3 next runs, got %v", jobs[0])
+		}
+
+		if status, _ := do("POST", "/cron/report/trigger", "secret"); status != fiber.StatusAccepted {
+			t.Fatalf("expected 202 triggering a job, got %d", status)
+		}
+		select {
+		case <-ran:
+		case <-time.After(2 * time.Second):
+			t.Fatal("triggered job did not run")
+		}
+		if status, _ := do("POST", "/cron/missing/trigger", "secret"); status != fiber.StatusNotFound {
+			t.Errorf("expected 404 for an unknown job, got %d", status)
+		}
+
+		status, body = do("POST", "/cron/report/pause", "secret")
+		if status != fiber.StatusOK || body["paused"] != true || body["next_run"] != nil {
+			t.Errorf("expected the job paused without a next run, got %d: %v", status, body)
+		}
+		status, body = do("POST", "/cron/report/resume", "secret")
+		if status != fiber.StatusOK || body["paused"] != false {
+			t.Errorf("expected the job resumed, got %d: %v", status, body)
+		}
+		if status, _ := do("POST", "/cron/missing/pause", "secret"); status != fiber.StatusNotFound {
+			t.Errorf("expected 404 pausing an unknown job, got %d", status)
+		}
+	})
+}
+
+func TestAsyncManager_CancelRunning(t *testing.T) {
+	m := NewAsyncManager(AsyncConfig{Logger: testLogger()})
+	started := make(chan struct{})
+	m.Register("wait", func(ctx *JobContext, payload json.RawMessage) (any, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer m.Stop()
+
+	id, err := m.Run("wait", nil)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	<-started
+	if err := m.Cancel(id); err != nil {
+		t.Fatalf("Cancel failed: %v", err)
+	}
+	waitForAsyncStatus(t, m, id, AsyncCanceled)
+
+	if err := m.Cancel(id); !errors.Is(err, ErrAsyncTaskState) {
+		t.Errorf("expected ErrAsyncTaskState, got %v", err)
+	}
+}
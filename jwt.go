@@ -0,0 +1,380 @@
+package cartridge
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// JWT signing algorithms.
+const (
+	JWTAlgHS256 = "HS256"
+	JWTAlgRS256 = "RS256"
+)
+
+// Errors returned when verifying a JWT.
+var (
+	ErrJWTMissing   = errors.New("cartridge: missing token")
+	ErrJWTMalformed = errors.New("cartridge: malformed token")
+	ErrJWTSignature = errors.New("cartridge: invalid token signature")
+	ErrJWTExpired   = errors.New("cartridge: token expired")
+	ErrJWTNoExpiry  = errors.New("cartridge: token has no expiry")
+	ErrJWTNotYet    = errors.New("cartridge: token not valid yet")
+	ErrJWTIssuer    = errors.New("cartridge: unexpected token issuer")
+)
+
+// JWTClaims are the claims of a token. Numeric claims decode as float64.
+type JWTClaims map[string]any
+
+// Subject returns the "sub" claim.
+func (c JWTClaims) Subject() string {
+	s, _ := c["sub"].(string)
+	return s
+}
+
+// ExpiresAt returns the "exp" claim, or the zero time if absent.
+func (c JWTClaims) ExpiresAt() time.Time {
+	return c.time("exp")
+}
+
+func (c JWTClaims) time(key string) time.Time {
+	switch v := c[key].(type) {
+	case float64:
+		return time.Unix(int64(v), 0)
+	case int64:
+		return time.Unix(v, 0)
+	case int:
+		return time.Unix(int64(v), 0)
+	case json.Number:
+		n, _ := v.Int64()
+		return time.Unix(n, 0)
+	}
+	return time.Time{}
+}
+
+// JWTConfig configures JWT signing and verification.
+type JWTConfig struct {
+	// Algorithm is HS256 or RS256. Default: HS256.
+	Algorithm string
+
+	// Secret is the HMAC key for HS256.
+	Secret []byte
+
+	// PrivateKey signs RS256 tokens. Optional for verification-only setups.
+	PrivateKey *rsa.PrivateKey
+
+	// PublicKey verifies RS256 tokens. Default: PrivateKey's public key.
+	PublicKey *rsa.PublicKey
+
+	// TTL is the lifetime of signed tokens. Default: 15 minutes.
+	TTL time.Duration
+
+	// Issuer is set as "iss" when signing and required when verifying. Optional.
+	Issuer string
+
+	// Leeway tolerates clock skew when checking exp and nbf. Default: 0.
+	Leeway time.Duration
+
+	// TokenLookup lists where to find the token, tried in order:
+	// "header:<name>", "cookie:<name>" or "query:<name>", comma-separated.
+	// Header tokens may use the "Bearer " prefix. Default: "header:Authorization".
+	TokenLookup string
+
+	// RefreshWithin reissues tokens that expire within this window. The new
+	// token is sent in the X-Refresh-Token header (and the cookie, when the
+	// token came from one). Default: 0 (disabled).
+	RefreshWithin time.Duration
+}
+
+// JWTAuth signs and verifies JSON Web Tokens.
+type JWTAuth struct {
+	cfg     JWTConfig
+	lookups [][2]string
+}
+
+// NewJWTAuth validates the configuration and creates a JWTAuth.
+func NewJWTAuth(cfg JWTConfig) (*JWTAuth, error) {
+	if cfg.Algorithm == "" {
+		cfg.Algorithm = JWTAlgHS256
+	}
+	switch cfg.Algorithm {
+	case JWTAlgHS256:
+		if len(cfg.Secret) == 0 {
+			return nil, errors.New("cartridge: JWT secret is required for HS256")
+		}
+	case JWTAlgRS256:
+		if cfg.PublicKey == nil && cfg.PrivateKey != nil {
+			cfg.PublicKey = &cfg.PrivateKey.PublicKey
+		}
+		if cfg.PublicKey == nil {
+			return nil, errors.New("cartridge: JWT public or private key is required for RS256")
+		}
+	default:
+		return nil, fmt.Errorf("cartridge: unsupported JWT algorithm %q", cfg.Algorithm)
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = 15 * time.Minute
+	}
+	if cfg.TokenLookup == "" {
+		cfg.TokenLookup = "header:Authorization"
+	}
+
+	var lookups [][2]string
+	for _, part := range strings.Split(cfg.TokenLookup, ",") {
+		source, name, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok || name == "" || (source != "header" && source != "cookie" && source != "query") {
+			return nil, fmt.Errorf("cartridge: invalid JWT token lookup %q", part)
+		}
+		lookups = append(lookups, [2]string{source, name})
+	}
+
+	return &JWTAuth{cfg: cfg, lookups: lookups}, nil
+}
+
+// JWT returns middleware that requires a valid token, using cfg.
+// It panics if cfg is invalid; use NewJWTAuth to handle the error.
+func JWT(cfg JWTConfig) fiber.Handler {
+	auth, err := NewJWTAuth(cfg)
+	if err != nil {
+		panic(err)
+	}
+	return auth.Middleware()
+}
+
+// jwtLocalsKey and jwtClaimsLocalsKey store the JWTAuth and verified claims.
+const (
+	jwtLocalsKey       = "cartridge_jwt"
+	jwtClaimsLocalsKey = "cartridge_jwt_claims"
+)
+
+// Provide returns middleware that makes the JWTAuth available to ctx.SignJWT
+// without requiring a token, e.g. for login routes.
+func (j *JWTAuth) Provide() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Locals(jwtLocalsKey, j)
+		return c.Next()
+	}
+}
+
+// Middleware rejects requests without a valid token with 401 Unauthorized.
+// Verified claims are available through ctx.JWTClaims().
+func (j *JWTAuth) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Locals(jwtLocalsKey, j)
+
+		token, source, name := j.extract(c)
+		if token == "" {
+			return fiber.NewError(fiber.StatusUnauthorized, "missing token")
+		}
+		claims, err := j.Verify(token)
+		if err != nil {
+			return fiber.NewError(fiber.StatusUnauthorized, "invalid token")
+		}
+		c.Locals(jwtClaimsLocalsKey, claims)
+
+		if j.cfg.RefreshWithin > 0 && time.Until(claims.ExpiresAt()) < j.cfg.RefreshWithin {
+			if err := j.refresh(c, claims, source, name); err != nil {
+				return err
+			}
+		}
+		return c.Next()
+	}
+}
+
+// refresh issues a new token with the same claims and a fresh expiry.
+func (j *JWTAuth) refresh(c *fiber.Ctx, claims JWTClaims, source, name string) error {
+	fresh := make(JWTClaims, len(claims))
+	for k, v := range claims {
+		fresh[k] = v
+	}
+	delete(fresh, "exp")
+	delete(fresh, "iat")
+
+	token, err := j.Sign(fresh)
+	if err != nil {
+		return err
+	}
+	c.Set("X-Refresh-Token", token)
+	if source == "cookie" {
+		c.Cookie(&fiber.Cookie{
+			Name:     name,
+			Value:    token,
+			Path:     "/",
+			Expires:  time.Now().Add(j.cfg.TTL),
+			Secure:   c.Protocol() == "https",
+			HTTPOnly: true,
+			SameSite: "Lax",
+		})
+	}
+	return nil
+}
+
+// extract finds the token using the configured lookups.
+func (j *JWTAuth) extract(c *fiber.Ctx) (token, source, name string) {
+	for _, l := range j.lookups {
+		source, name = l[0], l[1]
+		switch source {
+		case "header":
+			token = strings.TrimSpace(c.Get(name))
+			if len(token) > 7 && strings.EqualFold(token[:7], "bearer ") {
+				token = strings.TrimSpace(token[7:])
+			}
+		case "cookie":
+			token = c.Cookies(name)
+		case "query":
+			token = c.Query(name)
+		}
+		if token != "" {
+			return token, source, name
+		}
+	}
+	return "", "", ""
+}
+
+// Sign returns a signed token for claims. "exp", "iat" and "iss" are set
+// from the configuration unless already present.
+func (j *JWTAuth) Sign(claims JWTClaims) (string, error) {
+	if j.cfg.Algorithm == JWTAlgRS256 && j.cfg.PrivateKey == nil {
+		return "", errors.New("cartridge: JWT private key is required to sign RS256 tokens")
+	}
+
+	now := time.Now()
+	payload := make(JWTClaims, len(claims)+3)
+	for k, v := range claims {
+		payload[k] = v
+	}
+	if _, ok := payload["iat"]; !ok {
+		payload["iat"] = now.Unix()
+	}
+	if _, ok := payload["exp"]; !ok {
+		payload["exp"] = now.Add(j.cfg.TTL).Unix()
+	}
+	if _, ok := payload["iss"]; !ok && j.cfg.Issuer != "" {
+		payload["iss"] = j.cfg.Issuer
+	}
+
+	header, err := json.Marshal(map[string]string{"alg": j.cfg.Algorithm, "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("cartridge: encode JWT claims: %w", err)
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(body)
+	sig, err := j.sign([]byte(signingInput))
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// Verify checks the token's algorithm, signature, expiry and issuer and
+// returns its claims. Tokens without an "exp" claim are rejected, since they
+// would never expire.
+func (j *JWTAuth) Verify(token string) (JWTClaims, error) {
+	if token == "" {
+		return nil, ErrJWTMissing
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrJWTMalformed
+	}
+
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrJWTMalformed
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return nil, ErrJWTMalformed
+	}
+	// Only the configured algorithm is accepted (prevents alg confusion)
+	if header.Alg != j.cfg.Algorithm {
+		return nil, ErrJWTSignature
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrJWTMalformed
+	}
+	if !j.verify([]byte(parts[0]+"."+parts[1]), sig) {
+		return nil, ErrJWTSignature
+	}
+
+	body, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrJWTMalformed
+	}
+	var claims JWTClaims
+	if err := json.Unmarshal(body, &claims); err != nil {
+		return nil, ErrJWTMalformed
+	}
+
+	now := time.Now()
+	exp := claims.ExpiresAt()
+	if exp.IsZero() {
+		return nil, ErrJWTNoExpiry
+	}
+	if now.After(exp.Add(j.cfg.Leeway)) {
+		return nil, ErrJWTExpired
+	}
+	if nbf := claims.time("nbf"); !nbf.IsZero() && now.Add(j.cfg.Leeway).Before(nbf) {
+		return nil, ErrJWTNotYet
+	}
+	if j.cfg.Issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != j.cfg.Issuer {
+			return nil, ErrJWTIssuer
+		}
+	}
+	return claims, nil
+}
+
+func (j *JWTAuth) sign(input []byte) ([]byte, error) {
+	if j.cfg.Algorithm == JWTAlgHS256 {
+		mac := hmac.New(sha256.New, j.cfg.Secret)
+		mac.Write(input)
+		return mac.Sum(nil), nil
+	}
+	digest := sha256.Sum256(input)
+	return rsa.SignPKCS1v15(rand.Reader, j.cfg.PrivateKey, crypto.SHA256, digest[:])
+}
+
+func (j *JWTAuth) verify(input, sig []byte) bool {
+	if j.cfg.Algorithm == JWTAlgHS256 {
+		mac := hmac.New(sha256.New, j.cfg.Secret)
+		mac.Write(input)
+		return hmac.Equal(mac.Sum(nil), sig)
+	}
+	digest := sha256.Sum256(input)
+	return rsa.VerifyPKCS1v15(j.cfg.PublicKey, crypto.SHA256, digest[:], sig) == nil
+}
+
+// SignJWT signs claims with the JWTAuth installed by JWTAuth.Provide,
+// JWTAuth.Middleware or WithJWT.
+func (ctx *Context) SignJWT(claims JWTClaims) (string, error) {
+	auth, ok := ctx.Locals(jwtLocalsKey).(*JWTAuth)
+	if !ok {
+		return "", errors.New("cartridge: JWT is not configured for this route")
+	}
+	return auth.Sign(claims)
+}
+
+// JWTClaims returns the claims verified by the JWT middleware, or nil.
+func (ctx *Context) JWTClaims() JWTClaims {
+	claims, _ := ctx.Locals(jwtClaimsLocalsKey).(JWTClaims)
+	return claims
+}
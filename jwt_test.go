@@ -0,0 +1,132 @@
+package cartridge
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestJWTAuth_SignVerify(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	configs := map[string]JWTConfig{
+		"HS256": {Secret: []byte("test-secret"), Issuer: "cartridge"},
+		"RS256": {Algorithm: JWTAlgRS256, PrivateKey: key, Issuer: "cartridge"},
+	}
+	for name, cfg := range configs {
+		t.Run(name, func(t *testing.T) {
+			auth, err := NewJWTAuth(cfg)
+			if err != nil {
+				t.Fatalf("NewJWTAuth failed: %v", err)
+			}
+			token, err := auth.Sign(JWTClaims{"sub": "42"})
+			if err != nil {
+				t.Fatalf("Sign failed: %v", err)
+			}
+			claims, err := auth.Verify(token)
+			if err != nil {
+				t.Fatalf("Verify failed: %v", err)
+			}
+			if claims.Subject() != "42" || claims["iss"] != "cartridge" {
+				t.Errorf("unexpected claims %v", claims)
+			}
+			if _, err := auth.Verify(token[:len(token)-2] + "xx"); err != ErrJWTSignature {
+				t.Errorf("expected ErrJWTSignature for tampered token, got %v", err)
+			}
+		})
+	}
+}
+
+func TestJWTAuth_Verify(t *testing.T) {
+	auth, _ := NewJWTAuth(JWTConfig{Secret: []byte("test-secret")})
+	other, _ := NewJWTAuth(JWTConfig{Secret: []byte("other-secret")})
+
+	expired, _ := auth.Sign(JWTClaims{"exp": time.Now().Add(-time.Minute).Unix()})
+	if _, err := auth.Verify(expired); err != ErrJWTExpired {
+		t.Errorf("expected ErrJWTExpired, got %v", err)
+	}
+
+	// Tokens that never expire are rejected
+	unbounded, _ := auth.Sign(JWTClaims{"sub": "1", "exp": nil})
+	if _, err := auth.Verify(unbounded); err != ErrJWTNoExpiry {
+		t.Errorf("expected ErrJWTNoExpiry, got %v", err)
+	}
+
+	foreign, _ := other.Sign(JWTClaims{"sub": "1"})
+	if _, err := auth.Verify(foreign); err != ErrJWTSignature {
+		t.Errorf("expected ErrJWTSignature, got %v", err)
+	}
+
+	// "alg":"none" tokens are rejected
+	if _, err := auth.Verify("eyJhbGciOiJub25lIn0.eyJzdWIiOiIxIn0."); err != ErrJWTSignature {
+		t.Errorf("expected ErrJWTSignature for alg none, got %v", err)
+	}
+
+	if _, err := NewJWTAuth(JWTConfig{}); err == nil {
+		t.Error("expected error without secret")
+	}
+}
+
+func TestJWTMiddleware(t *testing.T) {
+	cfg := JWTConfig{
+		Secret:        []byte("test-secret"),
+		TokenLookup:   "header:Authorization,cookie:jwt,query:token",
+		RefreshWithin: time.Hour,
+		TTL:           30 * time.Minute,
+	}
+	auth, _ := NewJWTAuth(cfg)
+
+	app := fiber.New()
+	app.Get("/me", JWT(cfg), func(c *fiber.Ctx) error {
+		return c.SendString((&Context{Ctx: c}).JWTClaims().Subject())
+	})
+
+	token, _ := auth.Sign(JWTClaims{"sub": "7"})
+
+	requests := map[string]*http.Request{
+		"header": httpGet("/me"),
+		"cookie": httpGet("/me"),
+		"query":  httpGet("/me?token=" + token),
+	}
+	requests["header"].Header.Set("Authorization", "Bearer "+token)
+	requests["cookie"].AddCookie(&http.Cookie{Name: "jwt", Value: token})
+
+	for name, req := range requests {
+		t.Run(name, func(t *testing.T) {
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != fiber.StatusOK || string(body) != "7" {
+				t.Errorf("expected 200 with subject 7, got %d %q", resp.StatusCode, body)
+			}
+			// TTL is within the refresh window, so a fresh token is issued
+			if resp.Header.Get("X-Refresh-Token") == "" {
+				t.Error("expected X-Refresh-Token header")
+			}
+		})
+	}
+
+	resp, _ := app.Test(httpGet("/me"))
+	if resp.StatusCode != fiber.StatusUnauthorized {
+		t.Errorf("expected 401 without token, got %d", resp.StatusCode)
+	}
+
+	// A token without exp is neither accepted nor refreshed
+	unbounded, _ := auth.Sign(JWTClaims{"sub": "7", "exp": nil})
+	req := httpGet("/me")
+	req.Header.Set("Authorization", "Bearer "+unbounded)
+	resp, _ = app.Test(req)
+	if resp.StatusCode != fiber.StatusUnauthorized || resp.Header.Get("X-Refresh-Token") != "" {
+		t.Errorf("expected 401 without a refresh for a token without exp, got %d", resp.StatusCode)
+	}
+}
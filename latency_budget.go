@@ -0,0 +1,185 @@
+package cartridge
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Phases of a route's chain, reported when it exceeds RouteConfig.LatencyBudget.
+const (
+	phaseMiddleware = "middleware" // Sec-Fetch-Site, CSRF, ETag, CORS, write concurrency
+	phaseCustom     = "custom_middleware"
+	phaseAuthorize  = "authorize"
+	phaseRateLimit  = "rate_limit"
+	phaseValidate   = "validate" // feature flag and payload validation
+	phaseCache      = "cache"
+	phaseHandler    = "handler"
+)
+
+const latencyBudgetLocalsKey = "cartridge_latency_budget"
+
+// routeTimings records when a request entered each phase of its route.
+type routeTimings struct {
+	phases []string
+	starts []time.Time
+}
+
+func (t *routeTimings) enter(phase string) {
+	t.phases = append(t.phases, phase)
+	t.starts = append(t.starts, time.Now())
+}
+
+// breakdown returns the time from entering each phase until the next one
+// began, or until end for the last. Work a middleware does after its
+// handler returns is counted in the last phase.
+func (t *routeTimings) breakdown(end time.Time) slog.Attr {
+	attrs := make([]any, 0, len(t.phases))
+	for i, phase := range t.phases {
+		until := end
+		if i+1 < len(t.starts) {
+			until = t.starts[i+1]
+		}
+		attrs = append(attrs, slog.Duration(phase, until.Sub(t.starts[i])))
+	}
+	return slog.Group("phases", attrs...)
+}
+
+// latencyBudget starts timing a route with a budget and warns when a
+// request exceeds it.
+func (s *Server) latencyBudget(route string, budget time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		timings := &routeTimings{}
+		c.Locals(latencyBudgetLocalsKey, timings)
+		timings.enter(phaseMiddleware)
+
+		err := c.Next()
+
+		end := time.Now()
+		elapsed := end.Sub(timings.starts[0])
+		exceeded := elapsed > budget
+		s.budgets.observe(c.Method(), route, budget, exceeded)
+		if !exceeded {
+			return err
+		}
+
+		args := []any{
+			"method", c.Method(),
+			"route", route,
+			"path", c.Path(),
+			"duration", elapsed,
+			"budget", budget,
+			timings.breakdown(end),
+		}
+		if trace := s.context(c).QueryTrace(); trace != nil && trace.Count() > 0 {
+			args = append(args, "queries", trace.Count(), "query_time", trace.Duration())
+		}
+		if requestID, ok := c.Locals("requestid").(string); ok {
+			args = append(args, "request_id", requestID)
+		}
+		if err != nil {
+			args = append(args, "error", err)
+		}
+		s.cfg.Logger.Warn("route exceeded latency budget", args...)
+		return err
+	}
+}
+
+// latencyPhase marks the start of phase for routes with a latency budget.
+func latencyPhase(phase string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if timings, ok := c.Locals(latencyBudgetLocalsKey).(*routeTimings); ok {
+			timings.enter(phase)
+		}
+		return c.Next()
+	}
+}
+
+// LatencyBudgetMetrics counts requests to a route with a latency budget and
+// how many of them exceeded it.
+type LatencyBudgetMetrics struct {
+	Method   string        `json:"method"`
+	Route    string        `json:"route"`
+	Budget   time.Duration `json:"budget"`
+	Requests int64         `json:"requests"`
+	Exceeded int64         `json:"exceeded"`
+}
+
+// latencyBudgetRecorder collects LatencyBudgetMetrics per method and route.
+type latencyBudgetRecorder struct {
+	mu      sync.Mutex
+	metrics map[string]*LatencyBudgetMetrics
+}
+
+func (r *latencyBudgetRecorder) observe(method, route string, budget time.Duration, exceeded bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.metrics == nil {
+		r.metrics = make(map[string]*LatencyBudgetMetrics)
+	}
+	key := method + " " + route
+	m := r.metrics[key]
+	if m == nil {
+		m = &LatencyBudgetMetrics{Method: method, Route: route, Budget: budget}
+		r.metrics[key] = m
+	}
+	m.Requests++
+	if exceeded {
+		m.Exceeded++
+	}
+}
+
+// snapshot returns a copy of the metrics sorted by route and method.
+func (r *latencyBudgetRecorder) snapshot() []LatencyBudgetMetrics {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make([]LatencyBudgetMetrics, 0, len(r.metrics))
+	for _, m := range r.metrics {
+		out = append(out, *m)
+	}
+	slices.SortFunc(out, func(a, b LatencyBudgetMetrics) int {
+		if c := strings.Compare(a.Route, b.Route); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Method, b.Method)
+	})
+	return out
+}
+
+// LatencyBudgetMetrics returns request and exceeded counts for routes with
+// RouteConfig.LatencyBudget that have been requested.
+func (s *Server) LatencyBudgetMetrics() []LatencyBudgetMetrics {
+	return s.budgets.snapshot()
+}
+
+// WriteLatencyBudgetMetrics writes metrics in the Prometheus text format,
+// labelled with the method and route.
+func WriteLatencyBudgetMetrics(w io.Writer, metrics []LatencyBudgetMetrics) {
+	if len(metrics) == 0 {
+		return
+	}
+	label := func(m LatencyBudgetMetrics) string {
+		return `method="` + promEscape(m.Method) + `",route="` + promEscape(m.Route) + `"`
+	}
+
+	fmt.Fprintf(w, "# TYPE cartridge_route_latency_budget_seconds gauge\n")
+	for _, m := range metrics {
+		fmt.Fprintf(w, "cartridge_route_latency_budget_seconds{%s} %g\n", label(m), m.Budget.Seconds())
+	}
+	fmt.Fprintf(w, "# TYPE cartridge_route_latency_budget_requests_total counter\n")
+	for _, m := range metrics {
+		fmt.Fprintf(w, "cartridge_route_latency_budget_requests_total{%s} %d\n", label(m), m.Requests)
+	}
+	fmt.Fprintf(w, "# TYPE cartridge_route_latency_budget_exceeded_total counter\n")
+	for _, m := range metrics {
+		fmt.Fprintf(w, "cartridge_route_latency_budget_exceeded_total{%s} %d\n", label(m), m.Exceeded)
+	}
+}
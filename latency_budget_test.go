@@ -0,0 +1,91 @@
+package cartridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestLatencyBudget(t *testing.T) {
+	var logs bytes.Buffer
+	cfg := DefaultServerConfig()
+	cfg.EnableStaticAssets = false
+	cfg.EnableRequestLogger = false
+	cfg.EnableSecFetchSite = false
+	cfg.Config = &testConfig{}
+	cfg.Logger = slog.New(slog.NewJSONHandler(&logs, nil))
+	cfg.DBManager = &testDBManager{}
+	cfg.ErrorHandler = DefaultErrorHandler(testLogger(), false)
+
+	srv, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	srv.Get("/slow/:id", func(ctx *Context) error {
+		time.Sleep(20 * time.Millisecond)
+		return ctx.SendString("ok")
+	}, &RouteConfig{
+		LatencyBudget: 5 * time.Millisecond,
+		Validate:      func(c *fiber.Ctx) error { return c.Next() },
+	})
+	srv.Get("/fast", func(ctx *Context) error {
+		return ctx.SendString("ok")
+	}, &RouteConfig{LatencyBudget: time.Second})
+
+	for _, path := range []string{"/slow/1", "/slow/2", "/fast"} {
+		if _, err := srv.App().Test(httptest.NewRequest("GET", path, nil)); err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+	}
+
+	var lines []string
+	for _, line := range strings.Split(logs.String(), "\n") {
+		if strings.Contains(line, "route exceeded latency budget") {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 warnings, got %d: %s", len(lines), logs.String())
+	}
+	var entry struct {
+		Level  string
+		Msg    string
+		Route  string
+		Phases map[string]float64
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("invalid log line: %v", err)
+	}
+	if entry.Level != "WARN" || entry.Route != "/slow/:id" {
+		t.Errorf("unexpected warning: %s", lines[0])
+	}
+	if _, ok := entry.Phases[phaseValidate]; !ok {
+		t.Errorf("expected the validate phase in the breakdown, got %v", entry.Phases)
+	}
+	if handler := time.Duration(entry.Phases[phaseHandler]); handler < 20*time.Millisecond {
+		t.Errorf("expected the handler phase to hold the sleep, got %v", handler)
+	}
+
+	metrics := srv.LatencyBudgetMetrics()
+	if len(metrics) != 2 {
+		t.Fatalf("expected metrics for 2 routes, got %+v", metrics)
+	}
+	if m := metrics[1]; m.Route != "/slow/:id" || m.Requests != 2 || m.Exceeded != 2 {
+		t.Errorf("unexpected slow route metrics %+v", m)
+	}
+	if m := metrics[0]; m.Route != "/fast" || m.Requests != 1 || m.Exceeded != 0 {
+		t.Errorf("unexpected fast route metrics %+v", m)
+	}
+
+	var b strings.Builder
+	WriteLatencyBudgetMetrics(&b, metrics)
+	if want := `cartridge_route_latency_budget_exceeded_total{method="GET",route="/slow/:id"} 2`; !strings.Contains(b.String(), want) {
+		t.Errorf("expected %q in:\n%s", want, b.String())
+	}
+}
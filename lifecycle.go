@@ -0,0 +1,293 @@
+package cartridge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// LifecyclePhase is a step in the application startup sequence.
+type LifecyclePhase string
+
+// Lifecycle phases, in default order.
+const (
+	// PhaseMigrate runs the configured Migrator.
+	PhaseMigrate LifecyclePhase = "migrate"
+
+	// PhaseWarmup runs warmup functions (cache priming, template compilation, ...).
+	PhaseWarmup LifecyclePhase = "warmup"
+
+	// PhaseWorkers starts background workers (job dispatchers, async tasks).
+	PhaseWorkers LifecyclePhase = "workers"
+
+	// PhaseCron starts cron schedulers.
+	PhaseCron LifecyclePhase = "cron"
+
+	// PhaseListen starts accepting HTTP connections.
+	PhaseListen LifecyclePhase = "listen"
+
+	// PhaseReady waits for readiness checks to pass.
+	PhaseReady LifecyclePhase = "ready"
+)
+
+// DefaultLifecycleOrder is the startup order used when none is configured.
+var DefaultLifecycleOrder = []LifecyclePhase{
+	PhaseMigrate, PhaseWarmup, PhaseWorkers, PhaseCron, PhaseListen, PhaseReady,
+}
+
+// gatedLifecycleOrder delays workers and cron until readiness checks pass.
+var gatedLifecycleOrder = []LifecyclePhase{
+	PhaseMigrate, PhaseWarmup, PhaseListen, PhaseReady, PhaseWorkers, PhaseCron,
+}
+
+// PhasedWorker is a BackgroundWorker that starts in a specific lifecycle phase.
+// Workers that don't implement it start in PhaseWorkers.
+type PhasedWorker interface {
+	BackgroundWorker
+	Phase() LifecyclePhase
+}
+
+// ReadinessCheck reports whether a dependency is ready to serve traffic.
+type ReadinessCheck struct {
+	// Name identifies the check in logs and errors.
+	Name string
+
+	// Check returns nil once the dependency is ready. It is retried until
+	// it passes or the readiness timeout expires.
+	Check func(ctx context.Context) error
+}
+
+// LifecycleConfig configures the application startup sequence.
+type LifecycleConfig struct {
+	// Migrator runs in PhaseMigrate, using MigrateStartup if it is a
+	// StartupMigrator. Optional.
+	Migrator Migrator
+
+	// SchemaCheck compares the database with GORM models after the
+	// migrator runs (see Application.CheckSchema). Optional.
+	SchemaCheck *SchemaCheckConfig
+
+	// Warmup functions run in PhaseWarmup, in order. Optional.
+	Warmup []func(ctx context.Context) error
+
+	// ReadinessChecks must all pass in PhaseReady. Optional.
+	ReadinessChecks []ReadinessCheck
+
+	// ReadinessTimeout bounds how long PhaseReady waits. Default: 30s.
+	ReadinessTimeout time.Duration
+
+	// DelayWorkersUntilReady starts background workers and cron only after the
+	// server is listening and readiness checks pass. Ignored when Order is set.
+	DelayWorkersUntilReady bool
+
+	// Order overrides the phase order. It must list every phase exactly once.
+	// Default: DefaultLifecycleOrder.
+	Order []LifecyclePhase
+
+	// OnPhase is called as each phase begins. Optional.
+	OnPhase func(phase LifecyclePhase)
+
+	// DrainTimeout bounds how long Shutdown waits for running async tasks
+	// and cron jobs before canceling them. Default: the shutdown timeout.
+	DrainTimeout time.Duration
+}
+
+// order returns the validated phase order.
+func (c LifecycleConfig) order() ([]LifecyclePhase, error) {
+	if len(c.Order) == 0 {
+		if c.DelayWorkersUntilReady {
+			return gatedLifecycleOrder, nil
+		}
+		return DefaultLifecycleOrder, nil
+	}
+
+	seen := make(map[LifecyclePhase]bool, len(c.Order))
+	for _, phase := range c.Order {
+		if !isLifecyclePhase(phase) {
+			return nil, fmt.Errorf("cartridge: unknown lifecycle phase %q", phase)
+		}
+		if seen[phase] {
+			return nil, fmt.Errorf("cartridge: lifecycle phase %q listed twice", phase)
+		}
+		seen[phase] = true
+	}
+	if len(seen) != len(DefaultLifecycleOrder) {
+		return nil, fmt.Errorf("cartridge: lifecycle order must list all phases %v", DefaultLifecycleOrder)
+	}
+	return c.Order, nil
+}
+
+// isLifecyclePhase reports whether phase is a known phase.
+func isLifecyclePhase(phase LifecyclePhase) bool {
+	for _, p := range DefaultLifecycleOrder {
+		if p == phase {
+			return true
+		}
+	}
+	return false
+}
+
+// workerPhase returns the phase a worker starts in.
+func workerPhase(w BackgroundWorker) LifecyclePhase {
+	if pw, ok := w.(PhasedWorker); ok {
+		return pw.Phase()
+	}
+	return PhaseWorkers
+}
+
+// Phase returns the most recent lifecycle phase the application entered.
+func (a *Application) Phase() LifecyclePhase {
+	a.phaseMu.RLock()
+	defer a.phaseMu.RUnlock()
+	return a.phase
+}
+
+// Ready reports whether the application has completed every lifecycle phase.
+func (a *Application) Ready() bool {
+	a.phaseMu.RLock()
+	defer a.phaseMu.RUnlock()
+	return a.ready
+}
+
+// startPhases runs each lifecycle phase in order. The server's Listen result
+// is delivered on serveErr once PhaseListen has run.
+func (a *Application) startPhases(serveErr chan<- error) error {
+	order, orderErr := a.lifecycle.order()
+	if orderErr != nil {
+		return orderErr
+	}
+
+	a.logConfigChanges()
+
+	listening := false
+	for _, phase := range order {
+		a.enterPhase(phase)
+
+		var err error
+		switch phase {
+		case PhaseMigrate:
+			err = a.runMigrations()
+			if err == nil {
+				err = a.runSchemaCheck()
+			}
+		case PhaseWarmup:
+			err = a.runWarmup()
+		case PhaseWorkers, PhaseCron:
+			err = a.startWorkers(phase)
+		case PhaseListen:
+			listening = true
+			go func() {
+				serveErr <- a.Server.Start()
+			}()
+		case PhaseReady:
+			err = a.waitReady()
+		}
+
+		if err != nil {
+			a.stopWorkers()
+			if listening {
+				if shutdownErr := a.Server.App().Shutdown(); shutdownErr != nil {
+					a.Logger.Error("failed to stop server", "error", shutdownErr)
+				}
+			}
+			return fmt.Errorf("cartridge: %s phase: %w", phase, err)
+		}
+	}
+
+	a.phaseMu.Lock()
+	a.ready = true
+	a.phaseMu.Unlock()
+	a.Logger.Info("Application ready")
+	return nil
+}
+
+// enterPhase records and reports the start of a phase.
+func (a *Application) enterPhase(phase LifecyclePhase) {
+	a.phaseMu.Lock()
+	a.phase = phase
+	a.phaseMu.Unlock()
+
+	a.Logger.Debug("lifecycle phase", "phase", phase)
+	if a.lifecycle.OnPhase != nil {
+		a.lifecycle.OnPhase(phase)
+	}
+}
+
+// runMigrations runs the configured migrator, if any, preferring
+// MigrateStartup for StartupMigrators.
+func (a *Application) runMigrations() error {
+	if a.lifecycle.Migrator == nil {
+		return nil
+	}
+	if a.DBManager == nil {
+		return errors.New("migrator configured without a database manager")
+	}
+	db, err := a.DBManager.Connect()
+	if err != nil {
+		return fmt.Errorf("connect database: %w", err)
+	}
+	if m, ok := a.lifecycle.Migrator.(StartupMigrator); ok {
+		return m.MigrateStartup(db)
+	}
+	return a.lifecycle.Migrator.Migrate(db)
+}
+
+// runWarmup runs the warmup functions in order.
+func (a *Application) runWarmup() error {
+	for _, fn := range a.lifecycle.Warmup {
+		if err := fn(context.Background()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startWorkers starts the workers assigned to the given phase.
+func (a *Application) startWorkers(phase LifecyclePhase) error {
+	for _, w := range a.workers {
+		if workerPhase(w) != phase {
+			continue
+		}
+		if setup, ok := w.(WorkerSetup); ok {
+			setup.SetupWorker(a.Logger, a.DBManager)
+		}
+		if err := w.Start(); err != nil {
+			return err
+		}
+		a.started = append(a.started, w)
+	}
+	return nil
+}
+
+// waitReady retries the readiness checks until they all pass or the timeout expires.
+func (a *Application) waitReady() error {
+	if len(a.lifecycle.ReadinessChecks) == 0 {
+		return nil
+	}
+
+	timeout := a.lifecycle.ReadinessTimeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+
+	for _, check := range a.lifecycle.ReadinessChecks {
+		for {
+			err := check.Check(ctx)
+			if err == nil {
+				break
+			}
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("readiness check %q did not pass within %s: %w", check.Name, timeout, err)
+			case <-ticker.C:
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+package cartridge
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// recordingWorker records Start and Stop calls into a shared log.
+type recordingWorker struct {
+	name  string
+	phase LifecyclePhase
+	log   *[]string
+}
+
+func (w *recordingWorker) Start() error {
+	*w.log = append(*w.log, "start:"+w.name)
+	return nil
+}
+
+func (w *recordingWorker) Stop() {
+	*w.log = append(*w.log, "stop:"+w.name)
+}
+
+// phasedRecordingWorker is a recordingWorker that declares its lifecycle phase.
+type phasedRecordingWorker struct {
+	recordingWorker
+}
+
+func (w *phasedRecordingWorker) Phase() LifecyclePhase {
+	return w.phase
+}
+
+// setupRecordingWorker records the dependencies passed to SetupWorker.
+type setupRecordingWorker struct {
+	recordingWorker
+	logger    Logger
+	dbManager DBManager
+}
+
+func (w *setupRecordingWorker) SetupWorker(logger Logger, dbManager DBManager) {
+	w.logger = logger
+	w.dbManager = dbManager
+	*w.log = append(*w.log, "setup:"+w.name)
+}
+
+// migratorFunc adapts a function to the Migrator interface.
+type migratorFunc func(db *gorm.DB) error
+
+func (f migratorFunc) Migrate(db *gorm.DB) error { return f(db) }
+
+// drainingRecordingWorker is a recordingWorker that can be drained.
+type drainingRecordingWorker struct {
+	recordingWorker
+}
+
+func (w *drainingRecordingWorker) Drain(ctx context.Context) error {
+	*w.log = append(*w.log, "drain:"+w.name)
+	if _, ok := ctx.Deadline(); !ok {
+		return errors.New("no drain deadline")
+	}
+	return nil
+}
+
+func TestLifecycleConfig_Order(t *testing.T) {
+	t.Run("default order", func(t *testing.T) {
+		order, err := LifecycleConfig{}.order()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if order[len(order)-1] != PhaseReady || order[2] != PhaseWorkers {
+			t.Errorf("unexpected default order: %v", order)
+		}
+	})
+
+	t.Run("delay workers until ready", func(t *testing.T) {
+		order, _ := LifecycleConfig{DelayWorkersUntilReady: true}.order()
+		if order[len(order)-1] != PhaseCron {
+			t.Errorf("expected cron last, got %v", order)
+		}
+	})
+
+	t.Run("rejects incomplete order", func(t *testing.T) {
+		_, err := LifecycleConfig{Order: []LifecyclePhase{PhaseMigrate, PhaseListen}}.order()
+		if err == nil {
+			t.Error("expected error for incomplete order")
+		}
+	})
+
+	t.Run("rejects duplicate phases", func(t *testing.T) {
+		order := append([]LifecyclePhase{PhaseMigrate}, DefaultLifecycleOrder[:5]...)
+		if _, err := (LifecycleConfig{Order: order}).order(); err == nil {
+			t.Error("expected error for duplicate phase")
+		}
+	})
+}
+
+func TestApplication_StartPhases(t *testing.T) {
+	t.Run("starts workers before cron and stops them when readiness fails", func(t *testing.T) {
+		var events []string
+		var phases []LifecyclePhase
+
+		app := &Application{
+			Logger: testLogger(),
+			workers: []BackgroundWorker{
+				&phasedRecordingWorker{recordingWorker{name: "cron", phase: PhaseCron, log: &events}},
+				&recordingWorker{name: "jobs", log: &events},
+			},
+			lifecycle: LifecycleConfig{
+				Warmup: []func(ctx context.Context) error{
+					func(ctx context.Context) error {
+						events = append(events, "warmup")
+						return nil
+					},
+				},
+				ReadinessChecks: []ReadinessCheck{{
+					Name:  "cache",
+					Check: func(ctx context.Context) error { return errors.New("not ready") },
+				}},
+				ReadinessTimeout: 50 * time.Millisecond,
+				// Keep listen last so the test never binds a port
+				Order:   []LifecyclePhase{PhaseMigrate, PhaseWarmup, PhaseWorkers, PhaseCron, PhaseReady, PhaseListen},
+				OnPhase: func(phase LifecyclePhase) { phases = append(phases, phase) },
+			},
+		}
+
+		err := app.startPhases(make(chan error, 1))
+		if err == nil || !strings.Contains(err.Error(), `readiness check "cache"`) {
+			t.Fatalf("expected readiness error, got %v", err)
+		}
+
+		expected := "warmup,start:jobs,start:cron,stop:cron,stop:jobs"
+		if got := strings.Join(events, ","); got != expected {
+			t.Errorf("expected events %s, got %s", expected, got)
+		}
+		if len(phases) != 5 || phases[4] != PhaseReady {
+			t.Errorf("expected to stop in ready phase, got %v", phases)
+		}
+		if app.Ready() {
+			t.Error("expected application not to be ready")
+		}
+	})
+
+	t.Run("migration failure prevents workers from starting", func(t *testing.T) {
+		var events []string
+
+		app := &Application{
+			Logger:    testLogger(),
+			DBManager: &mockDBManager{},
+			workers:   []BackgroundWorker{&recordingWorker{name: "jobs", log: &events}},
+			lifecycle: LifecycleConfig{
+				Migrator: migratorFunc(func(db *gorm.DB) error { return errors.New("bad migration") }),
+			},
+		}
+
+		err := app.startPhases(make(chan error, 1))
+		if err == nil || !strings.Contains(err.Error(), "migrate phase") {
+			t.Fatalf("expected migrate phase error, got %v", err)
+		}
+		if len(events) != 0 {
+			t.Errorf("expected no workers started, got %v", events)
+		}
+		if app.Phase() != PhaseMigrate {
+			t.Errorf("expected migrate phase, got %s", app.Phase())
+		}
+	})
+}
+
+func TestApp_AddWorker(t *testing.T) {
+	var events []string
+	db := &mockDBManager{}
+	early := &setupRecordingWorker{recordingWorker: recordingWorker{name: "early", log: &events}}
+	late := &recordingWorker{name: "late", log: &events}
+
+	// Workers added before the Application exists (from WithInit) are kept
+	app := &App{}
+	app.AddWorker(early)
+	app.Application = &Application{
+		Logger:    testLogger(),
+		DBManager: db,
+		workers:   app.pendingWorkers,
+	}
+	app.AddWorker(late)
+
+	if err := app.startWorkers(PhaseWorkers); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	app.stopWorkers()
+
+	expected := "setup:early,start:early,start:late,stop:late,stop:early"
+	if got := strings.Join(events, ","); got != expected {
+		t.Errorf("expected events %s, got %s", expected, got)
+	}
+	if early.logger == nil || early.dbManager != db {
+		t.Error("expected SetupWorker to receive the app logger and database")
+	}
+}
+
+func TestApplication_DrainWorkers(t *testing.T) {
+	var events []string
+	async := &drainingRecordingWorker{recordingWorker{name: "async", log: &events}}
+	plain := &recordingWorker{name: "plain", log: &events}
+	cron := &drainingRecordingWorker{recordingWorker{name: "cron", log: &events}}
+
+	app := &Application{
+		Logger:    testLogger(),
+		workers:   []BackgroundWorker{async, plain, cron},
+		lifecycle: LifecycleConfig{DrainTimeout: time.Second},
+	}
+	if err := app.startWorkers(PhaseWorkers); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	app.drainWorkers(context.Background())
+	app.stopWorkers()
+
+	expected := "start:async,start:plain,start:cron,drain:cron,drain:async,stop:cron,stop:plain,stop:async"
+	if got := strings.Join(events, ","); got != expected {
+		t.Errorf("expected events %s, got %s", expected, got)
+	}
+}
@@ -0,0 +1,71 @@
+package cartridge
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// listenFDsStart is the first file descriptor passed by systemd socket
+// activation; stdin, stdout and stderr come before it.
+const listenFDsStart = 3
+
+// activatedListener returns the first socket passed by systemd socket
+// activation, starting at file descriptor first, or nil when LISTEN_PID
+// doesn't name this process. The LISTEN_* variables are cleared so child
+// processes don't take the socket for theirs.
+func activatedListener(first int) (net.Listener, error) {
+	if os.Getenv("LISTEN_PID") != strconv.Itoa(os.Getpid()) {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n < 1 {
+		return nil, nil
+	}
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	f := os.NewFile(uintptr(first), "LISTEN_FD_"+strconv.Itoa(first))
+	defer f.Close() // FileListener holds its own copy
+	ln, err := net.FileListener(f)
+	if err != nil {
+		return nil, fmt.Errorf("cartridge: socket activation: %w", err)
+	}
+	return ln, nil
+}
+
+// listenUnix listens on a Unix domain socket at path with the given
+// permissions (0660 when zero). A socket file left by a previous run is
+// removed first, unless a server still answers on it.
+func listenUnix(path string, perms os.FileMode) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("cartridge: unix socket %s is in use", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("cartridge: remove stale unix socket: %w", err)
+		}
+	} else if err == nil {
+		return nil, fmt.Errorf("cartridge: %s exists and is not a socket", path)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("cartridge: unix socket: %w", err)
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("cartridge: listen on unix socket: %w", err)
+	}
+	if perms == 0 {
+		perms = 0o660
+	}
+	if err := os.Chmod(path, perms); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("cartridge: unix socket permissions: %w", err)
+	}
+	return ln, nil
+}
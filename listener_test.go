@@ -0,0 +1,128 @@
+package cartridge
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestServer_UnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "http.sock")
+
+	cfg := DefaultServerConfig()
+	cfg.EnableStaticAssets = false
+	cfg.EnableRequestLogger = false
+	cfg.Config = &testConfig{}
+	cfg.Logger = testLogger()
+	cfg.DBManager = &testDBManager{}
+	cfg.UnixSocket = socket
+	cfg.UnixSocketPerms = 0o600
+	srv, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	srv.Get("/ping", func(ctx *Context) error { return ctx.SendString("pong") })
+
+	// A socket left behind by a crashed run
+	stale, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- srv.Start() }()
+	defer srv.Shutdown(context.Background())
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		},
+	}}
+	var resp *http.Response
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		if resp, err = client.Get("http://unix/ping"); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		t.Fatalf("request over the socket failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "pong" {
+		t.Errorf("expected pong, got %q", body)
+	}
+
+	info, err := os.Stat(socket)
+	if err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("expected socket permissions 0600, got %v %v", info, err)
+	}
+	if _, err := listenUnix(socket, 0); err == nil {
+		t.Error("expected a socket with a live server to be refused")
+	}
+
+	file := filepath.Join(t.TempDir(), "data.txt")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := listenUnix(file, 0); err == nil {
+		t.Error("expected a regular file to be left alone")
+	}
+
+	if err := srv.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown failed: %v", err)
+	}
+	if err := <-serveErr; err != nil {
+		t.Errorf("Start returned %v", err)
+	}
+}
+
+func TestActivatedListener(t *testing.T) {
+	if ln, err := activatedListener(listenFDsStart); ln != nil || err != nil {
+		t.Fatalf("expected no listener without LISTEN_PID, got %v %v", ln, err)
+	}
+
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tcp.Close()
+	f, err := tcp.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	// activatedListener closes the descriptor it is given
+	fd, err := syscall.Dup(int(f.Fd()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+	t.Setenv("LISTEN_FDS", "1")
+	if ln, _ := activatedListener(fd); ln != nil {
+		t.Fatal("expected sockets passed to another process to be ignored")
+	}
+
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	ln, err := activatedListener(fd)
+	if err != nil || ln == nil {
+		t.Fatalf("expected the passed socket, got %v %v", ln, err)
+	}
+	defer ln.Close()
+	if ln.Addr().String() != tcp.Addr().String() {
+		t.Errorf("expected the listener on %s, got %s", tcp.Addr(), ln.Addr())
+	}
+	if os.Getenv("LISTEN_FDS") != "" {
+		t.Error("expected LISTEN_FDS to be cleared")
+	}
+}
@@ -0,0 +1,137 @@
+package cartridge
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// DefaultLogTailSize is the number of records NewSSRApp and NewInertiaApp
+// keep for the debug UI.
+const DefaultLogTailSize = 200
+
+// LogEntry is a log record kept by a LogTail.
+type LogEntry struct {
+	Time    time.Time         `json:"time"`
+	Level   string            `json:"level"`
+	Message string            `json:"message"`
+	Attrs   map[string]string `json:"attrs,omitempty"`
+}
+
+// LogTail is a slog.Handler that keeps the most recent records in memory
+// and passes every record on to another handler. Attributes that look like
+// secrets are redacted in the kept copy only.
+//
+//	tail := cartridge.NewLogTail(logger.Handler(), 500)
+//	logger = slog.New(tail)
+type LogTail struct {
+	next  slog.Handler
+	attrs []slog.Attr // from WithAttrs, keys already prefixed with groups
+	group string      // from WithGroup, e.g. "request."
+	ring  *logRing
+}
+
+// logRing is the buffer shared by a LogTail and the handlers derived from it.
+type logRing struct {
+	mu      sync.Mutex
+	entries []LogEntry
+	next    int
+}
+
+// NewLogTail wraps next, keeping the last size records. Default size:
+// DefaultLogTailSize
+func NewLogTail(next slog.Handler, size int) *LogTail {
+	if size <= 0 {
+		size = DefaultLogTailSize
+	}
+	return &LogTail{next: next, ring: &logRing{entries: make([]LogEntry, 0, size)}}
+}
+
+// Entries returns the kept records, oldest first.
+func (h *LogTail) Entries() []LogEntry {
+	r := h.ring
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.entries) < cap(r.entries) {
+		return slices.Clone(r.entries)
+	}
+	return append(slices.Clone(r.entries[r.next:]), r.entries[:r.next]...)
+}
+
+// Enabled implements slog.Handler.
+func (h *LogTail) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler.
+func (h *LogTail) Handle(ctx context.Context, r slog.Record) error {
+	entry := LogEntry{Time: r.Time, Level: r.Level.String(), Message: r.Message}
+	if len(h.attrs) > 0 || r.NumAttrs() > 0 {
+		entry.Attrs = make(map[string]string, len(h.attrs)+r.NumAttrs())
+		for _, a := range h.attrs {
+			addLogAttr(entry.Attrs, "", a)
+		}
+		r.Attrs(func(a slog.Attr) bool {
+			addLogAttr(entry.Attrs, h.group, a)
+			return true
+		})
+	}
+
+	ring := h.ring
+	ring.mu.Lock()
+	if len(ring.entries) < cap(ring.entries) {
+		ring.entries = append(ring.entries, entry)
+	} else {
+		ring.entries[ring.next] = entry
+		ring.next = (ring.next + 1) % len(ring.entries)
+	}
+	ring.mu.Unlock()
+
+	return h.next.Handle(ctx, r)
+}
+
+// WithAttrs implements slog.Handler.
+func (h *LogTail) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.next = h.next.WithAttrs(attrs)
+	clone.attrs = slices.Clone(h.attrs)
+	for _, a := range attrs {
+		a.Key = h.group + a.Key
+		clone.attrs = append(clone.attrs, a)
+	}
+	return &clone
+}
+
+// WithGroup implements slog.Handler.
+func (h *LogTail) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.next = h.next.WithGroup(name)
+	clone.group = h.group + name + "."
+	return &clone
+}
+
+// addLogAttr flattens a into attrs under prefix, redacting secrets.
+func addLogAttr(attrs map[string]string, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() == slog.KindGroup {
+		group := prefix
+		if a.Key != "" {
+			group = prefix + a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			addLogAttr(attrs, group, ga)
+		}
+		return
+	}
+	key := prefix + a.Key
+	if isSecretSetting(key) {
+		attrs[key] = "[redacted]"
+		return
+	}
+	attrs[key] = redactCredentials(a.Value.String())
+}
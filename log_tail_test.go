@@ -0,0 +1,38 @@
+package cartridge
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+)
+
+func TestLogTail(t *testing.T) {
+	tail := NewLogTail(slog.NewTextHandler(io.Discard, nil), 3)
+	logger := slog.New(tail)
+
+	logger.Debug("below the level")
+	logger.Info("first")
+	logger.With("component", "mailer").WithGroup("smtp").Info("second", "host", "mail.example", "password", "hunter2")
+	logger.Info("third", slog.Group("db", "url", "postgres://app:pa55@db/app"))
+	logger.Warn("fourth")
+
+	entries := tail.Entries()
+	if len(entries) != 3 || entries[0].Message != "second" || entries[2].Message != "fourth" {
+		t.Fatalf("expected the last three records oldest first, got %+v", entries)
+	}
+	for key, want := range map[string]string{
+		"component":     "mailer",
+		"smtp.host":     "mail.example",
+		"smtp.password": "[redacted]",
+	} {
+		if got := entries[0].Attrs[key]; got != want {
+			t.Errorf("%s: expected %q, got %q", key, want, got)
+		}
+	}
+	if got := entries[1].Attrs["db.url"]; got != "postgres://app:xxxxx@db/app" {
+		t.Errorf("expected the URL password to be redacted, got %q", got)
+	}
+	if entries[2].Level != "WARN" {
+		t.Errorf("expected the level to be kept, got %q", entries[2].Level)
+	}
+}
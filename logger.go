@@ -79,6 +79,12 @@ func LogConfigFromProvider(p LogConfigProvider) *LogConfig {
 //   - Default level: error
 //   - Files rotated via lumberjack
 func NewLogger(cfg Config, logCfg *LogConfig) *slog.Logger {
+	return newLogger(cfg, logCfg, new(slog.LevelVar))
+}
+
+// newLogger is NewLogger with the level kept in level, so it can be changed
+// while the app runs.
+func newLogger(cfg Config, logCfg *LogConfig, level *slog.LevelVar) *slog.Logger {
 	// Auto-extract log config if cfg implements LogConfigProvider
 	if logCfg == nil {
 		if provider, ok := cfg.(LogConfigProvider); ok {
@@ -89,7 +95,7 @@ func NewLogger(cfg Config, logCfg *LogConfig) *slog.Logger {
 	}
 
 	// Determine log level
-	level := resolveLogLevel(cfg, logCfg.Level)
+	level.Set(resolveLogLevel(cfg, logCfg.Level))
 
 	// Create appropriate handler based on environment
 	if cfg.IsDevelopment() || cfg.IsTest() {
@@ -135,10 +141,10 @@ func resolveLogLevel(cfg Config, configLevel string) slog.Level {
 }
 
 // newDevLogger creates a colored text logger for development/test.
-func newDevLogger(level slog.Level) *slog.Logger {
+func newDevLogger(level slog.Leveler) *slog.Logger {
 	opts := &slog.HandlerOptions{
 		Level:     level,
-		AddSource: level == slog.LevelDebug,
+		AddSource: level.Level() == slog.LevelDebug,
 	}
 
 	// Use text handler with colors for dev/test
@@ -147,7 +153,7 @@ func newDevLogger(level slog.Level) *slog.Logger {
 }
 
 // newProdLogger creates a JSON logger that writes to stdout and file.
-func newProdLogger(level slog.Level, logCfg *LogConfig) *slog.Logger {
+func newProdLogger(level slog.Leveler, logCfg *LogConfig) *slog.Logger {
 	// Apply defaults
 	appName := logCfg.AppName
 	if appName == "" {
@@ -195,7 +201,7 @@ func newProdLogger(level slog.Level, logCfg *LogConfig) *slog.Logger {
 
 	opts := &slog.HandlerOptions{
 		Level:     level,
-		AddSource: level == slog.LevelDebug,
+		AddSource: level.Level() == slog.LevelDebug,
 	}
 
 	return slog.New(slog.NewJSONHandler(multiWriter, opts))
@@ -205,13 +211,13 @@ func newProdLogger(level slog.Level, logCfg *LogConfig) *slog.Logger {
 type colorHandler struct {
 	slog.Handler
 	w     io.Writer
-	level slog.Level
+	level slog.Leveler
 }
 
 func newColorHandler(w io.Writer, opts *slog.HandlerOptions) *colorHandler {
-	level := slog.LevelInfo
+	var level slog.Leveler = slog.LevelInfo
 	if opts != nil && opts.Level != nil {
-		level = opts.Level.Level()
+		level = opts.Level
 	}
 	return &colorHandler{
 		Handler: slog.NewTextHandler(w, opts),
@@ -276,7 +282,7 @@ func (h *colorHandler) Handle(ctx context.Context, r slog.Record) error {
 }
 
 func (h *colorHandler) Enabled(ctx context.Context, level slog.Level) bool {
-	return level >= h.level
+	return level >= h.level.Level()
 }
 
 func (h *colorHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
@@ -0,0 +1,184 @@
+package cartridge
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	cartridgemiddleware "github.com/karloscodes/cartridge/middleware"
+)
+
+// Cron job IDs of the SQLite maintenance tasks.
+const (
+	MaintenanceVacuumJob    = "cartridge_sqlite_vacuum"
+	MaintenanceOptimizeJob  = "cartridge_sqlite_optimize"
+	MaintenanceIntegrityJob = "cartridge_sqlite_integrity_check"
+)
+
+// MaintenanceConfig schedules SQLite maintenance tasks. Empty schedules
+// disable their task, so start from DefaultMaintenanceConfig and adjust.
+type MaintenanceConfig struct {
+	// VacuumSchedule runs PRAGMA incremental_vacuum, returning free pages to
+	// the file system. It only has an effect on databases created with
+	// PRAGMA auto_vacuum = INCREMENTAL. Default: "@daily"
+	VacuumSchedule string
+	// VacuumPages caps the pages freed per run, to keep runs short.
+	// Default: 0 (all free pages)
+	VacuumPages int
+
+	// OptimizeSchedule runs PRAGMA optimize, which runs ANALYZE on tables
+	// whose statistics are stale so the query planner picks good indexes.
+	// Default: "@every 6h"
+	OptimizeSchedule string
+
+	// IntegritySchedule runs PRAGMA quick_check. Default: "@weekly"
+	IntegritySchedule string
+	// FullIntegrityCheck runs PRAGMA integrity_check instead, which also
+	// verifies indexes match their tables but reads the whole database.
+	FullIntegrityCheck bool
+	// OnIntegrityFailure is called with the problems found, e.g. to page
+	// someone. Failures are also logged and fail the cron run.
+	OnIntegrityFailure func(problems []string)
+
+	// WriteTimeout is how long vacuum and optimize wait for a slot in the
+	// write concurrency limit before skipping the run. Default: 10 seconds
+	WriteTimeout time.Duration
+}
+
+// DefaultMaintenanceConfig returns the default maintenance schedules.
+func DefaultMaintenanceConfig() MaintenanceConfig {
+	return MaintenanceConfig{
+		VacuumSchedule:    "@daily",
+		OptimizeSchedule:  "@every 6h",
+		IntegritySchedule: "@weekly",
+		WriteTimeout:      10 * time.Second,
+	}
+}
+
+// MaintenanceJobs returns cron jobs for the scheduled SQLite maintenance
+// tasks. Vacuum and optimize write to the database, so they take a slot
+// in limiter's write limit like a write request and skip the run when none
+// frees up within WriteTimeout. limiter may be nil.
+func MaintenanceJobs(limiter *cartridgemiddleware.ConcurrencyLimiter, cfg MaintenanceConfig) []CronJob {
+	if cfg.WriteTimeout <= 0 {
+		cfg.WriteTimeout = DefaultMaintenanceConfig().WriteTimeout
+	}
+
+	var jobs []CronJob
+	if cfg.VacuumSchedule != "" {
+		jobs = append(jobs, CronJob{
+			ID:            MaintenanceVacuumJob,
+			Schedule:      cfg.VacuumSchedule,
+			SkipIfRunning: true,
+			Handler: withWriteSlot(limiter, cfg.WriteTimeout, func(ctx *JobContext) error {
+				return incrementalVacuum(ctx, cfg.VacuumPages)
+			}),
+		})
+	}
+	if cfg.OptimizeSchedule != "" {
+		jobs = append(jobs, CronJob{
+			ID:            MaintenanceOptimizeJob,
+			Schedule:      cfg.OptimizeSchedule,
+			SkipIfRunning: true,
+			Handler: withWriteSlot(limiter, cfg.WriteTimeout, func(ctx *JobContext) error {
+				return ctx.DB.Exec("PRAGMA optimize").Error
+			}),
+		})
+	}
+	if cfg.IntegritySchedule != "" {
+		jobs = append(jobs, CronJob{
+			ID:            MaintenanceIntegrityJob,
+			Schedule:      cfg.IntegritySchedule,
+			SkipIfRunning: true,
+			Handler: func(ctx *JobContext) error {
+				return integrityCheck(ctx, cfg.FullIntegrityCheck, cfg.OnIntegrityFailure)
+			},
+		})
+	}
+	return jobs
+}
+
+// withWriteSlot runs handler holding a write slot, or skips the run when
+// the limit stays full for timeout.
+func withWriteSlot(limiter *cartridgemiddleware.ConcurrencyLimiter, timeout time.Duration, handler CronHandler) CronHandler {
+	return func(ctx *JobContext) error {
+		if ctx.DB == nil {
+			return fmt.Errorf("cartridge: maintenance needs a database")
+		}
+		if limiter == nil {
+			return handler(ctx)
+		}
+		acquireCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		if err := limiter.AcquireWrite(acquireCtx); err != nil {
+			ctx.Logger.Warn("database maintenance skipped: write limit busy", "timeout", timeout)
+			return nil
+		}
+		defer limiter.ReleaseWrite()
+		return handler(ctx)
+	}
+}
+
+// incrementalVacuum frees up to pages free pages (all when 0).
+func incrementalVacuum(ctx *JobContext, pages int) error {
+	var mode int
+	if err := ctx.DB.Raw("PRAGMA auto_vacuum").Scan(&mode).Error; err != nil {
+		return err
+	}
+	if mode != 2 {
+		ctx.Logger.Debug("incremental vacuum skipped: auto_vacuum is not INCREMENTAL")
+		return nil
+	}
+
+	var before int64
+	if err := ctx.DB.Raw("PRAGMA freelist_count").Scan(&before).Error; err != nil {
+		return err
+	}
+	if before == 0 {
+		return nil
+	}
+	// incremental_vacuum frees one page per step, so read it to the end
+	rows, err := ctx.DB.Raw(fmt.Sprintf("PRAGMA incremental_vacuum(%d)", pages)).Rows()
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+	}
+	rows.Close() // before the next query: SQLite apps use a single connection
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	var after int64
+	if err := ctx.DB.Raw("PRAGMA freelist_count").Scan(&after).Error; err != nil {
+		return err
+	}
+	ctx.Logger.Info("incremental vacuum freed pages", "pages", before-after, "free_pages", after)
+	return nil
+}
+
+// integrityCheck runs quick_check or integrity_check and reports problems.
+func integrityCheck(ctx *JobContext, full bool, onFailure func([]string)) error {
+	if ctx.DB == nil {
+		return fmt.Errorf("cartridge: maintenance needs a database")
+	}
+	pragma := "PRAGMA quick_check"
+	if full {
+		pragma = "PRAGMA integrity_check"
+	}
+	var results []string
+	if err := ctx.DB.Raw(pragma).Scan(&results).Error; err != nil {
+		return err
+	}
+	if len(results) == 1 && results[0] == "ok" {
+		ctx.Logger.Debug("database integrity check passed", "full", full)
+		return nil
+	}
+
+	ctx.Logger.Error("database integrity check failed", "full", full, "problems", results)
+	if onFailure != nil {
+		onFailure(results)
+	}
+	return fmt.Errorf("cartridge: integrity check found %d problems: %s", len(results), strings.Join(results, "; "))
+}
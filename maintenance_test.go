@@ -0,0 +1,79 @@
+package cartridge
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	cartridgemiddleware "github.com/karloscodes/cartridge/middleware"
+)
+
+func maintenanceJob(t *testing.T, jobs []CronJob, id string) CronJob {
+	t.Helper()
+	for _, job := range jobs {
+		if job.ID == id {
+			return job
+		}
+	}
+	t.Fatalf("job %q not registered", id)
+	return CronJob{}
+}
+
+func TestMaintenanceJobs(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "maint.db")+"?_auto_vacuum=incremental"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	db.Exec("CREATE TABLE blobs (id INTEGER PRIMARY KEY, data BLOB)")
+	for i := 0; i < 50; i++ {
+		db.Exec("INSERT INTO blobs (data) VALUES (randomblob(4000))")
+	}
+	db.Exec("DELETE FROM blobs")
+
+	limiter := cartridgemiddleware.NewConcurrencyLimiter(10, 1, time.Second, testLogger())
+	cfg := DefaultMaintenanceConfig()
+	cfg.WriteTimeout = 20 * time.Millisecond
+	jobs := MaintenanceJobs(limiter, cfg)
+	if len(jobs) != 3 {
+		t.Fatalf("expected 3 jobs, got %d", len(jobs))
+	}
+	ctx := &JobContext{Context: context.Background(), Logger: testLogger(), DB: db}
+
+	// Vacuum waits for the write limit and skips when it stays full
+	_ = limiter.AcquireWrite(context.Background())
+	if err := maintenanceJob(t, jobs, MaintenanceVacuumJob).Handler(ctx); err != nil {
+		t.Fatalf("vacuum failed: %v", err)
+	}
+	var free int64
+	db.Raw("PRAGMA freelist_count").Scan(&free)
+	if free == 0 {
+		t.Fatal("expected vacuum to be skipped while the write limit is full")
+	}
+	limiter.ReleaseWrite()
+
+	if err := maintenanceJob(t, jobs, MaintenanceVacuumJob).Handler(ctx); err != nil {
+		t.Fatalf("vacuum failed: %v", err)
+	}
+	db.Raw("PRAGMA freelist_count").Scan(&free)
+	if free != 0 {
+		t.Errorf("expected vacuum to free all pages, %d left", free)
+	}
+
+	if err := maintenanceJob(t, jobs, MaintenanceOptimizeJob).Handler(ctx); err != nil {
+		t.Errorf("optimize failed: %v", err)
+	}
+	if err := maintenanceJob(t, jobs, MaintenanceIntegrityJob).Handler(ctx); err != nil {
+		t.Errorf("integrity check failed on a healthy database: %v", err)
+	}
+}
+
+func TestMaintenanceJobs_DisabledSchedules(t *testing.T) {
+	jobs := MaintenanceJobs(nil, MaintenanceConfig{IntegritySchedule: "@daily", FullIntegrityCheck: true})
+	if len(jobs) != 1 || jobs[0].ID != MaintenanceIntegrityJob {
+		t.Fatalf("expected only the integrity job, got %+v", jobs)
+	}
+}
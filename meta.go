@@ -0,0 +1,177 @@
+package cartridge
+
+import (
+	"html"
+	"html/template"
+	"net/url"
+	"strings"
+)
+
+// metaLocalsKey stores the request's PageMetadata in fiber locals.
+const metaLocalsKey = "cartridge_meta"
+
+// PageMetadata describes an SSR page for browsers, screen readers, search
+// engines and link previews. Handlers set it with ctx.SetMeta and layouts
+// render it in <head> with {{ metaTags . }}:
+//
+//	<html lang="{{ .Helpers.Meta.Lang }}">
+//	<head>{{ metaTags . }}</head>
+//
+// Empty fields fall back to ServerConfig.PageMeta.
+type PageMetadata struct {
+	// Title of the page, also used for og:title and twitter:title.
+	// Default: SiteName
+	Title string
+
+	// Description for search results and link previews.
+	Description string
+
+	// URL is the canonical URL, absolute or relative to SiteURL.
+	// Default: the request path, without the query string
+	URL string
+
+	// Image is the link preview image, absolute or relative to SiteURL.
+	Image string
+
+	// ImageAlt describes Image for screen readers.
+	ImageAlt string
+
+	// Type is the Open Graph type. Default: "website"
+	Type string
+
+	// SiteName is og:site_name.
+	SiteName string
+
+	// SiteURL is the origin relative URLs resolve against, e.g.
+	// "https://example.com". Default: the request's scheme and host
+	SiteURL string
+
+	// Lang is the page language for <html lang>; og:locale is derived
+	// from it. Default: "en"
+	Lang string
+
+	// TwitterSite is the site's handle, e.g. "@example".
+	TwitterSite string
+
+	// NoIndex asks search engines not to index the page or follow its links.
+	NoIndex bool
+}
+
+// merge returns m with its empty fields taken from defaults.
+func (m PageMetadata) merge(defaults PageMetadata) PageMetadata {
+	for _, f := range []struct{ field, fallback *string }{
+		{&m.Title, &defaults.Title},
+		{&m.Description, &defaults.Description},
+		{&m.URL, &defaults.URL},
+		{&m.Image, &defaults.Image},
+		{&m.ImageAlt, &defaults.ImageAlt},
+		{&m.Type, &defaults.Type},
+		{&m.SiteName, &defaults.SiteName},
+		{&m.SiteURL, &defaults.SiteURL},
+		{&m.Lang, &defaults.Lang},
+		{&m.TwitterSite, &defaults.TwitterSite},
+	} {
+		if *f.field == "" {
+			*f.field = *f.fallback
+		}
+	}
+	m.NoIndex = m.NoIndex || defaults.NoIndex
+	return m
+}
+
+// SetMeta sets the page metadata rendered by {{ metaTags . }}. Calls
+// accumulate: non-empty fields replace those set earlier in the request,
+// e.g. by middleware.
+func (ctx *Context) SetMeta(meta PageMetadata) {
+	if prev, ok := ctx.Locals(metaLocalsKey).(PageMetadata); ok {
+		meta = meta.merge(prev)
+	}
+	ctx.Locals(metaLocalsKey, meta)
+}
+
+// Meta returns the request's page metadata with defaults applied: the
+// fields set with SetMeta, then ServerConfig.PageMeta, then values derived
+// from the request. URL and Image are absolute.
+func (ctx *Context) Meta() PageMetadata {
+	meta, _ := ctx.Locals(metaLocalsKey).(PageMetadata)
+	if ctx.pageMeta != nil {
+		meta = meta.merge(*ctx.pageMeta)
+	}
+	meta = meta.merge(PageMetadata{
+		Title:   meta.SiteName,
+		URL:     ctx.Path(),
+		Type:    "website",
+		SiteURL: ctx.BaseURL(),
+		Lang:    "en",
+	})
+	meta.URL = absoluteURL(meta.SiteURL, meta.URL)
+	if meta.Image != "" {
+		meta.Image = absoluteURL(meta.SiteURL, meta.Image)
+	}
+	return meta
+}
+
+// Tags renders the metadata as <head> elements: title, description,
+// canonical link, robots, Open Graph and Twitter card tags. Call it on
+// ctx.Meta(), which fills in defaults.
+func (m PageMetadata) Tags() template.HTML {
+	var b strings.Builder
+	tag := func(attr, key, value string) {
+		if value != "" {
+			b.WriteString(`<meta ` + attr + `="` + key + `" content="` + html.EscapeString(value) + "\">\n")
+		}
+	}
+
+	if m.Title != "" {
+		b.WriteString("<title>" + html.EscapeString(m.Title) + "</title>\n")
+	}
+	tag("name", "description", m.Description)
+	if m.URL != "" {
+		b.WriteString(`<link rel="canonical" href="` + html.EscapeString(m.URL) + "\">\n")
+	}
+	if m.NoIndex {
+		tag("name", "robots", "noindex, nofollow")
+	}
+
+	tag("property", "og:type", m.Type)
+	tag("property", "og:title", m.Title)
+	tag("property", "og:description", m.Description)
+	tag("property", "og:url", m.URL)
+	tag("property", "og:site_name", m.SiteName)
+	tag("property", "og:locale", ogLocale(m.Lang))
+	tag("property", "og:image", m.Image)
+	tag("property", "og:image:alt", m.ImageAlt)
+
+	card := "summary"
+	if m.Image != "" {
+		card = "summary_large_image"
+	}
+	tag("name", "twitter:card", card)
+	tag("name", "twitter:site", m.TwitterSite)
+	tag("name", "twitter:title", m.Title)
+	tag("name", "twitter:description", m.Description)
+	tag("name", "twitter:image", m.Image)
+	tag("name", "twitter:image:alt", m.ImageAlt)
+
+	return template.HTML(b.String())
+}
+
+// absoluteURL resolves ref against base. ref is returned as is when either
+// doesn't parse.
+func absoluteURL(base, ref string) string {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return ref
+	}
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return baseURL.ResolveReference(refURL).String()
+}
+
+// ogLocale converts a language tag to Open Graph's form: "en-US" becomes
+// "en_US".
+func ogLocale(lang string) string {
+	return strings.ReplaceAll(lang, "-", "_")
+}
@@ -0,0 +1,80 @@
+package cartridge
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/template/html/v2"
+)
+
+func TestPageMeta(t *testing.T) {
+	views := fstest.MapFS{
+		"page.html": {Data: []byte(`<html lang="{{ .Helpers.Meta.Lang }}"><head>{{ metaTags . }}</head></html>`)},
+	}
+	engine := html.NewFileSystem(http.FS(views), ".html")
+	engine.AddFuncMap(helperTemplateFuncs())
+	srv := newTemplateTestServer(t, engine)
+	srv.cfg.PageMeta = &PageMetadata{
+		SiteName: "Acme",
+		SiteURL:  "https://acme.com",
+		Image:    "/og/default.png",
+		Lang:     "en-GB",
+	}
+	setTwitter := func(c *fiber.Ctx) error {
+		srv.context(c).SetMeta(PageMetadata{TwitterSite: "@acme", Title: "Products"})
+		return c.Next()
+	}
+	srv.Get("/products/:id", func(ctx *Context) error {
+		ctx.SetMeta(PageMetadata{
+			Title:       `Rock & "Roll" Boots`,
+			Description: "Waterproof boots",
+			ImageAlt:    "Brown leather boots",
+		})
+		return ctx.Render("page", fiber.Map{})
+	}, &RouteConfig{CustomMiddleware: []fiber.Handler{setTwitter}})
+	srv.Get("/", func(ctx *Context) error {
+		return ctx.Render("page", fiber.Map{})
+	})
+
+	get := func(target string) string {
+		t.Helper()
+		resp, err := srv.App().Test(httptest.NewRequest("GET", target, nil))
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	page := get("/products/7?utm_source=x")
+	for _, want := range []string{
+		`<html lang="en-GB">`,
+		`<title>Rock &amp; &#34;Roll&#34; Boots</title>`,
+		`<meta name="description" content="Waterproof boots">`,
+		`<link rel="canonical" href="https://acme.com/products/7">`,
+		`<meta property="og:type" content="website">`,
+		`<meta property="og:site_name" content="Acme">`,
+		`<meta property="og:locale" content="en_GB">`,
+		`<meta property="og:image" content="https://acme.com/og/default.png">`,
+		`<meta property="og:image:alt" content="Brown leather boots">`,
+		`<meta name="twitter:card" content="summary_large_image">`,
+		`<meta name="twitter:site" content="@acme">`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("expected %s in:\n%s", want, page)
+		}
+	}
+
+	home := get("/")
+	if !strings.Contains(home, "<title>Acme</title>") {
+		t.Errorf("expected the site name as the default title in:\n%s", home)
+	}
+	if strings.Contains(home, "Boots") {
+		t.Error("metadata leaked between requests")
+	}
+}
@@ -0,0 +1,134 @@
+package cartridge
+
+import (
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// routeTable maps each HTTP method to its registered path patterns, rebuilt
+// whenever routes are added.
+type routeTable struct {
+	mu       sync.Mutex
+	handlers uint32
+	methods  map[string][]string
+}
+
+// methodNotAllowedMiddleware answers requests whose path is registered for
+// other methods: OPTIONS gets 204 and 405s go through the error handler, both
+// with an Allow header listing the registered methods. Requests for unknown
+// paths and CORS preflights continue down the chain unchanged.
+func (s *Server) methodNotAllowedMiddleware() fiber.Handler {
+	table := &routeTable{}
+
+	return func(c *fiber.Ctx) error {
+		preflight := c.Method() == fiber.MethodOptions && c.Get(fiber.HeaderAccessControlRequestMethod) != ""
+		if preflight {
+			return c.Next()
+		}
+
+		methods := table.snapshot(s.app)
+		path := c.Path()
+		if table.matchAny(c.App(), methods[c.Method()], path) {
+			return c.Next()
+		}
+
+		var allowed []string
+		for _, method := range fiber.DefaultMethods {
+			if table.matchAny(c.App(), methods[method], path) {
+				allowed = append(allowed, method)
+			}
+		}
+		if len(allowed) == 0 {
+			return c.Next()
+		}
+		if !slices.Contains(allowed, fiber.MethodOptions) {
+			allowed = append(allowed, fiber.MethodOptions)
+		}
+
+		c.Set(fiber.HeaderAllow, strings.Join(allowed, ", "))
+		if c.Method() == fiber.MethodOptions {
+			return c.SendStatus(fiber.StatusNoContent)
+		}
+		return fiber.ErrMethodNotAllowed
+	}
+}
+
+// snapshot returns the method table, rebuilding it if routes were added.
+func (t *routeTable) snapshot(app *fiber.App) map[string][]string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.methods != nil && t.handlers == app.HandlersCount() {
+		return t.methods
+	}
+	methods := make(map[string][]string)
+	for _, route := range app.GetRoutes(true) {
+		// The catch-all redirect matches everything; it doesn't make a path exist
+		if route.Path == "*" || route.Path == "/*" {
+			continue
+		}
+		methods[route.Method] = append(methods[route.Method], route.Path)
+	}
+	t.handlers = app.HandlersCount()
+	t.methods = methods
+	return methods
+}
+
+// matchAny reports whether any pattern matches path, honoring the app's
+// case-sensitivity and strict-routing settings.
+func (t *routeTable) matchAny(app *fiber.App, patterns []string, path string) bool {
+	cfg := app.Config()
+	for _, pattern := range patterns {
+		if matchRoutePattern(pattern, path, cfg.CaseSensitive, cfg.StrictRouting) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchRoutePattern matches a Fiber route pattern: ":param" (optionally
+// "?"-suffixed) matches one segment, "*" matches the rest of the path and
+// "+" the non-empty rest.
+func matchRoutePattern(pattern, path string, caseSensitive, strict bool) bool {
+	if !caseSensitive {
+		pattern = strings.ToLower(pattern)
+		path = strings.ToLower(path)
+	}
+	if !strict {
+		if len(pattern) > 1 {
+			pattern = strings.TrimSuffix(pattern, "/")
+		}
+		if len(path) > 1 {
+			path = strings.TrimSuffix(path, "/")
+		}
+	}
+
+	patternSegs := strings.Split(strings.TrimPrefix(pattern, "/"), "/")
+	pathSegs := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	for i, seg := range patternSegs {
+		// Wildcards consume the rest of the path, after any literal prefix ("/assets*")
+		if j := strings.IndexAny(seg, "*+"); j >= 0 {
+			rest := ""
+			if i < len(pathSegs) {
+				rest = strings.Join(pathSegs[i:], "/")
+			}
+			return strings.HasPrefix(rest, seg[:j]) && (seg[j] == '*' || len(rest) > j)
+		}
+		if i >= len(pathSegs) {
+			// Only trailing optional parameters may be missing
+			return strings.HasPrefix(seg, ":") && strings.HasSuffix(seg, "?") && i == len(patternSegs)-1
+		}
+		switch {
+		case strings.Contains(seg, ":"):
+			if pathSegs[i] == "" && !strings.HasSuffix(seg, "?") {
+				return false
+			}
+		case seg != pathSegs[i]:
+			return false
+		}
+	}
+	return len(pathSegs) == len(patternSegs)
+}
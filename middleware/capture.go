@@ -0,0 +1,240 @@
+package middleware
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// CaptureConfig configures the RequestCapture middleware.
+type CaptureConfig struct {
+	// Dir receives one JSON fixture per captured request.
+	// Default: "testdata/captures"
+	Dir string
+
+	// Header turns capture on for a request. Its value names the fixture;
+	// "1", "on" or "true" derive a name from the method and path.
+	// Default: "X-Capture"
+	Header string
+
+	// RedactHeaders are stored as "[REDACTED]", request and response alike.
+	// Default: the RequestLogger's RedactHeaders, plus Set-Cookie
+	RedactHeaders []string
+
+	// Logger reports each fixture written. Optional.
+	Logger Logger
+
+	// Next defines a function to skip this middleware when returning true.
+	Next func(c *fiber.Ctx) bool
+}
+
+// DefaultCaptureConfig returns the default configuration.
+func DefaultCaptureConfig() CaptureConfig {
+	return CaptureConfig{
+		Dir:           "testdata/captures",
+		Header:        "X-Capture",
+		RedactHeaders: append(DefaultRequestLoggerConfig().RedactHeaders, fiber.HeaderSetCookie),
+	}
+}
+
+// CaptureFixture is a recorded request and the response it received.
+type CaptureFixture struct {
+	Name       string          `json:"name"`
+	RecordedAt time.Time       `json:"recorded_at"`
+	Request    CapturedMessage `json:"request"`
+	Response   CapturedMessage `json:"response"`
+}
+
+// CapturedMessage is one side of a captured interaction. Method and Path
+// (with the query string) are set on requests, Status on responses.
+type CapturedMessage struct {
+	Method  string            `json:"method,omitempty"`
+	Path    string            `json:"path,omitempty"`
+	Status  int               `json:"status,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+	// Base64 is set when Body holds binary data, base64-encoded.
+	Base64 bool `json:"base64,omitempty"`
+}
+
+// DecodedBody returns the body bytes.
+func (m CapturedMessage) DecodedBody() ([]byte, error) {
+	if m.Base64 {
+		return base64.StdEncoding.DecodeString(m.Body)
+	}
+	return []byte(m.Body), nil
+}
+
+// ReadCaptureFixture reads a fixture written by RequestCapture.
+func ReadCaptureFixture(path string) (*CaptureFixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fixture CaptureFixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("capture: decode %s: %w", path, err)
+	}
+	return &fixture, nil
+}
+
+// fixtureNameUnsafe matches characters replaced in fixture file names.
+var fixtureNameUnsafe = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// RequestCapture records requests carrying the capture header, together
+// with their responses, as JSON fixtures in Dir. Replay them in tests with
+// testsupport's TestServer.Replay to turn manual QA sessions into
+// regression tests. Meant for development only: fixtures hold real request
+// data, with only RedactHeaders masked.
+//
+// Errors are passed to the app's error handler here so the captured
+// response matches what the client received.
+func RequestCapture(config ...CaptureConfig) fiber.Handler {
+	cfg := DefaultCaptureConfig()
+	if len(config) > 0 {
+		cfg = config[0]
+		defaults := DefaultCaptureConfig()
+		if cfg.Dir == "" {
+			cfg.Dir = defaults.Dir
+		}
+		if cfg.Header == "" {
+			cfg.Header = defaults.Header
+		}
+		if cfg.RedactHeaders == nil {
+			cfg.RedactHeaders = defaults.RedactHeaders
+		}
+	}
+	redact := lowerSet(cfg.RedactHeaders)
+
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+		name := c.Get(cfg.Header)
+		if name == "" {
+			return c.Next()
+		}
+
+		fixture := CaptureFixture{
+			Name:       fixtureName(name, c.Method(), c.Path()),
+			RecordedAt: time.Now().UTC(),
+			Request: CapturedMessage{
+				Method:  c.Method(),
+				Path:    string(c.Request().URI().RequestURI()),
+				Headers: capturedHeaders(c.Request().Header.VisitAll, redact, cfg.Header, fiber.HeaderContentLength),
+			},
+		}
+		fixture.Request.Body, fixture.Request.Base64 = encodeBody(c.Body())
+
+		if err := c.Next(); err != nil {
+			if handlerErr := c.App().ErrorHandler(c, err); handlerErr != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		fixture.Response = CapturedMessage{
+			Status:  c.Response().StatusCode(),
+			Headers: capturedHeaders(c.Response().Header.VisitAll, redact, fiber.HeaderContentLength, fiber.HeaderDate),
+		}
+		fixture.Response.Body, fixture.Response.Base64 = encodeBody(c.Response().Body())
+
+		path, err := writeFixture(cfg.Dir, fixture)
+		if cfg.Logger != nil {
+			if err != nil {
+				cfg.Logger.Warn("request capture failed", "fixture", fixture.Name, "error", err)
+			} else {
+				cfg.Logger.Info("request captured", "fixture", path)
+			}
+		}
+		return nil
+	}
+}
+
+// fixtureName returns the fixture name requested by the capture header.
+func fixtureName(value, method, path string) string {
+	switch strings.ToLower(value) {
+	case "1", "on", "true":
+		value = method + " " + path
+	}
+	name := strings.Trim(fixtureNameUnsafe.ReplaceAllString(strings.ToLower(value), "-"), "-")
+	if name == "" {
+		name = strings.ToLower(method)
+	}
+	return name
+}
+
+// capturedHeaders copies headers, redacting sensitive ones and leaving out skip.
+func capturedHeaders(visit func(func(key, value []byte)), redact map[string]bool, skip ...string) map[string]string {
+	headers := make(map[string]string)
+	visit(func(key, value []byte) {
+		name := string(key)
+		for _, s := range skip {
+			if strings.EqualFold(name, s) {
+				return
+			}
+		}
+		if redact[strings.ToLower(name)] {
+			headers[name] = redacted
+			return
+		}
+		if prev, ok := headers[name]; ok {
+			headers[name] = prev + ", " + string(value)
+			return
+		}
+		headers[name] = string(value)
+	})
+	if len(headers) == 0 {
+		return nil
+	}
+	return headers
+}
+
+// encodeBody stores text bodies as is and binary ones as base64.
+func encodeBody(body []byte) (string, bool) {
+	if utf8.Valid(body) {
+		return string(body), false
+	}
+	return base64.StdEncoding.EncodeToString(body), true
+}
+
+// writeFixture writes fixture to dir without overwriting earlier captures
+// of the same name, and returns the file path.
+func writeFixture(dir string, fixture CaptureFixture) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	for i := 1; ; i++ {
+		name := fixture.Name
+		if i > 1 {
+			name = fmt.Sprintf("%s-%d", name, i)
+		}
+		path := filepath.Join(dir, name+".json")
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		_, err = file.Write(append(data, '\n'))
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		return path, err
+	}
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestCapture(t *testing.T) {
+	dir := t.TempDir()
+	app := fiber.New()
+	app.Use(RequestCapture(CaptureConfig{Dir: dir}))
+	app.Post("/orders", func(c *fiber.Ctx) error {
+		c.Cookie(&fiber.Cookie{Name: "session", Value: "secret"})
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{"id": 1, "item": string(c.Body())})
+	})
+	app.Get("/missing", func(c *fiber.Ctx) error { return fiber.ErrNotFound })
+
+	req := httptest.NewRequest("POST", "/orders?ref=home", strings.NewReader("book"))
+	req.Header.Set("X-Capture", "Create Order")
+	req.Header.Set("Authorization", "Bearer token")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+
+	fixture, err := ReadCaptureFixture(filepath.Join(dir, "create-order.json"))
+	require.NoError(t, err)
+	assert.Equal(t, "POST", fixture.Request.Method)
+	assert.Equal(t, "/orders?ref=home", fixture.Request.Path)
+	assert.Equal(t, "book", fixture.Request.Body)
+	assert.Equal(t, redacted, fixture.Request.Headers["Authorization"])
+	assert.NotContains(t, fixture.Request.Headers, "X-Capture")
+	assert.Equal(t, fiber.StatusCreated, fixture.Response.Status)
+	assert.JSONEq(t, `{"id":1,"item":"book"}`, fixture.Response.Body)
+	assert.Equal(t, redacted, fixture.Response.Headers["Set-Cookie"])
+
+	// A second capture under the same name keeps the first
+	req = httptest.NewRequest("POST", "/orders", strings.NewReader("pen"))
+	req.Header.Set("X-Capture", "create-order")
+	_, err = app.Test(req)
+	require.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, "create-order-2.json"))
+	assert.NoError(t, err)
+
+	// Errors are recorded with the status the client received
+	req = httptest.NewRequest("GET", "/missing", nil)
+	req.Header.Set("X-Capture", "1")
+	resp, err = app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	fixture, err = ReadCaptureFixture(filepath.Join(dir, "get-missing.json"))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusNotFound, fixture.Response.Status)
+
+	// Requests without the header are not recorded
+	_, err = app.Test(httptest.NewRequest("GET", "/missing", nil))
+	require.NoError(t, err)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 3)
+}
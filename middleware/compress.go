@@ -0,0 +1,244 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/compress"
+)
+
+// skipCompressKey marks a request whose response must not be compressed.
+const skipCompressKey = "cartridge_skip_compress"
+
+// CompressConfig configures the response compression middleware.
+type CompressConfig struct {
+	// Level trades speed for size: compress.LevelBestSpeed, LevelDefault or
+	// LevelBestCompression. LevelDisabled turns compression off.
+	// Default: compress.LevelDefault
+	Level compress.Level
+
+	// Encodings are the encodings offered, "br", "gzip" or "deflate", in
+	// order of preference when the client accepts several equally. Other
+	// names are ignored. Default: ["br", "gzip"]
+	Encodings []string
+
+	// MinSize leaves smaller bodies uncompressed, since the encoding overhead
+	// outweighs the savings. Default: 1024
+	MinSize int
+
+	// ContentTypes are the media types to compress. An entry ending in "/"
+	// matches the whole type, e.g. "text/". Default: text formats, JSON,
+	// JavaScript, XML, SVG and WebAssembly
+	ContentTypes []string
+
+	// Next defines a function to skip this middleware when returning true.
+	Next func(c *fiber.Ctx) bool
+}
+
+// DefaultCompressConfig returns the default configuration.
+func DefaultCompressConfig() CompressConfig {
+	return CompressConfig{
+		Level:     compress.LevelDefault,
+		Encodings: []string{"br", "gzip"},
+		MinSize:   1024,
+		ContentTypes: []string{
+			"text/",
+			"application/json",
+			"application/problem+json",
+			"application/manifest+json",
+			"application/javascript",
+			"application/xml",
+			"application/rss+xml",
+			"application/atom+xml",
+			"application/wasm",
+			"image/svg+xml",
+		},
+	}
+}
+
+// Compress compresses response bodies with the best encoding the client
+// accepts, honoring Accept-Encoding q-values. Bodies under MinSize, other
+// content types, partial (206) responses, responses that are already
+// encoded or marked Cache-Control: no-transform, and streamed bodies, such
+// as server-sent events and ctx.SendFileRange downloads, are sent as is.
+// Handlers opt out with SkipCompress.
+func Compress(config ...CompressConfig) fiber.Handler {
+	cfg := DefaultCompressConfig()
+	if len(config) > 0 {
+		defaults := cfg
+		cfg = config[0]
+		if len(cfg.Encodings) == 0 {
+			cfg.Encodings = defaults.Encodings
+		}
+		if cfg.MinSize <= 0 {
+			cfg.MinSize = defaults.MinSize
+		}
+		if len(cfg.ContentTypes) == 0 {
+			cfg.ContentTypes = defaults.ContentTypes
+		}
+	}
+	if cfg.Level == compress.LevelDisabled {
+		return func(c *fiber.Ctx) error { return c.Next() }
+	}
+
+	encoders := make([]*encoder, 0, len(cfg.Encodings))
+	for _, name := range cfg.Encodings {
+		if enc := newEncoder(strings.ToLower(name), cfg.Level); enc != nil {
+			encoders = append(encoders, enc)
+		}
+	}
+
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		resp := c.Response()
+		switch {
+		case c.Locals(skipCompressKey) != nil,
+			resp.IsBodyStream(),
+			resp.StatusCode() == fiber.StatusPartialContent,
+			len(resp.Header.Peek(fiber.HeaderContentEncoding)) > 0,
+			len(resp.Body()) < cfg.MinSize,
+			!compressible(string(resp.Header.ContentType()), cfg.ContentTypes),
+			strings.Contains(string(resp.Header.Peek(fiber.HeaderCacheControl)), "no-transform"):
+			return nil
+		}
+
+		c.Vary(fiber.HeaderAcceptEncoding)
+		enc := negotiateEncoding(c.Get(fiber.HeaderAcceptEncoding), encoders)
+		if enc == nil {
+			return nil
+		}
+		body, err := enc.encode(resp.Body())
+		if err != nil {
+			return err
+		}
+		resp.SetBodyRaw(body)
+		resp.Header.Set(fiber.HeaderContentEncoding, enc.name)
+		return nil
+	}
+}
+
+// SkipCompress stops the compression middleware from compressing this
+// response, e.g. for content that is already compressed.
+func SkipCompress(c *fiber.Ctx) {
+	c.Locals(skipCompressKey, true)
+}
+
+// compressible reports whether contentType matches one of types.
+func compressible(contentType string, types []string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	if mediaType == "" {
+		return false
+	}
+	for _, t := range types {
+		if mediaType == t || (strings.HasSuffix(t, "/") && strings.HasPrefix(mediaType, t)) {
+			return true
+		}
+	}
+	return false
+}
+
+// negotiateEncoding returns the encoder the client prefers by q-value,
+// breaking ties by the server's order, or nil if it accepts none.
+func negotiateEncoding(header string, encoders []*encoder) *encoder {
+	if header == "" {
+		return nil
+	}
+	accepted := make(map[string]float64)
+	for _, item := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(item, ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		accepted[strings.ToLower(strings.TrimSpace(name))] = q
+	}
+
+	var best *encoder
+	bestQ := 0.0
+	for _, enc := range encoders {
+		q, ok := accepted[enc.name]
+		if !ok {
+			q = accepted["*"]
+		}
+		if q > bestQ {
+			best, bestQ = enc, q
+		}
+	}
+	return best
+}
+
+// encoder compresses bodies with one encoding, reusing its writers.
+type encoder struct {
+	name    string
+	writers sync.Pool
+}
+
+// resettableWriter is a compressing writer that can be reused.
+type resettableWriter interface {
+	io.WriteCloser
+	Reset(w io.Writer)
+}
+
+// newEncoder returns an encoder for a Content-Encoding name, or nil for
+// unsupported ones.
+func newEncoder(name string, level compress.Level) *encoder {
+	// Brotli's own default, 6, is slow for dynamic responses
+	brLevel, flateLevel := 4, gzip.DefaultCompression
+	switch level {
+	case compress.LevelBestSpeed:
+		brLevel, flateLevel = brotli.BestSpeed, gzip.BestSpeed
+	case compress.LevelBestCompression:
+		brLevel, flateLevel = brotli.BestCompression, gzip.BestCompression
+	}
+
+	enc := &encoder{name: name}
+	switch name {
+	case "br":
+		enc.writers.New = func() any { return brotli.NewWriterLevel(io.Discard, brLevel) }
+	case "gzip":
+		enc.writers.New = func() any {
+			w, _ := gzip.NewWriterLevel(io.Discard, flateLevel) // levels are valid
+			return w
+		}
+	case "deflate":
+		// HTTP's deflate is the zlib format
+		enc.writers.New = func() any {
+			w, _ := zlib.NewWriterLevel(io.Discard, flateLevel)
+			return w
+		}
+	default:
+		return nil
+	}
+	return enc
+}
+
+// encode returns body compressed.
+func (e *encoder) encode(body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := e.writers.Get().(resettableWriter)
+	defer e.writers.Put(w)
+	w.Reset(&buf)
+	if _, err := w.Write(body); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
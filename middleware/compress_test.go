@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func decodeBody(t *testing.T, encoding string, body []byte) string {
+	t.Helper()
+	var r io.Reader = bytes.NewReader(body)
+	switch encoding {
+	case "gzip":
+		zr, err := gzip.NewReader(r)
+		assert.NoError(t, err)
+		r = zr
+	case "deflate":
+		zr, err := zlib.NewReader(r)
+		assert.NoError(t, err)
+		r = zr
+	case "br":
+		r = brotli.NewReader(r)
+	}
+	decoded, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	return string(decoded)
+}
+
+func TestCompress(t *testing.T) {
+	page := strings.Repeat("<p>hello</p>", 200)
+
+	app := fiber.New()
+	app.Use(Compress(CompressConfig{Encodings: []string{"br", "gzip", "deflate"}}))
+	app.Get("/page", func(c *fiber.Ctx) error {
+		c.Type("html")
+		return c.SendString(page)
+	})
+	app.Get("/small", func(c *fiber.Ctx) error {
+		return c.SendString("tiny")
+	})
+	app.Get("/image", func(c *fiber.Ctx) error {
+		c.Type("png")
+		return c.Send(bytes.Repeat([]byte{0}, 4096))
+	})
+	app.Get("/skip", func(c *fiber.Ctx) error {
+		SkipCompress(c)
+		return c.SendString(page)
+	})
+	app.Get("/events", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, "text/event-stream")
+		c.Response().SetBodyStream(strings.NewReader(page), -1)
+		return nil
+	})
+
+	get := func(path, acceptEncoding string) (string, string, string) {
+		req := httptest.NewRequest("GET", path, nil)
+		if acceptEncoding != "" {
+			req.Header.Set(fiber.HeaderAcceptEncoding, acceptEncoding)
+		}
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		return resp.Header.Get(fiber.HeaderContentEncoding), resp.Header.Get(fiber.HeaderVary), string(body)
+	}
+
+	tests := []struct{ accept, want string }{
+		{"gzip, deflate, br", "br"},
+		{"gzip", "gzip"},
+		{"deflate", "deflate"},
+		{"br;q=0.5, gzip", "gzip"},
+		{"br;q=0, *", "gzip"},
+		{"identity", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		encoding, vary, body := get("/page", tt.accept)
+		assert.Equal(t, tt.want, encoding, tt.accept)
+		assert.Equal(t, "Accept-Encoding", vary, tt.accept)
+		assert.Equal(t, page, decodeBody(t, encoding, []byte(body)), tt.accept)
+	}
+
+	for _, path := range []string{"/small", "/image", "/skip", "/events"} {
+		encoding, _, _ := get(path, "gzip, br")
+		assert.Empty(t, encoding, path)
+	}
+}
+
+func TestCompress_Thresholds(t *testing.T) {
+	app := fiber.New()
+	app.Use(Compress(CompressConfig{MinSize: 10, ContentTypes: []string{"application/x-ndjson"}}))
+	app.Get("/ndjson", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, "application/x-ndjson; charset=utf-8")
+		return c.SendString(`{"a":1}` + "\n" + `{"a":2}` + "\n")
+	})
+	app.Get("/text", func(c *fiber.Ctx) error {
+		return c.SendString(strings.Repeat("text ", 100))
+	})
+
+	for path, want := range map[string]string{"/ndjson": "br", "/text": ""} {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set(fiber.HeaderAcceptEncoding, "br")
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, want, resp.Header.Get(fiber.HeaderContentEncoding), path)
+	}
+}
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -21,19 +22,22 @@ type Logger interface {
 // This is particularly useful for SQLite with WAL mode, which allows
 // one writer + multiple readers concurrently.
 type ConcurrencyLimiter struct {
-	readSem  *semaphore.Weighted
-	writeSem *semaphore.Weighted
-	timeout  time.Duration
-	logger   Logger
+	readSem    *semaphore.Weighted
+	writeSem   *semaphore.Weighted
+	writeLimit int64
+	writes     atomic.Int64 // writes holding the semaphore
+	timeout    time.Duration
+	logger     Logger
 }
 
 // NewConcurrencyLimiter creates a limiter with the provided thresholds.
 func NewConcurrencyLimiter(readLimit, writeLimit int64, timeout time.Duration, logger Logger) *ConcurrencyLimiter {
 	return &ConcurrencyLimiter{
-		readSem:  semaphore.NewWeighted(readLimit),
-		writeSem: semaphore.NewWeighted(writeLimit),
-		timeout:  timeout,
-		logger:   logger,
+		readSem:    semaphore.NewWeighted(readLimit),
+		writeSem:   semaphore.NewWeighted(writeLimit),
+		writeLimit: writeLimit,
+		timeout:    timeout,
+		logger:     logger,
 	}
 }
 
@@ -44,7 +48,11 @@ func (cl *ConcurrencyLimiter) AcquireRead(ctx context.Context) error {
 
 // AcquireWrite acquires a write semaphore.
 func (cl *ConcurrencyLimiter) AcquireWrite(ctx context.Context) error {
-	return cl.writeSem.Acquire(ctx, 1)
+	if err := cl.writeSem.Acquire(ctx, 1); err != nil {
+		return err
+	}
+	cl.writes.Add(1)
+	return nil
 }
 
 // ReleaseRead releases a read semaphore.
@@ -54,9 +62,23 @@ func (cl *ConcurrencyLimiter) ReleaseRead() {
 
 // ReleaseWrite releases a write semaphore.
 func (cl *ConcurrencyLimiter) ReleaseWrite() {
+	cl.writes.Add(-1)
 	cl.writeSem.Release(1)
 }
 
+// ActiveWrites returns the number of write operations in progress.
+func (cl *ConcurrencyLimiter) ActiveWrites() int64 {
+	return cl.writes.Load()
+}
+
+// WriteLoad returns the fraction of the write limit in use, from 0 to 1.
+func (cl *ConcurrencyLimiter) WriteLoad() float64 {
+	if cl.writeLimit <= 0 {
+		return 0
+	}
+	return float64(cl.writes.Load()) / float64(cl.writeLimit)
+}
+
 // WriteConcurrencyLimitMiddleware limits concurrent write operations to protect database integrity.
 // For SQLite with WAL mode, this prevents write contention while allowing reasonable concurrency.
 func WriteConcurrencyLimitMiddleware(limiter *ConcurrencyLimiter) fiber.Handler {
@@ -156,3 +156,26 @@ func TestConcurrencyLimiter_ContextCancellation(t *testing.T) {
 
 	limiter.ReleaseWrite()
 }
+
+func TestConcurrencyLimiter_WriteLoad(t *testing.T) {
+	limiter := NewConcurrencyLimiter(10, 4, time.Second, &mockLogger{})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if err := limiter.AcquireWrite(ctx); err != nil {
+			t.Fatalf("AcquireWrite failed: %v", err)
+		}
+	}
+	if got := limiter.ActiveWrites(); got != 2 {
+		t.Errorf("expected 2 active writes, got %d", got)
+	}
+	if got := limiter.WriteLoad(); got != 0.5 {
+		t.Errorf("expected write load 0.5, got %v", got)
+	}
+
+	limiter.ReleaseWrite()
+	limiter.ReleaseWrite()
+	if got := limiter.WriteLoad(); got != 0 {
+		t.Errorf("expected write load 0 after release, got %v", got)
+	}
+}
@@ -0,0 +1,226 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// CORSConfig configures the CORS middleware.
+type CORSConfig struct {
+	// AllowOrigins lists allowed origins. Entries are exact origins
+	// ("https://app.example.com"), wildcard subdomain patterns
+	// ("https://*.example.com", which does not match the apex domain), or "*".
+	AllowOrigins []string
+
+	// AllowOriginFunc is consulted for origins not matched by AllowOrigins,
+	// e.g. to check a tenant's custom domain in the database. Optional.
+	AllowOriginFunc func(origin string) bool
+
+	// AllowMethods lists methods allowed in preflight requests.
+	// Default: ["GET", "POST", "PUT", "DELETE", "PATCH", "OPTIONS"]
+	AllowMethods []string
+
+	// AllowHeaders lists request headers allowed in preflight requests.
+	// Default: ["Origin", "Content-Type", "Accept", "Authorization"]
+	AllowHeaders []string
+
+	// ExposeHeaders lists response headers readable by the browser.
+	ExposeHeaders []string
+
+	// AllowCredentials allows cookies and Authorization headers.
+	// Cannot be combined with AllowOrigins: ["*"].
+	AllowCredentials bool
+
+	// MaxAge is how long, in seconds, browsers may cache preflight results.
+	MaxAge int
+
+	// Next defines a function to skip this middleware when returning true.
+	Next func(c *fiber.Ctx) bool
+}
+
+// DefaultCORSConfig returns a permissive configuration without credentials.
+func DefaultCORSConfig() CORSConfig {
+	return CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{"GET", "POST", "PUT", "DELETE", "PATCH", "OPTIONS"},
+		AllowHeaders: []string{"Origin", "Content-Type", "Accept", "Authorization"},
+	}
+}
+
+// DevelopmentCORS allows the given origins plus any http://localhost and
+// http://127.0.0.1 port, with credentials, for local frontends such as Vite.
+func DevelopmentCORS(origins ...string) CORSConfig {
+	cfg := DefaultCORSConfig()
+	cfg.AllowOrigins = origins
+	cfg.AllowOriginFunc = isLoopbackOrigin
+	cfg.AllowCredentials = true
+	return cfg
+}
+
+// ProductionCORS allows only the given origins and wildcard subdomain
+// patterns, with credentials. Without origins it allows none, so a missing
+// CORS_ORIGINS setting fails closed. Validate rejects "*" in the list.
+func ProductionCORS(origins ...string) CORSConfig {
+	cfg := DefaultCORSConfig()
+	cfg.AllowOrigins = origins
+	cfg.AllowCredentials = true
+	cfg.MaxAge = 600
+	return cfg
+}
+
+// CORSForEnvironment returns DevelopmentCORS for "development" and "test",
+// and ProductionCORS otherwise.
+func CORSForEnvironment(env string, origins ...string) CORSConfig {
+	switch env {
+	case "development", "test":
+		return DevelopmentCORS(origins...)
+	default:
+		return ProductionCORS(origins...)
+	}
+}
+
+// Validate reports configuration mistakes: malformed origin patterns and
+// wildcard origins combined with credentials.
+func (cfg CORSConfig) Validate() error {
+	for _, origin := range cfg.AllowOrigins {
+		if origin == "*" {
+			if cfg.AllowCredentials {
+				return errors.New(`cors: AllowOrigins "*" cannot be used with AllowCredentials`)
+			}
+			continue
+		}
+		scheme, host, ok := strings.Cut(origin, "://")
+		if !ok || scheme == "" || host == "" || strings.Contains(host, "/") {
+			return fmt.Errorf("cors: invalid origin %q (want scheme://host[:port])", origin)
+		}
+		if strings.Contains(host, "*") && (!strings.HasPrefix(host, "*.") || strings.Count(host, "*") > 1) {
+			return fmt.Errorf("cors: invalid wildcard origin %q (want scheme://*.domain)", origin)
+		}
+	}
+	return nil
+}
+
+// originMatcher compiles AllowOrigins and AllowOriginFunc into one check.
+func (cfg CORSConfig) originMatcher() (matchAll bool, match func(origin string) bool) {
+	exact := make(map[string]bool)
+	var wildcards [][2]string // scheme, ".domain[:port]"
+	for _, origin := range cfg.AllowOrigins {
+		origin = strings.ToLower(strings.TrimSuffix(origin, "/"))
+		if origin == "*" {
+			matchAll = true
+			continue
+		}
+		scheme, host, _ := strings.Cut(origin, "://")
+		if strings.HasPrefix(host, "*.") {
+			wildcards = append(wildcards, [2]string{scheme, host[1:]})
+			continue
+		}
+		exact[origin] = true
+	}
+
+	return matchAll, func(origin string) bool {
+		origin = strings.ToLower(origin)
+		if matchAll || exact[origin] {
+			return true
+		}
+		scheme, host, ok := strings.Cut(origin, "://")
+		if ok {
+			for _, w := range wildcards {
+				sub := strings.TrimSuffix(host, w[1])
+				if scheme == w[0] && sub != host && sub != "" && !strings.ContainsAny(sub, ":/") {
+					return true
+				}
+			}
+		}
+		return cfg.AllowOriginFunc != nil && cfg.AllowOriginFunc(origin)
+	}
+}
+
+// isLoopbackOrigin reports whether origin is http://localhost or
+// http://127.0.0.1 on any port.
+func isLoopbackOrigin(origin string) bool {
+	host, ok := strings.CutPrefix(origin, "http://")
+	if !ok {
+		return false
+	}
+	name, port, hasPort := strings.Cut(host, ":")
+	if hasPort {
+		if _, err := strconv.Atoi(port); err != nil {
+			return false
+		}
+	}
+	return name == "localhost" || name == "127.0.0.1"
+}
+
+// CORS returns a CORS middleware supporting wildcard subdomains and dynamic
+// origin checks. Disallowed origins get no CORS headers, so browsers block
+// the response. It panics if the configuration is invalid.
+func CORS(config ...CORSConfig) fiber.Handler {
+	cfg := DefaultCORSConfig()
+	if len(config) > 0 {
+		cfg = config[0]
+		if cfg.AllowMethods == nil {
+			cfg.AllowMethods = DefaultCORSConfig().AllowMethods
+		}
+		if cfg.AllowHeaders == nil {
+			cfg.AllowHeaders = DefaultCORSConfig().AllowHeaders
+		}
+	}
+	if err := cfg.Validate(); err != nil {
+		panic(err)
+	}
+
+	matchAll, allowed := cfg.originMatcher()
+	allowMethods := strings.Join(cfg.AllowMethods, ", ")
+	allowHeaders := strings.Join(cfg.AllowHeaders, ", ")
+	exposeHeaders := strings.Join(cfg.ExposeHeaders, ", ")
+	maxAge := strconv.Itoa(cfg.MaxAge)
+
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		origin := c.Get(fiber.HeaderOrigin)
+		preflight := c.Method() == fiber.MethodOptions && c.Get(fiber.HeaderAccessControlRequestMethod) != ""
+		if !matchAll || cfg.AllowCredentials {
+			c.Vary(fiber.HeaderOrigin)
+		}
+		if origin == "" {
+			return c.Next()
+		}
+
+		ok := allowed(origin)
+		if ok {
+			if matchAll && !cfg.AllowCredentials {
+				c.Set(fiber.HeaderAccessControlAllowOrigin, "*")
+			} else {
+				c.Set(fiber.HeaderAccessControlAllowOrigin, origin)
+			}
+			if cfg.AllowCredentials {
+				c.Set(fiber.HeaderAccessControlAllowCredentials, "true")
+			}
+		}
+
+		if !preflight {
+			if ok && exposeHeaders != "" {
+				c.Set(fiber.HeaderAccessControlExposeHeaders, exposeHeaders)
+			}
+			return c.Next()
+		}
+
+		c.Vary(fiber.HeaderAccessControlRequestMethod, fiber.HeaderAccessControlRequestHeaders)
+		if ok {
+			c.Set(fiber.HeaderAccessControlAllowMethods, allowMethods)
+			c.Set(fiber.HeaderAccessControlAllowHeaders, allowHeaders)
+			if cfg.MaxAge > 0 {
+				c.Set(fiber.HeaderAccessControlMaxAge, maxAge)
+			}
+		}
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+}
@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func corsRequest(t *testing.T, app *fiber.App, method, origin string) (int, string) {
+	t.Helper()
+	req := httptest.NewRequest(method, "/test", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	if method == fiber.MethodOptions {
+		req.Header.Set("Access-Control-Request-Method", "POST")
+	}
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	return resp.StatusCode, resp.Header.Get("Access-Control-Allow-Origin")
+}
+
+func newCORSApp(cfg CORSConfig) *fiber.App {
+	app := fiber.New()
+	app.Use(CORS(cfg))
+	app.Get("/test", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	return app
+}
+
+func TestCORS(t *testing.T) {
+	t.Run("matches exact and wildcard subdomain origins", func(t *testing.T) {
+		app := newCORSApp(ProductionCORS("https://example.com", "https://*.example.com"))
+
+		tests := map[string]bool{
+			"https://example.com":          true,
+			"https://app.example.com":      true,
+			"https://a.b.example.com":      true,
+			"http://app.example.com":       false,
+			"https://example.com.evil.io":  false,
+			"https://evilexample.com":      false,
+			"https://app.example.com:8443": false,
+		}
+		for origin, want := range tests {
+			_, allowOrigin := corsRequest(t, app, fiber.MethodGet, origin)
+			if want {
+				assert.Equal(t, origin, allowOrigin, origin)
+			} else {
+				assert.Empty(t, allowOrigin, origin)
+			}
+		}
+	})
+
+	t.Run("consults AllowOriginFunc", func(t *testing.T) {
+		cfg := ProductionCORS()
+		cfg.AllowOriginFunc = func(origin string) bool { return origin == "https://tenant.io" }
+		app := newCORSApp(cfg)
+
+		status, allowOrigin := corsRequest(t, app, fiber.MethodOptions, "https://tenant.io")
+		assert.Equal(t, fiber.StatusNoContent, status)
+		assert.Equal(t, "https://tenant.io", allowOrigin)
+
+		_, allowOrigin = corsRequest(t, app, fiber.MethodOptions, "https://other.io")
+		assert.Empty(t, allowOrigin)
+	})
+
+	t.Run("wildcard without credentials", func(t *testing.T) {
+		app := newCORSApp(DefaultCORSConfig())
+		_, allowOrigin := corsRequest(t, app, fiber.MethodGet, "https://anywhere.io")
+		assert.Equal(t, "*", allowOrigin)
+	})
+
+	t.Run("development allows loopback origins", func(t *testing.T) {
+		app := newCORSApp(CORSForEnvironment("development"))
+		_, allowOrigin := corsRequest(t, app, fiber.MethodGet, "http://localhost:5173")
+		assert.Equal(t, "http://localhost:5173", allowOrigin)
+
+		app = newCORSApp(CORSForEnvironment("production", "https://app.example.com"))
+		_, allowOrigin = corsRequest(t, app, fiber.MethodGet, "http://localhost:5173")
+		assert.Empty(t, allowOrigin)
+	})
+
+	t.Run("production without origins denies all", func(t *testing.T) {
+		app := newCORSApp(CORSForEnvironment("production"))
+		status, allowOrigin := corsRequest(t, app, fiber.MethodOptions, "https://app.example.com")
+		assert.Equal(t, fiber.StatusNoContent, status)
+		assert.Empty(t, allowOrigin)
+	})
+}
+
+func TestCORSConfigValidate(t *testing.T) {
+	assert.Error(t, ProductionCORS("*").Validate(), "wildcard with credentials")
+	assert.Error(t, ProductionCORS("example.com").Validate(), "missing scheme")
+	assert.Error(t, ProductionCORS("https://app.*.example.com").Validate(), "inner wildcard")
+	assert.NoError(t, ProductionCORS().Validate(), "no origins denies all")
+	assert.NoError(t, ProductionCORS("https://*.example.com").Validate())
+	assert.NoError(t, DefaultCORSConfig().Validate())
+
+	assert.Panics(t, func() { CORS(ProductionCORS("*")) })
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSP_Build(t *testing.T) {
+	t.Run("quotes keywords and adds the nonce to scripts", func(t *testing.T) {
+		policy := NewCSP().
+			AllowScripts("self", "https://cdn.example.com").
+			AllowStyles("self", "nonce").
+			AllowImages("self", "data:").
+			FrameAncestors("none").
+			ReportURI("/csp-reports")
+
+		assert.Equal(t, "default-src 'self'; "+
+			"script-src 'self' https://cdn.example.com 'nonce-abc'; "+
+			"style-src 'self' 'nonce-abc'; "+
+			"img-src 'self' data:; "+
+			"frame-ancestors 'none'; "+
+			"report-uri /csp-reports", policy.Build("abc"))
+	})
+
+	t.Run("derives script-src from default-src", func(t *testing.T) {
+		policy := NewCSP().UpgradeInsecureRequests()
+		assert.Equal(t, "default-src 'self'; upgrade-insecure-requests; script-src 'self' 'nonce-abc'", policy.Build("abc"))
+		assert.Equal(t, "default-src 'self'; upgrade-insecure-requests", policy.Build(""))
+	})
+
+	t.Run("merges repeated directives", func(t *testing.T) {
+		policy := NewCSP().AllowScripts("self", "nonce").AllowScripts("sha256-xyz=")
+		assert.Equal(t, "default-src 'self'; script-src 'self' 'nonce-n' 'sha256-xyz='", policy.Build("n"))
+	})
+}
+
+func TestHelmet_CSPNonce(t *testing.T) {
+	headers := DefaultSecurityHeaders()
+	headers.CSP = NewCSP().AllowScripts("self")
+
+	app := fiber.New()
+	app.Use(Helmet(headers))
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendString(CSPNonce(c)) })
+
+	nonces := make(map[string]bool)
+	for range 2 {
+		resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+		require.NoError(t, err)
+		body := make([]byte, 64)
+		n, _ := resp.Body.Read(body)
+		nonce := string(body[:n])
+
+		require.NotEmpty(t, nonce)
+		assert.True(t, strings.HasSuffix(resp.Header.Get("Content-Security-Policy"), "script-src 'self' 'nonce-"+nonce+"'"))
+		nonces[nonce] = true
+	}
+	assert.Len(t, nonces, 2, "every request should get a fresh nonce")
+}
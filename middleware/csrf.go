@@ -0,0 +1,288 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ErrCSRFTokenInvalid is passed to CSRFConfig.ErrorHandler when a request's
+// token is missing, expired or doesn't match its cookie.
+var ErrCSRFTokenInvalid = errors.New("csrf: invalid token")
+
+// CSRFConfig configures the CSRF middleware.
+type CSRFConfig struct {
+	// Secret signs tokens so they can't be forged or altered. Use the same
+	// secret on every instance. Default: a random key per process
+	Secret []byte
+
+	// Binding returns what tokens are bound to, typically the signed-in
+	// user's ID. A token issued under one binding is rejected under another,
+	// so a sibling subdomain that can set cookies can't plant a token minted
+	// for its own visitor. When the binding changes during a request, e.g.
+	// at login, a new token is issued. Default: nil (a valid token is
+	// accepted from any visitor)
+	Binding func(c *fiber.Ctx) string
+
+	// CookieName is the cookie holding the token. Default: "csrf_token"
+	CookieName string
+
+	// CookieSecure requires HTTPS for the cookie. Enable in production.
+	CookieSecure bool
+
+	// CookieReadable lets JavaScript read the cookie, for clients that send
+	// the token from it, as Inertia's axios does. Default: false (HttpOnly)
+	CookieReadable bool
+
+	// CookieSameSite is the SameSite attribute. Default: "Lax"
+	CookieSameSite string
+
+	// HeaderName is checked for the submitted token first. Default: "X-CSRF-Token"
+	HeaderName string
+
+	// FormField is checked for the submitted token when the header is
+	// missing. Default: "_csrf"
+	FormField string
+
+	// Expiration is how long a token is valid. Tokens past half their life
+	// are reissued on the next request. Default: 12 hours
+	Expiration time.Duration
+
+	// ExcludedPaths skip validation, e.g. webhooks. Entries are exact paths
+	// or prefixes ending in "*" ("/webhooks/*").
+	ExcludedPaths []string
+
+	// Methods specifies which HTTP methods require a token.
+	// Default: ["POST", "PUT", "DELETE", "PATCH"]
+	Methods []string
+
+	// ContextKey is the c.Locals key the token is stored under for
+	// templates and handlers. Default: "csrf"
+	ContextKey string
+
+	// ErrorHandler answers rejected requests. Default: 403 with a JSON body
+	ErrorHandler func(c *fiber.Ctx, err error) error
+
+	// Next defines a function to skip this middleware when returning true.
+	Next func(c *fiber.Ctx) bool
+}
+
+// DefaultCSRFConfig returns the default configuration.
+func DefaultCSRFConfig() CSRFConfig {
+	return CSRFConfig{
+		CookieName:     "csrf_token",
+		CookieSameSite: "Lax",
+		HeaderName:     "X-CSRF-Token",
+		FormField:      "_csrf",
+		Expiration:     12 * time.Hour,
+		Methods:        []string{"POST", "PUT", "DELETE", "PATCH"},
+		ContextKey:     "csrf",
+	}
+}
+
+// InertiaCSRFConfig uses the XSRF-TOKEN cookie and X-XSRF-TOKEN header that
+// axios, and so Inertia, handle automatically.
+func InertiaCSRFConfig() CSRFConfig {
+	cfg := DefaultCSRFConfig()
+	cfg.CookieName = "XSRF-TOKEN"
+	cfg.CookieReadable = true
+	cfg.HeaderName = "X-XSRF-TOKEN"
+	return cfg
+}
+
+// csrfRotateKey marks a request whose token must be replaced.
+const csrfRotateKey = "csrf_rotate"
+
+// CSRF protects against cross-site request forgery with signed
+// double-submit cookies. Every request gets a token in a cookie and in
+// c.Locals(ContextKey); requests with protected methods must echo it in the
+// HeaderName header or the FormField form field.
+//
+//	<input type="hidden" name="_csrf" value="{{ .Helpers.CSRFToken }}">
+func CSRF(config ...CSRFConfig) fiber.Handler {
+	cfg := DefaultCSRFConfig()
+	if len(config) > 0 {
+		cfg = config[0]
+		defaults := DefaultCSRFConfig()
+		if cfg.CookieName == "" {
+			cfg.CookieName = defaults.CookieName
+		}
+		if cfg.CookieSameSite == "" {
+			cfg.CookieSameSite = defaults.CookieSameSite
+		}
+		if cfg.HeaderName == "" {
+			cfg.HeaderName = defaults.HeaderName
+		}
+		if cfg.FormField == "" {
+			cfg.FormField = defaults.FormField
+		}
+		if cfg.Expiration <= 0 {
+			cfg.Expiration = defaults.Expiration
+		}
+		if cfg.Methods == nil {
+			cfg.Methods = defaults.Methods
+		}
+		if cfg.ContextKey == "" {
+			cfg.ContextKey = defaults.ContextKey
+		}
+	}
+	if len(cfg.Secret) == 0 {
+		cfg.Secret = make([]byte, 32)
+		if _, err := rand.Read(cfg.Secret); err != nil {
+			panic("csrf: generate secret: " + err.Error())
+		}
+	}
+	if cfg.ErrorHandler == nil {
+		cfg.ErrorHandler = func(c *fiber.Ctx, err error) error {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error":   "forbidden",
+				"message": "invalid CSRF token",
+			})
+		}
+	}
+
+	methodSet := make(map[string]bool, len(cfg.Methods))
+	for _, m := range cfg.Methods {
+		methodSet[m] = true
+	}
+
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+		if csrfExcluded(cfg.ExcludedPaths, c.Path()) {
+			return c.Next()
+		}
+
+		binding := csrfBinding(c, cfg)
+		token := c.Cookies(cfg.CookieName)
+		issued, valid := verifyCSRFToken(cfg.Secret, token, binding, cfg.Expiration)
+
+		if methodSet[c.Method()] {
+			submitted := c.Get(cfg.HeaderName)
+			if submitted == "" {
+				submitted = c.FormValue(cfg.FormField)
+			}
+			if !valid || subtle.ConstantTimeCompare([]byte(submitted), []byte(token)) != 1 {
+				return cfg.ErrorHandler(c, ErrCSRFTokenInvalid)
+			}
+		}
+
+		if !valid || time.Since(issued) > cfg.Expiration/2 {
+			token = newCSRFToken(cfg.Secret, binding)
+			setCSRFCookie(c, cfg, token)
+		}
+		c.Locals(cfg.ContextKey, token)
+
+		err := c.Next()
+
+		// Replace the token after login or logout (see RotateCSRFToken)
+		rotate, _ := c.Locals(csrfRotateKey).(bool)
+		if current := csrfBinding(c, cfg); current != binding {
+			binding = current
+			rotate = true
+		}
+		if rotate {
+			token = newCSRFToken(cfg.Secret, binding)
+			setCSRFCookie(c, cfg, token)
+			c.Locals(cfg.ContextKey, token)
+		}
+		return err
+	}
+}
+
+// csrfBinding returns cfg.Binding's value for the request, or "".
+func csrfBinding(c *fiber.Ctx, cfg CSRFConfig) string {
+	if cfg.Binding == nil {
+		return ""
+	}
+	return cfg.Binding(c)
+}
+
+// RotateCSRFToken issues a new CSRF token once the handler returns. Call it
+// when the user signs in or out, so a token seen before can't be reused.
+func RotateCSRFToken(c *fiber.Ctx) {
+	c.Locals(csrfRotateKey, true)
+}
+
+// csrfExcluded reports whether path matches an excluded path or prefix.
+func csrfExcluded(excluded []string, path string) bool {
+	for _, p := range excluded {
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		} else if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// newCSRFToken returns "<random>.<issued unix>.<signature>". The signature
+// also covers binding, which the token doesn't carry.
+func newCSRFToken(secret []byte, binding string) string {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		panic("csrf: generate token: " + err.Error())
+	}
+	payload := base64.RawURLEncoding.EncodeToString(b) + "." + strconv.FormatInt(time.Now().Unix(), 10)
+	return payload + "." + signCSRFToken(secret, payload, binding)
+}
+
+// verifyCSRFToken checks the signature, binding and age of token and returns
+// when it was issued.
+func verifyCSRFToken(secret []byte, token, binding string, expiration time.Duration) (time.Time, bool) {
+	i := strings.LastIndexByte(token, '.')
+	if i < 0 {
+		return time.Time{}, false
+	}
+	payload, sig := token[:i], token[i+1:]
+	if !hmac.Equal([]byte(sig), []byte(signCSRFToken(secret, payload, binding))) {
+		return time.Time{}, false
+	}
+
+	_, ts, ok := strings.Cut(payload, ".")
+	if !ok {
+		return time.Time{}, false
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	issued := time.Unix(unix, 0)
+	if time.Since(issued) > expiration {
+		return time.Time{}, false
+	}
+	return issued, true
+}
+
+func signCSRFToken(secret []byte, payload, binding string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	if binding != "" {
+		// "|" can't appear in the payload, so the two can't be confused
+		mac.Write([]byte("|" + binding))
+	}
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func setCSRFCookie(c *fiber.Ctx, cfg CSRFConfig, token string) {
+	c.Cookie(&fiber.Cookie{
+		Name:     cfg.CookieName,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   int(cfg.Expiration.Seconds()),
+		Secure:   cfg.CookieSecure,
+		HTTPOnly: !cfg.CookieReadable,
+		SameSite: cfg.CookieSameSite,
+	})
+}
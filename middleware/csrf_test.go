@@ -0,0 +1,207 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCSRFTestApp(cfg CSRFConfig) *fiber.App {
+	app := fiber.New()
+	app.Use(CSRF(cfg))
+	app.Get("/form", func(c *fiber.Ctx) error {
+		return c.SendString(c.Locals("csrf").(string))
+	})
+	app.Post("/submit", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	app.Post("/login", func(c *fiber.Ctx) error {
+		RotateCSRFToken(c)
+		return c.SendStatus(fiber.StatusOK)
+	})
+	app.Post("/webhooks/stripe", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	return app
+}
+
+// csrfCookie returns the CSRF cookie set by resp, or nil.
+func csrfCookie(resp *http.Response, name string) *http.Cookie {
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+	return nil
+}
+
+func TestCSRF(t *testing.T) {
+	cfg := DefaultCSRFConfig()
+	cfg.Secret = []byte("test-secret")
+	cfg.ExcludedPaths = []string{"/webhooks/*"}
+	app := newCSRFTestApp(cfg)
+
+	// fetchToken issues a token the way a page load would
+	fetchToken := func(t *testing.T) string {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest("GET", "/form", nil))
+		require.NoError(t, err)
+		cookie := csrfCookie(resp, "csrf_token")
+		require.NotNil(t, cookie, "GET should issue a token cookie")
+		assert.True(t, cookie.HttpOnly)
+		return cookie.Value
+	}
+
+	post := func(path, cookie, header, form string) int {
+		var req *http.Request
+		if form != "" {
+			req = httptest.NewRequest("POST", path, strings.NewReader(url.Values{"_csrf": {form}}.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		} else {
+			req = httptest.NewRequest("POST", path, nil)
+		}
+		if cookie != "" {
+			req.AddCookie(&http.Cookie{Name: "csrf_token", Value: cookie})
+		}
+		if header != "" {
+			req.Header.Set("X-CSRF-Token", header)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	t.Run("rejects requests without a token", func(t *testing.T) {
+		assert.Equal(t, fiber.StatusForbidden, post("/submit", "", "", ""))
+		assert.Equal(t, fiber.StatusForbidden, post("/submit", fetchToken(t), "", ""))
+	})
+
+	t.Run("accepts the token in the header or form", func(t *testing.T) {
+		token := fetchToken(t)
+		assert.Equal(t, fiber.StatusOK, post("/submit", token, token, ""))
+		assert.Equal(t, fiber.StatusOK, post("/submit", token, "", token))
+	})
+
+	t.Run("rejects mismatched and forged tokens", func(t *testing.T) {
+		assert.Equal(t, fiber.StatusForbidden, post("/submit", fetchToken(t), fetchToken(t), ""))
+
+		forged := "abc." + strconv.FormatInt(time.Now().Unix(), 10) + ".bogus"
+		assert.Equal(t, fiber.StatusForbidden, post("/submit", forged, forged, ""))
+	})
+
+	t.Run("rejects expired tokens", func(t *testing.T) {
+		payload := "abc.1"
+		expired := payload + "." + signCSRFToken(cfg.Secret, payload, "")
+		assert.Equal(t, fiber.StatusForbidden, post("/submit", expired, expired, ""))
+	})
+
+	t.Run("skips excluded paths", func(t *testing.T) {
+		assert.Equal(t, fiber.StatusOK, post("/webhooks/stripe", "", "", ""))
+	})
+
+	t.Run("rotates the token on request", func(t *testing.T) {
+		token := fetchToken(t)
+		req := httptest.NewRequest("POST", "/login", nil)
+		req.AddCookie(&http.Cookie{Name: "csrf_token", Value: token})
+		req.Header.Set("X-CSRF-Token", token)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		rotated := csrfCookie(resp, "csrf_token")
+		require.NotNil(t, rotated)
+		assert.NotEqual(t, token, rotated.Value)
+	})
+
+	t.Run("keeps a fresh token", func(t *testing.T) {
+		token := fetchToken(t)
+		req := httptest.NewRequest("GET", "/form", nil)
+		req.AddCookie(&http.Cookie{Name: "csrf_token", Value: token})
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		assert.Nil(t, csrfCookie(resp, "csrf_token"), "a fresh token shouldn't be reissued")
+	})
+}
+
+func TestCSRF_Binding(t *testing.T) {
+	cfg := DefaultCSRFConfig()
+	cfg.Secret = []byte("test-secret")
+	cfg.Binding = func(c *fiber.Ctx) string {
+		if user, ok := c.Locals("user").(string); ok {
+			return user
+		}
+		return c.Cookies("user")
+	}
+	app := fiber.New()
+	app.Use(CSRF(cfg))
+	app.Get("/form", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	app.Post("/submit", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	app.Post("/login", func(c *fiber.Ctx) error {
+		c.Locals("user", "alice") // as if the auth cookie was just set
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	send := func(method, path, user, token string) *http.Response {
+		req := httptest.NewRequest(method, path, nil)
+		if user != "" {
+			req.AddCookie(&http.Cookie{Name: "user", Value: user})
+		}
+		if token != "" {
+			req.AddCookie(&http.Cookie{Name: "csrf_token", Value: token})
+			req.Header.Set("X-CSRF-Token", token)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp
+	}
+	tokenFor := func(user string) string {
+		cookie := csrfCookie(send("GET", "/form", user, ""), "csrf_token")
+		require.NotNil(t, cookie)
+		return cookie.Value
+	}
+
+	alice, mallory := tokenFor("alice"), tokenFor("mallory")
+	assert.Equal(t, fiber.StatusOK, send("POST", "/submit", "alice", alice).StatusCode)
+	assert.Equal(t, fiber.StatusForbidden, send("POST", "/submit", "alice", mallory).StatusCode,
+		"a token planted from another session must be rejected")
+	assert.Equal(t, fiber.StatusForbidden, send("POST", "/submit", "alice", tokenFor("")).StatusCode)
+
+	// Signing in changes the binding, so the token is replaced
+	anonymous := tokenFor("")
+	resp := send("POST", "/login", "", anonymous)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	rotated := csrfCookie(resp, "csrf_token")
+	require.NotNil(t, rotated)
+	assert.Equal(t, fiber.StatusOK, send("POST", "/submit", "alice", rotated.Value).StatusCode)
+}
+
+func TestCSRF_Inertia(t *testing.T) {
+	cfg := InertiaCSRFConfig()
+	cfg.Secret = []byte("test-secret")
+	app := newCSRFTestApp(cfg)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/form", nil))
+	require.NoError(t, err)
+	cookie := csrfCookie(resp, "XSRF-TOKEN")
+	require.NotNil(t, cookie)
+	assert.False(t, cookie.HttpOnly, "axios must be able to read the cookie")
+
+	req := httptest.NewRequest("POST", "/submit", nil)
+	req.AddCookie(&http.Cookie{Name: "XSRF-TOKEN", Value: cookie.Value})
+	req.Header.Set("X-XSRF-TOKEN", cookie.Value)
+	resp, err = app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
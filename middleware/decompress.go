@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gofiber/fiber/v2"
+)
+
+// DecompressConfig configures the request decompression middleware.
+type DecompressConfig struct {
+	// MaxSize is the maximum decompressed body size in bytes. Larger bodies
+	// are rejected with 413, which guards against zip bombs.
+	// Default: 10 MB
+	MaxSize int64
+
+	// Next defines a function to skip this middleware when returning true.
+	Next func(c *fiber.Ctx) bool
+}
+
+// DefaultDecompressConfig returns the default configuration.
+func DefaultDecompressConfig() DecompressConfig {
+	return DecompressConfig{
+		MaxSize: 10 * 1024 * 1024,
+	}
+}
+
+// Decompress transparently decodes request bodies sent with
+// Content-Encoding gzip, br or deflate, so handlers and BodyParser see plain
+// bytes. Unlike Fiber's on-demand decoding in c.Body(), the decompressed size
+// is capped. Unknown encodings get 415 and corrupt bodies get 400.
+func Decompress(config ...DecompressConfig) fiber.Handler {
+	cfg := DefaultDecompressConfig()
+	if len(config) > 0 {
+		cfg = config[0]
+		if cfg.MaxSize <= 0 {
+			cfg.MaxSize = DefaultDecompressConfig().MaxSize
+		}
+	}
+
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		encoding := strings.ToLower(strings.TrimSpace(c.Get(fiber.HeaderContentEncoding)))
+		if encoding == "" || encoding == "identity" {
+			return c.Next()
+		}
+
+		// Read the raw bytes: c.Body() would decode without a size limit
+		body := bytes.NewReader(c.Request().Body())
+		var reader io.Reader
+		switch encoding {
+		case "gzip", "x-gzip":
+			zr, err := gzip.NewReader(body)
+			if err != nil {
+				return fiber.NewError(fiber.StatusBadRequest, "invalid gzip request body")
+			}
+			defer zr.Close()
+			reader = zr
+		case "deflate":
+			zr, err := zlib.NewReader(body)
+			if err != nil {
+				return fiber.NewError(fiber.StatusBadRequest, "invalid deflate request body")
+			}
+			defer zr.Close()
+			reader = zr
+		case "br":
+			reader = brotli.NewReader(body)
+		default:
+			return fiber.NewError(fiber.StatusUnsupportedMediaType, "unsupported content encoding: "+encoding)
+		}
+
+		// Read one byte past the limit to detect oversized bodies
+		decoded, err := io.ReadAll(io.LimitReader(reader, cfg.MaxSize+1))
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid "+encoding+" request body")
+		}
+		if int64(len(decoded)) > cfg.MaxSize {
+			return fiber.ErrRequestEntityTooLarge
+		}
+
+		c.Request().SetBody(decoded)
+		c.Request().Header.Del(fiber.HeaderContentEncoding)
+		c.Request().Header.SetContentLength(len(decoded))
+		return c.Next()
+	}
+}
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func compressBody(t *testing.T, encoding, body string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	case "br":
+		w = brotli.NewWriter(&buf)
+	}
+	_, err := w.Write([]byte(body))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestDecompress(t *testing.T) {
+	app := fiber.New()
+	app.Use(Decompress(DecompressConfig{MaxSize: 1024}))
+	app.Post("/ingest", func(c *fiber.Ctx) error {
+		var payload struct {
+			Event string `json:"event"`
+		}
+		if err := c.BodyParser(&payload); err != nil {
+			return err
+		}
+		return c.SendString(payload.Event)
+	})
+
+	send := func(encoding string, body []byte) (int, string) {
+		req := httptest.NewRequest("POST", "/ingest", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if encoding != "" {
+			req.Header.Set("Content-Encoding", encoding)
+		}
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		out, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(out)
+	}
+
+	for _, encoding := range []string{"gzip", "deflate", "br"} {
+		t.Run(encoding, func(t *testing.T) {
+			status, body := send(encoding, compressBody(t, encoding, `{"event":"signup"}`))
+			assert.Equal(t, fiber.StatusOK, status)
+			assert.Equal(t, "signup", body)
+		})
+	}
+
+	t.Run("passes uncompressed bodies through", func(t *testing.T) {
+		status, body := send("", []byte(`{"event":"plain"}`))
+		assert.Equal(t, fiber.StatusOK, status)
+		assert.Equal(t, "plain", body)
+	})
+
+	t.Run("rejects bodies over the limit", func(t *testing.T) {
+		large := `{"event":"` + strings.Repeat("x", 2048) + `"}`
+		status, _ := send("gzip", compressBody(t, "gzip", large))
+		assert.Equal(t, fiber.StatusRequestEntityTooLarge, status)
+	})
+
+	t.Run("rejects corrupt bodies", func(t *testing.T) {
+		status, _ := send("gzip", []byte("not gzip"))
+		assert.Equal(t, fiber.StatusBadRequest, status)
+	})
+
+	t.Run("rejects unknown encodings", func(t *testing.T) {
+		status, _ := send("zstd", []byte("data"))
+		assert.Equal(t, fiber.StatusUnsupportedMediaType, status)
+	})
+}
@@ -0,0 +1,146 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// skipETagKey marks a request whose response must not get an ETag.
+const skipETagKey = "cartridge_skip_etag"
+
+// weakETagKey marks a request whose response must get a weak ETag.
+const weakETagKey = "cartridge_weak_etag"
+
+// ETagConfig configures the ETag middleware.
+type ETagConfig struct {
+	// Weak generates weak validators (W/"..."). Use weak ETags when responses
+	// are compressed, since the same ETag then covers several encodings.
+	// Default: false
+	Weak bool
+
+	// Next defines a function to skip this middleware when returning true.
+	Next func(c *fiber.Ctx) bool
+}
+
+// ETag sets an ETag on successful GET and HEAD responses from a hash of the
+// body, and answers 304 Not Modified when the request's If-None-Match (or,
+// without it, If-Modified-Since against Last-Modified) shows the client
+// already has the response. ETags set by the handler are kept. Streamed
+// bodies are not buffered: they get a weak ETag from Content-Length and
+// Last-Modified, or none.
+func ETag(config ...ETagConfig) fiber.Handler {
+	var cfg ETagConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+		if c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead {
+			return c.Next()
+		}
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+		if c.Locals(skipETagKey) != nil || c.Response().StatusCode() != fiber.StatusOK {
+			return nil
+		}
+
+		etag := string(c.Response().Header.Peek(fiber.HeaderETag))
+		if etag == "" {
+			etag = responseETag(c, cfg.Weak || c.Locals(weakETagKey) != nil)
+			if etag == "" {
+				return nil
+			}
+			c.Set(fiber.HeaderETag, etag)
+		}
+
+		lastModified, _ := http.ParseTime(string(c.Response().Header.Peek(fiber.HeaderLastModified)))
+		if NotModified(c, etag, lastModified) {
+			c.Response().ResetBody()
+			c.Status(fiber.StatusNotModified)
+		}
+		return nil
+	}
+}
+
+// SkipETag stops the ETag middleware from tagging this response, for
+// handlers whose output must always be refetched.
+func SkipETag(c *fiber.Ctx) {
+	c.Locals(skipETagKey, true)
+}
+
+// WeakETag makes the ETag middleware give this response a weak ETag, for
+// routes that compress it when the middleware's Weak isn't set.
+func WeakETag(c *fiber.Ctx) {
+	c.Locals(weakETagKey, true)
+}
+
+// NotModified reports whether the request's conditional headers match the
+// current representation, so the response can be 304 Not Modified.
+// If-None-Match takes precedence; If-Modified-Since is only consulted
+// without it and when lastModified is known.
+func NotModified(c *fiber.Ctx, etag string, lastModified time.Time) bool {
+	if inm := c.Get(fiber.HeaderIfNoneMatch); inm != "" {
+		return etag != "" && etagMatches(inm, etag)
+	}
+	if lastModified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(c.Get(fiber.HeaderIfModifiedSince))
+	if err != nil {
+		return false
+	}
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+// responseETag computes the ETag for the buffered response body.
+func responseETag(c *fiber.Ctx, weak bool) string {
+	resp := c.Response()
+	if resp.IsBodyStream() {
+		// Reading the stream would buffer it; describe it instead
+		size := resp.Header.ContentLength()
+		modified := string(resp.Header.Peek(fiber.HeaderLastModified))
+		if size < 0 || modified == "" {
+			return ""
+		}
+		t, err := http.ParseTime(modified)
+		if err != nil {
+			return ""
+		}
+		return `W/"` + strconv.FormatInt(int64(size), 16) + "-" + strconv.FormatInt(t.Unix(), 16) + `"`
+	}
+
+	body := resp.Body()
+	if len(body) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	if weak {
+		etag = "W/" + etag
+	}
+	return etag
+}
+
+// etagMatches applies the weak comparison of If-None-Match: "*" or any
+// listed tag equal to etag, ignoring W/ prefixes.
+func etagMatches(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestETag(t *testing.T) {
+	lastModified := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	app := fiber.New()
+	app.Use(ETag())
+	app.Get("/poll", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"status": "pending"})
+	})
+	app.Get("/fresh", func(c *fiber.Ctx) error {
+		SkipETag(c)
+		return c.SendString("always new")
+	})
+	app.Get("/report", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderLastModified, lastModified.Format(http.TimeFormat))
+		return c.SendString("report")
+	})
+	app.Post("/poll", func(c *fiber.Ctx) error {
+		return c.SendString("created")
+	})
+
+	get := func(path string, headers map[string]string) (int, string, string) {
+		req := httptest.NewRequest("GET", path, nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, resp.Header.Get(fiber.HeaderETag), string(body)
+	}
+
+	status, etag, body := get("/poll", nil)
+	assert.Equal(t, fiber.StatusOK, status)
+	assert.NotEmpty(t, etag)
+	assert.False(t, strings.HasPrefix(etag, "W/"))
+	assert.Contains(t, body, "pending")
+
+	// Same body, same tag: the client's copy is current
+	status, _, body = get("/poll", map[string]string{fiber.HeaderIfNoneMatch: etag})
+	assert.Equal(t, fiber.StatusNotModified, status)
+	assert.Empty(t, body)
+
+	// Weak comparison and lists
+	status, _, _ = get("/poll", map[string]string{fiber.HeaderIfNoneMatch: `"stale", W/` + etag})
+	assert.Equal(t, fiber.StatusNotModified, status)
+	status, _, _ = get("/poll", map[string]string{fiber.HeaderIfNoneMatch: `"stale"`})
+	assert.Equal(t, fiber.StatusOK, status)
+
+	status, etag, _ = get("/fresh", nil)
+	assert.Equal(t, fiber.StatusOK, status)
+	assert.Empty(t, etag)
+
+	// If-Modified-Since applies only without If-None-Match
+	since := map[string]string{fiber.HeaderIfModifiedSince: lastModified.Add(time.Hour).Format(http.TimeFormat)}
+	status, _, _ = get("/report", since)
+	assert.Equal(t, fiber.StatusNotModified, status)
+	since[fiber.HeaderIfNoneMatch] = `"stale"`
+	status, _, _ = get("/report", since)
+	assert.Equal(t, fiber.StatusOK, status)
+
+	resp, err := app.Test(httptest.NewRequest("POST", "/poll", nil))
+	assert.NoError(t, err)
+	assert.Empty(t, resp.Header.Get(fiber.HeaderETag))
+}
+
+func TestETag_Weak(t *testing.T) {
+	app := fiber.New()
+	app.Use(ETag(ETagConfig{Weak: true}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(resp.Header.Get(fiber.HeaderETag), `W/"`))
+}
@@ -1,19 +1,286 @@
 package middleware
 
 import (
+	"strconv"
+	"strings"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/helmet"
 )
 
-// Helmet creates a security headers middleware using Fiber's built-in helmet.
-// It sets various HTTP headers to help protect against common web vulnerabilities.
-func Helmet() fiber.Handler {
-	return helmet.New(helmet.Config{
-		ReferrerPolicy: "same-origin",
-	})
+// SecurityHeaders lists the security headers set on every response. Empty
+// fields are not sent, so start from DefaultSecurityHeaders and adjust.
+type SecurityHeaders struct {
+	// ContentSecurityPolicy restricts where scripts, styles and other
+	// resources load from. Default: "" (not sent)
+	ContentSecurityPolicy string
+	// CSP builds the Content-Security-Policy with a fresh nonce for every
+	// request, available from CSPNonce. Overrides ContentSecurityPolicy.
+	CSP *CSP
+	// CSPReportOnly sends the policy as Content-Security-Policy-Report-Only,
+	// to try a policy out without breaking pages.
+	CSPReportOnly bool
+
+	// HSTSMaxAge is the Strict-Transport-Security max-age in seconds, sent on
+	// HTTPS requests only. Default: 0 (not sent)
+	HSTSMaxAge int
+	// HSTSIncludeSubdomains applies HSTS to every subdomain.
+	HSTSIncludeSubdomains bool
+	// HSTSPreload opts in to browsers' HSTS preload lists.
+	HSTSPreload bool
+
+	// XFrameOptions controls framing. Default: "SAMEORIGIN"
+	XFrameOptions string
+	// ContentTypeNosniff is the X-Content-Type-Options value. Default: "nosniff"
+	ContentTypeNosniff string
+	// ReferrerPolicy is the Referrer-Policy value. Default: "same-origin"
+	ReferrerPolicy string
+	// PermissionsPolicy restricts browser features such as the camera.
+	// Default: "" (not sent)
+	PermissionsPolicy string
+	// PermissionsPolicyReportOnly sends the policy as
+	// Permissions-Policy-Report-Only, to try it out without disabling features.
+	PermissionsPolicyReportOnly bool
+	// XSSProtection is the X-XSS-Protection value. "0" disables the legacy
+	// filter, which browsers no longer need. Default: "0"
+	XSSProtection string
+
+	// CrossOriginEmbedderPolicy is the Cross-Origin-Embedder-Policy value.
+	// Default: "require-corp"
+	CrossOriginEmbedderPolicy string
+	// CrossOriginOpenerPolicy is the Cross-Origin-Opener-Policy value.
+	// Default: "same-origin"
+	CrossOriginOpenerPolicy string
+	// CrossOriginResourcePolicy is the Cross-Origin-Resource-Policy value.
+	// Default: "same-origin"
+	CrossOriginResourcePolicy string
+	// OriginAgentCluster is the Origin-Agent-Cluster value. Default: "?1"
+	OriginAgentCluster string
+
+	// XDNSPrefetchControl is the X-DNS-Prefetch-Control value. Default: "off"
+	XDNSPrefetchControl string
+	// XDownloadOptions is the X-Download-Options value. Default: "noopen"
+	XDownloadOptions string
+	// XPermittedCrossDomain is the X-Permitted-Cross-Domain-Policies value.
+	// Default: "none"
+	XPermittedCrossDomain string
+
+	// ReportURI receives CSP and Permissions-Policy violation reports (see
+	// SecurityReportHandler). It is added to the CSP as report-uri and, as
+	// the "default" group, to Reporting-Endpoints. Default: "" (no reports)
+	ReportURI string
+
+	// Next defines a function to skip this middleware when returning true.
+	Next func(c *fiber.Ctx) bool
+}
+
+// DefaultSecurityHeaders returns the headers Helmet sends by default.
+func DefaultSecurityHeaders() SecurityHeaders {
+	return SecurityHeaders{
+		XFrameOptions:             "SAMEORIGIN",
+		ContentTypeNosniff:        "nosniff",
+		ReferrerPolicy:            "same-origin",
+		XSSProtection:             "0",
+		CrossOriginEmbedderPolicy: "require-corp",
+		CrossOriginOpenerPolicy:   "same-origin",
+		CrossOriginResourcePolicy: "same-origin",
+		OriginAgentCluster:        "?1",
+		XDNSPrefetchControl:       "off",
+		XDownloadOptions:          "noopen",
+		XPermittedCrossDomain:     "none",
+	}
+}
+
+// Security header preset names, for SecurityHeadersPreset.
+const (
+	PresetDefault      = "default"
+	PresetStrict       = "strict"
+	PresetRelaxedEmbed = "relaxed-embed"
+	PresetAPIOnly      = "api-only"
+)
+
+// StrictSecurityHeaders returns headers for pages that are never framed and
+// load everything from their own origin: a CSP allowing only 'self', no
+// referrer, HSTS for two years and the camera, microphone, geolocation and
+// payment APIs disabled.
+func StrictSecurityHeaders() SecurityHeaders {
+	headers := DefaultSecurityHeaders()
+	headers.CSP = NewCSP().
+		FrameAncestors("none").
+		FormAction("self").
+		BaseURI("self").
+		Directive("object-src", "none")
+	headers.XFrameOptions = "DENY"
+	headers.ReferrerPolicy = "no-referrer"
+	headers.PermissionsPolicy = "camera=(), microphone=(), geolocation=(), payment=()"
+	headers.HSTSMaxAge = 63072000
+	return headers
+}
+
+// EmbedSecurityHeaders returns headers for pages other sites embed, such as
+// widgets and booking forms: any origin may frame them, and the
+// cross-origin isolation headers that would block them are not sent.
+func EmbedSecurityHeaders() SecurityHeaders {
+	headers := DefaultSecurityHeaders()
+	headers.ContentSecurityPolicy = "frame-ancestors *"
+	headers.XFrameOptions = ""
+	headers.ReferrerPolicy = "strict-origin-when-cross-origin"
+	headers.CrossOriginEmbedderPolicy = ""
+	headers.CrossOriginOpenerPolicy = ""
+	headers.CrossOriginResourcePolicy = "cross-origin"
+	return headers
+}
+
+// APISecurityHeaders returns headers for JSON APIs, whose responses are
+// never rendered as documents: a CSP blocking everything, no framing and
+// no referrer. Headers that only matter for pages are not sent.
+func APISecurityHeaders() SecurityHeaders {
+	return SecurityHeaders{
+		ContentSecurityPolicy: "default-src 'none'; frame-ancestors 'none'",
+		XFrameOptions:         "DENY",
+		ContentTypeNosniff:    "nosniff",
+		ReferrerPolicy:        "no-referrer",
+		XSSProtection:         "0",
+		// Browsers may still load API responses cross-origin with CORS
+		CrossOriginResourcePolicy: "same-site",
+		XPermittedCrossDomain:     "none",
+	}
+}
+
+// SecurityHeadersPreset returns the preset named name: PresetDefault,
+// PresetStrict, PresetRelaxedEmbed or PresetAPIOnly.
+func SecurityHeadersPreset(name string) (SecurityHeaders, bool) {
+	switch name {
+	case PresetDefault:
+		return DefaultSecurityHeaders(), true
+	case PresetStrict:
+		return StrictSecurityHeaders(), true
+	case PresetRelaxedEmbed:
+		return EmbedSecurityHeaders(), true
+	case PresetAPIOnly:
+		return APISecurityHeaders(), true
+	}
+	return SecurityHeaders{}, false
+}
+
+// helmetHeaders lists every header Helmet may send, so a later Helmet can
+// clear what an earlier one set.
+var helmetHeaders = []string{
+	fiber.HeaderXFrameOptions,
+	fiber.HeaderXContentTypeOptions,
+	fiber.HeaderReferrerPolicy,
+	fiber.HeaderPermissionsPolicy,
+	permissionsPolicyReportOnly,
+	fiber.HeaderXXSSProtection,
+	"Cross-Origin-Embedder-Policy",
+	"Cross-Origin-Opener-Policy",
+	"Cross-Origin-Resource-Policy",
+	"Origin-Agent-Cluster",
+	fiber.HeaderXDNSPrefetchControl,
+	fiber.HeaderXDownloadOptions,
+	fiber.HeaderXPermittedCrossDomainPolicies,
+	fiber.HeaderContentSecurityPolicy,
+	fiber.HeaderContentSecurityPolicyReportOnly,
+	fiber.HeaderStrictTransportSecurity,
+	reportingEndpoints,
+}
+
+const (
+	permissionsPolicyReportOnly = "Permissions-Policy-Report-Only"
+	reportingEndpoints          = "Reporting-Endpoints"
+)
+
+// Helmet sets security headers on every response. Without arguments it sends
+// DefaultSecurityHeaders. It replaces security headers set by an earlier
+// Helmet in the chain, so a route can send a different preset than the app.
+//
+//	headers := middleware.DefaultSecurityHeaders()
+//	headers.ContentSecurityPolicy = "default-src 'self'"
+//	headers.HSTSMaxAge = 63072000
+//	app.Use(middleware.Helmet(headers))
+func Helmet(config ...SecurityHeaders) fiber.Handler {
+	cfg := DefaultSecurityHeaders()
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+
+	permissionsHeader := fiber.HeaderPermissionsPolicy
+	if cfg.PermissionsPolicyReportOnly {
+		permissionsHeader = permissionsPolicyReportOnly
+	}
+
+	// Static headers are computed once
+	headers := [][2]string{
+		{fiber.HeaderXFrameOptions, cfg.XFrameOptions},
+		{fiber.HeaderXContentTypeOptions, cfg.ContentTypeNosniff},
+		{fiber.HeaderReferrerPolicy, cfg.ReferrerPolicy},
+		{permissionsHeader, cfg.PermissionsPolicy},
+		{fiber.HeaderXXSSProtection, cfg.XSSProtection},
+		{"Cross-Origin-Embedder-Policy", cfg.CrossOriginEmbedderPolicy},
+		{"Cross-Origin-Opener-Policy", cfg.CrossOriginOpenerPolicy},
+		{"Cross-Origin-Resource-Policy", cfg.CrossOriginResourcePolicy},
+		{"Origin-Agent-Cluster", cfg.OriginAgentCluster},
+		{fiber.HeaderXDNSPrefetchControl, cfg.XDNSPrefetchControl},
+		{fiber.HeaderXDownloadOptions, cfg.XDownloadOptions},
+		{fiber.HeaderXPermittedCrossDomainPolicies, cfg.XPermittedCrossDomain},
+	}
+	cspHeader := fiber.HeaderContentSecurityPolicy
+	if cfg.CSPReportOnly {
+		cspHeader = fiber.HeaderContentSecurityPolicyReportOnly
+	}
+	var policy *CSP
+	if cfg.CSP != nil {
+		policy = cfg.CSP.clone() // later changes to the builder don't race with requests
+		if cfg.ReportURI != "" && !policy.has("report-uri") {
+			policy.ReportURI(cfg.ReportURI).ReportTo("default")
+		}
+	} else if cfg.ContentSecurityPolicy != "" {
+		csp := cfg.ContentSecurityPolicy
+		if cfg.ReportURI != "" && !strings.Contains(csp, "report-uri") {
+			csp += "; report-uri " + cfg.ReportURI + "; report-to default"
+		}
+		headers = append(headers, [2]string{cspHeader, csp})
+	}
+	if cfg.ReportURI != "" {
+		headers = append(headers, [2]string{reportingEndpoints, `default="` + cfg.ReportURI + `"`})
+	}
+
+	hsts := ""
+	if cfg.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.Itoa(cfg.HSTSMaxAge)
+		if cfg.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		if cfg.HSTSPreload {
+			hsts += "; preload"
+		}
+	}
+
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+		for _, name := range helmetHeaders {
+			c.Response().Header.Del(name)
+		}
+		for _, h := range headers {
+			if h[1] != "" {
+				c.Set(h[0], h[1])
+			}
+		}
+		if hsts != "" && c.Protocol() == "https" {
+			c.Set(fiber.HeaderStrictTransportSecurity, hsts)
+		}
+		if policy != nil {
+			nonce := newCSPNonce()
+			c.Locals(cspNonceKey, nonce)
+			c.Set(cspHeader, policy.Build(nonce))
+		}
+		return c.Next()
+	}
 }
 
-// HelmetWithConfig creates a Helmet middleware with custom configuration.
+// HelmetWithConfig creates a Helmet middleware from Fiber's helmet configuration.
 func HelmetWithConfig(config helmet.Config) fiber.Handler {
 	return helmet.New(config)
 }
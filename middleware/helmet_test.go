@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHelmet(t *testing.T) {
+	t.Run("sends default headers", func(t *testing.T) {
+		app := fiber.New()
+		app.Use(Helmet())
+		app.Get("/", func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+		require.NoError(t, err)
+		assert.Equal(t, "SAMEORIGIN", resp.Header.Get("X-Frame-Options"))
+		assert.Equal(t, "nosniff", resp.Header.Get("X-Content-Type-Options"))
+		assert.Equal(t, "same-origin", resp.Header.Get("Referrer-Policy"))
+		assert.Empty(t, resp.Header.Get("Content-Security-Policy"))
+		assert.Empty(t, resp.Header.Get("Strict-Transport-Security"))
+	})
+
+	t.Run("sends configured headers", func(t *testing.T) {
+		headers := DefaultSecurityHeaders()
+		headers.ContentSecurityPolicy = "default-src 'self'"
+		headers.CSPReportOnly = true
+		headers.PermissionsPolicy = "camera=()"
+		headers.XFrameOptions = ""
+		headers.HSTSMaxAge = 3600
+		headers.HSTSIncludeSubdomains = true
+
+		app := fiber.New()
+		app.Use(Helmet(headers))
+		app.Get("/", func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+		require.NoError(t, err)
+		assert.Equal(t, "default-src 'self'", resp.Header.Get("Content-Security-Policy-Report-Only"))
+		assert.Empty(t, resp.Header.Get("Content-Security-Policy"))
+		assert.Equal(t, "camera=()", resp.Header.Get("Permissions-Policy"))
+		assert.Empty(t, resp.Header.Get("X-Frame-Options"), "empty fields should not be sent")
+		assert.Empty(t, resp.Header.Get("Strict-Transport-Security"), "HSTS is only sent over HTTPS")
+
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Forwarded-Proto", "https")
+		resp, err = app.Test(req)
+		require.NoError(t, err)
+		assert.Equal(t, "max-age=3600; includeSubDomains", resp.Header.Get("Strict-Transport-Security"))
+	})
+}
+
+func TestHelmet_Presets(t *testing.T) {
+	t.Run("a route's Helmet replaces the app's headers", func(t *testing.T) {
+		app := fiber.New()
+		app.Use(Helmet())
+		app.Get("/widget", Helmet(EmbedSecurityHeaders()), func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/widget", nil))
+		require.NoError(t, err)
+		assert.Empty(t, resp.Header.Get("X-Frame-Options"))
+		assert.Empty(t, resp.Header.Get("Cross-Origin-Embedder-Policy"))
+		assert.Equal(t, "frame-ancestors *", resp.Header.Get("Content-Security-Policy"))
+		assert.Equal(t, "cross-origin", resp.Header.Get("Cross-Origin-Resource-Policy"))
+	})
+
+	t.Run("report-only sends CSP and Permissions-Policy for reporting", func(t *testing.T) {
+		headers, ok := SecurityHeadersPreset(PresetStrict)
+		require.True(t, ok)
+		headers.CSPReportOnly = true
+		headers.PermissionsPolicyReportOnly = true
+		headers.ReportURI = "/_security-reports"
+
+		app := fiber.New()
+		app.Use(Helmet(headers))
+		app.Get("/", func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+		require.NoError(t, err)
+		assert.Empty(t, resp.Header.Get("Content-Security-Policy"))
+		assert.Empty(t, resp.Header.Get("Permissions-Policy"))
+		csp := resp.Header.Get("Content-Security-Policy-Report-Only")
+		assert.Contains(t, csp, "frame-ancestors 'none'")
+		assert.Contains(t, csp, "report-uri /_security-reports; report-to default")
+		assert.Equal(t, "camera=(), microphone=(), geolocation=(), payment=()", resp.Header.Get("Permissions-Policy-Report-Only"))
+		assert.Equal(t, `default="/_security-reports"`, resp.Header.Get("Reporting-Endpoints"))
+	})
+
+	t.Run("api-only blocks rendering", func(t *testing.T) {
+		headers, ok := SecurityHeadersPreset(PresetAPIOnly)
+		require.True(t, ok)
+		assert.Equal(t, "default-src 'none'; frame-ancestors 'none'", headers.ContentSecurityPolicy)
+		assert.Empty(t, headers.CrossOriginEmbedderPolicy)
+
+		_, ok = SecurityHeadersPreset("lenient")
+		assert.False(t, ok)
+	})
+}
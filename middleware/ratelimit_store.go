@@ -0,0 +1,252 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/karloscodes/cartridge/cache"
+)
+
+// RateLimitRule is the limit applied to one key.
+type RateLimitRule struct {
+	Strategy RateLimitStrategy // Default: FixedWindow
+	Limit    int               // Requests per Window
+	Window   time.Duration
+	Burst    int // TokenBucket capacity. Default: Limit
+}
+
+// RateLimitResult is the outcome of one request against a rule.
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int           // Requests allowed per window (bucket capacity for TokenBucket)
+	Remaining  int           // Requests left before the limit
+	Reset      time.Duration // Until the quota is fully restored
+	RetryAfter time.Duration // Until a rejected request may succeed
+}
+
+// RateLimitStore keeps limiter state. Take must apply the rule atomically,
+// so concurrent requests can't both take the last slot.
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, rule RateLimitRule) (RateLimitResult, error)
+}
+
+// rateLimitState is the per-key state shared by all strategies.
+type rateLimitState struct {
+	// Count is the current window's requests, or the tokens left for TokenBucket
+	Count float64 `json:"c"`
+	// Prev is the previous window's requests (SlidingWindow)
+	Prev float64 `json:"p,omitempty"`
+	// Start is the window start, or the last refill for TokenBucket (Unix nanoseconds)
+	Start int64 `json:"s"`
+}
+
+// take applies the rule to st at now.
+func (r RateLimitRule) take(st rateLimitState, now time.Time) (rateLimitState, RateLimitResult) {
+	limit := float64(r.Limit)
+	window := r.Window
+
+	switch r.Strategy {
+	case TokenBucket:
+		capacity := float64(r.Burst)
+		if capacity <= 0 {
+			capacity = limit
+		}
+		perToken := window / time.Duration(r.Limit)
+		if st.Start == 0 {
+			st.Count = capacity
+		} else {
+			elapsed := now.Sub(time.Unix(0, st.Start))
+			st.Count = math.Min(capacity, st.Count+float64(elapsed)/float64(perToken))
+		}
+		st.Start = now.UnixNano()
+
+		res := RateLimitResult{Limit: int(capacity)}
+		if st.Count >= 1 {
+			st.Count--
+			res.Allowed = true
+		} else {
+			res.RetryAfter = time.Duration((1 - st.Count) * float64(perToken))
+		}
+		res.Remaining = int(st.Count)
+		res.Reset = time.Duration((capacity - st.Count) * float64(perToken))
+		return st, res
+
+	case SlidingWindow:
+		start := now.Truncate(window)
+		if st.Start != start.UnixNano() {
+			if st.Start == start.Add(-window).UnixNano() {
+				st.Prev = st.Count
+			} else {
+				st.Prev = 0
+			}
+			st.Count = 0
+			st.Start = start.UnixNano()
+		}
+		elapsed := float64(now.Sub(start)) / float64(window)
+		used := st.Prev*(1-elapsed) + st.Count
+
+		res := RateLimitResult{Limit: r.Limit, Reset: start.Add(window).Sub(now)}
+		if used+1 <= limit {
+			st.Count++
+			used++
+			res.Allowed = true
+		} else if st.Count+1 <= limit {
+			// Wait for enough of the previous window to slide out
+			frac := 1 - (limit-1-st.Count)/st.Prev
+			res.RetryAfter = start.Add(time.Duration(frac * float64(window))).Sub(now)
+		} else {
+			// Wait into the next window, where this one becomes the previous
+			frac := 1 - (limit-1)/st.Count
+			res.RetryAfter = start.Add(window + time.Duration(frac*float64(window))).Sub(now)
+		}
+		res.Remaining = max(int(limit-used), 0)
+		return st, res
+
+	default:
+		start := now.Truncate(window)
+		if st.Start != start.UnixNano() {
+			st = rateLimitState{Start: start.UnixNano()}
+		}
+		res := RateLimitResult{Limit: r.Limit, Reset: start.Add(window).Sub(now)}
+		if st.Count < limit {
+			st.Count++
+			res.Allowed = true
+		} else {
+			res.RetryAfter = res.Reset
+		}
+		res.Remaining = int(limit - st.Count)
+		return st, res
+	}
+}
+
+// ttl is how long state must be kept to apply the rule.
+func (r RateLimitRule) ttl() time.Duration {
+	switch r.Strategy {
+	case SlidingWindow:
+		return 2 * r.Window
+	case TokenBucket:
+		if r.Burst > r.Limit {
+			return r.Window * time.Duration(r.Burst) / time.Duration(r.Limit)
+		}
+	}
+	return r.Window
+}
+
+// MemoryRateLimitStore keeps limiter state in process memory. Limits are
+// per instance.
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	entries   map[string]memoryRateLimitEntry
+	nextSweep time.Time
+}
+
+type memoryRateLimitEntry struct {
+	state     rateLimitState
+	expiresAt time.Time
+}
+
+// NewMemoryRateLimitStore creates an in-memory store. Expired keys are
+// swept at most once a minute.
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{entries: make(map[string]memoryRateLimitEntry)}
+}
+
+// Take applies rule to key.
+func (s *MemoryRateLimitStore) Take(_ context.Context, key string, rule RateLimitRule) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.After(s.nextSweep) {
+		for k, entry := range s.entries {
+			if now.After(entry.expiresAt) {
+				delete(s.entries, k)
+			}
+		}
+		s.nextSweep = now.Add(time.Minute)
+	}
+
+	state, res := rule.take(s.entries[key].state, now)
+	s.entries[key] = memoryRateLimitEntry{state: state, expiresAt: now.Add(rule.ttl())}
+	return res, nil
+}
+
+// storageRateLimitStore adapts a fiber.Storage. Read-modify-write is not
+// atomic, so concurrent instances may let a few extra requests through.
+type storageRateLimitStore struct {
+	storage fiber.Storage
+}
+
+func (s storageRateLimitStore) Take(_ context.Context, key string, rule RateLimitRule) (RateLimitResult, error) {
+	var state rateLimitState
+	data, err := s.storage.Get(key)
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &state); err != nil {
+			return RateLimitResult{}, fmt.Errorf("decode rate limit state: %w", err)
+		}
+	}
+
+	state, res := rule.take(state, time.Now())
+	if data, err = json.Marshal(state); err != nil {
+		return RateLimitResult{}, err
+	}
+	return res, s.storage.Set(key, data, rule.ttl())
+}
+
+// redisCompareAndSet replaces KEYS[1] only if it still holds ARGV[1].
+const redisCompareAndSet = `
+local current = redis.call('GET', KEYS[1])
+if (current or '') ~= ARGV[1] then return 0 end
+redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+return 1`
+
+// RedisRateLimitStore shares limiter state between instances through Redis.
+// Each update is a compare-and-set, retried when another instance wins.
+type RedisRateLimitStore struct {
+	redis *cache.RedisStore
+}
+
+// NewRedisRateLimitStore creates a store on redis. Keys get the store's prefix.
+func NewRedisRateLimitStore(redis *cache.RedisStore) *RedisRateLimitStore {
+	return &RedisRateLimitStore{redis: redis}
+}
+
+// Take applies rule to key.
+func (s *RedisRateLimitStore) Take(ctx context.Context, key string, rule RateLimitRule) (RateLimitResult, error) {
+	key = "ratelimit:" + key
+	ttl := fmt.Sprint(max(rule.ttl().Milliseconds(), 1))
+
+	for range 10 {
+		current, _ := s.redis.Read(ctx, key)
+		var state rateLimitState
+		if len(current) > 0 {
+			if err := json.Unmarshal(current, &state); err != nil {
+				return RateLimitResult{}, fmt.Errorf("decode rate limit state: %w", err)
+			}
+		}
+
+		state, res := rule.take(state, time.Now())
+		next, err := json.Marshal(state)
+		if err != nil {
+			return RateLimitResult{}, err
+		}
+		swapped, err := s.redis.Eval(ctx, redisCompareAndSet, []string{key}, string(current), string(next), ttl)
+		if err != nil {
+			return RateLimitResult{}, err
+		}
+		if swapped == int64(1) {
+			return res, nil
+		}
+	}
+	return RateLimitResult{}, errors.New("rate limit: too much contention on " + key)
+}
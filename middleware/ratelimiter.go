@@ -1,10 +1,12 @@
 package middleware
 
 import (
+	"log/slog"
+	"math"
+	"strconv"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/limiter"
 	"github.com/gofiber/fiber/v2/utils"
 )
 
@@ -15,13 +17,46 @@ type EnvironmentChecker interface {
 	IsDevelopment() bool
 }
 
+// RateLimitStrategy selects the rate limiting algorithm.
+type RateLimitStrategy string
+
+const (
+	// FixedWindow counts requests in consecutive windows aligned to the
+	// clock. Cheap, but allows up to 2×Max across a window boundary.
+	FixedWindow RateLimitStrategy = "fixed_window"
+
+	// SlidingWindow weights the previous window's count by how much of it
+	// still overlaps the last Duration, smoothing out boundary bursts.
+	SlidingWindow RateLimitStrategy = "sliding_window"
+
+	// TokenBucket refills Max tokens per Duration up to Burst, allowing
+	// short bursts while holding the average rate.
+	TokenBucket RateLimitStrategy = "token_bucket"
+)
+
 // RateLimiterConfig holds configuration for the rate limiter.
 type RateLimiterConfig struct {
 	Max      int
 	Duration time.Duration
 	Skip     func(*fiber.Ctx) bool
-	Storage  fiber.Storage        // Optional: persistent storage for distributed rate limiting
-	Env      EnvironmentChecker   // Optional: environment checker to skip rate limiting in dev/test
+	Storage  fiber.Storage      // Optional: persistent storage for distributed rate limiting
+	Env      EnvironmentChecker // Optional: environment checker to skip rate limiting in dev/test
+
+	// Strategy is the algorithm. Default: FixedWindow
+	Strategy RateLimitStrategy
+	// Burst is the token bucket capacity. Default: Max
+	Burst int
+	// KeyFunc identifies the client. Default: KeyByIP()
+	KeyFunc func(*fiber.Ctx) string
+	// KeyPrefix namespaces keys, e.g. per route. Optional.
+	KeyPrefix string
+	// Store keeps limiter state and takes precedence over Storage, e.g.
+	// NewRedisRateLimitStore for limits shared between instances.
+	// Default: in memory
+	Store RateLimitStore
+	// Logger reports store failures, which let requests through.
+	// Default: slog.Default()
+	Logger *slog.Logger
 }
 
 // RateLimiterOption defines a function to modify RateLimiterConfig.
@@ -52,7 +87,7 @@ func WithSkip(skip func(*fiber.Ctx) bool) RateLimiterOption {
 }
 
 // WithStorage configures persistent storage for distributed rate limiting.
-// Use this with Redis or other fiber.Storage implementations for multi-instance deployments.
+// Updates are not atomic across instances; prefer WithStore(NewRedisRateLimitStore(...)).
 // Example: WithStorage(myRedisStorage)
 func WithStorage(storage fiber.Storage) RateLimiterOption {
 	return func(cfg *RateLimiterConfig) {
@@ -70,9 +105,73 @@ func WithEnv(env EnvironmentChecker) RateLimiterOption {
 	}
 }
 
+// WithStrategy sets the rate limiting algorithm.
+// Example: WithStrategy(TokenBucket)
+func WithStrategy(strategy RateLimitStrategy) RateLimiterOption {
+	return func(cfg *RateLimiterConfig) {
+		cfg.Strategy = strategy
+	}
+}
+
+// WithBurst sets the token bucket capacity.
+// Example: WithMax(10), WithDuration(time.Second), WithBurst(50)
+func WithBurst(burst int) RateLimiterOption {
+	return func(cfg *RateLimiterConfig) {
+		cfg.Burst = burst
+	}
+}
+
+// WithKeyFunc sets how clients are identified.
+// Example: WithKeyFunc(KeyByHeader("X-API-Key"))
+func WithKeyFunc(fn func(*fiber.Ctx) string) RateLimiterOption {
+	return func(cfg *RateLimiterConfig) {
+		cfg.KeyFunc = fn
+	}
+}
+
+// WithStore configures where limiter state is kept.
+// Example: WithStore(NewRedisRateLimitStore(redisStore))
+func WithStore(store RateLimitStore) RateLimiterOption {
+	return func(cfg *RateLimiterConfig) {
+		cfg.Store = store
+	}
+}
+
+// KeyByIP identifies clients by IP address.
+func KeyByIP() func(*fiber.Ctx) string {
+	return func(c *fiber.Ctx) string {
+		return "ip:" + c.IP()
+	}
+}
+
+// KeyByHeader identifies clients by a request header such as an API key,
+// falling back to the IP address when it is missing.
+func KeyByHeader(name string) func(*fiber.Ctx) string {
+	return func(c *fiber.Ctx) string {
+		if value := c.Get(name); value != "" {
+			return "header:" + value
+		}
+		return "ip:" + c.IP()
+	}
+}
+
+// KeyByLocals identifies clients by a string in c.Locals, such as a user ID
+// set by authentication middleware, falling back to the IP address.
+func KeyByLocals(key string) func(*fiber.Ctx) string {
+	return func(c *fiber.Ctx) string {
+		if value, ok := c.Locals(key).(string); ok && value != "" {
+			return "user:" + value
+		}
+		return "ip:" + c.IP()
+	}
+}
+
 // RateLimiter creates a rate limiting middleware with customizable options.
 // By default, limits to 50 requests per second per IP address.
-// Uses in-memory storage by default - use WithStorage() for distributed setups.
+// Uses in-memory storage by default - use WithStore() for distributed setups.
+//
+// Every limited response carries RateLimit-Limit, RateLimit-Remaining and
+// RateLimit-Reset headers; rejected requests get 429 with Retry-After.
 //
 // Example usage:
 //
@@ -94,39 +193,58 @@ func RateLimiter(options ...RateLimiterOption) fiber.Handler {
 	if cfg.Duration <= 0 {
 		cfg.Duration = time.Second
 	}
+	if cfg.KeyFunc == nil {
+		cfg.KeyFunc = KeyByIP()
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+	store := cfg.Store
+	switch {
+	case store != nil:
+	case cfg.Storage != nil:
+		store = storageRateLimitStore{storage: cfg.Storage}
+	default:
+		store = NewMemoryRateLimitStore()
+	}
+	rule := RateLimitRule{Strategy: cfg.Strategy, Limit: cfg.Max, Window: cfg.Duration, Burst: cfg.Burst}
+
+	return func(c *fiber.Ctx) error {
+		// Skip rate limiting in dev/test environments (convention over configuration)
+		if cfg.Env != nil && (cfg.Env.IsTest() || cfg.Env.IsDevelopment()) {
+			return c.Next()
+		}
+		if cfg.Skip != nil && cfg.Skip(c) {
+			return c.Next()
+		}
+
+		// Use utils.CopyString to avoid memory issues with pooled contexts
+		key := utils.CopyString(cfg.KeyPrefix + cfg.KeyFunc(c))
+		result, err := store.Take(c.UserContext(), key, rule)
+		if err != nil {
+			cfg.Logger.Warn("rate limit store failed, allowing request", slog.String("key", key), slog.Any("error", err))
+			return c.Next()
+		}
+
+		c.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+		if result.Allowed {
+			return c.Next()
+		}
+
+		// Set Retry-After header for well-behaved clients
+		retryAfter := ceilSeconds(result.RetryAfter)
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+			"error":       "Too Many Requests",
+			"message":     "Rate limit exceeded. Please try again later.",
+			"retry_after": retryAfter,
+		})
+	}
+}
 
-	limiterConfig := limiter.Config{
-		Max:        cfg.Max,
-		Expiration: cfg.Duration,
-		Storage:    cfg.Storage, // nil = in-memory (default)
-		KeyGenerator: func(c *fiber.Ctx) string {
-			// Use utils.CopyString to avoid memory issues with pooled contexts
-			return utils.CopyString(c.IP())
-		},
-		LimitReached: func(c *fiber.Ctx) error {
-			// Set Retry-After header for well-behaved clients
-			c.Set("Retry-After", "60") // Suggest retry after 60 seconds
-			c.Set("X-RateLimit-Limit", string(rune(cfg.Max)))
-			c.Set("X-RateLimit-Remaining", "0")
-
-			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-				"error":       "Too Many Requests",
-				"message":     "Rate limit exceeded. Please try again later.",
-				"retry_after": 60,
-			})
-		},
-		Next: func(c *fiber.Ctx) bool {
-			// Skip rate limiting in dev/test environments (convention over configuration)
-			if cfg.Env != nil && (cfg.Env.IsTest() || cfg.Env.IsDevelopment()) {
-				return true
-			}
-			// Check custom skip function
-			if cfg.Skip != nil {
-				return cfg.Skip(c)
-			}
-			return false
-		},
-	}
-
-	return limiter.New(limiterConfig)
+// ceilSeconds rounds d up to whole seconds, at least 1 for positive d.
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
 }
@@ -0,0 +1,203 @@
+package middleware
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/karloscodes/cartridge/cache"
+)
+
+func TestRateLimitRule(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("fixed window resets at the boundary", func(t *testing.T) {
+		rule := RateLimitRule{Strategy: FixedWindow, Limit: 2, Window: time.Minute}
+		var st rateLimitState
+		var res RateLimitResult
+		for range 2 {
+			st, res = rule.take(st, start.Add(10*time.Second))
+			assert.True(t, res.Allowed)
+		}
+		st, res = rule.take(st, start.Add(20*time.Second))
+		assert.False(t, res.Allowed)
+		assert.Equal(t, 40*time.Second, res.RetryAfter)
+
+		_, res = rule.take(st, start.Add(time.Minute))
+		assert.True(t, res.Allowed)
+		assert.Equal(t, 1, res.Remaining)
+	})
+
+	t.Run("sliding window weights the previous window", func(t *testing.T) {
+		rule := RateLimitRule{Strategy: SlidingWindow, Limit: 10, Window: time.Minute}
+		var st rateLimitState
+		var res RateLimitResult
+		for range 10 {
+			st, res = rule.take(st, start.Add(50*time.Second))
+			require.True(t, res.Allowed)
+		}
+
+		// 15s into the next window, 75% of the previous 10 still count
+		st, res = rule.take(st, start.Add(75*time.Second))
+		assert.True(t, res.Allowed)
+		assert.Equal(t, 1, res.Remaining)
+		st, _ = rule.take(st, start.Add(75*time.Second))
+		_, res = rule.take(st, start.Add(75*time.Second))
+		assert.False(t, res.Allowed)
+		assert.Positive(t, res.RetryAfter)
+	})
+
+	t.Run("token bucket allows bursts and refills", func(t *testing.T) {
+		rule := RateLimitRule{Strategy: TokenBucket, Limit: 1, Window: time.Second, Burst: 3}
+		var st rateLimitState
+		var res RateLimitResult
+		for range 3 {
+			st, res = rule.take(st, start)
+			require.True(t, res.Allowed)
+		}
+		st, res = rule.take(st, start)
+		assert.False(t, res.Allowed)
+		assert.Equal(t, time.Second, res.RetryAfter)
+		assert.Equal(t, 3, res.Limit)
+
+		_, res = rule.take(st, start.Add(time.Second))
+		assert.True(t, res.Allowed)
+	})
+}
+
+func TestRateLimiter(t *testing.T) {
+	app := fiber.New()
+	app.Use(RateLimiter(WithMax(2), WithDuration(time.Minute), WithKeyFunc(KeyByHeader("X-API-Key"))))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+
+	get := func(apiKey string) *http.Response {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-API-Key", apiKey)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	first := get("a")
+	assert.Equal(t, fiber.StatusOK, first.StatusCode)
+	assert.Equal(t, "2", first.Header.Get("RateLimit-Limit"))
+	assert.Equal(t, "1", first.Header.Get("RateLimit-Remaining"))
+	assert.NotEmpty(t, first.Header.Get("RateLimit-Reset"))
+
+	get("a")
+	limited := get("a")
+	assert.Equal(t, fiber.StatusTooManyRequests, limited.StatusCode)
+	assert.Equal(t, "0", limited.Header.Get("RateLimit-Remaining"))
+	assert.NotEmpty(t, limited.Header.Get("Retry-After"))
+
+	// Another API key has its own budget
+	assert.Equal(t, fiber.StatusOK, get("b").StatusCode)
+}
+
+// fakeRedis serves GET and the compare-and-set EVAL from a map.
+func fakeRedis(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	var mu sync.Mutex
+	data := map[string]string{}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+					args := make([]string, n)
+					for i := range args {
+						// Bulk strings are read by length: the script spans lines
+						header, _ := r.ReadString('\n')
+						size, _ := strconv.Atoi(strings.TrimSpace(header[1:]))
+						arg := make([]byte, size+2)
+						io.ReadFull(r, arg)
+						args[i] = string(arg[:size])
+					}
+
+					mu.Lock()
+					var reply string
+					switch strings.ToUpper(args[0]) {
+					case "GET":
+						if v, ok := data[args[1]]; ok {
+							reply = "$" + strconv.Itoa(len(v)) + "\r\n" + v + "\r\n"
+						} else {
+							reply = "$-1\r\n"
+						}
+					case "EVAL": // EVAL script 1 key expected next ttl
+						reply = ":0\r\n"
+						if data[args[3]] == args[4] {
+							data[args[3]] = args[5]
+							reply = ":1\r\n"
+						}
+					default:
+						reply = "-ERR unknown command\r\n"
+					}
+					mu.Unlock()
+					conn.Write([]byte(reply))
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestRedisRateLimitStore(t *testing.T) {
+	redis := cache.NewRedisStore(cache.RedisConfig{Addr: fakeRedis(t), Prefix: "app:"})
+	defer redis.Close()
+
+	// Two instances share one budget
+	a, b := NewRedisRateLimitStore(redis), NewRedisRateLimitStore(redis)
+	rule := RateLimitRule{Strategy: SlidingWindow, Limit: 3, Window: time.Minute}
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	allowed := 0
+	for i := range 6 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			store := a
+			if i%2 == 1 {
+				store = b
+			}
+			res, err := store.Take(ctx, "ip:1.2.3.4", rule)
+			assert.NoError(t, err)
+			if res.Allowed {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 3, allowed)
+}
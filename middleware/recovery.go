@@ -1,14 +1,71 @@
 package middleware
 
 import (
+	"fmt"
+	"os"
+	"runtime/debug"
+
 	"github.com/gofiber/fiber/v2"
-	fiberrecover "github.com/gofiber/fiber/v2/middleware/recover"
 )
 
-// Recover creates a panic recovery middleware using Fiber's built-in recover.
-// It enables stack traces for debugging.
-func Recover() fiber.Handler {
-	return fiberrecover.New(fiberrecover.Config{
-		EnableStackTrace: true,
-	})
+// RecoverConfig configures the Recover middleware.
+type RecoverConfig struct {
+	// Logger receives each panic with its stack trace and request ID.
+	// Default: nil (written to stderr)
+	Logger Logger
+
+	// PanicHandler renders the response for a recovered panic, e.g. a
+	// branded error page. Default: nil (the panic is returned as an error,
+	// which the app's error handler answers with a 500)
+	PanicHandler func(c *fiber.Ctx, recovered any, stack []byte) error
+
+	// Next defines a function to skip this middleware when returning true.
+	Next func(c *fiber.Ctx) bool
+}
+
+// Recover turns panics in later handlers into errors, logging the stack
+// trace so the crash can be found from the request ID the client saw.
+func Recover(config ...RecoverConfig) fiber.Handler {
+	var cfg RecoverConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+
+	return func(c *fiber.Ctx) (err error) {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+			stack := debug.Stack()
+
+			if cfg.Logger != nil {
+				cfg.Logger.Error("panic recovered",
+					"panic", r,
+					"method", c.Method(),
+					"path", c.Path(),
+					"request_id", requestID(c),
+					"stack", string(stack),
+				)
+			} else {
+				fmt.Fprintf(os.Stderr, "panic: %v\n%s\n", r, stack)
+			}
+
+			if cfg.PanicHandler != nil {
+				err = cfg.PanicHandler(c, r, stack)
+				return
+			}
+			if e, ok := r.(error); ok {
+				err = fmt.Errorf("panic: %w", e)
+			} else {
+				err = fmt.Errorf("panic: %v", r)
+			}
+		}()
+
+		return c.Next()
+	}
 }
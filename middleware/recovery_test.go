@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecover(t *testing.T) {
+	t.Run("logs the panic and returns an error", func(t *testing.T) {
+		var buf bytes.Buffer
+		app := fiber.New()
+		app.Use(requestid.New())
+		app.Use(Recover(RecoverConfig{Logger: slog.New(slog.NewJSONHandler(&buf, nil))}))
+		app.Get("/", func(c *fiber.Ctx) error { panic("boom") })
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+
+		logged := buf.String()
+		assert.Contains(t, logged, `"panic":"boom"`)
+		assert.Contains(t, logged, `"request_id":"`+resp.Header.Get("X-Request-ID")+`"`)
+		assert.Contains(t, logged, "recovery_test.go", "the stack trace should be logged")
+	})
+
+	t.Run("renders with the panic handler", func(t *testing.T) {
+		app := fiber.New()
+		app.Use(Recover(RecoverConfig{
+			Logger: slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)),
+			PanicHandler: func(c *fiber.Ctx, recovered any, stack []byte) error {
+				return c.Status(fiber.StatusServiceUnavailable).SendString("sorry")
+			},
+		}))
+		app.Get("/", func(c *fiber.Ctx) error { panic("boom") })
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusServiceUnavailable, resp.StatusCode)
+	})
+}
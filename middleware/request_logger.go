@@ -1,34 +1,267 @@
 package middleware
 
 import (
+	"bytes"
+	"encoding/json"
+	"net/url"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-// RequestLogger emits structured request logs using the provided logger.
+// redacted replaces the values of redacted headers, parameters and fields.
+const redacted = "[REDACTED]"
+
+// queryStats is implemented by the per-request query trace stored in locals.
+type queryStats interface {
+	Count() int
+	Duration() time.Duration
+}
+
+// RequestLoggerConfig configures the RequestLogger middleware.
+type RequestLoggerConfig struct {
+	// Headers lists request headers to log, e.g. "User-Agent" or "Referer".
+	// Default: none
+	Headers []string
+
+	// RedactHeaders are logged as "[REDACTED]" when listed in Headers.
+	// Default: Authorization, Cookie and API key and CSRF token headers
+	RedactHeaders []string
+
+	// RedactParams are query parameters, and form or JSON body fields, whose
+	// values are logged as "[REDACTED]". Names are case-insensitive.
+	// Default: password, token, secret and API key names (see DefaultRequestLoggerConfig)
+	RedactParams []string
+
+	// MaxBodyBytes logs up to this many bytes of text, form and JSON request
+	// bodies. Meant for debugging; leave it off in production. Default: 0 (off)
+	MaxBodyBytes int
+
+	// SampleRate logs one in every SampleRate successful requests. Requests
+	// answered with 4xx or 5xx are always logged. Default: 1 (every request)
+	SampleRate int
+
+	// Next defines a function to skip this middleware when returning true.
+	Next func(c *fiber.Ctx) bool
+}
+
+// DefaultRequestLoggerConfig returns the default configuration.
+func DefaultRequestLoggerConfig() RequestLoggerConfig {
+	return RequestLoggerConfig{
+		RedactHeaders: []string{
+			fiber.HeaderAuthorization,
+			fiber.HeaderProxyAuthorization,
+			fiber.HeaderCookie,
+			"X-Api-Key",
+			"X-CSRF-Token",
+			"X-XSRF-TOKEN",
+		},
+		RedactParams: []string{
+			"password", "password_confirmation", "current_password",
+			"token", "access_token", "refresh_token", "id_token",
+			"secret", "client_secret", "api_key", "apikey", "_csrf",
+		},
+		SampleRate: 1,
+	}
+}
+
+// RequestLogger emits structured request logs using the provided logger:
+// method, path, redacted query, status, duration, response bytes (before
+// compression), client IP and request ID. Server errors are logged at error level.
 // Health check endpoints (/_health) are not logged to reduce noise.
-func RequestLogger(logger Logger) fiber.Handler {
+// When query tracing is enabled, the request's query count and time are included.
+//
+// Errors are passed to the app's error handler here so the logged status
+// matches the response.
+func RequestLogger(logger Logger, config ...RequestLoggerConfig) fiber.Handler {
+	cfg := DefaultRequestLoggerConfig()
+	if len(config) > 0 {
+		cfg = config[0]
+		defaults := DefaultRequestLoggerConfig()
+		if cfg.RedactHeaders == nil {
+			cfg.RedactHeaders = defaults.RedactHeaders
+		}
+		if cfg.RedactParams == nil {
+			cfg.RedactParams = defaults.RedactParams
+		}
+		if cfg.SampleRate <= 0 {
+			cfg.SampleRate = defaults.SampleRate
+		}
+	}
+	redactHeaders := lowerSet(cfg.RedactHeaders)
+	redactParams := lowerSet(cfg.RedactParams)
+	var successes atomic.Uint64
+
 	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
 		start := time.Now()
-		err := c.Next()
+		if err := c.Next(); err != nil {
+			if handlerErr := c.App().ErrorHandler(c, err); handlerErr != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
 		stop := time.Since(start)
 
 		// Skip logging health check endpoints
 		path := c.Path()
 		if strings.HasPrefix(path, "/_health") {
-			return err
+			return nil
+		}
+
+		// Sample successful requests; errors are always logged
+		status := c.Response().StatusCode()
+		if status < fiber.StatusBadRequest && cfg.SampleRate > 1 && (successes.Add(1)-1)%uint64(cfg.SampleRate) != 0 {
+			return nil
 		}
 
-		logger.Info("http request",
+		args := []any{
 			"method", c.Method(),
 			"path", path,
-			"status", c.Response().StatusCode(),
+			"status", status,
 			"duration", stop,
+			"bytes", responseSize(c),
 			"ip", c.IP(),
-		)
+		}
+		if query := c.Request().URI().QueryString(); len(query) > 0 {
+			args = append(args, "query", redactQuery(string(query), redactParams))
+		}
+		if id := requestID(c); id != "" {
+			args = append(args, "request_id", id)
+		}
+		for _, name := range cfg.Headers {
+			value := c.Get(name)
+			if value == "" {
+				continue
+			}
+			if redactHeaders[strings.ToLower(name)] {
+				value = redacted
+			}
+			args = append(args, "header_"+strings.ReplaceAll(strings.ToLower(name), "-", "_"), value)
+		}
+		if cfg.MaxBodyBytes > 0 && len(c.Body()) > 0 {
+			args = append(args, "body", logBody(c, cfg.MaxBodyBytes, redactParams))
+		}
+		if cfg.SampleRate > 1 {
+			args = append(args, "sample_rate", cfg.SampleRate)
+		}
+		if stats, ok := c.Locals("cartridge_query_trace").(queryStats); ok && stats.Count() > 0 {
+			args = append(args, "queries", stats.Count(), "query_time", stats.Duration())
+		}
+
+		if status >= fiber.StatusInternalServerError {
+			logger.Error("http request", args...)
+		} else {
+			logger.Info("http request", args...)
+		}
+
+		return nil
+	}
+}
+
+// responseSize returns the response body size without draining streamed
+// bodies, which report their Content-Length (-1 when chunked).
+func responseSize(c *fiber.Ctx) int {
+	if c.Response().IsBodyStream() {
+		return c.Response().Header.ContentLength()
+	}
+	return len(c.Response().Body())
+}
+
+// requestID returns the ID set by the requestid middleware.
+func requestID(c *fiber.Ctx) string {
+	if id, ok := c.Locals("requestid").(string); ok && id != "" {
+		return id
+	}
+	return c.GetRespHeader(fiber.HeaderXRequestID)
+}
+
+// redactQuery replaces the values of redacted parameters in a query string.
+func redactQuery(query string, redact map[string]bool) string {
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return redacted // can't tell what it holds
+	}
+	if !redactValues(values, redact) {
+		return query
+	}
+	return values.Encode()
+}
+
+// redactValues redacts values in place and reports whether any were.
+func redactValues(values url.Values, redact map[string]bool) bool {
+	changed := false
+	for name, vs := range values {
+		if redact[strings.ToLower(name)] {
+			for i := range vs {
+				vs[i] = redacted
+			}
+			changed = true
+		}
+	}
+	return changed
+}
+
+// logBody returns up to limit bytes of the request body, with redacted
+// fields of form and JSON bodies replaced.
+func logBody(c *fiber.Ctx, limit int, redact map[string]bool) string {
+	body := c.Body()
+	contentType := strings.ToLower(c.Get(fiber.HeaderContentType))
+	switch {
+	case strings.HasPrefix(contentType, fiber.MIMEApplicationForm):
+		if values, err := url.ParseQuery(string(body)); err == nil && redactValues(values, redact) {
+			body = []byte(values.Encode())
+		}
+	case strings.HasPrefix(contentType, fiber.MIMEApplicationJSON) || strings.HasSuffix(strings.SplitN(contentType, ";", 2)[0], "+json"):
+		var v any
+		if json.Unmarshal(body, &v) == nil && redactJSON(v, redact) {
+			if out, err := json.Marshal(v); err == nil {
+				body = out
+			}
+		}
+	case strings.HasPrefix(contentType, "text/"), strings.HasPrefix(contentType, fiber.MIMEApplicationXML):
+	default:
+		return "[" + strings.SplitN(contentType, ";", 2)[0] + " body]"
+	}
+
+	if len(body) > limit {
+		return string(bytes.ToValidUTF8(body[:limit], nil)) + "..."
+	}
+	return string(body)
+}
+
+// redactJSON redacts fields in decoded JSON in place, at any depth, and
+// reports whether any were.
+func redactJSON(v any, redact map[string]bool) bool {
+	changed := false
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if redact[strings.ToLower(key)] {
+				v[key] = redacted
+				changed = true
+			} else if redactJSON(value, redact) {
+				changed = true
+			}
+		}
+	case []any:
+		for _, value := range v {
+			if redactJSON(value, redact) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
 
-		return err
+func lowerSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[strings.ToLower(name)] = true
 	}
+	return set
 }
@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	app := fiber.New()
+	app.Use(requestid.New())
+	app.Use(RequestLogger(slog.New(slog.NewJSONHandler(&buf, nil))))
+	app.Get("/hello", func(c *fiber.Ctx) error { return c.SendString("hello") })
+	app.Get("/missing", func(c *fiber.Ctx) error { return fiber.ErrNotFound })
+	app.Get("/_health", func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	logged := func(path string) map[string]any {
+		t.Helper()
+		buf.Reset()
+		resp, err := app.Test(httptest.NewRequest("GET", path, nil))
+		require.NoError(t, err)
+		if buf.Len() == 0 {
+			return nil
+		}
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+		assert.Equal(t, resp.Header.Get("X-Request-ID"), entry["request_id"])
+		return entry
+	}
+
+	entry := logged("/hello")
+	require.NotNil(t, entry)
+	assert.Equal(t, "INFO", entry["level"])
+	assert.Equal(t, float64(fiber.StatusOK), entry["status"])
+	assert.Equal(t, float64(len("hello")), entry["bytes"])
+	assert.Contains(t, entry, "duration")
+
+	entry = logged("/missing")
+	require.NotNil(t, entry)
+	assert.Equal(t, float64(fiber.StatusNotFound), entry["status"], "errors should be logged with their response status")
+
+	assert.Nil(t, logged("/_health"), "health checks should not be logged")
+}
+
+func TestRequestLogger_Redaction(t *testing.T) {
+	var buf bytes.Buffer
+	app := fiber.New()
+	app.Use(RequestLogger(slog.New(slog.NewJSONHandler(&buf, nil)), RequestLoggerConfig{
+		Headers:      []string{"Authorization", "User-Agent"},
+		RedactParams: []string{"token", "password"},
+		MaxBodyBytes: 64,
+	}))
+	app.Post("/login", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusNoContent) })
+
+	req := httptest.NewRequest("POST", "/login?token=abc&page=2", strings.NewReader(`{"email":"a@b.c","nested":{"Password":"hunter2"}}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("User-Agent", "test")
+	req.Header.Set("Content-Type", "application/json")
+	_, err := app.Test(req)
+	require.NoError(t, err)
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "page=2&token=%5BREDACTED%5D", entry["query"])
+	assert.Equal(t, "[REDACTED]", entry["header_authorization"])
+	assert.Equal(t, "test", entry["header_user_agent"])
+	assert.Equal(t, `{"email":"a@b.c","nested":{"Password":"[REDACTED]"}}`, entry["body"])
+	assert.NotContains(t, buf.String(), "hunter2")
+	assert.NotContains(t, buf.String(), "Bearer secret")
+}
+
+func TestRequestLogger_BodyTruncated(t *testing.T) {
+	var buf bytes.Buffer
+	app := fiber.New()
+	app.Use(RequestLogger(slog.New(slog.NewJSONHandler(&buf, nil)), RequestLoggerConfig{MaxBodyBytes: 5}))
+	app.Post("/notes", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusCreated) })
+
+	req := httptest.NewRequest("POST", "/notes", strings.NewReader("hello world"))
+	req.Header.Set("Content-Type", "text/plain")
+	_, err := app.Test(req)
+	require.NoError(t, err)
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "hello...", entry["body"])
+}
+
+func TestRequestLogger_Sampling(t *testing.T) {
+	var buf bytes.Buffer
+	app := fiber.New()
+	app.Use(RequestLogger(slog.New(slog.NewJSONHandler(&buf, nil)), RequestLoggerConfig{SampleRate: 3}))
+	app.Get("/ok", func(c *fiber.Ctx) error { return c.SendString("ok") })
+	app.Get("/fail", func(c *fiber.Ctx) error { return fiber.ErrInternalServerError })
+
+	for i := 0; i < 6; i++ {
+		_, err := app.Test(httptest.NewRequest("GET", "/ok", nil))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, strings.Count(buf.String(), "\n"), "one in three successful requests should be logged")
+
+	buf.Reset()
+	for i := 0; i < 2; i++ {
+		_, err := app.Test(httptest.NewRequest("GET", "/fail", nil))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, strings.Count(buf.String(), "\n"), "errors should always be logged")
+}
@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxSecurityReportSize bounds the report bodies SecurityReportHandler reads.
+const maxSecurityReportSize = 64 * 1024
+
+// SecurityReport is a CSP or Permissions-Policy violation reported by a
+// browser, from either the legacy report-uri format or the Reporting API.
+type SecurityReport struct {
+	// Type is "csp-violation" or "permissions-policy-violation".
+	Type string `json:"type"`
+	// DocumentURL is the page that caused the violation.
+	DocumentURL string `json:"document_url"`
+	// Directive is the violated CSP directive or Permissions-Policy feature,
+	// e.g. "script-src-elem" or "camera".
+	Directive string `json:"directive"`
+	// BlockedURL is the resource the CSP blocked, or "inline" and "eval".
+	BlockedURL string `json:"blocked_url,omitempty"`
+	// Disposition is "enforce", or "report" for report-only policies.
+	Disposition string `json:"disposition"`
+	SourceFile  string `json:"source_file,omitempty"`
+	LineNumber  int    `json:"line_number,omitempty"`
+	// Sample is the start of the blocked inline script or style, when the
+	// policy asks for it with 'report-sample'.
+	Sample string `json:"sample,omitempty"`
+}
+
+// cspReport is the legacy application/csp-report body.
+type cspReport struct {
+	Report struct {
+		DocumentURI        string `json:"document-uri"`
+		ViolatedDirective  string `json:"violated-directive"`
+		EffectiveDirective string `json:"effective-directive"`
+		BlockedURI         string `json:"blocked-uri"`
+		Disposition        string `json:"disposition"`
+		SourceFile         string `json:"source-file"`
+		LineNumber         int    `json:"line-number"`
+		ScriptSample       string `json:"script-sample"`
+	} `json:"csp-report"`
+}
+
+// reportingAPIReport is one report of an application/reports+json body.
+type reportingAPIReport struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+	Body struct {
+		EffectiveDirective string `json:"effectiveDirective"`
+		FeatureID          string `json:"featureId"`
+		BlockedURL         string `json:"blockedURL"`
+		Disposition        string `json:"disposition"`
+		SourceFile         string `json:"sourceFile"`
+		LineNumber         int    `json:"lineNumber"`
+		Sample             string `json:"sample"`
+	} `json:"body"`
+}
+
+// SecurityReportHandler receives the violation reports browsers send to
+// SecurityHeaders.ReportURI and calls onReport for each. It accepts both
+// application/csp-report and application/reports+json bodies, and answers
+// 204, or 400 for bodies it can't parse.
+//
+//	app.Post("/_security-reports", middleware.SecurityReportHandler(func(c *fiber.Ctx, r middleware.SecurityReport) {
+//	    slog.Warn("security policy violation", "directive", r.Directive, "page", r.DocumentURL)
+//	}))
+func SecurityReportHandler(onReport func(c *fiber.Ctx, report SecurityReport)) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		body := c.Body()
+		if len(body) > maxSecurityReportSize {
+			return fiber.ErrRequestEntityTooLarge
+		}
+		reports, err := parseSecurityReports(body)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid security report")
+		}
+		for _, report := range reports {
+			onReport(c, report)
+		}
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+}
+
+// parseSecurityReports reads a report-uri or Reporting API body. The
+// Reporting API may batch other report types, such as deprecations; they
+// are skipped.
+func parseSecurityReports(body []byte) ([]SecurityReport, error) {
+	if trimmed := strings.TrimSpace(string(body)); !strings.HasPrefix(trimmed, "[") {
+		var legacy cspReport
+		if err := json.Unmarshal(body, &legacy); err != nil {
+			return nil, err
+		}
+		r := legacy.Report
+		directive := r.EffectiveDirective
+		if directive == "" {
+			directive, _, _ = strings.Cut(r.ViolatedDirective, " ")
+		}
+		disposition := r.Disposition
+		if disposition == "" {
+			disposition = "enforce"
+		}
+		return []SecurityReport{{
+			Type:        "csp-violation",
+			DocumentURL: r.DocumentURI,
+			Directive:   directive,
+			BlockedURL:  r.BlockedURI,
+			Disposition: disposition,
+			SourceFile:  r.SourceFile,
+			LineNumber:  r.LineNumber,
+			Sample:      r.ScriptSample,
+		}}, nil
+	}
+
+	var batch []reportingAPIReport
+	if err := json.Unmarshal(body, &batch); err != nil {
+		return nil, err
+	}
+	reports := make([]SecurityReport, 0, len(batch))
+	for _, r := range batch {
+		directive := r.Body.EffectiveDirective
+		switch r.Type {
+		case "csp-violation":
+		case "permissions-policy-violation":
+			directive = r.Body.FeatureID
+		default:
+			continue
+		}
+		reports = append(reports, SecurityReport{
+			Type:        r.Type,
+			DocumentURL: r.URL,
+			Directive:   directive,
+			BlockedURL:  r.Body.BlockedURL,
+			Disposition: r.Body.Disposition,
+			SourceFile:  r.Body.SourceFile,
+			LineNumber:  r.Body.LineNumber,
+			Sample:      r.Body.Sample,
+		})
+	}
+	return reports, nil
+}
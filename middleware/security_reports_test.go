@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecurityReportHandler(t *testing.T) {
+	var reports []SecurityReport
+	app := fiber.New()
+	app.Post("/reports", SecurityReportHandler(func(c *fiber.Ctx, r SecurityReport) {
+		reports = append(reports, r)
+	}))
+
+	post := func(contentType, body string) int {
+		t.Helper()
+		req := httptest.NewRequest("POST", "/reports", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	status := post("application/csp-report", `{"csp-report": {
+		"document-uri": "https://example.com/page",
+		"violated-directive": "script-src 'self'",
+		"blocked-uri": "https://evil.example/x.js"
+	}}`)
+	assert.Equal(t, 204, status)
+
+	status = post("application/reports+json", `[
+		{"type": "csp-violation", "url": "https://example.com/a", "body": {"effectiveDirective": "img-src", "blockedURL": "data", "disposition": "report"}},
+		{"type": "permissions-policy-violation", "url": "https://example.com/b", "body": {"featureId": "camera", "disposition": "enforce"}},
+		{"type": "deprecation", "url": "https://example.com/c", "body": {}}
+	]`)
+	assert.Equal(t, 204, status)
+
+	require.Len(t, reports, 3)
+	assert.Equal(t, SecurityReport{
+		Type:        "csp-violation",
+		DocumentURL: "https://example.com/page",
+		Directive:   "script-src",
+		BlockedURL:  "https://evil.example/x.js",
+		Disposition: "enforce",
+	}, reports[0])
+	assert.Equal(t, "img-src", reports[1].Directive)
+	assert.Equal(t, "report", reports[1].Disposition)
+	assert.Equal(t, "permissions-policy-violation", reports[2].Type)
+	assert.Equal(t, "camera", reports[2].Directive)
+
+	assert.Equal(t, 400, post("application/csp-report", "not json"))
+}
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/karloscodes/cartridge/middleware"
+
+// TracingConfig configures the tracing middleware.
+type TracingConfig struct {
+	// TracerProvider creates request spans. Default: otel.GetTracerProvider()
+	TracerProvider trace.TracerProvider
+
+	// Propagator reads the caller's trace context (traceparent) from request
+	// headers. Default: otel.GetTextMapPropagator()
+	Propagator propagation.TextMapPropagator
+
+	// Next defines a function to skip this middleware when returning true.
+	Next func(c *fiber.Ctx) bool
+}
+
+// Tracing starts an OpenTelemetry server span for each request, continuing
+// the caller's trace when a traceparent header is present. The span is stored
+// in c.UserContext() so handlers, queries and background tasks can parent
+// their own spans on it. Errors are passed to the app's error handler here so
+// the recorded status code matches the response.
+func Tracing(config ...TracingConfig) fiber.Handler {
+	var cfg TracingConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.TracerProvider == nil {
+		cfg.TracerProvider = otel.GetTracerProvider()
+	}
+	if cfg.Propagator == nil {
+		cfg.Propagator = otel.GetTextMapPropagator()
+	}
+	tracer := cfg.TracerProvider.Tracer(tracerName)
+
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		method := c.Method()
+		parent := cfg.Propagator.Extract(c.UserContext(), headerCarrier{c})
+		ctx, span := tracer.Start(parent, method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", method),
+				attribute.String("url.path", c.Path()),
+				attribute.String("client.address", c.IP()),
+				attribute.String("user_agent.original", c.Get(fiber.HeaderUserAgent)),
+			),
+		)
+		defer span.End()
+		c.SetUserContext(ctx)
+
+		if err := c.Next(); err != nil {
+			span.RecordError(err)
+			if handlerErr := c.App().ErrorHandler(c, err); handlerErr != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		// The matched route is only known once the chain has run
+		route := c.Route().Path
+		status := c.Response().StatusCode()
+		span.SetName(method + " " + route)
+		span.SetAttributes(
+			attribute.String("http.route", route),
+			attribute.Int("http.response.status_code", status),
+		)
+		if id, ok := c.Locals("requestid").(string); ok && id != "" {
+			span.SetAttributes(attribute.String("http.request.id", id))
+		}
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		return nil
+	}
+}
+
+// headerCarrier adapts fiber request headers to propagation.TextMapCarrier.
+type headerCarrier struct {
+	c *fiber.Ctx
+}
+
+func (h headerCarrier) Get(key string) string {
+	return h.c.Get(key)
+}
+
+func (h headerCarrier) Set(key, value string) {
+	h.c.Request().Header.Set(key, value)
+}
+
+func (h headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(h.c.GetReqHeaders()))
+	for k := range h.c.GetReqHeaders() {
+		keys = append(keys, k)
+	}
+	return keys
+}
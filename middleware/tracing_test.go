@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func spanAttr(span sdktrace.ReadOnlySpan, key string) attribute.Value {
+	for _, kv := range span.Attributes() {
+		if string(kv.Key) == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	app := fiber.New()
+	app.Use(Tracing(TracingConfig{
+		TracerProvider: provider,
+		Propagator:     propagation.TraceContext{},
+		Next:           func(c *fiber.Ctx) bool { return c.Path() == "/_health" },
+	}))
+	app.Get("/users/:id", func(c *fiber.Ctx) error {
+		assert.True(t, trace.SpanFromContext(c.UserContext()).IsRecording())
+		return fiber.ErrNotFound
+	})
+	app.Get("/boom", func(c *fiber.Ctx) error {
+		return fiber.ErrInternalServerError
+	})
+	app.Get("/_health", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	t.Run("continues the caller's trace", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/users/42", nil)
+		req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+
+		spans := recorder.Ended()
+		assert.Len(t, spans, 1)
+		span := spans[0]
+		assert.Equal(t, "GET /users/:id", span.Name())
+		assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+		assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+		assert.Equal(t, int64(404), spanAttr(span, "http.response.status_code").AsInt64())
+		assert.Equal(t, "/users/:id", spanAttr(span, "http.route").AsString())
+	})
+
+	t.Run("marks server errors", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest("GET", "/boom", nil))
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+
+		spans := recorder.Ended()
+		span := spans[len(spans)-1]
+		assert.Equal(t, "GET /boom", span.Name())
+		assert.Equal(t, "Error", span.Status().Code.String())
+	})
+
+	t.Run("skips requests matched by Next", func(t *testing.T) {
+		before := len(recorder.Ended())
+		_, err := app.Test(httptest.NewRequest("GET", "/_health", nil))
+		assert.NoError(t, err)
+		assert.Len(t, recorder.Ended(), before)
+	})
+}
@@ -1,6 +1,8 @@
 package cartridge
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 )
 
@@ -10,6 +12,41 @@ type Migrator interface {
 	Migrate(db *gorm.DB) error
 }
 
+// StartupMigrator is a Migrator with a more cautious variant for the
+// migrate phase of Run(), e.g. SQLMigrator holding back contract migrations.
+type StartupMigrator interface {
+	Migrator
+
+	// MigrateStartup runs the migrations that are safe while the previous
+	// app version may still be serving.
+	MigrateStartup(db *gorm.DB) error
+}
+
+// MigrationStatus describes a migration for `migrate:status`.
+type MigrationStatus struct {
+	Version   string
+	Name      string
+	Kind      MigrationKind
+	AppliedAt *time.Time // nil while pending
+}
+
+// StatusMigrator is a Migrator that can list its migrations, e.g. SQLMigrator.
+type StatusMigrator interface {
+	Migrator
+
+	// Status lists every migration, applied or pending, in order.
+	Status(db *gorm.DB) ([]MigrationStatus, error)
+}
+
+// RollbackMigrator is a Migrator that can revert its latest migrations,
+// e.g. SQLMigrator.
+type RollbackMigrator interface {
+	Migrator
+
+	// Rollback reverts the last steps migrations and returns them.
+	Rollback(db *gorm.DB, steps int) ([]MigrationStatus, error)
+}
+
 // AutoMigrator uses GORM's AutoMigrate for simple migration needs.
 type AutoMigrator struct {
 	models []any
@@ -0,0 +1,220 @@
+package cartridge
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Model is a base for application models, like gorm.Model with JSON tags.
+// Embedding it gives a model soft deletes: Delete sets DeletedAt, and
+// queries skip deleted rows unless they use WithTrashed or OnlyTrashed.
+//
+//	type Post struct {
+//	    cartridge.Model
+//	    Title string `json:"title"`
+//	}
+type Model struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+// WithTrashed includes soft-deleted rows in the results.
+func (q *TypedQueryBuilder[T]) WithTrashed() *TypedQueryBuilder[T] {
+	q.db = q.db.Unscoped()
+	return q
+}
+
+// OnlyTrashed limits the results to soft-deleted rows.
+func (q *TypedQueryBuilder[T]) OnlyTrashed() *TypedQueryBuilder[T] {
+	column, err := softDeleteColumn[T](q.db)
+	if err != nil {
+		q.db = q.db.Unscoped()
+		q.db.AddError(err)
+		return q
+	}
+	q.db = q.db.Unscoped().Where(clause.Neq{Column: column, Value: nil})
+	return q
+}
+
+// Restore undeletes the matching soft-deleted rows and returns how many
+// were restored:
+//
+//	n, err := cartridge.Query[Post](ctx.DB()).Where("id = ?", id).Restore()
+func (q *TypedQueryBuilder[T]) Restore() (int64, error) {
+	column, err := softDeleteColumn[T](q.db)
+	if err != nil {
+		return 0, err
+	}
+	result := q.session().Unscoped().
+		Where(clause.Neq{Column: column, Value: nil}).
+		UpdateColumn(column.Name, nil)
+	return result.RowsAffected, result.Error
+}
+
+// softDeleteColumn returns T's gorm.DeletedAt column.
+func softDeleteColumn[T any](db *gorm.DB) (clause.Column, error) {
+	modelSchema, err := schema.Parse(new(T), &resourceSchemas, db.NamingStrategy)
+	if err != nil {
+		return clause.Column{}, err
+	}
+	for _, field := range modelSchema.Fields {
+		if field.FieldType == reflect.TypeFor[gorm.DeletedAt]() && field.DBName != "" {
+			return clause.Column{Table: clause.CurrentTable, Name: field.DBName}, nil
+		}
+	}
+	return clause.Column{}, fmt.Errorf("cartridge: %s has no soft delete column (embed cartridge.Model or add a gorm.DeletedAt field)", resourceName[T]())
+}
+
+// ModelHooks are callbacks run around writes of model T, for behavior that
+// belongs to the application rather than the model, e.g. indexing posts
+// for search. Each receives the transaction and the row being written; an
+// error aborts the write and rolls the transaction back.
+type ModelHooks[T any] struct {
+	BeforeCreate func(tx *gorm.DB, model *T) error
+	AfterCreate  func(tx *gorm.DB, model *T) error
+	BeforeUpdate func(tx *gorm.DB, model *T) error
+	AfterUpdate  func(tx *gorm.DB, model *T) error
+	BeforeDelete func(tx *gorm.DB, model *T) error
+	AfterDelete  func(tx *gorm.DB, model *T) error
+}
+
+// RegisterModelHooks runs hooks for every write of T through db and the
+// sessions derived from it, such as ctx.DB(). Registering several times
+// adds hooks; they run in registration order, after the model's own GORM
+// hook methods. Register hooks at startup, before serving requests:
+//
+//	cartridge.RegisterModelHooks(db, cartridge.ModelHooks[Post]{
+//	    AfterUpdate: func(tx *gorm.DB, post *Post) error {
+//	        return search.Index(tx.Statement.Context, post)
+//	    },
+//	})
+//
+// Writes that don't carry a T, like Delete(&Post{}, "author_id = ?", id),
+// pass the zero value.
+func RegisterModelHooks[T any](db *gorm.DB, hooks ModelHooks[T]) error {
+	registry, ok := db.Config.Plugins[modelHooksPlugin].(*modelHookRegistry)
+	if !ok {
+		registry = &modelHookRegistry{hooks: make(map[modelHookKey][]modelHook)}
+		if err := db.Use(registry); err != nil {
+			return fmt.Errorf("cartridge: register model hooks: %w", err)
+		}
+	}
+
+	modelType := reflect.TypeFor[T]()
+	for event, fn := range map[string]func(*gorm.DB, *T) error{
+		"before_create": hooks.BeforeCreate,
+		"after_create":  hooks.AfterCreate,
+		"before_update": hooks.BeforeUpdate,
+		"after_update":  hooks.AfterUpdate,
+		"before_delete": hooks.BeforeDelete,
+		"after_delete":  hooks.AfterDelete,
+	} {
+		if fn == nil {
+			continue
+		}
+		registry.add(modelHookKey{modelType, event}, func(tx *gorm.DB, value reflect.Value) error {
+			return fn(tx, value.Addr().Interface().(*T))
+		})
+	}
+	return nil
+}
+
+// modelHooksPlugin is the name of the registry in gorm.Config.Plugins.
+const modelHooksPlugin = "cartridge:model_hooks"
+
+// modelHook runs a typed hook on one addressable row.
+type modelHook func(tx *gorm.DB, value reflect.Value) error
+
+type modelHookKey struct {
+	model reflect.Type
+	event string
+}
+
+// modelHookRegistry is a GORM plugin dispatching write callbacks to the
+// hooks registered for the statement's model.
+type modelHookRegistry struct {
+	mu    sync.RWMutex
+	hooks map[modelHookKey][]modelHook
+}
+
+// Name implements gorm.Plugin.
+func (r *modelHookRegistry) Name() string {
+	return modelHooksPlugin
+}
+
+// Initialize implements gorm.Plugin. Before hooks run right after GORM's
+// own before callbacks and after hooks right after its after callbacks,
+// inside the write's transaction.
+func (r *modelHookRegistry) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	return errors.Join(
+		callbacks.Create().After("gorm:before_create").Before("gorm:create").Register("cartridge:before_create", r.callback("before_create")),
+		callbacks.Create().After("gorm:after_create").Before("gorm:commit_or_rollback_transaction").Register("cartridge:after_create", r.callback("after_create")),
+		callbacks.Update().After("gorm:before_update").Before("gorm:update").Register("cartridge:before_update", r.callback("before_update")),
+		callbacks.Update().After("gorm:after_update").Before("gorm:commit_or_rollback_transaction").Register("cartridge:after_update", r.callback("after_update")),
+		callbacks.Delete().After("gorm:before_delete").Before("gorm:delete").Register("cartridge:before_delete", r.callback("before_delete")),
+		callbacks.Delete().After("gorm:after_delete").Before("gorm:commit_or_rollback_transaction").Register("cartridge:after_delete", r.callback("after_delete")),
+	)
+}
+
+func (r *modelHookRegistry) add(key modelHookKey, hook modelHook) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hooks[key] = append(r.hooks[key], hook)
+}
+
+// callback returns the GORM callback running the event's hooks for each
+// row of the statement.
+func (r *modelHookRegistry) callback(event string) func(tx *gorm.DB) {
+	return func(tx *gorm.DB) { r.run(tx, event) }
+}
+
+func (r *modelHookRegistry) run(tx *gorm.DB, event string) {
+	if tx.Error != nil || tx.Statement.Schema == nil || tx.Statement.SkipHooks {
+		return
+	}
+	r.mu.RLock()
+	hooks := r.hooks[modelHookKey{tx.Statement.Schema.ModelType, event}]
+	r.mu.RUnlock()
+	if len(hooks) == 0 {
+		return
+	}
+
+	var rows []reflect.Value
+	value := reflect.Indirect(tx.Statement.ReflectValue)
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			rows = append(rows, reflect.Indirect(value.Index(i)))
+		}
+	case reflect.Struct:
+		rows = append(rows, value)
+	}
+
+	for _, row := range rows {
+		if row.Type() != tx.Statement.Schema.ModelType {
+			continue
+		}
+		if !row.CanAddr() {
+			// Hooks take a pointer; copy rows passed by value
+			addressable := reflect.New(row.Type()).Elem()
+			addressable.Set(row)
+			row = addressable
+		}
+		for _, hook := range hooks {
+			if err := hook(tx, row); err != nil {
+				tx.AddError(err)
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package cartridge
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+type modelPost struct {
+	Model
+	Title string
+	Slug  string
+}
+
+func TestModel_SoftDeletes(t *testing.T) {
+	db := openAsyncTestDB(t)
+	if err := db.AutoMigrate(&modelPost{}); err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+	posts := []modelPost{{Title: "kept"}, {Title: "deleted"}}
+	db.Create(&posts)
+	if err := db.Delete(&posts[1]).Error; err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+
+	count := func(q *TypedQueryBuilder[modelPost]) int64 {
+		t.Helper()
+		n, err := q.Count()
+		if err != nil {
+			t.Fatalf("count failed: %v", err)
+		}
+		return n
+	}
+	if n := count(Query[modelPost](db)); n != 1 {
+		t.Errorf("expected deleted posts to be hidden, got %d", n)
+	}
+	if n := count(Query[modelPost](db).WithTrashed()); n != 2 {
+		t.Errorf("expected WithTrashed to include deleted posts, got %d", n)
+	}
+	trashed, err := Query[modelPost](db).OnlyTrashed().Find()
+	if err != nil || len(trashed) != 1 || trashed[0].Title != "deleted" {
+		t.Fatalf("expected only the deleted post, got %+v (%v)", trashed, err)
+	}
+
+	n, err := Query[modelPost](db).Where("id = ?", posts[1].ID).Restore()
+	if err != nil || n != 1 {
+		t.Fatalf("expected one restored post, got %d (%v)", n, err)
+	}
+	if n := count(Query[modelPost](db)); n != 2 {
+		t.Errorf("expected the restored post to be visible, got %d", n)
+	}
+
+	if _, err := Query[queryProduct](db).Restore(); err == nil || !strings.Contains(err.Error(), "no soft delete column") {
+		t.Errorf("expected an error for a model without DeletedAt, got %v", err)
+	}
+}
+
+func TestModel_Hooks(t *testing.T) {
+	db := openAsyncTestDB(t)
+	if err := db.AutoMigrate(&modelPost{}); err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+
+	var events []string
+	err := RegisterModelHooks(db, ModelHooks[modelPost]{
+		BeforeCreate: func(tx *gorm.DB, post *modelPost) error {
+			if post.Title == "" {
+				return errors.New("title is required")
+			}
+			post.Slug = strings.ReplaceAll(strings.ToLower(post.Title), " ", "-")
+			return nil
+		},
+		AfterUpdate: func(tx *gorm.DB, post *modelPost) error {
+			events = append(events, "updated "+post.Title)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("RegisterModelHooks failed: %v", err)
+	}
+	err = RegisterModelHooks(db, ModelHooks[modelPost]{
+		AfterDelete: func(tx *gorm.DB, post *modelPost) error {
+			events = append(events, "deleted")
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("second RegisterModelHooks failed: %v", err)
+	}
+
+	post := modelPost{Title: "Hello World"}
+	if err := db.Create(&post).Error; err != nil || post.Slug != "hello-world" {
+		t.Fatalf("expected BeforeCreate to set the slug, got %q (%v)", post.Slug, err)
+	}
+	batch := []modelPost{{Title: "A"}, {Title: "B"}}
+	if err := db.Create(&batch).Error; err != nil || batch[1].Slug != "b" {
+		t.Errorf("expected hooks to run for each row, got %+v (%v)", batch, err)
+	}
+	if err := db.Create(&modelPost{}).Error; err == nil || !strings.Contains(err.Error(), "title is required") {
+		t.Errorf("expected the hook to abort the insert, got %v", err)
+	}
+	var total int64
+	db.Model(&modelPost{}).Count(&total)
+	if total != 3 {
+		t.Errorf("expected the failed insert to be rolled back, got %d rows", total)
+	}
+
+	post.Title = "Edited"
+	db.Save(&post)
+	db.Delete(&post)
+	if strings.Join(events, ", ") != "updated Edited, deleted" {
+		t.Errorf("unexpected events: %v", events)
+	}
+
+	// Other models are unaffected
+	if err := db.AutoMigrate(&queryVendor{}); err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+	if err := db.Create(&queryVendor{Name: "acme"}).Error; err != nil {
+		t.Errorf("expected other models to save, got %v", err)
+	}
+}
@@ -0,0 +1,270 @@
+package cartridge
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrCurrencyMismatch is returned when combining amounts in different currencies.
+var ErrCurrencyMismatch = errors.New("cartridge: currency mismatch")
+
+// Money is an amount in integer minor units (cents for USD) with an ISO 4217
+// currency code. Use it instead of float64 for prices to avoid rounding errors.
+//
+// In a model, Money is stored in a single column as "USD 19.99". To keep
+// amount and currency in separate columns (e.g. to SUM in SQL), embed it:
+//
+//	Price cartridge.Money `gorm:"embedded;embeddedPrefix:price_"`
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// currencyExponents lists currencies that don't use two decimal places.
+var currencyExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0,
+	"KRW": 0, "PYG": 0, "RWF": 0, "UGX": 0, "VND": 0, "VUV": 0, "XAF": 0,
+	"XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// currencySymbols are used by Format; other currencies show their code.
+var currencySymbols = map[string]string{
+	"USD": "$", "EUR": "€", "GBP": "£", "JPY": "¥", "INR": "₹", "KRW": "₩",
+	"BRL": "R$", "CAD": "CA$", "AUD": "A$", "MXN": "MX$", "CHF": "CHF ",
+}
+
+// CurrencyExponent returns the number of decimal places for currency.
+func CurrencyExponent(currency string) int {
+	if exp, ok := currencyExponents[strings.ToUpper(currency)]; ok {
+		return exp
+	}
+	return 2
+}
+
+// validCurrency reports whether code looks like an ISO 4217 code.
+func validCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// NewMoney creates an amount in minor units: NewMoney(1999, "USD") is $19.99.
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+// ParseMoney parses a decimal string such as "19.99" or "-5" without going
+// through float64. More decimals than the currency allows is an error.
+func ParseMoney(s, currency string) (Money, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if !validCurrency(currency) {
+		return Money{}, fmt.Errorf("cartridge: invalid currency %q", currency)
+	}
+	exp := CurrencyExponent(currency)
+
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" {
+		return Money{}, fmt.Errorf("cartridge: invalid amount %q", s)
+	}
+	if len(frac) > exp {
+		return Money{}, fmt.Errorf("cartridge: %s allows %d decimal places, got %q", currency, exp, s)
+	}
+	digits := whole + frac + strings.Repeat("0", exp-len(frac))
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return Money{}, fmt.Errorf("cartridge: invalid amount %q", s)
+		}
+	}
+	amount, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("cartridge: invalid amount %q: %w", s, err)
+	}
+	if negative {
+		amount = -amount
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// IsZero reports whether the amount is zero.
+func (m Money) IsZero() bool { return m.Amount == 0 }
+
+// IsNegative reports whether the amount is below zero.
+func (m Money) IsNegative() bool { return m.Amount < 0 }
+
+// Add returns m + o. Both must share a currency.
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	return Money{Amount: m.Amount + o.Amount, Currency: m.Currency}, nil
+}
+
+// Sub returns m - o. Both must share a currency.
+func (m Money) Sub(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	return Money{Amount: m.Amount - o.Amount, Currency: m.Currency}, nil
+}
+
+// Mul returns the amount multiplied by a quantity.
+func (m Money) Mul(qty int64) Money {
+	return Money{Amount: m.Amount * qty, Currency: m.Currency}
+}
+
+// Split divides the amount into n parts that sum to the original, spreading
+// the remainder one minor unit at a time over the first parts.
+func (m Money) Split(n int) []Money {
+	if n <= 0 {
+		return nil
+	}
+	parts := make([]Money, n)
+	share, rem := m.Amount/int64(n), m.Amount%int64(n)
+	for i := range parts {
+		parts[i] = Money{Amount: share, Currency: m.Currency}
+		if int64(i) < abs64(rem) {
+			if rem > 0 {
+				parts[i].Amount++
+			} else {
+				parts[i].Amount--
+			}
+		}
+	}
+	return parts
+}
+
+func abs64(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Decimal returns the amount in major units, e.g. "19.99".
+func (m Money) Decimal() string {
+	exp := CurrencyExponent(m.Currency)
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	digits := strconv.FormatInt(amount, 10)
+	if exp == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+}
+
+// Format returns the amount for display with a currency symbol and
+// thousands separators, e.g. "$1,234.50" or "-€5.00".
+func (m Money) Format() string {
+	dec := m.Decimal()
+	sign := ""
+	if strings.HasPrefix(dec, "-") {
+		sign, dec = "-", dec[1:]
+	}
+	whole, frac, hasFrac := strings.Cut(dec, ".")
+
+	var b strings.Builder
+	for i, r := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(r)
+	}
+	if hasFrac {
+		b.WriteString("." + frac)
+	}
+
+	if symbol, ok := currencySymbols[m.Currency]; ok {
+		return sign + symbol + b.String()
+	}
+	return sign + b.String() + " " + m.Currency
+}
+
+// String returns the amount with its currency code, e.g. "USD 19.99".
+func (m Money) String() string {
+	return m.Currency + " " + m.Decimal()
+}
+
+// Value stores Money in a single column as "USD 19.99".
+func (m Money) Value() (driver.Value, error) {
+	if m.Currency == "" && m.Amount == 0 {
+		return nil, nil
+	}
+	return m.String(), nil
+}
+
+// Scan reads a value written by Value.
+func (m *Money) Scan(value any) error {
+	var s string
+	switch v := value.(type) {
+	case nil:
+		*m = Money{}
+		return nil
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return fmt.Errorf("cartridge: cannot scan %T into Money", value)
+	}
+
+	currency, amount, ok := strings.Cut(strings.TrimSpace(s), " ")
+	if !ok {
+		return fmt.Errorf("cartridge: invalid money value %q", s)
+	}
+	parsed, err := ParseMoney(amount, currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// UnmarshalJSON accepts {"amount": 1999, "currency": "USD"} or "USD 19.99".
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		return m.Scan(s)
+	}
+
+	type plain Money
+	var p plain
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	*m = NewMoney(p.Amount, p.Currency)
+	return nil
+}
+
+// moneyTemplateFuncs returns the "money" template helper.
+//
+//	{{money .Product.Price}}  <!-- $19.99 -->
+func moneyTemplateFuncs() map[string]any {
+	return map[string]any{
+		"money": func(m Money) string { return m.Format() },
+	}
+}
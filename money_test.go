@@ -0,0 +1,117 @@
+package cartridge
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		input    string
+		currency string
+		want     int64
+		wantErr  bool
+	}{
+		{"19.99", "USD", 1999, false},
+		{"19.9", "usd", 1990, false},
+		{"-5", "EUR", -500, false},
+		{"1500", "JPY", 1500, false},
+		{"1.234", "KWD", 1234, false},
+		{"0.10", "USD", 10, false},
+		{"1.999", "USD", 0, true},
+		{"1.5", "JPY", 0, true},
+		{"abc", "USD", 0, true},
+		{"1", "US", 0, true},
+	}
+	for _, tt := range tests {
+		m, err := ParseMoney(tt.input, tt.currency)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseMoney(%q, %q): expected error", tt.input, tt.currency)
+			}
+			continue
+		}
+		if err != nil || m.Amount != tt.want {
+			t.Errorf("ParseMoney(%q, %q) = %d, %v; want %d", tt.input, tt.currency, m.Amount, err, tt.want)
+		}
+	}
+}
+
+func TestMoney_Format(t *testing.T) {
+	tests := map[Money]string{
+		NewMoney(123450, "USD"): "$1,234.50",
+		NewMoney(-500, "EUR"):   "-€5.00",
+		NewMoney(5, "USD"):      "$0.05",
+		NewMoney(1500, "JPY"):   "¥1,500",
+		NewMoney(1000, "SEK"):   "10.00 SEK",
+	}
+	for m, want := range tests {
+		if got := m.Format(); got != want {
+			t.Errorf("%v.Format() = %q, want %q", m, got, want)
+		}
+	}
+}
+
+func TestMoney_Arithmetic(t *testing.T) {
+	a, b := NewMoney(1000, "USD"), NewMoney(250, "USD")
+	sum, err := a.Add(b)
+	if err != nil || sum.Amount != 1250 {
+		t.Errorf("Add = %v, %v", sum, err)
+	}
+	if _, err := a.Add(NewMoney(1, "EUR")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("expected ErrCurrencyMismatch, got %v", err)
+	}
+
+	parts := NewMoney(1000, "USD").Split(3)
+	if parts[0].Amount != 334 || parts[1].Amount != 333 || parts[2].Amount != 333 {
+		t.Errorf("unexpected split %v", parts)
+	}
+}
+
+func TestMoney_JSONAndScan(t *testing.T) {
+	data, _ := json.Marshal(NewMoney(1999, "USD"))
+	if string(data) != `{"amount":1999,"currency":"USD"}` {
+		t.Errorf("unexpected JSON %s", data)
+	}
+
+	var m Money
+	if err := json.Unmarshal([]byte(`"EUR 12.50"`), &m); err != nil || m != NewMoney(1250, "EUR") {
+		t.Errorf("unmarshal string = %v, %v", m, err)
+	}
+
+	v, _ := NewMoney(1999, "USD").Value()
+	var scanned Money
+	if err := scanned.Scan(v); err != nil || scanned != NewMoney(1999, "USD") {
+		t.Errorf("Scan(%v) = %v, %v", v, scanned, err)
+	}
+}
+
+func TestValidate_Money(t *testing.T) {
+	type order struct {
+		Total Money `json:"total" validate:"required,min=0.50,currency=USD"`
+	}
+
+	if err := Validate(order{Total: NewMoney(100, "USD")}); err != nil {
+		t.Errorf("expected valid, got %v", err)
+	}
+
+	err := Validate(order{Total: NewMoney(10, "USD")})
+	if fields := asValidationErrors(t, err).Fields(); fields["total"] != "total must be at least 0.50" {
+		t.Errorf("unexpected errors %v", fields)
+	}
+
+	err = Validate(order{Total: NewMoney(100, "EUR")})
+	if fields := asValidationErrors(t, err).Fields(); fields["total"] != "total must be in USD" {
+		t.Errorf("unexpected errors %v", fields)
+	}
+}
+
+func asValidationErrors(t *testing.T, err error) ValidationErrors {
+	t.Helper()
+	var verrs ValidationErrors
+	if !errors.As(err, &verrs) {
+		t.Fatalf("expected ValidationErrors, got %v", err)
+	}
+	return verrs
+}
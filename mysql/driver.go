@@ -0,0 +1,83 @@
+package mysql
+
+import (
+	"log/slog"
+	"net/url"
+	"strings"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+
+	"github.com/karloscodes/cartridge/database"
+)
+
+// Driver implements database.Driver for MySQL and MariaDB.
+type Driver struct{}
+
+// NewDriver creates a new MySQL driver.
+func NewDriver() *Driver {
+	return &Driver{}
+}
+
+// Name returns "mysql".
+func (d *Driver) Name() string {
+	return "mysql"
+}
+
+// Open returns a GORM MySQL dialector.
+func (d *Driver) Open(dsn string) gorm.Dialector {
+	return mysql.Open(dsn)
+}
+
+// ConfigureDSN adds MySQL-specific options to the DSN unless already present.
+// parseTime is always enabled so DATETIME columns scan into time.Time.
+func (d *Driver) ConfigureDSN(dsn string, cfg *database.Config) string {
+	params := map[string]string{"parseTime": "true"}
+	if cfg.MySQL.Charset != "" {
+		params["charset"] = cfg.MySQL.Charset
+	}
+	if cfg.MySQL.Loc != "" {
+		params["loc"] = url.QueryEscape(cfg.MySQL.Loc)
+	}
+
+	var extra []string
+	for _, key := range []string{"parseTime", "charset", "loc"} {
+		value, ok := params[key]
+		if !ok || strings.Contains(dsn, key+"=") {
+			continue
+		}
+		extra = append(extra, key+"="+value)
+	}
+	if len(extra) == 0 {
+		return dsn
+	}
+
+	separator := "?"
+	if strings.Contains(dsn, "?") {
+		separator = "&"
+	}
+	return dsn + separator + strings.Join(extra, "&")
+}
+
+// AfterConnect is a no-op for MySQL.
+func (d *Driver) AfterConnect(db *gorm.DB, cfg *database.Config, logger *slog.Logger) error {
+	return nil
+}
+
+// Close is a no-op for MySQL.
+func (d *Driver) Close(db *gorm.DB, logger *slog.Logger) error {
+	return nil
+}
+
+// SupportsCheckpoint returns false for MySQL.
+func (d *Driver) SupportsCheckpoint() bool {
+	return false
+}
+
+// Checkpoint is a no-op for MySQL.
+func (d *Driver) Checkpoint(db *gorm.DB, mode string) error {
+	return nil
+}
+
+// Ensure Driver implements database.Driver
+var _ database.Driver = (*Driver)(nil)
@@ -0,0 +1,25 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/karloscodes/cartridge/database"
+)
+
+func TestDriver_ConfigureDSN(t *testing.T) {
+	d := NewDriver()
+	cfg := database.DefaultConfig("")
+
+	got := d.ConfigureDSN("user:pass@tcp(localhost:3306)/app", cfg)
+	want := "user:pass@tcp(localhost:3306)/app?parseTime=true&charset=utf8mb4&loc=UTC"
+	if got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+
+	// Existing params are preserved and not duplicated
+	got = d.ConfigureDSN("user@tcp(db)/app?charset=latin1", cfg)
+	want = "user@tcp(db)/app?charset=latin1&parseTime=true&loc=UTC"
+	if got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
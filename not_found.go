@@ -0,0 +1,80 @@
+package cartridge
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// NotFound sets the handler for requests no route matches, replacing the
+// error handler's 404 and SetCatchAllRedirect. The response status starts
+// at 404. Use ctx.Negotiate to answer API clients with JSON and browsers
+// with a page:
+//
+//	srv.NotFound(func(ctx *cartridge.Context) error {
+//	    return ctx.Negotiate(fiber.StatusNotFound, "errors/not_found", fiber.Map{"path": ctx.Path()})
+//	})
+//
+// Missing static assets go to ServerConfig.StaticNotFound instead.
+func (s *Server) NotFound(handler HandlerFunc) {
+	s.notFound = s.wrapHandler(handler)
+}
+
+// MethodNotAllowed sets the handler for requests whose path is registered
+// for other methods only. The response status starts at 405 and the Allow
+// header lists the registered methods (with EnableMethodNotAllowed).
+func (s *Server) MethodNotAllowed(handler HandlerFunc) {
+	s.notAllowed = s.wrapHandler(handler)
+}
+
+// NotFound sets the handler for requests no route matches (see
+// Server.NotFound).
+func (a *App) NotFound(handler HandlerFunc) {
+	a.Server.NotFound(handler)
+}
+
+// MethodNotAllowed sets the handler for requests with a method the path
+// isn't registered for (see Server.MethodNotAllowed).
+func (a *App) MethodNotAllowed(handler HandlerFunc) {
+	a.Server.MethodNotAllowed(handler)
+}
+
+// fallbackMiddleware hands requests that matched no route to the NotFound
+// handler, and 405s to the MethodNotAllowed handler.
+func (s *Server) fallbackMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		err := c.Next()
+		switch {
+		case err == nil:
+			return nil
+		case s.notFound != nil && unmatchedRoute(c, err):
+			c.Status(fiber.StatusNotFound)
+			return s.notFound(c)
+		case s.notAllowed != nil && errors.Is(err, fiber.ErrMethodNotAllowed):
+			c.Status(fiber.StatusMethodNotAllowed)
+			return s.notAllowed(c)
+		}
+		return err
+	}
+}
+
+// unmatchedRoute reports whether err is Fiber's 404 for a request that ran
+// out of routes, as opposed to a 404 returned by a handler.
+func unmatchedRoute(c *fiber.Ctx, err error) bool {
+	var fiberErr *fiber.Error
+	return errors.As(err, &fiberErr) && fiberErr.Code == fiber.StatusNotFound &&
+		strings.HasPrefix(fiberErr.Message, "Cannot "+c.Method()+" ")
+}
+
+// Negotiate writes data as JSON for clients that accept JSON, and renders
+// template with data for browsers, which prefer HTML.
+//
+//	return ctx.Negotiate(fiber.StatusOK, "products/show", product)
+func (ctx *Context) Negotiate(status int, template string, data any) error {
+	ctx.Vary(fiber.HeaderAccept)
+	if wantsJSON(ctx.Ctx) {
+		return ctx.Status(status).JSON(data)
+	}
+	return ctx.Status(status).Render(template, data)
+}
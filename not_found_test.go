@@ -0,0 +1,61 @@
+package cartridge
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/template/html/v2"
+)
+
+func TestServer_NotFoundAndMethodNotAllowed(t *testing.T) {
+	views := html.NewFileSystem(http.FS(fstest.MapFS{
+		"errors/not_found.html": {Data: []byte(`<h1>No page at {{ .path }}</h1>`)},
+	}), ".html")
+	srv := newTemplateTestServer(t, views)
+	srv.Get("/products/:id", func(ctx *Context) error {
+		return ErrNotFound("product")
+	})
+	srv.NotFound(func(ctx *Context) error {
+		return ctx.Negotiate(fiber.StatusNotFound, "errors/not_found", fiber.Map{"path": ctx.Path()})
+	})
+	srv.MethodNotAllowed(func(ctx *Context) error {
+		return ctx.JSON(fiber.Map{"allow": string(ctx.Response().Header.Peek(fiber.HeaderAllow))})
+	})
+
+	do := func(method, path, accept string) (int, string, string) {
+		req := httpGet(path)
+		req.Method = method
+		req.Header.Set("Accept", accept)
+		resp, err := srv.App().Test(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, resp.Header.Get("Content-Type"), string(body)
+	}
+	browser := "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+
+	status, contentType, body := do("GET", "/missing", browser)
+	if status != fiber.StatusNotFound || !strings.HasPrefix(contentType, "text/html") || body != "<h1>No page at /missing</h1>" {
+		t.Errorf("expected the not-found page, got %d %s %q", status, contentType, body)
+	}
+	status, _, body = do("GET", "/missing", "application/json")
+	if status != fiber.StatusNotFound || body != `{"path":"/missing"}` {
+		t.Errorf("expected JSON for API clients, got %d %q", status, body)
+	}
+
+	// A handler's own 404 still goes to the error handler
+	status, contentType, body = do("GET", "/products/1", browser)
+	if status != fiber.StatusNotFound || !strings.HasPrefix(contentType, "text/html") || !strings.Contains(body, "product not found") {
+		t.Errorf("expected the error page for a missing product, got %d %s %q", status, contentType, body)
+	}
+
+	status, _, body = do("DELETE", "/products/1", "application/json")
+	if status != fiber.StatusMethodNotAllowed || body != `{"allow":"GET, HEAD, OPTIONS"}` {
+		t.Errorf("expected the custom 405, got %d %q", status, body)
+	}
+}
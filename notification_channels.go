@@ -0,0 +1,348 @@
+package cartridge
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/karloscodes/cartridge/safehttp"
+)
+
+// MailMessage is an email rendered from a notification. At least one of
+// Text and HTML is required; with both, mail clients pick one.
+type MailMessage struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// MailNotification is a Notification that can be sent by email.
+type MailNotification interface {
+	Notification
+	ToMail(to Notifiable) MailMessage
+}
+
+// Mailer sends email, e.g. the SMTP mailer from NewSMTPMailer or a provider's API.
+type Mailer interface {
+	SendMail(ctx context.Context, to string, msg MailMessage) error
+}
+
+// SMTPConfig configures NewSMTPMailer.
+type SMTPConfig struct {
+	// Host is the SMTP server. Required.
+	Host string
+
+	// Port is the SMTP port. STARTTLS is used when the server offers it.
+	// Default: 587
+	Port int
+
+	// Username and Password authenticate with PLAIN auth, which net/smtp
+	// only sends over TLS or to localhost. Optional.
+	Username string
+	Password string
+
+	// From is the sender address, e.g. "Shop <noreply@shop.example>". Required.
+	From string
+}
+
+// smtpMailer sends mail with net/smtp.
+type smtpMailer struct {
+	cfg  SMTPConfig
+	from *mail.Address
+	send func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewSMTPMailer creates a Mailer that sends through an SMTP server.
+func NewSMTPMailer(cfg SMTPConfig) (Mailer, error) {
+	if cfg.Host == "" {
+		return nil, fmt.Errorf("cartridge: SMTP host is required")
+	}
+	if cfg.Port == 0 {
+		cfg.Port = 587
+	}
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("cartridge: SMTP from address: %w", err)
+	}
+	return &smtpMailer{cfg: cfg, from: from, send: smtp.SendMail}, nil
+}
+
+// SendMail implements Mailer. net/smtp has no context support, so ctx is
+// only checked before sending.
+func (m *smtpMailer) SendMail(ctx context.Context, to string, msg MailMessage) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	rcpt, err := mail.ParseAddress(to)
+	if err != nil {
+		return fmt.Errorf("recipient: %w", err)
+	}
+	body, err := buildMail(m.from, rcpt, msg)
+	if err != nil {
+		return err
+	}
+	var auth smtp.Auth
+	if m.cfg.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+	}
+	addr := net.JoinHostPort(m.cfg.Host, strconv.Itoa(m.cfg.Port))
+	return m.send(addr, auth, m.from.Address, []string{rcpt.Address}, body)
+}
+
+// buildMail renders msg as a MIME message, multipart/alternative when it
+// has both a text and an HTML body.
+func buildMail(from, to *mail.Address, msg MailMessage) ([]byte, error) {
+	if msg.Text == "" && msg.HTML == "" {
+		return nil, fmt.Errorf("mail %q has no body", msg.Subject)
+	}
+	id := make([]byte, 16)
+	rand.Read(id)
+
+	var b bytes.Buffer
+	header := func(key, value string) { fmt.Fprintf(&b, "%s: %s\r\n", key, value) }
+	header("From", from.String())
+	header("To", to.String())
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", "<"+hex.EncodeToString(id)+"@"+from.Address[strings.LastIndex(from.Address, "@")+1:]+">")
+	header("MIME-Version", "1.0")
+
+	part := func(w io.Writer, content string) error {
+		qp := quotedprintable.NewWriter(w)
+		if _, err := io.WriteString(qp, content); err != nil {
+			return err
+		}
+		return qp.Close()
+	}
+	if msg.Text == "" || msg.HTML == "" {
+		contentType, content := "text/plain; charset=utf-8", msg.Text
+		if msg.HTML != "" {
+			contentType, content = "text/html; charset=utf-8", msg.HTML
+		}
+		header("Content-Type", contentType)
+		header("Content-Transfer-Encoding", "quoted-printable")
+		b.WriteString("\r\n")
+		if err := part(&b, content); err != nil {
+			return nil, err
+		}
+		return b.Bytes(), nil
+	}
+
+	mw := multipart.NewWriter(&b)
+	header("Content-Type", "multipart/alternative; boundary="+mw.Boundary())
+	b.WriteString("\r\n")
+	for _, p := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := part(w, p.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// mailChannel delivers MailNotifications with a Mailer.
+type mailChannel struct {
+	mailer Mailer
+}
+
+// NewMailChannel creates the "mail" channel. Recipients' NotificationRoute("mail")
+// is their email address.
+func NewMailChannel(mailer Mailer) NotificationChannel {
+	return &mailChannel{mailer: mailer}
+}
+
+// Send implements NotificationChannel.
+func (c *mailChannel) Send(ctx context.Context, to Notifiable, n Notification) error {
+	mn, ok := n.(MailNotification)
+	if !ok {
+		return ErrNotificationUnsupported
+	}
+	address := to.NotificationRoute(MailChannel)
+	if address == "" {
+		return fmt.Errorf("recipient has no email address")
+	}
+	return c.mailer.SendMail(ctx, address, mn.ToMail(to))
+}
+
+// SlackMessage is a Slack incoming webhook message.
+type SlackMessage struct {
+	Text   string `json:"text"`
+	Blocks []any  `json:"blocks,omitempty"`
+}
+
+// SlackNotification is a Notification that can be posted to Slack.
+type SlackNotification interface {
+	Notification
+	ToSlack(to Notifiable) SlackMessage
+}
+
+// SlackChannelConfig configures NewSlackChannel.
+type SlackChannelConfig struct {
+	// WebhookURL is the incoming webhook messages are posted to when the
+	// recipient's NotificationRoute("slack") is empty. Optional.
+	WebhookURL string
+
+	// Client posts the messages. Default: a safehttp client, since
+	// recipients may supply their own webhook URLs
+	Client *http.Client
+}
+
+// slackChannel posts SlackNotifications to incoming webhooks.
+type slackChannel struct {
+	cfg SlackChannelConfig
+}
+
+// NewSlackChannel creates the "slack" channel.
+func NewSlackChannel(cfg SlackChannelConfig) NotificationChannel {
+	if cfg.Client == nil {
+		cfg.Client = safehttp.New(safehttp.Config{}).HTTPClient()
+	}
+	return &slackChannel{cfg: cfg}
+}
+
+// Send implements NotificationChannel.
+func (c *slackChannel) Send(ctx context.Context, to Notifiable, n Notification) error {
+	sn, ok := n.(SlackNotification)
+	if !ok {
+		return ErrNotificationUnsupported
+	}
+	target := to.NotificationRoute(SlackChannel)
+	if target == "" {
+		target = c.cfg.WebhookURL
+	}
+	if target == "" {
+		return fmt.Errorf("no Slack webhook URL")
+	}
+	body, err := json.Marshal(sn.ToSlack(to))
+	if err != nil {
+		return err
+	}
+	return postNotification(ctx, c.cfg.Client, target, body, nil)
+}
+
+// WebhookNotification is a Notification that can be posted as JSON.
+type WebhookNotification interface {
+	Notification
+	// ToWebhook returns the payload's "data", marshaled with encoding/json.
+	ToWebhook(to Notifiable) any
+}
+
+// WebhookChannelConfig configures NewWebhookChannel.
+type WebhookChannelConfig struct {
+	// URL receives notifications when the recipient's
+	// NotificationRoute("webhook") is empty. Optional.
+	URL string
+
+	// Secret signs each body with HMAC-SHA256, sent as
+	// "X-Cartridge-Signature: sha256=<hex>". Optional.
+	Secret string
+
+	// Client posts the payloads. Default: a safehttp client, since
+	// recipients may supply their own webhook URLs
+	Client *http.Client
+}
+
+// WebhookPayload is the body the webhook channel posts.
+type WebhookPayload struct {
+	Type   string    `json:"type"`
+	SentAt time.Time `json:"sent_at"`
+	Data   any       `json:"data"`
+}
+
+// webhookChannel posts WebhookNotifications as JSON.
+type webhookChannel struct {
+	cfg WebhookChannelConfig
+}
+
+// NewWebhookChannel creates the "webhook" channel, which posts a
+// WebhookPayload to the recipient's URL.
+func NewWebhookChannel(cfg WebhookChannelConfig) NotificationChannel {
+	if cfg.Client == nil {
+		cfg.Client = safehttp.New(safehttp.Config{}).HTTPClient()
+	}
+	return &webhookChannel{cfg: cfg}
+}
+
+// Send implements NotificationChannel.
+func (c *webhookChannel) Send(ctx context.Context, to Notifiable, n Notification) error {
+	wn, ok := n.(WebhookNotification)
+	if !ok {
+		return ErrNotificationUnsupported
+	}
+	target := to.NotificationRoute(WebhookChannel)
+	if target == "" {
+		target = c.cfg.URL
+	}
+	if target == "" {
+		return fmt.Errorf("no webhook URL")
+	}
+	body, err := json.Marshal(WebhookPayload{Type: n.NotificationType(), SentAt: time.Now().UTC(), Data: wn.ToWebhook(to)})
+	if err != nil {
+		return err
+	}
+	var headers map[string]string
+	if c.cfg.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(c.cfg.Secret))
+		mac.Write(body)
+		headers = map[string]string{"X-Cartridge-Signature": "sha256=" + hex.EncodeToString(mac.Sum(nil))}
+	}
+	return postNotification(ctx, c.cfg.Client, target, body, headers)
+}
+
+// postNotification posts a JSON body and expects a 2xx answer. Errors name
+// the host only, as webhook URLs carry secrets.
+func postNotification(ctx context.Context, client *http.Client, target string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return errors.New("invalid webhook URL")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return fmt.Errorf("POST %s: %w", req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("POST %s: %s", req.URL.Host, resp.Status)
+	}
+	return nil
+}
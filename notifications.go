@@ -0,0 +1,149 @@
+package cartridge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+)
+
+// Built-in notification channel names.
+const (
+	MailChannel    = "mail"
+	SlackChannel   = "slack"
+	WebhookChannel = "webhook"
+)
+
+// ErrNotificationUnsupported is returned by a NotificationChannel for a
+// notification it can't render, e.g. the mail channel for one without ToMail.
+var ErrNotificationUnsupported = errors.New("cartridge: notification not supported by channel")
+
+// Notifiable is a notification recipient, typically a user model.
+type Notifiable interface {
+	// NotificationRoute returns the recipient's address on a channel: the
+	// email address for "mail", a webhook URL for "webhook" or "slack".
+	// Return "" for the channel's default destination, if it has one.
+	NotificationRoute(channel string) string
+}
+
+// Notification is a message for a Notifiable. Channels render it through
+// the interface they need: MailNotification, SlackNotification or
+// WebhookNotification.
+//
+//	type PasswordResetNotification struct{ URL string }
+//
+//	func (n PasswordResetNotification) NotificationType() string { return "password_reset" }
+//
+//	func (n PasswordResetNotification) ToMail(to cartridge.Notifiable) cartridge.MailMessage {
+//	    return cartridge.MailMessage{Subject: "Reset your password", Text: "Reset it at " + n.URL}
+//	}
+type Notification interface {
+	// NotificationType names the kind of notification, e.g.
+	// "password_reset", for NotifierConfig.Via, logs and webhook payloads.
+	NotificationType() string
+}
+
+// NotificationChannel delivers notifications, e.g. by email.
+type NotificationChannel interface {
+	// Send delivers n to to. It returns ErrNotificationUnsupported when n
+	// has nothing to send on this channel.
+	Send(ctx context.Context, to Notifiable, n Notification) error
+}
+
+// NotifierConfig configures a Notifier. Which channels a notification goes
+// out on is configuration, so handlers don't change when it does.
+type NotifierConfig struct {
+	// Channels are the delivery channels by name, e.g.
+	// {"mail": NewMailChannel(mailer), "slack": NewSlackChannel(...)}. Required.
+	Channels map[string]NotificationChannel
+
+	// Via lists the channels per notification type, e.g.
+	// {"password_reset": {"mail"}}. Listed channels must support the
+	// notification. Optional.
+	Via map[string][]string
+
+	// Default lists the channels for types not in Via. Default: every
+	// channel the notification supports
+	Default []string
+
+	// Logger records deliveries. Default: slog.Default()
+	Logger Logger
+}
+
+// Notifier sends notifications through the configured channels.
+type Notifier struct {
+	cfg NotifierConfig
+}
+
+// NewNotifier creates a Notifier. It returns an error if Via or Default
+// names a channel that isn't configured.
+func NewNotifier(cfg NotifierConfig) (*Notifier, error) {
+	if len(cfg.Channels) == 0 {
+		return nil, fmt.Errorf("cartridge: notifier needs at least one channel")
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+	check := func(names []string) error {
+		for _, name := range names {
+			if cfg.Channels[name] == nil {
+				return fmt.Errorf("cartridge: notification channel %q is not configured", name)
+			}
+		}
+		return nil
+	}
+	if err := check(cfg.Default); err != nil {
+		return nil, err
+	}
+	for _, names := range cfg.Via {
+		if err := check(names); err != nil {
+			return nil, err
+		}
+	}
+	return &Notifier{cfg: cfg}, nil
+}
+
+// Send delivers n to to on its channels, one after another. Every channel
+// is tried; the errors of those that failed are joined.
+func (nf *Notifier) Send(ctx context.Context, to Notifiable, n Notification) error {
+	names, explicit := nf.cfg.Via[n.NotificationType()]
+	if !explicit && len(nf.cfg.Default) > 0 {
+		names, explicit = nf.cfg.Default, true
+	}
+	if !explicit {
+		names = slices.Sorted(maps.Keys(nf.cfg.Channels))
+	}
+
+	var errs []error
+	sent := 0
+	for _, name := range names {
+		err := nf.cfg.Channels[name].Send(ctx, to, n)
+		switch {
+		case err == nil:
+			sent++
+			nf.cfg.Logger.Debug("notification sent", "type", n.NotificationType(), "channel", name)
+		case errors.Is(err, ErrNotificationUnsupported) && !explicit:
+			// Not every notification goes to every channel
+		default:
+			errs = append(errs, fmt.Errorf("cartridge: notify %s via %s: %w", n.NotificationType(), name, err))
+		}
+	}
+	if sent == 0 && len(errs) == 0 {
+		return fmt.Errorf("cartridge: no channel can send notification %s", n.NotificationType())
+	}
+	return errors.Join(errs...)
+}
+
+// Notify sends n to to through the app's Notifier, within the request:
+//
+//	return ctx.Notify(user, PasswordResetNotification{URL: link})
+//
+// Use ctx.Go to send without making the response wait.
+func (ctx *Context) Notify(to Notifiable, n Notification) error {
+	if ctx.notifier == nil {
+		return fmt.Errorf("cartridge: notifications are not enabled (use WithNotifications)")
+	}
+	return ctx.notifier.Send(ctx.UserContext(), to, n)
+}
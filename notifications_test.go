@@ -0,0 +1,277 @@
+package cartridge
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type testRecipient struct {
+	email, webhook string
+}
+
+func (r testRecipient) NotificationRoute(channel string) string {
+	switch channel {
+	case MailChannel:
+		return r.email
+	case WebhookChannel:
+		return r.webhook
+	}
+	return ""
+}
+
+type testPasswordReset struct{ URL string }
+
+func (n testPasswordReset) NotificationType() string { return "password_reset" }
+
+func (n testPasswordReset) ToMail(to Notifiable) MailMessage {
+	return MailMessage{Subject: "Reset your password", Text: "Reset it at " + n.URL, HTML: `<a href="` + n.URL + `">Reset</a>`}
+}
+
+type testOrderShipped struct{ ID int }
+
+func (n testOrderShipped) NotificationType() string { return "order_shipped" }
+
+func (n testOrderShipped) ToSlack(to Notifiable) SlackMessage {
+	return SlackMessage{Text: "Order shipped"}
+}
+
+func (n testOrderShipped) ToWebhook(to Notifiable) any { return map[string]int{"order_id": n.ID} }
+
+// testOrderShippedMail goes out by mail too
+type testOrderShippedMail struct{ testOrderShipped }
+
+func (n testOrderShippedMail) ToMail(to Notifiable) MailMessage {
+	return MailMessage{Subject: "Order shipped", Text: "On its way"}
+}
+
+type recordingMailer struct {
+	sent []string
+	err  error
+}
+
+func (m *recordingMailer) SendMail(ctx context.Context, to string, msg MailMessage) error {
+	m.sent = append(m.sent, to+": "+msg.Subject)
+	return m.err
+}
+
+func TestNotifier(t *testing.T) {
+	var posts []string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		posts = append(posts, r.URL.Path+" "+string(body))
+	}))
+	defer receiver.Close()
+
+	mailer := &recordingMailer{}
+	channels := map[string]NotificationChannel{
+		MailChannel:    NewMailChannel(mailer),
+		SlackChannel:   NewSlackChannel(SlackChannelConfig{WebhookURL: receiver.URL + "/slack", Client: receiver.Client()}),
+		WebhookChannel: NewWebhookChannel(WebhookChannelConfig{Client: receiver.Client()}),
+	}
+	user := testRecipient{email: "ada@example.com", webhook: receiver.URL + "/hooks/ada"}
+
+	t.Run("every supporting channel by default", func(t *testing.T) {
+		posts, mailer.sent = nil, nil
+		notifier, err := NewNotifier(NotifierConfig{Channels: channels, Logger: testLogger()})
+		if err != nil {
+			t.Fatalf("NewNotifier failed: %v", err)
+		}
+		if err := notifier.Send(context.Background(), user, testPasswordReset{URL: "https://shop.example/reset"}); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+		if err := notifier.Send(context.Background(), user, testOrderShipped{ID: 7}); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+		if len(mailer.sent) != 1 || mailer.sent[0] != "ada@example.com: Reset your password" {
+			t.Errorf("expected one email, got %v", mailer.sent)
+		}
+		if len(posts) != 2 || posts[0] != `/slack {"text":"Order shipped"}` ||
+			!strings.HasPrefix(posts[1], `/hooks/ada {"type":"order_shipped"`) || !strings.Contains(posts[1], `"data":{"order_id":7}`) {
+			t.Errorf("expected the Slack message and the webhook, got %v", posts)
+		}
+	})
+
+	t.Run("via", func(t *testing.T) {
+		posts = nil
+		notifier, _ := NewNotifier(NotifierConfig{Channels: channels, Via: map[string][]string{"order_shipped": {SlackChannel}}})
+		if err := notifier.Send(context.Background(), user, testOrderShipped{ID: 7}); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+		if len(posts) != 1 || !strings.HasPrefix(posts[0], "/slack ") {
+			t.Errorf("expected only the Slack message, got %v", posts)
+		}
+	})
+
+	t.Run("listed channels must support the notification", func(t *testing.T) {
+		notifier, _ := NewNotifier(NotifierConfig{Channels: channels, Default: []string{MailChannel}})
+		if err := notifier.Send(context.Background(), user, testOrderShipped{}); !errors.Is(err, ErrNotificationUnsupported) {
+			t.Errorf("expected ErrNotificationUnsupported, got %v", err)
+		}
+	})
+
+	t.Run("a failing channel doesn't stop the others", func(t *testing.T) {
+		posts, mailer.err = nil, errors.New("smtp down")
+		defer func() { mailer.err = nil }()
+		notifier, _ := NewNotifier(NotifierConfig{Channels: channels})
+		err := notifier.Send(context.Background(), user, testOrderShippedMail{testOrderShipped{ID: 7}})
+		if err == nil || !strings.Contains(err.Error(), "smtp down") {
+			t.Errorf("expected the mail error, got %v", err)
+		}
+		if len(posts) != 2 {
+			t.Errorf("expected Slack and the webhook to deliver, got %v", posts)
+		}
+	})
+
+	t.Run("receiver errors", func(t *testing.T) {
+		down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer down.Close()
+		notifier, _ := NewNotifier(NotifierConfig{Channels: map[string]NotificationChannel{
+			WebhookChannel: NewWebhookChannel(WebhookChannelConfig{URL: down.URL + "/secret-token", Client: down.Client()}),
+		}})
+		err := notifier.Send(context.Background(), testRecipient{}, testOrderShipped{})
+		if err == nil || !strings.Contains(err.Error(), "500") {
+			t.Errorf("expected the 500, got %v", err)
+		}
+		if err != nil && strings.Contains(err.Error(), "secret-token") {
+			t.Errorf("error leaks the webhook URL: %v", err)
+		}
+	})
+
+	t.Run("unknown channel", func(t *testing.T) {
+		if _, err := NewNotifier(NotifierConfig{Channels: channels, Via: map[string][]string{"password_reset": {"sms"}}}); err == nil {
+			t.Error("expected an error for an unconfigured channel")
+		}
+	})
+}
+
+func TestWebhookChannelSignature(t *testing.T) {
+	var body []byte
+	var signature string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		signature = r.Header.Get("X-Cartridge-Signature")
+	}))
+	defer receiver.Close()
+
+	channel := NewWebhookChannel(WebhookChannelConfig{URL: receiver.URL, Secret: "s3cret", Client: receiver.Client()})
+	if err := channel.Send(context.Background(), testRecipient{}, testOrderShipped{ID: 7}); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(body)
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); signature != want {
+		t.Errorf("expected signature %q, got %q", want, signature)
+	}
+}
+
+func TestSMTPMailer(t *testing.T) {
+	if _, err := NewSMTPMailer(SMTPConfig{From: "noreply@shop.example"}); err == nil {
+		t.Error("expected an error without a host")
+	}
+
+	mailer, err := NewSMTPMailer(SMTPConfig{Host: "smtp.example", From: "Shop <noreply@shop.example>"})
+	if err != nil {
+		t.Fatalf("NewSMTPMailer failed: %v", err)
+	}
+	var addr, from string
+	var to []string
+	var msg []byte
+	mailer.(*smtpMailer).send = func(a string, _ smtp.Auth, f string, t []string, m []byte) error {
+		addr, from, to, msg = a, f, t, m
+		return nil
+	}
+
+	err = mailer.SendMail(context.Background(), "Ada <ada@example.com>", MailMessage{
+		Subject: "Réinitialiser",
+		Text:    "Reset it at https://shop.example/reset",
+		HTML:    `<a href="https://shop.example/reset">Reset</a>`,
+	})
+	if err != nil {
+		t.Fatalf("SendMail failed: %v", err)
+	}
+	if addr != "smtp.example:587" || from != "noreply@shop.example" || len(to) != 1 || to[0] != "ada@example.com" {
+		t.Errorf("unexpected envelope: %s %s %v", addr, from, to)
+	}
+	mail := string(msg)
+	for _, want := range []string{
+		"From: \"Shop\" <noreply@shop.example>\r\n",
+		"To: \"Ada\" <ada@example.com>\r\n",
+		"Subject: =?utf-8?q?R=C3=A9initialiser?=\r\n",
+		"Content-Type: multipart/alternative; boundary=",
+		"Content-Type: text/plain; charset=utf-8",
+		"Content-Type: text/html; charset=utf-8",
+		"<a href=3D\"https://shop.example/reset\">Reset</a>",
+	} {
+		if !strings.Contains(mail, want) {
+			t.Errorf("expected mail to contain %q:\n%s", want, mail)
+		}
+	}
+
+	if err := mailer.SendMail(context.Background(), "ada@example.com", MailMessage{Subject: "Empty"}); err == nil {
+		t.Error("expected an error for a mail without a body")
+	}
+}
+
+func TestContextNotify(t *testing.T) {
+	mailer := &recordingMailer{}
+	notifier, err := NewNotifier(NotifierConfig{Channels: map[string]NotificationChannel{MailChannel: NewMailChannel(mailer)}})
+	if err != nil {
+		t.Fatalf("NewNotifier failed: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name       string
+		notifier   *Notifier
+		wantStatus int
+	}{
+		{"enabled", notifier, fiber.StatusNoContent},
+		{"disabled", nil, fiber.StatusInternalServerError},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := DefaultServerConfig()
+			cfg.EnableStaticAssets = false
+			cfg.EnableRequestLogger = false
+			cfg.EnableSecFetchSite = false
+			cfg.Config = &testConfig{}
+			cfg.Logger = testLogger()
+			cfg.DBManager = &testDBManager{}
+			cfg.ErrorHandler = DefaultErrorHandler(testLogger(), false)
+			cfg.Notifier = tc.notifier
+			srv, err := NewServer(cfg)
+			if err != nil {
+				t.Fatalf("failed to create server: %v", err)
+			}
+			srv.Post("/password/reset", func(ctx *Context) error {
+				if err := ctx.Notify(testRecipient{email: "ada@example.com"}, testPasswordReset{URL: "https://shop.example/reset"}); err != nil {
+					return err
+				}
+				return ctx.SendStatus(fiber.StatusNoContent)
+			})
+
+			resp, err := srv.App().Test(httptest.NewRequest("POST", "/password/reset", nil))
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			if resp.StatusCode != tc.wantStatus {
+				t.Errorf("expected %d, got %d", tc.wantStatus, resp.StatusCode)
+			}
+		})
+	}
+	if len(mailer.sent) != 1 {
+		t.Errorf("expected one email, got %v", mailer.sent)
+	}
+}
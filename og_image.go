@@ -0,0 +1,277 @@
+package cartridge
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/sync/singleflight"
+)
+
+// ogSlugPattern limits image slugs to characters safe in cache and storage keys.
+var ogSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,127}$`)
+
+// OGImageRenderer draws an HTML page as a PNG of the given size.
+type OGImageRenderer interface {
+	RenderPNG(ctx context.Context, html []byte, width, height int) ([]byte, error)
+}
+
+// OGImageConfig configures Server.OGImages.
+type OGImageConfig struct {
+	// Path is the route prefix; images are served at "<Path>/:slug.png".
+	// Default: "/og"
+	Path string
+
+	// Template is the view rendered to HTML, with the binding
+	// fiber.Map{"Slug", "Data", "Width", "Height"}. It should be a complete
+	// page sized to Width x Height. Default: "og/card"
+	Template string
+
+	// Data loads what the card shows for slug, e.g. a post's title and
+	// author. A nil result answers 404. The image is re-rendered when the
+	// data changes. Required.
+	Data func(ctx *Context, slug string) (any, error)
+
+	// Renderer draws the page. Default: &ChromeRenderer{}
+	Renderer OGImageRenderer
+
+	// Width and Height of the image. Default: 1200 x 630
+	Width  int
+	Height int
+
+	// Storage keeps rendered images, e.g. on S3, so they survive restarts
+	// and are shared between instances. Default: nil (ctx.Cache() for TTL)
+	Storage UploadStorage
+
+	// TTL is how long images are cached, and the Cache-Control max-age
+	// sent to clients and CDNs. Default: 24h
+	TTL time.Duration
+}
+
+// OGImages serves Open Graph preview images rendered from a template at
+// /og/:slug.png, so each page gets a social card without designing images
+// by hand. Point the page's metadata at it:
+//
+//	s.OGImages(cartridge.OGImageConfig{
+//	    Data: func(ctx *cartridge.Context, slug string) (any, error) {
+//	        return posts.FindBySlug(ctx.DB(), slug) // nil when missing
+//	    },
+//	})
+//	ctx.SetMeta(cartridge.PageMetadata{Image: "/og/" + post.Slug + ".png"})
+//
+// Images are keyed by slug and a hash of the data and template, and
+// rendered once per key; concurrent requests for a missing image share one
+// render. Requires ServerConfig.ViewsEngine.
+func (s *Server) OGImages(cfg OGImageConfig) {
+	if cfg.Data == nil {
+		panic("cartridge: OGImageConfig.Data is required")
+	}
+	if cfg.Path == "" {
+		cfg.Path = "/og"
+	}
+	if cfg.Template == "" {
+		cfg.Template = "og/card"
+	}
+	if cfg.Renderer == nil {
+		cfg.Renderer = &ChromeRenderer{}
+	}
+	if cfg.Width <= 0 {
+		cfg.Width = 1200
+	}
+	if cfg.Height <= 0 {
+		cfg.Height = 630
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = 24 * time.Hour
+	}
+
+	renders := &singleflight.Group{} // shares Storage misses, see ogImage
+	s.Get(strings.TrimRight(cfg.Path, "/")+"/:slug.png", func(ctx *Context) error {
+		slug := ctx.Params("slug")
+		if !ogSlugPattern.MatchString(slug) {
+			return ErrNotFound("image")
+		}
+		data, err := cfg.Data(ctx, slug)
+		if err != nil {
+			return err
+		}
+		if v := reflect.ValueOf(data); !v.IsValid() || (v.Kind() == reflect.Pointer && v.IsNil()) {
+			return ErrNotFound("image")
+		}
+
+		png, err := s.ogImage(ctx, cfg, renders, slug, data)
+		if err != nil {
+			return err
+		}
+		ctx.Set(fiber.HeaderContentType, "image/png")
+		ctx.Set(fiber.HeaderCacheControl, "public, max-age="+strconv.Itoa(int(cfg.TTL.Seconds())))
+		return ctx.Send(png)
+	}, &RouteConfig{Name: "og.image"})
+}
+
+// ogImage returns the cached image for slug and data, rendering it on a miss.
+// Misses on Storage share one render per key through renders; Cache.Remember
+// already does the same for the cache.
+func (s *Server) ogImage(ctx *Context, cfg OGImageConfig, renders *singleflight.Group, slug string, data any) ([]byte, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("cartridge: encode og image data: %w", err)
+	}
+	sum := sha256.Sum256(append([]byte(fmt.Sprintf("%s:%dx%d:", cfg.Template, cfg.Width, cfg.Height)), encoded...))
+	key := "og/" + slug + "-" + hex.EncodeToString(sum[:8]) + ".png"
+
+	render := func() ([]byte, error) {
+		views := s.app.Config().Views
+		if views == nil {
+			return nil, fmt.Errorf("cartridge: og images need a views engine")
+		}
+		var page bytes.Buffer
+		binding := fiber.Map{"Slug": slug, "Data": data, "Width": cfg.Width, "Height": cfg.Height}
+		if err := views.Render(&page, cfg.Template, binding); err != nil {
+			return nil, fmt.Errorf("cartridge: render og template: %w", err)
+		}
+		png, err := cfg.Renderer.RenderPNG(ctx.UserContext(), page.Bytes(), cfg.Width, cfg.Height)
+		if err != nil {
+			return nil, fmt.Errorf("cartridge: render og image: %w", err)
+		}
+		return png, nil
+	}
+
+	if cfg.Storage == nil {
+		var png []byte
+		err := ctx.Cache().Remember(key, cfg.TTL, &png, func() (any, error) { return render() })
+		return png, err
+	}
+
+	png, err, _ := renders.Do(key, func() (any, error) {
+		file, err := cfg.Storage.Open(ctx.UserContext(), key)
+		if err == nil {
+			defer file.Close()
+			return io.ReadAll(file)
+		}
+		if !errors.Is(err, ErrUploadNotFound) {
+			return nil, err
+		}
+		png, err := render()
+		if err != nil {
+			return nil, err
+		}
+		if err := cfg.Storage.Put(ctx.UserContext(), key, bytes.NewReader(png), int64(len(png)), "image/png"); err != nil {
+			return nil, err
+		}
+		return png, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return png.([]byte), nil
+}
+
+// ChromeRenderer screenshots pages with headless Chrome or Chromium.
+type ChromeRenderer struct {
+	// Path is the browser binary. Default: the first of chromium,
+	// chromium-browser, google-chrome and google-chrome-stable on PATH
+	Path string
+
+	// Args are extra command-line flags, e.g. "--no-sandbox" in containers
+	// running as root.
+	Args []string
+
+	// Timeout limits each screenshot. Default: 20s
+	Timeout time.Duration
+
+	// MaxConcurrent caps browsers running at once. Default: 2
+	MaxConcurrent int
+
+	once  sync.Once
+	slots chan struct{}
+}
+
+// RenderPNG implements OGImageRenderer.
+func (r *ChromeRenderer) RenderPNG(ctx context.Context, html []byte, width, height int) ([]byte, error) {
+	binary, err := r.binary()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer func() { <-r.slots }()
+
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = 20 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	dir, err := os.MkdirTemp("", "cartridge-og-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	page := filepath.Join(dir, "page.html")
+	if err := os.WriteFile(page, html, 0o600); err != nil {
+		return nil, err
+	}
+	shot := filepath.Join(dir, "shot.png")
+
+	args := append([]string{
+		"--headless",
+		"--disable-gpu",
+		"--hide-scrollbars",
+		"--user-data-dir=" + filepath.Join(dir, "profile"),
+		fmt.Sprintf("--window-size=%d,%d", width, height),
+		"--screenshot=" + shot,
+	}, r.Args...)
+	out, err := exec.CommandContext(ctx, binary, append(args, "file://"+page)...).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w: %s", filepath.Base(binary), err, bytes.TrimSpace(out))
+	}
+	return os.ReadFile(shot)
+}
+
+// binary returns the browser to run.
+func (r *ChromeRenderer) binary() (string, error) {
+	if r.Path != "" {
+		return r.Path, nil
+	}
+	for _, name := range []string{"chromium", "chromium-browser", "google-chrome", "google-chrome-stable"} {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("cartridge: no Chrome or Chromium found on PATH (set ChromeRenderer.Path)")
+}
+
+// acquire waits for a free browser slot.
+func (r *ChromeRenderer) acquire(ctx context.Context) error {
+	r.once.Do(func() {
+		n := r.MaxConcurrent
+		if n <= 0 {
+			n = 2
+		}
+		r.slots = make(chan struct{}, n)
+	})
+	select {
+	case r.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,143 @@
+package cartridge
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/gofiber/template/html/v2"
+)
+
+type fakeOGRenderer struct {
+	calls atomic.Int32
+	delay time.Duration
+}
+
+func (r *fakeOGRenderer) RenderPNG(ctx context.Context, page []byte, width, height int) ([]byte, error) {
+	r.calls.Add(1)
+	time.Sleep(r.delay)
+	return append([]byte("PNG:"), page...), nil
+}
+
+func TestOGImages(t *testing.T) {
+	views := fstest.MapFS{
+		"og/card.html": {Data: []byte(`<h1>{{ .Data }}</h1>`)},
+	}
+	engine := html.NewFileSystem(http.FS(views), ".html")
+	srv := newTemplateTestServer(t, engine)
+
+	titles := map[string]string{"hello-world": "Hello & welcome"}
+	renderer := &fakeOGRenderer{}
+	srv.OGImages(OGImageConfig{
+		Renderer: renderer,
+		Data: func(ctx *Context, slug string) (any, error) {
+			if title, ok := titles[slug]; ok {
+				return title, nil
+			}
+			return nil, nil
+		},
+	})
+
+	get := func(target string) (*http.Response, string) {
+		t.Helper()
+		resp, err := srv.App().Test(httptest.NewRequest("GET", target, nil))
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		return resp, string(body)
+	}
+
+	resp, body := get("/og/hello-world.png")
+	if resp.StatusCode != 200 || body != "PNG:<h1>Hello &amp; welcome</h1>" {
+		t.Fatalf("unexpected image: %d %q", resp.StatusCode, body)
+	}
+	if resp.Header.Get("Content-Type") != "image/png" || resp.Header.Get("Cache-Control") != "public, max-age=86400" {
+		t.Errorf("unexpected headers: %v", resp.Header)
+	}
+
+	get("/og/hello-world.png")
+	if n := renderer.calls.Load(); n != 1 {
+		t.Errorf("expected one render for unchanged data, got %d", n)
+	}
+	titles["hello-world"] = "Hello again"
+	if _, body := get("/og/hello-world.png"); !strings.Contains(body, "Hello again") {
+		t.Errorf("expected a new image after the data changed, got %q", body)
+	}
+
+	for _, target := range []string{"/og/missing.png", "/og/Bad..Slug.png"} {
+		if resp, _ := get(target); resp.StatusCode != 404 {
+			t.Errorf("%s: expected 404, got %d", target, resp.StatusCode)
+		}
+	}
+}
+
+func TestOGImages_StorageSharesRenders(t *testing.T) {
+	views := fstest.MapFS{
+		"og/card.html": {Data: []byte(`<h1>{{ .Data }}</h1>`)},
+	}
+	srv := newTemplateTestServer(t, html.NewFileSystem(http.FS(views), ".html"))
+
+	storage := NewMemoryStorage()
+	renderer := &fakeOGRenderer{delay: 50 * time.Millisecond}
+	srv.OGImages(OGImageConfig{
+		Renderer: renderer,
+		Storage:  storage,
+		Data: func(ctx *Context, slug string) (any, error) {
+			return "Hello", nil
+		},
+	})
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := srv.App().Test(httptest.NewRequest("GET", "/og/hello.png", nil))
+			if err != nil {
+				t.Errorf("request failed: %v", err)
+				return
+			}
+			if body, _ := io.ReadAll(resp.Body); string(body) != "PNG:<h1>Hello</h1>" {
+				t.Errorf("unexpected image: %d %q", resp.StatusCode, body)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := renderer.calls.Load(); n != 1 {
+		t.Errorf("expected concurrent misses to share one render, got %d", n)
+	}
+}
+
+func TestChromeRenderer(t *testing.T) {
+	// A stand-in browser that writes the window size and page as the screenshot
+	bin := filepath.Join(t.TempDir(), "fake-chrome")
+	script := "#!/bin/sh\n" +
+		"for a in \"$@\"; do case $a in --screenshot=*) out=${a#--screenshot=};; --window-size=*) size=${a#--window-size=};; file://*) page=${a#file://};; esac; done\n" +
+		"printf 'PNG %s ' \"$size\" > \"$out\"; cat \"$page\" >> \"$out\"\n"
+	if err := os.WriteFile(bin, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	r := &ChromeRenderer{Path: bin}
+	png, err := r.RenderPNG(context.Background(), []byte("<h1>Hi</h1>"), 1200, 630)
+	if err != nil || string(png) != "PNG 1200,630 <h1>Hi</h1>" {
+		t.Fatalf("unexpected screenshot %q (%v)", png, err)
+	}
+
+	if err := os.WriteFile(bin, []byte("#!/bin/sh\necho boom >&2\nexit 3\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.RenderPNG(context.Background(), []byte("x"), 1, 1); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("expected the browser's error output, got %v", err)
+	}
+}
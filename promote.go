@@ -0,0 +1,140 @@
+package cartridge
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AsyncStatusPath is where clients poll promoted requests:
+// GET /_async/:id returns the task status and, once completed, its result.
+const AsyncStatusPath = "/_async"
+
+// ErrAsyncNotEnabled is returned by ctx.Promote when the server has no
+// AsyncManager (see WithAsyncRequests).
+var ErrAsyncNotEnabled = errors.New("cartridge: async requests are not enabled (use WithAsyncRequests)")
+
+// asyncAccepted is the 202 body returned for promoted requests.
+type asyncAccepted struct {
+	ID        string          `json:"id"`
+	Status    AsyncTaskStatus `json:"status"`
+	StatusURL string          `json:"status_url"`
+}
+
+// asyncStatus is the body served at AsyncStatusPath/:id.
+type asyncStatus struct {
+	ID         string          `json:"id"`
+	Status     AsyncTaskStatus `json:"status"`
+	Result     json.RawMessage `json:"result,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	StartedAt  *time.Time      `json:"started_at,omitempty"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+}
+
+// Promote moves the rest of the request to a background task and responds
+// 202 Accepted with a Location header pointing at the status URL. fn runs on
+// the async worker pool and must not use ctx, which is recycled once the
+// handler returns; copy what it needs first.
+//
+//	func exportReport(ctx *cartridge.Context) error {
+//	    month := ctx.Query("month")
+//	    return ctx.Promote(func(job *cartridge.JobContext) (any, error) {
+//	        return reports.Build(job, job.DB, month)
+//	    })
+//	}
+func (ctx *Context) Promote(fn AsyncFunc) error {
+	if ctx.async == nil {
+		return ErrAsyncNotEnabled
+	}
+	id, err := ctx.async.RunFunc(ctx.promotedTaskName(), fn, AsyncTraceFrom(ctx.UserContext()))
+	if err != nil {
+		if errors.Is(err, ErrAsyncQueueFull) {
+			return NewError(fiber.StatusServiceUnavailable, "server is busy, try again later").Wrap(err)
+		}
+		return err
+	}
+	return ctx.acceptedAsync(id)
+}
+
+// PromoteAfter runs fn right away and waits up to threshold. If fn finishes in
+// time its result is sent as JSON (or its error returned) as usual; otherwise
+// the request is answered with 202 and fn keeps running as a task. As with
+// Promote, fn must not use ctx.
+func (ctx *Context) PromoteAfter(threshold time.Duration, fn AsyncFunc) error {
+	if ctx.async == nil {
+		return ErrAsyncNotEnabled
+	}
+
+	var result any
+	var fnErr error
+	id, done, err := ctx.async.goFunc(ctx.promotedTaskName(), func(job *JobContext) (any, error) {
+		result, fnErr = fn(job)
+		return result, fnErr
+	}, AsyncTraceFrom(ctx.UserContext()))
+	if err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(threshold)
+	defer timer.Stop()
+	select {
+	case <-done:
+		if task, _ := ctx.async.Get(id); task != nil && task.Status == AsyncFailed && fnErr == nil {
+			fnErr = errors.New(task.Error) // fn panicked
+		}
+		if fnErr != nil {
+			return fnErr
+		}
+		return ctx.JSON(result)
+	case <-timer.C:
+		return ctx.acceptedAsync(id)
+	}
+}
+
+// promotedTaskName labels promoted tasks with their route, e.g. "POST /reports".
+func (ctx *Context) promotedTaskName() string {
+	return ctx.Method() + " " + ctx.Route().Path
+}
+
+// acceptedAsync writes the 202 response for a promoted task.
+func (ctx *Context) acceptedAsync(id string) error {
+	statusURL := AsyncStatusPath + "/" + id
+	ctx.Ctx.Location(statusURL)
+	return ctx.Status(fiber.StatusAccepted).JSON(asyncAccepted{
+		ID:        id,
+		Status:    AsyncPending,
+		StatusURL: statusURL,
+	})
+}
+
+// asyncStatusHandler serves the status of a task at AsyncStatusPath/:id.
+func asyncStatusHandler(m *AsyncManager) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		task, err := m.Get(c.Params("id"))
+		if errors.Is(err, ErrAsyncTaskNotFound) {
+			return ErrNotFound("task")
+		}
+		if err != nil {
+			return err
+		}
+
+		resp := asyncStatus{
+			ID:         task.ID,
+			Status:     task.Status,
+			Error:      task.Error,
+			CreatedAt:  task.CreatedAt,
+			StartedAt:  task.StartedAt,
+			FinishedAt: task.FinishedAt,
+		}
+		if task.Result != "" {
+			resp.Result = json.RawMessage(task.Result)
+		}
+		if task.Status == AsyncPending || task.Status == AsyncRunning {
+			c.Set(fiber.HeaderRetryAfter, "1")
+		}
+		return c.JSON(resp)
+	}
+}
@@ -0,0 +1,136 @@
+package cartridge
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func newPromoteTestServer(t *testing.T) *Server {
+	t.Helper()
+	cfg := DefaultServerConfig()
+	cfg.EnableStaticAssets = false
+	cfg.EnableRequestLogger = false
+	cfg.EnableSecFetchSite = false
+	cfg.Config = &testConfig{}
+	cfg.Logger = testLogger()
+	cfg.DBManager = &testDBManager{}
+	cfg.ErrorHandler = DefaultErrorHandler(testLogger(), false)
+
+	srv, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	m := NewAsyncManager(AsyncConfig{Logger: testLogger()})
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	t.Cleanup(m.Stop)
+	srv.SetAsync(m)
+	return srv
+}
+
+func promoteRequest(t *testing.T, srv *Server, method, path string) (*http.Response, map[string]any) {
+	t.Helper()
+	req, _ := http.NewRequest(method, path, nil)
+	req.Header.Set("Accept", "application/json")
+	resp, err := srv.App().Test(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	var out map[string]any
+	_ = json.Unmarshal(body, &out)
+	return resp, out
+}
+
+func TestPromote(t *testing.T) {
+	srv := newPromoteTestServer(t)
+	srv.Post("/reports", func(ctx *Context) error {
+		month := ctx.Query("month")
+		return ctx.Promote(func(job *JobContext) (any, error) {
+			return map[string]string{"month": month}, nil
+		})
+	})
+
+	resp, accepted := promoteRequest(t, srv, "POST", "/reports?month=2026-09")
+	if resp.StatusCode != fiber.StatusAccepted {
+		t.Fatalf("expected 202, got %d", resp.StatusCode)
+	}
+	statusURL, _ := accepted["status_url"].(string)
+	if statusURL == "" || resp.Header.Get("Location") != statusURL {
+		t.Fatalf("expected status URL in body and Location, got %v / %q", accepted, resp.Header.Get("Location"))
+	}
+
+	waitForAsyncStatus(t, srv.Async(), accepted["id"].(string), AsyncCompleted)
+	resp, status := promoteRequest(t, srv, "GET", statusURL)
+	if resp.StatusCode != fiber.StatusOK || status["status"] != string(AsyncCompleted) {
+		t.Fatalf("unexpected status response %d: %v", resp.StatusCode, status)
+	}
+	result, _ := status["result"].(map[string]any)
+	if result["month"] != "2026-09" {
+		t.Errorf("expected result month, got %v", status["result"])
+	}
+
+	if resp, _ := promoteRequest(t, srv, "GET", AsyncStatusPath+"/unknown"); resp.StatusCode != fiber.StatusNotFound {
+		t.Errorf("expected 404 for unknown task, got %d", resp.StatusCode)
+	}
+}
+
+func TestPromoteAfter(t *testing.T) {
+	srv := newPromoteTestServer(t)
+	release := make(chan struct{})
+	srv.Get("/fast", func(ctx *Context) error {
+		return ctx.PromoteAfter(time.Second, func(job *JobContext) (any, error) {
+			return map[string]int{"count": 3}, nil
+		})
+	})
+	srv.Get("/slow", func(ctx *Context) error {
+		return ctx.PromoteAfter(10*time.Millisecond, func(job *JobContext) (any, error) {
+			<-release
+			return "done", nil
+		})
+	})
+	srv.Get("/failing", func(ctx *Context) error {
+		return ctx.PromoteAfter(time.Second, func(job *JobContext) (any, error) {
+			return nil, ErrNotFound("report")
+		})
+	})
+
+	t.Run("responds inline when fast", func(t *testing.T) {
+		resp, body := promoteRequest(t, srv, "GET", "/fast")
+		if resp.StatusCode != fiber.StatusOK || body["count"] != float64(3) {
+			t.Errorf("unexpected response %d: %v", resp.StatusCode, body)
+		}
+	})
+
+	t.Run("returns errors inline", func(t *testing.T) {
+		if resp, _ := promoteRequest(t, srv, "GET", "/failing"); resp.StatusCode != fiber.StatusNotFound {
+			t.Errorf("expected 404, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("promotes when slow", func(t *testing.T) {
+		resp, body := promoteRequest(t, srv, "GET", "/slow")
+		close(release)
+		if resp.StatusCode != fiber.StatusAccepted {
+			t.Fatalf("expected 202, got %d", resp.StatusCode)
+		}
+		task := waitForAsyncStatus(t, srv.Async(), body["id"].(string), AsyncCompleted)
+		if task.Result != `"done"` {
+			t.Errorf("expected result \"done\", got %s", task.Result)
+		}
+	})
+}
+
+func TestPromote_NotEnabled(t *testing.T) {
+	ctx := &Context{}
+	if err := ctx.Promote(func(job *JobContext) (any, error) { return nil, nil }); err != ErrAsyncNotEnabled {
+		t.Errorf("expected ErrAsyncNotEnabled, got %v", err)
+	}
+}
@@ -0,0 +1,227 @@
+package cartridge
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// PWAConfig configures favicon, web app manifest and service worker serving.
+type PWAConfig struct {
+	// FS contains the icon, manifest and service worker files at its root. Required.
+	FS fs.FS
+
+	// MaxAge is the Cache-Control max-age for icons and the manifest. Default: 30 days.
+	MaxAge time.Duration
+
+	// ServiceWorker is the service worker filename (e.g. "sw.js"). Optional.
+	// It is served with Cache-Control: no-cache so updates are picked up promptly.
+	ServiceWorker string
+
+	// ThemeColor is emitted as <meta name="theme-color"> by LinkTags. Optional.
+	ThemeColor string
+}
+
+// pwaIconFiles are the well-known icon files served when present, in link tag order.
+var pwaIconFiles = []string{
+	"favicon.ico",
+	"favicon.svg",
+	"favicon-32x32.png",
+	"favicon-16x16.png",
+	"apple-touch-icon.png",
+	"apple-touch-icon-precomposed.png",
+}
+
+// pwaManifestFiles are the accepted web app manifest filenames, in priority order.
+var pwaManifestFiles = []string{"site.webmanifest", "manifest.webmanifest", "manifest.json"}
+
+// PWAAssets serves favicons, the web app manifest and service worker from an FS.
+type PWAAssets struct {
+	cfg           PWAConfig
+	files         []string // discovered files, in link tag order
+	manifest      string
+	serviceWorker string
+}
+
+// NewPWAAssets discovers and validates PWA assets in cfg.FS.
+// It returns an error if the manifest is not valid JSON, if the manifest references
+// icons that don't exist, or if the configured service worker is missing.
+func NewPWAAssets(cfg PWAConfig) (*PWAAssets, error) {
+	if cfg.FS == nil {
+		return nil, fmt.Errorf("cartridge: pwa: FS is required")
+	}
+	if cfg.MaxAge <= 0 {
+		cfg.MaxAge = 30 * 24 * time.Hour
+	}
+
+	p := &PWAAssets{cfg: cfg}
+
+	for _, name := range pwaIconFiles {
+		if pwaFileExists(cfg.FS, name) {
+			p.files = append(p.files, name)
+		}
+	}
+
+	for _, name := range pwaManifestFiles {
+		if pwaFileExists(cfg.FS, name) {
+			icons, err := validatePWAManifest(cfg.FS, name)
+			if err != nil {
+				return nil, err
+			}
+			p.manifest = name
+			p.files = append(p.files, name)
+
+			// Serve icons referenced by the manifest (e.g. icons/192.png)
+			for _, icon := range icons {
+				if !slices.Contains(p.files, icon) {
+					p.files = append(p.files, icon)
+				}
+			}
+			break
+		}
+	}
+
+	if cfg.ServiceWorker != "" {
+		name := strings.TrimPrefix(cfg.ServiceWorker, "/")
+		if !pwaFileExists(cfg.FS, name) {
+			return nil, fmt.Errorf("cartridge: pwa: service worker %q not found", name)
+		}
+		p.serviceWorker = name
+		p.files = append(p.files, name)
+	}
+
+	if len(p.files) == 0 {
+		return nil, fmt.Errorf("cartridge: pwa: no favicon, manifest or service worker found (expected one of %s)",
+			strings.Join(append(append([]string{}, pwaIconFiles...), pwaManifestFiles...), ", "))
+	}
+
+	return p, nil
+}
+
+// Files returns the discovered asset filenames.
+func (p *PWAAssets) Files() []string {
+	return append([]string(nil), p.files...)
+}
+
+// Mount registers a GET route at the root for every discovered asset.
+func (p *PWAAssets) Mount(app *fiber.App) {
+	for _, name := range p.files {
+		app.Get("/"+name, p.handler(name))
+	}
+}
+
+// handler serves a single asset with its content type and cache headers.
+func (p *PWAAssets) handler(name string) fiber.Handler {
+	contentType := pwaContentType(name)
+	cacheControl := "public, max-age=" + strconv.Itoa(int(p.cfg.MaxAge.Seconds()))
+	isServiceWorker := name == p.serviceWorker
+	if isServiceWorker {
+		cacheControl = "no-cache"
+	}
+
+	return func(c *fiber.Ctx) error {
+		data, err := fs.ReadFile(p.cfg.FS, name)
+		if err != nil {
+			return fiber.ErrNotFound
+		}
+		c.Set(fiber.HeaderContentType, contentType)
+		c.Set(fiber.HeaderCacheControl, cacheControl)
+		if isServiceWorker {
+			c.Set("Service-Worker-Allowed", "/")
+		}
+		return c.Send(data)
+	}
+}
+
+// LinkTags returns the <link> and <meta> tags for the discovered assets.
+// Register it as a template function to emit the tags in your layout:
+//
+//	<head>{{ pwaTags }}</head>
+func (p *PWAAssets) LinkTags() template.HTML {
+	var b strings.Builder
+	for _, name := range p.files {
+		href := template.HTMLEscapeString("/" + name)
+		switch {
+		case name == "favicon.ico":
+			fmt.Fprintf(&b, `<link rel="icon" href="%s" sizes="any">`+"\n", href)
+		case name == "favicon.svg":
+			fmt.Fprintf(&b, `<link rel="icon" href="%s" type="image/svg+xml">`+"\n", href)
+		case strings.HasPrefix(name, "favicon-"):
+			size := strings.TrimSuffix(strings.TrimPrefix(name, "favicon-"), ".png")
+			fmt.Fprintf(&b, `<link rel="icon" type="image/png" sizes="%s" href="%s">`+"\n", size, href)
+		case name == "apple-touch-icon.png":
+			fmt.Fprintf(&b, `<link rel="apple-touch-icon" href="%s">`+"\n", href)
+		case name == p.manifest:
+			fmt.Fprintf(&b, `<link rel="manifest" href="%s">`+"\n", href)
+		}
+	}
+	if p.cfg.ThemeColor != "" {
+		fmt.Fprintf(&b, `<meta name="theme-color" content="%s">`+"\n", template.HTMLEscapeString(p.cfg.ThemeColor))
+	}
+	return template.HTML(b.String())
+}
+
+// validatePWAManifest checks that the manifest is valid JSON and that any
+// locally referenced icons exist in the FS. It returns the local icon paths.
+func validatePWAManifest(fsys fs.FS, name string) ([]string, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("cartridge: pwa: read %s: %w", name, err)
+	}
+
+	var manifest struct {
+		Icons []struct {
+			Src string `json:"src"`
+		} `json:"icons"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("cartridge: pwa: invalid %s: %w", name, err)
+	}
+
+	var icons []string
+	for _, icon := range manifest.Icons {
+		if icon.Src == "" || strings.Contains(icon.Src, "://") || strings.HasPrefix(icon.Src, "data:") {
+			continue
+		}
+		src := path.Clean(strings.TrimPrefix(icon.Src, "/"))
+		if !pwaFileExists(fsys, src) {
+			return nil, fmt.Errorf("cartridge: pwa: %s references missing icon %q", name, icon.Src)
+		}
+		icons = append(icons, src)
+	}
+	return icons, nil
+}
+
+// pwaFileExists reports whether name is a regular file in fsys.
+func pwaFileExists(fsys fs.FS, name string) bool {
+	info, err := fs.Stat(fsys, name)
+	return err == nil && !info.IsDir()
+}
+
+// pwaContentType returns the MIME type for a PWA asset filename.
+func pwaContentType(name string) string {
+	switch path.Ext(name) {
+	case ".ico":
+		return "image/x-icon"
+	case ".svg":
+		return "image/svg+xml"
+	case ".png":
+		return "image/png"
+	case ".webmanifest":
+		return "application/manifest+json"
+	case ".json":
+		return fiber.MIMEApplicationJSON
+	case ".js":
+		return "text/javascript; charset=utf-8"
+	default:
+		return "application/octet-stream"
+	}
+}
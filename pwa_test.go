@@ -0,0 +1,121 @@
+package cartridge
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestPWAAssets(t *testing.T) {
+	files := fstest.MapFS{
+		"favicon.ico":          &fstest.MapFile{Data: []byte("ico")},
+		"apple-touch-icon.png": &fstest.MapFile{Data: []byte("png")},
+		"icons/192.png":        &fstest.MapFile{Data: []byte("png")},
+		"site.webmanifest":     &fstest.MapFile{Data: []byte(`{"name":"App","icons":[{"src":"/icons/192.png"}]}`)},
+		"sw.js":                &fstest.MapFile{Data: []byte("self.addEventListener('fetch', () => {})")},
+	}
+
+	pwa, err := NewPWAAssets(PWAConfig{FS: files, ServiceWorker: "sw.js", ThemeColor: "#111"})
+	if err != nil {
+		t.Fatalf("NewPWAAssets failed: %v", err)
+	}
+
+	app := fiber.New()
+	pwa.Mount(app)
+
+	t.Run("serves icons with long-lived caching", func(t *testing.T) {
+		resp, err := app.Test(httpGet("/favicon.ico"))
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		if resp.StatusCode != 200 {
+			t.Errorf("expected 200, got %d", resp.StatusCode)
+		}
+		if got := resp.Header.Get("Content-Type"); got != "image/x-icon" {
+			t.Errorf("expected image/x-icon, got %s", got)
+		}
+		if got := resp.Header.Get("Cache-Control"); got != "public, max-age=2592000" {
+			t.Errorf("unexpected Cache-Control: %s", got)
+		}
+	})
+
+	t.Run("serves manifest and referenced icons", func(t *testing.T) {
+		resp, _ := app.Test(httpGet("/site.webmanifest"))
+		if got := resp.Header.Get("Content-Type"); got != "application/manifest+json" {
+			t.Errorf("expected manifest content type, got %s", got)
+		}
+
+		resp, _ = app.Test(httpGet("/icons/192.png"))
+		if resp.StatusCode != 200 {
+			t.Errorf("expected manifest icon to be served, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("serves service worker without caching", func(t *testing.T) {
+		resp, _ := app.Test(httpGet("/sw.js"))
+		if got := resp.Header.Get("Cache-Control"); got != "no-cache" {
+			t.Errorf("expected no-cache, got %s", got)
+		}
+		if got := resp.Header.Get("Service-Worker-Allowed"); got != "/" {
+			t.Errorf("expected Service-Worker-Allowed /, got %s", got)
+		}
+	})
+
+	t.Run("emits link tags", func(t *testing.T) {
+		tags := string(pwa.LinkTags())
+		for _, want := range []string{
+			`<link rel="icon" href="/favicon.ico" sizes="any">`,
+			`<link rel="apple-touch-icon" href="/apple-touch-icon.png">`,
+			`<link rel="manifest" href="/site.webmanifest">`,
+			`<meta name="theme-color" content="#111">`,
+		} {
+			if !strings.Contains(tags, want) {
+				t.Errorf("expected %q in link tags:\n%s", want, tags)
+			}
+		}
+	})
+}
+
+func TestPWAAssets_Validation(t *testing.T) {
+	t.Run("rejects manifest with missing icon", func(t *testing.T) {
+		files := fstest.MapFS{
+			"manifest.json": &fstest.MapFile{Data: []byte(`{"icons":[{"src":"icon-512.png"}]}`)},
+		}
+		if _, err := NewPWAAssets(PWAConfig{FS: files}); err == nil {
+			t.Error("expected error for missing manifest icon")
+		}
+	})
+
+	t.Run("rejects invalid manifest JSON", func(t *testing.T) {
+		files := fstest.MapFS{
+			"site.webmanifest": &fstest.MapFile{Data: []byte(`{`)},
+		}
+		if _, err := NewPWAAssets(PWAConfig{FS: files}); err == nil {
+			t.Error("expected error for invalid manifest")
+		}
+	})
+
+	t.Run("rejects missing service worker", func(t *testing.T) {
+		files := fstest.MapFS{
+			"favicon.ico": &fstest.MapFile{Data: []byte("ico")},
+		}
+		if _, err := NewPWAAssets(PWAConfig{FS: files, ServiceWorker: "sw.js"}); err == nil {
+			t.Error("expected error for missing service worker")
+		}
+	})
+
+	t.Run("rejects empty FS", func(t *testing.T) {
+		if _, err := NewPWAAssets(PWAConfig{FS: fstest.MapFS{}}); err == nil {
+			t.Error("expected error for empty FS")
+		}
+	})
+}
+
+// httpGet builds a GET request for app.Test.
+func httpGet(path string) *http.Request {
+	req, _ := http.NewRequest("GET", path, nil)
+	return req
+}
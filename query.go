@@ -0,0 +1,177 @@
+package cartridge
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxPerPage caps Paginate's page size so a client can't request every row.
+const maxPerPage = 100
+
+// TypedQueryBuilder builds a GORM query for model T and returns typed
+// results, so list endpoints don't declare result slices or repeat the
+// count query:
+//
+//	page, err := cartridge.Query[Product](ctx.ReadDB()).
+//	    Where("vendor_id = ?", vendorID).
+//	    OrderBy("created_at", true).
+//	    Preload("Variants").
+//	    Paginate(ctx.QueryInt("page", 1), 20)
+//
+// Conditions accumulate on the builder; Find, First, Count and Paginate
+// don't consume them, so one builder can run several of them.
+type TypedQueryBuilder[T any] struct {
+	db *gorm.DB
+}
+
+// Query starts a query for model T on db, typically ctx.DB() or ctx.ReadDB().
+func Query[T any](db *gorm.DB) *TypedQueryBuilder[T] {
+	return &TypedQueryBuilder[T]{db: db.Model(new(T))}
+}
+
+// Where adds a condition, as gorm.DB.Where: a SQL fragment with
+// placeholders, a struct or a map.
+func (q *TypedQueryBuilder[T]) Where(query any, args ...any) *TypedQueryBuilder[T] {
+	q.db = q.db.Where(query, args...)
+	return q
+}
+
+// OrderBy sorts by column, descending when desc is true. column is quoted,
+// so it may come from a request once checked against an allow list.
+func (q *TypedQueryBuilder[T]) OrderBy(column string, desc ...bool) *TypedQueryBuilder[T] {
+	q.db = q.db.Order(clause.OrderByColumn{
+		Column: clause.Column{Name: column},
+		Desc:   len(desc) > 0 && desc[0],
+	})
+	return q
+}
+
+// Limit caps the rows Find returns. Paginate sets its own limit.
+func (q *TypedQueryBuilder[T]) Limit(n int) *TypedQueryBuilder[T] {
+	q.db = q.db.Limit(n)
+	return q
+}
+
+// Offset skips the first n rows Find returns. Paginate sets its own offset.
+func (q *TypedQueryBuilder[T]) Offset(n int) *TypedQueryBuilder[T] {
+	q.db = q.db.Offset(n)
+	return q
+}
+
+// Preload loads an association with the results, as gorm.DB.Preload.
+func (q *TypedQueryBuilder[T]) Preload(association string, args ...any) *TypedQueryBuilder[T] {
+	q.db = q.db.Preload(association, args...)
+	return q
+}
+
+// Scopes applies reusable query functions, e.g. a published filter.
+func (q *TypedQueryBuilder[T]) Scopes(scopes ...func(*gorm.DB) *gorm.DB) *TypedQueryBuilder[T] {
+	q.db = q.db.Scopes(scopes...)
+	return q
+}
+
+// Find returns the matching rows. It never returns a nil slice, so empty
+// lists encode as [].
+func (q *TypedQueryBuilder[T]) Find() ([]T, error) {
+	items := []T{}
+	if err := q.session().Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// First returns the first matching row, by primary key unless OrderBy was
+// used. When nothing matches it returns ErrNotFound for the model, which
+// the error handler answers with 404 and which wraps gorm.ErrRecordNotFound.
+func (q *TypedQueryBuilder[T]) First() (*T, error) {
+	item := new(T)
+	err := q.session().First(item).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound(resourceName[T]()).Wrap(err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// Count returns the number of matching rows, ignoring Limit and Offset.
+func (q *TypedQueryBuilder[T]) Count() (int64, error) {
+	db := q.session().Limit(-1).Offset(-1)
+	db.Statement.Preloads = nil
+	var total int64
+	err := db.Count(&total).Error
+	return total, err
+}
+
+// Page is one page of results with the totals a list endpoint returns.
+type Page[T any] struct {
+	Items      []T   `json:"items"`
+	Page       int   `json:"page"`
+	PerPage    int   `json:"per_page"`
+	Total      int64 `json:"total"`
+	TotalPages int   `json:"total_pages"`
+}
+
+// HasNext reports whether a later page exists.
+func (p *Page[T]) HasNext() bool {
+	return p.Page < p.TotalPages
+}
+
+// Paginate returns page (1-based) of the results, perPage rows at a time,
+// with the total count. page below 1 is treated as 1; perPage is clamped to
+// 1..100. Pages past the end have no items.
+func (q *TypedQueryBuilder[T]) Paginate(page, perPage int) (*Page[T], error) {
+	page = max(page, 1)
+	perPage = min(max(perPage, 1), maxPerPage)
+
+	total, err := q.Count()
+	if err != nil {
+		return nil, fmt.Errorf("cartridge: count %s: %w", resourceName[T](), err)
+	}
+	result := &Page[T]{
+		Items:      []T{},
+		Page:       page,
+		PerPage:    perPage,
+		Total:      total,
+		TotalPages: int((total + int64(perPage) - 1) / int64(perPage)),
+	}
+	if int64((page-1)*perPage) >= total {
+		return result, nil
+	}
+	if err := q.session().Limit(perPage).Offset((page - 1) * perPage).Find(&result.Items).Error; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// MapPage converts a page of models to DTOs like Map, keeping the totals.
+func MapPage[S, D any](page *Page[S], mapping ...Mapping[S, D]) *Page[D] {
+	return &Page[D]{
+		Items:      Map(page.Items, mapping...),
+		Page:       page.Page,
+		PerPage:    page.PerPage,
+		Total:      page.Total,
+		TotalPages: page.TotalPages,
+	}
+}
+
+// session returns a copy of the query, so running it leaves the builder's
+// conditions intact for the next call.
+func (q *TypedQueryBuilder[T]) session() *gorm.DB {
+	return q.db.Session(&gorm.Session{})
+}
+
+// resourceName names model T in errors: "product" for Product.
+func resourceName[T any]() string {
+	name := reflect.TypeFor[T]().Name()
+	if name == "" {
+		return "record"
+	}
+	return strings.ToLower(name)
+}
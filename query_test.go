@@ -0,0 +1,118 @@
+package cartridge
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+type queryVendor struct {
+	ID       uint
+	Name     string
+	Products []queryProduct `gorm:"foreignKey:VendorID"`
+}
+
+type queryProduct struct {
+	ID       uint
+	VendorID uint
+	Name     string
+	Price    int
+}
+
+func seedQueryTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := openAsyncTestDB(t)
+	if err := db.AutoMigrate(&queryVendor{}, &queryProduct{}); err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+	vendor := queryVendor{Name: "acme"}
+	for i := 1; i <= 25; i++ {
+		vendor.Products = append(vendor.Products, queryProduct{Name: fmt.Sprintf("p%02d", i), Price: i})
+	}
+	if err := db.Create(&vendor).Error; err != nil {
+		t.Fatalf("seed failed: %v", err)
+	}
+	return db
+}
+
+func TestQuery_FindFirstCount(t *testing.T) {
+	db := seedQueryTestDB(t)
+
+	q := Query[queryProduct](db).Where("price > ?", 20).OrderBy("price", true)
+	items, err := q.Find()
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if len(items) != 5 || items[0].Price != 25 {
+		t.Errorf("expected 5 products from price 25 down, got %+v", items)
+	}
+
+	count, err := q.Limit(2).Count()
+	if err != nil || count != 5 {
+		t.Errorf("expected count 5 ignoring the limit, got %d (%v)", count, err)
+	}
+	if items, _ := q.Find(); len(items) != 2 {
+		t.Errorf("expected the limit to apply to Find, got %d", len(items))
+	}
+
+	first, err := q.First()
+	if err != nil || first.Price != 25 {
+		t.Errorf("expected the most expensive product, got %+v (%v)", first, err)
+	}
+
+	_, err = Query[queryProduct](db).Where("price > ?", 100).First()
+	var appErr *Error
+	if !errors.As(err, &appErr) || !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("expected a not found Error, got %v", err)
+	}
+	if items, err := Query[queryProduct](db).Where("price > ?", 100).Find(); err != nil || items == nil {
+		t.Errorf("expected an empty non-nil slice, got %v (%v)", items, err)
+	}
+}
+
+func TestQuery_Paginate(t *testing.T) {
+	db := seedQueryTestDB(t)
+	q := Query[queryVendor](db).Preload("Products", func(db *gorm.DB) *gorm.DB {
+		return db.Order("price")
+	})
+	vendors, err := q.Paginate(1, 10)
+	if err != nil {
+		t.Fatalf("Paginate failed: %v", err)
+	}
+	if vendors.Total != 1 || len(vendors.Items) != 1 || len(vendors.Items[0].Products) != 25 {
+		t.Errorf("expected one vendor with preloaded products, got %+v", vendors)
+	}
+
+	products := Query[queryProduct](db).OrderBy("price")
+	tests := []struct {
+		page, perPage int
+		wantPage      int
+		wantFirst     int
+		wantLen       int
+		wantNext      bool
+	}{
+		{1, 10, 1, 1, 10, true},
+		{3, 10, 3, 21, 5, false},
+		{0, 10, 1, 1, 10, true},
+		{4, 10, 4, 0, 0, false},
+	}
+	for _, tt := range tests {
+		page, err := products.Paginate(tt.page, tt.perPage)
+		if err != nil {
+			t.Fatalf("Paginate(%d, %d) failed: %v", tt.page, tt.perPage, err)
+		}
+		if page.Page != tt.wantPage || page.Total != 25 || page.TotalPages != 3 || len(page.Items) != tt.wantLen || page.HasNext() != tt.wantNext {
+			t.Errorf("Paginate(%d, %d): unexpected page %+v", tt.page, tt.perPage, page)
+		}
+		if tt.wantLen > 0 && page.Items[0].Price != tt.wantFirst {
+			t.Errorf("Paginate(%d, %d): expected first price %d, got %d", tt.page, tt.perPage, tt.wantFirst, page.Items[0].Price)
+		}
+	}
+
+	page, err := products.Paginate(1, 1000)
+	if err != nil || page.PerPage != maxPerPage || len(page.Items) != 25 {
+		t.Errorf("expected perPage to be capped, got %+v (%v)", page, err)
+	}
+}
@@ -0,0 +1,139 @@
+package cartridge
+
+import (
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/karloscodes/cartridge/database"
+)
+
+// queryTraceLocalsKey stores the request's *database.QueryTrace in fiber locals.
+const queryTraceLocalsKey = "cartridge_query_trace"
+
+// maxSlowQueries caps how many slow queries Server.SlowQueries keeps.
+const maxSlowQueries = 50
+
+// SlowQuery is a query that took longer than ServerConfig.SlowQueryThreshold.
+type SlowQuery struct {
+	Time      time.Time     `json:"time"`
+	Method    string        `json:"method"`
+	Route     string        `json:"route"`
+	RequestID string        `json:"request_id,omitempty"`
+	SQL       string        `json:"sql"`
+	Duration  time.Duration `json:"duration"`
+	Rows      int64         `json:"rows"`
+}
+
+// slowQueryLog keeps the most recent slow queries.
+type slowQueryLog struct {
+	mu      sync.Mutex
+	queries []SlowQuery
+}
+
+func (l *slowQueryLog) add(q SlowQuery) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.queries) == maxSlowQueries {
+		l.queries = slices.Delete(l.queries, 0, 1)
+	}
+	l.queries = append(l.queries, q)
+}
+
+// SlowQueries returns the most recent queries slower than
+// ServerConfig.SlowQueryThreshold, oldest first. They are recorded when
+// EnableQueryTracing is on.
+func (s *Server) SlowQueries() []SlowQuery {
+	s.slowQueries.mu.Lock()
+	defer s.slowQueries.mu.Unlock()
+	return slices.Clone(s.slowQueries.queries)
+}
+
+// queryTraceMiddleware attaches a query trace to each request and keeps the
+// slow queries it records. In development it reports the query count and time in X-Query-Count/X-Query-Time headers, warns
+// when a request exceeds QueryCountWarnThreshold, and flags likely N+1 patterns.
+func (s *Server) queryTraceMiddleware() fiber.Handler {
+	threshold := s.cfg.QueryCountWarnThreshold
+	if threshold <= 0 {
+		threshold = 50
+	}
+	nPlusOne := s.cfg.NPlusOneThreshold
+	if nPlusOne <= 0 {
+		nPlusOne = 5
+	}
+	slow := s.cfg.SlowQueryThreshold
+	if slow <= 0 {
+		slow = 200 * time.Millisecond
+	}
+
+	return func(c *fiber.Ctx) error {
+		requestID, _ := c.Locals("requestid").(string)
+		trace := database.NewQueryTrace(requestID, "")
+		c.Locals(queryTraceLocalsKey, trace)
+
+		err := c.Next()
+
+		// No single query can be slow unless all of them together are
+		if trace.Duration() > slow {
+			for _, q := range trace.Queries() {
+				if q.Duration > slow {
+					s.slowQueries.add(SlowQuery{
+						Time:      time.Now(),
+						Method:    c.Method(),
+						Route:     trace.Route(),
+						RequestID: requestID,
+						SQL:       q.SQL,
+						Duration:  q.Duration,
+						Rows:      q.Rows,
+					})
+				}
+			}
+		}
+
+		if !s.cfg.Config.IsDevelopment() {
+			return err
+		}
+
+		count := trace.Count()
+		if count == 0 {
+			return err
+		}
+
+		c.Set("X-Query-Count", strconv.Itoa(count))
+		c.Set("X-Query-Time", trace.Duration().String())
+
+		if count > threshold {
+			s.cfg.Logger.Warn("request issued many queries",
+				"method", c.Method(),
+				"route", trace.Route(),
+				"request_id", requestID,
+				"queries", count,
+				"query_time", trace.Duration(),
+			)
+		}
+
+		warnNPlusOne(s.cfg.Logger, c.Method(), trace, nPlusOne)
+		return err
+	}
+}
+
+// warnNPlusOne logs each query fingerprint repeated at least threshold times,
+// with a Preload suggestion for the repeated table.
+func warnNPlusOne(logger Logger, method string, trace *database.QueryTrace, threshold int) {
+	for _, r := range trace.Repeated(threshold) {
+		args := []any{
+			"method", method,
+			"route", trace.Route(),
+			"request_id", trace.RequestID(),
+			"count", r.Count,
+			"sql", r.Fingerprint,
+		}
+		if hint := r.SuggestedPreload(); hint != "" {
+			args = append(args, "suggestion", "load the association up front with "+hint)
+		}
+		logger.Warn("possible N+1 query", args...)
+	}
+}
@@ -0,0 +1,110 @@
+package cartridge
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	cartridgemiddleware "github.com/karloscodes/cartridge/middleware"
+)
+
+// RateLimit limits how often a client may call routes. Set it on
+// ServerConfig.RateLimit for every route, or on RouteConfig.RateLimit to
+// give a route its own budget.
+type RateLimit struct {
+	// Max is the number of requests allowed per Window. Default: 60
+	Max int
+
+	// Window is the period Max applies to. Default: 1 minute
+	Window time.Duration
+
+	// Strategy is the algorithm. Default: cartridgemiddleware.SlidingWindow
+	Strategy cartridgemiddleware.RateLimitStrategy
+
+	// Burst is the token bucket capacity. Default: Max
+	Burst int
+
+	// Key identifies the client. Default: RateLimitByIP
+	Key RateLimitKey
+}
+
+// RateLimitKey returns the identity a request is counted against.
+type RateLimitKey func(ctx *Context) string
+
+// RateLimitByIP counts requests per client IP.
+func RateLimitByIP(ctx *Context) string {
+	return "ip:" + ctx.IP()
+}
+
+// RateLimitByHeader counts requests per value of a header such as an API
+// key, falling back to the client IP when it is missing.
+func RateLimitByHeader(name string) RateLimitKey {
+	return func(ctx *Context) string {
+		if value := ctx.Get(name); value != "" {
+			return "header:" + value
+		}
+		return RateLimitByIP(ctx)
+	}
+}
+
+// RateLimitByUser counts requests per authenticated user: the route's
+// Principal, or DefaultPrincipalResolver's on routes without Roles or
+// Authorize. Anonymous requests fall back to the client IP.
+func RateLimitByUser(ctx *Context) string {
+	p := ctx.Principal()
+	if p == nil {
+		p, _ = DefaultPrincipalResolver(ctx)
+	}
+	if p != nil && p.ID != "" {
+		return "user:" + p.ID
+	}
+	return RateLimitByIP(ctx)
+}
+
+// routeRateLimit returns the limiter for a route: its own RateLimit, else
+// ServerConfig.RateLimit, or nil when neither applies or it opted out.
+func (s *Server) routeRateLimit(method, path string, routeCfg *RouteConfig) fiber.Handler {
+	if routeCfg == nil {
+		return s.globalRateLimit
+	}
+	if routeCfg.EnableRateLimit != nil && !*routeCfg.EnableRateLimit {
+		return nil
+	}
+	if routeCfg.RateLimit != nil {
+		return s.rateLimit(*routeCfg.RateLimit, method+" "+path)
+	}
+	return s.globalRateLimit
+}
+
+// rateLimit returns middleware enforcing limit. Keys are namespaced by
+// scope, so routes with their own RateLimit don't share a budget.
+func (s *Server) rateLimit(limit RateLimit, scope string) fiber.Handler {
+	if limit.Max <= 0 {
+		limit.Max = 60
+	}
+	if limit.Window <= 0 {
+		limit.Window = time.Minute
+	}
+	if limit.Strategy == "" {
+		limit.Strategy = cartridgemiddleware.SlidingWindow
+	}
+	key := limit.Key
+	if key == nil {
+		key = RateLimitByIP
+	}
+
+	return cartridgemiddleware.RateLimiter(
+		cartridgemiddleware.WithMax(limit.Max),
+		cartridgemiddleware.WithDuration(limit.Window),
+		cartridgemiddleware.WithStrategy(limit.Strategy),
+		cartridgemiddleware.WithBurst(limit.Burst),
+		cartridgemiddleware.WithStore(s.rateLimits),
+		cartridgemiddleware.WithKeyFunc(func(c *fiber.Ctx) string {
+			return key(s.context(c))
+		}),
+		func(cfg *cartridgemiddleware.RateLimiterConfig) {
+			cfg.KeyPrefix = scope + ":"
+			cfg.Logger = s.cfg.Logger
+		},
+	)
+}
@@ -0,0 +1,71 @@
+package cartridge
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	cartridgemiddleware "github.com/karloscodes/cartridge/middleware"
+)
+
+func TestRouteRateLimit(t *testing.T) {
+	cfg := DefaultServerConfig()
+	cfg.EnableStaticAssets = false
+	cfg.EnableRequestLogger = false
+	cfg.EnableSecFetchSite = false
+	cfg.Config = &testConfig{}
+	cfg.Logger = testLogger()
+	cfg.DBManager = &testDBManager{}
+	cfg.ErrorHandler = DefaultErrorHandler(testLogger(), false)
+	cfg.RateLimit = &RateLimit{Max: 2, Window: time.Minute}
+
+	srv, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	ok := func(ctx *Context) error { return ctx.SendString("ok") }
+	srv.Get("/a", ok)
+	srv.Get("/b", ok)
+	srv.Post("/login", ok, &RouteConfig{RateLimit: &RateLimit{
+		Max:      1,
+		Window:   time.Minute,
+		Strategy: cartridgemiddleware.TokenBucket,
+		Key:      RateLimitByHeader("X-Account"),
+	}})
+	srv.Get("/health", ok, &RouteConfig{EnableRateLimit: Bool(false)})
+
+	do := func(method, path, account string) int {
+		req := httptest.NewRequest(method, path, nil)
+		if account != "" {
+			req.Header.Set("X-Account", account)
+		}
+		resp, err := srv.App().Test(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		return resp.StatusCode
+	}
+
+	// Routes without their own limit share the server budget
+	do("GET", "/a", "")
+	do("GET", "/b", "")
+	if status := do("GET", "/a", ""); status != fiber.StatusTooManyRequests {
+		t.Errorf("expected the shared budget to be spent, got %d", status)
+	}
+	if status := do("GET", "/health", ""); status != fiber.StatusOK {
+		t.Errorf("expected opted-out route to pass, got %d", status)
+	}
+
+	// The override has its own budget, counted per account
+	if status := do("POST", "/login", "ada"); status != fiber.StatusOK {
+		t.Errorf("expected first login to pass, got %d", status)
+	}
+	if status := do("POST", "/login", "ada"); status != fiber.StatusTooManyRequests {
+		t.Errorf("expected second login to be limited, got %d", status)
+	}
+	if status := do("POST", "/login", "grace"); status != fiber.StatusOK {
+		t.Errorf("expected another account to pass, got %d", status)
+	}
+}
@@ -0,0 +1,324 @@
+package cartridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ResourceAction names one of the RESTful routes registered by Server.Resource.
+type ResourceAction string
+
+const (
+	ActionIndex  ResourceAction = "index"  // GET    /products
+	ActionShow   ResourceAction = "show"   // GET    /products/:id
+	ActionCreate ResourceAction = "create" // POST   /products
+	ActionUpdate ResourceAction = "update" // PUT    /products/:id (and PATCH)
+	ActionDelete ResourceAction = "delete" // DELETE /products/:id
+)
+
+// Resource controllers implement any of these interfaces; Server.Resource
+// registers a route for each action the controller has.
+type (
+	ResourceIndexer interface{ Index(ctx *Context) error }
+	ResourceShower  interface{ Show(ctx *Context) error }
+	ResourceCreator interface{ Create(ctx *Context) error }
+	ResourceUpdater interface{ Update(ctx *Context) error }
+	ResourceDeleter interface{ Delete(ctx *Context) error }
+)
+
+// ResourceConfig configures the routes of Server.Resource.
+type ResourceConfig struct {
+	// Name prefixes the route names: "products.index", "products.show", ...
+	// Default: the last segment of the path
+	Name string
+
+	// Param is the path parameter holding the ID. Default: "id"
+	Param string
+
+	// Only limits the routes to these actions.
+	// Default: every action the controller implements
+	Only []ResourceAction
+
+	// Route configures every action's route, e.g. Roles or CustomMiddleware.
+	Route *RouteConfig
+
+	// Actions replace Route for single actions, e.g. to require a role only
+	// for writes. Their Name is ignored.
+	Actions map[ResourceAction]*RouteConfig
+}
+
+// Resource maps the controller's Index, Show, Create, Update and Delete
+// methods to RESTful routes under path:
+//
+//	s.Resource("/products", &ProductController{}, cartridge.ResourceConfig{
+//	    Route: &cartridge.RouteConfig{Roles: []string{"admin"}},
+//	    Actions: map[cartridge.ResourceAction]*cartridge.RouteConfig{
+//	        cartridge.ActionIndex: {}, // public
+//	        cartridge.ActionShow:  {},
+//	    },
+//	})
+//
+// Routes are named "<name>.<action>", so templates link to them with
+// {{ .Helpers.Route "products.show" .ID }}. Panics if the controller
+// implements none of the actions.
+func (s *Server) Resource(path string, controller any, config ...ResourceConfig) {
+	var cfg ResourceConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	path = "/" + strings.Trim(path, "/")
+	if cfg.Name == "" {
+		cfg.Name = path[strings.LastIndex(path, "/")+1:]
+	}
+	if cfg.Param == "" {
+		cfg.Param = "id"
+	}
+	member := path + "/:" + cfg.Param
+
+	routes := []struct {
+		action  ResourceAction
+		methods []string
+		path    string
+		handler HandlerFunc
+	}{
+		{ActionIndex, []string{fiber.MethodGet}, path, methodOf[ResourceIndexer](controller, ResourceIndexer.Index)},
+		{ActionShow, []string{fiber.MethodGet}, member, methodOf[ResourceShower](controller, ResourceShower.Show)},
+		{ActionCreate, []string{fiber.MethodPost}, path, methodOf[ResourceCreator](controller, ResourceCreator.Create)},
+		{ActionUpdate, []string{fiber.MethodPut, fiber.MethodPatch}, member, methodOf[ResourceUpdater](controller, ResourceUpdater.Update)},
+		{ActionDelete, []string{fiber.MethodDelete}, member, methodOf[ResourceDeleter](controller, ResourceDeleter.Delete)},
+	}
+
+	registered := 0
+	for _, route := range routes {
+		if route.handler == nil || (cfg.Only != nil && !slices.Contains(cfg.Only, route.action)) {
+			continue
+		}
+		base := cfg.Route
+		if override, ok := cfg.Actions[route.action]; ok {
+			base = override
+		}
+		for i, method := range route.methods {
+			var routeCfg RouteConfig
+			if base != nil {
+				routeCfg = *base
+			}
+			routeCfg.Name = ""
+			if i == 0 {
+				routeCfg.Name = cfg.Name + "." + string(route.action)
+			}
+			s.registerRoute(method, route.path, route.handler, &routeCfg)
+		}
+		registered++
+	}
+	if registered == 0 {
+		panic(fmt.Sprintf("cartridge: resource %s has no actions to register", path))
+	}
+}
+
+// methodOf returns the controller's action as a handler, or nil when the
+// controller doesn't implement it.
+func methodOf[I any](controller any, method func(I, *Context) error) HandlerFunc {
+	c, ok := controller.(I)
+	if !ok {
+		return nil
+	}
+	return func(ctx *Context) error { return method(c, ctx) }
+}
+
+// ModelResourceConfig configures a ModelResource.
+type ModelResourceConfig struct {
+	// PerPage is the default page size of Index; clients pick another with
+	// ?per_page= up to 100. Default: 20
+	PerPage int
+
+	// OrderBy is the column Index sorts by. Default: the primary key
+	OrderBy string
+
+	// Desc sorts Index in descending order.
+	Desc bool
+
+	// Scope restricts every action to the rows the caller may see, e.g.
+	// those owned by the signed-in user. Default: nil (all rows)
+	Scope func(ctx *Context, db *gorm.DB) *gorm.DB
+}
+
+// ModelResource is a JSON controller for model T backed by ctx.DB(), for
+// Server.Resource:
+//
+//	s.Resource("/api/notes", cartridge.NewModelResource[Note](cartridge.ModelResourceConfig{
+//	    Scope: func(ctx *cartridge.Context, db *gorm.DB) *gorm.DB {
+//	        return db.Where("owner_id = ?", ctx.Principal().ID)
+//	    },
+//	}), cartridge.ResourceConfig{Route: &cartridge.RouteConfig{Roles: []string{"user"}}})
+//
+// Index answers a Page (?page=, ?per_page=), Show the row or 404, Create
+// 201 with the row, Update the row after applying the JSON body to it,
+// and Delete 204. Bodies are validated with `validate` tags like BindJSON.
+// Embed it in a controller to override single actions.
+type ModelResource[T any] struct {
+	cfg ModelResourceConfig
+}
+
+// NewModelResource creates a ModelResource for T.
+func NewModelResource[T any](config ...ModelResourceConfig) *ModelResource[T] {
+	var cfg ModelResourceConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.PerPage <= 0 {
+		cfg.PerPage = 20
+	}
+	return &ModelResource[T]{cfg: cfg}
+}
+
+// Index answers a page of rows.
+func (r *ModelResource[T]) Index(ctx *Context) error {
+	q := r.query(ctx)
+	if r.cfg.OrderBy != "" {
+		q.OrderBy(r.cfg.OrderBy, r.cfg.Desc)
+	} else {
+		q.db = q.db.Order(clause.OrderByColumn{Column: primaryKeyColumn, Desc: r.cfg.Desc})
+	}
+	page, err := q.Paginate(ctx.QueryInt("page", 1), ctx.QueryInt("per_page", r.cfg.PerPage))
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(page)
+}
+
+// Show answers the row with the ID in the path.
+func (r *ModelResource[T]) Show(ctx *Context) error {
+	item, err := r.find(ctx)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(item)
+}
+
+// Create inserts the row in the body.
+func (r *ModelResource[T]) Create(ctx *Context) error {
+	item, err := BindJSON[T](ctx)
+	if err != nil {
+		return err
+	}
+	// IDs are assigned by the database, never by the client
+	db := ctx.DB()
+	pk, err := primaryKeyField(db, &item)
+	if err != nil {
+		return err
+	}
+	if pk != nil {
+		if err := pk.Set(ctx.UserContext(), reflect.ValueOf(&item).Elem(), reflect.Zero(pk.FieldType).Interface()); err != nil {
+			return err
+		}
+	}
+	if err := db.Create(&item).Error; err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusCreated).JSON(item)
+}
+
+// Update applies the body to the row with the ID in the path. Fields left
+// out of the body keep their values and the primary key can't be changed;
+// override Update when other columns, like an owner, must not be either.
+func (r *ModelResource[T]) Update(ctx *Context) error {
+	item, err := r.find(ctx)
+	if err != nil {
+		return err
+	}
+	db := ctx.DB()
+	pk, err := primaryKeyField(db, item)
+	if err != nil {
+		return err
+	}
+	var id any
+	if pk != nil {
+		id, _ = pk.ValueOf(ctx.UserContext(), reflect.ValueOf(item).Elem())
+	}
+
+	body := bytes.TrimSpace(ctx.Body())
+	if len(body) == 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "request body is empty")
+	}
+	if err := json.Unmarshal(body, item); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid JSON body: "+err.Error())
+	}
+	if pk != nil {
+		if err := pk.Set(ctx.UserContext(), reflect.ValueOf(item).Elem(), id); err != nil {
+			return err
+		}
+	}
+	if err := Validate(*item); err != nil {
+		return err
+	}
+	if err := db.Save(item).Error; err != nil {
+		return err
+	}
+	return ctx.JSON(item)
+}
+
+// Delete removes the row with the ID in the path.
+func (r *ModelResource[T]) Delete(ctx *Context) error {
+	db := ctx.DB().Model(new(T))
+	if r.cfg.Scope != nil {
+		db = r.cfg.Scope(ctx, db)
+	}
+	result := db.Where(clause.Eq{Column: primaryKeyColumn, Value: ctx.Params(resourceParam(ctx))}).Delete(new(T))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound(resourceName[T]())
+	}
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+// resourceSchemas caches the model schemas parsed by primaryKeyField.
+var resourceSchemas sync.Map
+
+// primaryKeyField returns the primary key field of model, or nil if it has none.
+func primaryKeyField(db *gorm.DB, model any) (*schema.Field, error) {
+	modelSchema, err := schema.Parse(model, &resourceSchemas, db.NamingStrategy)
+	if err != nil {
+		return nil, err
+	}
+	return modelSchema.PrioritizedPrimaryField, nil
+}
+
+// primaryKeyColumn refers to the model's primary key in conditions.
+var primaryKeyColumn = clause.Column{Table: clause.CurrentTable, Name: clause.PrimaryKey}
+
+// query starts a scoped query for the request.
+func (r *ModelResource[T]) query(ctx *Context) *TypedQueryBuilder[T] {
+	q := Query[T](ctx.DB())
+	if r.cfg.Scope != nil {
+		scope := r.cfg.Scope
+		q.Scopes(func(db *gorm.DB) *gorm.DB { return scope(ctx, db) })
+	}
+	return q
+}
+
+// find loads the row with the ID in the path, or returns ErrNotFound.
+func (r *ModelResource[T]) find(ctx *Context) (*T, error) {
+	return r.query(ctx).Where(clause.Eq{Column: primaryKeyColumn, Value: ctx.Params(resourceParam(ctx))}).First()
+}
+
+// resourceParam returns the name of the route's last parameter, the ID
+// parameter of a member route whatever ResourceConfig.Param is.
+func resourceParam(ctx *Context) string {
+	params := ctx.Route().Params
+	if len(params) == 0 {
+		return "id"
+	}
+	return params[len(params)-1]
+}
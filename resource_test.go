@@ -0,0 +1,134 @@
+package cartridge
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type resourceNote struct {
+	ID    uint   `json:"id"`
+	Owner string `json:"owner"`
+	Body  string `json:"body" validate:"required"`
+}
+
+type auditedNotes struct {
+	*ModelResource[resourceNote]
+	deleted bool
+}
+
+func (c *auditedNotes) Delete(ctx *Context) error {
+	c.deleted = true
+	return c.ModelResource.Delete(ctx)
+}
+
+func newResourceTestServer(t *testing.T) (*Server, *gorm.DB) {
+	t.Helper()
+	db := openAsyncTestDB(t)
+	if err := db.AutoMigrate(&resourceNote{}); err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+
+	cfg := DefaultServerConfig()
+	cfg.EnableStaticAssets = false
+	cfg.EnableRequestLogger = false
+	cfg.EnableSecFetchSite = false
+	cfg.Config = &testConfig{}
+	cfg.Logger = testLogger()
+	cfg.DBManager = &mockDBManager{db: db}
+	cfg.ErrorHandler = DefaultErrorHandler(testLogger(), false)
+
+	srv, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	return srv, db
+}
+
+func TestResource_ModelResource(t *testing.T) {
+	srv, db := newResourceTestServer(t)
+	db.Create(&resourceNote{Owner: "bob", Body: "not yours"})
+
+	controller := &auditedNotes{ModelResource: NewModelResource[resourceNote](ModelResourceConfig{
+		Scope: func(ctx *Context, db *gorm.DB) *gorm.DB {
+			return db.Where("owner = ?", "alice")
+		},
+	})}
+	requireUser := func(c *fiber.Ctx) error {
+		if c.Get("X-User") == "" {
+			return ErrUnauthorized("sign in")
+		}
+		return c.Next()
+	}
+	srv.Resource("/notes", controller, ResourceConfig{
+		Route:   &RouteConfig{CustomMiddleware: []fiber.Handler{requireUser}},
+		Actions: map[ResourceAction]*RouteConfig{ActionIndex: {}},
+	})
+
+	send := func(method, target, body string) (int, string) {
+		t.Helper()
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User", "alice")
+		resp, err := srv.App().Test(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		data, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(data)
+	}
+
+	status, body := send("POST", "/notes", `{"id": 99, "owner": "alice", "body": "first"}`)
+	if status != 201 || !strings.Contains(body, `"id":2`) {
+		t.Fatalf("expected 201 with a database ID, got %d %s", status, body)
+	}
+	if status, _ := send("POST", "/notes", `{"owner": "alice"}`); status != 422 {
+		t.Errorf("expected 422 for an invalid body, got %d", status)
+	}
+
+	if status, body := send("GET", "/notes/2", ""); status != 200 || !strings.Contains(body, `"body":"first"`) {
+		t.Errorf("expected the note, got %d %s", status, body)
+	}
+	if status, _ := send("GET", "/notes/1", ""); status != 404 {
+		t.Errorf("expected 404 outside the scope, got %d", status)
+	}
+
+	if status, body := send("PATCH", "/notes/2", `{"id": 7, "body": "edited"}`); status != 200 || !strings.Contains(body, `"id":2,"owner":"alice","body":"edited"`) {
+		t.Errorf("expected the updated note, got %d %s", status, body)
+	}
+
+	status, body = send("GET", "/notes?per_page=1", "")
+	var page Page[resourceNote]
+	if err := json.Unmarshal([]byte(body), &page); err != nil || status != 200 {
+		t.Fatalf("expected a page, got %d %s", status, body)
+	}
+	if page.Total != 1 || len(page.Items) != 1 || page.Items[0].Body != "edited" {
+		t.Errorf("expected only alice's note, got %+v", page)
+	}
+
+	if status, _ := send("DELETE", "/notes/1", ""); status != 404 {
+		t.Errorf("expected 404 deleting outside the scope, got %d", status)
+	}
+	if status, _ := send("DELETE", "/notes/2", ""); status != 204 || !controller.deleted {
+		t.Errorf("expected 204 from the overridden Delete, got %d", status)
+	}
+
+	// Index is public, the other actions keep the resource middleware
+	resp, err := srv.App().Test(httptest.NewRequest("GET", "/notes", nil))
+	if err != nil || resp.StatusCode != 200 {
+		t.Errorf("expected a public index, got %v %v", resp.StatusCode, err)
+	}
+	resp, err = srv.App().Test(httptest.NewRequest("GET", "/notes/2", nil))
+	if err != nil || resp.StatusCode != 401 {
+		t.Errorf("expected 401 without a user, got %v %v", resp.StatusCode, err)
+	}
+
+	if path, err := srv.URL("notes.show", 5); err != nil || path != "/notes/5" {
+		t.Errorf("expected a named show route, got %q %v", path, err)
+	}
+}
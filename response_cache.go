@@ -0,0 +1,186 @@
+package cartridge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/karloscodes/cartridge/cache"
+)
+
+// CachedResponse is a response stored by the route cache.
+type CachedResponse struct {
+	Status int               `json:"status"`
+	Header map[string]string `json:"header"`
+	Body   []byte            `json:"body"`
+}
+
+// responseCacheKeyPrefix namespaces route responses, so the store can be
+// shared with other cache users.
+const responseCacheKeyPrefix = "cartridge:response:"
+
+// RouteCache caches a GET route's successful responses, e.g. for read-heavy
+// listings that don't need a database round trip on every request.
+type RouteCache struct {
+	// TTL is how long a response is served from the cache. Default: 1 minute
+	TTL time.Duration
+
+	// KeyFunc returns the cache key for the request. Keys should start with
+	// the path so Invalidate patterns like "/products*" cover them.
+	// Default: the path and sorted query string
+	KeyFunc func(ctx *Context) string
+
+	// VaryHeaders are request headers whose values get separate entries,
+	// e.g. "Accept-Language". They are also listed in the Vary response header.
+	VaryHeaders []string
+}
+
+// cachedResponseHeaders are the response headers replayed from the cache.
+// Per-request headers like Set-Cookie and X-Request-ID are never stored.
+var cachedResponseHeaders = []string{
+	fiber.HeaderContentType,
+	fiber.HeaderContentLanguage,
+	fiber.HeaderContentDisposition,
+	fiber.HeaderCacheControl,
+	fiber.HeaderLastModified,
+	fiber.HeaderETag,
+}
+
+// ResponseCache serves cached route responses and invalidates them.
+type ResponseCache struct {
+	store  cache.Store
+	logger Logger
+}
+
+// ResponseCache returns the route response cache.
+func (s *Server) ResponseCache() *ResponseCache {
+	return s.cache
+}
+
+// Cache returns the route response cache, to invalidate entries after
+// writes:
+//
+//	app.Cache().Invalidate("/products*")
+func (a *App) Cache() *ResponseCache {
+	return a.Server.ResponseCache()
+}
+
+// Store returns the backing store.
+func (rc *ResponseCache) Store() cache.Store {
+	return rc.store
+}
+
+// Invalidate removes cached responses by key. A trailing "*" matches any
+// suffix: "/products*" drops every product page and query variant,
+// "/products/42" only that exact entry.
+func (rc *ResponseCache) Invalidate(pattern string) error {
+	ctx := context.Background()
+	prefix, wildcard := strings.CutSuffix(pattern, "*")
+	if strings.Contains(prefix, "*") {
+		return fmt.Errorf("cartridge: invalidate cache %q: \"*\" is only supported at the end", pattern)
+	}
+
+	n := 1
+	var err error
+	if wildcard {
+		n, err = rc.store.DeleteByPrefix(ctx, responseCacheKeyPrefix+prefix)
+	} else {
+		err = rc.store.Delete(ctx, responseCacheKeyPrefix+prefix)
+	}
+	if err != nil {
+		return fmt.Errorf("cartridge: invalidate cache %q: %w", pattern, err)
+	}
+	rc.logger.Debug("response cache invalidated", slog.String("pattern", pattern), slog.Int("count", n))
+	return nil
+}
+
+// middleware serves GET and HEAD requests from the cache, and stores 200
+// responses that don't set cookies or use the session. Responses carry
+// X-Cache: HIT or MISS.
+func (rc *ResponseCache) middleware(s *Server, route RouteCache) fiber.Handler {
+	ttl := route.TTL
+	if ttl <= 0 {
+		ttl = time.Minute
+	}
+
+	return func(c *fiber.Ctx) error {
+		if c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead {
+			return c.Next()
+		}
+		if len(route.VaryHeaders) > 0 {
+			c.Vary(route.VaryHeaders...)
+		}
+
+		key := responseCacheKeyPrefix + responseCacheKey(s.context(c), route)
+		if data, ok := rc.store.Read(c.UserContext(), key); ok {
+			var cached CachedResponse
+			if err := json.Unmarshal(data, &cached); err != nil {
+				return fmt.Errorf("cartridge: decode cached response: %w", err)
+			}
+			for name, value := range cached.Header {
+				c.Set(name, value)
+			}
+			c.Set("X-Cache", "HIT")
+			return c.Status(cached.Status).Send(cached.Body)
+		}
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		resp := c.Response()
+		setsCookie := false
+		resp.Header.VisitAllCookie(func(_, _ []byte) { setsCookie = true })
+		// The session is saved after this runs, so its cookie isn't set yet
+		if sess, ok := c.Locals(sessionLocalsKey).(*Session); ok && sess.accessed() {
+			return nil
+		}
+		if resp.StatusCode() != fiber.StatusOK || setsCookie || resp.IsBodyStream() {
+			return nil
+		}
+
+		entry := CachedResponse{
+			Status: fiber.StatusOK,
+			Header: make(map[string]string),
+			Body:   append([]byte(nil), resp.Body()...),
+		}
+		for _, name := range cachedResponseHeaders {
+			if value := resp.Header.Peek(name); len(value) > 0 {
+				entry.Header[name] = string(value)
+			}
+		}
+		data, err := json.Marshal(entry)
+		if err == nil {
+			err = rc.store.WriteWithTTL(c.UserContext(), key, data, ttl)
+		}
+		if err != nil {
+			rc.logger.Warn("response cache write failed", slog.String("key", key), slog.Any("error", err))
+		}
+		c.Set("X-Cache", "MISS")
+		return nil
+	}
+}
+
+// responseCacheKey builds the cache key for a request.
+func responseCacheKey(ctx *Context, route RouteCache) string {
+	var key string
+	if route.KeyFunc != nil {
+		key = route.KeyFunc(ctx)
+	} else {
+		key = ctx.Path()
+		// Sorted so ?a=1&b=2 and ?b=2&a=1 share an entry
+		if query, err := url.ParseQuery(string(ctx.Request().URI().QueryString())); err == nil && len(query) > 0 {
+			key += "?" + query.Encode()
+		}
+	}
+	for _, header := range route.VaryHeaders {
+		key += "|" + strings.ToLower(header) + "=" + ctx.Get(header)
+	}
+	return key
+}
@@ -0,0 +1,193 @@
+package cartridge
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/karloscodes/cartridge/cache"
+)
+
+func TestRouteCache(t *testing.T) {
+	cfg := DefaultServerConfig()
+	cfg.EnableStaticAssets = false
+	cfg.EnableRequestLogger = false
+	cfg.EnableSecFetchSite = false
+	cfg.Config = &testConfig{}
+	cfg.Logger = testLogger()
+	cfg.DBManager = &testDBManager{}
+	cfg.ErrorHandler = DefaultErrorHandler(testLogger(), false)
+
+	srv, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	queries := 0
+	srv.Get("/products", func(ctx *Context) error {
+		queries++
+		return ctx.JSON(fiber.Map{"lang": ctx.Get("Accept-Language"), "page": ctx.Query("page")})
+	}, &RouteConfig{Cache: &RouteCache{TTL: time.Minute, VaryHeaders: []string{"Accept-Language"}}})
+	srv.Get("/cart", func(ctx *Context) error {
+		queries++
+		ctx.Cookie(&fiber.Cookie{Name: "cart", Value: "1"})
+		return ctx.SendString("cart")
+	}, &RouteConfig{Cache: &RouteCache{}})
+
+	get := func(path, lang string) (string, string) {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Accept-Language", lang)
+		resp, err := srv.App().Test(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", path, resp.StatusCode)
+		}
+		return resp.Header.Get("X-Cache"), string(body)
+	}
+
+	if status, _ := get("/products?page=2&sort=name", "en"); status != "MISS" {
+		t.Errorf("expected first request to miss, got %q", status)
+	}
+	status, body := get("/products?sort=name&page=2", "en")
+	if status != "HIT" || body != `{"lang":"en","page":"2"}` {
+		t.Errorf("expected a hit regardless of query order, got %q with %s", status, body)
+	}
+	if status, _ := get("/products?page=2&sort=name", "de"); status != "MISS" {
+		t.Errorf("expected another Accept-Language to miss, got %q", status)
+	}
+	if queries != 2 {
+		t.Errorf("expected 2 handler calls, got %d", queries)
+	}
+
+	if err := srv.ResponseCache().Invalidate("/products*"); err != nil {
+		t.Fatalf("Invalidate failed: %v", err)
+	}
+	if status, _ := get("/products?page=2&sort=name", "en"); status != "MISS" {
+		t.Errorf("expected a miss after invalidation, got %q", status)
+	}
+
+	// Responses setting cookies are per-user and never cached
+	get("/cart", "")
+	if status, _ := get("/cart", ""); status == "HIT" {
+		t.Error("expected response with Set-Cookie not to be cached")
+	}
+}
+
+func TestRouteCache_SkipsSessionResponses(t *testing.T) {
+	cfg := DefaultServerConfig()
+	cfg.EnableStaticAssets = false
+	cfg.EnableRequestLogger = false
+	cfg.EnableSecFetchSite = false
+	cfg.Config = &testConfig{}
+	cfg.Logger = testLogger()
+	cfg.DBManager = &testDBManager{}
+	cfg.ErrorHandler = DefaultErrorHandler(testLogger(), false)
+
+	srv, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	srv.SetSessions(NewSessions(SessionsConfig{Store: NewMemorySessionStore(), CookieName: "test_session", Logger: testLogger()}))
+	srv.Post("/save", func(ctx *Context) error {
+		ctx.Session().Flash("notice", "Saved")
+		return ctx.SendString("ok")
+	})
+	srv.Get("/dashboard", func(ctx *Context) error {
+		notice, _ := ctx.Session().GetFlash("notice").(string)
+		return ctx.SendString("dashboard " + notice)
+	}, &RouteConfig{Cache: &RouteCache{}})
+
+	resp, err := srv.App().Test(httptest.NewRequest("POST", "/save", nil))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	var session string
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == "test_session" {
+			session = cookie.Value
+		}
+	}
+	if session == "" {
+		t.Fatal("expected a session cookie")
+	}
+
+	get := func(session string) (string, string) {
+		req := httptest.NewRequest("GET", "/dashboard", nil)
+		if session != "" {
+			req.Header.Set("Cookie", "test_session="+session)
+		}
+		resp, err := srv.App().Test(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		return resp.Header.Get("X-Cache"), string(body)
+	}
+
+	if _, body := get(session); body != "dashboard Saved" {
+		t.Fatalf("expected the flash to be shown, got %q", body)
+	}
+	// Neither the flash nor the page of another visitor may be served from cache
+	if status, body := get(""); status == "HIT" || body != "dashboard " {
+		t.Errorf("expected a response rendered for this visitor, got %q with %q", status, body)
+	}
+}
+
+func TestRouteCache_SharedStore(t *testing.T) {
+	store, err := cache.NewDatabaseStore(openAsyncTestDB(t), cache.WithCleanupInterval(0))
+	if err != nil {
+		t.Fatalf("NewDatabaseStore failed: %v", err)
+	}
+	defer store.Close()
+
+	// Two instances on one database see each other's entries
+	newServer := func() *Server {
+		cfg := DefaultServerConfig()
+		cfg.EnableStaticAssets = false
+		cfg.EnableRequestLogger = false
+		cfg.EnableSecFetchSite = false
+		cfg.Config = &testConfig{}
+		cfg.Logger = testLogger()
+		cfg.DBManager = &testDBManager{}
+		cfg.ErrorHandler = DefaultErrorHandler(testLogger(), false)
+		cfg.ResponseCacheStore = store
+
+		srv, err := NewServer(cfg)
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		srv.Get("/product_tags", func(ctx *Context) error {
+			return ctx.SendString("tags")
+		}, &RouteConfig{Cache: &RouteCache{}})
+		return srv
+	}
+	get := func(srv *Server) string {
+		resp, err := srv.App().Test(httptest.NewRequest("GET", "/product_tags", nil))
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		return resp.Header.Get("X-Cache")
+	}
+
+	first, second := newServer(), newServer()
+	get(first)
+	if status := get(second); status != "HIT" {
+		t.Errorf("expected the second instance to hit, got %q", status)
+	}
+
+	if err := second.ResponseCache().Invalidate("/product_tags"); err != nil {
+		t.Fatalf("Invalidate failed: %v", err)
+	}
+	if status := get(first); status != "MISS" {
+		t.Errorf("expected a miss after exact invalidation, got %q", status)
+	}
+	if err := first.ResponseCache().Invalidate("/products/*/reviews"); err == nil {
+		t.Error("expected an error for a wildcard before the end")
+	}
+}
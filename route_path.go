@@ -0,0 +1,98 @@
+package cartridge
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Param declares the type of a route parameter for Path.
+type Param struct {
+	name       string
+	constraint string // Fiber route constraint, "" for any segment
+}
+
+// IntParam declares an integer parameter. Requests where it isn't one get a
+// 404 from the router, and ctx.ParamInt reads it.
+func IntParam(name string) Param { return Param{name: name, constraint: "int"} }
+
+// UUIDParam declares a UUID parameter, e.g. "3f2b1c8e-...".
+func UUIDParam(name string) Param { return Param{name: name, constraint: "guid"} }
+
+// StringParam declares a parameter that matches any segment.
+func StringParam(name string) Param { return Param{name: name} }
+
+// Path declares a route path with typed parameters. Every ":param" in
+// pattern must be declared, and every declared parameter must appear in
+// pattern; Path panics otherwise, so mistakes fail at startup. Declare paths
+// as package variables and use them to register routes:
+//
+//	var ProductPath = cartridge.Path("/products/:id", cartridge.IntParam("id"))
+//
+//	s.Get(ProductPath, func(ctx *cartridge.Context) error {
+//	    id, err := ctx.ParamInt("id")
+//	    ...
+//	})
+//
+// The returned pattern carries Fiber route constraints ("/products/:id<int>").
+// The routecheck analyzer (cmd/routecheck) reports handlers that read
+// parameters their route doesn't declare.
+func Path(pattern string, params ...Param) string {
+	declared := make(map[string]Param, len(params))
+	for _, p := range params {
+		if p.name == "" {
+			panic(fmt.Sprintf("cartridge: Path %q: parameter without a name", pattern))
+		}
+		if _, dup := declared[p.name]; dup {
+			panic(fmt.Sprintf("cartridge: Path %q: parameter %q declared twice", pattern, p.name))
+		}
+		declared[p.name] = p
+	}
+
+	var b strings.Builder
+	seen := make(map[string]bool, len(params))
+	for i := 0; i < len(pattern); i++ {
+		b.WriteByte(pattern[i])
+		if pattern[i] != ':' || (i > 0 && pattern[i-1] == '\\') {
+			continue // "\\:" is a literal colon
+		}
+		end := i + 1
+		for end < len(pattern) && !strings.ContainsRune(routeParamDelimiters, rune(pattern[end])) {
+			end++
+		}
+		name := pattern[i+1 : end]
+		if end < len(pattern) && pattern[end] == '<' {
+			panic(fmt.Sprintf("cartridge: Path %q: declare the type of %q with a Param instead of a constraint", pattern, name))
+		}
+		p, ok := declared[name]
+		if !ok {
+			panic(fmt.Sprintf("cartridge: Path %q: parameter %q is not declared", pattern, name))
+		}
+		seen[name] = true
+		b.WriteString(name)
+		if p.constraint != "" {
+			b.WriteString("<" + p.constraint + ">")
+		}
+		i = end - 1
+	}
+	for _, p := range params {
+		if !seen[p.name] {
+			panic(fmt.Sprintf("cartridge: Path %q: declared parameter %q is not in the pattern", pattern, p.name))
+		}
+	}
+	return b.String()
+}
+
+// routeParamDelimiters end a parameter name in a Fiber route pattern.
+const routeParamDelimiters = "/-.?<:*+"
+
+// ParamInt returns the integer route parameter name. Routes declared with
+// IntParam only match integers; elsewhere a missing or non-integer value is
+// a 400 error.
+func (ctx *Context) ParamInt(name string) (int, error) {
+	n, err := strconv.Atoi(ctx.Params(name))
+	if err != nil {
+		return 0, ErrBadRequest(name + " must be an integer")
+	}
+	return n, nil
+}
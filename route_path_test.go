@@ -0,0 +1,85 @@
+package cartridge
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		params  []Param
+		want    string
+	}{
+		{"/products/:id", []Param{IntParam("id")}, "/products/:id<int>"},
+		{"/products/:id/reviews/:review", []Param{IntParam("id"), UUIDParam("review")}, "/products/:id<int>/reviews/:review<guid>"},
+		{"/users/:name?", []Param{StringParam("name")}, "/users/:name?"},
+		{"/pages/:page?", []Param{IntParam("page")}, "/pages/:page<int>?"},
+		{"/flights/:from-:to", []Param{StringParam("from"), StringParam("to")}, "/flights/:from-:to"},
+		{`/time\:now`, nil, `/time\:now`},
+	}
+	for _, tt := range tests {
+		if got := Path(tt.pattern, tt.params...); got != tt.want {
+			t.Errorf("Path(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestPath_Mismatch(t *testing.T) {
+	tests := map[string]func(){
+		"undeclared":     func() { Path("/products/:id") },
+		"not in pattern": func() { Path("/products/:id", IntParam("id"), IntParam("slug")) },
+		"duplicate":      func() { Path("/products/:id", IntParam("id"), StringParam("id")) },
+		"constraint":     func() { Path("/products/:id<int>", IntParam("id")) },
+	}
+	for name, fn := range tests {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected a panic")
+				}
+			}()
+			fn()
+		})
+	}
+}
+
+func TestParamInt(t *testing.T) {
+	cfg := DefaultServerConfig()
+	cfg.EnableStaticAssets = false
+	cfg.EnableRequestLogger = false
+	cfg.EnableSecFetchSite = false
+	cfg.Config = &testConfig{}
+	cfg.Logger = testLogger()
+	cfg.DBManager = &testDBManager{}
+	cfg.ErrorHandler = DefaultErrorHandler(testLogger(), false)
+
+	srv, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	srv.Get(Path("/products/:id", IntParam("id")), func(ctx *Context) error {
+		id, err := ctx.ParamInt("id")
+		if err != nil {
+			return err
+		}
+		return ctx.JSON(id)
+	})
+
+	resp, err := srv.App().Test(httptest.NewRequest("GET", "/products/42", nil))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if body, _ := io.ReadAll(resp.Body); resp.StatusCode != 200 || string(body) != "42" {
+		t.Errorf("expected 200 with 42, got %d %s", resp.StatusCode, body)
+	}
+
+	resp, err = srv.App().Test(httptest.NewRequest("GET", "/products/abc", nil))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.StatusCode != 404 {
+		t.Errorf("expected a non-integer id not to match the route, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,423 @@
+// Package routecheck reports handlers that read route parameters their
+// route doesn't have. It follows routes registered on a cartridge.Server
+// (s.Get, s.Post, ...) whose path is a string literal or a cartridge.Path
+// declaration, into handlers given as function literals or functions and
+// methods of the same package, and checks their ctx.Params, ctx.ParamsInt
+// and ctx.ParamInt calls:
+//
+//	var ProductPath = cartridge.Path("/products/:id", cartridge.IntParam("id"))
+//
+//	s.Get(ProductPath, func(ctx *cartridge.Context) error {
+//	    slug := ctx.Params("slug") // route "/products/:id" has no parameter "slug"
+//	    ...
+//	})
+//
+// It also reports cartridge.Path calls whose parameters don't match the
+// pattern, which would otherwise panic at startup. The analysis is
+// syntactic, so it runs without building the package; run it in CI with
+//
+//	go run github.com/karloscodes/cartridge/cmd/routecheck ./...
+package routecheck
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const cartridgePath = "github.com/karloscodes/cartridge"
+
+// routeMethods are the Server methods that register a route.
+var routeMethods = map[string]bool{
+	"Get": true, "Post": true, "Put": true, "Delete": true,
+	"Patch": true, "Options": true, "Head": true,
+}
+
+// paramTypes maps cartridge Param constructors to the type they declare.
+var paramTypes = map[string]string{
+	"IntParam":    "int",
+	"UUIDParam":   "uuid",
+	"StringParam": "string",
+}
+
+// routeParamDelimiters end a parameter name in a Fiber route pattern.
+const routeParamDelimiters = "/-.?<:*+"
+
+// Diagnostic is a problem found at a position in the source.
+type Diagnostic struct {
+	Pos     token.Position
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s", d.Pos, d.Message)
+}
+
+// route is a resolved route path.
+type route struct {
+	pattern string
+	params  map[string]string // name -> declared type, "" when untyped
+	typed   bool              // declared with cartridge.Path
+}
+
+type checker struct {
+	fset    *token.FileSet
+	funcs   map[string]*ast.FuncDecl   // package functions by name
+	methods map[string][]*ast.FuncDecl // methods by name
+	values  map[string]ast.Expr        // package-level var and const values
+	diags   []Diagnostic
+}
+
+// Check analyzes the files of one package.
+func Check(fset *token.FileSet, files []*ast.File) []Diagnostic {
+	c := &checker{
+		fset:    fset,
+		funcs:   make(map[string]*ast.FuncDecl),
+		methods: make(map[string][]*ast.FuncDecl),
+		values:  make(map[string]ast.Expr),
+	}
+	for _, f := range files {
+		c.collect(f)
+	}
+	for _, f := range files {
+		if name := cartridgeImport(f); name != "" {
+			c.checkFile(f, name)
+		}
+	}
+	sort.Slice(c.diags, func(i, j int) bool {
+		a, b := c.diags[i].Pos, c.diags[j].Pos
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		return a.Offset < b.Offset
+	})
+	return c.diags
+}
+
+// CheckDir parses and analyzes the Go files in dir, test files included.
+// Files are grouped by package, so external test packages are checked on
+// their own.
+func CheckDir(dir string) ([]Diagnostic, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	packages := make(map[string][]*ast.File)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, filepath.Join(dir, entry.Name()), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		packages[f.Name.Name] = append(packages[f.Name.Name], f)
+	}
+
+	names := make([]string, 0, len(packages))
+	for name := range packages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var diags []Diagnostic
+	for _, name := range names {
+		diags = append(diags, Check(fset, packages[name])...)
+	}
+	return diags, nil
+}
+
+// cartridgeImport returns the name the file imports cartridge under, or ""
+// when it doesn't.
+func cartridgeImport(f *ast.File) string {
+	for _, imp := range f.Imports {
+		if path, _ := strconv.Unquote(imp.Path.Value); path != cartridgePath {
+			continue
+		}
+		if imp.Name != nil {
+			if imp.Name.Name == "_" || imp.Name.Name == "." {
+				return ""
+			}
+			return imp.Name.Name
+		}
+		return "cartridge"
+	}
+	return ""
+}
+
+func (c *checker) collect(f *ast.File) {
+	for _, decl := range f.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv == nil {
+				c.funcs[decl.Name.Name] = decl
+			} else {
+				c.methods[decl.Name.Name] = append(c.methods[decl.Name.Name], decl)
+			}
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				vs, ok := spec.(*ast.ValueSpec)
+				if !ok || len(vs.Values) != len(vs.Names) {
+					continue
+				}
+				for i, name := range vs.Names {
+					c.values[name.Name] = vs.Values[i]
+				}
+			}
+		}
+	}
+}
+
+func (c *checker) checkFile(f *ast.File, pkg string) {
+	ast.Inspect(f, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if isPkg(sel.X, pkg) && sel.Sel.Name == "Path" {
+			c.checkPath(call, pkg)
+			return true
+		}
+		if routeMethods[sel.Sel.Name] && len(call.Args) >= 2 {
+			c.checkRoute(call, pkg)
+		}
+		return true
+	})
+}
+
+// checkPath reports a cartridge.Path call whose parameters don't match its
+// pattern.
+func (c *checker) checkPath(call *ast.CallExpr, pkg string) {
+	if len(call.Args) == 0 {
+		return
+	}
+	pattern, ok := stringLit(call.Args[0])
+	if !ok {
+		return
+	}
+	declared, ok := declaredParams(call, pkg)
+	if !ok {
+		return
+	}
+	inPattern := patternParams(pattern)
+	for _, name := range inPattern {
+		if _, ok := declared[name]; !ok {
+			c.report(call.Args[0], "path %q: parameter %q is not declared", pattern, name)
+		}
+	}
+	for i, arg := range call.Args[1:] {
+		name := paramName(arg)
+		if !slices.Contains(inPattern, name) {
+			c.report(call.Args[i+1], "path %q has no parameter %q", pattern, name)
+		}
+	}
+}
+
+// checkRoute checks the parameters read by a route's handler.
+func (c *checker) checkRoute(call *ast.CallExpr, pkg string) {
+	r, ok := c.resolveRoute(call.Args[0], pkg, 0)
+	if !ok {
+		return
+	}
+	fn := c.resolveHandler(call.Args[1])
+	if fn == nil {
+		return
+	}
+	ctx := handlerContext(fn.Type, pkg)
+	if ctx == "" {
+		return
+	}
+
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		read, ok := n.(*ast.CallExpr)
+		if !ok || len(read.Args) == 0 {
+			return true
+		}
+		sel, ok := read.Fun.(*ast.SelectorExpr)
+		if !ok || !isIdent(sel.X, ctx) {
+			return true
+		}
+		method := sel.Sel.Name
+		if method != "Params" && method != "ParamsInt" && method != "ParamInt" {
+			return true
+		}
+		name, ok := stringLit(read.Args[0])
+		if !ok || strings.HasPrefix(name, "*") || strings.HasPrefix(name, "+") {
+			return true // wildcard values aren't named in the pattern
+		}
+		typ, ok := r.params[name]
+		switch {
+		case !ok:
+			c.report(read.Args[0], "route %q has no parameter %q", r.pattern, name)
+		case r.typed && method != "Params" && typ != "int":
+			c.report(read, "%s.%s(%q): parameter is declared as %s, not int", ctx, method, name, typ)
+		}
+		return true
+	})
+}
+
+// resolveRoute follows a route path expression to a string literal or a
+// cartridge.Path call, through package-level variables and constants.
+func (c *checker) resolveRoute(expr ast.Expr, pkg string, depth int) (route, bool) {
+	if depth > 8 {
+		return route{}, false
+	}
+	switch e := expr.(type) {
+	case *ast.ParenExpr:
+		return c.resolveRoute(e.X, pkg, depth+1)
+	case *ast.BasicLit:
+		pattern, ok := stringLit(e)
+		if !ok {
+			return route{}, false
+		}
+		params := make(map[string]string)
+		for _, name := range patternParams(pattern) {
+			params[name] = ""
+		}
+		return route{pattern: pattern, params: params}, true
+	case *ast.Ident:
+		if value, ok := c.values[e.Name]; ok {
+			return c.resolveRoute(value, pkg, depth+1)
+		}
+	case *ast.CallExpr:
+		sel, ok := e.Fun.(*ast.SelectorExpr)
+		if !ok || !isPkg(sel.X, pkg) || sel.Sel.Name != "Path" || len(e.Args) == 0 {
+			return route{}, false
+		}
+		pattern, ok := stringLit(e.Args[0])
+		if !ok {
+			return route{}, false
+		}
+		params, ok := declaredParams(e, pkg)
+		if !ok {
+			return route{}, false
+		}
+		return route{pattern: pattern, params: params, typed: true}, true
+	}
+	return route{}, false
+}
+
+// resolveHandler returns the function a handler expression refers to: a
+// function literal, a package function, or a method when only one method
+// in the package has that name.
+func (c *checker) resolveHandler(expr ast.Expr) *ast.FuncLit {
+	var decl *ast.FuncDecl
+	switch e := expr.(type) {
+	case *ast.FuncLit:
+		return e
+	case *ast.Ident:
+		decl = c.funcs[e.Name]
+	case *ast.SelectorExpr:
+		if methods := c.methods[e.Sel.Name]; len(methods) == 1 {
+			decl = methods[0]
+		}
+	}
+	if decl == nil || decl.Body == nil {
+		return nil
+	}
+	return &ast.FuncLit{Type: decl.Type, Body: decl.Body}
+}
+
+// handlerContext returns the name of a handler's *cartridge.Context or
+// *fiber.Ctx parameter, or "" when fn isn't a handler.
+func handlerContext(fn *ast.FuncType, pkg string) string {
+	if fn.Params == nil || len(fn.Params.List) != 1 || len(fn.Params.List[0].Names) != 1 {
+		return ""
+	}
+	field := fn.Params.List[0]
+	star, ok := field.Type.(*ast.StarExpr)
+	if !ok {
+		return ""
+	}
+	sel, ok := star.X.(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+	if (isPkg(sel.X, pkg) && sel.Sel.Name == "Context") || (isIdent(sel.X, "fiber") && sel.Sel.Name == "Ctx") {
+		return field.Names[0].Name
+	}
+	return ""
+}
+
+// declaredParams returns the parameters declared in a cartridge.Path call,
+// or false when one isn't a literal Param constructor call.
+func declaredParams(call *ast.CallExpr, pkg string) (map[string]string, bool) {
+	params := make(map[string]string, len(call.Args)-1)
+	for _, arg := range call.Args[1:] {
+		ctor, ok := arg.(*ast.CallExpr)
+		if !ok || len(ctor.Args) != 1 {
+			return nil, false
+		}
+		sel, ok := ctor.Fun.(*ast.SelectorExpr)
+		if !ok || !isPkg(sel.X, pkg) {
+			return nil, false
+		}
+		typ, ok := paramTypes[sel.Sel.Name]
+		name, isLit := stringLit(ctor.Args[0])
+		if !ok || !isLit {
+			return nil, false
+		}
+		params[name] = typ
+	}
+	return params, true
+}
+
+// paramName returns the name passed to a Param constructor, or "".
+func paramName(expr ast.Expr) string {
+	if ctor, ok := expr.(*ast.CallExpr); ok && len(ctor.Args) == 1 {
+		name, _ := stringLit(ctor.Args[0])
+		return name
+	}
+	return ""
+}
+
+// patternParams returns the ":param" names in a Fiber route pattern.
+func patternParams(pattern string) []string {
+	var names []string
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != ':' || (i > 0 && pattern[i-1] == '\\') {
+			continue
+		}
+		end := i + 1
+		for end < len(pattern) && !strings.ContainsRune(routeParamDelimiters, rune(pattern[end])) {
+			end++
+		}
+		names = append(names, pattern[i+1:end])
+		i = end - 1
+	}
+	return names
+}
+
+func (c *checker) report(node ast.Node, format string, args ...any) {
+	c.diags = append(c.diags, Diagnostic{
+		Pos:     c.fset.Position(node.Pos()),
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func stringLit(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	s, err := strconv.Unquote(lit.Value)
+	return s, err == nil
+}
+
+func isIdent(expr ast.Expr, name string) bool {
+	id, ok := expr.(*ast.Ident)
+	return ok && id.Name == name
+}
+
+func isPkg(expr ast.Expr, pkg string) bool { return isIdent(expr, pkg) }
@@ -0,0 +1,123 @@
+package routecheck
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func check(t *testing.T, src string) []string {
+	t.Helper()
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "routes.go", src, 0)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	var out []string
+	for _, d := range Check(fset, []*ast.File{f}) {
+		out = append(out, d.String())
+	}
+	return out
+}
+
+func TestCheck(t *testing.T) {
+	diags := check(t, `package app
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/karloscodes/cartridge"
+)
+
+var (
+	ProductPath = cartridge.Path("/products/:id", cartridge.IntParam("id"))
+	ReviewPath  = cartridge.Path("/products/:id/reviews/:review", cartridge.IntParam("id"), cartridge.UUIDParam("review"))
+	BrokenPath  = cartridge.Path("/orders/:id", cartridge.IntParam("order"))
+)
+
+const legacyPath = "/legacy/:slug"
+
+type handlers struct{}
+
+func (h *handlers) review(ctx *cartridge.Context) error {
+	id, _ := ctx.ParamInt("id")
+	_, _ = ctx.ParamInt("review")
+	return ctx.JSON(id)
+}
+
+func showProduct(ctx *cartridge.Context) error {
+	_ = ctx.Params("id")
+	_, _ = ctx.ParamInt("slug")
+	return nil
+}
+
+func register(s *cartridge.Server, h *handlers) {
+	s.Get(ProductPath, showProduct)
+	s.Get(ReviewPath, h.review)
+	s.Get(legacyPath, func(c *fiber.Ctx) error {
+		_ = c.Params("slug")
+		_ = c.Params("id")
+		_ = c.Params("*")
+		return nil
+	})
+	s.Get("/static/*", func(ctx *cartridge.Context) error {
+		return ctx.SendFile(ctx.Params("*"))
+	})
+
+	cache := map[string]string{}
+	_ = cache["Get"]
+}
+`)
+
+	want := []string{
+		`routes.go:11:31: path "/orders/:id": parameter "id" is not declared`,
+		`routes.go:11:46: path "/orders/:id" has no parameter "order"`,
+		`routes.go:20:9: ctx.ParamInt("review"): parameter is declared as uuid, not int`,
+		`routes.go:26:22: route "/products/:id" has no parameter "slug"`,
+		`routes.go:35:16: route "/legacy/:slug" has no parameter "id"`,
+	}
+	if strings.Join(diags, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\nwant:\n%s", strings.Join(diags, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestCheck_IgnoresFilesWithoutCartridge(t *testing.T) {
+	diags := check(t, `package app
+
+type router struct{}
+
+func (router) Get(path string, h func(ctx *Context) error) {}
+
+type Context struct{}
+
+func (*Context) Params(string) string { return "" }
+
+func register(r router) {
+	r.Get("/a/:id", func(ctx *Context) error {
+		_ = ctx.Params("missing")
+		return nil
+	})
+}
+`)
+	if len(diags) != 0 {
+		t.Errorf("expected no diagnostics, got %v", diags)
+	}
+}
+
+func TestPatternParams(t *testing.T) {
+	tests := map[string][]string{
+		"/products/:id":         {"id"},
+		"/flights/:from-:to":    {"from", "to"},
+		"/files/:name.:ext":     {"name", "ext"},
+		"/users/:id?":           {"id"},
+		"/users/:id<int>/posts": {"id"},
+		`/time\:now`:            nil,
+		"/assets/*":             nil,
+	}
+	for pattern, want := range tests {
+		if got := patternParams(pattern); strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("patternParams(%q) = %v, want %v", pattern, got, want)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+// Package safehttp fetches user-supplied URLs without exposing internal
+// services (SSRF). Destinations are checked when the connection is made, so
+// DNS tricks that resolve to private addresses are caught along with literal
+// IPs, and every redirect hop is validated again.
+package safehttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// ErrBlocked is returned when a URL, redirect or resolved address is not allowed.
+var ErrBlocked = errors.New("destination not allowed")
+
+// ErrResponseTooLarge is returned while reading a body that exceeds MaxResponseSize.
+var ErrResponseTooLarge = errors.New("response too large")
+
+// Config configures a Client.
+type Config struct {
+	// AllowedSchemes lists the URL schemes that may be fetched.
+	// Default: ["http", "https"]
+	AllowedSchemes []string
+
+	// AllowedPorts lists the destination ports that may be dialed.
+	// Default: [80, 443]
+	AllowedPorts []int
+
+	// AllowedNetworks are exceptions to the private-address block, e.g. an
+	// internal webhook receiver at 10.0.5.0/24.
+	AllowedNetworks []netip.Prefix
+
+	// MaxRedirects caps redirects per request; -1 disables them. Default: 3
+	MaxRedirects int
+
+	// MaxResponseSize caps the response body in bytes. Default: 5MB
+	MaxResponseSize int64
+
+	// Timeout bounds the whole request, including reading the body. Default: 10s
+	Timeout time.Duration
+
+	// Logger receives blocked attempts. Default: slog.Default()
+	Logger *slog.Logger
+}
+
+// Client is an http.Client wrapper that refuses private, loopback,
+// link-local and other non-public destinations.
+type Client struct {
+	cfg    Config
+	client *http.Client
+}
+
+// New creates a Client, applying defaults to cfg.
+func New(cfg Config) *Client {
+	if len(cfg.AllowedSchemes) == 0 {
+		cfg.AllowedSchemes = []string{"http", "https"}
+	}
+	if len(cfg.AllowedPorts) == 0 {
+		cfg.AllowedPorts = []int{80, 443}
+	}
+	if cfg.MaxRedirects == 0 {
+		cfg.MaxRedirects = 3
+	}
+	if cfg.MaxResponseSize <= 0 {
+		cfg.MaxResponseSize = 5 << 20
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+
+	c := &Client{cfg: cfg}
+	dialer := &net.Dialer{
+		Timeout: cfg.Timeout,
+		// Control runs after DNS resolution, on the address actually dialed
+		Control: func(network, address string, _ syscall.RawConn) error {
+			return c.checkAddress(address)
+		},
+	}
+	c.client = &http.Client{
+		Timeout: cfg.Timeout,
+		Transport: &http.Transport{
+			Proxy:                 nil, // a proxy would dial on our behalf, bypassing the checks
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   cfg.Timeout,
+			ResponseHeaderTimeout: cfg.Timeout,
+			MaxIdleConns:          10,
+			IdleConnTimeout:       30 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if cfg.MaxRedirects < 0 || len(via) > cfg.MaxRedirects {
+				return c.block(req.URL.String(), "too many redirects")
+			}
+			return c.checkURL(req.URL)
+		},
+	}
+	return c
+}
+
+// Get fetches rawURL.
+func (c *Client) Get(ctx context.Context, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req)
+}
+
+// Do validates and sends req. The response body fails with
+// ErrResponseTooLarge once MaxResponseSize bytes have been read.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	if err := c.checkURL(req.URL); err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.ContentLength > c.cfg.MaxResponseSize {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: %w", req.URL.Redacted(), ErrResponseTooLarge)
+	}
+	resp.Body = &limitedBody{ReadCloser: resp.Body, remaining: c.cfg.MaxResponseSize}
+	return resp, nil
+}
+
+// HTTPClient returns the underlying *http.Client for libraries that need one.
+// Destination checks still apply; the response size cap does not.
+func (c *Client) HTTPClient() *http.Client {
+	return c.client
+}
+
+// checkURL validates the scheme, port and literal host of u.
+func (c *Client) checkURL(u *url.URL) error {
+	if !slices.Contains(c.cfg.AllowedSchemes, u.Scheme) {
+		return c.block(u.Redacted(), "scheme "+u.Scheme)
+	}
+	if u.User != nil {
+		return c.block(u.Redacted(), "credentials in URL")
+	}
+	if u.Hostname() == "" {
+		return c.block(u.Redacted(), "missing host")
+	}
+	if err := c.checkPort(u); err != nil {
+		return err
+	}
+	// Literal IPs fail early; hostnames are checked once resolved
+	if addr, err := netip.ParseAddr(u.Hostname()); err == nil && !c.addrAllowed(addr) {
+		return c.block(u.Redacted(), "address "+addr.String())
+	}
+	return nil
+}
+
+// checkPort validates the explicit or scheme-default port of u.
+func (c *Client) checkPort(u *url.URL) error {
+	port := u.Port()
+	if port == "" {
+		if u.Scheme == "https" {
+			port = "443"
+		} else {
+			port = "80"
+		}
+	}
+	n, err := strconv.Atoi(port)
+	if err != nil || !slices.Contains(c.cfg.AllowedPorts, n) {
+		return c.block(u.Redacted(), "port "+port)
+	}
+	return nil
+}
+
+// checkAddress validates a resolved "ip:port" just before it is dialed.
+func (c *Client) checkAddress(address string) error {
+	ap, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return c.block(address, "unparseable address")
+	}
+	if !slices.Contains(c.cfg.AllowedPorts, int(ap.Port())) {
+		return c.block(address, "port "+strconv.Itoa(int(ap.Port())))
+	}
+	if !c.addrAllowed(ap.Addr()) {
+		return c.block(address, "address "+ap.Addr().String())
+	}
+	return nil
+}
+
+// addrAllowed reports whether addr is public or explicitly allowed.
+func (c *Client) addrAllowed(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, p := range c.cfg.AllowedNetworks {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return IsPublic(addr)
+}
+
+// block logs a refused destination and returns an ErrBlocked error.
+func (c *Client) block(target, reason string) error {
+	c.cfg.Logger.Warn("blocked outbound request", "target", target, "reason", reason)
+	return fmt.Errorf("%s (%s): %w", target, reason, ErrBlocked)
+}
+
+// nonPublic lists special-purpose ranges not covered by the netip predicates.
+var nonPublic = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // "this" network
+	netip.MustParsePrefix("100.64.0.0/10"),   // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // documentation
+	netip.MustParsePrefix("198.18.0.0/15"),   // benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // documentation
+	netip.MustParsePrefix("203.0.113.0/24"),  // documentation
+	netip.MustParsePrefix("240.0.0.0/4"),     // reserved, broadcast
+	netip.MustParsePrefix("64:ff9b::/96"),    // NAT64, may embed private IPv4
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // local-use NAT64
+	netip.MustParsePrefix("2001:db8::/32"),   // documentation
+	netip.MustParsePrefix("2002::/16"),       // 6to4, may embed private IPv4
+}
+
+// IsPublic reports whether addr is a globally routable unicast address:
+// not loopback, private, link-local, multicast, unspecified or reserved.
+func IsPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() {
+		return false
+	}
+	for _, p := range nonPublic {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// limitedBody fails reads past the size cap instead of silently truncating.
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (l *limitedBody) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		// Probe for more data so a body of exactly the limit still succeeds
+		var probe [1]byte
+		if n, _ := l.ReadCloser.Read(probe[:]); n > 0 {
+			return 0, ErrResponseTooLarge
+		}
+		return 0, io.EOF
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.ReadCloser.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
@@ -0,0 +1,20 @@
+package cartridge
+
+import (
+	"html/template"
+
+	"github.com/karloscodes/cartridge/sanitize"
+)
+
+// Sanitize returns s with unsafe HTML removed, using the user-generated
+// content policy. Use it before storing or rendering untrusted HTML.
+func (ctx *Context) Sanitize(s string) string {
+	return sanitize.HTML(s)
+}
+
+// Markdown renders untrusted markdown to sanitized HTML, ready to embed in
+// a template or JSON response.
+func (ctx *Context) Markdown(src string) (template.HTML, error) {
+	out, err := sanitize.Markdown(src)
+	return template.HTML(out), err
+}
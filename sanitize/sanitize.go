@@ -0,0 +1,93 @@
+// Package sanitize renders untrusted user content as XSS-safe HTML.
+//
+// Policies are allowlists: anything not explicitly permitted (script tags,
+// event handler attributes, javascript: URLs, ...) is removed.
+package sanitize
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// Policy decides which elements and attributes survive sanitization.
+type Policy struct {
+	p *bluemonday.Policy
+}
+
+// UGC returns a policy for user-generated content: common formatting,
+// links, lists, tables and images are kept; scripts, styles, iframes and
+// event handlers are removed. Links get rel="nofollow noopener".
+func UGC() *Policy {
+	p := bluemonday.UGCPolicy()
+	p.RequireNoFollowOnLinks(true)
+	p.AddTargetBlankToFullyQualifiedLinks(true)
+	return &Policy{p: p}
+}
+
+// Strict returns a policy that removes all HTML, leaving escaped text.
+func Strict() *Policy {
+	return &Policy{p: bluemonday.StrictPolicy()}
+}
+
+// NewPolicy wraps a custom bluemonday policy.
+func NewPolicy(p *bluemonday.Policy) *Policy {
+	return &Policy{p: p}
+}
+
+// Sanitize returns the sanitized HTML.
+func (p *Policy) Sanitize(s string) string {
+	return p.p.Sanitize(s)
+}
+
+// HTML returns the sanitized HTML, marked safe for html/template.
+func (p *Policy) HTML(s string) template.HTML {
+	return template.HTML(p.p.Sanitize(s))
+}
+
+var (
+	ugc    = UGC()
+	strict = Strict()
+
+	markdown = goldmark.New(goldmark.WithExtensions(extension.GFM))
+)
+
+// HTML sanitizes s with the UGC policy.
+func HTML(s string) string {
+	return ugc.Sanitize(s)
+}
+
+// Text removes all HTML from s.
+func Text(s string) string {
+	return strict.Sanitize(s)
+}
+
+// Markdown renders GitHub-flavored markdown and sanitizes the result with
+// the UGC policy, so embedded HTML can't inject scripts.
+func Markdown(src string) (string, error) {
+	var buf bytes.Buffer
+	if err := markdown.Convert([]byte(src), &buf); err != nil {
+		return "", fmt.Errorf("sanitize: render markdown: %w", err)
+	}
+	return ugc.Sanitize(buf.String()), nil
+}
+
+// TemplateFuncs returns the "sanitize" and "markdown" template functions.
+//
+//	{{sanitize .Post.Body}}
+//	{{markdown .Comment.Body}}
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"sanitize": func(s string) template.HTML {
+			return template.HTML(HTML(s))
+		},
+		"markdown": func(s string) (template.HTML, error) {
+			out, err := Markdown(s)
+			return template.HTML(out), err
+		},
+	}
+}
@@ -0,0 +1,92 @@
+package sanitize
+
+import (
+	"bytes"
+	"html/template"
+	"strings"
+	"testing"
+)
+
+func TestHTML(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		keep    []string
+		removed []string
+	}{
+		{
+			name:    "script tag",
+			input:   `<p>hello</p><script>alert(1)</script>`,
+			keep:    []string{"<p>hello</p>"},
+			removed: []string{"<script", "alert(1)"},
+		},
+		{
+			name:    "event handler",
+			input:   `<img src="https://example.com/a.png" onerror="alert(1)">`,
+			keep:    []string{`src="https://example.com/a.png"`},
+			removed: []string{"onerror"},
+		},
+		{
+			name:    "javascript url",
+			input:   `<a href="javascript:alert(1)">click</a>`,
+			keep:    []string{"click"},
+			removed: []string{"javascript:"},
+		},
+		{
+			name:  "formatting and links",
+			input: `<strong>bold</strong> <a href="https://example.com">link</a>`,
+			keep:  []string{"<strong>bold</strong>", `href="https://example.com"`, `rel="nofollow noopener"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := HTML(tt.input)
+			for _, s := range tt.keep {
+				if !strings.Contains(got, s) {
+					t.Errorf("expected %q in %q", s, got)
+				}
+			}
+			for _, s := range tt.removed {
+				if strings.Contains(got, s) {
+					t.Errorf("expected %q to be removed from %q", s, got)
+				}
+			}
+		})
+	}
+}
+
+func TestText(t *testing.T) {
+	if got := Text(`<b>hi</b> <script>x</script>there`); got != "hi there" {
+		t.Errorf("expected %q, got %q", "hi there", got)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	got, err := Markdown("# Title\n\nSome *emphasis* and [a link](javascript:alert(1)).\n\n<script>alert(1)</script>\n")
+	if err != nil {
+		t.Fatalf("Markdown failed: %v", err)
+	}
+	if !strings.Contains(got, "<h1") || !strings.Contains(got, "<em>emphasis</em>") {
+		t.Errorf("expected rendered markdown, got %q", got)
+	}
+	if strings.Contains(got, "javascript:") || strings.Contains(got, "<script") {
+		t.Errorf("expected unsafe content to be removed, got %q", got)
+	}
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	tpl := template.Must(template.New("t").Funcs(TemplateFuncs()).Parse(`{{sanitize .Body}}|{{markdown .Body}}`))
+
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, map[string]string{"Body": "**hi**<script>x</script>"}); err != nil {
+		t.Fatalf("execute failed: %v", err)
+	}
+	got := buf.String()
+	if strings.Contains(got, "<script") {
+		t.Errorf("expected script to be removed, got %q", got)
+	}
+	if !strings.Contains(got, "<strong>hi</strong>") {
+		t.Errorf("expected markdown to render, got %q", got)
+	}
+}
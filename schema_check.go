@@ -0,0 +1,135 @@
+package cartridge
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// SchemaDifference is one way the database differs from a GORM model.
+type SchemaDifference struct {
+	Model  string // Go type, e.g. "*app.User"
+	Table  string
+	Column string // set for a missing column
+	Index  string // set for a missing index
+}
+
+// String describes the difference, e.g. "users: missing column last_seen_at".
+func (d SchemaDifference) String() string {
+	switch {
+	case d.Column != "":
+		return fmt.Sprintf("%s: missing column %s", d.Table, d.Column)
+	case d.Index != "":
+		return fmt.Sprintf("%s: missing index %s", d.Table, d.Index)
+	default:
+		return fmt.Sprintf("%s: missing table", d.Table)
+	}
+}
+
+// SchemaDrift lists the differences between the database and the models it
+// was checked against. Application.CheckSchema returns it as the error that
+// stops startup when SchemaCheckConfig.FailInProduction is set.
+type SchemaDrift []SchemaDifference
+
+// Error joins the differences.
+func (s SchemaDrift) Error() string {
+	msgs := make([]string, len(s))
+	for i, d := range s {
+		msgs[i] = d.String()
+	}
+	return "cartridge: schema drift: " + strings.Join(msgs, "; ")
+}
+
+// SchemaCheckConfig configures the schema check run after PhaseMigrate.
+type SchemaCheckConfig struct {
+	// Models are the GORM models the database should match, e.g. &User{}.
+	Models []any
+
+	// FailInProduction stops startup in production when the schema has
+	// drifted. Default: false (differences are logged as warnings)
+	FailInProduction bool
+}
+
+// DetectSchemaDrift compares db with models and returns the tables,
+// columns and indexes the models define but the database lacks. Extra
+// tables and columns in the database are not reported, so expand
+// migrations ahead of the models don't count as drift.
+func DetectSchemaDrift(db *gorm.DB, models ...any) (SchemaDrift, error) {
+	var drift SchemaDrift
+	migrator := db.Migrator()
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return nil, fmt.Errorf("cartridge: parse model %T: %w", model, err)
+		}
+		diff := SchemaDifference{Model: fmt.Sprintf("%T", model), Table: stmt.Table}
+
+		if !migrator.HasTable(model) {
+			drift = append(drift, diff)
+			continue
+		}
+		for _, name := range stmt.Schema.DBNames {
+			field := stmt.Schema.FieldsByDBName[name]
+			if field.IgnoreMigration || migrator.HasColumn(model, name) {
+				continue
+			}
+			missing := diff
+			missing.Column = name
+			drift = append(drift, missing)
+		}
+		for _, index := range stmt.Schema.ParseIndexes() {
+			if migrator.HasIndex(model, index.Name) {
+				continue
+			}
+			missing := diff
+			missing.Index = index.Name
+			drift = append(drift, missing)
+		}
+	}
+	return drift, nil
+}
+
+// CheckSchema compares the live database with models and logs a warning for
+// each missing table, column or index. It returns the differences found,
+// or an error if the database can't be inspected.
+//
+//	drift, err := app.CheckSchema(&User{}, &Post{})
+//
+// To check at every startup, use WithSchemaCheck or
+// LifecycleConfig.SchemaCheck.
+func (a *Application) CheckSchema(models ...any) (SchemaDrift, error) {
+	if a.DBManager == nil {
+		return nil, errors.New("cartridge: schema check needs a database manager")
+	}
+	db, err := a.DBManager.Connect()
+	if err != nil {
+		return nil, fmt.Errorf("connect database: %w", err)
+	}
+	drift, err := DetectSchemaDrift(db, models...)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range drift {
+		a.Logger.Warn("schema drift", "model", d.Model, "table", d.Table, "column", d.Column, "index", d.Index)
+	}
+	return drift, nil
+}
+
+// runSchemaCheck runs LifecycleConfig.SchemaCheck, failing in production
+// when the schema has drifted and FailInProduction is set.
+func (a *Application) runSchemaCheck() error {
+	check := a.lifecycle.SchemaCheck
+	if check == nil || len(check.Models) == 0 {
+		return nil
+	}
+	drift, err := a.CheckSchema(check.Models...)
+	if err != nil {
+		return err
+	}
+	if len(drift) > 0 && check.FailInProduction && a.Config != nil && a.Config.IsProduction() {
+		return drift
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package cartridge
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/karloscodes/cartridge/config"
+)
+
+type driftUser struct {
+	ID        uint
+	Email     string `gorm:"uniqueIndex"`
+	LastSeen  int64
+	Transient string `gorm:"-:migration"`
+}
+
+type driftPost struct {
+	ID    uint
+	Title string
+}
+
+func TestDetectSchemaDrift(t *testing.T) {
+	db := openAsyncTestDB(t)
+	if err := db.Exec("CREATE TABLE drift_users (id INTEGER PRIMARY KEY, email TEXT)").Error; err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+
+	drift, err := DetectSchemaDrift(db, &driftUser{}, &driftPost{})
+	if err != nil {
+		t.Fatalf("DetectSchemaDrift failed: %v", err)
+	}
+	want := []string{
+		"drift_users: missing column last_seen",
+		"drift_users: missing index idx_drift_users_email",
+		"drift_posts: missing table",
+	}
+	if len(drift) != len(want) {
+		t.Fatalf("expected %v, got %v", want, drift)
+	}
+	for i, d := range drift {
+		if d.String() != want[i] {
+			t.Errorf("difference %d: expected %q, got %q", i, want[i], d.String())
+		}
+	}
+
+	if err := db.AutoMigrate(&driftUser{}, &driftPost{}); err != nil {
+		t.Fatalf("AutoMigrate failed: %v", err)
+	}
+	if drift, err := DetectSchemaDrift(db, &driftUser{}, &driftPost{}); err != nil || len(drift) != 0 {
+		t.Errorf("expected no drift after AutoMigrate, got %v (%v)", drift, err)
+	}
+}
+
+func TestApplication_SchemaCheckFailsInProduction(t *testing.T) {
+	db := openAsyncTestDB(t)
+	check := &SchemaCheckConfig{Models: []any{&driftPost{}}, FailInProduction: true}
+	newApp := func(env string) *Application {
+		return &Application{
+			Config:    &config.Config{Environment: env},
+			Logger:    testLogger(),
+			DBManager: &mockDBManager{db: db},
+			lifecycle: LifecycleConfig{SchemaCheck: check},
+		}
+	}
+
+	if err := newApp(config.Development).runSchemaCheck(); err != nil {
+		t.Errorf("expected drift to only warn in development, got %v", err)
+	}
+	var drift SchemaDrift
+	if err := newApp(config.Production).runSchemaCheck(); !errors.As(err, &drift) || len(drift) != 1 {
+		t.Errorf("expected drift to fail startup in production, got %v", err)
+	}
+}
@@ -0,0 +1,314 @@
+package cartridge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/karloscodes/cartridge/config"
+)
+
+// EnvSecrets reads secrets from environment variables. The secret
+// "db_password" is read from DB_PASSWORD, or MYAPP_DB_PASSWORD with Prefix
+// "MYAPP".
+type EnvSecrets struct {
+	Prefix string
+}
+
+// Secret implements config.SecretsProvider.
+func (e EnvSecrets) Secret(_ context.Context, name string) (string, error) {
+	key := strings.ToUpper(strings.NewReplacer(".", "_", "-", "_", "/", "_").Replace(name))
+	if e.Prefix != "" {
+		key = strings.ToUpper(e.Prefix) + "_" + key
+	}
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return "", fmt.Errorf("cartridge: %s: %w", key, config.ErrSecretNotFound)
+	}
+	return value, nil
+}
+
+// FileSecrets reads each secret from a file named after it, as Docker and
+// Kubernetes mount them. A trailing newline is dropped.
+type FileSecrets struct {
+	// Dir holds the secret files. Default: "/run/secrets"
+	Dir string
+}
+
+// Secret implements config.SecretsProvider.
+func (f FileSecrets) Secret(_ context.Context, name string) (string, error) {
+	if !filepath.IsLocal(name) {
+		return "", fmt.Errorf("cartridge: invalid secret name %q", name)
+	}
+	dir := f.Dir
+	if dir == "" {
+		dir = "/run/secrets"
+	}
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("cartridge: %s: %w", name, config.ErrSecretNotFound)
+	}
+	if err != nil {
+		return "", fmt.Errorf("cartridge: read secret: %w", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// VaultConfig configures VaultSecrets.
+type VaultConfig struct {
+	// Address is the Vault server URL. Default: $VAULT_ADDR
+	Address string
+
+	// Token authenticates requests. Default: $VAULT_TOKEN
+	Token string
+
+	// Namespace is the Vault Enterprise namespace. Default: $VAULT_NAMESPACE
+	Namespace string
+
+	// Mount is the KV version 2 secrets engine's path. Default: "secret"
+	Mount string
+
+	// HTTPClient sends requests. Default: a client with a 10s timeout.
+	HTTPClient *http.Client
+}
+
+// VaultSecrets reads secrets from a HashiCorp Vault KV version 2 engine.
+// The secret "myapp/db#password" is the password key of the myapp/db
+// secret; without a key, the "value" key is read.
+type VaultSecrets struct {
+	cfg VaultConfig
+}
+
+// NewVaultSecrets creates a Vault secrets provider.
+func NewVaultSecrets(cfg VaultConfig) (*VaultSecrets, error) {
+	if cfg.Address == "" {
+		cfg.Address = os.Getenv("VAULT_ADDR")
+	}
+	if cfg.Token == "" {
+		cfg.Token = os.Getenv("VAULT_TOKEN")
+	}
+	if cfg.Namespace == "" {
+		cfg.Namespace = os.Getenv("VAULT_NAMESPACE")
+	}
+	if cfg.Address == "" || cfg.Token == "" {
+		return nil, fmt.Errorf("cartridge: vault address and token are required")
+	}
+	if cfg.Mount == "" {
+		cfg.Mount = "secret"
+	}
+	cfg.Address = strings.TrimRight(cfg.Address, "/")
+	cfg.Mount = strings.Trim(cfg.Mount, "/")
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &VaultSecrets{cfg: cfg}, nil
+}
+
+// Secret implements config.SecretsProvider.
+func (v *VaultSecrets) Secret(ctx context.Context, name string) (string, error) {
+	path, key, ok := strings.Cut(name, "#")
+	if !ok {
+		key = "value"
+	}
+	endpoint := v.cfg.Address + "/v1/" + v.cfg.Mount + "/data/" + s3EscapePath(strings.Trim(path, "/"))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", v.cfg.Token)
+	if v.cfg.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.cfg.Namespace)
+	}
+
+	var body struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	if err := doSecretsRequest(v.cfg.HTTPClient, req, "vault", name, &body); err != nil {
+		return "", err
+	}
+	return secretField(body.Data.Data, name, key)
+}
+
+// AWSSecretsConfig configures AWSSecrets.
+type AWSSecretsConfig struct {
+	// Region hosts the secrets. Default: $AWS_REGION, then $AWS_DEFAULT_REGION
+	Region string
+
+	// AccessKey, SecretKey and SessionToken are the credentials. Default:
+	// $AWS_ACCESS_KEY_ID, $AWS_SECRET_ACCESS_KEY and $AWS_SESSION_TOKEN
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+
+	// Endpoint is the service URL. Default:
+	// "https://secretsmanager.<region>.amazonaws.com"
+	Endpoint string
+
+	// HTTPClient sends requests. Default: a client with a 10s timeout.
+	HTTPClient *http.Client
+}
+
+// AWSSecrets reads secrets from AWS Secrets Manager. The secret
+// "prod/myapp" is the secret string of prod/myapp; "prod/myapp#password"
+// is the password key of a JSON secret string.
+type AWSSecrets struct {
+	cfg AWSSecretsConfig
+}
+
+// NewAWSSecrets creates an AWS Secrets Manager provider.
+func NewAWSSecrets(cfg AWSSecretsConfig) (*AWSSecrets, error) {
+	if cfg.Region == "" {
+		cfg.Region = os.Getenv("AWS_REGION")
+	}
+	if cfg.Region == "" {
+		cfg.Region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if cfg.AccessKey == "" && cfg.SecretKey == "" {
+		cfg.AccessKey = os.Getenv("AWS_ACCESS_KEY_ID")
+		cfg.SecretKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		if cfg.SessionToken == "" {
+			cfg.SessionToken = os.Getenv("AWS_SESSION_TOKEN")
+		}
+	}
+	if cfg.Region == "" {
+		return nil, fmt.Errorf("cartridge: aws secrets region is required")
+	}
+	if cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, fmt.Errorf("cartridge: aws secrets credentials are required")
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://secretsmanager." + cfg.Region + ".amazonaws.com"
+	}
+	cfg.Endpoint = strings.TrimRight(cfg.Endpoint, "/")
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &AWSSecrets{cfg: cfg}, nil
+}
+
+// Secret implements config.SecretsProvider.
+func (a *AWSSecrets) Secret(ctx context.Context, name string) (string, error) {
+	id, key, hasKey := strings.Cut(name, "#")
+	payload, err := json.Marshal(map[string]string{"SecretId": id})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.cfg.Endpoint+"/", bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	signAWSRequest(req, time.Now().UTC(), awsCredentials{
+		region:       a.cfg.Region,
+		accessKey:    a.cfg.AccessKey,
+		secretKey:    a.cfg.SecretKey,
+		sessionToken: a.cfg.SessionToken,
+	}, "secretsmanager", sha256Hex(payload))
+
+	var body struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err := doSecretsRequest(a.cfg.HTTPClient, req, "aws secrets", name, &body); err != nil {
+		return "", err
+	}
+	if !hasKey {
+		return body.SecretString, nil
+	}
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(body.SecretString), &fields); err != nil {
+		return "", fmt.Errorf("cartridge: aws secret %q is not JSON: %w", id, err)
+	}
+	return secretField(fields, name, key)
+}
+
+// doSecretsRequest sends req and decodes the JSON response into out. A 404,
+// or Secrets Manager's ResourceNotFoundException, is ErrSecretNotFound.
+func doSecretsRequest(client *http.Client, req *http.Request, service, name string, out any) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cartridge: %s: %w", service, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("cartridge: %s: %w", service, err)
+	}
+	if resp.StatusCode == http.StatusNotFound || bytes.Contains(body, []byte("ResourceNotFoundException")) {
+		return fmt.Errorf("cartridge: %s: %s: %w", service, name, config.ErrSecretNotFound)
+	}
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		if len(body) > 1024 {
+			body = body[:1024]
+		}
+		return fmt.Errorf("cartridge: %s returned %d: %s", service, resp.StatusCode, body)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("cartridge: %s: decode response: %w", service, err)
+	}
+	return nil
+}
+
+// secretField returns key from a secret's fields. Values that aren't
+// strings are returned as JSON.
+func secretField(fields map[string]any, name, key string) (string, error) {
+	value, ok := fields[key]
+	if !ok {
+		return "", fmt.Errorf("cartridge: %s: %w", name, config.ErrSecretNotFound)
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("cartridge: %s: %w", name, err)
+	}
+	return string(data), nil
+}
+
+// WithSecrets resolves the ${secret:NAME} references in the config with p
+// when the app starts (see config.Config.ResolveSecrets):
+//
+//	vault, err := cartridge.NewVaultSecrets(cartridge.VaultConfig{})
+//	app, err := cartridge.NewSSRApp("myapp", cartridge.WithSecrets(vault))
+//
+// with MYAPP_SESSION_SECRET=${secret:myapp/session#secret}. Config reloads
+// fetch the secrets again (see WithSecretsRotation).
+func WithSecrets(p config.SecretsProvider) AppOption {
+	return func(c *appConfig) {
+		c.secrets = p
+	}
+}
+
+// WithSecretsRotation reloads the config every interval, fetching the
+// secrets again, so rotated secrets reach app.CurrentConfig and
+// app.OnConfigChange callbacks. Values copied at startup, such as the
+// session secret and database URL, keep their old value until a restart.
+func WithSecretsRotation(interval time.Duration) AppOption {
+	return func(c *appConfig) {
+		c.reloadConfig = true
+		c.reloadEvery = interval
+	}
+}
+
+// secretsTimeout bounds fetching the secrets on startup and reload.
+const secretsTimeout = 30 * time.Second
+
+// resolveSecrets fetches the secrets referenced by cfg with p.
+func resolveSecrets(cfg *config.Config, p config.SecretsProvider) error {
+	ctx, cancel := context.WithTimeout(context.Background(), secretsTimeout)
+	defer cancel()
+	if err := cfg.ResolveSecrets(ctx, p); err != nil {
+		return fmt.Errorf("resolve secrets: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package cartridge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/karloscodes/cartridge/config"
+)
+
+func TestEnvAndFileSecrets(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("MYAPP_DB_PASSWORD", "from-env")
+	if got, err := (EnvSecrets{Prefix: "myapp"}).Secret(ctx, "db.password"); err != nil || got != "from-env" {
+		t.Errorf("expected the env secret, got %q %v", got, err)
+	}
+	if _, err := (EnvSecrets{}).Secret(ctx, "db_password"); !errors.Is(err, config.ErrSecretNotFound) {
+		t.Errorf("expected ErrSecretNotFound, got %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "session_secret"), []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	files := FileSecrets{Dir: dir}
+	if got, err := files.Secret(ctx, "session_secret"); err != nil || got != "from-file" {
+		t.Errorf("expected the file secret without its newline, got %q %v", got, err)
+	}
+	if _, err := files.Secret(ctx, "missing"); !errors.Is(err, config.ErrSecretNotFound) {
+		t.Errorf("expected ErrSecretNotFound, got %v", err)
+	}
+	if _, err := files.Secret(ctx, "../etc/passwd"); err == nil {
+		t.Error("expected names outside the directory to be rejected")
+	}
+}
+
+func TestVaultSecrets(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Path != "/v1/kv/data/myapp/db" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data": {"data": {"password": "pw", "value": "default", "port": 5432}}}`))
+	}))
+	defer api.Close()
+
+	vault, err := NewVaultSecrets(VaultConfig{Address: api.URL + "/", Token: "root", Mount: "kv"})
+	if err != nil {
+		t.Fatalf("NewVaultSecrets failed: %v", err)
+	}
+	ctx := context.Background()
+
+	tests := map[string]string{"myapp/db#password": "pw", "myapp/db": "default", "myapp/db#port": "5432"}
+	for name, want := range tests {
+		if got, err := vault.Secret(ctx, name); err != nil || got != want {
+			t.Errorf("%s: expected %q, got %q %v", name, want, got, err)
+		}
+	}
+	for _, name := range []string{"myapp/db#user", "other/db#password"} {
+		if _, err := vault.Secret(ctx, name); !errors.Is(err, config.ErrSecretNotFound) {
+			t.Errorf("%s: expected ErrSecretNotFound, got %v", name, err)
+		}
+	}
+
+	t.Setenv("VAULT_ADDR", "")
+	t.Setenv("VAULT_TOKEN", "")
+	if _, err := NewVaultSecrets(VaultConfig{}); err == nil {
+		t.Error("expected an error without an address and token")
+	}
+}
+
+func TestAWSSecrets(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/") || !strings.Contains(auth, "/eu-west-1/secretsmanager/aws4_request") ||
+			r.Header.Get("X-Amz-Security-Token") != "session" || r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		var req struct{ SecretId string }
+		_ = json.Unmarshal(body, &req)
+		switch req.SecretId {
+		case "prod/token":
+			_, _ = w.Write([]byte(`{"SecretString": "tok"}`))
+		case "prod/db":
+			_, _ = w.Write([]byte(`{"SecretString": "{\"password\": \"pw\"}"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type": "ResourceNotFoundException"}`))
+		}
+	}))
+	defer api.Close()
+
+	aws, err := NewAWSSecrets(AWSSecretsConfig{
+		Region:       "eu-west-1",
+		AccessKey:    "AKID",
+		SecretKey:    "secret",
+		SessionToken: "session",
+		Endpoint:     api.URL,
+	})
+	if err != nil {
+		t.Fatalf("NewAWSSecrets failed: %v", err)
+	}
+	ctx := context.Background()
+
+	if got, err := aws.Secret(ctx, "prod/token"); err != nil || got != "tok" {
+		t.Errorf("expected the secret string, got %q %v", got, err)
+	}
+	if got, err := aws.Secret(ctx, "prod/db#password"); err != nil || got != "pw" {
+		t.Errorf("expected the JSON key, got %q %v", got, err)
+	}
+	if _, err := aws.Secret(ctx, "prod/token#password"); err == nil {
+		t.Error("expected an error for a key in a plain secret string")
+	}
+	if _, err := aws.Secret(ctx, "prod/missing"); !errors.Is(err, config.ErrSecretNotFound) {
+		t.Errorf("expected ErrSecretNotFound, got %v", err)
+	}
+}
+
+func TestInertiaWithSecrets(t *testing.T) {
+	t.Setenv("INERTIASECRETS_ENV", "development")
+	t.Setenv("INERTIASECRETS_PORT", "${secret:port}")
+	t.Setenv("VAULT_PORT", "4000")
+	cfg, err := config.Load("inertiasecrets")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	app, err := NewInertiaApp(
+		InertiaWithConfig(cfg),
+		InertiaWithSecrets(EnvSecrets{Prefix: "VAULT"}),
+		InertiaWithDBManager(&testDBManager{}),
+	)
+	if err != nil {
+		t.Fatalf("NewInertiaApp failed: %v", err)
+	}
+	if got := app.Config.(*config.Config).Port; got != "4000" {
+		t.Errorf("expected the port from the secrets provider, got %q", got)
+	}
+
+	t.Setenv("INERTIASECRETS_PORT", "${secret:missing}")
+	cfg, err = config.Load("inertiasecrets")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if _, err := NewInertiaApp(InertiaWithConfig(cfg), InertiaWithSecrets(EnvSecrets{Prefix: "VAULT"}), InertiaWithDBManager(&testDBManager{})); !errors.Is(err, config.ErrSecretNotFound) {
+		t.Errorf("expected ErrSecretNotFound for a missing secret, got %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+package cartridge
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/karloscodes/cartridge/config"
+	cartridgemiddleware "github.com/karloscodes/cartridge/middleware"
+)
+
+// DefaultSecurityReportsPath receives violation reports when
+// SecurityReportsConfig.Path is empty.
+const DefaultSecurityReportsPath = "/_security-reports"
+
+// SecurityReportsConfig configures the endpoint collecting CSP and
+// Permissions-Policy violation reports. Every security header preset
+// reports to it.
+type SecurityReportsConfig struct {
+	// Path receives the reports. Default: DefaultSecurityReportsPath
+	Path string
+
+	// OnReport is called for each report, e.g. to count violations per
+	// directive before enforcing a policy. Default: logs a warning
+	OnReport func(ctx *Context, report cartridgemiddleware.SecurityReport)
+}
+
+func (c *SecurityReportsConfig) path() string {
+	if c.Path == "" {
+		return DefaultSecurityReportsPath
+	}
+	return c.Path
+}
+
+// reportOnlyIn returns whether report-only headers apply to cfg's
+// environment: one of environments, or any when none are given.
+func reportOnlyIn(environments []string) func(cfg Config) bool {
+	return func(cfg Config) bool {
+		if len(environments) == 0 {
+			return true
+		}
+		env := config.Test
+		switch {
+		case cfg.IsDevelopment():
+			env = config.Development
+		case cfg.IsProduction():
+			env = config.Production
+		}
+		return slices.Contains(environments, env)
+	}
+}
+
+// headerPreset returns the security header preset named name, from
+// ServerConfig.SecurityHeaderPresets or the built-in ones, with the
+// server's report-only and report settings applied.
+func headerPreset(cfg *ServerConfig, name string) (cartridgemiddleware.SecurityHeaders, error) {
+	headers, ok := cfg.SecurityHeaderPresets[name]
+	if !ok {
+		headers, ok = cartridgemiddleware.SecurityHeadersPreset(name)
+	}
+	if !ok {
+		return headers, fmt.Errorf("cartridge: unknown security header preset %q", name)
+	}
+	return withSecurityReporting(cfg, headers), nil
+}
+
+// withSecurityReporting applies ServerConfig.SecurityReportOnly and
+// SecurityReports to headers.
+func withSecurityReporting(cfg *ServerConfig, headers cartridgemiddleware.SecurityHeaders) cartridgemiddleware.SecurityHeaders {
+	if cfg.SecurityReportOnly {
+		headers.CSPReportOnly = true
+		headers.PermissionsPolicyReportOnly = true
+	}
+	if cfg.SecurityReports != nil && headers.ReportURI == "" {
+		headers.ReportURI = cfg.SecurityReports.path()
+	}
+	return headers
+}
+
+// helmet returns the app-wide security headers middleware. Requests under
+// a ServerConfig.HeaderPresets prefix get that preset instead.
+func (s *Server) helmet() (fiber.Handler, error) {
+	headers := cartridgemiddleware.DefaultSecurityHeaders()
+	if s.cfg.SecurityHeaders != nil {
+		headers = *s.cfg.SecurityHeaders
+	}
+	fallback := cartridgemiddleware.Helmet(withSecurityReporting(s.cfg, headers))
+	if len(s.cfg.HeaderPresets) == 0 {
+		return fallback, nil
+	}
+
+	type prefixPreset struct {
+		prefix  string
+		handler fiber.Handler
+	}
+	presets := make([]prefixPreset, 0, len(s.cfg.HeaderPresets))
+	for prefix, name := range s.cfg.HeaderPresets {
+		headers, err := headerPreset(s.cfg, name)
+		if err != nil {
+			return nil, err
+		}
+		presets = append(presets, prefixPreset{strings.TrimSuffix(prefix, "/"), cartridgemiddleware.Helmet(headers)})
+	}
+	// Longest prefix first
+	slices.SortFunc(presets, func(a, b prefixPreset) int {
+		return len(b.prefix) - len(a.prefix)
+	})
+
+	return func(c *fiber.Ctx) error {
+		path := c.Path()
+		for _, preset := range presets {
+			if path == preset.prefix || strings.HasPrefix(path, preset.prefix+"/") {
+				return preset.handler(c)
+			}
+		}
+		return fallback(c)
+	}, nil
+}
+
+// routeHeaders returns the Helmet middleware for RouteConfig.Headers.
+func (s *Server) routeHeaders(path, name string) fiber.Handler {
+	headers, err := headerPreset(s.cfg, name)
+	if err != nil {
+		panic(fmt.Sprintf("%v (route %s)", err, path))
+	}
+	return cartridgemiddleware.Helmet(headers)
+}
+
+// mountSecurityReports registers the violation report endpoint.
+func (s *Server) mountSecurityReports() {
+	cfg := s.cfg.SecurityReports
+	onReport := cfg.OnReport
+	if onReport == nil {
+		onReport = func(ctx *Context, report cartridgemiddleware.SecurityReport) {
+			ctx.Logger.Warn("security policy violation",
+				"type", report.Type,
+				"directive", report.Directive,
+				"blocked_url", report.BlockedURL,
+				"document_url", report.DocumentURL,
+				"disposition", report.Disposition,
+			)
+		}
+	}
+	handler := cartridgemiddleware.SecurityReportHandler(func(c *fiber.Ctx, report cartridgemiddleware.SecurityReport) {
+		onReport(s.context(c), report)
+	})
+
+	// Browsers send reports without CSRF tokens, and from report-only pages
+	s.Post(cfg.path(), func(ctx *Context) error {
+		return handler(ctx.Ctx)
+	}, &RouteConfig{
+		Name:               "security_reports",
+		EnableSecFetchSite: Bool(false),
+		EnableCSRF:         Bool(false),
+	})
+}
@@ -0,0 +1,109 @@
+package cartridge
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	cartridgemiddleware "github.com/karloscodes/cartridge/middleware"
+)
+
+func newSecurityHeadersTestServer(t *testing.T, adjust func(cfg *ServerConfig)) *Server {
+	t.Helper()
+	cfg := DefaultServerConfig()
+	cfg.EnableStaticAssets = false
+	cfg.EnableRequestLogger = false
+	cfg.EnableSecFetchSite = false
+	cfg.Config = &testConfig{}
+	cfg.Logger = testLogger()
+	cfg.DBManager = &testDBManager{}
+	cfg.ErrorHandler = DefaultErrorHandler(testLogger(), false)
+	adjust(cfg)
+	srv, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	return srv
+}
+
+func TestServer_HeaderPresets(t *testing.T) {
+	srv := newSecurityHeadersTestServer(t, func(cfg *ServerConfig) {
+		cfg.HeaderPresets = map[string]string{"/api": cartridgemiddleware.PresetAPIOnly}
+		cfg.SecurityHeaderPresets = map[string]cartridgemiddleware.SecurityHeaders{
+			"partner": {XFrameOptions: "", ContentSecurityPolicy: "frame-ancestors https://partner.example"},
+		}
+	})
+	ok := func(ctx *Context) error { return ctx.SendString("ok") }
+	srv.Get("/", ok)
+	srv.Get("/api/orders", ok)
+	srv.Get("/apiary", ok)
+	srv.Get("/widget", ok, &RouteConfig{Headers: "partner"})
+
+	get := func(path string) (frame, csp string) {
+		t.Helper()
+		resp, err := srv.App().Test(httptest.NewRequest("GET", path, nil))
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		return resp.Header.Get("X-Frame-Options"), resp.Header.Get("Content-Security-Policy")
+	}
+
+	if frame, csp := get("/"); frame != "SAMEORIGIN" || csp != "" {
+		t.Errorf("expected the default headers, got %q %q", frame, csp)
+	}
+	if frame, csp := get("/api/orders"); frame != "DENY" || !strings.HasPrefix(csp, "default-src 'none'") {
+		t.Errorf("expected the api-only preset under /api, got %q %q", frame, csp)
+	}
+	if frame, _ := get("/apiary"); frame != "SAMEORIGIN" {
+		t.Errorf("expected /apiary not to match the /api prefix, got %q", frame)
+	}
+	if frame, csp := get("/widget"); frame != "" || csp != "frame-ancestors https://partner.example" {
+		t.Errorf("expected the route's preset to replace the app's headers, got %q %q", frame, csp)
+	}
+
+	cfg := DefaultServerConfig()
+	cfg.Config = &testConfig{}
+	cfg.Logger = testLogger()
+	cfg.DBManager = &testDBManager{}
+	cfg.HeaderPresets = map[string]string{"/": "lenient"}
+	if _, err := NewServer(cfg); err == nil || !strings.Contains(err.Error(), `unknown security header preset "lenient"`) {
+		t.Errorf("expected an unknown preset to be rejected, got %v", err)
+	}
+}
+
+func TestServer_SecurityReports(t *testing.T) {
+	var reports []cartridgemiddleware.SecurityReport
+	srv := newSecurityHeadersTestServer(t, func(cfg *ServerConfig) {
+		headers := cartridgemiddleware.StrictSecurityHeaders()
+		cfg.SecurityHeaders = &headers
+		cfg.SecurityReportOnly = true
+		cfg.SecurityReports = &SecurityReportsConfig{
+			OnReport: func(ctx *Context, report cartridgemiddleware.SecurityReport) {
+				reports = append(reports, report)
+			},
+		}
+	})
+	srv.Get("/", func(ctx *Context) error { return ctx.SendString("ok") })
+
+	resp, err := srv.App().Test(httptest.NewRequest("GET", "/", nil))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if csp := resp.Header.Get("Content-Security-Policy-Report-Only"); !strings.Contains(csp, "report-uri "+DefaultSecurityReportsPath) {
+		t.Errorf("expected a report-only CSP reporting to the endpoint, got %q", csp)
+	}
+	if resp.Header.Get("Content-Security-Policy") != "" || resp.Header.Get("Permissions-Policy-Report-Only") == "" {
+		t.Error("expected the policies to be sent as report-only")
+	}
+
+	req := httptest.NewRequest("POST", DefaultSecurityReportsPath, strings.NewReader(
+		`[{"type": "csp-violation", "url": "https://example.com/", "body": {"effectiveDirective": "script-src-elem", "blockedURL": "inline", "disposition": "report"}}]`))
+	req.Header.Set("Content-Type", "application/reports+json")
+	resp, err = srv.App().Test(req)
+	if err != nil || resp.StatusCode != 204 {
+		t.Fatalf("expected 204 for a report, got %v %v", resp.StatusCode, err)
+	}
+	if len(reports) != 1 || reports[0].Directive != "script-src-elem" {
+		t.Errorf("expected the report to reach OnReport, got %+v", reports)
+	}
+}
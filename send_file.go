@@ -0,0 +1,234 @@
+package cartridge
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	cartridgemiddleware "github.com/karloscodes/cartridge/middleware"
+)
+
+// SendFileOptions configures ctx.SendFileRange.
+type SendFileOptions struct {
+	// ContentType overrides the type from GetContentType.
+	ContentType string
+
+	// Download sends the file as an attachment with this name, so browsers
+	// save it instead of displaying it. Default: "" (inline)
+	Download string
+
+	// ETag sends a strong ETag from the file's size and modification time,
+	// for If-None-Match and If-Range. Files without a modification time,
+	// such as embedded ones, get none. Default: false
+	ETag bool
+}
+
+// SendFileRange serves a file that clients can download in parts and
+// resume, e.g. exports and media. file is a path or an fs.File, which
+// SendFileRange closes:
+//
+//	s.Get("/exports/:id", func(ctx *cartridge.Context) error {
+//	    return ctx.SendFileRange(exportPath(ctx.Params("id")), cartridge.SendFileOptions{
+//	        Download: "export.csv",
+//	        ETag:     true,
+//	    })
+//	})
+//
+// A single byte range in the Range header gets 206 with that part, unless
+// If-Range names an older version of the file; unsatisfiable ranges get
+// 416. Multiple ranges are answered with the whole file. If-None-Match and
+// If-Modified-Since get 304. An fs.File that can't seek is always sent
+// whole. A missing path returns a 404 Error.
+func (ctx *Context) SendFileRange(file any, opts ...SendFileOptions) error {
+	var o SendFileOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+
+	f, err := openSendFile(file)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("cartridge: stat file: %w", err)
+	}
+	if info.IsDir() {
+		f.Close()
+		return ErrNotFound("file")
+	}
+	size := info.Size()
+	modTime := info.ModTime().UTC().Truncate(time.Second)
+	seeker, seekable := f.(io.ReadSeeker)
+
+	contentType := o.ContentType
+	if contentType == "" {
+		contentType = GetContentType(info.Name(), seeker)
+	}
+	etag := ""
+	if !modTime.IsZero() {
+		ctx.Set(fiber.HeaderLastModified, modTime.Format(http.TimeFormat))
+		if o.ETag {
+			etag = `"` + strconv.FormatInt(size, 16) + "-" + strconv.FormatInt(modTime.Unix(), 16) + `"`
+			ctx.Set(fiber.HeaderETag, etag)
+		}
+	}
+	if seekable {
+		ctx.Set(fiber.HeaderAcceptRanges, "bytes")
+	}
+
+	if cartridgemiddleware.NotModified(ctx.Ctx, etag, modTime) {
+		f.Close()
+		return ctx.SendStatus(fiber.StatusNotModified)
+	}
+
+	start, length := int64(0), size
+	ctx.Status(fiber.StatusOK)
+	if header := ctx.Get(fiber.HeaderRange); header != "" && seekable && ifRangeMatches(ctx.Ctx, etag, modTime) &&
+		(ctx.Method() == fiber.MethodGet || ctx.Method() == fiber.MethodHead) {
+		first, last, err := parseByteRange(header, size)
+		switch {
+		case errors.Is(err, errRangeUnsatisfiable):
+			f.Close()
+			ctx.Set(fiber.HeaderContentRange, "bytes */"+strconv.FormatInt(size, 10))
+			return NewError(fiber.StatusRequestedRangeNotSatisfiable, "requested range not satisfiable")
+		case err == nil:
+			start, length = first, last-first+1
+			ctx.Set(fiber.HeaderContentRange, fmt.Sprintf("bytes %d-%d/%d", first, last, size))
+			ctx.Status(fiber.StatusPartialContent)
+		}
+	}
+	if start > 0 {
+		if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+			f.Close()
+			return fmt.Errorf("cartridge: seek file: %w", err)
+		}
+	}
+
+	if o.Download != "" {
+		ctx.Attachment(o.Download)
+	}
+	ctx.Set(fiber.HeaderContentType, contentType)
+	ctx.Response().SetBodyStream(&fileBody{Reader: io.LimitReader(f, length), Closer: f}, int(length))
+	return nil
+}
+
+// fileBody streams part of a file and closes it when the response is sent.
+type fileBody struct {
+	io.Reader
+	io.Closer
+}
+
+// openSendFile opens a SendFileRange argument.
+func openSendFile(file any) (fs.File, error) {
+	switch file := file.(type) {
+	case string:
+		f, err := os.Open(file)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotFound("file")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cartridge: open file: %w", err)
+		}
+		return f, nil
+	case fs.File:
+		return file, nil
+	default:
+		return nil, fmt.Errorf("cartridge: SendFileRange takes a path or an fs.File, not %T", file)
+	}
+}
+
+// GetContentType returns the media type of a file from its name's
+// extension or, for unknown extensions, by sniffing the first 512 bytes of
+// content, which is rewound afterwards. content may be nil. Default:
+// "application/octet-stream"
+func GetContentType(name string, content io.ReadSeeker) string {
+	if contentType := mime.TypeByExtension(path.Ext(name)); contentType != "" {
+		return contentType
+	}
+	if content == nil {
+		return fiber.MIMEOctetStream
+	}
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(content, head)
+	if _, err := content.Seek(0, io.SeekStart); err != nil || n == 0 {
+		return fiber.MIMEOctetStream
+	}
+	return http.DetectContentType(head[:n])
+}
+
+// errRangeUnsatisfiable is a byte range entirely past the end of the file.
+var errRangeUnsatisfiable = errors.New("cartridge: range not satisfiable")
+
+// parseByteRange parses a Range header holding one byte range and returns
+// its first and last byte, clamped to size. Headers it can't serve, such as
+// multiple ranges or other units, return an error so the whole file is sent.
+func parseByteRange(header string, size int64) (first, last int64, err error) {
+	errIgnored := errors.New("cartridge: unsupported range " + header)
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok || strings.Contains(spec, ",") {
+		return 0, 0, errIgnored
+	}
+	from, to, ok := strings.Cut(strings.TrimSpace(spec), "-")
+	if !ok {
+		return 0, 0, errIgnored
+	}
+
+	if from == "" {
+		// bytes=-n is the last n bytes
+		n, err := strconv.ParseInt(to, 10, 64)
+		if err != nil || n < 0 {
+			return 0, 0, errIgnored
+		}
+		if n == 0 || size == 0 {
+			return 0, 0, errRangeUnsatisfiable
+		}
+		return size - min(n, size), size - 1, nil
+	}
+
+	first, err = strconv.ParseInt(from, 10, 64)
+	if err != nil || first < 0 {
+		return 0, 0, errIgnored
+	}
+	if first >= size {
+		return 0, 0, errRangeUnsatisfiable
+	}
+	last = size - 1
+	if to != "" {
+		end, err := strconv.ParseInt(to, 10, 64)
+		if err != nil || end < first {
+			return 0, 0, errIgnored
+		}
+		last = min(end, last)
+	}
+	return first, last, nil
+}
+
+// ifRangeMatches reports whether a Range request may be served in part: it
+// has no If-Range, or If-Range names the current version of the file by its
+// strong ETag or exact Last-Modified date.
+func ifRangeMatches(c *fiber.Ctx, etag string, modTime time.Time) bool {
+	ifRange := c.Get(fiber.HeaderIfRange)
+	switch {
+	case ifRange == "":
+		return true
+	case strings.HasPrefix(ifRange, `"`):
+		return etag != "" && ifRange == etag
+	case strings.HasPrefix(ifRange, "W/"):
+		// Weak tags can't vouch for byte-identical content
+		return false
+	}
+	t, err := http.ParseTime(ifRange)
+	return err == nil && !modTime.IsZero() && t.Equal(modTime)
+}
@@ -0,0 +1,113 @@
+package cartridge
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestContext_SendFileRange(t *testing.T) {
+	content := "0123456789abcdefghijklmnopqrstuvwxyz"
+	dir := t.TempDir()
+	file := filepath.Join(dir, "export.csv")
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	modified := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(file, modified, modified); err != nil {
+		t.Fatal(err)
+	}
+	lastModified := modified.Format(http.TimeFormat)
+
+	srv := newTemplateTestServer(t, nil)
+	srv.Get("/export", func(ctx *Context) error {
+		return ctx.SendFileRange(file, SendFileOptions{ETag: true, Download: "report.csv"})
+	})
+	srv.Get("/missing", func(ctx *Context) error {
+		return ctx.SendFileRange(filepath.Join(dir, "missing.csv"))
+	})
+	embedded := fstest.MapFS{"logo": {Data: []byte("\x89PNG\r\n\x1a\n")}}
+	srv.Get("/logo", func(ctx *Context) error {
+		f, err := embedded.Open("logo")
+		if err != nil {
+			return err
+		}
+		return ctx.SendFileRange(f)
+	})
+
+	get := func(path string, headers map[string]string) (*http.Response, string) {
+		t.Helper()
+		req := httpGet(path)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		resp, err := srv.App().Test(req, -1)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		return resp, string(body)
+	}
+
+	resp, body := get("/export", nil)
+	etag := resp.Header.Get("ETag")
+	if resp.StatusCode != fiber.StatusOK || body != content || resp.Header.Get("Accept-Ranges") != "bytes" || etag == "" {
+		t.Fatalf("expected the whole file with range support, got %d %q %v", resp.StatusCode, body, resp.Header)
+	}
+	if got := resp.Header.Get("Content-Type"); got != "text/csv; charset=utf-8" {
+		t.Errorf("expected the type from the extension, got %q", got)
+	}
+	if got := resp.Header.Get("Content-Disposition"); got != `attachment; filename="report.csv"` {
+		t.Errorf("expected an attachment, got %q", got)
+	}
+	if got := resp.Header.Get("Last-Modified"); got != lastModified {
+		t.Errorf("expected Last-Modified %q, got %q", lastModified, got)
+	}
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		status  int
+		body    string
+		rng     string
+	}{
+		{"range", map[string]string{"Range": "bytes=10-15"}, fiber.StatusPartialContent, "abcdef", "bytes 10-15/36"},
+		{"open range", map[string]string{"Range": "bytes=30-"}, fiber.StatusPartialContent, "uvwxyz", "bytes 30-35/36"},
+		{"suffix", map[string]string{"Range": "bytes=-4"}, fiber.StatusPartialContent, "wxyz", "bytes 32-35/36"},
+		{"clamped", map[string]string{"Range": "bytes=34-100"}, fiber.StatusPartialContent, "yz", "bytes 34-35/36"},
+		{"multiple ranges", map[string]string{"Range": "bytes=0-1,4-5"}, fiber.StatusOK, content, ""},
+		{"unsatisfiable", map[string]string{"Range": "bytes=36-"}, fiber.StatusRequestedRangeNotSatisfiable, "", "bytes */36"},
+		{"if-range etag", map[string]string{"Range": "bytes=0-3", "If-Range": etag}, fiber.StatusPartialContent, "0123", "bytes 0-3/36"},
+		{"if-range date", map[string]string{"Range": "bytes=0-3", "If-Range": lastModified}, fiber.StatusPartialContent, "0123", "bytes 0-3/36"},
+		{"stale if-range", map[string]string{"Range": "bytes=0-3", "If-Range": `"old"`}, fiber.StatusOK, content, ""},
+		{"if-none-match", map[string]string{"If-None-Match": etag}, fiber.StatusNotModified, "", ""},
+		{"compressed client", map[string]string{"Range": "bytes=0-3", "Accept-Encoding": "gzip"}, fiber.StatusPartialContent, "0123", "bytes 0-3/36"},
+	}
+	for _, tt := range tests {
+		resp, body := get("/export", tt.headers)
+		if resp.StatusCode != tt.status || resp.Header.Get("Content-Range") != tt.rng {
+			t.Errorf("%s: expected %d %q, got %d %q", tt.name, tt.status, tt.rng, resp.StatusCode, resp.Header.Get("Content-Range"))
+		}
+		if tt.body != "" && body != tt.body {
+			t.Errorf("%s: expected body %q, got %q", tt.name, tt.body, body)
+		}
+	}
+
+	if resp, _ := get("/missing", nil); resp.StatusCode != fiber.StatusNotFound {
+		t.Errorf("expected 404 for a missing file, got %d", resp.StatusCode)
+	}
+
+	resp, body = get("/logo", map[string]string{"Range": "bytes=1-3"})
+	if resp.StatusCode != fiber.StatusPartialContent || body != "PNG" || resp.Header.Get("Content-Type") != "image/png" {
+		t.Errorf("expected a sniffed range of the fs.File, got %d %q %q", resp.StatusCode, body, resp.Header.Get("Content-Type"))
+	}
+	if resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != "" {
+		t.Errorf("expected no validators without a modification time, got %v", resp.Header)
+	}
+}
@@ -2,19 +2,23 @@ package cartridge
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io/fs"
 	"log/slog"
 	"net/http"
 	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/compress"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/filesystem"
+	"github.com/gofiber/fiber/v2/middleware/proxy"
 	"github.com/gofiber/fiber/v2/middleware/requestid"
 
+	"github.com/karloscodes/cartridge/cache"
 	cartridgemiddleware "github.com/karloscodes/cartridge/middleware"
 )
 
@@ -27,12 +31,45 @@ type ServerConfig struct {
 
 	// Fiber configuration
 	ErrorHandler   fiber.ErrorHandler
+	ErrorFormat    ErrorFormat // JSON error shape for the default handler and ctx.Fail. Default: ErrorFormatSimple
+	ErrorPages     ErrorPages  // HTML error page templates for the default handler and ctx.Fail. Default: the built-in page
 	Concurrency    int
 	ProxyHeader    string
 	TrustedProxies []string
 	ReadTimeout    time.Duration
 	WriteTimeout   time.Duration
 
+	// Connection hardening. ReadTimeout also cuts off slowloris clients that
+	// trickle headers.
+	// MaxHeaderSize caps request headers in bytes; larger requests get 431. Default: 8192
+	MaxHeaderSize int
+	// WriteBufferSize is the per-connection response buffer in bytes. Default: 4096
+	WriteBufferSize int
+	// IdleTimeout closes keep-alive connections idle this long. Default: 120s
+	IdleTimeout time.Duration
+	// MaxRequestsPerConn closes a keep-alive connection after this many requests. Default: 0 (unlimited)
+	MaxRequestsPerConn int
+	// MaxConnsPerIP limits concurrent connections per client IP. Default: 0 (unlimited).
+	// Leave at 0 behind a reverse proxy, where all connections share the proxy's IP.
+	MaxConnsPerIP int
+	// MaxBodySize caps request bodies in bytes; larger requests get 413.
+	// Routes lower it with RouteConfig.MaxBodySize. Default: 4 MB
+	MaxBodySize int
+	// StreamRequestBody hands request bodies to handlers as they arrive
+	// instead of reading them first, so multipart uploads spill to temporary
+	// files rather than memory (see ctx.MultipartReader), and
+	// RouteConfig.MaxBodySize can raise MaxBodySize for upload routes.
+	// Default: false
+	StreamRequestBody bool
+
+	// Listening. Start serves the socket passed by systemd socket activation
+	// (LISTEN_FDS) instead, when there is one.
+	// UnixSocket listens on a Unix domain socket at this path instead of the
+	// configured port, e.g. behind nginx or Caddy on the same host. Default: "" (TCP)
+	UnixSocket string
+	// UnixSocketPerms are the socket file's permissions. Default: 0660
+	UnixSocketPerms os.FileMode
+
 	// Template engine configuration
 	EnableTemplates    bool
 	TemplatesFS        fs.FS  // Embedded filesystem for templates (production)
@@ -47,19 +84,171 @@ type ServerConfig struct {
 	PublicFS           fs.FS  // Root-level public files (favicon.svg, robots.txt), served at / (production)
 	PublicDirectory    string // Directory for public files in development (e.g. "web/public")
 
+	// StaticIndex is served for directory requests under StaticPrefix. Default: "index.html"
+	StaticIndex string
+	// StaticBrowse lists directory contents when a directory has no index file. Default: false
+	StaticBrowse bool
+	// StaticAllowDotfiles serves files and directories whose name starts with "."
+	// (.env, .git). When false they are answered like missing files. Default: false
+	StaticAllowDotfiles bool
+	// StaticNotFound handles missing files under StaticPrefix, so they never fall
+	// through to app routes or the catch-all redirect. Default: 404 via the error handler
+	StaticNotFound fiber.Handler
+	// StaticDevServer proxies requests under StaticPrefix to a frontend dev
+	// server in development, e.g. Vite at "http://localhost:5173" with base
+	// "/assets/", so its modules and HMR client load from the app's origin.
+	// Ignored outside development. Default: "" (serve from disk)
+	StaticDevServer string
+	// CacheProfiles set Cache-Control on embedded static assets (first match wins)
+	// and back ctx.ApplyCacheProfile. Unmatched assets are cached for a year.
+	// Default: DefaultCacheProfiles()
+	CacheProfiles []CacheProfile
+
 	// Middleware configuration
 	EnableRequestID     bool
 	EnableRecover       bool
 	EnableHelmet        bool
-	EnableCompress      bool
+	EnableCompress      bool // Brotli/gzip response bodies (see Compress)
+	EnableDecompress    bool // Decode gzip/br/deflate request bodies (see DecompressMaxSize)
 	EnableSecFetchSite  bool // CSRF protection via Sec-Fetch-Site header
 	EnableRequestLogger bool
+	EnableTracing       bool // OpenTelemetry span per request via the global tracer provider (see SetupTracing)
+
+	// SecurityHeaders are sent by the Helmet middleware, e.g. to add a
+	// Content-Security-Policy or HSTS. Default: middleware.DefaultSecurityHeaders()
+	SecurityHeaders *cartridgemiddleware.SecurityHeaders
+
+	// SecurityHeaderPresets adds named security header presets, or replaces
+	// built-in ones, for HeaderPresets and RouteConfig.Headers. The built-in
+	// presets are "default", "strict", "relaxed-embed" and "api-only" (see
+	// middleware.SecurityHeadersPreset).
+	SecurityHeaderPresets map[string]cartridgemiddleware.SecurityHeaders
+
+	// HeaderPresets sends a preset instead of SecurityHeaders under a path
+	// prefix, e.g. {"/api": "api-only", "/embed": "relaxed-embed"}. The
+	// longest matching prefix wins.
+	HeaderPresets map[string]string
+
+	// SecurityReportOnly sends every Content-Security-Policy and
+	// Permissions-Policy as report-only, e.g. in staging while rolling out
+	// stricter headers. Default: false
+	SecurityReportOnly bool
+
+	// SecurityReports collects CSP and Permissions-Policy violation reports
+	// (see SecurityReportsConfig). Default: nil (disabled)
+	SecurityReports *SecurityReportsConfig
+
+	// Compress configures response compression when EnableCompress is on:
+	// encodings, level, minimum size and content types. Routes opt out with
+	// RouteConfig.Compress. Default: middleware.DefaultCompressConfig()
+	Compress *cartridgemiddleware.CompressConfig
+
+	// RequestLog configures the request logger: redacted headers and
+	// parameters, debug body logging and sampling of successful requests.
+	// Default: middleware.DefaultRequestLoggerConfig()
+	RequestLog *cartridgemiddleware.RequestLoggerConfig
+
+	// RequestCapture records requests sent with the capture header as test
+	// fixtures (see middleware.RequestCapture). Development only.
+	// Default: nil (disabled)
+	RequestCapture *cartridgemiddleware.CaptureConfig
+
+	// PanicHandler renders the response when a handler panics, after the
+	// panic and its stack trace are logged. Default: the error handler's 500
+	PanicHandler func(ctx *Context, recovered any, stack []byte) error
+
+	// EnableMethodNotAllowed answers 405 with an Allow header when the path is
+	// registered for other methods, and OPTIONS from the route table, instead
+	// of 404 or the catch-all redirect. Default: true
+	EnableMethodNotAllowed bool
+
+	// EnableHealthCheck serves uptime, memory, goroutine, database pool and
+	// async queue stats at HealthPath (see HealthStats). Default: true
+	EnableHealthCheck bool
+
+	// EnableETag tags GET responses with a hash of the body and answers 304
+	// to clients that send a matching If-None-Match. ETags are weak when
+	// EnableCompress or the route's Compress is on. Routes override it with
+	// RouteConfig.ETag.
+	// Default: false
+	EnableETag bool
+
+	// DecompressMaxSize caps decompressed request bodies in bytes. Default: 10 MB
+	DecompressMaxSize int64
 
 	// SecFetchSite configuration
 	// Allowed values for Sec-Fetch-Site header. Default: ["same-origin", "none"]
 	// For cross-origin APIs (analytics, public endpoints): ["cross-site", "same-site", "same-origin"]
 	SecFetchSiteAllowedValues []string
 
+	// UploadStorage is where ctx.SaveUpload stores files unless the call
+	// sets its own. Default: nil (uploads disabled)
+	UploadStorage UploadStorage
+
+	// Cache is returned by ctx.Cache(). Share it with AsyncConfig and
+	// CronConfig so jobs see the same entries. Default: a process-wide
+	// in-memory cache
+	Cache *Cache
+
+	// ResponseCacheStore backs RouteConfig.Cache. Keys are prefixed, so the
+	// store can be shared. Default: in memory, 10000 entries (cache.NewLRUStore)
+	ResponseCacheStore cache.Store
+
+	// RateLimit applies to every route without its own RouteConfig.RateLimit,
+	// as one budget per client across those routes. Default: nil (no limit)
+	RateLimit *RateLimit
+
+	// RateLimitStore keeps rate limiter state, e.g.
+	// cartridgemiddleware.NewRedisRateLimitStore to share limits between
+	// instances. Default: in memory
+	RateLimitStore cartridgemiddleware.RateLimitStore
+
+	// Features decides which feature flags are on for a request, for
+	// RouteConfig.Feature and ctx.Feature. Default: nil (all features off)
+	Features FeatureResolver
+	// FeatureDeniedStatus answers requests to routes whose feature is off:
+	// 404 hides the route, 403 reveals it. Default: 404
+	FeatureDeniedStatus int
+
+	// Tenancy resolves the tenant of each request for ctx.Tenant and can
+	// scope ctx.DB() to it. Default: nil (single tenant)
+	Tenancy *TenancyConfig
+
+	// PrincipalResolver loads the caller's roles and permissions for routes with
+	// Roles or Authorize. Default: DefaultPrincipalResolver (JWT claims, API token, then session)
+	PrincipalResolver PrincipalResolver
+
+	// CSRF enables double-submit cookie CSRF protection on every route.
+	// Without a Binding, tokens are bound to the session's signed-in user.
+	// Routes opt out with RouteConfig.EnableCSRF. Default: nil (disabled)
+	CSRF *cartridgemiddleware.CSRFConfig
+
+	// Notifier delivers ctx.Notify. Default: nil (notifications disabled)
+	Notifier *Notifier
+
+	// PageMeta holds site-wide defaults for ctx.Meta, such as SiteName,
+	// SiteURL and a default preview Image. Default: nil
+	PageMeta *PageMetadata
+
+	// CurrentUser loads the user returned by the currentUser template helper
+	// (see TemplateHelpers). Default: the caller's Principal
+	CurrentUser func(ctx *Context) (any, error)
+
+	// CORS is the policy for routes with EnableCORS that don't set their own.
+	// Default: any origin, without credentials. See middleware.CORSForEnvironment.
+	CORS *cartridgemiddleware.CORSConfig
+
+	// Query tracing configuration
+	// EnableQueryTracing attributes SQL queries to the request that issued them (see Context.QueryTrace).
+	EnableQueryTracing bool
+	// QueryCountWarnThreshold logs a warning in development when a request issues more queries. Default: 50
+	QueryCountWarnThreshold int
+	// NPlusOneThreshold logs a possible N+1 warning in development when the same query
+	// fingerprint repeats this many times in one request. Default: 5
+	NPlusOneThreshold int
+	// SlowQueryThreshold keeps queries slower than this for Server.SlowQueries. Default: 200ms
+	SlowQueryThreshold time.Duration
+
 	// Concurrency configuration (for SQLite WAL mode)
 	MaxConcurrentReads  int
 	MaxConcurrentWrites int
@@ -74,9 +263,17 @@ func DefaultServerConfig() *ServerConfig {
 		ReadTimeout:  30 * time.Second,
 		WriteTimeout: 30 * time.Second,
 
+		// Connection hardening
+		MaxHeaderSize:   8 * 1024,
+		WriteBufferSize: 4 * 1024,
+		IdleTimeout:     120 * time.Second,
+		MaxBodySize:     DefaultMaxBodySize,
+
 		// Static assets
 		EnableStaticAssets: true,
 		StaticPrefix:       "/assets",
+		StaticIndex:        "index.html",
+		CacheProfiles:      DefaultCacheProfiles(),
 
 		// Middleware defaults (all enabled)
 		EnableTemplates:     true,
@@ -84,9 +281,19 @@ func DefaultServerConfig() *ServerConfig {
 		EnableRecover:       true,
 		EnableHelmet:        true,
 		EnableCompress:      true,
+		EnableDecompress:    true,
 		EnableSecFetchSite:  true,
 		EnableRequestLogger: true,
 
+		EnableMethodNotAllowed: true,
+		EnableHealthCheck:      true,
+
+		// Query tracing defaults
+		EnableQueryTracing:      true,
+		QueryCountWarnThreshold: 50,
+		NPlusOneThreshold:       5,
+		SlowQueryThreshold:      200 * time.Millisecond,
+
 		// Concurrency defaults optimized for SQLite WAL mode
 		MaxConcurrentReads:  128,
 		MaxConcurrentWrites: 8,
@@ -96,8 +303,14 @@ func DefaultServerConfig() *ServerConfig {
 
 // RouteConfig allows per-route middleware customization.
 type RouteConfig struct {
+	// Name identifies the route for Server.URL and the Route template helper.
+	Name string
+
 	// EnableCORS enables CORS for this route.
 	EnableCORS bool
+	// CORS overrides ServerConfig.CORS for this route.
+	CORS *cartridgemiddleware.CORSConfig
+	// CORSConfig uses Fiber's CORS middleware instead. Takes precedence over CORS.
 	CORSConfig *cors.Config
 
 	// WriteConcurrency enables write concurrency limiting for this route.
@@ -107,8 +320,75 @@ type RouteConfig struct {
 	// Set to Bool(false) for public/cross-origin routes.
 	EnableSecFetchSite *bool
 
+	// EnableCSRF controls CSRF token validation when ServerConfig.CSRF is set.
+	// Default true (nil = enabled). Set to Bool(false) for webhooks and APIs
+	// authenticated by tokens.
+	EnableCSRF *bool
+
+	// EnableHead serves HEAD for GET routes with the same handlers, minus the
+	// body. Default true (nil = enabled). A HEAD route registered before the
+	// GET route takes precedence.
+	EnableHead *bool
+
+	// ETag overrides ServerConfig.EnableETag for this route (nil = server setting).
+	ETag *bool
+
+	// Compress overrides ServerConfig.EnableCompress for this route (nil =
+	// server setting), e.g. Bool(false) for already compressed downloads.
+	Compress *bool
+
+	// Headers sends the named security header preset (see
+	// ServerConfig.SecurityHeaderPresets) instead of the app's, e.g.
+	// "relaxed-embed" for a page other sites frame.
+	Headers string
+
 	// CustomMiddleware are additional middleware to run before the handler.
 	CustomMiddleware []fiber.Handler
+
+	// Roles admits callers with any of these roles. Checked after
+	// CustomMiddleware, so authentication middleware runs first.
+	Roles []string
+	// Authorize is a policy the caller must satisfy, e.g. Policy("products:write").
+	// Unauthenticated callers get 401 and unauthorized ones 403.
+	Authorize *AuthPolicy
+
+	// RateLimit gives the route its own budget, overriding ServerConfig.RateLimit.
+	// Checked after authorization, so RateLimitByUser sees the Principal.
+	RateLimit *RateLimit
+	// EnableRateLimit controls rate limiting. Default true (nil = enabled).
+	// Set to Bool(false) to exempt a route from ServerConfig.RateLimit.
+	EnableRateLimit *bool
+
+	// Feature gates the route behind a feature flag (see ServerConfig.Features).
+	// Checked after authorization, so the resolver can use ctx.Principal().
+	Feature string
+
+	// Validate runs after CustomMiddleware and authorization, and rejects
+	// invalid payloads before the handler, e.g. ValidateBody[CreateProductRequest]().
+	Validate fiber.Handler
+
+	// Cache serves successful GET responses from ServerConfig.ResponseCacheStore
+	// for Cache.TTL. Checked last, so only authorized, valid requests hit it.
+	Cache *RouteCache
+
+	// MaxBodySize caps the route's request bodies in bytes. Without
+	// ServerConfig.StreamRequestBody it can only lower ServerConfig.MaxBodySize;
+	// with it, upload routes can raise it. Default: 0 (server limit)
+	MaxBodySize int
+
+	// LatencyBudget is the route's latency target. Slower requests log a
+	// warning with the time spent in each phase of the chain and are counted
+	// in Server.LatencyBudgetMetrics. Default: 0 (no budget)
+	LatencyBudget time.Duration
+}
+
+// corsPreset returns the CORS environment preset for origins.
+func corsPreset(cfg Config, origins []string) *cartridgemiddleware.CORSConfig {
+	preset := cartridgemiddleware.DevelopmentCORS(origins...)
+	if cfg.IsProduction() {
+		preset = cartridgemiddleware.ProductionCORS(origins...)
+	}
+	return &preset
 }
 
 // Bool returns a pointer to a bool value. Useful for optional config fields.
@@ -116,11 +396,38 @@ func Bool(v bool) *bool { return &v }
 
 // Server is the cartridge framework server with clean route registration API.
 type Server struct {
-	app               *fiber.App
-	cfg               *ServerConfig
-	limiter           *cartridgemiddleware.ConcurrencyLimiter
-	catchAll          string
-	session *SessionManager
+	app      *fiber.App
+	cfg      *ServerConfig
+	limiter  *cartridgemiddleware.ConcurrencyLimiter
+	catchAll string
+	session  *SessionManager
+	sessions *Sessions
+	audit    AuditStore
+	jwt      *JWTAuth
+	tokens   *APITokens
+	async    *AsyncManager
+	events   *EventBus
+	services map[ServiceKey]any
+	cache    *ResponseCache
+	policies map[string]func(ctx *Context, p *Principal) bool
+	routes   []RouteInfo
+	started  time.Time // for HealthStats uptime
+
+	rateLimits      cartridgemiddleware.RateLimitStore
+	globalRateLimit fiber.Handler // ServerConfig.RateLimit, shared by routes
+	largestBody     int           // largest route MaxBodySize, for streamed bodies
+	liveRateLimit   *liveHandler  // behind globalRateLimit, replaced by SetRateLimit
+	cors            fiber.Handler // ServerConfig.CORS, shared by routes
+	liveCORS        *liveHandler  // behind cors, replaced by SetCORSOrigins
+	corsMu          sync.Mutex
+	corsConfig      cartridgemiddleware.CORSConfig // current ServerConfig.CORS
+	routeNames      map[string]string
+	csrf            fiber.Handler // ServerConfig.CSRF, shared by routes
+	notFound        fiber.Handler // set by NotFound
+	notAllowed      fiber.Handler // set by MethodNotAllowed
+	budgets         latencyBudgetRecorder
+	goroutines      *goroutineGroup // Server.Go and the parent of ctx.Go
+	slowQueries     slowQueryLog
 }
 
 // Session returns the session manager. Returns nil if sessions are not enabled.
@@ -133,6 +440,71 @@ func (s *Server) SetSession(sm *SessionManager) {
 	s.session = sm
 }
 
+// Sessions returns the request session subsystem. Returns nil if not enabled.
+func (s *Server) Sessions() *Sessions {
+	return s.sessions
+}
+
+// SetSessions enables ctx.Session() for routes registered afterwards.
+// Called by the factory before routes are mounted.
+func (s *Server) SetSessions(sessions *Sessions) {
+	s.sessions = sessions
+	s.app.Use(sessions.Middleware())
+}
+
+// AuditStore returns the audit log store, for building admin audit views.
+// Returns nil if the audit log is not enabled.
+func (s *Server) AuditStore() AuditStore {
+	return s.audit
+}
+
+// SetAuditStore enables ctx.Audit. Called by the factory before routes are mounted.
+func (s *Server) SetAuditStore(store AuditStore) {
+	s.audit = store
+}
+
+// JWT returns the JWT authenticator. Returns nil if JWT is not enabled.
+func (s *Server) JWT() *JWTAuth {
+	return s.jwt
+}
+
+// SetJWT enables ctx.SignJWT for routes registered afterwards. Protect routes
+// with RouteConfig.CustomMiddleware: []fiber.Handler{s.JWT().Middleware()}.
+func (s *Server) SetJWT(auth *JWTAuth) {
+	s.jwt = auth
+	s.app.Use(auth.Provide())
+}
+
+// APITokens returns the API token store. Returns nil if API tokens are not enabled.
+func (s *Server) APITokens() *APITokens {
+	return s.tokens
+}
+
+// SetAPITokens enables Server.APITokenRoutes. Protect API routes with
+// RouteConfig.CustomMiddleware: []fiber.Handler{s.APITokens().Middleware()}.
+func (s *Server) SetAPITokens(tokens *APITokens) {
+	s.tokens = tokens
+}
+
+// Async returns the async manager used by ctx.Promote. Returns nil if not set.
+func (s *Server) Async() *AsyncManager {
+	return s.async
+}
+
+// SetAsync enables ctx.Promote and EventAsync subscribers, and mounts the
+// task status endpoint at AsyncStatusPath. Call it before mounting routes
+// that could shadow it.
+func (s *Server) SetAsync(m *AsyncManager) {
+	s.async = m
+	s.events.SetAsync(m)
+	s.app.Get(AsyncStatusPath+"/:id", asyncStatusHandler(m))
+}
+
+// Events returns the event bus behind ctx.Emit.
+func (s *Server) Events() *EventBus {
+	return s.events
+}
+
 // NewServer creates a new cartridge server with the provided configuration.
 func NewServer(cfg *ServerConfig) (*Server, error) {
 	if cfg == nil {
@@ -147,6 +519,16 @@ func NewServer(cfg *ServerConfig) (*Server, error) {
 	if cfg.DBManager == nil {
 		return nil, fmt.Errorf("cartridge: database manager is required")
 	}
+	if cfg.CORS != nil {
+		if err := cfg.CORS.Validate(); err != nil {
+			return nil, fmt.Errorf("cartridge: %w", err)
+		}
+	}
+	for _, name := range cfg.HeaderPresets {
+		if _, err := headerPreset(cfg, name); err != nil {
+			return nil, err
+		}
+	}
 
 	// Build Fiber configuration
 	fiberCfg := fiber.Config{
@@ -155,6 +537,15 @@ func NewServer(cfg *ServerConfig) (*Server, error) {
 		Concurrency:           cfg.Concurrency,
 		ReadTimeout:           cfg.ReadTimeout,
 		WriteTimeout:          cfg.WriteTimeout,
+		IdleTimeout:           cfg.IdleTimeout,
+		ReadBufferSize:        cfg.MaxHeaderSize, // fasthttp rejects headers that don't fit
+		WriteBufferSize:       cfg.WriteBufferSize,
+		BodyLimit:             cfg.MaxBodySize,
+	}
+	if cfg.StreamRequestBody {
+		// Limits are checked per route; multipart forms are parsed on demand
+		fiberCfg.StreamRequestBody = true
+		fiberCfg.DisablePreParseMultipartForm = true
 	}
 
 	if cfg.ProxyHeader != "" {
@@ -173,11 +564,15 @@ func NewServer(cfg *ServerConfig) (*Server, error) {
 	if cfg.ErrorHandler != nil {
 		fiberCfg.ErrorHandler = cfg.ErrorHandler
 	} else {
-		fiberCfg.ErrorHandler = createDefaultErrorHandler(cfg.Logger, cfg.Config)
+		fiberCfg.ErrorHandler = createDefaultErrorHandler(cfg.Logger, cfg.ErrorFormat, cfg.ErrorPages)
 	}
 
 	app := fiber.New(fiberCfg)
 
+	// Limits fiber.Config doesn't expose
+	app.Server().MaxRequestsPerConn = cfg.MaxRequestsPerConn
+	app.Server().MaxConnsPerIP = cfg.MaxConnsPerIP
+
 	// Create concurrency limiter
 	limiter := cartridgemiddleware.NewConcurrencyLimiter(
 		int64(cfg.MaxConcurrentReads),
@@ -186,10 +581,43 @@ func NewServer(cfg *ServerConfig) (*Server, error) {
 		cfg.Logger,
 	)
 
+	cacheStore := cfg.ResponseCacheStore
+	if cacheStore == nil {
+		cacheStore = cache.NewLRUStore()
+	}
+
+	rateLimits := cfg.RateLimitStore
+	if rateLimits == nil {
+		rateLimits = cartridgemiddleware.NewMemoryRateLimitStore()
+	}
+
 	server := &Server{
-		app:               app,
-		cfg:               cfg,
-		limiter:           limiter,
+		app:        app,
+		cfg:        cfg,
+		limiter:    limiter,
+		rateLimits: rateLimits,
+		cache:      &ResponseCache{store: cacheStore, logger: cfg.Logger},
+		routeNames: make(map[string]string),
+		goroutines: newGoroutineGroup(context.Background(), cfg.Logger, false),
+		events:     NewEventBus(cfg.Logger),
+		started:    time.Now(),
+	}
+	if cfg.RateLimit != nil {
+		server.liveRateLimit = newLiveHandler(server.rateLimit(*cfg.RateLimit, "global"))
+		server.globalRateLimit = server.liveRateLimit.serve
+	}
+	if cfg.CORS != nil {
+		server.corsConfig = *cfg.CORS
+		server.liveCORS = newLiveHandler(cartridgemiddleware.CORS(*cfg.CORS))
+		server.cors = server.liveCORS.serve
+	}
+	if cfg.CSRF != nil {
+		csrf := *cfg.CSRF
+		csrf.ContextKey = csrfLocalsKey // read back by ctx.CSRFToken
+		if csrf.Binding == nil {
+			csrf.Binding = server.csrfBinding
+		}
+		server.csrf = cartridgemiddleware.CSRF(csrf)
 	}
 
 	// Setup global middleware
@@ -201,6 +629,13 @@ func NewServer(cfg *ServerConfig) (*Server, error) {
 	// Setup root-level public files (favicon, robots.txt, etc.)
 	server.setupPublicFiles()
 
+	if cfg.SecurityReports != nil {
+		server.mountSecurityReports()
+	}
+	if cfg.EnableHealthCheck {
+		server.mountHealthCheck()
+	}
+
 	return server, nil
 }
 
@@ -210,17 +645,43 @@ func (s *Server) setupGlobalMiddleware() {
 		s.app.Use(requestid.New())
 	}
 
+	if s.cfg.EnableTracing {
+		s.app.Use(cartridgemiddleware.Tracing())
+	}
+
+	if s.cfg.EnableQueryTracing {
+		s.app.Use(s.queryTraceMiddleware())
+	}
+
 	if s.cfg.EnableRecover {
-		s.app.Use(cartridgemiddleware.Recover())
+		recoverCfg := cartridgemiddleware.RecoverConfig{}
+		if s.cfg.Logger != nil {
+			recoverCfg.Logger = s.cfg.Logger
+		}
+		if render := s.cfg.PanicHandler; render != nil {
+			recoverCfg.PanicHandler = func(c *fiber.Ctx, recovered any, stack []byte) error {
+				return render(s.context(c), recovered, stack)
+			}
+		}
+		s.app.Use(cartridgemiddleware.Recover(recoverCfg))
 	}
 
 	if s.cfg.EnableHelmet {
-		s.app.Use(cartridgemiddleware.Helmet())
+		helmet, _ := s.helmet() // presets are validated by NewServer
+		s.app.Use(helmet)
 	}
 
 	if s.cfg.EnableCompress {
-		s.app.Use(compress.New(compress.Config{
-			Level: compress.LevelDefault,
+		s.app.Use(cartridgemiddleware.Compress(s.compressConfig()))
+	}
+
+	if s.cfg.StreamRequestBody {
+		s.app.Use(s.streamBodyPrecheck)
+	}
+
+	if s.cfg.EnableDecompress {
+		s.app.Use(cartridgemiddleware.Decompress(cartridgemiddleware.DecompressConfig{
+			MaxSize: s.cfg.DecompressMaxSize,
 		}))
 	}
 
@@ -228,11 +689,42 @@ func (s *Server) setupGlobalMiddleware() {
 	// (not as global middleware) so routes can opt out with EnableSecFetchSite: false
 
 	if s.cfg.EnableRequestLogger {
-		s.app.Use(cartridgemiddleware.RequestLogger(s.cfg.Logger))
+		requestLog := cartridgemiddleware.DefaultRequestLoggerConfig()
+		if s.cfg.RequestLog != nil {
+			requestLog = *s.cfg.RequestLog
+		}
+		s.app.Use(cartridgemiddleware.RequestLogger(s.cfg.Logger, requestLog))
+	}
+
+	// Inside compression, so fixtures hold the plain response body
+	if s.cfg.RequestCapture != nil {
+		capture := *s.cfg.RequestCapture
+		if capture.Logger == nil && s.cfg.Logger != nil {
+			capture.Logger = s.cfg.Logger
+		}
+		s.app.Use(cartridgemiddleware.RequestCapture(capture))
+	}
+
+	if s.cfg.Tenancy != nil {
+		s.app.Use(s.tenancyMiddleware(*s.cfg.Tenancy))
+	}
+
+	if s.cfg.EnableETag {
+		s.app.Use(cartridgemiddleware.ETag(s.etagConfig()))
+	}
+
+	s.app.Use(s.fallbackMiddleware())
+
+	if s.cfg.EnableMethodNotAllowed {
+		s.app.Use(s.methodNotAllowedMiddleware())
 	}
+
+	s.app.Use(s.templateHelpers())
 }
 
 // setupStaticAssets configures static file serving.
+// Requests under the prefix are handled entirely here: dotfiles and traversal
+// attempts are rejected, and missing files go to StaticNotFound.
 func (s *Server) setupStaticAssets() {
 	if !s.cfg.EnableStaticAssets {
 		return
@@ -242,32 +734,97 @@ func (s *Server) setupStaticAssets() {
 	if prefix == "" {
 		prefix = "/assets"
 	}
+	index := s.cfg.StaticIndex
+	if index == "" {
+		index = "index.html"
+	}
+	notFound := s.cfg.StaticNotFound
+	if notFound == nil {
+		notFound = func(c *fiber.Ctx) error {
+			return fiber.ErrNotFound
+		}
+	}
 
+	if s.cfg.StaticDevServer != "" && s.cfg.Config.IsDevelopment() {
+		// No staticGuard: the dev server guards its own paths, which include
+		// dot segments such as node_modules/.vite
+		s.app.Use(prefix, s.staticDevProxy(strings.TrimSuffix(s.cfg.StaticDevServer, "/")))
+		return
+	}
+
+	var serve fiber.Handler
 	if s.cfg.StaticFS != nil {
 		// Use embedded filesystem (production)
-		// Hashed filenames from Vite provide cache busting, so cache aggressively (1 year).
-		s.app.Use(prefix, filesystem.New(filesystem.Config{
+		// Cache aggressively (1 year) unless a cache profile says otherwise.
+		serve = filesystem.New(filesystem.Config{
 			Root:       http.FS(s.cfg.StaticFS),
-			Browse:     false,
+			Browse:     s.cfg.StaticBrowse,
+			Index:      "/" + strings.TrimPrefix(index, "/"),
 			MaxAge:     int((365 * 24 * time.Hour).Seconds()),
 			PathPrefix: "",
-		}))
+		})
 	} else {
 		// Use directory (development) — no caching so rebuilds are picked up immediately
 		dir := s.cfg.StaticDirectory
 		if dir == "" {
 			dir = s.cfg.Config.GetPublicDirectory()
 		}
-		if dir != "" {
-			s.app.Static(prefix, dir, fiber.Static{
-				Compress:  true,
-				ByteRange: true,
-				Browse:    false,
-			})
+		if dir == "" {
+			return
+		}
+		s.app.Use(prefix, s.staticGuard(prefix, notFound))
+		s.app.Static(prefix, dir, fiber.Static{
+			Compress:  true,
+			ByteRange: true,
+			Browse:    s.cfg.StaticBrowse,
+			Index:     strings.TrimPrefix(index, "/"),
+		})
+		s.app.Use(prefix, notFound)
+		return
+	}
+
+	s.app.Use(prefix, s.staticGuard(prefix, notFound), s.cacheProfileMiddleware(prefix, strings.TrimPrefix(index, "/")), s.staticValidators(prefix, strings.TrimPrefix(index, "/")), serve, notFound)
+}
+
+// staticDevProxy forwards asset requests to the dev server at target.
+func (s *Server) staticDevProxy(target string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if err := proxy.Do(c, target+c.OriginalURL()); err != nil {
+			return fiber.NewError(fiber.StatusBadGateway, fmt.Sprintf("dev server %s unavailable: %v", target, err))
+		}
+		return nil
+	}
+}
+
+// staticGuard rejects asset paths with ".." segments and, unless
+// StaticAllowDotfiles is set, paths with a segment starting with ".".
+func (s *Server) staticGuard(prefix string, notFound fiber.Handler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !isSafeStaticPath(strings.TrimPrefix(c.Path(), prefix), s.cfg.StaticAllowDotfiles) {
+			return notFound(c)
 		}
+		return c.Next()
 	}
 }
 
+// isSafeStaticPath reports whether a request path relative to the static prefix
+// may be served. fasthttp already normalizes "..", so this is defense in depth
+// against encoded or backslash variants.
+func isSafeStaticPath(p string, allowDotfiles bool) bool {
+	if strings.Contains(p, "\\") || strings.ContainsRune(p, 0) {
+		return false
+	}
+	for _, seg := range strings.Split(p, "/") {
+		if seg == ".." {
+			return false
+		}
+		if !allowDotfiles && strings.HasPrefix(seg, ".") {
+			return false
+		}
+	}
+	return true
+}
+
 // setupPublicFiles serves root-level public files (favicon.svg, robots.txt, etc.)
 // In production, serves from PublicFS (embedded). In development, serves from PublicDirectory (disk).
 func (s *Server) setupPublicFiles() {
@@ -291,12 +848,16 @@ func (s *Server) setupPublicFiles() {
 		if entry.IsDir() {
 			continue
 		}
+		if !s.cfg.StaticAllowDotfiles && strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
 		path := "/" + entry.Name()
 		s.app.Get(path, func(c *fiber.Ctx) error {
 			return filesystem.SendFile(c, httpFS, path)
 		})
 	}
 }
+
 // SetCatchAllRedirect configures a fallback redirect for unmatched routes.
 func (s *Server) SetCatchAllRedirect(path string) {
 	s.catchAll = path
@@ -348,16 +909,53 @@ func (s *Server) registerRoute(method, path string, handler HandlerFunc, cfgs ..
 	capacity := 1 // At least the handler itself
 	if routeCfg != nil {
 		capacity += len(routeCfg.CustomMiddleware)
+		if len(routeCfg.Roles) > 0 || routeCfg.Authorize != nil {
+			capacity++
+		}
+		if routeCfg.Validate != nil {
+			capacity++
+		}
+		if routeCfg.Feature != "" {
+			capacity++
+		}
+		if routeCfg.Cache != nil {
+			capacity++
+		}
 		if routeCfg.EnableCORS {
 			capacity++
 		}
 		if routeCfg.WriteConcurrency {
 			capacity++
 		}
+		if routeCfg.ETag != nil {
+			capacity++
+		}
+		if routeCfg.Headers != "" {
+			capacity++
+		}
 	}
 
 	handlers := make([]fiber.Handler, 0, capacity)
 
+	// Time each phase of the chain for routes with a latency budget
+	timed := routeCfg != nil && routeCfg.LatencyBudget > 0
+	if timed {
+		handlers = append(handlers, s.latencyBudget(path, routeCfg.LatencyBudget))
+	}
+	mark := func(phase string) {
+		if timed {
+			handlers = append(handlers, latencyPhase(phase))
+		}
+	}
+
+	if limit := s.routeBodyLimit(routeCfg); limit != nil {
+		handlers = append(handlers, limit)
+	}
+
+	if routeCfg != nil && routeCfg.Headers != "" {
+		handlers = append(handlers, s.routeHeaders(path, routeCfg.Headers))
+	}
+
 	// Apply SecFetchSite per-route: enabled by default, disabled with EnableSecFetchSite: false
 	skipSecFetch := routeCfg != nil && routeCfg.EnableSecFetchSite != nil && !*routeCfg.EnableSecFetchSite
 	if s.cfg.EnableSecFetchSite && !skipSecFetch {
@@ -368,18 +966,33 @@ func (s *Server) registerRoute(method, path string, handler HandlerFunc, cfgs ..
 		handlers = append(handlers, cartridgemiddleware.SecFetchSiteMiddleware(secFetchCfg))
 	}
 
+	// Apply CSRF per-route too, so webhooks can opt out with EnableCSRF: false
+	skipCSRF := routeCfg != nil && routeCfg.EnableCSRF != nil && !*routeCfg.EnableCSRF
+	if s.csrf != nil && !skipCSRF {
+		handlers = append(handlers, s.csrf)
+	}
+
+	if routeCfg != nil && routeCfg.ETag != nil {
+		handlers = append(handlers, s.routeETag(*routeCfg.ETag))
+	}
+
+	if routeCfg != nil && routeCfg.Compress != nil {
+		handlers = append(handlers, s.routeCompress(*routeCfg.Compress))
+	}
+
 	if routeCfg != nil {
 		// Add CORS if enabled (must come first for preflight handling)
 		if routeCfg.EnableCORS {
-			corsCfg := routeCfg.CORSConfig
-			if corsCfg == nil {
-				corsCfg = &cors.Config{
-					AllowOrigins: "*",
-					AllowMethods: "GET,POST,PUT,DELETE,PATCH,OPTIONS",
-					AllowHeaders: "Origin, Content-Type, Accept, Authorization",
-				}
+			switch {
+			case routeCfg.CORSConfig != nil:
+				handlers = append(handlers, cors.New(*routeCfg.CORSConfig))
+			case routeCfg.CORS != nil:
+				handlers = append(handlers, cartridgemiddleware.CORS(*routeCfg.CORS))
+			case s.cors != nil:
+				handlers = append(handlers, s.cors)
+			default:
+				handlers = append(handlers, cartridgemiddleware.CORS())
 			}
-			handlers = append(handlers, cors.New(*corsCfg))
 		}
 
 		// Add write concurrency limiting if enabled
@@ -389,32 +1002,121 @@ func (s *Server) registerRoute(method, path string, handler HandlerFunc, cfgs ..
 
 		// Add custom middleware
 		if len(routeCfg.CustomMiddleware) > 0 {
+			mark(phaseCustom)
 			handlers = append(handlers, routeCfg.CustomMiddleware...)
 		}
+
+		// Authorize once authentication middleware has run
+		if len(routeCfg.Roles) > 0 || routeCfg.Authorize != nil {
+			mark(phaseAuthorize)
+			handlers = append(handlers, s.authorize(routeCfg.Roles, routeCfg.Authorize))
+		}
+	}
+
+	// Limit once the caller is known, before flags, validation and the cache
+	if limit := s.routeRateLimit(method, path, routeCfg); limit != nil {
+		mark(phaseRateLimit)
+		handlers = append(handlers, limit)
+	}
+
+	if routeCfg != nil {
+		if routeCfg.Feature != "" || routeCfg.Validate != nil {
+			mark(phaseValidate)
+		}
+
+		// Hide dark-launched routes once the caller is known
+		if routeCfg.Feature != "" {
+			handlers = append(handlers, s.requireFeature(routeCfg.Feature))
+		}
+
+		// Validate the payload once auth and other middleware have passed
+		if routeCfg.Validate != nil {
+			handlers = append(handlers, routeCfg.Validate)
+		}
+
+		// Serve from the cache only once the request has been let through
+		if routeCfg.Cache != nil {
+			mark(phaseCache)
+			handlers = append(handlers, s.cache.middleware(s, *routeCfg.Cache))
+		}
 	}
 
 	// Add the wrapped handler
+	mark(phaseHandler)
 	handlers = append(handlers, s.wrapHandler(handler))
 
 	s.app.Add(method, path, handlers...)
+
+	// HEAD runs the GET chain; fasthttp drops the body but keeps its Content-Length
+	skipHead := routeCfg != nil && routeCfg.EnableHead != nil && !*routeCfg.EnableHead
+	if method == fiber.MethodGet && !skipHead {
+		s.app.Add(fiber.MethodHead, path, handlers...)
+	}
+
+	info := RouteInfo{Method: method, Path: path}
+	if routeCfg != nil && routeCfg.Name != "" {
+		if existing, ok := s.routeNames[routeCfg.Name]; ok {
+			panic(fmt.Sprintf("cartridge: route name %q is already used by %s", routeCfg.Name, existing))
+		}
+		s.routeNames[routeCfg.Name] = path
+		info.Name = routeCfg.Name
+	}
+	if routeCfg != nil {
+		info.Roles = routeCfg.Roles
+		if routeCfg.Authorize != nil {
+			info.Policy = routeCfg.Authorize.Name()
+		}
+		info.Feature = routeCfg.Feature
+	}
+	s.routes = append(s.routes, info)
 }
 
 // wrapHandler converts a cartridge HandlerFunc to a Fiber handler.
 func (s *Server) wrapHandler(handler HandlerFunc) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		ctx := &Context{
-			Ctx:       c,
-			Logger:    s.cfg.Logger,
-			Config:    s.cfg.Config,
-			DBManager: s.cfg.DBManager,
-			Session:   s.session,
-		}
-		// Store context in locals for middleware access
-		c.Locals("cartridge_ctx", ctx)
+		ctx := s.context(c)
+		// Attribute queries to the matched route
+		if trace := ctx.QueryTrace(); trace != nil {
+			trace.SetRoute(c.Route().Path)
+		}
+		defer ctx.finishGoroutines()
 		return handler(ctx)
 	}
 }
 
+// context returns the request's Context, creating it on first use and storing
+// it in locals for middleware access.
+func (s *Server) context(c *fiber.Ctx) *Context {
+	if ctx, ok := c.Locals("cartridge_ctx").(*Context); ok {
+		return ctx
+	}
+	ctx := &Context{
+		Ctx:         c,
+		Logger:      s.cfg.Logger,
+		Config:      s.cfg.Config,
+		DBManager:   s.cfg.DBManager,
+		Auth:        s.session,
+		async:       s.async,
+		errorFormat: s.cfg.ErrorFormat,
+		errorPages:  s.cfg.ErrorPages,
+		caching:     s.cfg.CacheProfiles,
+		uploads:     s.cfg.UploadStorage,
+		cache:       s.cfg.Cache,
+		features:    s.cfg.Features,
+		audit:       s.audit,
+		principals:  s.cfg.PrincipalResolver,
+		pageMeta:    s.cfg.PageMeta,
+		notifier:    s.cfg.Notifier,
+		events:      s.events,
+		background:  s.goroutines,
+	}
+	if len(s.services) > 0 {
+		ctx.services = &serviceScope{provided: s.services}
+	}
+	c.Locals("cartridge_ctx", ctx)
+	return ctx
+}
+
 // App returns the underlying Fiber application for advanced usage.
 func (s *Server) App() *fiber.App {
 	return s.app
@@ -435,15 +1137,33 @@ func (s *Server) GetDBManager() DBManager {
 	return s.cfg.DBManager
 }
 
-// Start starts the HTTP server on the configured port.
+// Start starts the HTTP server on the socket passed by systemd socket
+// activation, ServerConfig.UnixSocket, or the configured port.
 func (s *Server) Start() error {
-	// Add catch-all redirect if configured
-	if s.catchAll != "" {
+	// Add catch-all redirect if configured and not replaced by NotFound
+	if s.catchAll != "" && s.notFound == nil {
 		s.app.All("*", func(c *fiber.Ctx) error {
 			return c.Redirect(s.catchAll, fiber.StatusTemporaryRedirect)
 		})
 	}
 
+	ln, err := activatedListener(listenFDsStart)
+	if err != nil {
+		return err
+	}
+	if ln != nil {
+		s.cfg.Logger.Info("Server started and ready to accept requests", "addr", ln.Addr().String(), "socket_activation", true)
+		return s.app.Listener(ln)
+	}
+	if s.cfg.UnixSocket != "" {
+		ln, err := listenUnix(s.cfg.UnixSocket, s.cfg.UnixSocketPerms)
+		if err != nil {
+			return err
+		}
+		s.cfg.Logger.Info("Server started and ready to accept requests", "socket", s.cfg.UnixSocket)
+		return s.app.Listener(ln)
+	}
+
 	port := s.cfg.Config.GetPort()
 	s.cfg.Logger.Info("Server started and ready to accept requests", "port", port)
 	return s.app.Listen(":" + port)
@@ -466,20 +1186,33 @@ func (s *Server) Shutdown(ctx context.Context) error {
 		done <- s.app.Shutdown()
 	}()
 
+	var err error
 	select {
 	case <-ctx.Done():
-		return ctx.Err()
-	case err := <-done:
-		return err
+		err = ctx.Err()
+	case err = <-done:
 	}
+
+	// In-flight requests have waited for their own goroutines
+	if stopErr := s.goroutines.stop(ctx); err == nil {
+		err = stopErr
+	}
+	return err
 }
 
 // createDefaultErrorHandler creates a default error handler.
-func createDefaultErrorHandler(logger Logger, cfg Config) fiber.ErrorHandler {
+func createDefaultErrorHandler(logger Logger, format ErrorFormat, pages ErrorPages) fiber.ErrorHandler {
 	return func(c *fiber.Ctx, err error) error {
-		code := fiber.StatusInternalServerError
-		if e, ok := err.(*fiber.Error); ok {
-			code = e.Code
+		appErr := asError(err)
+		code := appErr.status()
+
+		// Validation failures are client errors with per-field details
+		var validationErrs ValidationErrors
+		if errors.As(err, &validationErrs) {
+			if wantsJSON(c) {
+				return writeErrorJSON(c, format, appErr)
+			}
+			return writeErrorHTML(c, logger, pages, appErr, appErr.Message)
 		}
 
 		logger.Error("Request error",
@@ -490,14 +1223,21 @@ func createDefaultErrorHandler(logger Logger, cfg Config) fiber.ErrorHandler {
 		)
 
 		// JSON error response for API requests
-		if c.Accepts(fiber.MIMEApplicationJSON) == fiber.MIMEApplicationJSON {
-			return c.Status(code).JSON(fiber.Map{
-				"error":   "internal_server_error",
-				"message": err.Error(),
-			})
+		if wantsJSON(c) {
+			if format == "" {
+				return c.Status(code).JSON(fiber.Map{
+					"error":   appErr.code(),
+					"message": appErr.Message,
+				})
+			}
+			return writeErrorJSON(c, format, appErr)
 		}
 
-		// Fallback text response
-		return c.Status(code).SendString(fmt.Sprintf("Error: %d - %s", code, err.Error()))
+		// HTML error page; server errors don't show their message
+		message := appErr.Message
+		if code >= fiber.StatusInternalServerError {
+			message = ""
+		}
+		return writeErrorHTML(c, logger, pages, appErr, message)
 	}
 }
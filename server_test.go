@@ -1,14 +1,20 @@
 package cartridge
 
 import (
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 	"testing/fstest"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
+
+	"github.com/karloscodes/cartridge/config"
 )
 
 func TestPublicFS(t *testing.T) {
@@ -129,3 +135,308 @@ type testDBManager struct{}
 
 func (d *testDBManager) GetConnection() *gorm.DB    { return nil }
 func (d *testDBManager) Connect() (*gorm.DB, error) { return nil, nil }
+
+func TestConnectionLimits(t *testing.T) {
+	cfg := DefaultServerConfig()
+	cfg.EnableStaticAssets = false
+	cfg.EnableRequestLogger = false
+	cfg.Config = &testConfig{}
+	cfg.Logger = slog.New(slog.NewTextHandler(os.Stderr, nil))
+	cfg.DBManager = &testDBManager{}
+	cfg.MaxHeaderSize = 16 * 1024
+	cfg.MaxRequestsPerConn = 1000
+	cfg.MaxConnsPerIP = 20
+
+	srv, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	if got := srv.app.Config().ReadBufferSize; got != 16*1024 {
+		t.Errorf("expected read buffer of 16KB, got %d", got)
+	}
+	if got := srv.app.Config().IdleTimeout; got != DefaultServerConfig().IdleTimeout {
+		t.Errorf("expected default idle timeout, got %v", got)
+	}
+	if got := srv.app.Server().MaxRequestsPerConn; got != 1000 {
+		t.Errorf("expected MaxRequestsPerConn 1000, got %d", got)
+	}
+	if got := srv.app.Server().MaxConnsPerIP; got != 20 {
+		t.Errorf("expected MaxConnsPerIP 20, got %d", got)
+	}
+}
+
+func TestStaticAssets(t *testing.T) {
+	files := fstest.MapFS{
+		"app.js":           &fstest.MapFile{Data: []byte("console.log(1)")},
+		"docs/index.html":  &fstest.MapFile{Data: []byte("<h1>docs</h1>")},
+		"images/logo.png":  &fstest.MapFile{Data: []byte("png")},
+		".env":             &fstest.MapFile{Data: []byte("SECRET=1")},
+		".git/config":      &fstest.MapFile{Data: []byte("[core]")},
+		"nested/.htaccess": &fstest.MapFile{Data: []byte("deny")},
+	}
+	dir := t.TempDir()
+	for name, f := range files {
+		path := dir + "/" + name
+		if err := os.MkdirAll(path[:strings.LastIndex(path, "/")], 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, f.Data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	newServer := func(t *testing.T, fn func(cfg *ServerConfig)) *Server {
+		t.Helper()
+		cfg := DefaultServerConfig()
+		cfg.EnableRequestLogger = false
+		cfg.Config = &testConfig{}
+		cfg.Logger = slog.New(slog.NewTextHandler(os.Stderr, nil))
+		cfg.DBManager = &testDBManager{}
+		fn(cfg)
+		srv, err := NewServer(cfg)
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		srv.SetCatchAllRedirect("/login")
+		return srv
+	}
+
+	modes := map[string]func(cfg *ServerConfig){
+		"embedded":  func(cfg *ServerConfig) { cfg.StaticFS = files },
+		"directory": func(cfg *ServerConfig) { cfg.StaticDirectory = dir },
+	}
+	for mode, setup := range modes {
+		t.Run(mode, func(t *testing.T) {
+			srv := newServer(t, setup)
+			srv.Get("/assets/route", func(ctx *Context) error { return ctx.SendString("route") })
+
+			tests := map[string]int{
+				"/assets/app.js":           fiber.StatusOK,
+				"/assets/docs/":            fiber.StatusOK,
+				"/assets/missing.js":       fiber.StatusNotFound,
+				"/assets/route":            fiber.StatusNotFound,
+				"/assets/.env":             fiber.StatusNotFound,
+				"/assets/.git/config":      fiber.StatusNotFound,
+				"/assets/nested/.htaccess": fiber.StatusNotFound,
+			}
+			for path, want := range tests {
+				resp, err := srv.App().Test(httptest.NewRequest("GET", path, nil))
+				if err != nil {
+					t.Fatalf("%s: request failed: %v", path, err)
+				}
+				if resp.StatusCode != want {
+					t.Errorf("%s: expected %d, got %d", path, want, resp.StatusCode)
+				}
+			}
+
+			// fasthttp normalizes these out of the prefix; they must never reach a file
+			for _, path := range []string{"/assets/%2e%2e/server.go", "/assets/..%2f..%2fetc/hosts", "/assets/..%5cserver.go"} {
+				resp, _ := srv.App().Test(httptest.NewRequest("GET", path, nil))
+				if resp.StatusCode == fiber.StatusOK {
+					t.Errorf("%s: traversal should not be served", path)
+				}
+			}
+
+			resp, _ := srv.App().Test(httptest.NewRequest("GET", "/assets/images/", nil))
+			if resp.StatusCode == fiber.StatusOK {
+				t.Error("expected directory listing to be disabled by default")
+			}
+		})
+	}
+
+	t.Run("browse, dotfiles and custom 404", func(t *testing.T) {
+		srv := newServer(t, func(cfg *ServerConfig) {
+			cfg.StaticFS = files
+			cfg.StaticBrowse = true
+			cfg.StaticAllowDotfiles = true
+			cfg.StaticNotFound = func(c *fiber.Ctx) error {
+				return c.Status(fiber.StatusNotFound).SendString("no such asset")
+			}
+		})
+
+		resp, _ := srv.App().Test(httptest.NewRequest("GET", "/assets/images/", nil))
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != fiber.StatusOK || !strings.Contains(string(body), "logo.png") {
+			t.Errorf("expected directory listing, got %d: %s", resp.StatusCode, body)
+		}
+
+		if resp, _ := srv.App().Test(httptest.NewRequest("GET", "/assets/.env", nil)); resp.StatusCode != fiber.StatusOK {
+			t.Errorf("expected dotfile to be served when allowed, got %d", resp.StatusCode)
+		}
+
+		resp, _ = srv.App().Test(httptest.NewRequest("GET", "/assets/missing.js", nil))
+		body, _ = io.ReadAll(resp.Body)
+		if resp.StatusCode != fiber.StatusNotFound || string(body) != "no such asset" {
+			t.Errorf("expected custom 404, got %d: %s", resp.StatusCode, body)
+		}
+	})
+
+	t.Run("dev server proxy", func(t *testing.T) {
+		vite := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, "vite %s", r.URL.RequestURI())
+		}))
+		defer vite.Close()
+
+		srv := newServer(t, func(cfg *ServerConfig) {
+			cfg.Config = &config.Config{Environment: config.Development}
+			cfg.StaticDirectory = dir
+			cfg.StaticDevServer = vite.URL + "/"
+		})
+		resp, _ := srv.App().Test(httptest.NewRequest("GET", "/assets/node_modules/.vite/deps/react.js?v=1", nil))
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != fiber.StatusOK || string(body) != "vite /assets/node_modules/.vite/deps/react.js?v=1" {
+			t.Errorf("expected the request to be proxied, got %d: %s", resp.StatusCode, body)
+		}
+
+		vite.Close()
+		if resp, _ := srv.App().Test(httptest.NewRequest("GET", "/assets/app.js", nil)); resp.StatusCode != fiber.StatusBadGateway {
+			t.Errorf("expected 502 when the dev server is down, got %d", resp.StatusCode)
+		}
+
+		// Outside development assets come from disk
+		srv = newServer(t, func(cfg *ServerConfig) {
+			cfg.StaticDirectory = dir
+			cfg.StaticDevServer = vite.URL
+		})
+		resp, _ = srv.App().Test(httptest.NewRequest("GET", "/assets/app.js", nil))
+		body, _ = io.ReadAll(resp.Body)
+		if string(body) != "console.log(1)" {
+			t.Errorf("expected the file from disk, got %q", body)
+		}
+	})
+}
+
+func TestPublicFilesSkipDotfiles(t *testing.T) {
+	cfg := DefaultServerConfig()
+	cfg.EnableStaticAssets = false
+	cfg.EnableRequestLogger = false
+	cfg.Config = &testConfig{}
+	cfg.Logger = slog.New(slog.NewTextHandler(os.Stderr, nil))
+	cfg.DBManager = &testDBManager{}
+	cfg.PublicFS = fstest.MapFS{
+		"robots.txt": &fstest.MapFile{Data: []byte("User-agent: *")},
+		".env":       &fstest.MapFile{Data: []byte("SECRET=1")},
+	}
+
+	srv, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	if resp, _ := srv.App().Test(httptest.NewRequest("GET", "/robots.txt", nil)); resp.StatusCode != fiber.StatusOK {
+		t.Errorf("expected robots.txt to be served, got %d", resp.StatusCode)
+	}
+	if resp, _ := srv.App().Test(httptest.NewRequest("GET", "/.env", nil)); resp.StatusCode == fiber.StatusOK {
+		t.Error("expected .env not to be served")
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	cfg := DefaultServerConfig()
+	cfg.EnableStaticAssets = false
+	cfg.EnableRequestLogger = false
+	cfg.EnableSecFetchSite = false
+	cfg.Config = &testConfig{}
+	cfg.Logger = testLogger()
+	cfg.DBManager = &testDBManager{}
+	cfg.ErrorHandler = DefaultErrorHandler(testLogger(), false)
+
+	srv, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	ok := func(ctx *Context) error { return ctx.SendString("ok") }
+	srv.Get("/products", ok)
+	srv.Post("/products", ok)
+	srv.Delete("/products/:id", ok)
+	srv.App().All("*", func(c *fiber.Ctx) error { return c.Redirect("/", fiber.StatusTemporaryRedirect) })
+
+	tests := []struct {
+		method, path string
+		status       int
+		allow        string
+	}{
+		{"PUT", "/products", fiber.StatusMethodNotAllowed, "GET, HEAD, POST, OPTIONS"},
+		{"GET", "/products/7", fiber.StatusMethodNotAllowed, "DELETE, OPTIONS"},
+		{"OPTIONS", "/products/", fiber.StatusNoContent, "GET, HEAD, POST, OPTIONS"},
+		{"GET", "/products", fiber.StatusOK, ""},
+		{"PUT", "/missing", fiber.StatusTemporaryRedirect, ""},
+	}
+	for _, tc := range tests {
+		resp, err := srv.App().Test(httptest.NewRequest(tc.method, tc.path, nil))
+		if err != nil {
+			t.Fatalf("%s %s failed: %v", tc.method, tc.path, err)
+		}
+		if resp.StatusCode != tc.status || resp.Header.Get("Allow") != tc.allow {
+			t.Errorf("%s %s: expected %d with Allow %q, got %d with %q",
+				tc.method, tc.path, tc.status, tc.allow, resp.StatusCode, resp.Header.Get("Allow"))
+		}
+	}
+}
+
+func TestHeadForGetRoutes(t *testing.T) {
+	cfg := DefaultServerConfig()
+	cfg.EnableStaticAssets = false
+	cfg.EnableRequestLogger = false
+	cfg.EnableSecFetchSite = false
+	cfg.EnableCompress = false
+	cfg.Config = &testConfig{}
+	cfg.Logger = testLogger()
+	cfg.DBManager = &testDBManager{}
+	cfg.ErrorHandler = DefaultErrorHandler(testLogger(), false)
+
+	srv, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	srv.Get("/health", func(ctx *Context) error {
+		ctx.Set("X-Version", "1.2.3")
+		return ctx.SendString("healthy")
+	})
+	srv.Get("/export", func(ctx *Context) error {
+		return ctx.SendString("csv")
+	}, &RouteConfig{EnableHead: Bool(false)})
+
+	resp, err := srv.App().Test(httptest.NewRequest("HEAD", "/health", nil))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != fiber.StatusOK || len(body) != 0 {
+		t.Errorf("expected 200 without a body, got %d with %q", resp.StatusCode, body)
+	}
+	if resp.ContentLength != int64(len("healthy")) || resp.Header.Get("X-Version") != "1.2.3" {
+		t.Errorf("expected GET headers, got Content-Length %d and %v", resp.ContentLength, resp.Header)
+	}
+
+	resp, err = srv.App().Test(httptest.NewRequest("HEAD", "/export", nil))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusMethodNotAllowed {
+		t.Errorf("expected 405 with EnableHead false, got %d", resp.StatusCode)
+	}
+}
+
+func TestMatchRoutePattern(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"/", "/", true},
+		{"/users/:id", "/users/42", true},
+		{"/users/:id", "/users", false},
+		{"/users/:id?", "/users", true},
+		{"/users/:id", "/users/42/posts", false},
+		{"/assets*", "/assets/app.js", true},
+		{"/files/*", "/files/a/b.txt", true},
+		{"/files/+", "/files", false},
+		{"/Users", "/users/", true},
+	}
+	for _, tc := range tests {
+		if got := matchRoutePattern(tc.pattern, tc.path, false, false); got != tc.want {
+			t.Errorf("matchRoutePattern(%q, %q) = %v, want %v", tc.pattern, tc.path, got, tc.want)
+		}
+	}
+}
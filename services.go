@@ -0,0 +1,139 @@
+package cartridge
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ServiceKey names a dependency that handlers resolve from the Context and
+// that can be replaced for a single request.
+type ServiceKey string
+
+// Built-in keys for the Context's own dependencies.
+const (
+	LoggerKey    ServiceKey = "logger"
+	ConfigKey    ServiceKey = "config"
+	DBManagerKey ServiceKey = "db_manager"
+)
+
+// serviceScope holds the app-wide services and this request's overrides.
+type serviceScope struct {
+	provided  map[ServiceKey]any
+	overrides map[ServiceKey]any
+}
+
+// Provide registers the app-wide implementation of a service, resolved in
+// handlers with ctx.Service. Provide services while mounting routes, before
+// the server starts.
+func (s *Server) Provide(key ServiceKey, service any) {
+	if s.services == nil {
+		s.services = make(map[ServiceKey]any)
+	}
+	s.services[key] = service
+}
+
+// Middleware adapts a cartridge handler for use in RouteConfig.CustomMiddleware
+// or App().Use. The handler shares the request's Context and continues the
+// chain with ctx.Next():
+//
+//	canary := s.Middleware(func(ctx *cartridge.Context) error {
+//	    if ctx.Get("X-Canary") == "1" {
+//	        ctx.Override(MailerKey, newMailer)
+//	    }
+//	    return ctx.Next()
+//	})
+func (s *Server) Middleware(fn HandlerFunc) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return fn(s.context(c))
+	}
+}
+
+// Service returns the request's override for key, or the service registered
+// with Server.Provide, or nil. The built-in keys return the Context's Logger,
+// Config and DBManager.
+func (ctx *Context) Service(key ServiceKey) any {
+	switch key {
+	case LoggerKey:
+		return ctx.Logger
+	case ConfigKey:
+		return ctx.Config
+	case DBManagerKey:
+		return ctx.DBManager
+	}
+	if ctx.services == nil {
+		return nil
+	}
+	if service, ok := ctx.services.overrides[key]; ok {
+		return service
+	}
+	return ctx.services.provided[key]
+}
+
+// ServiceAs returns the service for key as T:
+//
+//	mailer, ok := cartridge.ServiceAs[Mailer](ctx, MailerKey)
+func ServiceAs[T any](ctx *Context, key ServiceKey) (T, bool) {
+	service, ok := ctx.Service(key).(T)
+	return service, ok
+}
+
+// Override replaces a service for the rest of this request only; other
+// requests keep the app-wide implementation. It returns a function that
+// restores the previous value, for overrides scoped to part of the chain.
+// Overriding LoggerKey, ConfigKey or DBManagerKey replaces the matching
+// Context field and panics if service has the wrong type.
+//
+//	ctx.Override(cartridge.LoggerKey, testLogger)
+func (ctx *Context) Override(key ServiceKey, service any) (restore func()) {
+	previous := ctx.Service(key)
+
+	switch key {
+	case LoggerKey:
+		ctx.Logger = mustService[Logger](key, service)
+		return func() { ctx.Logger, _ = previous.(Logger) }
+	case ConfigKey:
+		ctx.Config = mustService[Config](key, service)
+		return func() { ctx.Config, _ = previous.(Config) }
+	case DBManagerKey:
+		ctx.setDBManager(mustService[DBManager](key, service))
+		return func() {
+			db, _ := previous.(DBManager)
+			ctx.setDBManager(db)
+		}
+	}
+
+	if ctx.services == nil {
+		ctx.services = &serviceScope{}
+	}
+	if ctx.services.overrides == nil {
+		ctx.services.overrides = make(map[ServiceKey]any)
+	}
+	existing, overridden := ctx.services.overrides[key]
+	ctx.services.overrides[key] = service
+	return func() {
+		if overridden {
+			ctx.services.overrides[key] = existing
+		} else {
+			delete(ctx.services.overrides, key)
+		}
+	}
+}
+
+// setDBManager swaps the database manager and drops cached connections so
+// the next DB() call uses it.
+func (ctx *Context) setDBManager(db DBManager) {
+	ctx.DBManager = db
+	ctx.db = nil
+	ctx.readDB = nil
+}
+
+// mustService asserts a built-in service override has the right type.
+func mustService[T any](key ServiceKey, service any) T {
+	typed, ok := service.(T)
+	if !ok && service != nil {
+		panic(fmt.Sprintf("cartridge: Override(%s) needs a %s, got %T", key, reflect.TypeFor[T](), service))
+	}
+	return typed
+}
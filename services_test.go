@@ -0,0 +1,97 @@
+package cartridge
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// testMailer is a stand-in app service.
+type testMailer struct{ name string }
+
+const testMailerKey ServiceKey = "mailer"
+
+func TestContext_Override(t *testing.T) {
+	cfg := DefaultServerConfig()
+	cfg.EnableStaticAssets = false
+	cfg.EnableRequestLogger = false
+	cfg.EnableSecFetchSite = false
+	cfg.Config = &testConfig{}
+	cfg.Logger = testLogger()
+	cfg.DBManager = &testDBManager{}
+	cfg.ErrorHandler = DefaultErrorHandler(testLogger(), false)
+
+	srv, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	srv.Provide(testMailerKey, &testMailer{name: "smtp"})
+
+	fakeLogger := testLogger()
+	canary := srv.Middleware(func(ctx *Context) error {
+		if ctx.Get("X-Canary") == "1" {
+			ctx.Override(testMailerKey, &testMailer{name: "capture"})
+			ctx.Override(LoggerKey, fakeLogger)
+		}
+		return ctx.Next()
+	})
+	srv.Get("/send", func(ctx *Context) error {
+		mailer, ok := ServiceAs[*testMailer](ctx, testMailerKey)
+		if !ok {
+			return ErrInternal(nil)
+		}
+		name := mailer.name
+		if ctx.Logger == fakeLogger {
+			name += "+fake-logger"
+		}
+		return ctx.SendString(name)
+	}, &RouteConfig{CustomMiddleware: []fiber.Handler{canary}})
+
+	send := func(canaryHeader string) string {
+		req := httptest.NewRequest("GET", "/send", nil)
+		req.Header.Set("X-Canary", canaryHeader)
+		resp, err := srv.App().Test(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	if got := send("1"); got != "capture+fake-logger" {
+		t.Errorf("expected overridden services, got %q", got)
+	}
+	// Overrides don't leak into other requests
+	if got := send(""); got != "smtp" {
+		t.Errorf("expected app-wide mailer, got %q", got)
+	}
+}
+
+func TestContext_OverrideRestore(t *testing.T) {
+	base := &testDBManager{}
+	ctx := &Context{Logger: testLogger(), DBManager: base}
+
+	restoreMailer := ctx.Override(testMailerKey, &testMailer{name: "capture"})
+	restoreDB := ctx.Override(DBManagerKey, &mockDBManager{})
+	if _, ok := ctx.DBManager.(*mockDBManager); !ok {
+		t.Fatal("expected DBManager override to replace the field")
+	}
+
+	restoreDB()
+	restoreMailer()
+	if ctx.DBManager != base {
+		t.Error("expected DBManager to be restored")
+	}
+	if ctx.Service(testMailerKey) != nil {
+		t.Error("expected mailer override to be removed")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for a logger of the wrong type")
+		}
+	}()
+	ctx.Override(LoggerKey, "not a logger")
+}
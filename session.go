@@ -6,12 +6,15 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+
+	"github.com/karloscodes/cartridge/crypto"
 )
 
 // SessionConfig configures the session manager.
@@ -30,8 +33,16 @@ type SessionConfig struct {
 
 	// LoginPath is where to redirect unauthenticated users. Default: "/login".
 	LoginPath string
+
+	// SlidingExpiration reissues auth cookies that are past half their TTL,
+	// so active users stay logged in. Default: false.
+	SlidingExpiration bool
 }
 
+// authUserLocalsKey holds the user signed in or out while handling the
+// request, before the browser sends the new cookie.
+const authUserLocalsKey = "cartridge_auth_user"
+
 // SessionManager handles cookie-based session authentication.
 type SessionManager struct {
 	cookieName string
@@ -39,12 +50,15 @@ type SessionManager struct {
 	ttl        time.Duration
 	secure     bool
 	loginPath  string
+	sliding    bool
 }
 
 // SessionData stores session information in the cookie.
 type SessionData struct {
 	UserID    string    `json:"user_id"`
 	ExpiresAt time.Time `json:"expires_at"`
+	IssuedAt  time.Time `json:"issued_at,omitempty"`
+	TokenID   string    `json:"token_id,omitempty"`
 }
 
 // NewSessionManager creates a session manager with the given configuration.
@@ -70,6 +84,7 @@ func NewSessionManager(cfg SessionConfig) *SessionManager {
 		ttl:        ttl,
 		secure:     cfg.Secure,
 		loginPath:  loginPath,
+		sliding:    cfg.SlidingExpiration,
 	}
 }
 
@@ -90,6 +105,7 @@ func (sm *SessionManager) SetSession(c *fiber.Ctx, userID uint) error {
 		return err
 	}
 
+	c.Locals(authUserLocalsKey, sessionData.UserID)
 	c.Cookie(&fiber.Cookie{
 		Name:     sm.cookieName,
 		Value:    token,
@@ -107,67 +123,159 @@ func (sm *SessionManager) SetSession(c *fiber.Ctx, userID uint) error {
 	return nil
 }
 
-// ClearSession removes the session cookie.
-func (sm *SessionManager) ClearSession(c *fiber.Ctx) {
-	c.ClearCookie(sm.cookieName)
+// SetAuthCookie logs the user in with an AES-GCM encrypted cookie. Each call
+// issues a new random token, so logging in rotates the cookie value and any
+// cookie captured before login becomes useless.
+func (sm *SessionManager) SetAuthCookie(c *fiber.Ctx, userID string) error {
+	if userID == "" {
+		return errors.New("cartridge: user ID is required")
+	}
+	tokenID, err := newSessionID()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	return sm.writeAuthCookie(c, SessionData{
+		UserID:    userID,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(sm.ttl),
+		TokenID:   tokenID,
+	})
+}
+
+// GetAuthCookie returns the user ID from the encrypted auth cookie.
+// With SlidingExpiration, cookies past half their TTL are reissued.
+func (sm *SessionManager) GetAuthCookie(c *fiber.Ctx) (string, bool) {
+	data, ok := sm.readAuthCookie(c)
+	if !ok {
+		return "", false
+	}
+	return data.UserID, true
+}
+
+// writeAuthCookie encrypts data into the session cookie.
+func (sm *SessionManager) writeAuthCookie(c *fiber.Ctx, data SessionData) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	token, err := crypto.Encrypt(string(payload), string(sm.secret))
+	if err != nil {
+		return fmt.Errorf("cartridge: encrypt auth cookie: %w", err)
+	}
+	c.Locals(authUserLocalsKey, data.UserID)
+
 	c.Cookie(&fiber.Cookie{
 		Name:     sm.cookieName,
-		Value:    "",
+		Value:    token,
 		Path:     "/",
-		MaxAge:   -1,
-		Expires:  time.Now().Add(-24 * time.Hour),
+		MaxAge:   int(time.Until(data.ExpiresAt).Seconds()),
+		Expires:  data.ExpiresAt,
 		Secure:   sm.secure,
 		HTTPOnly: true,
 		SameSite: "Lax",
 	})
-	slog.Debug("session cleared")
+	return nil
 }
 
-// IsAuthenticated checks if the request has a valid session.
-func (sm *SessionManager) IsAuthenticated(c *fiber.Ctx) bool {
+// readAuthCookie validates the session cookie, sliding its expiry when
+// enabled. Both signed (SetSession) and encrypted (SetAuthCookie) cookies are
+// accepted.
+func (sm *SessionManager) readAuthCookie(c *fiber.Ctx) (*SessionData, bool) {
 	token := c.Cookies(sm.cookieName)
 	if token == "" {
-		return false
+		return nil, false
 	}
 
-	sessionData, err := sm.verify(token)
+	var data *SessionData
+	var err error
+	if strings.Contains(token, ".") {
+		data, err = sm.verify(token)
+	} else {
+		data, err = sm.decrypt(token)
+	}
 	if err != nil {
 		slog.Debug("session verification failed", slog.Any("error", err))
-		return false
+		return nil, false
 	}
 
-	if time.Now().After(sessionData.ExpiresAt) {
-		slog.Debug("session expired", slog.Time("expires_at", sessionData.ExpiresAt))
-		return false
+	now := time.Now()
+	if now.After(data.ExpiresAt) {
+		slog.Debug("session expired", slog.Time("expires_at", data.ExpiresAt))
+		return nil, false
 	}
+	if data.UserID == "" {
+		return nil, false
+	}
+	if sm.sliding && data.ExpiresAt.Sub(now) < sm.ttl/2 {
+		data.ExpiresAt = now.Add(sm.ttl)
+		if err := sm.writeAuthCookie(c, *data); err != nil {
+			slog.Warn("failed to extend auth cookie", slog.Any("error", err))
+		}
+	}
+	return data, true
+}
 
-	if _, err := strconv.ParseUint(sessionData.UserID, 10, 64); err != nil {
-		slog.Debug("invalid user ID in session", slog.String("user_id", sessionData.UserID))
-		return false
+// decrypt opens an encrypted auth cookie.
+func (sm *SessionManager) decrypt(token string) (*SessionData, error) {
+	plaintext, err := crypto.Decrypt(token, string(sm.secret))
+	if err != nil {
+		return nil, errors.New("invalid auth cookie")
+	}
+	var data SessionData
+	if err := json.Unmarshal([]byte(plaintext), &data); err != nil {
+		return nil, errors.New("invalid auth cookie data")
 	}
+	return &data, nil
+}
 
-	return true
+// ClearSession removes the session cookie.
+func (sm *SessionManager) ClearSession(c *fiber.Ctx) {
+	c.Locals(authUserLocalsKey, "")
+	c.ClearCookie(sm.cookieName)
+	c.Cookie(&fiber.Cookie{
+		Name:     sm.cookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		Expires:  time.Now().Add(-24 * time.Hour),
+		Secure:   sm.secure,
+		HTTPOnly: true,
+		SameSite: "Lax",
+	})
+	slog.Debug("session cleared")
 }
 
-// GetUserID retrieves the user ID from the session cookie.
-// Returns 0 and false if not authenticated.
-func (sm *SessionManager) GetUserID(c *fiber.Ctx) (uint, bool) {
-	token := c.Cookies(sm.cookieName)
-	if token == "" {
-		return 0, false
+// currentUser returns the ID of the signed-in user, or "". A sign-in or
+// sign-out earlier in the request counts, though its cookie isn't sent back
+// yet.
+func (sm *SessionManager) currentUser(c *fiber.Ctx) string {
+	if userID, ok := c.Locals(authUserLocalsKey).(string); ok {
+		return userID
 	}
-
-	sessionData, err := sm.verify(token)
-	if err != nil {
-		return 0, false
+	if data, ok := sm.readAuthCookie(c); ok {
+		return data.UserID
 	}
+	return ""
+}
+
+// IsAuthenticated checks if the request has a valid session.
+func (sm *SessionManager) IsAuthenticated(c *fiber.Ctx) bool {
+	_, ok := sm.readAuthCookie(c)
+	return ok
+}
 
-	if time.Now().After(sessionData.ExpiresAt) {
+// GetUserID retrieves the numeric user ID from the session cookie.
+// Returns 0 and false if not authenticated.
+func (sm *SessionManager) GetUserID(c *fiber.Ctx) (uint, bool) {
+	sessionData, ok := sm.readAuthCookie(c)
+	if !ok {
 		return 0, false
 	}
 
 	userID, err := strconv.ParseUint(sessionData.UserID, 10, 32)
 	if err != nil {
+		slog.Debug("invalid user ID in session", slog.String("user_id", sessionData.UserID))
 		return 0, false
 	}
 
@@ -0,0 +1,266 @@
+package cartridge
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/karloscodes/cartridge/crypto"
+)
+
+// SessionStore persists encoded session data between requests.
+//
+// The token is the value kept in the session cookie. Server-side stores use
+// an opaque random ID; the cookie store keeps the encrypted data itself.
+type SessionStore interface {
+	// Load returns the data for token, or nil if it is unknown or expired.
+	Load(ctx context.Context, token string) ([]byte, error)
+
+	// Save stores data for ttl and returns the token to put in the cookie.
+	// An empty token asks the store to allocate a new one.
+	Save(ctx context.Context, token string, data []byte, ttl time.Duration) (string, error)
+
+	// Delete removes the session for token.
+	Delete(ctx context.Context, token string) error
+}
+
+// SessionCleaner is implemented by stores that keep expired sessions around
+// until they are purged. The factory schedules DeleteExpired as a cron job.
+type SessionCleaner interface {
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+// newSessionID returns a random, URL-safe session identifier.
+func newSessionID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("cartridge: generate session id: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// CookieSessionStore keeps session data in the cookie itself, encrypted with
+// AES-GCM. Nothing is stored server-side; cookies are limited to about 4KB.
+type CookieSessionStore struct {
+	secret string
+}
+
+// maxCookieSessionSize is the largest encrypted token browsers reliably accept.
+const maxCookieSessionSize = 4000
+
+// cookieSessionEnvelope is the encrypted cookie payload.
+type cookieSessionEnvelope struct {
+	Data      []byte `json:"d"`
+	ExpiresAt int64  `json:"e"`
+}
+
+// NewCookieSessionStore creates a cookie store encrypting with secret.
+func NewCookieSessionStore(secret string) *CookieSessionStore {
+	return &CookieSessionStore{secret: secret}
+}
+
+// Load decrypts the cookie. Tampered or expired cookies return nil.
+func (s *CookieSessionStore) Load(_ context.Context, token string) ([]byte, error) {
+	plaintext, err := crypto.Decrypt(token, s.secret)
+	if err != nil {
+		return nil, nil
+	}
+	var env cookieSessionEnvelope
+	if err := json.Unmarshal([]byte(plaintext), &env); err != nil {
+		return nil, nil
+	}
+	if time.Now().Unix() > env.ExpiresAt {
+		return nil, nil
+	}
+	return env.Data, nil
+}
+
+// Save encrypts data into a new cookie value.
+func (s *CookieSessionStore) Save(_ context.Context, _ string, data []byte, ttl time.Duration) (string, error) {
+	payload, err := json.Marshal(cookieSessionEnvelope{Data: data, ExpiresAt: time.Now().Add(ttl).Unix()})
+	if err != nil {
+		return "", err
+	}
+	token, err := crypto.Encrypt(string(payload), s.secret)
+	if err != nil {
+		return "", fmt.Errorf("cartridge: encrypt session: %w", err)
+	}
+	if len(token) > maxCookieSessionSize {
+		return "", fmt.Errorf("cartridge: session data too large for cookie store (%d bytes)", len(token))
+	}
+	return token, nil
+}
+
+// Delete is a no-op; clearing the cookie removes the session.
+func (s *CookieSessionStore) Delete(context.Context, string) error {
+	return nil
+}
+
+// MemorySessionStore keeps sessions in process memory. Sessions are lost on
+// restart and not shared between instances; use it for development and tests.
+type MemorySessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]memorySession
+}
+
+type memorySession struct {
+	data      []byte
+	expiresAt time.Time
+}
+
+// NewMemorySessionStore creates an empty in-memory store.
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{sessions: make(map[string]memorySession)}
+}
+
+// Load returns the session data if present and not expired.
+func (s *MemorySessionStore) Load(_ context.Context, token string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, ok := s.sessions[token]
+	if !ok || time.Now().After(sess.expiresAt) {
+		return nil, nil
+	}
+	return sess.data, nil
+}
+
+// Save stores the session data, allocating an ID for new sessions.
+func (s *MemorySessionStore) Save(_ context.Context, token string, data []byte, ttl time.Duration) (string, error) {
+	if token == "" {
+		id, err := newSessionID()
+		if err != nil {
+			return "", err
+		}
+		token = id
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[token] = memorySession{data: data, expiresAt: time.Now().Add(ttl)}
+	return token, nil
+}
+
+// Delete removes the session.
+func (s *MemorySessionStore) Delete(_ context.Context, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, token)
+	return nil
+}
+
+// DeleteExpired removes expired sessions.
+func (s *MemorySessionStore) DeleteExpired(context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var n int64
+	for token, sess := range s.sessions {
+		if now.After(sess.expiresAt) {
+			delete(s.sessions, token)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// SessionRecord is the database model for server-side sessions.
+type SessionRecord struct {
+	ID        string `gorm:"primaryKey;size:64"`
+	Data      []byte
+	ExpiresAt time.Time `gorm:"index"`
+	UpdatedAt time.Time
+}
+
+// TableName specifies the table name.
+func (SessionRecord) TableName() string {
+	return "cartridge_sessions"
+}
+
+// DatabaseSessionStore keeps sessions in the cartridge_sessions table.
+// Works with any GORM-supported database (SQLite, PostgreSQL, MySQL).
+type DatabaseSessionStore struct {
+	dbManager DBManager
+}
+
+// NewDatabaseSessionStore creates a database-backed store.
+// The cartridge_sessions table is auto-migrated if it doesn't exist.
+func NewDatabaseSessionStore(dbManager DBManager) (*DatabaseSessionStore, error) {
+	db, err := dbManager.Connect()
+	if err != nil {
+		return nil, fmt.Errorf("cartridge: connect database: %w", err)
+	}
+	if err := db.AutoMigrate(&SessionRecord{}); err != nil {
+		return nil, fmt.Errorf("cartridge: migrate sessions: %w", err)
+	}
+	return &DatabaseSessionStore{dbManager: dbManager}, nil
+}
+
+// Load returns the session data if present and not expired.
+func (s *DatabaseSessionStore) Load(ctx context.Context, token string) ([]byte, error) {
+	db, err := s.dbManager.Connect()
+	if err != nil {
+		return nil, err
+	}
+
+	var rec SessionRecord
+	err = db.WithContext(ctx).
+		Where("id = ? AND expires_at > ?", token, time.Now().UTC()).
+		First(&rec).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rec.Data, nil
+}
+
+// Save upserts the session row, allocating an ID for new sessions.
+func (s *DatabaseSessionStore) Save(ctx context.Context, token string, data []byte, ttl time.Duration) (string, error) {
+	if token == "" {
+		id, err := newSessionID()
+		if err != nil {
+			return "", err
+		}
+		token = id
+	}
+
+	db, err := s.dbManager.Connect()
+	if err != nil {
+		return "", err
+	}
+
+	rec := SessionRecord{ID: token, Data: data, ExpiresAt: time.Now().UTC().Add(ttl)}
+	if err := db.WithContext(ctx).Save(&rec).Error; err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Delete removes the session row.
+func (s *DatabaseSessionStore) Delete(ctx context.Context, token string) error {
+	db, err := s.dbManager.Connect()
+	if err != nil {
+		return err
+	}
+	return db.WithContext(ctx).Delete(&SessionRecord{}, "id = ?", token).Error
+}
+
+// DeleteExpired removes expired session rows.
+func (s *DatabaseSessionStore) DeleteExpired(ctx context.Context) (int64, error) {
+	db, err := s.dbManager.Connect()
+	if err != nil {
+		return 0, err
+	}
+	result := db.WithContext(ctx).Where("expires_at <= ?", time.Now().UTC()).Delete(&SessionRecord{})
+	return result.RowsAffected, result.Error
+}
@@ -3,9 +3,16 @@ package cartridge
 import (
 	"encoding/base64"
 	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/karloscodes/cartridge/crypto"
 )
 
 func TestNewSessionManager(t *testing.T) {
@@ -174,3 +181,129 @@ func TestDifferentSecrets(t *testing.T) {
 		t.Errorf("expected verification to succeed with same secret: %v", err)
 	}
 }
+
+// authCookieRequest issues a GET with the given cookie and returns the body
+// and the auth cookie set by the response, if any.
+func authCookieRequest(t *testing.T, app *fiber.App, path string, cookie *http.Cookie) (string, *http.Cookie) {
+	t.Helper()
+	req := httpGet(path)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("request %s failed: %v", path, err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	for _, c := range resp.Cookies() {
+		if c.Name == "session" {
+			return string(body), c
+		}
+	}
+	return string(body), nil
+}
+
+func TestAuthCookie(t *testing.T) {
+	const secret = "test-secret-key-32-characters-xx"
+	sm := NewSessionManager(SessionConfig{Secret: secret, TTL: time.Hour, SlidingExpiration: true})
+
+	app := fiber.New()
+	app.Get("/login", func(c *fiber.Ctx) error {
+		if err := sm.SetAuthCookie(c, "42"); err != nil {
+			return err
+		}
+		return c.SendString("ok")
+	})
+	app.Get("/me", func(c *fiber.Ctx) error {
+		userID, ok := sm.GetAuthCookie(c)
+		if !ok {
+			return c.SendStatus(fiber.StatusUnauthorized)
+		}
+		return c.SendString(userID)
+	})
+	app.Get("/id", func(c *fiber.Ctx) error {
+		userID, ok := sm.GetUserID(c)
+		if !ok {
+			return c.SendStatus(fiber.StatusUnauthorized)
+		}
+		return c.SendString(strconv.FormatUint(uint64(userID), 10))
+	})
+
+	t.Run("roundtrip", func(t *testing.T) {
+		_, cookie := authCookieRequest(t, app, "/login", nil)
+		if cookie == nil {
+			t.Fatal("expected auth cookie to be set")
+		}
+		// Random ciphertext can contain "42", but never the JSON payload or
+		// its base64 encoding
+		if strings.Contains(cookie.Value, "user_id") || strings.HasPrefix(cookie.Value, "eyJ") {
+			t.Error("auth cookie should be encrypted")
+		}
+
+		body, refreshed := authCookieRequest(t, app, "/me", cookie)
+		if body != "42" {
+			t.Errorf("expected user 42, got %q", body)
+		}
+		if refreshed != nil {
+			t.Error("fresh cookie should not be reissued")
+		}
+
+		if body, _ := authCookieRequest(t, app, "/id", cookie); body != "42" {
+			t.Errorf("GetUserID: expected 42, got %q", body)
+		}
+	})
+
+	t.Run("rotates on login", func(t *testing.T) {
+		_, first := authCookieRequest(t, app, "/login", nil)
+		_, second := authCookieRequest(t, app, "/login", first)
+		if first.Value == second.Value {
+			t.Error("expected a new cookie value on each login")
+		}
+	})
+
+	t.Run("rejects tampered cookie", func(t *testing.T) {
+		_, cookie := authCookieRequest(t, app, "/login", nil)
+		flipped := "A"
+		if cookie.Value[0] == 'A' {
+			flipped = "B"
+		}
+		cookie.Value = flipped + cookie.Value[1:]
+		if body, _ := authCookieRequest(t, app, "/me", cookie); body == "42" {
+			t.Error("tampered cookie should be rejected")
+		}
+	})
+
+	t.Run("slides expiry past half the TTL", func(t *testing.T) {
+		payload, _ := json.Marshal(SessionData{UserID: "42", ExpiresAt: time.Now().Add(10 * time.Minute)})
+		token, _ := crypto.Encrypt(string(payload), secret)
+
+		body, refreshed := authCookieRequest(t, app, "/me", &http.Cookie{Name: "session", Value: token})
+		if body != "42" {
+			t.Fatalf("expected user 42, got %q", body)
+		}
+		if refreshed == nil {
+			t.Fatal("expected cookie to be reissued")
+		}
+		if refreshed.MaxAge < int((50 * time.Minute).Seconds()) {
+			t.Errorf("expected a full TTL, got max-age %d", refreshed.MaxAge)
+		}
+	})
+
+	t.Run("rejects expired cookie", func(t *testing.T) {
+		payload, _ := json.Marshal(SessionData{UserID: "42", ExpiresAt: time.Now().Add(-time.Minute)})
+		token, _ := crypto.Encrypt(string(payload), secret)
+
+		if body, _ := authCookieRequest(t, app, "/me", &http.Cookie{Name: "session", Value: token}); body == "42" {
+			t.Error("expired cookie should be rejected")
+		}
+	})
+
+	t.Run("accepts signed session cookies", func(t *testing.T) {
+		payload, _ := json.Marshal(SessionData{UserID: "7", ExpiresAt: time.Now().Add(time.Hour)})
+		token, _ := sm.sign(payload)
+
+		if body, _ := authCookieRequest(t, app, "/me", &http.Cookie{Name: "session", Value: token}); body != "7" {
+			t.Errorf("expected user 7, got %q", body)
+		}
+	})
+}
@@ -0,0 +1,429 @@
+package cartridge
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/karloscodes/cartridge/flash"
+)
+
+// SessionsConfig configures request sessions.
+type SessionsConfig struct {
+	// Store persists session data. Default: CookieSessionStore using Secret.
+	Store SessionStore
+
+	// Secret encrypts the default cookie store. Required when Store is nil.
+	Secret string
+
+	// CookieName is the name of the session cookie. Default: "cartridge_session".
+	CookieName string
+
+	// TTL is how long an idle session lives. Saving a session extends it.
+	// Default: 24 hours.
+	TTL time.Duration
+
+	// Secure sets the Secure flag on the cookie.
+	Secure bool
+
+	// Logger logs store failures. Default: slog.Default().
+	Logger Logger
+}
+
+// Sessions loads and saves per-request Session values through a SessionStore.
+type Sessions struct {
+	store      SessionStore
+	cookieName string
+	ttl        time.Duration
+	secure     bool
+	logger     Logger
+}
+
+// NewSessions creates the session subsystem.
+func NewSessions(cfg SessionsConfig) *Sessions {
+	store := cfg.Store
+	if store == nil {
+		store = NewCookieSessionStore(cfg.Secret)
+	}
+	cookieName := cfg.CookieName
+	if cookieName == "" {
+		cookieName = "cartridge_session"
+	}
+	ttl := cfg.TTL
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &Sessions{
+		store:      store,
+		cookieName: cookieName,
+		ttl:        ttl,
+		secure:     cfg.Secure,
+		logger:     logger,
+	}
+}
+
+// Store returns the backing session store.
+func (s *Sessions) Store() SessionStore {
+	return s.store
+}
+
+// CleanupJob returns a cron job purging expired sessions, or false if the
+// store doesn't need cleanup (e.g. the cookie store).
+func (s *Sessions) CleanupJob() (CronJob, bool) {
+	cleaner, ok := s.store.(SessionCleaner)
+	if !ok {
+		return CronJob{}, false
+	}
+	return CronJob{
+		ID:            "cartridge_session_cleanup",
+		Schedule:      "@every 15m",
+		SkipIfRunning: true,
+		Handler: func(ctx *JobContext) error {
+			n, err := cleaner.DeleteExpired(ctx)
+			if err != nil {
+				return err
+			}
+			if n > 0 {
+				ctx.Logger.Debug("expired sessions removed", "count", n)
+			}
+			return nil
+		},
+	}, true
+}
+
+// sessionLocalsKey stores the request's *Session in fiber locals.
+const sessionLocalsKey = "cartridge_session"
+
+// Middleware makes a Session available to the request and saves it after
+// the handler returns. The session is loaded lazily on first access, and
+// only written back when it was modified.
+func (s *Sessions) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		sess := &Session{sessions: s, ctx: c.Context(), token: c.Cookies(s.cookieName)}
+		c.Locals(sessionLocalsKey, sess)
+
+		err := c.Next()
+
+		if saveErr := sess.commit(c); saveErr != nil {
+			s.logger.Error("failed to save session", "error", saveErr)
+		}
+		return err
+	}
+}
+
+// Session returns the request's session bag. Returns nil if sessions are not enabled.
+func (ctx *Context) Session() *Session {
+	sess, _ := ctx.Locals(sessionLocalsKey).(*Session)
+	return sess
+}
+
+// sessionPayload is the encoded form of a session.
+type sessionPayload struct {
+	Values map[string]any `json:"v,omitempty"`
+	Flash  map[string]any `json:"f,omitempty"`
+}
+
+// Session is the per-request session bag. Values set during a request are
+// saved when it completes; flash values survive exactly one more request.
+type Session struct {
+	sessions *Sessions
+	ctx      context.Context
+
+	mu        sync.Mutex
+	loaded    bool
+	token     string
+	values    map[string]any
+	flash     map[string]any // flashes set by the previous request
+	nextFlash map[string]any // flashes set by this request
+	dirty     bool
+	destroyed bool
+	renew     bool
+}
+
+// load reads the session from the store on first access.
+func (s *Session) load() {
+	if s.loaded {
+		return
+	}
+	s.loaded = true
+	s.values = make(map[string]any)
+	s.nextFlash = make(map[string]any)
+
+	if s.token == "" {
+		return
+	}
+
+	data, err := s.sessions.store.Load(s.ctx, s.token)
+	if err != nil {
+		s.sessions.logger.Error("failed to load session", "error", err)
+		return
+	}
+	if data == nil {
+		// Unknown or expired: start over with a fresh token
+		s.token = ""
+		return
+	}
+
+	var p sessionPayload
+	if err := json.Unmarshal(data, &p); err != nil {
+		s.sessions.logger.Warn("discarding unreadable session", "error", err)
+		s.token = ""
+		return
+	}
+	if p.Values != nil {
+		s.values = p.Values
+	}
+	if len(p.Flash) > 0 {
+		// Flashes are consumed by this request
+		s.flash = p.Flash
+		s.dirty = true
+	}
+}
+
+// accessed reports whether the request read or wrote the session, which
+// makes its response specific to this visitor.
+func (s *Session) accessed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.loaded
+}
+
+// ID returns the session token, or "" for a session that hasn't been saved yet.
+func (s *Session) ID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	return s.token
+}
+
+// Get returns the value for key, or nil. Values round-trip through JSON, so
+// numbers read back from a previous request are float64.
+func (s *Session) Get(key string) any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	return s.values[key]
+}
+
+// Set stores a JSON-encodable value for key.
+func (s *Session) Set(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	s.values[key] = value
+	s.dirty = true
+}
+
+// Delete removes key from the session.
+func (s *Session) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	if _, ok := s.values[key]; ok {
+		delete(s.values, key)
+		s.dirty = true
+	}
+}
+
+// Flash stores a value that is readable via GetFlash on the next request only.
+func (s *Session) Flash(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	s.nextFlash[key] = value
+	s.dirty = true
+}
+
+// GetFlash returns a flash value set by the previous request, or nil.
+func (s *Session) GetFlash(key string) any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	return s.flash[key]
+}
+
+// GetString returns the string stored under key.
+func (s *Session) GetString(key string) (string, bool) {
+	return SessionValue[string](s, key)
+}
+
+// GetInt returns the integer stored under key.
+func (s *Session) GetInt(key string) (int, bool) {
+	return SessionValue[int](s, key)
+}
+
+// GetBool returns the bool stored under key.
+func (s *Session) GetBool(key string) (bool, bool) {
+	return SessionValue[bool](s, key)
+}
+
+// SessionValue returns the value stored under key decoded as T. Values saved
+// by a previous request come back as generic JSON, so structs are decoded
+// again and numbers are converted. The second result is false when the key is
+// missing or holds an incompatible value.
+//
+//	cart, ok := cartridge.SessionValue[Cart](ctx.Session(), "cart")
+func SessionValue[T any](s *Session, key string) (T, bool) {
+	return decodeSessionValue[T](s.Get(key))
+}
+
+// FlashValue is SessionValue for flash values set by the previous request.
+func FlashValue[T any](s *Session, key string) (T, bool) {
+	return decodeSessionValue[T](s.GetFlash(key))
+}
+
+// decodeSessionValue converts v to T, round-tripping through JSON when it
+// isn't already a T.
+func decodeSessionValue[T any](v any) (T, bool) {
+	var out T
+	if v == nil {
+		return out, false
+	}
+	if t, ok := v.(T); ok {
+		return t, true
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return out, false
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return out, false
+	}
+	return out, true
+}
+
+// flashMessagesKey holds the messages queued with AddFlash.
+const flashMessagesKey = "_messages"
+
+// AddFlash queues a user-facing message ("success", "error", ...) for the
+// next request. Unlike flash.SetFlash, messages travel in the session, so
+// several can be queued and no separate cookie is needed.
+func (s *Session) AddFlash(messageType, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	queued, _ := s.nextFlash[flashMessagesKey].([]flash.FlashMessage)
+	s.nextFlash[flashMessagesKey] = append(queued, flash.FlashMessage{Type: messageType, Message: message})
+	s.dirty = true
+}
+
+// Flashes returns the messages queued with AddFlash by the previous request.
+func (s *Session) Flashes() []flash.FlashMessage {
+	messages, _ := FlashValue[[]flash.FlashMessage](s, flashMessagesKey)
+	return messages
+}
+
+// Modified reports whether the session will be written back when the
+// request completes.
+func (s *Session) Modified() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.loaded && (s.dirty || s.destroyed)
+}
+
+// Regenerate issues a new session token while keeping the data.
+// Call it after login to prevent session fixation.
+func (s *Session) Regenerate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	s.renew = true
+	s.dirty = true
+}
+
+// Destroy removes the session from the store and clears the cookie.
+func (s *Session) Destroy() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	s.values = make(map[string]any)
+	s.flash = nil
+	s.nextFlash = make(map[string]any)
+	s.destroyed = true
+}
+
+// commit writes a modified session back to the store and sets the cookie.
+func (s *Session) commit(c *fiber.Ctx) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.loaded || (!s.dirty && !s.destroyed) {
+		return nil
+	}
+
+	ctx := c.Context()
+	store := s.sessions.store
+
+	if s.destroyed {
+		if s.token != "" {
+			if err := store.Delete(ctx, s.token); err != nil {
+				return err
+			}
+		}
+		s.clearCookie(c)
+		return nil
+	}
+
+	if s.renew && s.token != "" {
+		if err := store.Delete(ctx, s.token); err != nil {
+			return err
+		}
+		s.token = ""
+	}
+
+	// Nothing left to keep: drop the session rather than storing an empty one
+	if len(s.values) == 0 && len(s.nextFlash) == 0 {
+		if s.token != "" {
+			if err := store.Delete(ctx, s.token); err != nil {
+				return err
+			}
+			s.clearCookie(c)
+		}
+		return nil
+	}
+
+	data, err := json.Marshal(sessionPayload{Values: s.values, Flash: s.nextFlash})
+	if err != nil {
+		return err
+	}
+	token, err := store.Save(ctx, s.token, data, s.sessions.ttl)
+	if err != nil {
+		return err
+	}
+	s.token = token
+
+	c.Cookie(&fiber.Cookie{
+		Name:     s.sessions.cookieName,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   int(s.sessions.ttl.Seconds()),
+		Expires:  time.Now().Add(s.sessions.ttl),
+		Secure:   s.sessions.secure,
+		HTTPOnly: true,
+		SameSite: "Lax",
+	})
+	return nil
+}
+
+// clearCookie expires the session cookie.
+func (s *Session) clearCookie(c *fiber.Ctx) {
+	c.Cookie(&fiber.Cookie{
+		Name:     s.sessions.cookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		Expires:  time.Now().Add(-24 * time.Hour),
+		Secure:   s.sessions.secure,
+		HTTPOnly: true,
+		SameSite: "Lax",
+	})
+}
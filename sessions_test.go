@@ -0,0 +1,203 @@
+package cartridge
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// newSessionTestApp mounts handlers that exercise ctx.Session().
+func newSessionTestApp(sessions *Sessions) *fiber.App {
+	app := fiber.New()
+	app.Use(sessions.Middleware())
+
+	handle := func(fn func(ctx *Context) error) fiber.Handler {
+		return func(c *fiber.Ctx) error { return fn(&Context{Ctx: c}) }
+	}
+	app.Get("/set", handle(func(ctx *Context) error {
+		ctx.Session().Set("user", "ana")
+		ctx.Session().Flash("notice", "saved")
+		return ctx.SendString("ok")
+	}))
+	app.Get("/get", handle(func(ctx *Context) error {
+		user, _ := ctx.Session().Get("user").(string)
+		notice, _ := ctx.Session().GetFlash("notice").(string)
+		return ctx.SendString(user + "|" + notice)
+	}))
+	app.Get("/logout", handle(func(ctx *Context) error {
+		ctx.Session().Destroy()
+		return ctx.SendString("bye")
+	}))
+	return app
+}
+
+// sessionRequest issues a GET with the given cookie and returns the body and
+// the session cookie set by the response (or the original if none was set).
+func sessionRequest(t *testing.T, app *fiber.App, path string, cookie *http.Cookie) (string, *http.Cookie) {
+	t.Helper()
+	req := httpGet(path)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("request %s failed: %v", path, err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	for _, c := range resp.Cookies() {
+		if c.Name == "test_session" {
+			if c.Value == "" {
+				return string(body), nil
+			}
+			return string(body), c
+		}
+	}
+	return string(body), cookie
+}
+
+func TestSessions_Stores(t *testing.T) {
+	stores := map[string]SessionStore{
+		"cookie": NewCookieSessionStore("test-secret-key-32-characters-xx"),
+		"memory": NewMemorySessionStore(),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			app := newSessionTestApp(NewSessions(SessionsConfig{Store: store, CookieName: "test_session", Logger: testLogger()}))
+
+			_, cookie := sessionRequest(t, app, "/set", nil)
+			if cookie == nil {
+				t.Fatal("expected session cookie to be set")
+			}
+
+			body, cookie := sessionRequest(t, app, "/get", cookie)
+			if body != "ana|saved" {
+				t.Errorf("expected %q, got %q", "ana|saved", body)
+			}
+
+			// Flash is consumed; values persist
+			body, cookie = sessionRequest(t, app, "/get", cookie)
+			if body != "ana|" {
+				t.Errorf("expected %q, got %q", "ana|", body)
+			}
+
+			_, cookie = sessionRequest(t, app, "/logout", cookie)
+			if cookie != nil {
+				t.Error("expected session cookie to be cleared")
+			}
+			if body, _ = sessionRequest(t, app, "/get", cookie); body != "|" {
+				t.Errorf("expected empty session after logout, got %q", body)
+			}
+		})
+	}
+}
+
+func TestSessions_UnmodifiedSessionNotSaved(t *testing.T) {
+	app := newSessionTestApp(NewSessions(SessionsConfig{Store: NewMemorySessionStore(), CookieName: "test_session", Logger: testLogger()}))
+
+	_, cookie := sessionRequest(t, app, "/get", nil)
+	if cookie != nil {
+		t.Error("expected no cookie for a session that was never written")
+	}
+}
+
+func TestSessions_TypedValuesAndFlashes(t *testing.T) {
+	type cart struct {
+		Items []string `json:"items"`
+		Total int      `json:"total"`
+	}
+
+	app := fiber.New()
+	sessions := NewSessions(SessionsConfig{Store: NewMemorySessionStore(), CookieName: "test_session", Logger: testLogger()})
+	app.Use(sessions.Middleware())
+	app.Get("/set", func(c *fiber.Ctx) error {
+		sess := (&Context{Ctx: c}).Session()
+		sess.Set("count", 3)
+		sess.Set("admin", true)
+		sess.Set("cart", cart{Items: []string{"tea"}, Total: 450})
+		sess.AddFlash("success", "Saved")
+		sess.AddFlash("info", "Check your email")
+		return c.SendString("ok")
+	})
+	app.Get("/get", func(c *fiber.Ctx) error {
+		sess := (&Context{Ctx: c}).Session()
+		count, _ := sess.GetInt("count")
+		admin, _ := sess.GetBool("admin")
+		_, missing := sess.GetString("count")
+		ct, _ := SessionValue[cart](sess, "cart")
+		body := fmt.Sprintf("%d|%t|%t|%v|%d", count, admin, missing, ct.Items, ct.Total)
+		for _, m := range sess.Flashes() {
+			body += "|" + m.Type + ":" + m.Message
+		}
+		return c.SendString(body)
+	})
+	app.Get("/read", func(c *fiber.Ctx) error {
+		sess := (&Context{Ctx: c}).Session()
+		sess.Get("count")
+		return c.SendString(fmt.Sprint(sess.Modified()))
+	})
+
+	_, cookie := sessionRequest(t, app, "/set", nil)
+	body, cookie := sessionRequest(t, app, "/get", cookie)
+	want := "3|true|false|[tea]|450|success:Saved|info:Check your email"
+	if body != want {
+		t.Errorf("expected %q, got %q", want, body)
+	}
+
+	// Reading without flashes pending doesn't rewrite the session
+	if body, _ = sessionRequest(t, app, "/read", cookie); body != "false" {
+		t.Errorf("expected unmodified session, got %q", body)
+	}
+}
+
+func TestMemorySessionStore_DeleteExpired(t *testing.T) {
+	store := NewMemorySessionStore()
+	ctx := context.Background()
+
+	expired, _ := store.Save(ctx, "", []byte("a"), -time.Minute)
+	live, _ := store.Save(ctx, "", []byte("b"), time.Hour)
+
+	n, err := store.DeleteExpired(ctx)
+	if err != nil {
+		t.Fatalf("DeleteExpired failed: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 expired session, got %d", n)
+	}
+	if data, _ := store.Load(ctx, expired); data != nil {
+		t.Error("expected expired session to be gone")
+	}
+	if data, _ := store.Load(ctx, live); string(data) != "b" {
+		t.Errorf("expected live session, got %q", data)
+	}
+}
+
+func TestDatabaseSessionStore(t *testing.T) {
+	db := openAsyncTestDB(t)
+	store, err := NewDatabaseSessionStore(&mockDBManager{db: db})
+	if err != nil {
+		t.Fatalf("NewDatabaseSessionStore failed: %v", err)
+	}
+	ctx := context.Background()
+
+	token, err := store.Save(ctx, "", []byte(`{"v":{"k":1}}`), time.Hour)
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if data, _ := store.Load(ctx, token); string(data) != `{"v":{"k":1}}` {
+		t.Errorf("unexpected data %q", data)
+	}
+
+	if _, err := store.Save(ctx, "old", []byte("x"), -time.Minute); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	n, err := store.DeleteExpired(ctx)
+	if err != nil || n != 1 {
+		t.Errorf("expected 1 expired row removed, got %d (%v)", n, err)
+	}
+}
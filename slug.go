@@ -0,0 +1,117 @@
+package cartridge
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+	"gorm.io/gorm"
+)
+
+// ErrEmptySlug is returned when the slug source has no usable characters.
+var ErrEmptySlug = errors.New("cartridge: slug source has no letters or digits")
+
+// SlugifyString converts s into a lowercase, URL-safe slug: accents are
+// stripped, runs of other characters become single hyphens.
+//
+//	SlugifyString("Crème Brûlée: 10 Tips!") // "creme-brulee-10-tips"
+func SlugifyString(s string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range norm.NFD.String(s) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// Combining mark left over from decomposing an accented letter
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			hyphen = false
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			hyphen = true
+		}
+	}
+	return b.String()
+}
+
+// UniqueSlug slugifies source and makes it unique within table.column by
+// appending -2, -3, ... on collision. The lookup runs in a transaction; add a
+// unique index on the column so concurrent inserts can't both win.
+func UniqueSlug(db *gorm.DB, table, column, source string) (string, error) {
+	base := SlugifyString(source)
+	if base == "" {
+		return "", ErrEmptySlug
+	}
+
+	var slug string
+	err := db.Session(&gorm.Session{NewDB: true}).Transaction(func(tx *gorm.DB) error {
+		var taken []string
+		err := tx.Table(table).
+			Where(fmt.Sprintf("%s = ? OR %s LIKE ?", column, column), base, base+"-%").
+			Pluck(column, &taken).Error
+		if err != nil {
+			return err
+		}
+		slug = nextSlug(base, taken)
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("cartridge: unique slug: %w", err)
+	}
+	return slug, nil
+}
+
+// nextSlug returns base if unused, otherwise base-N with N one past the
+// highest numeric suffix in use.
+func nextSlug(base string, taken []string) string {
+	baseTaken := false
+	highest := 1
+	for _, s := range taken {
+		if s == base {
+			baseTaken = true
+			continue
+		}
+		if n, err := strconv.Atoi(strings.TrimPrefix(s, base+"-")); err == nil && n > highest {
+			highest = n
+		}
+	}
+	if !baseTaken {
+		return base
+	}
+	return base + "-" + strconv.Itoa(highest+1)
+}
+
+// SetUniqueSlug fills a model's slug column from source during a GORM hook,
+// leaving slugs that are already set untouched.
+//
+//	func (p *Post) BeforeCreate(tx *gorm.DB) error {
+//	    return cartridge.SetUniqueSlug(tx, "slug", p.Title)
+//	}
+func SetUniqueSlug(tx *gorm.DB, column, source string) error {
+	stmt := tx.Statement
+	if stmt.Schema == nil {
+		return errors.New("cartridge: SetUniqueSlug must be called from a model hook")
+	}
+	if stmt.ReflectValue.Kind() != reflect.Struct {
+		return errors.New("cartridge: SetUniqueSlug supports single-record creates only")
+	}
+	field := stmt.Schema.LookUpField(column)
+	if field == nil {
+		return fmt.Errorf("cartridge: model %s has no %q field", stmt.Schema.Name, column)
+	}
+	if _, zero := field.ValueOf(stmt.Context, stmt.ReflectValue); !zero {
+		return nil
+	}
+
+	slug, err := UniqueSlug(tx, stmt.Table, field.DBName, source)
+	if err != nil {
+		return err
+	}
+	stmt.SetColumn(field.DBName, slug)
+	return nil
+}
@@ -0,0 +1,71 @@
+package cartridge
+
+import (
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestSlugifyString(t *testing.T) {
+	tests := map[string]string{
+		"Hello World":             "hello-world",
+		"Crème Brûlée: 10 Tips!":  "creme-brulee-10-tips",
+		"  --Already-slugged--  ": "already-slugged",
+		"C++ & Go":                "c-go",
+		"日本語":                     "",
+	}
+	for input, want := range tests {
+		if got := SlugifyString(input); got != want {
+			t.Errorf("SlugifyString(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+type slugPost struct {
+	ID    uint
+	Title string
+	Slug  string `gorm:"uniqueIndex"`
+}
+
+func (p *slugPost) BeforeCreate(tx *gorm.DB) error {
+	return SetUniqueSlug(tx, "slug", p.Title)
+}
+
+func TestUniqueSlug(t *testing.T) {
+	db := openAsyncTestDB(t)
+	if err := db.AutoMigrate(&slugPost{}); err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+
+	want := []string{"hello-world", "hello-world-2", "hello-world-3"}
+	for _, expected := range want {
+		post := slugPost{Title: "Hello, World"}
+		if err := db.Create(&post).Error; err != nil {
+			t.Fatalf("create failed: %v", err)
+		}
+		if post.Slug != expected {
+			t.Errorf("expected slug %q, got %q", expected, post.Slug)
+		}
+	}
+
+	// Unrelated slugs sharing the prefix don't count as collisions
+	db.Create(&slugPost{Title: "x", Slug: "hello-world-tips"})
+	slug, err := UniqueSlug(db, "slug_posts", "slug", "Hello World")
+	if err != nil {
+		t.Fatalf("UniqueSlug failed: %v", err)
+	}
+	if slug != "hello-world-4" {
+		t.Errorf("expected hello-world-4, got %q", slug)
+	}
+
+	// Explicit slugs are kept
+	post := slugPost{Title: "Other", Slug: "custom"}
+	db.Create(&post)
+	if post.Slug != "custom" {
+		t.Errorf("expected explicit slug to be kept, got %q", post.Slug)
+	}
+
+	if _, err := UniqueSlug(db, "slug_posts", "slug", "!!!"); err != ErrEmptySlug {
+		t.Errorf("expected ErrEmptySlug, got %v", err)
+	}
+}
@@ -0,0 +1,485 @@
+package cartridge
+
+import (
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MigrationKind classifies a migration for blue/green and rolling deploys.
+type MigrationKind string
+
+const (
+	// MigrationExpand only adds to the schema (new tables, nullable columns,
+	// indexes), so the previous app version keeps working against it.
+	MigrationExpand MigrationKind = "expand"
+
+	// MigrationContract removes or reshapes schema the previous app version
+	// may still use (drops, renames, type changes, new NOT NULL constraints).
+	// Run it only once no instance of the old version is serving traffic.
+	MigrationContract MigrationKind = "contract"
+)
+
+// SQLMigration is one versioned SQL migration.
+type SQLMigration struct {
+	// Version orders migrations and identifies them once applied, e.g.
+	// "20240501120000". Versions are compared as strings, so keep them the
+	// same length.
+	Version string
+
+	// Name describes the migration, e.g. "add_orders_status".
+	Name string
+
+	// SQL holds one or more statements separated by semicolons.
+	SQL string
+
+	// Down reverts SQL, for Rollback. Optional; migrations without it
+	// can't be rolled back.
+	Down string
+
+	// Kind overrides the classification from ClassifyMigration, for
+	// statements the simple analysis gets wrong. Default: classified from SQL
+	Kind MigrationKind
+}
+
+// kind returns the explicit or classified kind and, for contract
+// migrations, the statement that made it one.
+func (m SQLMigration) kind() (MigrationKind, string) {
+	if m.Kind != "" {
+		return m.Kind, ""
+	}
+	return classifySQL(m.SQL)
+}
+
+// SchemaMigration records an applied migration.
+type SchemaMigration struct {
+	Version    string `gorm:"primaryKey;size:64"`
+	Name       string `gorm:"size:255"`
+	Kind       string `gorm:"size:16"`
+	AppVersion string `gorm:"size:64"`
+	AppliedAt  time.Time
+}
+
+// TableName specifies the table name.
+func (SchemaMigration) TableName() string {
+	return "cartridge_schema_migrations"
+}
+
+// SchemaRequirement records the schema version an app version was built
+// against, so operators can tell which releases a contract migration breaks.
+type SchemaRequirement struct {
+	AppVersion    string `gorm:"primaryKey;size:64"`
+	SchemaVersion string `gorm:"size:64"`
+	RecordedAt    time.Time
+}
+
+// TableName specifies the table name.
+func (SchemaRequirement) TableName() string {
+	return "cartridge_schema_requirements"
+}
+
+// SQLMigratorConfig configures SQLMigrator.
+type SQLMigratorConfig struct {
+	// AppVersion is recorded with applied migrations and in
+	// cartridge_schema_requirements, e.g. a release tag or commit SHA.
+	// Optional.
+	AppVersion string
+
+	// AllowContract lets Run() apply contract migrations at startup, e.g.
+	// from an ALLOW_CONTRACT_MIGRATIONS environment variable on the final
+	// deploy step. Default: false
+	AllowContract bool
+
+	// ContractDelay lets Run() apply a contract migration once the last
+	// migration has been live this long, giving old instances time to
+	// drain. Default: 0 (contract migrations wait for AllowContract)
+	ContractDelay time.Duration
+
+	// Logger reports held-back migrations. Default: slog.Default()
+	Logger Logger
+}
+
+// SQLMigrator applies versioned SQL migrations in order, each in its own
+// transaction, and records them in cartridge_schema_migrations.
+//
+// Migrate (used by App.MigrateDatabase) applies everything. At startup,
+// Run() uses MigrateStartup, which stops before the first contract
+// migration unless SQLMigratorConfig allows it:
+//
+//	migrator := cartridge.NewSQLMigrator(cartridge.SQLMigratorConfig{
+//	    AppVersion:    version,
+//	    ContractDelay: 24 * time.Hour,
+//	}, migrations...)
+type SQLMigrator struct {
+	cfg        SQLMigratorConfig
+	migrations []SQLMigration
+
+	// fsys, when set, is read again before each run (see NewSQLMigratorFS)
+	fsys fs.FS
+	mu   sync.Mutex
+}
+
+// NewSQLMigrator creates a migrator for migrations. They are sorted by Version.
+func NewSQLMigrator(cfg SQLMigratorConfig, migrations ...SQLMigration) *SQLMigrator {
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+	return &SQLMigrator{cfg: cfg, migrations: sortMigrations(migrations)}
+}
+
+func sortMigrations(migrations []SQLMigration) []SQLMigration {
+	sorted := slices.Clone(migrations)
+	slices.SortStableFunc(sorted, func(a, b SQLMigration) int {
+		return strings.Compare(a.Version, b.Version)
+	})
+	return sorted
+}
+
+// current returns the migrations, first reloading them from fsys if the
+// migrator has one.
+func (m *SQLMigrator) current() ([]SQLMigration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.fsys != nil {
+		migrations, err := LoadSQLMigrations(m.fsys)
+		if err != nil {
+			return nil, err
+		}
+		m.migrations = sortMigrations(migrations)
+	}
+	return m.migrations, nil
+}
+
+// Migrate applies all pending migrations, including contract ones.
+func (m *SQLMigrator) Migrate(db *gorm.DB) error {
+	return m.migrate(db, true)
+}
+
+// MigrateStartup applies pending migrations up to the first contract
+// migration that AllowContract or ContractDelay doesn't let through. Held
+// back migrations are logged and left for a later deploy or Migrate. On a
+// database with no recorded migrations everything runs, since no previous
+// app version can be serving from it.
+func (m *SQLMigrator) MigrateStartup(db *gorm.DB) error {
+	return m.migrate(db, m.cfg.AllowContract)
+}
+
+// Pending returns the migrations not yet applied, in order.
+func (m *SQLMigrator) Pending(db *gorm.DB) ([]SQLMigration, error) {
+	migrations, err := m.current()
+	if err != nil {
+		return nil, err
+	}
+	return pendingMigrations(db, migrations)
+}
+
+func pendingMigrations(db *gorm.DB, migrations []SQLMigration) ([]SQLMigration, error) {
+	if err := db.AutoMigrate(&SchemaMigration{}, &SchemaRequirement{}); err != nil {
+		return nil, fmt.Errorf("cartridge: migrate schema tables: %w", err)
+	}
+
+	var applied []string
+	if err := db.Model(&SchemaMigration{}).Pluck("version", &applied).Error; err != nil {
+		return nil, fmt.Errorf("cartridge: load applied migrations: %w", err)
+	}
+	done := make(map[string]bool, len(applied))
+	for _, version := range applied {
+		done[version] = true
+	}
+
+	var pending []SQLMigration
+	for _, migration := range migrations {
+		if !done[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Status lists every migration, applied or pending, in order.
+func (m *SQLMigrator) Status(db *gorm.DB) ([]MigrationStatus, error) {
+	migrations, err := m.current()
+	if err != nil {
+		return nil, err
+	}
+	if err := db.AutoMigrate(&SchemaMigration{}, &SchemaRequirement{}); err != nil {
+		return nil, fmt.Errorf("cartridge: migrate schema tables: %w", err)
+	}
+	var applied []SchemaMigration
+	if err := db.Find(&applied).Error; err != nil {
+		return nil, fmt.Errorf("cartridge: load applied migrations: %w", err)
+	}
+	done := make(map[string]SchemaMigration, len(applied))
+	for _, rec := range applied {
+		done[rec.Version] = rec
+	}
+
+	statuses := make([]MigrationStatus, len(migrations))
+	for i, migration := range migrations {
+		kind, _ := migration.kind()
+		statuses[i] = MigrationStatus{Version: migration.Version, Name: migration.Name, Kind: kind}
+		if rec, ok := done[migration.Version]; ok {
+			appliedAt := rec.AppliedAt
+			statuses[i].AppliedAt = &appliedAt
+		}
+	}
+	return statuses, nil
+}
+
+// Rollback reverts the last steps applied migrations, newest first, each
+// in its own transaction with its Down SQL. It stops at the first
+// migration without Down, or one this migrator doesn't know, and returns
+// the migrations it reverted.
+func (m *SQLMigrator) Rollback(db *gorm.DB, steps int) ([]MigrationStatus, error) {
+	migrations, err := m.current()
+	if err != nil {
+		return nil, err
+	}
+	if err := db.AutoMigrate(&SchemaMigration{}, &SchemaRequirement{}); err != nil {
+		return nil, fmt.Errorf("cartridge: migrate schema tables: %w", err)
+	}
+	var latest []SchemaMigration
+	if err := db.Order("version DESC").Limit(max(steps, 1)).Find(&latest).Error; err != nil {
+		return nil, fmt.Errorf("cartridge: load applied migrations: %w", err)
+	}
+
+	var reverted []MigrationStatus
+	for _, rec := range latest {
+		i := slices.IndexFunc(migrations, func(migration SQLMigration) bool {
+			return migration.Version == rec.Version
+		})
+		if i < 0 {
+			return reverted, fmt.Errorf("cartridge: migration %s %s is not known to this version of the app", rec.Version, rec.Name)
+		}
+		migration := migrations[i]
+		if strings.TrimSpace(migration.Down) == "" {
+			return reverted, fmt.Errorf("cartridge: migration %s %s has no Down SQL", migration.Version, migration.Name)
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			for _, stmt := range splitSQL(migration.Down) {
+				if err := tx.Exec(stmt).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Delete(&SchemaMigration{}, "version = ?", migration.Version).Error
+		})
+		if err != nil {
+			return reverted, fmt.Errorf("cartridge: roll back migration %s %s: %w", migration.Version, migration.Name, err)
+		}
+		kind, _ := migration.kind()
+		reverted = append(reverted, MigrationStatus{Version: migration.Version, Name: migration.Name, Kind: kind})
+	}
+	return reverted, nil
+}
+
+func (m *SQLMigrator) migrate(db *gorm.DB, allowContract bool) error {
+	migrations, err := m.current()
+	if err != nil {
+		return err
+	}
+	pending, err := pendingMigrations(db, migrations)
+	if err != nil {
+		return err
+	}
+	if err := m.recordRequirement(db, migrations); err != nil {
+		return err
+	}
+	var applied int64
+	if err := db.Model(&SchemaMigration{}).Count(&applied).Error; err != nil {
+		return fmt.Errorf("cartridge: count applied migrations: %w", err)
+	}
+	allowContract = allowContract || applied == 0
+
+	for i, migration := range pending {
+		kind, statement := migration.kind()
+		if kind == MigrationContract && !allowContract {
+			ready, err := m.contractDelayElapsed(db)
+			if err != nil {
+				return err
+			}
+			if !ready {
+				m.cfg.Logger.Warn("contract migration held back",
+					slog.String("version", migration.Version),
+					slog.String("name", migration.Name),
+					slog.String("statement", statement),
+					slog.Int("remaining", len(pending)-i))
+				return nil
+			}
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			for _, stmt := range splitSQL(migration.SQL) {
+				if err := tx.Exec(stmt).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Create(&SchemaMigration{
+				Version:    migration.Version,
+				Name:       migration.Name,
+				Kind:       string(kind),
+				AppVersion: m.cfg.AppVersion,
+				AppliedAt:  time.Now().UTC(),
+			}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("cartridge: migration %s %s: %w", migration.Version, migration.Name, err)
+		}
+	}
+	return nil
+}
+
+// contractDelayElapsed reports whether the last applied migration is older
+// than ContractDelay.
+func (m *SQLMigrator) contractDelayElapsed(db *gorm.DB) (bool, error) {
+	if m.cfg.ContractDelay <= 0 {
+		return false, nil
+	}
+	var last SchemaMigration
+	err := db.Order("applied_at DESC").Limit(1).Find(&last).Error
+	if err != nil {
+		return false, fmt.Errorf("cartridge: load last migration: %w", err)
+	}
+	return time.Since(last.AppliedAt) >= m.cfg.ContractDelay, nil
+}
+
+// recordRequirement stores the newest migration version as the schema this
+// AppVersion needs.
+func (m *SQLMigrator) recordRequirement(db *gorm.DB, migrations []SQLMigration) error {
+	if m.cfg.AppVersion == "" || len(migrations) == 0 {
+		return nil
+	}
+	req := SchemaRequirement{
+		AppVersion:    m.cfg.AppVersion,
+		SchemaVersion: migrations[len(migrations)-1].Version,
+		RecordedAt:    time.Now().UTC(),
+	}
+	err := db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&req).Error
+	if err != nil {
+		return fmt.Errorf("cartridge: record schema requirement: %w", err)
+	}
+	return nil
+}
+
+// ClassifyMigration reports whether sql is an expand or contract migration.
+// The analysis is deliberately simple: any statement that drops, renames,
+// truncates, deletes rows, changes a column type or adds a NOT NULL
+// constraint without a default makes the migration a contract migration.
+func ClassifyMigration(sql string) MigrationKind {
+	kind, _ := classifySQL(sql)
+	return kind
+}
+
+var (
+	contractPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`^DROP (TABLE|VIEW|SCHEMA|DATABASE|TYPE|SEQUENCE)\b`),
+		regexp.MustCompile(`^(TRUNCATE|DELETE FROM|RENAME)\b`),
+		regexp.MustCompile(`^ALTER TABLE .*\bRENAME\b`),
+		regexp.MustCompile(`^ALTER TABLE .*\bDROP\b`),
+		regexp.MustCompile(`^ALTER TABLE .*\b(MODIFY|CHANGE)\b`),
+		regexp.MustCompile(`^ALTER TABLE .*\bALTER (COLUMN )?\S+ (SET DATA )?TYPE\b`),
+		regexp.MustCompile(`^ALTER TABLE .*\bSET NOT NULL\b`),
+	}
+	// Dropping a default, NOT NULL constraint or index only relaxes the schema
+	expandDrops = regexp.MustCompile(`\bDROP (DEFAULT|NOT NULL|INDEX|KEY)\b`)
+	addNotNull  = regexp.MustCompile(`^ALTER TABLE .*\bADD\b.*\bNOT NULL\b`)
+	whitespace  = regexp.MustCompile(`\s+`)
+)
+
+// classifySQL classifies sql and returns the first contract statement.
+func classifySQL(sql string) (MigrationKind, string) {
+	for _, stmt := range splitSQL(sql) {
+		normalized := strings.ToUpper(whitespace.ReplaceAllString(blankLiterals(stmt), " "))
+		if isContractStatement(normalized) {
+			return MigrationContract, stmt
+		}
+	}
+	return MigrationExpand, ""
+}
+
+func isContractStatement(stmt string) bool {
+	if addNotNull.MatchString(stmt) && !strings.Contains(stmt, " DEFAULT ") {
+		return true
+	}
+	stmt = expandDrops.ReplaceAllString(stmt, "")
+	for _, pattern := range contractPatterns {
+		if pattern.MatchString(stmt) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitSQL splits sql into statements on semicolons outside quotes and
+// comments, dropping comments and empty statements.
+func splitSQL(sql string) []string {
+	var stmts []string
+	var b strings.Builder
+	flush := func() {
+		if stmt := strings.TrimSpace(b.String()); stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+		b.Reset()
+	}
+
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == '-' && strings.HasPrefix(sql[i:], "--"):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				i = len(sql)
+			} else {
+				i += end
+				b.WriteByte('\n')
+			}
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				i = len(sql)
+			} else {
+				i += end + 3
+				b.WriteByte(' ')
+			}
+		case c == '\'' || c == '"' || c == '`':
+			end := i + 1
+			for end < len(sql) && sql[end] != c {
+				end++
+			}
+			b.WriteString(sql[i:min(end+1, len(sql))])
+			i = end
+		case c == ';':
+			flush()
+		default:
+			b.WriteByte(c)
+		}
+	}
+	flush()
+	return stmts
+}
+
+// blankLiterals replaces string literal contents so keywords inside them
+// don't affect classification.
+func blankLiterals(stmt string) string {
+	var b strings.Builder
+	inLiteral := false
+	for i := 0; i < len(stmt); i++ {
+		if stmt[i] == '\'' {
+			inLiteral = !inLiteral
+			b.WriteByte('\'')
+			continue
+		}
+		if !inLiteral {
+			b.WriteByte(stmt[i])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,228 @@
+package cartridge
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SQLMigrationsDir holds SQL migration files, both on disk in development
+// and in an embedded filesystem.
+const SQLMigrationsDir = "migrations"
+
+// sqlMigrationFile matches "<version>_<name>.sql" and "<version>_<name>.down.sql".
+var sqlMigrationFile = regexp.MustCompile(`^(\d+)_(\w+?)(\.down)?\.sql$`)
+
+// LoadSQLMigrations reads migrations from the *.sql files in fsys's
+// "migrations" directory, or its root when it has none. Files are named
+// <version>_<name>.sql, e.g. 20240501120000_add_orders_status.sql, and an
+// optional <version>_<name>.down.sql holds the migration's Down SQL. Other
+// files are ignored.
+//
+//	//go:embed migrations/*.sql
+//	var migrationsFS embed.FS
+//
+//	migrations, err := cartridge.LoadSQLMigrations(migrationsFS)
+func LoadSQLMigrations(fsys fs.FS) ([]SQLMigration, error) {
+	dir := "."
+	if info, err := fs.Stat(fsys, SQLMigrationsDir); err == nil && info.IsDir() {
+		dir = SQLMigrationsDir
+	}
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("cartridge: read migrations: %w", err)
+	}
+
+	byVersion := make(map[string]*SQLMigration)
+	var migrations []*SQLMigration
+	for _, entry := range entries {
+		match := sqlMigrationFile.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, name, down := match[1], match[2], match[3] != ""
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("cartridge: read migration %s: %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &SQLMigration{Version: version, Name: name}
+			byVersion[version] = migration
+			migrations = append(migrations, migration)
+		} else if migration.Name != name {
+			return nil, fmt.Errorf("cartridge: migrations %s_%s and %s_%s share a version", version, migration.Name, version, name)
+		}
+		if down {
+			migration.Down = string(data)
+		} else {
+			migration.SQL = string(data)
+		}
+	}
+
+	loaded := make([]SQLMigration, 0, len(migrations))
+	for _, migration := range migrations {
+		if strings.TrimSpace(migration.SQL) == "" {
+			return nil, fmt.Errorf("cartridge: migration %s_%s has no SQL", migration.Version, migration.Name)
+		}
+		loaded = append(loaded, *migration)
+	}
+	return loaded, nil
+}
+
+// NewSQLMigratorFS creates a migrator for the SQL files in fsys (see
+// LoadSQLMigrations). The files are read again on every run, so with
+// os.DirFS a running development app picks up new and edited migrations.
+func NewSQLMigratorFS(cfg SQLMigratorConfig, fsys fs.FS) (*SQLMigrator, error) {
+	migrations, err := LoadSQLMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+	m := NewSQLMigrator(cfg, migrations...)
+	m.fsys = fsys
+	return m, nil
+}
+
+// sqlMigrationsSource returns the filesystem to read migrations from: the
+// migrations directory on disk in development, when it exists, and
+// embedded otherwise. Both are read with LoadSQLMigrations, so they hold
+// the same "migrations/<version>_<name>.sql" paths.
+func sqlMigrationsSource(embedded fs.FS, development bool) fs.FS {
+	if development {
+		if info, err := os.Stat(SQLMigrationsDir); err == nil && info.IsDir() {
+			return os.DirFS(".")
+		}
+	}
+	return embedded
+}
+
+// sqlMigrationsOption holds WithSQLMigrations' arguments.
+type sqlMigrationsOption struct {
+	fsys fs.FS
+	cfg  SQLMigratorConfig
+}
+
+// migrator creates the app's SQLMigrator and, when it reads migrations from
+// disk in development, the worker applying them as they change.
+func (o *sqlMigrationsOption) migrator(development bool, logger Logger) (*SQLMigrator, BackgroundWorker, error) {
+	cfg := o.cfg
+	if cfg.Logger == nil {
+		cfg.Logger = logger
+	}
+	fsys := sqlMigrationsSource(o.fsys, development)
+	if fsys == nil {
+		return nil, nil, fmt.Errorf("cartridge: no %s directory and no embedded migrations", SQLMigrationsDir)
+	}
+	migrator, err := NewSQLMigratorFS(cfg, fsys)
+	if err != nil {
+		return nil, nil, err
+	}
+	if fsys == o.fsys {
+		return migrator, nil, nil
+	}
+	return migrator, &sqlMigrationWatcher{migrator: migrator, interval: time.Second}, nil
+}
+
+// sqlMigrationWatcher applies migrations added to a filesystem-backed
+// SQLMigrator while a development app runs.
+type sqlMigrationWatcher struct {
+	migrator  *SQLMigrator
+	interval  time.Duration
+	logger    Logger
+	dbManager DBManager
+
+	cancel context.CancelFunc
+	done   sync.WaitGroup
+}
+
+// SetupWorker implements WorkerSetup.
+func (w *sqlMigrationWatcher) SetupWorker(logger Logger, dbManager DBManager) {
+	w.logger = logger
+	w.dbManager = dbManager
+}
+
+// Start polls the migration files and runs MigrateStartup when they change.
+func (w *sqlMigrationWatcher) Start() error {
+	if w.dbManager == nil {
+		return errors.New("cartridge: migration watcher needs a database manager")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	last := w.fingerprint()
+
+	w.done.Add(1)
+	go func() {
+		defer w.done.Done()
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			current := w.fingerprint()
+			if current == last {
+				continue
+			}
+			last = current
+			w.apply()
+		}
+	}()
+	return nil
+}
+
+// Stop ends polling.
+func (w *sqlMigrationWatcher) Stop() {
+	if w.cancel != nil {
+		w.cancel()
+		w.done.Wait()
+	}
+}
+
+// apply runs the pending migrations after a change.
+func (w *sqlMigrationWatcher) apply() {
+	db, err := w.dbManager.Connect()
+	if err != nil {
+		w.logger.Error("migration watcher: connect database", "error", err)
+		return
+	}
+	pending, err := w.migrator.Pending(db)
+	if err != nil {
+		w.logger.Error("migration watcher: load migrations", "error", err)
+		return
+	}
+	if len(pending) == 0 {
+		w.logger.Info("migration files changed; applied migrations are not rerun (use migrate:rollback)")
+		return
+	}
+	if err := w.migrator.MigrateStartup(db); err != nil {
+		w.logger.Error("migration watcher: migrate", "error", err)
+		return
+	}
+	for _, migration := range pending {
+		w.logger.Info("migration applied", "version", migration.Version, "name", migration.Name)
+	}
+}
+
+// fingerprint hashes the migration files' names and contents.
+func (w *sqlMigrationWatcher) fingerprint() string {
+	migrations, err := LoadSQLMigrations(w.migrator.fsys)
+	if err != nil {
+		return "error: " + err.Error()
+	}
+	h := sha256.New()
+	for _, migration := range migrations {
+		fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\x00", migration.Version, migration.Name, migration.SQL, migration.Down)
+	}
+	return string(h.Sum(nil))
+}
@@ -0,0 +1,201 @@
+package cartridge
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestClassifyMigration(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want MigrationKind
+	}{
+		{"CREATE TABLE orders (id INTEGER PRIMARY KEY)", MigrationExpand},
+		{"ALTER TABLE orders ADD COLUMN status TEXT", MigrationExpand},
+		{"alter table orders add column status text not null default 'new'", MigrationExpand},
+		{"CREATE INDEX idx_orders_status ON orders (status); DROP INDEX idx_old", MigrationExpand},
+		{"ALTER TABLE orders ALTER COLUMN status DROP NOT NULL", MigrationExpand},
+		{"UPDATE orders SET note = 'DROP TABLE users'", MigrationExpand},
+		{"-- DROP TABLE orders\nALTER TABLE orders ADD COLUMN note TEXT", MigrationExpand},
+		{"ALTER TABLE orders ADD COLUMN status TEXT NOT NULL", MigrationContract},
+		{"ALTER TABLE orders DROP COLUMN legacy", MigrationContract},
+		{"ALTER TABLE orders RENAME COLUMN total TO amount", MigrationContract},
+		{"ALTER TABLE orders ALTER COLUMN total TYPE numeric(12,2)", MigrationContract},
+		{"ALTER TABLE orders ALTER COLUMN status SET NOT NULL", MigrationContract},
+		{"ALTER TABLE orders MODIFY total DECIMAL(12,2)", MigrationContract},
+		{"CREATE TABLE archive (id INT);\n\ndrop table orders;", MigrationContract},
+		{"DELETE FROM sessions", MigrationContract},
+	}
+	for _, tc := range tests {
+		if got := ClassifyMigration(tc.sql); got != tc.want {
+			t.Errorf("ClassifyMigration(%q) = %s, want %s", tc.sql, got, tc.want)
+		}
+	}
+}
+
+func TestSQLMigrator_ExpandContract(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "migrations.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+
+	v1 := SQLMigration{Version: "001", Name: "create_orders", SQL: "CREATE TABLE orders (id INTEGER PRIMARY KEY, legacy TEXT)"}
+	v2 := SQLMigration{Version: "002", Name: "add_status", SQL: "ALTER TABLE orders ADD COLUMN status TEXT"}
+	v3 := SQLMigration{Version: "003", Name: "drop_legacy", SQL: "ALTER TABLE orders DROP COLUMN legacy"}
+
+	if err := NewSQLMigrator(SQLMigratorConfig{AppVersion: "1.0.0", Logger: testLogger()}, v1).MigrateStartup(db); err != nil {
+		t.Fatalf("MigrateStartup failed: %v", err)
+	}
+
+	// The expand migration runs at startup, the contract one waits
+	migrator := NewSQLMigrator(SQLMigratorConfig{AppVersion: "1.1.0", Logger: testLogger()}, v3, v2, v1)
+	if err := migrator.MigrateStartup(db); err != nil {
+		t.Fatalf("MigrateStartup failed: %v", err)
+	}
+	pending, err := migrator.Pending(db)
+	if err != nil || len(pending) != 1 || pending[0].Version != "003" {
+		t.Fatalf("expected only the contract migration pending, got %v, %v", pending, err)
+	}
+	if !db.Migrator().HasColumn("orders", "status") || !db.Migrator().HasColumn("orders", "legacy") {
+		t.Fatal("expected status added and legacy kept")
+	}
+
+	// Once the last migration has been live for ContractDelay it runs
+	time.Sleep(20 * time.Millisecond)
+	delayed := NewSQLMigrator(SQLMigratorConfig{AppVersion: "1.1.0", ContractDelay: 10 * time.Millisecond, Logger: testLogger()}, v1, v2, v3)
+	if err := delayed.MigrateStartup(db); err != nil {
+		t.Fatalf("MigrateStartup failed: %v", err)
+	}
+	if db.Migrator().HasColumn("orders", "legacy") {
+		t.Error("expected contract migration to run after the delay")
+	}
+
+	var applied SchemaMigration
+	db.First(&applied, "version = ?", "003")
+	if applied.Kind != string(MigrationContract) || applied.AppVersion != "1.1.0" {
+		t.Errorf("unexpected migration record %+v", applied)
+	}
+	var reqs []SchemaRequirement
+	db.Order("app_version").Find(&reqs)
+	if len(reqs) != 2 || reqs[0].SchemaVersion != "001" || reqs[1].SchemaVersion != "003" {
+		t.Errorf("unexpected schema requirements %+v", reqs)
+	}
+}
+
+func TestSQLMigrator_MigrateRunsContract(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "migrations.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+
+	create := SQLMigration{Version: "001", Name: "create_orders", SQL: "CREATE TABLE orders (id INTEGER PRIMARY KEY)"}
+	if err := NewSQLMigrator(SQLMigratorConfig{}, create).Migrate(db); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+
+	drop := SQLMigration{Version: "002", Name: "drop_orders", SQL: "DROP TABLE orders"}
+	if err := NewSQLMigrator(SQLMigratorConfig{}, create, drop).Migrate(db); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	if db.Migrator().HasTable("orders") {
+		t.Error("expected Migrate to apply contract migrations")
+	}
+
+	// A failing statement rolls back and is not recorded
+	bad := SQLMigration{Version: "003", Name: "broken", SQL: "CREATE TABLE ok (id INTEGER); SELECT * FROM missing"}
+	if err := NewSQLMigrator(SQLMigratorConfig{}, create, drop, bad).Migrate(db); err == nil {
+		t.Fatal("expected an error for a failing migration")
+	}
+	var count int64
+	db.Model(&SchemaMigration{}).Where("version = ?", "003").Count(&count)
+	if count != 0 {
+		t.Error("expected failed migration not to be recorded")
+	}
+}
+
+func TestLoadSQLMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/002_add_posts_title.sql":    {Data: []byte("ALTER TABLE posts ADD COLUMN title TEXT")},
+		"migrations/001_create_posts.sql":       {Data: []byte("CREATE TABLE posts (id INTEGER PRIMARY KEY)")},
+		"migrations/001_create_posts.down.sql":  {Data: []byte("DROP TABLE posts")},
+		"migrations/README.md":                  {Data: []byte("ignored")},
+		"migrations/archive/000_old_schema.sql": {Data: []byte("ignored")},
+	}
+	migrations, err := LoadSQLMigrations(fsys)
+	if err != nil || len(migrations) != 2 {
+		t.Fatalf("expected two migrations, got %+v (%v)", migrations, err)
+	}
+	if m := migrations[0]; m.Version != "001" || m.Name != "create_posts" || m.Down != "DROP TABLE posts" {
+		t.Errorf("expected the down file to be paired with its migration, got %+v", m)
+	}
+
+	// The root is used when there is no migrations directory
+	root := fstest.MapFS{"001_create_posts.sql": {Data: []byte("CREATE TABLE posts (id INTEGER)")}}
+	if migrations, err := LoadSQLMigrations(root); err != nil || len(migrations) != 1 {
+		t.Errorf("expected a migration from the root, got %+v (%v)", migrations, err)
+	}
+
+	for name, file := range map[string]string{
+		"share a version": "migrations/001_other.sql",
+		"has no SQL":      "migrations/003_empty.down.sql",
+	} {
+		bad := fstest.MapFS{file: {Data: []byte("DROP TABLE x")}}
+		for path, f := range fsys {
+			bad[path] = f
+		}
+		if _, err := LoadSQLMigrations(bad); err == nil || !strings.Contains(err.Error(), name) {
+			t.Errorf("expected %q error for %s, got %v", name, file, err)
+		}
+	}
+}
+
+func TestSQLMigratorFS_Reloads(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "migrations.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	dir := t.TempDir()
+	write := func(name, sql string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(sql), 0o644); err != nil {
+			t.Fatalf("failed to write migration: %v", err)
+		}
+	}
+	write("001_create_posts.sql", "CREATE TABLE posts (id INTEGER PRIMARY KEY)")
+	migrator, err := NewSQLMigratorFS(SQLMigratorConfig{}, os.DirFS(dir))
+	if err != nil {
+		t.Fatalf("NewSQLMigratorFS failed: %v", err)
+	}
+	if err := migrator.Migrate(db); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+
+	watcher := &sqlMigrationWatcher{migrator: migrator, interval: 10 * time.Millisecond}
+	watcher.SetupWorker(testLogger(), &mockDBManager{db: db})
+	if err := watcher.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer watcher.Stop()
+
+	// A file added while the app runs is applied
+	write("002_create_tags.sql", "CREATE TABLE tags (id INTEGER PRIMARY KEY)")
+	deadline := time.Now().Add(2 * time.Second)
+	for !db.Migrator().HasTable("tags") {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the new migration to be applied")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	statuses, err := migrator.Status(db)
+	if err != nil || len(statuses) != 2 || statuses[1].AppliedAt == nil {
+		t.Errorf("expected both migrations applied, got %+v (%v)", statuses, err)
+	}
+}
@@ -0,0 +1,183 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	sqlite3 "github.com/mattn/go-sqlite3"
+	"gorm.io/gorm"
+)
+
+// Encryption at rest uses SQLCipher. The default go-sqlite3 build bundles
+// plain SQLite, which silently ignores PRAGMA key, so encrypted databases
+// need the binary linked against SQLCipher instead:
+//
+//	CGO_CFLAGS="-DSQLITE_HAS_CODEC" CGO_LDFLAGS="-lsqlcipher" go build -tags libsqlite3
+//
+// Opening fails with ErrCipherUnavailable rather than writing plain text
+// when that isn't the case.
+
+var (
+	// ErrCipherUnavailable means a key was configured but the SQLite
+	// library is not SQLCipher.
+	ErrCipherUnavailable = errors.New("sqlite: encryption requires SQLCipher (build with -tags libsqlite3 against libsqlcipher)")
+
+	// ErrWrongKey means the database could not be read with the configured
+	// key: the key is wrong or the file is not encrypted (see EncryptFile).
+	ErrWrongKey = errors.New("sqlite: database cannot be decrypted with the configured key")
+)
+
+// cipherDrivers numbers the database/sql drivers registered per manager.
+var cipherDrivers atomic.Int64
+
+// Encrypted reports whether the database is opened with an encryption key.
+func (m *Manager) Encrypted() bool {
+	return m.cfg.EncryptionKey != "" || m.cfg.KeyProvider != nil
+}
+
+// resolveKey returns the configured encryption key.
+func (m *Manager) resolveKey() (string, error) {
+	if m.cfg.KeyProvider == nil {
+		return m.cfg.EncryptionKey, nil
+	}
+	key, err := m.cfg.KeyProvider()
+	if err != nil {
+		return "", fmt.Errorf("sqlite: load encryption key: %w", err)
+	}
+	if key == "" {
+		return "", fmt.Errorf("sqlite: key provider returned an empty encryption key")
+	}
+	return key, nil
+}
+
+// cipherDriver registers a driver that keys every new connection before
+// it touches the database file.
+func (m *Manager) cipherDriver() string {
+	name := fmt.Sprintf("cartridge_sqlcipher_%d", cipherDrivers.Add(1))
+	sql.Register(name, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			_, err := conn.Exec("PRAGMA key = "+quoteKey(m.key.Load().(string)), nil)
+			return err
+		},
+	})
+	return name
+}
+
+// checkCipher verifies SQLCipher is linked and the key opens the database.
+func checkCipher(db *gorm.DB) error {
+	var version string
+	if err := db.Raw("PRAGMA cipher_version").Scan(&version).Error; err != nil || version == "" {
+		return ErrCipherUnavailable
+	}
+	var tables int64
+	if err := db.Raw("SELECT count(*) FROM sqlite_master").Scan(&tables).Error; err != nil {
+		return fmt.Errorf("%w: %v", ErrWrongKey, err)
+	}
+	return nil
+}
+
+// Rekey re-encrypts the database with newKey. Update the key where
+// KeyProvider reads it once Rekey succeeds; until the manager is reopened
+// new connections use newKey. Rotate keys during a quiet period, e.g. as a
+// startup migration, since the database is briefly taken out of WAL mode.
+func (m *Manager) Rekey(ctx context.Context, newKey string) error {
+	if !m.Encrypted() {
+		return fmt.Errorf("sqlite: rekey needs an encrypted database")
+	}
+	if newKey == "" {
+		return fmt.Errorf("sqlite: rekey needs a non-empty key")
+	}
+	db, err := m.Connect()
+	if err != nil {
+		return err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("sqlite: access sql.DB: %w", err)
+	}
+
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// SQLCipher rewrites every page in place, which WAL mode doesn't allow
+	if _, err := conn.ExecContext(ctx, "PRAGMA journal_mode = DELETE"); err != nil {
+		return fmt.Errorf("sqlite: leave WAL mode: %w", err)
+	}
+	if _, err := conn.ExecContext(ctx, "PRAGMA rekey = "+quoteKey(newKey)); err != nil {
+		return fmt.Errorf("sqlite: rekey: %w", err)
+	}
+	m.key.Store(newKey)
+	if m.cfg.EnableWAL {
+		if _, err := conn.ExecContext(ctx, "PRAGMA journal_mode = WAL"); err != nil {
+			return fmt.Errorf("sqlite: restore WAL mode: %w", err)
+		}
+	}
+
+	// Drop idle connections still keyed with the old key
+	sqlDB.SetMaxIdleConns(0)
+	sqlDB.SetMaxIdleConns(m.cfg.MaxIdleConns)
+	m.logger.Info("sqlite database rekeyed", "path", m.cfg.Path)
+	return nil
+}
+
+// EncryptFile converts the unencrypted database at path to SQLCipher with
+// key, replacing the file. Run it once, with the application stopped,
+// before configuring the key.
+func EncryptFile(path, key string) error {
+	if key == "" {
+		return fmt.Errorf("sqlite: encrypt needs a non-empty key")
+	}
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return fmt.Errorf("sqlite: open: %w", err)
+	}
+	defer db.Close()
+
+	var version string
+	if err := db.QueryRow("PRAGMA cipher_version").Scan(&version); err != nil || version == "" {
+		return ErrCipherUnavailable
+	}
+	// Fold the WAL into the file so the export sees every committed row
+	if _, err := db.Exec("PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		return fmt.Errorf("sqlite: checkpoint: %w", err)
+	}
+
+	tmp := path + ".encrypting"
+	os.Remove(tmp)
+	stmts := []string{
+		"ATTACH DATABASE " + quoteKey(tmp) + " AS encrypted KEY " + quoteKey(key),
+		"SELECT sqlcipher_export('encrypted')",
+		"DETACH DATABASE encrypted",
+	}
+	for _, stmt := range stmts {
+		if _, err := db.Exec(stmt); err != nil {
+			os.Remove(tmp)
+			return fmt.Errorf("sqlite: encrypt: %w", err)
+		}
+	}
+	if err := db.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("sqlite: close: %w", err)
+	}
+
+	os.Remove(path + "-wal")
+	os.Remove(path + "-shm")
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("sqlite: replace database: %w", err)
+	}
+	return nil
+}
+
+// quoteKey quotes a key or path as a SQL string literal. Raw keys keep
+// SQLCipher's x'…' form inside the quotes.
+func quoteKey(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
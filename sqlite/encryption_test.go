@@ -0,0 +1,57 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+// The test binary links plain SQLite, so these cover the safety checks that
+// keep a configured key from silently producing an unencrypted database.
+
+func TestManager_EncryptionRequiresSQLCipher(t *testing.T) {
+	m := NewManager(Config{
+		Path:        filepath.Join(t.TempDir(), "secret.db"),
+		KeyProvider: func() (string, error) { return "correct horse battery staple", nil },
+	})
+	defer m.Close()
+
+	if !m.Encrypted() {
+		t.Fatal("expected the manager to report encryption")
+	}
+	if _, err := m.Connect(); !errors.Is(err, ErrCipherUnavailable) {
+		t.Fatalf("expected ErrCipherUnavailable, got %v", err)
+	}
+}
+
+func TestManager_KeyProviderError(t *testing.T) {
+	m := NewManager(Config{
+		Path:        filepath.Join(t.TempDir(), "secret.db"),
+		KeyProvider: func() (string, error) { return "", nil },
+	})
+	if _, err := m.Connect(); err == nil {
+		t.Fatal("expected an empty key to be rejected")
+	}
+}
+
+func TestManager_RekeyUnencrypted(t *testing.T) {
+	m := NewManager(Config{Path: filepath.Join(t.TempDir(), "plain.db")})
+	defer m.Close()
+	if err := m.Rekey(context.Background(), "new"); err == nil {
+		t.Fatal("expected rekey of an unencrypted database to fail")
+	}
+}
+
+func TestEncryptFile_RequiresSQLCipher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plain.db")
+	m := NewManager(Config{Path: path})
+	if _, err := m.Connect(); err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+	m.Close()
+
+	if err := EncryptFile(path, "key"); !errors.Is(err, ErrCipherUnavailable) {
+		t.Fatalf("expected ErrCipherUnavailable, got %v", err)
+	}
+}
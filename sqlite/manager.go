@@ -1,9 +1,15 @@
 package sqlite
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log/slog"
+	"os"
+	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"gorm.io/driver/sqlite"
@@ -38,6 +44,15 @@ type Config struct {
 	// TxImmediate uses immediate transaction locking. Default: true.
 	// This prevents SQLITE_BUSY errors in concurrent write scenarios.
 	TxImmediate bool
+
+	// EncryptionKey encrypts the database at rest with SQLCipher, which the
+	// binary must be linked against (see encryption.go). Optional.
+	EncryptionKey string
+
+	// KeyProvider loads the encryption key each time the database is
+	// opened, e.g. from a secrets manager. It takes precedence over
+	// EncryptionKey. Optional.
+	KeyProvider func() (string, error)
 }
 
 // Manager manages SQLite database connections with optimized settings.
@@ -47,6 +62,9 @@ type Manager struct {
 	db      *gorm.DB
 	dbOnce  sync.Once
 	dbMutex sync.Mutex
+
+	key        atomic.Value // current encryption key, read by new connections
+	driverName string       // SQLCipher driver registered for this manager
 }
 
 // NewManager creates a new SQLite database manager.
@@ -91,6 +109,10 @@ func (m *Manager) Connect() (*gorm.DB, error) {
 	if err != nil {
 		return nil, err
 	}
+	if m.db == nil {
+		// An earlier open failed; Close resets the manager for another try
+		return nil, fmt.Errorf("sqlite: database is not open")
+	}
 	return m.db.Session(&gorm.Session{}), nil
 }
 
@@ -138,6 +160,40 @@ func (m *Manager) CheckpointWAL(mode string) error {
 	return conn.Exec("PRAGMA wal_checkpoint(" + mode + ");").Error
 }
 
+// WALSize returns the size of the write-ahead log file in bytes, 0 when
+// there is none.
+func (m *Manager) WALSize() (int64, error) {
+	info, err := os.Stat(m.cfg.Path + "-wal")
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("sqlite: stat WAL: %w", err)
+	}
+	return info.Size(), nil
+}
+
+// Backup writes a consistent copy of the database to dst with VACUUM INTO,
+// without blocking writers. dst must not exist yet.
+func (m *Manager) Backup(ctx context.Context, dst string) error {
+	db, err := m.Connect()
+	if err != nil {
+		return err
+	}
+	return backupDB(ctx, db, dst)
+}
+
+// backupDB runs VACUUM INTO dst on db.
+func backupDB(ctx context.Context, db *gorm.DB, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return fmt.Errorf("sqlite: create backup directory: %w", err)
+	}
+	if err := db.WithContext(ctx).Exec("VACUUM INTO ?", dst).Error; err != nil {
+		return fmt.Errorf("sqlite: backup to %s: %w", dst, err)
+	}
+	return nil
+}
+
 func (m *Manager) open() error {
 	m.dbMutex.Lock()
 	defer m.dbMutex.Unlock()
@@ -155,7 +211,20 @@ func (m *Manager) open() error {
 	// Create GORM logger
 	gormLogger := database.NewGormLogger(m.logger.With(slog.String("component", "gorm")), nil)
 
-	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+	dialector := sqlite.Open(dsn)
+	if m.Encrypted() {
+		key, err := m.resolveKey()
+		if err != nil {
+			return err
+		}
+		m.key.Store(key)
+		if m.driverName == "" {
+			m.driverName = m.cipherDriver()
+		}
+		dialector = sqlite.New(sqlite.Config{DriverName: m.driverName, DSN: dsn})
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{
 		Logger:                 gormLogger,
 		SkipDefaultTransaction: true,
 		NowFunc: func() time.Time {
@@ -166,6 +235,15 @@ func (m *Manager) open() error {
 		return fmt.Errorf("sqlite: open: %w", err)
 	}
 
+	if m.Encrypted() {
+		if err := checkCipher(db); err != nil {
+			if sqlDB, dbErr := db.DB(); dbErr == nil {
+				sqlDB.Close()
+			}
+			return err
+		}
+	}
+
 	// Apply pragmas
 	if err := m.applyPragmas(db); err != nil {
 		return err
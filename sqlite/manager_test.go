@@ -158,3 +158,34 @@ func TestManager_CheckpointWAL(t *testing.T) {
 		_ = m.Close()
 	})
 }
+
+func TestManager_WALSize(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "wal_size.db")
+	m := NewManager(Config{Path: dbPath})
+	defer m.Close()
+
+	if size, err := m.WALSize(); err != nil || size != 0 {
+		t.Fatalf("expected 0 before connecting, got %d, %v", size, err)
+	}
+
+	db, err := m.Connect()
+	if err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	if err := db.Exec("CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT)").Error; err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	if err := db.Exec("INSERT INTO items (name) VALUES ('a'), ('b')").Error; err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if size, err := m.WALSize(); err != nil || size == 0 {
+		t.Fatalf("expected a non-empty WAL after writes, got %d, %v", size, err)
+	}
+
+	if err := m.CheckpointWAL("TRUNCATE"); err != nil {
+		t.Fatalf("CheckpointWAL failed: %v", err)
+	}
+	if size, err := m.WALSize(); err != nil || size != 0 {
+		t.Errorf("expected TRUNCATE to empty the WAL, got %d, %v", size, err)
+	}
+}
@@ -0,0 +1,345 @@
+package sqlite
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// keyPattern limits database keys to characters that are safe in file names.
+var keyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// MultiConfig configures a MultiDBManager.
+type MultiConfig struct {
+	// Dir holds one database file per key, "<Dir>/<key>.db". Required.
+	Dir string
+
+	// Database configures each database; its Path is ignored.
+	Database Config
+
+	// MaxOpen is how many databases stay open. The least recently used idle
+	// one is checkpointed and closed to make room. Default: 64.
+	MaxOpen int
+
+	// Migrate runs the first time each database is opened by this process,
+	// e.g. to auto-migrate the per-tenant schema. Optional.
+	Migrate func(db *gorm.DB, key string) error
+
+	// Logger for database operations. Optional.
+	Logger *slog.Logger
+}
+
+// MultiDBManager keeps one WAL-mode SQLite database per key, typically a
+// tenant ID, so each tenant's data lives in its own file. Databases open on
+// first use and the least recently used are closed beyond MaxOpen.
+type MultiDBManager struct {
+	cfg    MultiConfig
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	open    map[string]*list.Element // key -> *multiEntry in lru
+	lru     *list.List               // front = most recently used
+	closing map[string]chan struct{} // evicted keys, until their database is closed
+	closed  bool
+
+	migrateMu sync.Mutex // serializes Migrate, guards migrated
+	migrated  map[string]bool
+}
+
+type multiEntry struct {
+	key     string
+	manager *Manager
+	refs    int // handles given out and not yet released
+}
+
+// NewMultiDBManager creates a manager for the databases in cfg.Dir.
+func NewMultiDBManager(cfg MultiConfig) *MultiDBManager {
+	if cfg.MaxOpen <= 0 {
+		cfg.MaxOpen = 64
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if cfg.Database.Logger == nil {
+		cfg.Database.Logger = logger
+	}
+	return &MultiDBManager{
+		cfg:      cfg,
+		logger:   logger,
+		open:     make(map[string]*list.Element),
+		lru:      list.New(),
+		closing:  make(map[string]chan struct{}),
+		migrated: make(map[string]bool),
+	}
+}
+
+// Path returns the database file for key.
+func (m *MultiDBManager) Path(key string) string {
+	return filepath.Join(m.cfg.Dir, key+".db")
+}
+
+// TenantDB returns the database for key, opening and migrating it on first
+// use. The database stays open at least until release is called.
+func (m *MultiDBManager) TenantDB(ctx context.Context, key string) (*gorm.DB, func(), error) {
+	entry, err := m.acquire(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	db, err := entry.manager.Connect()
+	if err != nil {
+		m.discard(entry)
+		return nil, nil, err
+	}
+	if err := m.migrate(key, db); err != nil {
+		m.release(entry)
+		return nil, nil, err
+	}
+
+	var once sync.Once
+	return db.WithContext(ctx), func() { once.Do(func() { m.release(entry) }) }, nil
+}
+
+// Keys returns the keys of every database in Dir, open or not, sorted.
+func (m *MultiDBManager) Keys() ([]string, error) {
+	entries, err := os.ReadDir(m.cfg.Dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: list databases: %w", err)
+	}
+
+	var keys []string
+	for _, entry := range entries {
+		key, ok := strings.CutSuffix(entry.Name(), ".db")
+		if ok && entry.Type().IsRegular() && keyPattern.MatchString(key) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
+
+// Each calls fn with every database in Dir, one at a time, opening and
+// migrating it as TenantDB does. It keeps going when fn fails for one
+// database and returns the errors together, so a bad tenant doesn't stop
+// e.g. a nightly backup of the others.
+func (m *MultiDBManager) Each(ctx context.Context, fn func(key string, db *gorm.DB) error) error {
+	keys, err := m.Keys()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+		db, release, err := m.TenantDB(ctx, key)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := fn(key, db); err != nil {
+			errs = append(errs, fmt.Errorf("sqlite: %s: %w", key, err))
+		}
+		release()
+	}
+	return errors.Join(errs...)
+}
+
+// Backup copies every database into dir as "<dir>/<key>.db" (see
+// Manager.Backup). dir must not hold earlier backups.
+func (m *MultiDBManager) Backup(ctx context.Context, dir string) error {
+	return m.Each(ctx, func(key string, db *gorm.DB) error {
+		return backupDB(ctx, db, filepath.Join(dir, key+".db"))
+	})
+}
+
+// Opened returns the keys of the databases currently open.
+func (m *MultiDBManager) Opened() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]string, 0, m.lru.Len())
+	for e := m.lru.Front(); e != nil; e = e.Next() {
+		keys = append(keys, e.Value.(*multiEntry).key)
+	}
+	return keys
+}
+
+// Close truncates the WAL of every open database and closes them. Handles
+// still in use fail afterwards.
+func (m *MultiDBManager) Close() error {
+	m.mu.Lock()
+	m.closed = true
+	var entries []*multiEntry
+	for m.lru.Len() > 0 {
+		entries = append(entries, m.unlink(m.lru.Back()))
+	}
+	m.mu.Unlock()
+
+	var errs []error
+	for _, entry := range entries {
+		if err := m.closeEntry(entry); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// Wait for databases evicted just before
+	m.mu.Lock()
+	pending := make([]chan struct{}, 0, len(m.closing))
+	for _, done := range m.closing {
+		pending = append(pending, done)
+	}
+	m.mu.Unlock()
+	for _, done := range pending {
+		<-done
+	}
+	return errors.Join(errs...)
+}
+
+// acquire returns the open entry for key, opening it if needed.
+func (m *MultiDBManager) acquire(key string) (*multiEntry, error) {
+	if !keyPattern.MatchString(key) {
+		return nil, fmt.Errorf("sqlite: invalid database key %q", key)
+	}
+
+	m.mu.Lock()
+	for done := m.closing[key]; done != nil && !m.closed; done = m.closing[key] {
+		// Evicted and still being closed; don't open the file twice
+		m.mu.Unlock()
+		<-done
+		m.mu.Lock()
+	}
+	if m.closed {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("sqlite: multi database manager is closed")
+	}
+
+	if e, ok := m.open[key]; ok {
+		m.lru.MoveToFront(e)
+		entry := e.Value.(*multiEntry)
+		entry.refs++
+		m.mu.Unlock()
+		return entry, nil
+	}
+
+	if err := os.MkdirAll(m.cfg.Dir, 0o755); err != nil {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("sqlite: create database directory: %w", err)
+	}
+	cfg := m.cfg.Database
+	cfg.Path = m.Path(key)
+	entry := &multiEntry{key: key, manager: NewManager(cfg), refs: 1}
+	m.open[key] = m.lru.PushFront(entry)
+	evicted := m.evict()
+	m.mu.Unlock()
+
+	m.closeEvicted(evicted)
+	return entry, nil
+}
+
+// release returns a handle and closes surplus databases that became idle.
+func (m *MultiDBManager) release(entry *multiEntry) {
+	m.mu.Lock()
+	entry.refs--
+	evicted := m.evict()
+	m.mu.Unlock()
+
+	m.closeEvicted(evicted)
+}
+
+// discard forgets a database that failed to open, so the next use retries.
+func (m *MultiDBManager) discard(entry *multiEntry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry.refs--
+	if e, ok := m.open[entry.key]; ok && e.Value == entry && entry.refs == 0 {
+		m.lru.Remove(e)
+		delete(m.open, entry.key)
+	}
+}
+
+// evict unlinks idle databases, least recently used first, until at most
+// MaxOpen are open, and returns them for closeEvicted. Databases in use are
+// never evicted, so the limit can be exceeded while every open database is
+// busy. Callers hold m.mu.
+func (m *MultiDBManager) evict() []*multiEntry {
+	var evicted []*multiEntry
+	for e := m.lru.Back(); e != nil && m.lru.Len() > m.cfg.MaxOpen; {
+		prev := e.Prev()
+		if e.Value.(*multiEntry).refs == 0 {
+			evicted = append(evicted, m.unlink(e))
+		}
+		e = prev
+	}
+	return evicted
+}
+
+// closeEvicted closes the databases evict unlinked, logging failures.
+// Callers don't hold m.mu.
+func (m *MultiDBManager) closeEvicted(entries []*multiEntry) {
+	for _, entry := range entries {
+		if err := m.closeEntry(entry); err != nil {
+			m.logger.Warn("failed to close tenant database", slog.String("key", entry.key), slog.Any("error", err))
+		}
+	}
+}
+
+// unlink removes a database from the open set and marks its key closing
+// until closeEntry is done. Callers hold m.mu.
+func (m *MultiDBManager) unlink(e *list.Element) *multiEntry {
+	entry := e.Value.(*multiEntry)
+	m.lru.Remove(e)
+	delete(m.open, entry.key)
+	m.closing[entry.key] = make(chan struct{})
+	return entry
+}
+
+// closeEntry checkpoints and closes an unlinked database. Callers don't
+// hold m.mu, so a slow checkpoint doesn't block the other databases.
+func (m *MultiDBManager) closeEntry(entry *multiEntry) error {
+	var errs []error
+	if err := entry.manager.CheckpointWAL("TRUNCATE"); err != nil {
+		errs = append(errs, fmt.Errorf("sqlite: checkpoint %s: %w", entry.key, err))
+	}
+	if err := entry.manager.Close(); err != nil {
+		errs = append(errs, err)
+	}
+
+	m.mu.Lock()
+	close(m.closing[entry.key])
+	delete(m.closing, entry.key)
+	m.mu.Unlock()
+	return errors.Join(errs...)
+}
+
+// migrate runs cfg.Migrate once per key.
+func (m *MultiDBManager) migrate(key string, db *gorm.DB) error {
+	if m.cfg.Migrate == nil {
+		return nil
+	}
+	m.migrateMu.Lock()
+	defer m.migrateMu.Unlock()
+	if m.migrated[key] {
+		return nil
+	}
+	if err := m.cfg.Migrate(db, key); err != nil {
+		return fmt.Errorf("sqlite: migrate %s: %w", key, err)
+	}
+	m.migrated[key] = true
+	return nil
+}
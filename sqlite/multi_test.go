@@ -0,0 +1,189 @@
+package sqlite
+
+import (
+	"context"
+	"os"
+	"slices"
+	"sync"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+type multiNote struct {
+	ID   uint
+	Body string
+}
+
+func TestMultiDBManager(t *testing.T) {
+	migrations := map[string]int{}
+	m := NewMultiDBManager(MultiConfig{
+		Dir:     t.TempDir(),
+		MaxOpen: 2,
+		Migrate: func(db *gorm.DB, key string) error {
+			migrations[key]++
+			return db.AutoMigrate(&multiNote{})
+		},
+	})
+	ctx := context.Background()
+
+	// Each key gets its own file
+	for _, key := range []string{"acme", "acme", "globex"} {
+		db, release, err := m.TenantDB(ctx, key)
+		if err != nil {
+			t.Fatalf("TenantDB(%s) failed: %v", key, err)
+		}
+		if err := db.Create(&multiNote{Body: key}).Error; err != nil {
+			t.Fatalf("insert failed: %v", err)
+		}
+		release()
+	}
+	db, release, _ := m.TenantDB(ctx, "acme")
+	var count int64
+	db.Model(&multiNote{}).Count(&count)
+	if count != 2 {
+		t.Errorf("expected 2 notes for acme, got %d", count)
+	}
+
+	// The least recently used idle database is closed beyond MaxOpen, but
+	// never one in use
+	if _, rel, err := m.TenantDB(ctx, "initech"); err != nil {
+		t.Fatalf("TenantDB failed: %v", err)
+	} else {
+		rel()
+	}
+	if opened := m.Opened(); len(opened) != 2 || slices.Contains(opened, "globex") {
+		t.Errorf("expected globex to be closed, got %v", opened)
+	}
+	if err := db.Model(&multiNote{}).Count(&count).Error; err != nil {
+		t.Errorf("expected the database in use to stay open: %v", err)
+	}
+	release()
+
+	// Migrations run once per database
+	if _, rel, err := m.TenantDB(ctx, "globex"); err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	} else {
+		rel()
+	}
+	if migrations["acme"] != 1 || migrations["globex"] != 1 {
+		t.Errorf("expected one migration per database, got %v", migrations)
+	}
+
+	if _, _, err := m.TenantDB(ctx, "../escape"); err == nil {
+		t.Error("expected an invalid key to be rejected")
+	}
+
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	for _, key := range []string{"acme", "globex", "initech"} {
+		if info, err := os.Stat(m.Path(key) + "-wal"); err == nil && info.Size() > 0 {
+			t.Errorf("expected %s WAL to be truncated, got %d bytes", key, info.Size())
+		}
+	}
+	if _, _, err := m.TenantDB(ctx, "acme"); err == nil {
+		t.Error("expected TenantDB to fail after Close")
+	}
+}
+
+func TestMultiDBManager_Backup(t *testing.T) {
+	m := NewMultiDBManager(MultiConfig{
+		Dir:     t.TempDir(),
+		MaxOpen: 1,
+		Migrate: func(db *gorm.DB, key string) error {
+			return db.AutoMigrate(&multiNote{})
+		},
+	})
+	defer m.Close()
+	ctx := context.Background()
+
+	for _, key := range []string{"globex", "acme"} {
+		db, release, err := m.TenantDB(ctx, key)
+		if err != nil {
+			t.Fatalf("TenantDB failed: %v", err)
+		}
+		db.Create(&multiNote{Body: key})
+		release()
+	}
+
+	keys, err := m.Keys()
+	if err != nil || !slices.Equal(keys, []string{"acme", "globex"}) {
+		t.Fatalf("expected every database on disk, got %v (%v)", keys, err)
+	}
+
+	backups := t.TempDir()
+	if err := m.Backup(ctx, backups); err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	restored := NewMultiDBManager(MultiConfig{Dir: backups})
+	defer restored.Close()
+	err = restored.Each(ctx, func(key string, db *gorm.DB) error {
+		var note multiNote
+		if err := db.First(&note).Error; err != nil {
+			return err
+		}
+		if note.Body != key {
+			t.Errorf("expected %s's note in its backup, got %q", key, note.Body)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("reading backups failed: %v", err)
+	}
+
+	// A second backup into the same directory fails for every tenant
+	if err := m.Backup(ctx, backups); err == nil {
+		t.Error("expected existing backups not to be overwritten")
+	}
+}
+
+func TestMultiDBManager_ConcurrentEviction(t *testing.T) {
+	m := NewMultiDBManager(MultiConfig{
+		Dir:     t.TempDir(),
+		MaxOpen: 1,
+		Migrate: func(db *gorm.DB, key string) error {
+			return db.AutoMigrate(&multiNote{})
+		},
+	})
+	defer m.Close()
+	ctx := context.Background()
+
+	// Databases are evicted and reopened while other keys are in use
+	keys := []string{"acme", "globex", "initech"}
+	var wg sync.WaitGroup
+	errs := make(chan error, 30)
+	for i := 0; i < 30; i++ {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			db, release, err := m.TenantDB(ctx, key)
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer release()
+			errs <- db.Create(&multiNote{Body: key}).Error
+		}(keys[i%len(keys)])
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("concurrent use failed: %v", err)
+		}
+	}
+
+	for _, key := range keys {
+		db, release, err := m.TenantDB(ctx, key)
+		if err != nil {
+			t.Fatalf("TenantDB(%s) failed: %v", key, err)
+		}
+		var count int64
+		db.Model(&multiNote{}).Count(&count)
+		release()
+		if count != 10 {
+			t.Errorf("expected 10 notes for %s, got %d", key, count)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package cartridge
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ssoStateCookie carries the login state between the redirect to the
+// identity provider and the callback.
+const ssoStateCookie = "cartridge_sso_state"
+
+// SSOProfile is the user identity returned by an enterprise identity provider.
+type SSOProfile struct {
+	ID             string         `json:"id"`
+	Email          string         `json:"email"`
+	FirstName      string         `json:"first_name"`
+	LastName       string         `json:"last_name"`
+	OrganizationID string         `json:"organization_id"`
+	ConnectionID   string         `json:"connection_id"`
+	Groups         []string       `json:"groups"`
+	RawAttributes  map[string]any `json:"raw_attributes"`
+}
+
+// SSOLoginRequest describes the login being started. Providers use
+// Organization, Connection or Domain to pick the customer's identity provider.
+type SSOLoginRequest struct {
+	State        string
+	RedirectURI  string
+	Organization string
+	Connection   string
+	Domain       string
+}
+
+// SSOProvider performs the identity-provider side of an SSO login. Exchange
+// must only return a profile once the provider's response is verified, e.g.
+// SAML assertion signature, audience and validity window.
+type SSOProvider interface {
+	// AuthorizationURL returns the URL the browser is sent to for sign-in.
+	AuthorizationURL(req SSOLoginRequest) (string, error)
+
+	// Exchange trades the callback code for the verified user profile.
+	Exchange(ctx context.Context, code string) (*SSOProfile, error)
+}
+
+// SSOConfig configures enterprise single sign-on.
+type SSOConfig struct {
+	// Provider talks to the identity provider. Required.
+	Provider SSOProvider
+
+	// RedirectURI is the absolute callback URL registered with the provider,
+	// e.g. "https://app.example.com/sso/callback". Required.
+	RedirectURI string
+
+	// Provision maps a verified profile to a local user, creating it on first
+	// login (just-in-time provisioning), and returns the user ID stored in the
+	// auth cookie. Returning an *Error rejects the login. Required.
+	Provision func(ctx *Context, profile *SSOProfile) (userID string, err error)
+
+	// LoginPath starts the login. Default: "/sso/login"
+	LoginPath string
+
+	// CallbackPath receives the provider's redirect. Default: "/sso/callback"
+	CallbackPath string
+
+	// AfterLogin is where users land once signed in. Default: "/"
+	AfterLogin string
+
+	// Secure sets the Secure flag on the state cookie.
+	Secure bool
+}
+
+// SSO mounts the login and callback routes for enterprise single sign-on.
+// Signed-in users get the same auth cookie as SessionManager.SetAuthCookie.
+type SSO struct {
+	cfg SSOConfig
+}
+
+// NewSSO validates cfg and applies defaults.
+func NewSSO(cfg SSOConfig) (*SSO, error) {
+	if cfg.Provider == nil {
+		return nil, fmt.Errorf("cartridge: sso provider is required")
+	}
+	if cfg.RedirectURI == "" {
+		return nil, fmt.Errorf("cartridge: sso redirect URI is required")
+	}
+	if cfg.Provision == nil {
+		return nil, fmt.Errorf("cartridge: sso provision callback is required")
+	}
+	if cfg.LoginPath == "" {
+		cfg.LoginPath = "/sso/login"
+	}
+	if cfg.CallbackPath == "" {
+		cfg.CallbackPath = "/sso/callback"
+	}
+	if cfg.AfterLogin == "" {
+		cfg.AfterLogin = "/"
+	}
+	return &SSO{cfg: cfg}, nil
+}
+
+// Mount registers the login and callback routes. The server needs a
+// SessionManager (WithSession) to sign users in.
+func (s *SSO) Mount(server *Server) {
+	if server.Session() == nil {
+		panic("cartridge: sso requires a session manager (use WithSession)")
+	}
+	server.Get(s.cfg.LoginPath, s.login)
+	server.Get(s.cfg.CallbackPath, s.callback)
+}
+
+// login redirects to the identity provider. The organization, connection or
+// domain query parameter selects the customer's connection.
+func (s *SSO) login(ctx *Context) error {
+	state, err := newSessionID()
+	if err != nil {
+		return err
+	}
+	target, err := s.cfg.Provider.AuthorizationURL(SSOLoginRequest{
+		State:        state,
+		RedirectURI:  s.cfg.RedirectURI,
+		Organization: ctx.Query("organization"),
+		Connection:   ctx.Query("connection"),
+		Domain:       ctx.Query("domain"),
+	})
+	if err != nil {
+		return ErrBadRequest(err.Error())
+	}
+
+	ctx.Cookie(&fiber.Cookie{
+		Name:     ssoStateCookie,
+		Value:    state,
+		Path:     s.cfg.CallbackPath,
+		MaxAge:   int((10 * time.Minute).Seconds()),
+		Secure:   s.cfg.Secure,
+		HTTPOnly: true,
+		SameSite: "Lax", // sent on the provider's top-level redirect back
+	})
+	return ctx.Redirect(target)
+}
+
+// callback verifies the state, exchanges the code for a profile, provisions
+// the user and signs them in.
+func (s *SSO) callback(ctx *Context) error {
+	expected := ctx.Cookies(ssoStateCookie)
+	ctx.Cookie(&fiber.Cookie{
+		Name:     ssoStateCookie,
+		Value:    "",
+		Path:     s.cfg.CallbackPath,
+		MaxAge:   -1,
+		Expires:  time.Now().Add(-24 * time.Hour),
+		Secure:   s.cfg.Secure,
+		HTTPOnly: true,
+		SameSite: "Lax",
+	})
+
+	state := ctx.Query("state")
+	if expected == "" || subtle.ConstantTimeCompare([]byte(state), []byte(expected)) != 1 {
+		return ErrBadRequest("invalid sso state")
+	}
+	if reason := ctx.Query("error"); reason != "" {
+		s.warn(ctx, "sso login rejected by provider", "error", reason, "description", ctx.Query("error_description"))
+		return ErrUnauthorized("single sign-on failed")
+	}
+	code := ctx.Query("code")
+	if code == "" {
+		return ErrBadRequest("missing sso code")
+	}
+
+	// Checked before Provision, so no user is created that can't sign in
+	if ctx.Auth == nil {
+		return fmt.Errorf("cartridge: sso requires a session manager (use WithSession)")
+	}
+	profile, err := s.cfg.Provider.Exchange(ctx.UserContext(), code)
+	if err != nil {
+		s.warn(ctx, "sso code exchange failed", "error", err)
+		return ErrUnauthorized("single sign-on failed")
+	}
+	userID, err := s.cfg.Provision(ctx, profile)
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.Auth.SetAuthCookie(ctx.Ctx, userID); err != nil {
+		return err
+	}
+	ctx.RotateCSRFToken()
+	if sess := ctx.Session(); sess != nil {
+		sess.Regenerate()
+	}
+	return ctx.Redirect(s.cfg.AfterLogin)
+}
+
+// warn logs a failed login; the user only sees a generic error.
+func (s *SSO) warn(ctx *Context, msg string, args ...any) {
+	if ctx.Logger != nil {
+		ctx.Logger.Warn(msg, args...)
+	}
+}
+
+// WorkOSConfig configures the WorkOS SSO provider.
+type WorkOSConfig struct {
+	// ClientID is the WorkOS client ID. Required.
+	ClientID string
+
+	// APIKey is the WorkOS secret API key. Required.
+	APIKey string
+
+	// BaseURL is the WorkOS API. Default: "https://api.workos.com"
+	BaseURL string
+
+	// HTTPClient makes token requests. Default: a client with a 10s timeout.
+	HTTPClient *http.Client
+}
+
+// WorkOSProvider is an SSOProvider backed by WorkOS, which handles SAML and
+// OIDC connections, including metadata exchange and assertion validation.
+type WorkOSProvider struct {
+	cfg WorkOSConfig
+}
+
+// NewWorkOSProvider creates a WorkOS SSO provider.
+func NewWorkOSProvider(cfg WorkOSConfig) *WorkOSProvider {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = "https://api.workos.com"
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &WorkOSProvider{cfg: cfg}
+}
+
+// AuthorizationURL implements SSOProvider.
+func (w *WorkOSProvider) AuthorizationURL(req SSOLoginRequest) (string, error) {
+	q := url.Values{
+		"client_id":     {w.cfg.ClientID},
+		"redirect_uri":  {req.RedirectURI},
+		"response_type": {"code"},
+		"state":         {req.State},
+	}
+	switch {
+	case req.Connection != "":
+		q.Set("connection", req.Connection)
+	case req.Organization != "":
+		q.Set("organization", req.Organization)
+	default:
+		return "", errors.New("an organization or connection is required")
+	}
+	if req.Domain != "" {
+		q.Set("domain_hint", req.Domain)
+	}
+	return w.cfg.BaseURL + "/sso/authorize?" + q.Encode(), nil
+}
+
+// Exchange implements SSOProvider.
+func (w *WorkOSProvider) Exchange(ctx context.Context, code string) (*SSOProfile, error) {
+	form := url.Values{
+		"client_id":     {w.cfg.ClientID},
+		"client_secret": {w.cfg.APIKey},
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.cfg.BaseURL+"/sso/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := w.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("cartridge: workos token request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("cartridge: read workos response: %w", err)
+	}
+	var result struct {
+		Profile          *SSOProfile `json:"profile"`
+		Error            string      `json:"error"`
+		ErrorDescription string      `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("cartridge: decode workos response (status %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || result.Profile == nil {
+		return nil, fmt.Errorf("cartridge: workos token exchange failed (status %d): %s %s", resp.StatusCode, result.Error, result.ErrorDescription)
+	}
+	return result.Profile, nil
+}
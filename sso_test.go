@@ -0,0 +1,225 @@
+package cartridge
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	cartridgemiddleware "github.com/karloscodes/cartridge/middleware"
+)
+
+// fakeSSOProvider accepts the code "good" and rejects everything else.
+type fakeSSOProvider struct{}
+
+func (fakeSSOProvider) AuthorizationURL(req SSOLoginRequest) (string, error) {
+	if req.Organization == "" {
+		return "", errors.New("an organization is required")
+	}
+	return "https://idp.example.com/authorize?state=" + req.State, nil
+}
+
+func (fakeSSOProvider) Exchange(ctx context.Context, code string) (*SSOProfile, error) {
+	if code != "good" {
+		return nil, errors.New("invalid code")
+	}
+	return &SSOProfile{ID: "prof_1", Email: "ana@acme.test", OrganizationID: "org_1"}, nil
+}
+
+func TestSSO_LoginFlow(t *testing.T) {
+	cfg := DefaultServerConfig()
+	cfg.EnableStaticAssets = false
+	cfg.EnableRequestLogger = false
+	cfg.EnableSecFetchSite = false
+	cfg.Config = &testConfig{}
+	cfg.Logger = testLogger()
+	cfg.DBManager = &testDBManager{}
+	cfg.ErrorHandler = DefaultErrorHandler(testLogger(), false)
+	csrf := cartridgemiddleware.DefaultCSRFConfig()
+	csrf.Binding = func(c *fiber.Ctx) string { return "" } // rotation only
+	cfg.CSRF = &csrf
+
+	srv, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	sessions := NewSessionManager(SessionConfig{Secret: "test-secret-key-32-characters-xx"})
+	srv.SetSession(sessions)
+
+	var provisioned []string
+	sso, err := NewSSO(SSOConfig{
+		Provider:    fakeSSOProvider{},
+		RedirectURI: "https://app.example.com/sso/callback",
+		AfterLogin:  "/dashboard",
+		Provision: func(ctx *Context, p *SSOProfile) (string, error) {
+			if p.OrganizationID != "org_1" {
+				return "", ErrForbidden("unknown organization")
+			}
+			provisioned = append(provisioned, p.Email)
+			return "42", nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewSSO failed: %v", err)
+	}
+	sso.Mount(srv)
+
+	// Without an organization the provider can't pick a connection
+	resp, _ := srv.App().Test(httptest.NewRequest("GET", "/sso/login", nil))
+	if resp.StatusCode != fiber.StatusBadRequest {
+		t.Errorf("expected 400 without organization, got %d", resp.StatusCode)
+	}
+
+	login := func() *http.Cookie {
+		resp, err := srv.App().Test(httptest.NewRequest("GET", "/sso/login?organization=org_1", nil))
+		if err != nil {
+			t.Fatalf("login request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusFound {
+			t.Fatalf("expected redirect to provider, got %d", resp.StatusCode)
+		}
+		for _, c := range resp.Cookies() {
+			if c.Name == ssoStateCookie {
+				return c
+			}
+		}
+		t.Fatal("expected state cookie")
+		return nil
+	}
+
+	callback := func(query string, cookies ...*http.Cookie) *http.Response {
+		req := httptest.NewRequest("GET", "/sso/callback?"+query, nil)
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		resp, err := srv.App().Test(req)
+		if err != nil {
+			t.Fatalf("callback request failed: %v", err)
+		}
+		return resp
+	}
+
+	state := login()
+	if resp := callback("code=good&state=forged", state); resp.StatusCode != fiber.StatusBadRequest {
+		t.Errorf("expected 400 for mismatched state, got %d", resp.StatusCode)
+	}
+	if resp := callback("code=good&state=" + state.Value); resp.StatusCode != fiber.StatusBadRequest {
+		t.Errorf("expected 400 without state cookie, got %d", resp.StatusCode)
+	}
+	if resp := callback("code=bad&state="+state.Value, state); resp.StatusCode != fiber.StatusUnauthorized {
+		t.Errorf("expected 401 for rejected code, got %d", resp.StatusCode)
+	}
+	if resp := callback("error=access_denied&state="+state.Value, state); resp.StatusCode != fiber.StatusUnauthorized {
+		t.Errorf("expected 401 for provider error, got %d", resp.StatusCode)
+	}
+
+	// The token issued before signing in must be replaced by the callback
+	token := &http.Cookie{Name: csrf.CookieName}
+	for _, c := range callback("", state).Cookies() {
+		if c.Name == csrf.CookieName {
+			token.Value = c.Value
+		}
+	}
+	resp = callback("code=good&state="+url.QueryEscape(state.Value), state, token)
+	if resp.StatusCode != fiber.StatusFound || resp.Header.Get("Location") != "/dashboard" {
+		t.Fatalf("expected redirect to /dashboard, got %d %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	if len(provisioned) != 1 || provisioned[0] != "ana@acme.test" {
+		t.Errorf("expected one provisioned user, got %v", provisioned)
+	}
+	var signedIn, rotated bool
+	for _, c := range resp.Cookies() {
+		if c.Name == "session" && c.Value != "" {
+			signedIn = true
+		}
+		if c.Name == csrf.CookieName && c.Value != token.Value {
+			rotated = true
+		}
+	}
+	if !signedIn {
+		t.Error("expected auth cookie after sso login")
+	}
+	if !rotated {
+		t.Error("expected a new CSRF token after sso login")
+	}
+}
+
+func TestSSO_MountRequiresSession(t *testing.T) {
+	cfg := DefaultServerConfig()
+	cfg.EnableStaticAssets = false
+	cfg.EnableRequestLogger = false
+	cfg.EnableSecFetchSite = false
+	cfg.Config = &testConfig{}
+	cfg.Logger = testLogger()
+	cfg.DBManager = &testDBManager{}
+	cfg.ErrorHandler = DefaultErrorHandler(testLogger(), false)
+
+	srv, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	sso, err := NewSSO(SSOConfig{
+		Provider:    fakeSSOProvider{},
+		RedirectURI: "https://app.example.com/sso/callback",
+		Provision: func(ctx *Context, p *SSOProfile) (string, error) {
+			t.Error("Provision must not run without a session manager")
+			return "42", nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewSSO failed: %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected Mount to panic without a session manager")
+		}
+	}()
+	sso.Mount(srv)
+}
+
+func TestWorkOSProvider(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/sso/token" || r.FormValue("client_secret") != "sk_test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+		if r.FormValue("code") != "abc" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant","error_description":"expired"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"t","profile":{"id":"prof_1","email":"ana@acme.test","organization_id":"org_1","groups":["admins"]}}`))
+	}))
+	defer api.Close()
+
+	provider := NewWorkOSProvider(WorkOSConfig{ClientID: "client_1", APIKey: "sk_test", BaseURL: api.URL + "/"})
+
+	target, err := provider.AuthorizationURL(SSOLoginRequest{State: "s1", RedirectURI: "https://app.test/cb", Organization: "org_1"})
+	if err != nil {
+		t.Fatalf("AuthorizationURL failed: %v", err)
+	}
+	u, _ := url.Parse(target)
+	if u.Path != "/sso/authorize" || u.Query().Get("organization") != "org_1" || u.Query().Get("state") != "s1" {
+		t.Errorf("unexpected authorization URL %s", target)
+	}
+	if _, err := provider.AuthorizationURL(SSOLoginRequest{State: "s1"}); err == nil {
+		t.Error("expected error without organization or connection")
+	}
+
+	profile, err := provider.Exchange(context.Background(), "abc")
+	if err != nil {
+		t.Fatalf("Exchange failed: %v", err)
+	}
+	if profile.Email != "ana@acme.test" || len(profile.Groups) != 1 {
+		t.Errorf("unexpected profile %+v", profile)
+	}
+	if _, err := provider.Exchange(context.Background(), "stale"); err == nil {
+		t.Error("expected error for rejected code")
+	}
+}
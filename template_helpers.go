@@ -0,0 +1,242 @@
+package cartridge
+
+import (
+	"fmt"
+	"html/template"
+	"net/url"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/karloscodes/cartridge/flash"
+)
+
+// TemplateHelpers are request-bound helpers for SSR templates. The template
+// FuncMap is shared by every request, so anything that depends on the caller
+// is bound to the view data as .Helpers instead:
+//
+//	{{ with .Helpers.CurrentUser }}Signed in as {{ .ID }}{{ end }}
+//	{{ if .Helpers.Can "posts:write" }}<a href="{{ .Helpers.Route "posts.new" }}">New</a>{{ end }}
+//	<input type="hidden" name="_csrf" value="{{ .Helpers.CSRFToken }}">
+//	<script nonce="{{ .Helpers.CSPNonce }}">...</script>
+//	{{ range .Helpers.Flashes }}<p class="{{ .Type }}">{{ .Message }}</p>{{ end }}
+//
+// Helpers are bound when views are rendered with a fiber.Map (or nil) binding.
+// Inside range, with and partials use $.Helpers. Components get them from
+// HelpersFromContext.
+type TemplateHelpers struct {
+	server *Server
+	ctx    *Context
+
+	principal       *Principal
+	principalLoaded bool
+	user            any
+	userLoaded      bool
+}
+
+// templateHelpers binds TemplateHelpers to the view data of each request,
+// and to the context components are rendered with (see RenderComponent).
+func (s *Server) templateHelpers() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := s.context(c)
+		ctx.helpers = &TemplateHelpers{server: s, ctx: ctx}
+		if s.cfg.ViewsEngine != nil {
+			if err := c.Bind(fiber.Map{"Helpers": ctx.helpers}); err != nil {
+				return err
+			}
+		}
+		return c.Next()
+	}
+}
+
+// CurrentUser returns the signed-in user: ServerConfig.CurrentUser's result,
+// or the caller's Principal by default. Returns nil for anonymous requests.
+func (h *TemplateHelpers) CurrentUser() (any, error) {
+	if h.userLoaded {
+		return h.user, nil
+	}
+	h.userLoaded = true
+
+	if load := h.server.cfg.CurrentUser; load != nil {
+		user, err := load(h.ctx)
+		if err != nil {
+			return nil, err
+		}
+		h.user = user
+		return user, nil
+	}
+
+	p, err := h.currentPrincipal()
+	if err != nil || p == nil {
+		return nil, err
+	}
+	h.user = p
+	return p, nil
+}
+
+// Can reports whether the caller is allowed permission, honoring policies
+// registered with Server.DefinePolicy. Anonymous callers are allowed nothing.
+func (h *TemplateHelpers) Can(permission string) (bool, error) {
+	p, err := h.currentPrincipal()
+	if err != nil || p == nil {
+		return false, err
+	}
+	return h.server.allows(h.ctx, p, Policy(permission)), nil
+}
+
+// Route returns the path of the route named name (see RouteConfig.Name),
+// filling its parameters in order.
+func (h *TemplateHelpers) Route(name string, params ...any) (string, error) {
+	return h.server.URL(name, params...)
+}
+
+// CSRFToken returns the request's CSRF token, or "" without CSRF protection.
+func (h *TemplateHelpers) CSRFToken() string {
+	return h.ctx.CSRFToken()
+}
+
+// CSPNonce returns the request's Content-Security-Policy nonce, or "" without
+// a CSP policy (see WithCSP).
+func (h *TemplateHelpers) CSPNonce() string {
+	return h.ctx.CSPNonce()
+}
+
+// Meta returns the page metadata set with ctx.SetMeta, with defaults
+// applied (see Context.Meta).
+func (h *TemplateHelpers) Meta() PageMetadata {
+	return h.ctx.Meta()
+}
+
+// Flash returns the flash value stored under key by the previous request, or
+// nil. Requires sessions.
+func (h *TemplateHelpers) Flash(key string) any {
+	sess := h.ctx.Session()
+	if sess == nil {
+		return nil
+	}
+	return sess.GetFlash(key)
+}
+
+// Flashes returns the messages queued by the previous request with
+// Session.AddFlash or flash.SetFlash, consuming them.
+func (h *TemplateHelpers) Flashes() []flash.FlashMessage {
+	var messages []flash.FlashMessage
+	if sess := h.ctx.Session(); sess != nil {
+		messages = sess.Flashes()
+	}
+	if h.ctx.Cookies(flash.FlashCookieName) != "" {
+		if msg := flash.GetFlash(h.ctx.Ctx); msg.Message != "" {
+			messages = append(messages, *msg)
+		}
+	}
+	return messages
+}
+
+// currentPrincipal returns the route's Principal, resolving it on routes
+// without Roles or Authorize.
+func (h *TemplateHelpers) currentPrincipal() (*Principal, error) {
+	if h.principalLoaded {
+		return h.principal, nil
+	}
+	h.principalLoaded = true
+
+	if p := h.ctx.Principal(); p != nil {
+		h.principal = p
+		return p, nil
+	}
+	resolve := h.server.cfg.PrincipalResolver
+	if resolve == nil {
+		resolve = DefaultPrincipalResolver
+	}
+	p, err := resolve(h.ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.principal = p
+	return p, nil
+}
+
+// helperTemplateFuncs are template functions backed by TemplateHelpers, for
+// templates that prefer a function call: {{ cspNonce . }}, {{ metaTags . }}.
+// They take the view data (or .Helpers itself) and return "" when helpers
+// aren't bound.
+func helperTemplateFuncs() map[string]any {
+	return map[string]any{
+		"cspNonce": func(data any) string {
+			if h := boundHelpers(data); h != nil {
+				return h.CSPNonce()
+			}
+			return ""
+		},
+		"metaTags": func(data any) template.HTML {
+			if h := boundHelpers(data); h != nil {
+				return h.Meta().Tags()
+			}
+			return ""
+		},
+	}
+}
+
+// boundHelpers returns the TemplateHelpers in view data.
+func boundHelpers(data any) *TemplateHelpers {
+	switch d := data.(type) {
+	case *TemplateHelpers:
+		return d
+	case fiber.Map:
+		h, _ := d["Helpers"].(*TemplateHelpers)
+		return h
+	case map[string]any:
+		h, _ := d["Helpers"].(*TemplateHelpers)
+		return h
+	}
+	return nil
+}
+
+// URL returns the path of the route named name (see RouteConfig.Name). Params
+// fill the route's parameters in order; optional parameters may be left out.
+//
+//	s.Get("/posts/:id", showPost, &cartridge.RouteConfig{Name: "posts.show"})
+//	s.URL("posts.show", 42) // "/posts/42"
+func (s *Server) URL(name string, params ...any) (string, error) {
+	path, ok := s.routeNames[name]
+	if !ok {
+		return "", fmt.Errorf("cartridge: no route named %q", name)
+	}
+
+	segments := strings.Split(path, "/")
+	out := make([]string, 0, len(segments))
+	next := 0
+	for _, segment := range segments {
+		if !isRouteParam(segment) {
+			out = append(out, segment)
+			continue
+		}
+		if next == len(params) {
+			if strings.HasSuffix(segment, "?") || segment == "*" {
+				continue
+			}
+			return "", fmt.Errorf("cartridge: route %q needs a value for %s", name, segment)
+		}
+		value := fmt.Sprint(params[next])
+		next++
+		if segment == "*" || segment == "+" {
+			// Wildcards may span several segments
+			out = append(out, value)
+			continue
+		}
+		out = append(out, url.PathEscape(value))
+	}
+	if next < len(params) {
+		return "", fmt.Errorf("cartridge: route %q takes %d parameters, got %d", name, next, len(params))
+	}
+
+	if joined := strings.Join(out, "/"); joined != "" {
+		return joined, nil
+	}
+	return "/", nil
+}
+
+// isRouteParam reports whether a path segment is a parameter or wildcard.
+func isRouteParam(segment string) bool {
+	return strings.HasPrefix(segment, ":") || segment == "*" || segment == "+"
+}
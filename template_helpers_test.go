@@ -0,0 +1,111 @@
+package cartridge
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/template/html/v2"
+)
+
+func newTemplateTestServer(t *testing.T, views fiber.Views) *Server {
+	t.Helper()
+	cfg := DefaultServerConfig()
+	cfg.EnableStaticAssets = false
+	cfg.EnableRequestLogger = false
+	cfg.EnableSecFetchSite = false
+	cfg.Config = &testConfig{}
+	cfg.Logger = testLogger()
+	cfg.DBManager = &testDBManager{}
+	cfg.ErrorHandler = DefaultErrorHandler(testLogger(), false)
+	cfg.ViewsEngine = views
+	cfg.PrincipalResolver = func(ctx *Context) (*Principal, error) {
+		if id := ctx.Get("X-User"); id != "" {
+			return &Principal{ID: id, Permissions: []string{"posts:*"}}, nil
+		}
+		return nil, nil
+	}
+
+	srv, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	return srv
+}
+
+func TestTemplateHelpers(t *testing.T) {
+	views := fstest.MapFS{
+		"page.html": {Data: []byte(`{{ with .Helpers.CurrentUser }}user={{ .ID }}{{ else }}anonymous{{ end }}` +
+			` write={{ .Helpers.Can "posts:write" }}` +
+			` link={{ .Helpers.Route "posts.show" .ID }}` +
+			` csrf={{ .Helpers.CSRFToken }}` +
+			` nonce={{ cspNonce . }}`)},
+	}
+
+	engine := html.NewFileSystem(http.FS(views), ".html")
+	engine.AddFuncMap(helperTemplateFuncs())
+	srv := newTemplateTestServer(t, engine)
+	srv.Get("/posts/:id", func(ctx *Context) error {
+		ctx.Locals(csrfLocalsKey, "token123")
+		ctx.Locals("csp_nonce", "n0nce")
+		return ctx.Render("page", fiber.Map{"ID": ctx.Params("id")})
+	}, &RouteConfig{Name: "posts.show"})
+
+	render := func(user string) string {
+		req := httptest.NewRequest("GET", "/posts/7", nil)
+		if user != "" {
+			req.Header.Set("X-User", user)
+		}
+		resp, err := srv.App().Test(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	if got, want := render("ada"), "user=ada write=true link=/posts/7 csrf=token123 nonce=n0nce"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	if got, want := render(""), "anonymous write=false link=/posts/7 csrf=token123 nonce=n0nce"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestServerURL(t *testing.T) {
+	srv := newTemplateTestServer(t, nil)
+	noop := func(ctx *Context) error { return nil }
+	srv.Get("/", noop, &RouteConfig{Name: "home"})
+	srv.Get("/users/:user/posts/:slug?", noop, &RouteConfig{Name: "posts"})
+	srv.Get("/files/*", noop, &RouteConfig{Name: "files"})
+
+	tests := []struct {
+		name   string
+		params []any
+		want   string
+	}{
+		{"home", nil, "/"},
+		{"posts", []any{42, "hello world"}, "/users/42/posts/hello%20world"},
+		{"posts", []any{42}, "/users/42/posts"},
+		{"files", []any{"docs/a.pdf"}, "/files/docs/a.pdf"},
+	}
+	for _, tt := range tests {
+		got, err := srv.URL(tt.name, tt.params...)
+		if err != nil || got != tt.want {
+			t.Errorf("URL(%q, %v) = %q, %v; want %q", tt.name, tt.params, got, err, tt.want)
+		}
+	}
+
+	if _, err := srv.URL("posts"); err == nil {
+		t.Error("expected an error for a missing parameter")
+	}
+	if _, err := srv.URL("home", 1); err == nil {
+		t.Error("expected an error for an extra parameter")
+	}
+	if _, err := srv.URL("missing"); err == nil {
+		t.Error("expected an error for an unknown route")
+	}
+}
@@ -0,0 +1,202 @@
+package cartridge
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	texttemplate "text/template"
+	"text/template/parse"
+	"time"
+
+	"github.com/gofiber/template/html/v2"
+)
+
+// templateReloader keeps a development template set in sync with the files
+// on disk without re-parsing all of them on every render, as the engine's
+// own Reload(true) does. Each file's parse trees are cached with a hash of
+// its content: a render stats the files, hashes those whose size or mtime
+// changed, parses only the ones whose content differs, and swaps a set
+// assembled from the cache into the engine. The Fiber app, its routes and
+// renders already in progress keep the set they started with. In a running
+// app a templateWatcher tells it when to look, so renders skip the stats.
+type templateReloader struct {
+	engine *html.Engine
+	dir    string
+	ext    string
+
+	mu    sync.Mutex
+	files map[string]*templateFile // relative path -> parsed file
+	dirty bool                     // files changed since the last swap
+
+	watched atomic.Bool // a templateWatcher reports changes
+	stale   atomic.Bool // the watcher saw a change not yet refreshed
+}
+
+// templateFile is the cached parse of one template file.
+type templateFile struct {
+	modTime time.Time
+	size    int64
+	hash    [sha256.Size]byte
+	trees   map[string]*parse.Tree // the file's template and its {{define}}s
+}
+
+func newTemplateReloader(engine *html.Engine, dir, ext string) *templateReloader {
+	return &templateReloader{
+		engine: engine,
+		dir:    dir,
+		ext:    ext,
+		files:  make(map[string]*templateFile),
+	}
+}
+
+// refresh re-parses changed templates and installs the new set. On a parse
+// error the engine keeps the previous set and the error is returned until
+// the file is fixed.
+func (r *templateReloader) refresh() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	seen := make(map[string]bool, len(r.files))
+	err := filepath.WalkDir(r.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, r.ext) {
+			return nil
+		}
+		rel, err := filepath.Rel(r.dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		seen[rel] = true
+		return r.check(rel, path, d)
+	})
+	if err != nil {
+		return err
+	}
+	for rel := range r.files {
+		if !seen[rel] {
+			delete(r.files, rel)
+			r.dirty = true
+		}
+	}
+
+	if !r.dirty && r.engine.Loaded {
+		return nil
+	}
+	return r.swap()
+}
+
+// refreshStale refreshes when the files may have changed: always without a
+// watcher, otherwise only after it reported a change. A failed refresh stays
+// pending, so every render returns the error until the file is fixed.
+func (r *templateReloader) refreshStale() error {
+	if r.watched.Load() && !r.stale.Swap(false) {
+		return nil
+	}
+	if err := r.refresh(); err != nil {
+		r.stale.Store(true)
+		return err
+	}
+	return nil
+}
+
+// check re-parses one file if its content changed.
+func (r *templateReloader) check(rel, path string, d fs.DirEntry) error {
+	info, err := d.Info()
+	if err != nil {
+		return err
+	}
+	file := r.files[rel]
+	if file != nil && file.size == info.Size() && file.modTime.Equal(info.ModTime()) {
+		return nil
+	}
+
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	hash := sha256.Sum256(src)
+	if file != nil && file.hash == hash {
+		// Touched or saved without edits
+		file.size, file.modTime = info.Size(), info.ModTime()
+		return nil
+	}
+
+	trees, err := r.parse(strings.TrimSuffix(rel, r.ext), string(src))
+	if err != nil {
+		return err
+	}
+	r.files[rel] = &templateFile{modTime: info.ModTime(), size: info.Size(), hash: hash, trees: trees}
+	r.dirty = true
+	return nil
+}
+
+// parse parses one file, named like the engine names it ("layouts/main"),
+// checking its functions against the engine's.
+func (r *templateReloader) parse(name, src string) (map[string]*parse.Tree, error) {
+	r.engine.Mutex.RLock()
+	t, err := texttemplate.New(name).Delims(r.engine.Left, r.engine.Right).Funcs(r.engine.Funcmap).Parse(src)
+	r.engine.Mutex.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	trees := make(map[string]*parse.Tree)
+	for _, tmpl := range t.Templates() {
+		if tmpl.Tree != nil {
+			trees[tmpl.Name()] = tmpl.Tree
+		}
+	}
+	return trees, nil
+}
+
+// swap builds a template set from the cached trees and installs it. Trees
+// are copied because html/template escapes them in place when executed.
+// Files are added in path order so a later {{define}} wins, as with Load.
+func (r *templateReloader) swap() error {
+	r.engine.Mutex.Lock()
+	defer r.engine.Mutex.Unlock()
+
+	set := template.New(r.dir).Delims(r.engine.Left, r.engine.Right).Funcs(r.engine.Funcmap)
+	for _, rel := range slices.Sorted(maps.Keys(r.files)) {
+		for name, tree := range r.files[rel].trees {
+			if _, err := set.AddParseTree(name, tree.Copy()); err != nil {
+				return fmt.Errorf("cartridge: template %s: %w", rel, err)
+			}
+		}
+	}
+	r.engine.Templates = set
+	r.engine.Loaded = true
+	r.dirty = false
+	return nil
+}
+
+// reloadingViews is the development views engine: it brings the template
+// set up to date before each render.
+type reloadingViews struct {
+	*html.Engine
+	reloader *templateReloader
+}
+
+// Load parses the templates when the app starts.
+func (v *reloadingViews) Load() error {
+	return v.reloader.refresh()
+}
+
+// Render re-parses changed templates, then renders name.
+func (v *reloadingViews) Render(out io.Writer, name string, binding any, layout ...string) error {
+	if err := v.reloader.refreshStale(); err != nil {
+		return err
+	}
+	return v.Engine.Render(out, name, binding, layout...)
+}
@@ -0,0 +1,155 @@
+package cartridge
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gofiber/template/html/v2"
+)
+
+func TestTemplateReloader(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		// Ensure a new mtime even on coarse filesystems
+		future := time.Now().Add(time.Duration(len(content)) * time.Second)
+		os.Chtimes(path, future, future)
+	}
+	write("layouts/main.html", `<main>{{embed}}</main>`)
+	write("home.html", `{{template "partials/nav" .}}hello {{upper .}}`)
+	write("partials/nav.html", `[nav]`)
+
+	engine := html.New(dir, ".html")
+	engine.AddFunc("upper", func(s string) string { return s + "!" })
+	views := &reloadingViews{Engine: engine, reloader: newTemplateReloader(engine, dir, ".html")}
+	if err := views.Load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	render := func() string {
+		t.Helper()
+		var buf bytes.Buffer
+		if err := views.Render(&buf, "home", "bob", "layouts/main"); err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+		return buf.String()
+	}
+	if got := render(); got != "<main>[nav]hello bob!</main>" {
+		t.Fatalf("unexpected render %q", got)
+	}
+	set := engine.Templates
+	if render(); engine.Templates != set {
+		t.Error("unchanged templates should not be re-parsed")
+	}
+
+	write("partials/nav.html", `[navigation]`)
+	if got := render(); got != "<main>[navigation]hello bob!</main>" {
+		t.Errorf("edit not picked up: %q", got)
+	}
+	homeTree := views.reloader.files["home.html"].trees["home"]
+
+	write("partials/nav.html", `{{ broken`)
+	var buf bytes.Buffer
+	if err := views.Render(&buf, "home", "bob"); err == nil {
+		t.Error("expected a parse error")
+	}
+	write("partials/nav.html", `[fixed]`)
+	if got := render(); got != "<main>[fixed]hello bob!</main>" {
+		t.Errorf("fix not picked up: %q", got)
+	}
+	if views.reloader.files["home.html"].trees["home"] != homeTree {
+		t.Error("unchanged files should keep their parse trees")
+	}
+
+	os.Remove(filepath.Join(dir, "partials/nav.html"))
+	if err := views.Render(&buf, "home", "bob"); err == nil {
+		t.Error("expected an error after removing a partial")
+	}
+}
+
+func TestTemplateWatcher(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "home.html"), []byte(`v1`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	manifest := filepath.Join(t.TempDir(), "dist", ".vite", "manifest.json")
+	if err := os.MkdirAll(filepath.Dir(manifest), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	engine := html.New(dir, ".html")
+	views := &reloadingViews{Engine: engine, reloader: newTemplateReloader(engine, dir, ".html")}
+	if err := views.Load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	manifestReloads := make(chan struct{}, 10)
+	watcher := newTemplateWatcher(views.reloader).watchManifests([]string{manifest}, func() {
+		manifestReloads <- struct{}{}
+	})
+	watcher.SetupWorker(testLogger(), nil)
+	if err := watcher.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer watcher.Stop()
+	<-manifestReloads // read once at start
+
+	render := func() string {
+		t.Helper()
+		var buf bytes.Buffer
+		if err := views.Render(&buf, "home", nil); err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+		return buf.String()
+	}
+	eventually := func(what string, ok func() bool) {
+		t.Helper()
+		for deadline := time.Now().Add(5 * time.Second); !ok(); time.Sleep(10 * time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s", what)
+			}
+		}
+	}
+
+	// The watcher swaps the new set in without a render asking for it
+	set := engine.Templates
+	os.WriteFile(filepath.Join(dir, "home.html"), []byte(`v2`), 0o644)
+	eventually("the edit", func() bool {
+		engine.Mutex.RLock()
+		defer engine.Mutex.RUnlock()
+		return engine.Templates != set
+	})
+	if got := render(); got != "v2" {
+		t.Errorf("expected the edit to be rendered, got %q", got)
+	}
+
+	// Templates in new directories are picked up too
+	os.MkdirAll(filepath.Join(dir, "partials"), 0o755)
+	time.Sleep(100 * time.Millisecond) // let the watcher add the directory
+	os.WriteFile(filepath.Join(dir, "partials", "nav.html"), []byte(`nav`), 0o644)
+	eventually("the new partial", func() bool {
+		views.reloader.mu.Lock()
+		defer views.reloader.mu.Unlock()
+		return views.reloader.files["partials/nav.html"] != nil
+	})
+
+	os.WriteFile(manifest, []byte(`{}`), 0o644)
+	select {
+	case <-manifestReloads:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the manifest to be reloaded")
+	}
+
+	watcher.Stop()
+	if views.reloader.watched.Load() {
+		t.Error("expected renders to check the files again after Stop")
+	}
+}
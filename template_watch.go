@@ -0,0 +1,206 @@
+package cartridge
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// templateWatchDebounce groups the burst of events one editor save or
+// vite rebuild produces into a single reload.
+const templateWatchDebounce = 50 * time.Millisecond
+
+// templateWatcher reloads development templates, and optionally the Vite
+// manifest, as soon as fsnotify reports a change. While it runs, renders
+// only re-check the template files after a change instead of on every
+// request. The new set is swapped into the same engine, so the Fiber app
+// and in-flight requests are untouched.
+type templateWatcher struct {
+	reloader     *templateReloader // nil if the app has no templates
+	manifests    []string          // manifest files to watch
+	onManifest   func()            // called after a manifest changes
+	manifestDirs []string          // the manifests' directories
+	debounce     time.Duration
+	logger       Logger
+
+	watcher *fsnotify.Watcher
+	done    sync.WaitGroup
+}
+
+func newTemplateWatcher(reloader *templateReloader) *templateWatcher {
+	return &templateWatcher{reloader: reloader, debounce: templateWatchDebounce}
+}
+
+// watchManifests also watches the given manifest files, calling reload when
+// one changes.
+func (w *templateWatcher) watchManifests(files []string, reload func()) *templateWatcher {
+	w.manifests = make([]string, len(files))
+	for i, file := range files {
+		w.manifests[i] = filepath.Clean(file)
+	}
+	w.onManifest = reload
+	return w
+}
+
+// SetupWorker implements WorkerSetup.
+func (w *templateWatcher) SetupWorker(logger Logger, _ DBManager) {
+	w.logger = logger
+}
+
+// Start watches the template directory tree and the manifests' directories.
+// If the watcher can't be set up, e.g. when inotify limits are reached, it
+// logs a warning and renders keep checking the files themselves.
+func (w *templateWatcher) Start() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		w.logger.Warn("template watcher unavailable; templates are checked on every render", "error", err)
+		return nil
+	}
+	if w.reloader != nil {
+		if err := w.addTree(watcher, w.reloader.dir); err != nil {
+			watcher.Close()
+			w.logger.Warn("template watcher unavailable; templates are checked on every render", "error", err)
+			return nil
+		}
+	}
+	if w.onManifest != nil && w.watchManifestDirs(watcher) {
+		w.onManifest()
+	}
+
+	w.watcher = watcher
+	if w.reloader != nil {
+		w.reloader.watched.Store(true)
+	}
+	w.done.Add(1)
+	go w.run()
+	return nil
+}
+
+// Stop closes the watcher. Renders go back to checking the files.
+func (w *templateWatcher) Stop() {
+	if w.watcher == nil {
+		return
+	}
+	w.watcher.Close()
+	w.done.Wait()
+	w.watcher = nil
+	if w.reloader != nil {
+		w.reloader.watched.Store(false)
+	}
+}
+
+// run handles events until the watcher is closed.
+func (w *templateWatcher) run() {
+	defer w.done.Done()
+
+	timer := time.NewTimer(w.debounce)
+	timer.Stop()
+	defer timer.Stop()
+	var templates, manifest bool
+
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			switch {
+			case slices.Contains(w.manifests, filepath.Clean(event.Name)):
+				manifest = true
+			case slices.Contains(w.manifestDirs, filepath.Clean(event.Name)):
+				// vite's emptyOutDir removes the directory on each build
+				if event.Has(fsnotify.Create) {
+					w.watcher.Add(event.Name)
+				}
+				manifest = true
+			case w.reloader != nil && w.inTemplates(event.Name):
+				if event.Has(fsnotify.Create) {
+					// New directories need their own watch; addTree ignores files
+					w.addTree(w.watcher, event.Name)
+				}
+				// Mark the set stale right away so a render that beats the
+				// debounce still sees the change
+				w.reloader.stale.Store(true)
+				templates = true
+			default:
+				continue
+			}
+			timer.Reset(w.debounce)
+
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			if errors.Is(err, fsnotify.ErrEventOverflow) && w.reloader != nil {
+				w.reloader.stale.Store(true)
+				templates = true
+				timer.Reset(w.debounce)
+			}
+			w.logger.Warn("template watcher", "error", err)
+
+		case <-timer.C:
+			if templates {
+				templates = false
+				if err := w.reloader.refreshStale(); err != nil {
+					w.logger.Error("template reload failed", "error", err)
+				} else {
+					w.logger.Debug("templates reloaded")
+				}
+			}
+			if manifest {
+				manifest = false
+				w.onManifest()
+				w.logger.Debug("asset manifest reloaded")
+			}
+		}
+	}
+}
+
+// addTree watches dir and every directory below it.
+func (w *templateWatcher) addTree(watcher *fsnotify.Watcher, dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		return watcher.Add(path)
+	})
+}
+
+// inTemplates reports whether path is inside the template directory.
+func (w *templateWatcher) inTemplates(path string) bool {
+	rel, err := filepath.Rel(w.reloader.dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// watchManifestDirs watches the directories holding the manifests, which
+// vite replaces rather than rewrites, and their parents, to see the
+// directories come back after a clean build. It reports whether any could be
+// watched; if not, the adapter keeps re-reading the manifest itself.
+func (w *templateWatcher) watchManifestDirs(watcher *fsnotify.Watcher) bool {
+	w.manifestDirs = nil
+	for _, file := range w.manifests {
+		if dir := filepath.Dir(file); !slices.Contains(w.manifestDirs, dir) {
+			w.manifestDirs = append(w.manifestDirs, dir)
+		}
+	}
+	watched := false
+	for _, dir := range w.manifestDirs {
+		for _, path := range []string{filepath.Dir(dir), dir} {
+			err := watcher.Add(path)
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				w.logger.Warn("template watcher: watch manifest", "dir", path, "error", err)
+			}
+			watched = watched || err == nil
+		}
+	}
+	return watched
+}
@@ -0,0 +1,311 @@
+package cartridge
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"github.com/karloscodes/cartridge/sqlite"
+)
+
+// tenantLocalsKey stores the request's *Tenant in fiber locals.
+const tenantLocalsKey = "cartridge_tenant"
+
+// tenantIDPattern limits tenant IDs to characters that are safe in
+// subdomains, schema names and file names.
+var tenantIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// Tenant is the customer a request belongs to.
+type Tenant struct {
+	ID string
+	// Data is whatever TenancyConfig.Lookup loaded, e.g. the tenant's row.
+	Data any
+}
+
+// TenantResolver extracts the tenant ID from a request, or "" when the
+// request doesn't name one.
+type TenantResolver func(c *fiber.Ctx) string
+
+// TenantFromSubdomain reads the tenant from the first label of hosts under
+// baseDomain: "acme.example.com" is tenant "acme" for "example.com". The
+// base domain itself and "www" name no tenant.
+func TenantFromSubdomain(baseDomain string) TenantResolver {
+	suffix := "." + strings.TrimPrefix(strings.ToLower(baseDomain), ".")
+	return func(c *fiber.Ctx) string {
+		host, _, _ := strings.Cut(strings.ToLower(c.Hostname()), ":")
+		sub, ok := strings.CutSuffix(host, suffix)
+		if !ok || sub == "www" || strings.Contains(sub, ".") {
+			return ""
+		}
+		return sub
+	}
+}
+
+// TenantFromHeader reads the tenant from a request header, e.g.
+// "X-Tenant-ID" for API clients.
+func TenantFromHeader(name string) TenantResolver {
+	return func(c *fiber.Ctx) string {
+		return strings.ToLower(c.Get(name))
+	}
+}
+
+// TenantFromPath reads the tenant from the path segment after prefix:
+// "/t/acme/products" is tenant "acme" for "/t". Routes still include the
+// segment, e.g. s.Group("/t/:tenant").
+func TenantFromPath(prefix string) TenantResolver {
+	prefix = "/" + strings.Trim(prefix, "/") + "/"
+	if prefix == "//" {
+		prefix = "/"
+	}
+	return func(c *fiber.Ctx) string {
+		rest, ok := strings.CutPrefix(c.Path(), prefix)
+		if !ok {
+			return ""
+		}
+		id, _, _ := strings.Cut(rest, "/")
+		return strings.ToLower(id)
+	}
+}
+
+// TenantDatabase hands out a database scoped to one tenant.
+type TenantDatabase interface {
+	// TenantDB returns the tenant's database. release, if not nil, must be
+	// called once the caller is done with it; ctx.DB() does so when the
+	// request ends.
+	TenantDB(ctx context.Context, tenant string) (db *gorm.DB, release func(), err error)
+}
+
+// TenancyConfig configures multi-tenancy (see ServerConfig.Tenancy).
+type TenancyConfig struct {
+	// Resolvers are tried in order until one names a tenant. Required.
+	Resolvers []TenantResolver
+
+	// Lookup loads the tenant, e.g. from a tenants table, and its result
+	// becomes Tenant.Data. A nil result rejects the tenant with 404.
+	// Default: nil (every well-formed ID is accepted)
+	Lookup func(ctx *Context, id string) (any, error)
+
+	// Required answers 404 to requests that don't name a tenant.
+	// Default: false (such requests run without one)
+	Required bool
+
+	// Database scopes ctx.DB() to the tenant, see NewPostgresTenantSchemas
+	// and NewSQLiteTenantFiles. Default: nil (all tenants share the database)
+	Database TenantDatabase
+
+	// Next defines a function to skip this middleware when it returns true.
+	Next func(c *fiber.Ctx) bool
+}
+
+// tenancyMiddleware resolves the request's tenant and, with a
+// TenantDatabase, releases the tenant's connection after the request.
+func (s *Server) tenancyMiddleware(cfg TenancyConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		var id string
+		for _, resolve := range cfg.Resolvers {
+			if id = resolve(c); id != "" {
+				break
+			}
+		}
+		if id == "" {
+			if cfg.Required {
+				return ErrNotFound("tenant")
+			}
+			return c.Next()
+		}
+		if !tenantIDPattern.MatchString(id) {
+			return ErrNotFound("tenant")
+		}
+
+		ctx := s.context(c)
+		tenant := &Tenant{ID: id}
+		if cfg.Lookup != nil {
+			data, err := cfg.Lookup(ctx, id)
+			if err != nil {
+				return err
+			}
+			if data == nil {
+				return ErrNotFound("tenant")
+			}
+			tenant.Data = data
+		}
+		c.Locals(tenantLocalsKey, tenant)
+
+		if cfg.Database == nil {
+			return c.Next()
+		}
+		ctx.tenantDB = cfg.Database
+		defer ctx.releaseTenantDB()
+		return c.Next()
+	}
+}
+
+// Tenant returns the tenant resolved for this request, or nil when the
+// request has none or tenancy is not configured.
+func (ctx *Context) Tenant() *Tenant {
+	t, _ := ctx.Locals(tenantLocalsKey).(*Tenant)
+	return t
+}
+
+// openTenantDB opens the request tenant's database. Returns nil on failure.
+func (ctx *Context) openTenantDB(reqCtx context.Context) *gorm.DB {
+	db, release, err := ctx.tenantDB.TenantDB(reqCtx, ctx.Tenant().ID)
+	if err != nil {
+		if ctx.Logger != nil {
+			ctx.Logger.Error("failed to open tenant database", "tenant", ctx.Tenant().ID, "error", err)
+		}
+		return nil
+	}
+	ctx.releaseDB = release
+	return db
+}
+
+// releaseTenantDB returns the tenant connection taken by ctx.DB(), if any.
+func (ctx *Context) releaseTenantDB() {
+	if ctx.releaseDB != nil {
+		ctx.releaseDB()
+		ctx.releaseDB = nil
+	}
+	ctx.db = nil
+}
+
+// PostgresTenantSchemas gives each tenant its own PostgreSQL schema named
+// "tenant_<id>". A request holds one connection with search_path set to
+// the tenant's schema (then public), so models, joins and raw SQL all
+// resolve unqualified tables in the tenant's schema.
+type PostgresTenantSchemas struct {
+	db *gorm.DB
+}
+
+// NewPostgresTenantSchemas scopes tenants to schemas of db.
+func NewPostgresTenantSchemas(db *gorm.DB) *PostgresTenantSchemas {
+	return &PostgresTenantSchemas{db: db}
+}
+
+// Schema returns the schema holding tenant's tables. It keeps the ID as is,
+// so tenants differing only in "-" and "_" get separate schemas; quote it
+// in raw SQL.
+func (p *PostgresTenantSchemas) Schema(tenant string) string {
+	return "tenant_" + tenant
+}
+
+// schemaFor validates tenant and returns its schema. PostgreSQL truncates
+// identifiers past 63 bytes, which could merge two tenants, so IDs that
+// long are refused.
+func (p *PostgresTenantSchemas) schemaFor(tenant string) (string, error) {
+	schema := p.Schema(tenant)
+	if !tenantIDPattern.MatchString(tenant) || len(schema) > 63 {
+		return "", fmt.Errorf("cartridge: invalid tenant ID %q", tenant)
+	}
+	return schema, nil
+}
+
+// TenantDB returns a session pinned to a connection using tenant's schema.
+// release resets the connection and returns it to the pool.
+func (p *PostgresTenantSchemas) TenantDB(ctx context.Context, tenant string) (*gorm.DB, func(), error) {
+	schema, err := p.schemaFor(tenant)
+	if err != nil {
+		return nil, nil, err
+	}
+	sqlDB, err := p.db.DB()
+	if err != nil {
+		return nil, nil, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := conn.ExecContext(ctx, "SET search_path TO "+quoteIdent(schema)+", public"); err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("cartridge: set tenant schema: %w", err)
+	}
+
+	db := p.db.Session(&gorm.Session{NewDB: true, Context: ctx})
+	db.Statement.ConnPool = conn
+	release := func() {
+		if _, err := conn.ExecContext(context.Background(), "RESET search_path"); err != nil {
+			// Never hand a connection scoped to a tenant back to the pool
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}
+	return db, release, nil
+}
+
+// Migrate creates tenant's schema if needed and auto-migrates models in it.
+func (p *PostgresTenantSchemas) Migrate(ctx context.Context, tenant string, models ...any) error {
+	schema, err := p.schemaFor(tenant)
+	if err != nil {
+		return err
+	}
+	if err := p.db.WithContext(ctx).Exec("CREATE SCHEMA IF NOT EXISTS " + quoteIdent(schema)).Error; err != nil {
+		return fmt.Errorf("cartridge: create tenant schema: %w", err)
+	}
+	db, release, err := p.TenantDB(ctx, tenant)
+	if err != nil {
+		return err
+	}
+	defer release()
+	if err := db.AutoMigrate(models...); err != nil {
+		return fmt.Errorf("cartridge: migrate tenant %s: %w", tenant, err)
+	}
+	return nil
+}
+
+// quoteIdent quotes a SQL identifier.
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// SQLiteTenantFiles gives each tenant its own SQLite database file,
+// "<dir>/<id>.db", through a sqlite.MultiDBManager: files open on first use
+// and the least recently used idle ones close beyond MaxOpen. As a
+// BackgroundWorker it checkpoints and closes the open files on shutdown;
+// WithTenancy registers it automatically.
+type SQLiteTenantFiles struct {
+	*sqlite.MultiDBManager
+}
+
+// NewSQLiteTenantFiles keeps tenant databases in dir. cfg tunes the
+// manager, e.g. MaxOpen or a Migrate function run on each tenant's first
+// use; its Dir is ignored.
+func NewSQLiteTenantFiles(dir string, cfg ...sqlite.MultiConfig) *SQLiteTenantFiles {
+	var multi sqlite.MultiConfig
+	if len(cfg) > 0 {
+		multi = cfg[0]
+	}
+	multi.Dir = dir
+	return &SQLiteTenantFiles{MultiDBManager: sqlite.NewMultiDBManager(multi)}
+}
+
+// Migrate auto-migrates models in tenant's database.
+func (f *SQLiteTenantFiles) Migrate(ctx context.Context, tenant string, models ...any) error {
+	db, release, err := f.TenantDB(ctx, tenant)
+	if err != nil {
+		return err
+	}
+	defer release()
+	if err := db.AutoMigrate(models...); err != nil {
+		return fmt.Errorf("cartridge: migrate tenant %s: %w", tenant, err)
+	}
+	return nil
+}
+
+// Start implements BackgroundWorker; databases open on demand.
+func (f *SQLiteTenantFiles) Start() error {
+	return nil
+}
+
+// Stop truncates the WAL of every open tenant database and closes it.
+func (f *SQLiteTenantFiles) Stop() {
+	_ = f.Close()
+}
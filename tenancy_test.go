@@ -0,0 +1,154 @@
+package cartridge
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type tenantNote struct {
+	ID   uint
+	Body string
+}
+
+func newTenancyTestServer(t *testing.T, tenancy TenancyConfig) *Server {
+	t.Helper()
+	cfg := DefaultServerConfig()
+	cfg.EnableStaticAssets = false
+	cfg.EnableRequestLogger = false
+	cfg.EnableSecFetchSite = false
+	cfg.Config = &testConfig{}
+	cfg.Logger = testLogger()
+	cfg.DBManager = &testDBManager{}
+	cfg.ErrorHandler = DefaultErrorHandler(testLogger(), false)
+	cfg.Tenancy = &tenancy
+
+	srv, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	return srv
+}
+
+func TestTenancy_Resolvers(t *testing.T) {
+	srv := newTenancyTestServer(t, TenancyConfig{
+		Resolvers: []TenantResolver{
+			TenantFromSubdomain("example.com"),
+			TenantFromHeader("X-Tenant-ID"),
+			TenantFromPath("/t"),
+		},
+		Lookup: func(ctx *Context, id string) (any, error) {
+			if id == "gone" {
+				return nil, nil
+			}
+			return "plan:" + id, nil
+		},
+	})
+	srv.Get("/*", func(ctx *Context) error {
+		if tenant := ctx.Tenant(); tenant != nil {
+			return ctx.SendString(tenant.ID + " " + tenant.Data.(string))
+		}
+		return ctx.SendString("none")
+	})
+
+	tests := []struct {
+		name   string
+		target string
+		header string
+		status int
+		body   string
+	}{
+		{"subdomain", "http://acme.example.com/", "", 200, "acme plan:acme"},
+		{"www", "http://www.example.com/", "", 200, "none"},
+		{"header", "http://example.com/", "Globex", 200, "globex plan:globex"},
+		{"path", "http://example.com/t/initech/reports", "", 200, "initech plan:initech"},
+		{"unknown", "http://gone.example.com/", "", 404, ""},
+		{"malformed", "http://example.com/", "../etc", 404, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.target, nil)
+			if tt.header != "" {
+				req.Header.Set("X-Tenant-ID", tt.header)
+			}
+			resp, err := srv.App().Test(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.status || (tt.body != "" && string(body) != tt.body) {
+				t.Errorf("expected %d %q, got %d %q", tt.status, tt.body, resp.StatusCode, body)
+			}
+		})
+	}
+}
+
+func TestTenancy_SQLiteFiles(t *testing.T) {
+	files := NewSQLiteTenantFiles(t.TempDir())
+	defer files.Stop()
+	for _, tenant := range []string{"acme", "globex"} {
+		if err := files.Migrate(context.Background(), tenant, &tenantNote{}); err != nil {
+			t.Fatalf("Migrate failed: %v", err)
+		}
+	}
+
+	srv := newTenancyTestServer(t, TenancyConfig{
+		Resolvers: []TenantResolver{TenantFromHeader("X-Tenant-ID")},
+		Required:  true,
+		Database:  files,
+	})
+	srv.Post("/notes", func(ctx *Context) error {
+		return ctx.DB().Create(&tenantNote{Body: ctx.Query("body")}).Error
+	})
+
+	for _, tenant := range []string{"acme", "acme", "globex"} {
+		req := httptest.NewRequest("POST", "/notes?body=hi", nil)
+		req.Header.Set("X-Tenant-ID", tenant)
+		resp, err := srv.App().Test(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		if resp.StatusCode != 200 {
+			t.Fatalf("expected 200, got %d", resp.StatusCode)
+		}
+	}
+
+	resp, err := srv.App().Test(httptest.NewRequest("POST", "/notes", nil))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.StatusCode != 404 {
+		t.Errorf("expected 404 without a tenant, got %d", resp.StatusCode)
+	}
+
+	for tenant, want := range map[string]int64{"acme": 2, "globex": 1} {
+		db, release, err := files.TenantDB(context.Background(), tenant)
+		if err != nil {
+			t.Fatalf("TenantDB failed: %v", err)
+		}
+		var count int64
+		db.Model(&tenantNote{}).Count(&count)
+		release()
+		if count != want {
+			t.Errorf("expected %d notes for %s, got %d", want, tenant, count)
+		}
+	}
+}
+
+func TestPostgresTenantSchemas_Schema(t *testing.T) {
+	schemas := NewPostgresTenantSchemas(nil)
+	if a, b := schemas.Schema("acme-co"), schemas.Schema("acme_co"); a == b {
+		t.Errorf("expected separate schemas for acme-co and acme_co, both got %q", a)
+	}
+
+	// Names past PostgreSQL's 63-byte limit would be truncated into one
+	long := strings.Repeat("a", 57)
+	if _, _, err := schemas.TenantDB(context.Background(), long); err == nil {
+		t.Error("expected a tenant ID too long for a schema name to be refused")
+	}
+	if err := schemas.Migrate(context.Background(), "Bad ID"); err == nil {
+		t.Error("expected an invalid tenant ID to be refused")
+	}
+}
@@ -1,16 +1,22 @@
 package testsupport
 
 import (
+	"bytes"
+	"encoding/json"
 	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
 	"testing"
 
 	"github.com/gofiber/fiber/v2"
 
 	"github.com/karloscodes/cartridge"
+	cartridgemiddleware "github.com/karloscodes/cartridge/middleware"
 )
 
 // TestServerOptions configures test server creation.
@@ -136,3 +142,130 @@ func (ts *TestServer) Put(path, body string) *http.Response {
 func (ts *TestServer) Delete(path string) *http.Response {
 	return ts.Request("DELETE", path)
 }
+
+// ReplayOptions configures TestServer.Replay.
+type ReplayOptions struct {
+	// Headers are set on every replayed request, e.g. a session cookie to
+	// stand in for the redacted one.
+	Headers map[string]string
+
+	// IgnoreFields are JSON object keys, at any depth, left out when
+	// comparing JSON bodies, e.g. "id" or "created_at".
+	IgnoreFields []string
+}
+
+// Replay sends every fixture recorded by middleware.RequestCapture in dir
+// through the server, one subtest per fixture, and checks the status,
+// Location header and body against the recorded response. JSON bodies are
+// compared as values; other bodies must match exactly.
+func (ts *TestServer) Replay(dir string, opts ...ReplayOptions) {
+	ts.t.Helper()
+
+	var options ReplayOptions
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		ts.t.Fatalf("testsupport: list fixtures: %v", err)
+	}
+	if len(paths) == 0 {
+		ts.t.Fatalf("testsupport: no fixtures in %s", dir)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		fixture, err := cartridgemiddleware.ReadCaptureFixture(path)
+		if err != nil {
+			ts.t.Fatalf("testsupport: %v", err)
+		}
+		name := strings.TrimSuffix(filepath.Base(path), ".json")
+		ts.t.Run(name, func(t *testing.T) {
+			ts.replay(t, fixture, options)
+		})
+	}
+}
+
+// replay sends one fixture and compares the response.
+func (ts *TestServer) replay(t *testing.T, fixture *cartridgemiddleware.CaptureFixture, options ReplayOptions) {
+	t.Helper()
+
+	body, err := fixture.Request.DecodedBody()
+	if err != nil {
+		t.Fatalf("testsupport: decode request body: %v", err)
+	}
+	req := httptest.NewRequest(fixture.Request.Method, fixture.Request.Path, bytes.NewReader(body))
+	for name, value := range fixture.Request.Headers {
+		if value != "[REDACTED]" {
+			req.Header.Set(name, value)
+		}
+	}
+	for name, value := range options.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := ts.App.Test(req, -1)
+	if err != nil {
+		t.Fatalf("testsupport: request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	got, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("testsupport: read response: %v", err)
+	}
+
+	want := fixture.Response
+	if resp.StatusCode != want.Status {
+		t.Errorf("%s %s: expected status %d, got %d", fixture.Request.Method, fixture.Request.Path, want.Status, resp.StatusCode)
+	}
+	if location := headerValue(want.Headers, "Location"); resp.Header.Get("Location") != location {
+		t.Errorf("expected Location %q, got %q", location, resp.Header.Get("Location"))
+	}
+
+	wantBody, err := want.DecodedBody()
+	if err != nil {
+		t.Fatalf("testsupport: decode response body: %v", err)
+	}
+	if !sameBody(wantBody, got, options.IgnoreFields) {
+		t.Errorf("response body differs from %s\nexpected: %s\ngot:      %s", fixture.Name, wantBody, got)
+	}
+}
+
+// headerValue looks up a header in a captured header map.
+func headerValue(headers map[string]string, name string) string {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
+
+// sameBody compares JSON bodies as values without the ignored fields, and
+// anything else byte for byte.
+func sameBody(want, got []byte, ignore []string) bool {
+	var wantJSON, gotJSON any
+	if json.Unmarshal(want, &wantJSON) != nil || json.Unmarshal(got, &gotJSON) != nil {
+		return bytes.Equal(want, got)
+	}
+	return reflect.DeepEqual(dropFields(wantJSON, ignore), dropFields(gotJSON, ignore))
+}
+
+// dropFields removes the named keys from every object in a decoded JSON value.
+func dropFields(value any, fields []string) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for _, field := range fields {
+			delete(v, field)
+		}
+		for key, item := range v {
+			v[key] = dropFields(item, fields)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = dropFields(item, fields)
+		}
+	}
+	return value
+}
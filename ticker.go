@@ -0,0 +1,178 @@
+package cartridge
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// Ticker runs a function at a fixed interval. It implements BackgroundWorker;
+// use Application.Every to create one that starts and stops with the app.
+type Ticker struct {
+	name      string
+	interval  time.Duration
+	jitter    time.Duration
+	immediate bool
+	fn        func(ctx *JobContext) error
+	logger    Logger
+	dbManager DBManager
+
+	mu      sync.Mutex
+	running bool
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// TickerOption configures a Ticker.
+type TickerOption func(*Ticker)
+
+// TickerName labels the ticker in logs. Default: "every <interval>".
+func TickerName(name string) TickerOption {
+	return func(t *Ticker) {
+		t.name = name
+	}
+}
+
+// TickerJitter adds a random delay of up to d before each run, so instances
+// started together don't hit shared resources at the same moment.
+func TickerJitter(d time.Duration) TickerOption {
+	return func(t *Ticker) {
+		t.jitter = d
+	}
+}
+
+// TickerImmediate runs the function once on Start instead of waiting for the
+// first interval.
+func TickerImmediate() TickerOption {
+	return func(t *Ticker) {
+		t.immediate = true
+	}
+}
+
+// NewTicker creates a ticker that calls fn every interval. Runs never
+// overlap: the next wait starts when a run finishes. dbManager may be nil.
+func NewTicker(logger Logger, dbManager DBManager, interval time.Duration, fn func(ctx *JobContext) error, opts ...TickerOption) *Ticker {
+	t := &Ticker{
+		name:      fmt.Sprintf("every %s", interval),
+		interval:  interval,
+		fn:        fn,
+		logger:    logger,
+		dbManager: dbManager,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Every registers fn to run at a fixed interval while the application runs.
+// It is a lighter alternative to a cron job for simple loops:
+//
+//	app.Every(30*time.Second, refreshRates, cartridge.TickerJitter(5*time.Second))
+//
+// On shutdown the ticker stops scheduling runs and waits for the current one;
+// its context is canceled so long runs can return early.
+func (a *Application) Every(interval time.Duration, fn func(ctx *JobContext) error, opts ...TickerOption) *Ticker {
+	t := NewTicker(a.Logger, a.DBManager, interval, fn, opts...)
+	a.AddWorker(t)
+	return t
+}
+
+// Start begins the ticker loop.
+func (t *Ticker) Start() error {
+	if t.interval <= 0 {
+		return fmt.Errorf("cartridge: ticker %q interval must be positive", t.name)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.running {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+	t.running = true
+	t.wg.Add(1)
+	go t.loop(ctx)
+	return nil
+}
+
+// Stop cancels the ticker context and waits for the current run to finish.
+func (t *Ticker) Stop() {
+	t.mu.Lock()
+	if !t.running {
+		t.mu.Unlock()
+		return
+	}
+	t.cancel()
+	t.running = false
+	t.mu.Unlock()
+	t.wg.Wait()
+}
+
+func (t *Ticker) loop(ctx context.Context) {
+	defer t.wg.Done()
+
+	if t.immediate {
+		t.run(ctx)
+	}
+
+	timer := time.NewTimer(t.nextDelay())
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			t.run(ctx)
+			timer.Reset(t.nextDelay())
+		}
+	}
+}
+
+// nextDelay returns the interval plus a random jitter.
+func (t *Ticker) nextDelay() time.Duration {
+	if t.jitter <= 0 {
+		return t.interval
+	}
+	return t.interval + rand.N(t.jitter)
+}
+
+// run invokes fn once, recovering panics so the loop keeps going.
+func (t *Ticker) run(ctx context.Context) {
+	ctx, span := startJobSpan(ctx, "ticker "+t.name, trace.SpanContext{})
+	var err error
+	defer func() { endJobSpan(span, err) }()
+
+	jobCtx := &JobContext{
+		Context: ctx,
+		Logger:  t.logger,
+	}
+	if t.dbManager != nil {
+		var db *gorm.DB
+		db, err = t.dbManager.Connect()
+		if err != nil {
+			t.logger.Error("ticker failed to connect to database", "ticker", t.name, "error", err)
+			return
+		}
+		if db != nil {
+			jobCtx.DB = db.WithContext(ctx)
+		}
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+			t.logger.Error("ticker panicked", "ticker", t.name, "panic", r)
+		}
+	}()
+	if err = t.fn(jobCtx); err != nil {
+		t.logger.Error("ticker run failed", "ticker", t.name, "error", err)
+	}
+}
@@ -0,0 +1,108 @@
+package cartridge
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTicker(t *testing.T) {
+	t.Run("runs at the interval", func(t *testing.T) {
+		var runs atomic.Int32
+		ticker := NewTicker(testLogger(), nil, 10*time.Millisecond, func(ctx *JobContext) error {
+			runs.Add(1)
+			return nil
+		})
+		if err := ticker.Start(); err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+		time.Sleep(55 * time.Millisecond)
+		ticker.Stop()
+
+		if n := runs.Load(); n < 3 {
+			t.Errorf("expected at least 3 runs, got %d", n)
+		}
+		stopped := runs.Load()
+		time.Sleep(30 * time.Millisecond)
+		if runs.Load() != stopped {
+			t.Error("ticker kept running after Stop")
+		}
+	})
+
+	t.Run("immediate first run", func(t *testing.T) {
+		ran := make(chan struct{}, 1)
+		ticker := NewTicker(testLogger(), nil, time.Hour, func(ctx *JobContext) error {
+			ran <- struct{}{}
+			return nil
+		}, TickerImmediate())
+		if err := ticker.Start(); err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+		defer ticker.Stop()
+
+		select {
+		case <-ran:
+		case <-time.After(time.Second):
+			t.Fatal("expected an immediate run")
+		}
+	})
+
+	t.Run("stop cancels a running function", func(t *testing.T) {
+		started := make(chan struct{})
+		ticker := NewTicker(testLogger(), nil, time.Hour, func(ctx *JobContext) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		}, TickerImmediate())
+		if err := ticker.Start(); err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+		<-started
+
+		done := make(chan struct{})
+		go func() {
+			ticker.Stop()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("Stop did not return")
+		}
+	})
+
+	t.Run("survives errors and panics", func(t *testing.T) {
+		var runs atomic.Int32
+		ticker := NewTicker(testLogger(), nil, 5*time.Millisecond, func(ctx *JobContext) error {
+			if runs.Add(1) == 1 {
+				panic("boom")
+			}
+			return errors.New("failed")
+		}, TickerJitter(time.Millisecond))
+		if err := ticker.Start(); err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+		time.Sleep(40 * time.Millisecond)
+		ticker.Stop()
+
+		if n := runs.Load(); n < 2 {
+			t.Errorf("expected ticker to keep running after a panic, got %d runs", n)
+		}
+	})
+
+	t.Run("rejects non-positive interval", func(t *testing.T) {
+		ticker := NewTicker(testLogger(), nil, 0, func(ctx *JobContext) error { return nil })
+		if err := ticker.Start(); err == nil {
+			t.Error("expected an error for zero interval")
+		}
+	})
+}
+
+func TestApplicationEvery(t *testing.T) {
+	app := &Application{Logger: testLogger()}
+	ticker := app.Every(time.Minute, func(ctx *JobContext) error { return nil })
+	if len(app.workers) != 1 || app.workers[0] != ticker {
+		t.Errorf("expected ticker to be registered as a worker, got %v", app.workers)
+	}
+}
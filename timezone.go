@@ -0,0 +1,173 @@
+package cartridge
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// TimezoneConfig configures how the user's time zone is resolved.
+type TimezoneConfig struct {
+	// Resolver returns the time zone stored on the user's profile, or "".
+	// It is consulted first. Optional.
+	Resolver func(c *fiber.Ctx) string
+
+	// CookieName is the cookie holding an IANA zone name. Default: "tz".
+	CookieName string
+
+	// Header is the request header holding an IANA zone name. Default: "X-Timezone".
+	Header string
+
+	// Default is used when nothing else resolves. Default: time.UTC.
+	Default *time.Location
+}
+
+// locationLocalsKey stores the request's *time.Location in fiber locals.
+const locationLocalsKey = "cartridge_location"
+
+// DefaultTimeLayout is used by the localtime template helper.
+const DefaultTimeLayout = "Jan 2, 2006 3:04 PM"
+
+// locationCache avoids re-reading zoneinfo for every request.
+var locationCache sync.Map // map[string]*time.Location
+
+// loadLocation returns the named location, or nil if the name is invalid.
+func loadLocation(name string) *time.Location {
+	if name == "" {
+		return nil
+	}
+	if loc, ok := locationCache.Load(name); ok {
+		return loc.(*time.Location)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil
+	}
+	locationCache.Store(name, loc)
+	return loc
+}
+
+// TimezoneMiddleware resolves the user's time zone from the profile resolver,
+// the tz cookie, or the X-Timezone header (in that order) and makes it
+// available through ctx.Location(). Rendered fiber.Map data also receives a
+// "Timezone" value for the localtime template helper.
+func TimezoneMiddleware(cfg TimezoneConfig) fiber.Handler {
+	cookieName := cfg.CookieName
+	if cookieName == "" {
+		cookieName = "tz"
+	}
+	header := cfg.Header
+	if header == "" {
+		header = "X-Timezone"
+	}
+	fallback := cfg.Default
+	if fallback == nil {
+		fallback = time.UTC
+	}
+
+	return func(c *fiber.Ctx) error {
+		var loc *time.Location
+		if cfg.Resolver != nil {
+			loc = loadLocation(cfg.Resolver(c))
+		}
+		if loc == nil {
+			loc = loadLocation(c.Cookies(cookieName))
+		}
+		if loc == nil {
+			loc = loadLocation(c.Get(header))
+		}
+		if loc == nil {
+			loc = fallback
+		}
+
+		c.Locals(locationLocalsKey, loc)
+		if err := c.Bind(fiber.Map{"Timezone": loc}); err != nil {
+			return err
+		}
+		return c.Next()
+	}
+}
+
+// Location returns the user's time zone resolved by TimezoneMiddleware.
+// Returns time.UTC if the middleware is not installed.
+func (ctx *Context) Location() *time.Location {
+	if loc, ok := ctx.Locals(locationLocalsKey).(*time.Location); ok {
+		return loc
+	}
+	return time.UTC
+}
+
+// LocalTime converts t to the user's time zone, e.g. before passing
+// timestamps to Inertia props.
+func (ctx *Context) LocalTime(t time.Time) time.Time {
+	return t.In(ctx.Location())
+}
+
+// FormatTimeAgo describes t relative to now: "just now", "5 minutes ago",
+// "in 2 hours", "3 days ago". Times more than 30 days away are formatted as
+// a date ("Jan 2, 2006").
+func FormatTimeAgo(t time.Time) string {
+	return formatTimeAgo(t, time.Now())
+}
+
+func formatTimeAgo(t, now time.Time) string {
+	d := now.Sub(t)
+	future := d < 0
+	if future {
+		d = -d
+	}
+
+	var amount int
+	var unit string
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		amount, unit = int(d/time.Minute), "minute"
+	case d < 24*time.Hour:
+		amount, unit = int(d/time.Hour), "hour"
+	case d < 30*24*time.Hour:
+		amount, unit = int(d/(24*time.Hour)), "day"
+	default:
+		return t.Format("Jan 2, 2006")
+	}
+
+	if amount != 1 {
+		unit += "s"
+	}
+	if future {
+		return fmt.Sprintf("in %d %s", amount, unit)
+	}
+	return fmt.Sprintf("%d %s ago", amount, unit)
+}
+
+// timeTemplateFuncs returns the "localtime" and "timeago" template helpers.
+//
+//	{{localtime .CreatedAt .Timezone}}
+//	{{localtime .CreatedAt .Timezone "2006-01-02"}}
+//	{{timeago .CreatedAt}}
+func timeTemplateFuncs() map[string]any {
+	return map[string]any{
+		"localtime": func(t time.Time, zone any, layout ...string) string {
+			loc := time.UTC
+			switch z := zone.(type) {
+			case *time.Location:
+				if z != nil {
+					loc = z
+				}
+			case string:
+				if l := loadLocation(z); l != nil {
+					loc = l
+				}
+			}
+			format := DefaultTimeLayout
+			if len(layout) > 0 && layout[0] != "" {
+				format = layout[0]
+			}
+			return t.In(loc).Format(format)
+		},
+		"timeago": FormatTimeAgo,
+	}
+}
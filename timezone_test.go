@@ -0,0 +1,94 @@
+package cartridge
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestTimezoneMiddleware(t *testing.T) {
+	app := fiber.New()
+	app.Use(TimezoneMiddleware(TimezoneConfig{
+		Resolver: func(c *fiber.Ctx) string { return c.Query("profile_tz") },
+	}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString((&Context{Ctx: c}).Location().String())
+	})
+
+	tests := []struct {
+		name   string
+		path   string
+		cookie string
+		header string
+		want   string
+	}{
+		{name: "default", path: "/", want: "UTC"},
+		{name: "header", path: "/", header: "Europe/Madrid", want: "Europe/Madrid"},
+		{name: "cookie beats header", path: "/", cookie: "Asia/Tokyo", header: "Europe/Madrid", want: "Asia/Tokyo"},
+		{name: "profile beats cookie", path: "/?profile_tz=America/New_York", cookie: "Asia/Tokyo", want: "America/New_York"},
+		{name: "invalid zone ignored", path: "/", header: "Not/AZone", want: "UTC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httpGet(tt.path)
+			if tt.cookie != "" {
+				req.Header.Set("Cookie", "tz="+tt.cookie)
+			}
+			if tt.header != "" {
+				req.Header.Set("X-Timezone", tt.header)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if string(body) != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, body)
+			}
+		})
+	}
+}
+
+func TestFormatTimeAgo(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		t    time.Time
+		want string
+	}{
+		{now.Add(-30 * time.Second), "just now"},
+		{now.Add(-1 * time.Minute), "1 minute ago"},
+		{now.Add(-5 * time.Minute), "5 minutes ago"},
+		{now.Add(-3 * time.Hour), "3 hours ago"},
+		{now.Add(-2 * 24 * time.Hour), "2 days ago"},
+		{now.Add(2 * time.Hour), "in 2 hours"},
+		{now.Add(-60 * 24 * time.Hour), "Apr 16, 2024"},
+	}
+	for _, tt := range tests {
+		if got := formatTimeAgo(tt.t, now); got != tt.want {
+			t.Errorf("formatTimeAgo(%v) = %q, want %q", tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestLocaltimeTemplateFunc(t *testing.T) {
+	tpl := template.Must(template.New("t").Funcs(timeTemplateFuncs()).
+		Parse(`{{localtime .At .Timezone}}|{{localtime .At "Asia/Tokyo" "15:04"}}`))
+
+	madrid, _ := time.LoadLocation("Europe/Madrid")
+	var buf bytes.Buffer
+	err := tpl.Execute(&buf, map[string]any{
+		"At":       time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC),
+		"Timezone": madrid,
+	})
+	if err != nil {
+		t.Fatalf("execute failed: %v", err)
+	}
+	if got := buf.String(); got != "Jan 10, 2024 1:00 PM|21:00" {
+		t.Errorf("unexpected output %q", got)
+	}
+}
@@ -0,0 +1,115 @@
+package cartridge
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/karloscodes/cartridge"
+
+// TracingConfig configures OpenTelemetry trace export.
+type TracingConfig struct {
+	// Endpoint is the OTLP/HTTP collector. A bare "host:port" is sent over
+	// plain HTTP (a local agent); use an "https://" URL for remote collectors.
+	Endpoint string
+
+	// ServiceName identifies the app in the tracing backend.
+	ServiceName string
+
+	// SampleRatio is the fraction of new traces recorded. Traces started by a
+	// caller keep the caller's decision. Default: 1 (all)
+	SampleRatio float64
+}
+
+// Tracing exports spans to an OTLP collector. It is a BackgroundWorker whose
+// Stop flushes pending spans; register it first so it stops last.
+type Tracing struct {
+	provider *sdktrace.TracerProvider
+}
+
+// SetupTracing creates an OTLP exporter and installs it as the global tracer
+// provider, along with W3C trace context propagation. Request spans need
+// ServerConfig.EnableTracing; async tasks, cron runs and GORM queries are
+// traced automatically once a provider is installed.
+//
+//	tracing, err := cartridge.SetupTracing(cartridge.TracingConfig{
+//	    Endpoint:    "localhost:4318",
+//	    ServiceName: "myapp",
+//	})
+//	app, err := cartridge.NewApplication(cartridge.ApplicationOptions{
+//	    BackgroundWorkers: []cartridge.BackgroundWorker{tracing},
+//	    ...
+//	})
+func SetupTracing(cfg TracingConfig) (*Tracing, error) {
+	if cfg.Endpoint == "" {
+		return nil, fmt.Errorf("cartridge: tracing endpoint is required")
+	}
+	if cfg.SampleRatio <= 0 {
+		cfg.SampleRatio = 1
+	}
+
+	var opts []otlptracehttp.Option
+	if strings.Contains(cfg.Endpoint, "://") {
+		opts = append(opts, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+	} else {
+		opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint), otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("cartridge: create trace exporter: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", cfg.ServiceName))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+	return &Tracing{provider: provider}, nil
+}
+
+// Start implements BackgroundWorker; spans are exported in the background
+// from the moment SetupTracing returns.
+func (t *Tracing) Start() error {
+	return nil
+}
+
+// Stop flushes pending spans and shuts the exporter down.
+func (t *Tracing) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = t.provider.Shutdown(ctx)
+}
+
+// startJobSpan starts a span for background work. A valid parent places the
+// span in the trace of the request that queued the work.
+func startJobSpan(ctx context.Context, name string, parent trace.SpanContext, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if parent.IsValid() {
+		ctx = trace.ContextWithSpanContext(ctx, parent)
+	}
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endJobSpan records err, if any, and ends the span.
+func endJobSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
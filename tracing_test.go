@@ -0,0 +1,97 @@
+package cartridge
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// useTestTracer installs a recording tracer provider for the test.
+func useTestTracer(t *testing.T) (*sdktrace.TracerProvider, *tracetest.SpanRecorder) {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return provider, recorder
+}
+
+func TestTracing_AsyncTasks(t *testing.T) {
+	provider, recorder := useTestTracer(t)
+
+	m := NewAsyncManager(AsyncConfig{Logger: testLogger()})
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer m.Stop()
+
+	reqCtx, reqSpan := provider.Tracer("test").Start(context.Background(), "GET /reports")
+	id, err := m.RunFunc("report", func(ctx *JobContext) (any, error) {
+		return nil, errors.New("no data")
+	}, AsyncTraceFrom(reqCtx))
+	if err != nil {
+		t.Fatalf("RunFunc failed: %v", err)
+	}
+	reqSpan.End()
+	waitForAsyncStatus(t, m, id, AsyncFailed)
+
+	var found bool
+	for _, span := range recorder.Ended() {
+		if span.Name() != "async report" {
+			continue
+		}
+		found = true
+		if span.Parent().SpanID() != reqSpan.SpanContext().SpanID() {
+			t.Error("expected async span to be a child of the request span")
+		}
+		if span.Status().Code.String() != "Error" {
+			t.Errorf("expected error status, got %v", span.Status())
+		}
+	}
+	if !found {
+		t.Fatal("expected an async span")
+	}
+}
+
+func TestTracing_CronRuns(t *testing.T) {
+	_, recorder := useTestTracer(t)
+
+	m := NewCronManager(CronConfig{Logger: testLogger()})
+	var recording bool
+	if err := m.Add(CronJob{ID: "cleanup", Schedule: "@every 1h", Handler: func(ctx *JobContext) error {
+		recording = oteltrace.SpanFromContext(ctx).IsRecording()
+		return nil
+	}}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer m.Stop()
+
+	m.mu.Lock()
+	m.entries["cleanup"].running++
+	m.runs.Add(1)
+	m.mu.Unlock()
+	m.execute(m.ctx, m.entries["cleanup"])
+
+	if !recording {
+		t.Error("expected the handler context to carry the cron span")
+	}
+	spans := recorder.Ended()
+	if len(spans) != 1 || spans[0].Name() != "cron cleanup" {
+		t.Errorf("expected a cron span, got %v", spans)
+	}
+}
+
+func TestSetupTracing_RequiresEndpoint(t *testing.T) {
+	if _, err := SetupTracing(TracingConfig{}); err == nil {
+		t.Error("expected an error without an endpoint")
+	}
+}
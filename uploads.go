@@ -0,0 +1,351 @@
+package cartridge
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// UploadStorage stores uploaded files under slash-separated keys.
+type UploadStorage interface {
+	// Put stores size bytes from r under key.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+
+	// Open returns the stored file. The caller closes it.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete removes the file. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+
+	// URL returns the public URL of the file.
+	URL(key string) string
+}
+
+// ErrUploadNotFound is returned by UploadStorage.Open for unknown keys.
+var ErrUploadNotFound = errors.New("cartridge: upload not found")
+
+// UploadOptions configures ctx.SaveUpload.
+type UploadOptions struct {
+	// MaxSize is the largest accepted file in bytes. Default: 10MB
+	MaxSize int64
+
+	// AllowedTypes lists accepted MIME types, detected from the file content
+	// rather than the client's header. "image/*" matches any image. The file
+	// name's extension must then agree with the detected type, so a PNG named
+	// "x.html" isn't served as HTML. Empty accepts any type and name.
+	AllowedTypes []string
+
+	// Dir prefixes the storage key, e.g. "avatars".
+	Dir string
+
+	// Storage overrides ServerConfig.UploadStorage for this upload.
+	Storage UploadStorage
+}
+
+// StoredFile describes a saved upload.
+type StoredFile struct {
+	Key         string `json:"key"`          // storage key, e.g. "avatars/3f2a9c1d8e7b6a50-photo.png"
+	Name        string `json:"name"`         // sanitized original file name
+	Size        int64  `json:"size"`         // bytes
+	ContentType string `json:"content_type"` // detected MIME type
+
+	storage UploadStorage
+}
+
+// URL returns the file's public URL from its storage backend.
+func (f *StoredFile) URL() string {
+	if f.storage == nil {
+		return ""
+	}
+	return f.storage.URL(f.Key)
+}
+
+// SaveUpload validates the multipart file in fieldName and stores it under a
+// random, sanitized key. Missing files and size or type violations return
+// *Error (400, 413 or 415) with a message safe to show to users:
+//
+//	file, err := ctx.SaveUpload("avatar", cartridge.UploadOptions{
+//	    MaxSize:      2 << 20,
+//	    AllowedTypes: []string{"image/png", "image/jpeg"},
+//	    Dir:          "avatars",
+//	})
+//	if err != nil {
+//	    return err
+//	}
+//	user.AvatarURL = file.URL()
+func (ctx *Context) SaveUpload(fieldName string, opts UploadOptions) (*StoredFile, error) {
+	storage := opts.Storage
+	if storage == nil {
+		storage = ctx.uploads
+	}
+	if storage == nil {
+		return nil, fmt.Errorf("cartridge: no upload storage configured (set ServerConfig.UploadStorage)")
+	}
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = 10 << 20
+	}
+
+	header, err := ctx.FormFile(fieldName)
+	if err != nil {
+		return nil, ErrBadRequest(fmt.Sprintf("missing file %q", fieldName))
+	}
+	if header.Size > opts.MaxSize {
+		return nil, NewError(fiber.StatusRequestEntityTooLarge, fmt.Sprintf("file exceeds %d bytes", opts.MaxSize))
+	}
+	return saveUpload(ctx.UserContext(), header, storage, opts)
+}
+
+// saveUpload sniffs, validates and stores a multipart file.
+func saveUpload(ctx context.Context, header *multipart.FileHeader, storage UploadStorage, opts UploadOptions) (*StoredFile, error) {
+	file, err := header.Open()
+	if err != nil {
+		return nil, fmt.Errorf("cartridge: open upload: %w", err)
+	}
+	defer file.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("cartridge: read upload: %w", err)
+	}
+	head = head[:n]
+	contentType := http.DetectContentType(head)
+	if mediaType, _, ok := strings.Cut(contentType, ";"); ok {
+		contentType = mediaType
+	}
+	if !uploadTypeAllowed(contentType, opts.AllowedTypes) {
+		return nil, NewError(fiber.StatusUnsupportedMediaType, fmt.Sprintf("file type %s is not allowed", contentType))
+	}
+
+	name := SanitizeFilename(header.Filename)
+	if len(opts.AllowedTypes) > 0 && !uploadExtensionMatches(name, contentType) {
+		return nil, NewError(fiber.StatusUnsupportedMediaType, fmt.Sprintf("file extension %s does not match its content (%s)", path.Ext(name), contentType))
+	}
+	key := path.Join(strings.Trim(opts.Dir, "/"), randomHex(8)+"-"+name)
+	body := io.MultiReader(bytes.NewReader(head), file)
+	if err := storage.Put(ctx, key, body, header.Size, contentType); err != nil {
+		return nil, fmt.Errorf("cartridge: store upload: %w", err)
+	}
+	return &StoredFile{Key: key, Name: name, Size: header.Size, ContentType: contentType, storage: storage}, nil
+}
+
+// uploadTypeAllowed matches a MIME type against an allowlist with "type/*" wildcards.
+func uploadTypeAllowed(contentType string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, a := range allowed {
+		if a == contentType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(a, "/*"); ok && strings.HasPrefix(contentType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// uploadExtensionMatches reports whether a stored file named name would be
+// served as contentType. Files without an extension, or with one the server
+// doesn't know, are served as downloads and always match.
+func uploadExtensionMatches(name, contentType string) bool {
+	ext := path.Ext(name)
+	if ext == "" {
+		return true
+	}
+	served, _, _ := strings.Cut(utils.GetMIME(ext), ";")
+	return served == contentType || served == fiber.MIMEOctetStream
+}
+
+// randomHex returns n random bytes, hex encoded.
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// SanitizeFilename reduces a client-supplied file name to a safe base name:
+// directories are dropped, and anything but letters, digits, ".", "-" and
+// "_" becomes "-". Names are capped at 100 bytes, keeping the extension.
+// Returns "file" when nothing usable is left.
+func SanitizeFilename(name string) string {
+	// Browsers on Windows may send the full path
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+
+	var b strings.Builder
+	dash := false
+	for _, r := range name {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)), r == '.', r == '_':
+			b.WriteRune(r)
+			dash = false
+		case !dash:
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	clean := strings.Trim(b.String(), ".-_")
+	if clean == "" {
+		return "file"
+	}
+
+	if len(clean) > 100 {
+		ext := path.Ext(clean)
+		if len(ext) > 16 {
+			ext = ""
+		}
+		clean = strings.TrimRight(clean[:100-len(ext)], ".-_") + ext
+	}
+	return clean
+}
+
+// LocalStorage stores uploads on disk.
+type LocalStorage struct {
+	root    string
+	baseURL string
+}
+
+// NewLocalStorage stores files below root and builds URLs under baseURL, e.g.
+// NewLocalStorage("data/uploads", "/uploads"). Serve root at baseURL yourself,
+// or keep files private and stream them with Open.
+func NewLocalStorage(root, baseURL string) *LocalStorage {
+	return &LocalStorage{root: root, baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// path maps a key to a file below root, rejecting keys that escape it.
+func (l *LocalStorage) path(key string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(key)) {
+		return "", fmt.Errorf("cartridge: invalid upload key %q", key)
+	}
+	return filepath.Join(l.root, filepath.FromSlash(key)), nil
+}
+
+// Put implements UploadStorage.
+func (l *LocalStorage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	p, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	// Write to a temp file first so readers never see a partial upload
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+// Open implements UploadStorage.
+func (l *LocalStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	p, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrUploadNotFound
+	}
+	return f, err
+}
+
+// Delete implements UploadStorage.
+func (l *LocalStorage) Delete(ctx context.Context, key string) error {
+	p, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// URL implements UploadStorage.
+func (l *LocalStorage) URL(key string) string {
+	return l.baseURL + "/" + key
+}
+
+// MemoryStorage keeps uploads in memory. Use it in tests.
+type MemoryStorage struct {
+	mu    sync.RWMutex
+	files map[string][]byte
+}
+
+// NewMemoryStorage creates an empty in-memory upload store.
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{files: make(map[string][]byte)}
+}
+
+// Put implements UploadStorage.
+func (m *MemoryStorage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files[key] = data
+	return nil
+}
+
+// Open implements UploadStorage.
+func (m *MemoryStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	data, ok := m.files[key]
+	if !ok {
+		return nil, ErrUploadNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// Delete implements UploadStorage.
+func (m *MemoryStorage) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.files, key)
+	return nil
+}
+
+// URL implements UploadStorage.
+func (m *MemoryStorage) URL(key string) string {
+	return "/uploads/" + key
+}
+
+// Keys returns the stored keys.
+func (m *MemoryStorage) Keys() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	keys := make([]string, 0, len(m.files))
+	for k := range m.files {
+		keys = append(keys, k)
+	}
+	return keys
+}
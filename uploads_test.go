@@ -0,0 +1,231 @@
+package cartridge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestSanitizeFilename(t *testing.T) {
+	tests := map[string]string{
+		"photo.png":                       "photo.png",
+		`C:\Users\ana\My Photo.JPG`:       "My-Photo.JPG",
+		"../../etc/passwd":                "passwd",
+		"..":                              "file",
+		"<script>alert(1)</script>":       "script",
+		".htaccess":                       "htaccess",
+		"résumé final (2).pdf":            "r-sum-final-2-.pdf",
+		strings.Repeat("a", 200) + ".txt": strings.Repeat("a", 96) + ".txt",
+	}
+	for in, want := range tests {
+		if got := SanitizeFilename(in); got != want {
+			t.Errorf("SanitizeFilename(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// uploadRequest builds a multipart request with one file field.
+func uploadRequest(t *testing.T, field, filename string, content []byte) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreateFormFile(field, filename)
+	if err != nil {
+		t.Fatalf("CreateFormFile failed: %v", err)
+	}
+	_, _ = part.Write(content)
+	_ = w.Close()
+
+	req := httptest.NewRequest("POST", "/upload", &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return req
+}
+
+func TestContext_SaveUpload(t *testing.T) {
+	storage := NewMemoryStorage()
+
+	cfg := DefaultServerConfig()
+	cfg.EnableStaticAssets = false
+	cfg.EnableRequestLogger = false
+	cfg.EnableSecFetchSite = false
+	cfg.Config = &testConfig{}
+	cfg.Logger = testLogger()
+	cfg.DBManager = &testDBManager{}
+	cfg.ErrorHandler = DefaultErrorHandler(testLogger(), false)
+	cfg.UploadStorage = storage
+
+	srv, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	srv.Post("/upload", func(ctx *Context) error {
+		file, err := ctx.SaveUpload("avatar", UploadOptions{
+			MaxSize:      1024,
+			AllowedTypes: []string{"image/*"},
+			Dir:          "avatars",
+		})
+		if err != nil {
+			return err
+		}
+		return ctx.JSON(fiber.Map{"key": file.Key, "url": file.URL(), "type": file.ContentType, "name": file.Name})
+	})
+
+	png := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 64)...)
+
+	resp, err := srv.App().Test(uploadRequest(t, "avatar", "../My Avatar.png", png))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		t.Fatalf("expected 200, got %d: %s", resp.StatusCode, body)
+	}
+	var stored map[string]string
+	_ = json.NewDecoder(resp.Body).Decode(&stored)
+	if !strings.HasPrefix(stored["key"], "avatars/") || !strings.HasSuffix(stored["key"], "-My-Avatar.png") {
+		t.Errorf("unexpected key %q", stored["key"])
+	}
+	if stored["url"] != "/uploads/"+stored["key"] || stored["type"] != "image/png" || stored["name"] != "My-Avatar.png" {
+		t.Errorf("unexpected descriptor %v", stored)
+	}
+	rc, err := storage.Open(context.Background(), stored["key"])
+	if err != nil {
+		t.Fatalf("stored file missing: %v", err)
+	}
+	data, _ := io.ReadAll(rc)
+	if !bytes.Equal(data, png) {
+		t.Error("stored content differs from upload")
+	}
+
+	rejected := []struct {
+		name   string
+		req    *http.Request
+		status int
+	}{
+		{"wrong type", uploadRequest(t, "avatar", "evil.png", []byte("<html><script>alert(1)</script>")), fiber.StatusUnsupportedMediaType},
+		{"mismatched name", uploadRequest(t, "avatar", "evil.html", png), fiber.StatusUnsupportedMediaType},
+		{"too large", uploadRequest(t, "avatar", "big.png", append(png, make([]byte, 2048)...)), fiber.StatusRequestEntityTooLarge},
+		{"missing field", uploadRequest(t, "other", "a.png", png), fiber.StatusBadRequest},
+	}
+	for _, tc := range rejected {
+		resp, err := srv.App().Test(tc.req)
+		if err != nil {
+			t.Fatalf("%s: request failed: %v", tc.name, err)
+		}
+		if resp.StatusCode != tc.status {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.status, resp.StatusCode)
+		}
+	}
+	if len(storage.Keys()) != 1 {
+		t.Errorf("expected only the valid upload to be stored, got %v", storage.Keys())
+	}
+}
+
+func TestLocalStorage(t *testing.T) {
+	storage := NewLocalStorage(t.TempDir(), "/uploads/")
+	ctx := context.Background()
+
+	if err := storage.Put(ctx, "docs/a.txt", strings.NewReader("hello"), 5, "text/plain"); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	rc, err := storage.Open(ctx, "docs/a.txt")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(data) != "hello" {
+		t.Errorf("expected %q, got %q", "hello", data)
+	}
+	if url := storage.URL("docs/a.txt"); url != "/uploads/docs/a.txt" {
+		t.Errorf("unexpected URL %q", url)
+	}
+
+	if err := storage.Put(ctx, "../escape.txt", strings.NewReader("x"), 1, "text/plain"); err == nil {
+		t.Error("expected error for key outside the root")
+	}
+
+	if err := storage.Delete(ctx, "docs/a.txt"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := storage.Open(ctx, "docs/a.txt"); !errors.Is(err, ErrUploadNotFound) {
+		t.Errorf("expected ErrUploadNotFound, got %v", err)
+	}
+	if err := storage.Delete(ctx, "docs/a.txt"); err != nil {
+		t.Errorf("expected deleting a missing file to succeed, got %v", err)
+	}
+}
+
+func TestS3Storage(t *testing.T) {
+	objects := map[string]string{}
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/") || !strings.Contains(auth, "/auto/s3/aws4_request") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.Method {
+		case http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			objects[r.URL.Path] = string(body)
+		case http.MethodGet:
+			body, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte(body))
+		case http.MethodDelete:
+			delete(objects, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer api.Close()
+
+	storage, err := NewS3Storage(S3Config{
+		Endpoint:  api.URL,
+		Region:    "auto",
+		Bucket:    "media",
+		AccessKey: "AKID",
+		SecretKey: "secret",
+		PublicURL: "https://cdn.example.com/",
+	})
+	if err != nil {
+		t.Fatalf("NewS3Storage failed: %v", err)
+	}
+	ctx := context.Background()
+
+	if err := storage.Put(ctx, "avatars/a.png", strings.NewReader("png"), 3, "image/png"); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if _, ok := objects["/media/avatars/a.png"]; !ok {
+		t.Fatalf("expected path-style object, got %v", objects)
+	}
+	rc, err := storage.Open(ctx, "avatars/a.png")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(data) != "png" {
+		t.Errorf("expected %q, got %q", "png", data)
+	}
+	if url := storage.URL("avatars/a.png"); url != "https://cdn.example.com/avatars/a.png" {
+		t.Errorf("unexpected URL %q", url)
+	}
+	if err := storage.Delete(ctx, "avatars/a.png"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := storage.Open(ctx, "avatars/a.png"); !errors.Is(err, ErrUploadNotFound) {
+		t.Errorf("expected ErrUploadNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,262 @@
+package cartridge
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// FieldError describes a single failed validation rule.
+type FieldError struct {
+	Field   string `json:"field"`
+	Tag     string `json:"tag"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors is returned by Validate and BindJSON when one or more fields
+// fail validation. DefaultErrorHandler renders it as 422 Unprocessable Entity.
+type ValidationErrors []FieldError
+
+// Error joins the field messages.
+func (v ValidationErrors) Error() string {
+	msgs := make([]string, len(v))
+	for i, fe := range v {
+		msgs[i] = fe.Message
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Fields returns the messages keyed by field name (first failure per field).
+func (v ValidationErrors) Fields() map[string]string {
+	out := make(map[string]string, len(v))
+	for _, fe := range v {
+		if _, exists := out[fe.Field]; !exists {
+			out[fe.Field] = fe.Message
+		}
+	}
+	return out
+}
+
+// Validate checks struct fields against their `validate` tags.
+// Field names in errors use the json tag name when present.
+//
+// Supported rules: required, min=N, max=N, len=N, email, url, oneof=a b c,
+// alphanum, currency[=CODE]. For strings, min/max/len count characters; for
+// numbers and Money (in major units) they compare the value; for slices and
+// maps they compare the length.
+// Rules other than required are skipped for zero values, and nested structs
+// are validated recursively.
+//
+//	type SignupRequest struct {
+//	    Email    string `json:"email" validate:"required,email"`
+//	    Password string `json:"password" validate:"required,min=8"`
+//	    Plan     string `json:"plan" validate:"oneof=free pro"`
+//	}
+func Validate(v any) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var errs ValidationErrors
+	validateStruct(rv, "", &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validateStruct validates each exported field of rv, prefixing nested names.
+func validateStruct(rv reflect.Value, prefix string, errs *ValidationErrors) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		name := fieldName(sf)
+		if name == "-" {
+			continue
+		}
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+
+		fv := rv.Field(i)
+		if tag := sf.Tag.Get("validate"); tag != "" && tag != "-" {
+			validateField(fv, name, tag, errs)
+		}
+
+		// Recurse into nested structs (and non-nil struct pointers)
+		nested := fv
+		if nested.Kind() == reflect.Pointer && !nested.IsNil() {
+			nested = nested.Elem()
+		}
+		if nested.Kind() == reflect.Struct && nested.Type().PkgPath() != "time" {
+			if sf.Anonymous {
+				validateStruct(nested, prefix, errs)
+			} else {
+				validateStruct(nested, name, errs)
+			}
+		}
+	}
+}
+
+// fieldName returns the json name of a struct field, falling back to its Go name.
+func fieldName(sf reflect.StructField) string {
+	if tag := sf.Tag.Get("json"); tag != "" {
+		if name := strings.Split(tag, ",")[0]; name != "" {
+			return name
+		}
+	}
+	return sf.Name
+}
+
+// validateField applies each comma-separated rule to a single field.
+func validateField(fv reflect.Value, name, tag string, errs *ValidationErrors) {
+	// Optional nil pointers only fail "required"
+	if fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			if strings.Contains(","+tag+",", ",required,") {
+				*errs = append(*errs, FieldError{Field: name, Tag: "required", Message: name + " is required"})
+			}
+			return
+		}
+		fv = fv.Elem()
+	}
+
+	for _, rule := range strings.Split(tag, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		ruleName, param, _ := strings.Cut(rule, "=")
+
+		// Skip remaining rules for empty optional fields
+		if ruleName != "required" && fv.IsZero() {
+			continue
+		}
+
+		if msg, ok := checkRule(fv, name, ruleName, param); !ok {
+			*errs = append(*errs, FieldError{Field: name, Tag: ruleName, Param: param, Message: msg})
+			return
+		}
+	}
+}
+
+// checkRule reports whether fv satisfies the rule, with a message if it doesn't.
+func checkRule(fv reflect.Value, name, rule, param string) (string, bool) {
+	switch rule {
+	case "required":
+		return name + " is required", !fv.IsZero()
+
+	case "min", "max", "len":
+		limit, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return fmt.Sprintf("%s has invalid %s rule %q", name, rule, param), false
+		}
+		size, unit := fieldSize(fv)
+		var ok bool
+		switch rule {
+		case "min":
+			ok = size >= limit
+		case "max":
+			ok = size <= limit
+		default:
+			ok = size == limit
+		}
+		if ok {
+			return "", true
+		}
+		if unit != "" {
+			unit = " " + unit
+		}
+		switch rule {
+		case "min":
+			return fmt.Sprintf("%s must be at least %s%s", name, param, unit), false
+		case "max":
+			return fmt.Sprintf("%s must be at most %s%s", name, param, unit), false
+		default:
+			if unit != "" {
+				return fmt.Sprintf("%s must be exactly %s%s", name, param, unit), false
+			}
+			return fmt.Sprintf("%s must be %s", name, param), false
+		}
+
+	case "email":
+		s := fmt.Sprint(fv.Interface())
+		addr, err := mail.ParseAddress(s)
+		return name + " must be a valid email address", err == nil && addr.Address == s
+
+	case "url":
+		u, err := url.Parse(fmt.Sprint(fv.Interface()))
+		return name + " must be a valid URL", err == nil && u.Scheme != "" && u.Host != ""
+
+	case "oneof":
+		s := fmt.Sprint(fv.Interface())
+		options := strings.Fields(param)
+		for _, opt := range options {
+			if s == opt {
+				return "", true
+			}
+		}
+		return fmt.Sprintf("%s must be one of: %s", name, strings.Join(options, ", ")), false
+
+	case "currency":
+		code := fmt.Sprint(fv.Interface())
+		if m, ok := fv.Interface().(Money); ok {
+			code = m.Currency
+		}
+		if param != "" {
+			return fmt.Sprintf("%s must be in %s", name, param), code == strings.ToUpper(param)
+		}
+		return name + " must be a valid currency code", validCurrency(code)
+
+	case "alphanum":
+		s := fmt.Sprint(fv.Interface())
+		for _, r := range s {
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+				return name + " must contain only letters and numbers", false
+			}
+		}
+		return "", true
+	}
+
+	return fmt.Sprintf("%s has unknown validation rule %q", name, rule), false
+}
+
+// fieldSize returns the comparable size of a value and its unit for messages.
+// Numbers have no unit; strings count characters and collections count items.
+func fieldSize(fv reflect.Value) (float64, string) {
+	if m, ok := fv.Interface().(Money); ok {
+		amount, _ := strconv.ParseFloat(m.Decimal(), 64)
+		return amount, ""
+	}
+	switch fv.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(fv.String())), "characters"
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(fv.Len()), "items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(fv.Int()), ""
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(fv.Uint()), ""
+	case reflect.Float32, reflect.Float64:
+		return fv.Float(), ""
+	default:
+		return 0, ""
+	}
+}
@@ -0,0 +1,87 @@
+package cartridge
+
+import (
+	"errors"
+	"testing"
+)
+
+type testAddress struct {
+	City string `json:"city" validate:"required"`
+}
+
+type testSignup struct {
+	Email    string       `json:"email" validate:"required,email"`
+	Password string       `json:"password" validate:"required,min=8"`
+	Plan     string       `json:"plan" validate:"oneof=free pro"`
+	Age      int          `json:"age" validate:"min=18,max=130"`
+	Tags     []string     `json:"tags" validate:"max=2"`
+	Website  string       `json:"website" validate:"url"`
+	Address  testAddress  `json:"address"`
+	Nickname *string      `json:"nickname" validate:"alphanum"`
+	Billing  *testAddress `json:"billing"`
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("valid struct passes", func(t *testing.T) {
+		nick := "karlos1"
+		v := testSignup{
+			Email:    "user@example.com",
+			Password: "supersecret",
+			Plan:     "pro",
+			Age:      30,
+			Website:  "https://example.com",
+			Address:  testAddress{City: "Madrid"},
+			Nickname: &nick,
+		}
+		if err := Validate(&v); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+
+	t.Run("reports each failing field", func(t *testing.T) {
+		nick := "not valid!"
+		v := testSignup{
+			Email:    "nope",
+			Password: "short",
+			Plan:     "enterprise",
+			Age:      12,
+			Tags:     []string{"a", "b", "c"},
+			Website:  "example",
+			Nickname: &nick,
+			Billing:  &testAddress{},
+		}
+
+		err := Validate(v)
+		var errs ValidationErrors
+		if !errors.As(err, &errs) {
+			t.Fatalf("expected ValidationErrors, got %v", err)
+		}
+
+		fields := errs.Fields()
+		expected := map[string]string{
+			"email":        "email must be a valid email address",
+			"password":     "password must be at least 8 characters",
+			"plan":         "plan must be one of: free, pro",
+			"age":          "age must be at least 18",
+			"tags":         "tags must be at most 2 items",
+			"website":      "website must be a valid URL",
+			"address.city": "address.city is required",
+			"nickname":     "nickname must contain only letters and numbers",
+			"billing.city": "billing.city is required",
+		}
+		for field, msg := range expected {
+			if fields[field] != msg {
+				t.Errorf("%s: expected %q, got %q", field, msg, fields[field])
+			}
+		}
+		if len(fields) != len(expected) {
+			t.Errorf("expected %d failing fields, got %v", len(expected), fields)
+		}
+	})
+
+	t.Run("ignores non-struct values", func(t *testing.T) {
+		if err := Validate(42); err != nil {
+			t.Errorf("expected nil, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,112 @@
+package cartridge
+
+import (
+	"bytes"
+	"context"
+	"html/template"
+	"io"
+
+	"github.com/gofiber/fiber/v2"
+	fibertemplate "github.com/gofiber/template"
+
+	"github.com/karloscodes/cartridge/config"
+	"github.com/karloscodes/cartridge/sanitize"
+)
+
+// ViewEngine renders the views behind ctx.Render and the OG image cards.
+// It is fiber.Views, so Fiber's template engines (html, jet, pug, django,
+// handlebars, ...) plug in as they are:
+//
+//	engine := jet.New("./web/views", ".jet")
+//	app, err := cartridge.NewSSRApp("myapp", cartridge.WithViewEngine(engine))
+//
+// Views rendered with a fiber.Map (or nil) binding get .Helpers, as with
+// the default html/template engine (see TemplateHelpers).
+type ViewEngine = fiber.Views
+
+// viewFuncs is implemented by Fiber's template engines.
+type viewFuncs interface {
+	AddFuncMap(m map[string]interface{}) fibertemplate.IEngineCore
+}
+
+// viewReloads is implemented by Fiber's template engines that can re-read
+// their templates on every render.
+type viewReloads interface {
+	Reload(enabled bool) fibertemplate.IEngineCore
+}
+
+// configureViewEngine prepares an engine given to WithViewEngine: it gets
+// the same template functions as the default engine when it accepts them,
+// and reloads its templates in development when it can.
+func configureViewEngine(cfg *config.Config, engine ViewEngine, funcs template.FuncMap) ViewEngine {
+	if e, ok := engine.(viewFuncs); ok {
+		e.AddFuncMap(viewTemplateFuncs(funcs))
+	}
+	if e, ok := engine.(viewReloads); ok && cfg.IsDevelopment() {
+		e.Reload(true)
+	}
+	return engine
+}
+
+// viewTemplateFuncs collects the sanitize/markdown, helper, localtime/timeago
+// and money functions, then funcs, which may override them.
+func viewTemplateFuncs(funcs template.FuncMap) map[string]any {
+	all := make(map[string]any)
+	for _, set := range []map[string]any{
+		sanitize.TemplateFuncs(),
+		helperTemplateFuncs(),
+		timeTemplateFuncs(),
+		moneyTemplateFuncs(),
+		funcs,
+	} {
+		for name, fn := range set {
+			all[name] = fn
+		}
+	}
+	return all
+}
+
+// Component is a view that renders itself. templ components satisfy it:
+//
+//	func (h *PostsHandler) Show(ctx *cartridge.Context) error {
+//	    return ctx.RenderComponent(views.Post(post))
+//	}
+type Component interface {
+	Render(ctx context.Context, w io.Writer) error
+}
+
+// RenderComponent renders c as the HTML response. The component is rendered
+// with the request's context, from which HelpersFromContext returns the
+// request's TemplateHelpers. Nothing is sent if rendering fails.
+func (ctx *Context) RenderComponent(c Component) error {
+	var buf bytes.Buffer
+	if err := c.Render(WithTemplateHelpers(ctx.UserContext(), ctx.helpers), &buf); err != nil {
+		return err
+	}
+	ctx.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return ctx.Send(buf.Bytes())
+}
+
+type templateHelpersKey struct{}
+
+// WithTemplateHelpers returns a copy of parent carrying h, for components
+// rendered outside RenderComponent.
+func WithTemplateHelpers(parent context.Context, h *TemplateHelpers) context.Context {
+	if h == nil {
+		return parent
+	}
+	return context.WithValue(parent, templateHelpersKey{}, h)
+}
+
+// HelpersFromContext returns the TemplateHelpers of the request a component
+// is rendered for, or nil outside a request:
+//
+//	templ Nav() {
+//	    if h := cartridge.HelpersFromContext(ctx); h != nil {
+//	        <meta name="csrf-token" content={ h.CSRFToken() }/>
+//	    }
+//	}
+func HelpersFromContext(ctx context.Context) *TemplateHelpers {
+	h, _ := ctx.Value(templateHelpersKey{}).(*TemplateHelpers)
+	return h
+}
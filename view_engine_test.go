@@ -0,0 +1,96 @@
+package cartridge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	fibertemplate "github.com/gofiber/template"
+
+	"github.com/karloscodes/cartridge/config"
+)
+
+// stubEngine is a non-html/template engine built on Fiber's engine core.
+type stubEngine struct {
+	fibertemplate.Engine
+}
+
+func (e *stubEngine) Load() error { return nil }
+
+func (e *stubEngine) Render(out io.Writer, name string, binding any, _ ...string) error {
+	h := boundHelpers(binding)
+	shout := e.Funcmap["shout"].(func(string) string)
+	_, err := fmt.Fprintf(out, "%s %s csrf=%s", name, shout("hi"), h.CSRFToken())
+	return err
+}
+
+type componentFunc func(ctx context.Context, w io.Writer) error
+
+func (f componentFunc) Render(ctx context.Context, w io.Writer) error { return f(ctx, w) }
+
+func TestConfigureViewEngine(t *testing.T) {
+	engine := &stubEngine{}
+	engine.Funcmap = map[string]any{}
+	cfg := &config.Config{Environment: config.Development}
+	configureViewEngine(cfg, engine, map[string]any{"shout": func(s string) string { return s + "!" }})
+
+	if engine.Funcmap["timeago"] == nil || engine.Funcmap["shout"] == nil {
+		t.Errorf("expected cartridge and app template functions, got %v", engine.Funcmap)
+	}
+	if !engine.ShouldReload {
+		t.Error("expected the engine to reload in development")
+	}
+
+	srv := newTemplateTestServer(t, engine)
+	srv.Get("/", func(ctx *Context) error {
+		ctx.Locals(csrfLocalsKey, "token123")
+		return ctx.Render("home", fiber.Map{})
+	})
+	resp, err := srv.App().Test(httptest.NewRequest("GET", "/", nil))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "home hi! csrf=token123" {
+		t.Errorf("unexpected render %q", body)
+	}
+}
+
+func TestContext_RenderComponent(t *testing.T) {
+	srv := newTemplateTestServer(t, nil)
+	srv.Get("/", func(ctx *Context) error {
+		ctx.Locals(csrfLocalsKey, "token123")
+		return ctx.RenderComponent(componentFunc(func(ctx context.Context, w io.Writer) error {
+			_, err := fmt.Fprintf(w, "<p>csrf=%s</p>", HelpersFromContext(ctx).CSRFToken())
+			return err
+		}))
+	})
+	srv.Get("/broken", func(ctx *Context) error {
+		return ctx.RenderComponent(componentFunc(func(ctx context.Context, w io.Writer) error {
+			io.WriteString(w, "<p>half")
+			return errors.New("boom")
+		}))
+	})
+
+	resp, err := srv.App().Test(httptest.NewRequest("GET", "/", nil))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "<p>csrf=token123</p>" || resp.Header.Get("Content-Type") != fiber.MIMETextHTMLCharsetUTF8 {
+		t.Errorf("unexpected response %q %q", resp.Header.Get("Content-Type"), body)
+	}
+
+	resp, err = srv.App().Test(httptest.NewRequest("GET", "/broken", nil))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	if resp.StatusCode != 500 || string(body) == "<p>half" {
+		t.Errorf("expected an error response without the partial render, got %d %q", resp.StatusCode, body)
+	}
+}
@@ -0,0 +1,209 @@
+package cartridge
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	cartridgemiddleware "github.com/karloscodes/cartridge/middleware"
+)
+
+// WALCheckpointConfig configures the background WAL checkpointer.
+type WALCheckpointConfig struct {
+	// Interval between PASSIVE checkpoints, which copy the WAL into the
+	// database without blocking readers or writers. Default: 1 minute
+	Interval time.Duration
+
+	// TruncateInterval between TRUNCATE checkpoints, which also reset the
+	// WAL file to zero bytes. They only run when no write is in progress.
+	// Default: 1 hour
+	TruncateInterval time.Duration
+
+	// MaxWriteLoad skips a checkpoint while this fraction of the write
+	// concurrency limit is in use. Default: 0.5
+	MaxWriteLoad float64
+
+	// AlertSize logs a warning when the WAL grows beyond this many bytes.
+	// Checkpoints then run regardless of write load, and the next idle run
+	// truncates. Default: 64 MiB
+	AlertSize int64
+}
+
+// DefaultWALCheckpointConfig returns the default configuration.
+func DefaultWALCheckpointConfig() WALCheckpointConfig {
+	return WALCheckpointConfig{
+		Interval:         time.Minute,
+		TruncateInterval: time.Hour,
+		MaxWriteLoad:     0.5,
+		AlertSize:        64 << 20,
+	}
+}
+
+// WALDatabase is a database with a write-ahead log, such as sqlite.Manager.
+type WALDatabase interface {
+	CheckpointWAL(mode string) error
+	WALSize() (int64, error)
+}
+
+// WALMetrics describes the WAL file and the checkpointer's runs since the
+// process started.
+type WALMetrics struct {
+	SizeBytes      int64      `json:"size_bytes"`
+	AlertSize      int64      `json:"alert_size_bytes"`
+	Passive        int64      `json:"passive_checkpoints"`
+	Truncate       int64      `json:"truncate_checkpoints"`
+	Incomplete     int64      `json:"incomplete_truncates"` // readers kept the WAL from being reset
+	Skipped        int64      `json:"skipped"`
+	Failures       int64      `json:"failures"`
+	LastCheckpoint *time.Time `json:"last_checkpoint,omitempty"`
+}
+
+// WALCheckpointer checkpoints a SQLite WAL in the background so it doesn't
+// grow between restarts. It implements BackgroundWorker; enable it with
+// WithWALCheckpoints.
+type WALCheckpointer struct {
+	cfg     WALCheckpointConfig
+	db      WALDatabase
+	limiter *cartridgemiddleware.ConcurrencyLimiter
+	logger  Logger
+	ticker  *Ticker
+
+	mu           sync.Mutex
+	metrics      WALMetrics
+	lastTruncate time.Time
+}
+
+// NewWALCheckpointer creates a checkpointer for db. limiter may be nil, in
+// which case checkpoints never skip for write load.
+func NewWALCheckpointer(db WALDatabase, limiter *cartridgemiddleware.ConcurrencyLimiter, logger Logger, config ...WALCheckpointConfig) *WALCheckpointer {
+	cfg := DefaultWALCheckpointConfig()
+	if len(config) > 0 {
+		cfg = config[0]
+		defaults := DefaultWALCheckpointConfig()
+		if cfg.Interval <= 0 {
+			cfg.Interval = defaults.Interval
+		}
+		if cfg.TruncateInterval <= 0 {
+			cfg.TruncateInterval = defaults.TruncateInterval
+		}
+		if cfg.MaxWriteLoad <= 0 {
+			cfg.MaxWriteLoad = defaults.MaxWriteLoad
+		}
+		if cfg.AlertSize <= 0 {
+			cfg.AlertSize = defaults.AlertSize
+		}
+	}
+
+	w := &WALCheckpointer{
+		cfg:          cfg,
+		db:           db,
+		limiter:      limiter,
+		logger:       logger,
+		metrics:      WALMetrics{AlertSize: cfg.AlertSize},
+		lastTruncate: time.Now(),
+	}
+	w.ticker = NewTicker(logger, nil, cfg.Interval, w.run, TickerName("wal checkpoint"))
+	return w
+}
+
+// Start begins checkpointing every Interval.
+func (w *WALCheckpointer) Start() error { return w.ticker.Start() }
+
+// Stop stops checkpointing and waits for a running checkpoint.
+func (w *WALCheckpointer) Stop() { w.ticker.Stop() }
+
+// Metrics returns the WAL size and checkpoint counts.
+func (w *WALCheckpointer) Metrics() WALMetrics {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.metrics
+}
+
+// run checkpoints once: TRUNCATE when it is due or the WAL is over
+// AlertSize and no write is in progress, PASSIVE otherwise, or nothing
+// while writes are busy.
+func (w *WALCheckpointer) run(_ *JobContext) error {
+	size, err := w.db.WALSize()
+	if err != nil {
+		return err
+	}
+	w.setSize(size)
+	if size == 0 {
+		return nil
+	}
+
+	oversized := size > w.cfg.AlertSize
+	if oversized {
+		w.logger.Warn("WAL exceeds alert size", "size", size, "alert_size", w.cfg.AlertSize)
+	}
+
+	var active int64
+	var load float64
+	if w.limiter != nil {
+		active, load = w.limiter.ActiveWrites(), w.limiter.WriteLoad()
+	}
+	w.mu.Lock()
+	truncateDue := oversized || time.Since(w.lastTruncate) >= w.cfg.TruncateInterval
+	w.mu.Unlock()
+
+	mode := "PASSIVE"
+	switch {
+	case truncateDue && active == 0:
+		mode = "TRUNCATE"
+	case load >= w.cfg.MaxWriteLoad && !oversized:
+		w.mu.Lock()
+		w.metrics.Skipped++
+		w.mu.Unlock()
+		w.logger.Debug("WAL checkpoint skipped under write load", "active_writes", active, "size", size)
+		return nil
+	}
+
+	start := time.Now()
+	if err := w.db.CheckpointWAL(mode); err != nil {
+		w.mu.Lock()
+		w.metrics.Failures++
+		w.mu.Unlock()
+		return fmt.Errorf("cartridge: WAL checkpoint %s: %w", mode, err)
+	}
+	after, err := w.db.WALSize()
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.metrics.SizeBytes = after
+	w.metrics.LastCheckpoint = &start
+	if mode == "PASSIVE" {
+		w.metrics.Passive++
+		return nil
+	}
+	w.metrics.Truncate++
+	if after > 0 {
+		// A reader still needed the WAL; try again on the next run
+		w.metrics.Incomplete++
+		return nil
+	}
+	w.lastTruncate = start
+	w.logger.Debug("WAL truncated", "size_before", size, "duration", time.Since(start))
+	return nil
+}
+
+func (w *WALCheckpointer) setSize(size int64) {
+	w.mu.Lock()
+	w.metrics.SizeBytes = size
+	w.mu.Unlock()
+}
+
+// WriteWALMetrics writes WAL metrics in the Prometheus text format.
+func WriteWALMetrics(w io.Writer, m WALMetrics) {
+	fmt.Fprintf(w, "# TYPE cartridge_sqlite_wal_size_bytes gauge\ncartridge_sqlite_wal_size_bytes %d\n", m.SizeBytes)
+	fmt.Fprintf(w, "# TYPE cartridge_sqlite_wal_alert_size_bytes gauge\ncartridge_sqlite_wal_alert_size_bytes %d\n", m.AlertSize)
+	fmt.Fprintf(w, "# TYPE cartridge_sqlite_wal_checkpoints_total counter\n")
+	fmt.Fprintf(w, "cartridge_sqlite_wal_checkpoints_total{mode=\"passive\"} %d\n", m.Passive)
+	fmt.Fprintf(w, "cartridge_sqlite_wal_checkpoints_total{mode=\"truncate\"} %d\n", m.Truncate)
+	fmt.Fprintf(w, "# TYPE cartridge_sqlite_wal_truncates_incomplete_total counter\ncartridge_sqlite_wal_truncates_incomplete_total %d\n", m.Incomplete)
+	fmt.Fprintf(w, "# TYPE cartridge_sqlite_wal_checkpoints_skipped_total counter\ncartridge_sqlite_wal_checkpoints_skipped_total %d\n", m.Skipped)
+	fmt.Fprintf(w, "# TYPE cartridge_sqlite_wal_checkpoint_failures_total counter\ncartridge_sqlite_wal_checkpoint_failures_total %d\n", m.Failures)
+}
@@ -0,0 +1,72 @@
+package cartridge
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	cartridgemiddleware "github.com/karloscodes/cartridge/middleware"
+)
+
+type fakeWAL struct {
+	size  int64
+	modes []string
+}
+
+func (f *fakeWAL) CheckpointWAL(mode string) error {
+	f.modes = append(f.modes, mode)
+	if mode == "TRUNCATE" {
+		f.size = 0
+	}
+	return nil
+}
+
+func (f *fakeWAL) WALSize() (int64, error) { return f.size, nil }
+
+func TestWALCheckpointer(t *testing.T) {
+	db := &fakeWAL{size: 4096}
+	limiter := cartridgemiddleware.NewConcurrencyLimiter(10, 2, time.Second, testLogger())
+	w := NewWALCheckpointer(db, limiter, testLogger(), WALCheckpointConfig{AlertSize: 1 << 20})
+
+	if err := w.run(nil); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if strings.Join(db.modes, ",") != "PASSIVE" {
+		t.Fatalf("expected a passive checkpoint, got %v", db.modes)
+	}
+
+	// Skipped while the write limit is busy
+	ctx := context.Background()
+	_ = limiter.AcquireWrite(ctx)
+	if err := w.run(nil); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if len(db.modes) != 1 || w.Metrics().Skipped != 1 {
+		t.Fatalf("expected the checkpoint to be skipped under load, got %v %+v", db.modes, w.Metrics())
+	}
+
+	// Oversized WALs are checkpointed anyway, and truncated once writes finish
+	db.size = 2 << 20
+	if err := w.run(nil); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	limiter.ReleaseWrite()
+	if err := w.run(nil); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if strings.Join(db.modes, ",") != "PASSIVE,PASSIVE,TRUNCATE" {
+		t.Fatalf("unexpected checkpoints %v", db.modes)
+	}
+
+	m := w.Metrics()
+	if m.Passive != 2 || m.Truncate != 1 || m.SizeBytes != 0 || m.LastCheckpoint == nil {
+		t.Errorf("unexpected metrics %+v", m)
+	}
+
+	var b strings.Builder
+	WriteWALMetrics(&b, m)
+	if want := `cartridge_sqlite_wal_checkpoints_total{mode="truncate"} 1`; !strings.Contains(b.String(), want) {
+		t.Errorf("expected %q in:\n%s", want, b.String())
+	}
+}